@@ -33,12 +33,12 @@ var CmdKeys = cli.Command{
 		cli.StringFlag{
 			Name:  "type, t",
 			Value: "",
-			Usage: "Type of the SSH key provided to the SSH Server (requires content to be provided too)",
+			Usage: "Type of the SSH key or certificate provided to the SSH Server (requires content to be provided too)",
 		},
 		cli.StringFlag{
 			Name:  "content, k",
 			Value: "",
-			Usage: "Base64 encoded content of the SSH key provided to the SSH Server (requires type to be provided too)",
+			Usage: "Base64 encoded content of the SSH key or certificate provided to the SSH Server (requires type to be provided too)",
 		},
 	},
 }
@@ -25,6 +25,8 @@ var (
 			subcmdShutdown,
 			subcmdRestart,
 			subcmdFlushQueues,
+			subcmdReindexCode,
+			subcmdReindexCommits,
 		},
 	}
 	subcmdShutdown = cli.Command{
@@ -53,6 +55,28 @@ var (
 			},
 		},
 	}
+	subcmdReindexCode = cli.Command{
+		Name:   "reindex-code",
+		Usage:  "Rebuild the code search index in the running process",
+		Action: runReindexCode,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "repo",
+				Usage: "Only reindex this repository, given as owner/name",
+			},
+		},
+	}
+	subcmdReindexCommits = cli.Command{
+		Name:   "reindex-commits",
+		Usage:  "Rebuild the commit search index in the running process",
+		Action: runReindexCommits,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "repo",
+				Usage: "Only reindex this repository, given as owner/name",
+			},
+		},
+	}
 )
 
 func runShutdown(c *cli.Context) error {
@@ -90,3 +114,25 @@ func runFlushQueues(c *cli.Context) error {
 	fmt.Fprintln(os.Stdout, msg)
 	return nil
 }
+
+func runReindexCode(c *cli.Context) error {
+	setup("manager", false)
+	statusCode, msg := private.ReindexCode(c.String("repo"))
+	if statusCode != http.StatusOK {
+		fail(msg, "")
+	}
+
+	fmt.Fprintln(os.Stdout, msg)
+	return nil
+}
+
+func runReindexCommits(c *cli.Context) error {
+	setup("manager", false)
+	statusCode, msg := private.ReindexCommits(c.String("repo"))
+	if statusCode != http.StatusOK {
+		fail(msg, "")
+	}
+
+	fmt.Fprintln(os.Stdout, msg)
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknwon/i18n"
+)
+
+func TestAPIAdminAuditLog(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	testLoginFailed(t, "user2", "wrongPassword", i18n.Tr("en", "form.username_password_incorrect"))
+
+	session := loginUserWithPassword(t, "user2", userPassword)
+	token := getTokenForLoggedInUser(t, session)
+
+	private := true
+	req := NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token, &api.EditRepoOption{Private: &private})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	// only site admins can read the audit log
+	session.MakeRequest(t, NewRequest(t, "GET", "/api/v1/admin/audit?token="+token), http.StatusForbidden)
+
+	adminSession := loginUser(t, "user1")
+	adminToken := getTokenForLoggedInUser(t, adminSession)
+
+	var events []*api.AuditEvent
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?action=repo.visibility&token="+adminToken)
+	resp := adminSession.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	if assert.Len(t, events, 1) {
+		assert.EqualValues(t, "user2", events[0].Actor)
+		assert.EqualValues(t, "repo", events[0].TargetType)
+		assert.EqualValues(t, 1, events[0].TargetID)
+		assert.EqualValues(t, "user2/repo1", events[0].Target)
+		assert.Equal(t, map[string]interface{}{"old": false, "new": true}, events[0].Diff["private"])
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?action=user.login_failed&token="+adminToken)
+	resp = adminSession.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	if assert.Len(t, events, 1) {
+		assert.Empty(t, events[0].Actor)
+		assert.EqualValues(t, "user2", events[0].Target)
+		assert.EqualValues(t, "invalid_credentials", events[0].Diff["reason"])
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?actor=user2&token="+adminToken)
+	resp = adminSession.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	actions := make([]string, 0, len(events))
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []string{
+		string(models.AuditRepoVisibility),
+		string(models.AuditUserAccessTokenCreate),
+		string(models.AuditUserLogin),
+	}, actions)
+	assert.EqualValues(t, "3", resp.Header().Get("X-Total-Count"))
+
+	req = NewRequest(t, "GET", "/api/v1/admin/audit?since=yesterday&token="+adminToken)
+	adminSession.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// the admin page lists the same events
+	resp = adminSession.MakeRequest(t, NewRequest(t, "GET", "/admin/audit?actor=user2"), http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 3, htmlDoc.doc.Find("table tbody tr").Length())
+	assert.Contains(t, htmlDoc.doc.Find("table tbody").Text(), "user2/repo1")
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func containerDigest(content []byte) string {
+	hash := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+func newContainerRequest(t *testing.T, method, urlStr, user, token string, body []byte) *http.Request {
+	req := NewRequestWithBody(t, method, urlStr, bytes.NewReader(body))
+	if len(user) > 0 {
+		req.SetBasicAuth(user, token)
+	}
+	return req
+}
+
+func TestAPIContainerRegistry(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer func(enabled bool, path string) {
+			setting.ContainerRegistry.Enabled = enabled
+			setting.ContainerRegistry.ContentPath = path
+		}(setting.ContainerRegistry.Enabled, setting.ContainerRegistry.ContentPath)
+		dir, err := ioutil.TempDir("", "container-registry")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir)
+		setting.ContainerRegistry.Enabled = true
+		setting.ContainerRegistry.ContentPath = dir
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		adminToken := getTokenForLoggedInUser(t, loginUser(t, "user1"))
+		otherToken := getTokenForLoggedInUser(t, loginUser(t, "user4"))
+
+		// The version check tells clients to authenticate
+		resp := MakeRequest(t, NewRequest(t, "GET", "/v2/"), http.StatusUnauthorized)
+		assert.Contains(t, resp.Header().Get("WWW-Authenticate"), "Basic")
+		assert.EqualValues(t, "registry/2.0", resp.Header().Get("Docker-Distribution-API-Version"))
+		MakeRequest(t, AddBasicAuthHeader(NewRequest(t, "GET", "/v2/"), "user2"), http.StatusOK)
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/", "user2", token, nil), http.StatusOK)
+		// Users with two-factor authentication must use access tokens
+		MakeRequest(t, AddBasicAuthHeader(NewRequest(t, "GET", "/v2/"), "user24"), http.StatusUnauthorized)
+
+		config := []byte(`{"architecture":"amd64","os":"linux"}`)
+		layer := []byte("layer content of the image")
+		configDigest, layerDigest := containerDigest(config), containerDigest(layer)
+
+		// Monolithic upload
+		req := newContainerRequest(t, "POST", "/v2/user2/app/blobs/uploads/?digest="+configDigest, "user2", token, config)
+		resp = MakeRequest(t, req, http.StatusCreated)
+		assert.EqualValues(t, "/v2/user2/app/blobs/"+configDigest, resp.Header().Get("Location"))
+		MakeRequest(t, newContainerRequest(t, "POST", "/v2/user2/app/blobs/uploads/?digest="+layerDigest, "user2", token, config), http.StatusBadRequest)
+
+		// Chunked upload
+		resp = MakeRequest(t, newContainerRequest(t, "POST", "/v2/user2/app/blobs/uploads/", "user2", token, nil), http.StatusAccepted)
+		location := resp.Header().Get("Location")
+		assert.NotEmpty(t, resp.Header().Get("Docker-Upload-UUID"))
+		req = newContainerRequest(t, "PATCH", location, "user2", token, layer[:10])
+		req.Header.Set("Content-Range", "0-9")
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		assert.EqualValues(t, "0-9", resp.Header().Get("Range"))
+		req = newContainerRequest(t, "PATCH", location, "user2", token, layer[10:])
+		req.Header.Set("Content-Range", "5-20")
+		MakeRequest(t, req, http.StatusRequestedRangeNotSatisfiable)
+		resp = MakeRequest(t, newContainerRequest(t, "GET", location, "user2", token, nil), http.StatusNoContent)
+		assert.EqualValues(t, "0-9", resp.Header().Get("Range"))
+		resp = MakeRequest(t, newContainerRequest(t, "PUT", location+"?digest="+layerDigest, "user2", token, layer[10:]), http.StatusCreated)
+		assert.EqualValues(t, layerDigest, resp.Header().Get("Docker-Content-Digest"))
+		MakeRequest(t, newContainerRequest(t, "GET", location, "user2", token, nil), http.StatusNotFound)
+
+		resp = MakeRequest(t, newContainerRequest(t, "HEAD", "/v2/user2/app/blobs/"+layerDigest, "user2", token, nil), http.StatusOK)
+		assert.EqualValues(t, fmt.Sprint(len(layer)), resp.Header().Get("Content-Length"))
+		resp = MakeRequest(t, NewRequest(t, "GET", "/v2/user2/app/blobs/"+layerDigest), http.StatusOK)
+		assert.EqualValues(t, layer, resp.Body.Bytes())
+
+		// Manifests can only reference blobs of the image
+		manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"%s","size":%d},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar","digest":"%s","size":%d}]}`,
+			container.MediaTypeImageManifest, configDigest, len(config), layerDigest, len(layer)))
+		manifestDigest := containerDigest(manifest)
+		unknown := bytes.Replace(manifest, []byte(layerDigest), []byte(containerDigest([]byte("unknown"))), 1)
+		req = newContainerRequest(t, "PUT", "/v2/user2/app/manifests/latest", "user2", token, unknown)
+		req.Header.Set("Content-Type", container.MediaTypeImageManifest)
+		resp = MakeRequest(t, req, http.StatusBadRequest)
+		assert.Contains(t, resp.Body.String(), container.ErrorCodeManifestBlobUnknown)
+
+		req = newContainerRequest(t, "PUT", "/v2/user2/app/manifests/latest", "user2", token, manifest)
+		req.Header.Set("Content-Type", container.MediaTypeImageManifest)
+		resp = MakeRequest(t, req, http.StatusCreated)
+		assert.EqualValues(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+
+		for _, reference := range []string{"latest", manifestDigest} {
+			resp = MakeRequest(t, newContainerRequest(t, "GET", "/v2/user2/app/manifests/"+reference, "user2", token, nil), http.StatusOK)
+			assert.EqualValues(t, manifest, resp.Body.Bytes())
+			assert.EqualValues(t, container.MediaTypeImageManifest, resp.Header().Get("Content-Type"))
+			assert.EqualValues(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		}
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/user2/app/manifests/missing", "user2", token, nil), http.StatusNotFound)
+
+		index := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":"%s","manifests":[{"mediaType":"%s","digest":"%s","size":%d}]}`,
+			container.MediaTypeImageIndex, container.MediaTypeImageManifest, manifestDigest, len(manifest)))
+		req = newContainerRequest(t, "PUT", "/v2/user2/app/manifests/multi", "user2", token, index)
+		req.Header.Set("Content-Type", container.MediaTypeImageIndex)
+		MakeRequest(t, req, http.StatusCreated)
+
+		var tags struct {
+			Name string
+			Tags []string
+		}
+		DecodeJSON(t, MakeRequest(t, NewRequest(t, "GET", "/v2/user2/app/tags/list"), http.StatusOK), &tags)
+		assert.EqualValues(t, "user2/app", tags.Name)
+		assert.EqualValues(t, []string{"latest", "multi"}, tags.Tags)
+		resp = MakeRequest(t, NewRequest(t, "GET", "/v2/user2/app/tags/list?n=1"), http.StatusOK)
+		DecodeJSON(t, resp, &tags)
+		assert.EqualValues(t, []string{"latest"}, tags.Tags)
+		assert.Contains(t, resp.Header().Get("Link"), "last=latest")
+		DecodeJSON(t, MakeRequest(t, NewRequest(t, "GET", "/v2/user2/app/tags/list?n=1&last=latest"), http.StatusOK), &tags)
+		assert.EqualValues(t, []string{"multi"}, tags.Tags)
+
+		// Permissions follow the owner
+		MakeRequest(t, newContainerRequest(t, "POST", "/v2/user2/app/blobs/uploads/", "", "", nil), http.StatusUnauthorized)
+		resp = MakeRequest(t, newContainerRequest(t, "POST", "/v2/user2/app/blobs/uploads/", "user4", otherToken, nil), http.StatusForbidden)
+		assert.Contains(t, resp.Body.String(), container.ErrorCodeDenied)
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/user2/app/manifests/latest", "user4", otherToken, nil), http.StatusOK)
+
+		req = newContainerRequest(t, "POST", "/v2/privated_org/secret/blobs/uploads/?digest="+configDigest, "user1", adminToken, config)
+		MakeRequest(t, req, http.StatusCreated)
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/privated_org/secret/blobs/"+configDigest, "user2", token, nil), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", "/v2/privated_org/secret/blobs/"+configDigest), http.StatusUnauthorized)
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/nobody/secret/tags/list", "user2", token, nil), http.StatusNotFound)
+
+		var catalog struct {
+			Repositories []string
+		}
+		DecodeJSON(t, MakeRequest(t, NewRequest(t, "GET", "/v2/_catalog"), http.StatusOK), &catalog)
+		assert.EqualValues(t, []string{"user2/app"}, catalog.Repositories)
+		DecodeJSON(t, MakeRequest(t, newContainerRequest(t, "GET", "/v2/_catalog", "user1", adminToken, nil), http.StatusOK), &catalog)
+		assert.EqualValues(t, []string{"privated_org/secret", "user2/app"}, catalog.Repositories)
+
+		// Blobs of readable images can be mounted
+		req = newContainerRequest(t, "POST", "/v2/user2/copy/blobs/uploads/?mount="+layerDigest+"&from=user2/app", "user2", token, nil)
+		MakeRequest(t, req, http.StatusCreated)
+		req = newContainerRequest(t, "POST", "/v2/user2/copy/blobs/uploads/?mount="+configDigest+"&from=privated_org/secret", "user2", token, nil)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		// Images are listed on the profile of their owner
+		session := loginUser(t, "user2")
+		resp = session.MakeRequest(t, NewRequest(t, "GET", "/user2?tab=container_images"), http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "user2/app")
+
+		// Deletion
+		MakeRequest(t, newContainerRequest(t, "DELETE", "/v2/user2/app/blobs/"+layerDigest, "user2", token, nil), http.StatusConflict)
+		MakeRequest(t, newContainerRequest(t, "DELETE", "/v2/user2/app/manifests/multi", "user2", token, nil), http.StatusAccepted)
+		MakeRequest(t, newContainerRequest(t, "DELETE", "/v2/user2/app/manifests/multi", "user2", token, nil), http.StatusNotFound)
+		MakeRequest(t, newContainerRequest(t, "DELETE", "/v2/user2/app/manifests/"+manifestDigest, "user2", token, nil), http.StatusAccepted)
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/user2/app/manifests/latest", "user2", token, nil), http.StatusNotFound)
+		MakeRequest(t, newContainerRequest(t, "DELETE", "/v2/user2/copy/blobs/"+layerDigest, "user2", token, nil), http.StatusAccepted)
+
+		// The garbage collection removes content no manifest references anymore
+		assert.NoError(t, container.GarbageCollect(context.Background(), -time.Minute))
+		MakeRequest(t, newContainerRequest(t, "GET", "/v2/user2/app/blobs/"+layerDigest, "user2", token, nil), http.StatusNotFound)
+		models.AssertNotExistsBean(t, &models.ContainerBlob{Digest: layerDigest})
+		for _, digest := range []string{layerDigest, configDigest} {
+			_, err = container.NewContentStore().Stat(digest)
+			assert.True(t, os.IsNotExist(err))
+		}
+		models.AssertNotExistsBean(t, &models.ContainerImage{Name: "secret"})
+	})
+}
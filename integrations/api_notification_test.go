@@ -112,3 +112,75 @@ func TestAPINotification(t *testing.T) {
 	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/notifications/new?token=%s", token))
 	resp = session.MakeRequest(t, req, http.StatusNoContent)
 }
+
+func TestAPINotificationFilters(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/notifications?all=true&reason=mention&reason=assign&token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiNL []api.NotificationThread
+	DecodeJSON(t, resp, &apiNL)
+	assert.Len(t, apiNL, 2)
+	assert.EqualValues(t, 5, apiNL[0].ID)
+	assert.EqualValues(t, "assign", apiNL[0].Reason)
+	assert.EqualValues(t, 4, apiNL[1].ID)
+	assert.EqualValues(t, "mention", apiNL[1].Reason)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/notifications?all=true&reason=subscribed&subject_type=issue&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiNL)
+	assert.Len(t, apiNL, 2)
+	assert.EqualValues(t, 3, apiNL[0].ID)
+	assert.EqualValues(t, 2, apiNL[1].ID)
+
+	req = NewRequestf(t, "GET", "/api/v1/notifications?all=true&subject_type=pull&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiNL)
+	assert.Len(t, apiNL, 0)
+
+	req = NewRequestf(t, "GET", "/api/v1/notifications?reason=unknown&token=%s", token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestf(t, "GET", "/api/v1/notifications?subject_type=unknown&token=%s", token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPINotificationEditThreads(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	// notification 1 belongs to user1 and is left untouched
+	req := NewRequestWithJSON(t, "PATCH", "/api/v1/notifications/threads?token="+token, &api.EditNotificationThreadsOption{
+		IDs:    []int64{1, 4, 5},
+		Action: "pin",
+	})
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 1, Status: models.NotificationStatusUnread})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusPinned})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 5, Status: models.NotificationStatusPinned})
+
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/notifications/threads?token="+token, &api.EditNotificationThreadsOption{
+		IDs:    []int64{4, 5},
+		Action: "unpin",
+	})
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusRead})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 5, Status: models.NotificationStatusRead})
+
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/notifications/threads?token="+token, &api.EditNotificationThreadsOption{
+		IDs:    []int64{5},
+		Action: "unsubscribe",
+	})
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.IssueWatch{UserID: 2, IssueID: 4}, models.Cond("is_watching = ?", false))
+
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/notifications/threads?token="+token, &api.EditNotificationThreadsOption{
+		IDs:    []int64{4},
+		Action: "delete",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
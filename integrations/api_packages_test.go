@@ -0,0 +1,381 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPackageRequest(t *testing.T, method, urlStr, user, token string, body []byte) *http.Request {
+	req := NewRequestWithBody(t, method, urlStr, bytes.NewReader(body))
+	if len(user) > 0 {
+		req.SetBasicAuth(user, token)
+	}
+	return req
+}
+
+// enablePackages enables the package registry with an empty content store, the returned
+// function restores the settings
+func enablePackages(t *testing.T) func() {
+	enabled, path := setting.Packages.Enabled, setting.Packages.ContentPath
+	dir, err := ioutil.TempDir("", "packages")
+	assert.NoError(t, err)
+	setting.Packages.Enabled = true
+	setting.Packages.ContentPath = dir
+	return func() {
+		setting.Packages.Enabled = enabled
+		setting.Packages.ContentPath = path
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPackagesGeneric(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer enablePackages(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		otherToken := getTokenForLoggedInUser(t, loginUser(t, "user4"))
+		content := []byte("generic package content")
+		url := "/api/packages/user2/generic/tool/1.0.0/tool.bin"
+
+		// Publishing requires an access token and write access to the owner
+		resp := MakeRequest(t, newPackageRequest(t, "PUT", url, "", "", content), http.StatusUnauthorized)
+		assert.Contains(t, resp.Header().Get("WWW-Authenticate"), "Basic")
+		MakeRequest(t, AddBasicAuthHeader(newPackageRequest(t, "PUT", url, "", "", content), "user2"), http.StatusUnauthorized)
+		MakeRequest(t, newPackageRequest(t, "PUT", url, "user4", otherToken, content), http.StatusForbidden)
+		MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user2/generic/tool/1.0.0/..bin", "user2", token, content), http.StatusBadRequest)
+
+		MakeRequest(t, newPackageRequest(t, "PUT", url, "user2", token, content), http.StatusCreated)
+		MakeRequest(t, newPackageRequest(t, "PUT", url, "user2", token, content), http.StatusConflict)
+		// Organization members who can create repositories publish for the organization
+		MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user3/generic/tool/1.0.0/tool.bin", "user2", token, content), http.StatusCreated)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", url), http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/generic/tool/1.0.0/other.bin"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/generic/tool/2.0.0/tool.bin"), http.StatusNotFound)
+
+		pv, err := models.GetPackageVersionByName(2, models.PackageGeneric, "tool", "1.0.0")
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, pv.DownloadCount)
+		assert.EqualValues(t, 2, pv.CreatorID)
+
+		// The packages of a private organization are hidden from non-members
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/privated_org/generic/tool/1.0.0/tool.bin"), http.StatusUnauthorized)
+		MakeRequest(t, newPackageRequest(t, "GET", "/api/packages/privated_org/generic/tool/1.0.0/tool.bin", "user4", otherToken, nil), http.StatusNotFound)
+
+		// Users owning packages can't be deleted
+		MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user4/generic/tool/1.0.0/tool.bin", "user4", otherToken, content), http.StatusCreated)
+		err = models.DeleteUser(&models.User{ID: 4})
+		assert.True(t, models.IsErrUserOwnPackages(err), "%v", err)
+
+		MakeRequest(t, newPackageRequest(t, "DELETE", url, "user4", otherToken, nil), http.StatusForbidden)
+		MakeRequest(t, newPackageRequest(t, "DELETE", url, "user2", token, nil), http.StatusNoContent)
+		MakeRequest(t, NewRequest(t, "GET", url), http.StatusNotFound)
+		_, err = models.GetPackage(2, models.PackageGeneric, "tool")
+		assert.True(t, models.IsErrPackageNotExist(err))
+
+		// The content is still used by the package of the organization
+		resp = MakeRequest(t, NewRequest(t, "GET", "/api/packages/user3/generic/tool/1.0.0/tool.bin"), http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		// Disabled registries are not served
+		setting.Packages.Enabled = false
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user3/generic/tool/1.0.0/tool.bin"), http.StatusNotFound)
+	})
+}
+
+func newNpmPublishRequest(t *testing.T, name, version, token string, tarball []byte) *http.Request {
+	req := newPackageRequest(t, "PUT", "/api/packages/user2/npm/"+url.PathEscape(name), "user2", token, []byte(npmPublishBody(name, version, tarball)))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// npmPublishBody returns the document the npm client sends to publish a version
+func npmPublishBody(name, version string, tarball []byte) string {
+	hash := sha512.Sum512(tarball)
+	return fmt.Sprintf(`{
+  "_id": "%[1]s",
+  "name": "%[1]s",
+  "description": "a test package",
+  "dist-tags": {"latest": "%[2]s"},
+  "versions": {
+    "%[2]s": {
+      "_id": "%[1]s@%[2]s",
+      "name": "%[1]s",
+      "version": "%[2]s",
+      "description": "a test package",
+      "license": "MIT",
+      "dependencies": {"left-pad": "^1.3.0"},
+      "dist": {
+        "integrity": "sha512-%[3]s",
+        "tarball": "http://localhost/%[1]s/-/package-%[2]s.tgz"
+      }
+    }
+  },
+  "_attachments": {
+    "package-%[2]s.tgz": {
+      "content_type": "application/octet-stream",
+      "data": "%[4]s",
+      "length": %[5]d
+    }
+  }
+}`, name, version, base64.StdEncoding.EncodeToString(hash[:]), base64.StdEncoding.EncodeToString(tarball), len(tarball))
+}
+
+func TestPackagesNpm(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer enablePackages(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		tarball := []byte("npm package tarball")
+
+		for _, name := range []string{"test-package", "@scope/test-package"} {
+			MakeRequest(t, newNpmPublishRequest(t, name, "1.0.0", token, tarball), http.StatusCreated)
+			MakeRequest(t, newNpmPublishRequest(t, name, "1.0.0", token, tarball), http.StatusConflict)
+			MakeRequest(t, newNpmPublishRequest(t, name, "1.1.0", token, tarball), http.StatusCreated)
+
+			resp := MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/npm/"+url.PathEscape(name)), http.StatusOK)
+			var doc npm.PackageDocument
+			DecodeJSON(t, resp, &doc)
+			assert.EqualValues(t, name, doc.Name)
+			assert.EqualValues(t, "a test package", doc.Description)
+			assert.EqualValues(t, "1.1.0", doc.DistTags["latest"])
+			if assert.Len(t, doc.Versions, 2) {
+				v := doc.Versions["1.0.0"]
+				assert.EqualValues(t, name+"@1.0.0", v.ID)
+				assert.EqualValues(t, "MIT", v.License)
+				assert.EqualValues(t, "^1.3.0", v.Dependencies["left-pad"])
+				hash := sha1.Sum(tarball)
+				assert.EqualValues(t, hex.EncodeToString(hash[:]), v.Dist.Shasum)
+				assert.True(t, npm.VerifyIntegrity(v.Dist.Integrity, tarball))
+				assert.EqualValues(t, setting.AppURL+"api/packages/user2/npm/"+name+"/-/1.0.0/package-1.0.0.tgz", v.Dist.Tarball)
+
+				tarballURL := v.Dist.Tarball[len(setting.AppURL)-1:]
+				resp = MakeRequest(t, NewRequest(t, "GET", tarballURL), http.StatusOK)
+				assert.Equal(t, tarball, resp.Body.Bytes())
+			}
+		}
+
+		// The integrity of the tarball is verified
+		body := strings.Replace(npmPublishBody("test-package", "2.0.0", tarball), `"integrity": "sha512-`, `"integrity": "sha512-AAAA`, 1)
+		MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user2/npm/test-package", "user2", token, []byte(body)), http.StatusBadRequest)
+		MakeRequest(t, newNpmPublishRequest(t, "Invalid Name", "2.0.0", token, tarball), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/npm/unknown-package"), http.StatusNotFound)
+	})
+}
+
+func TestPackagesMaven(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer enablePackages(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		root := "/api/packages/user2/maven/com/example/my-lib"
+		jar := []byte("maven jar content")
+		pom := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <modelVersion>4.0.0</modelVersion>
+  <groupId>com.example</groupId>
+  <artifactId>my-lib</artifactId>
+  <version>1.0</version>
+  <name>My Library</name>
+  <description>A library for tests</description>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.12</version>
+    </dependency>
+  </dependencies>
+</project>`)
+
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.jar", "", "", jar), http.StatusUnauthorized)
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.jar", "user2", token, jar), http.StatusCreated)
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.pom", "user2", token, pom), http.StatusCreated)
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.jar", "user2", token, jar), http.StatusConflict)
+
+		// Uploaded checksums are verified
+		hash := sha1.Sum(jar)
+		sum := hex.EncodeToString(hash[:])
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.jar.sha1", "user2", token, []byte(sum)), http.StatusOK)
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.0/my-lib-1.0.jar.sha1", "user2", token, []byte("0000")), http.StatusBadRequest)
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/maven-metadata.xml", "user2", token, []byte("<metadata/>")), http.StatusOK)
+
+		resp := MakeRequest(t, NewRequest(t, "GET", root+"/1.0/my-lib-1.0.jar"), http.StatusOK)
+		assert.Equal(t, jar, resp.Body.Bytes())
+		resp = MakeRequest(t, NewRequest(t, "GET", root+"/1.0/my-lib-1.0.jar.sha1"), http.StatusOK)
+		assert.Equal(t, sum, resp.Body.String())
+		MakeRequest(t, NewRequest(t, "GET", root+"/1.0/my-lib-1.0-sources.jar"), http.StatusNotFound)
+
+		MakeRequest(t, newPackageRequest(t, "PUT", root+"/1.1-SNAPSHOT/my-lib-1.1-SNAPSHOT.jar", "user2", token, jar), http.StatusCreated)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", root+"/maven-metadata.xml"), http.StatusOK)
+		metadata := resp.Body.String()
+		assert.Contains(t, metadata, "<groupId>com.example</groupId>")
+		assert.Contains(t, metadata, "<artifactId>my-lib</artifactId>")
+		assert.Contains(t, metadata, "<latest>1.1-SNAPSHOT</latest>")
+		assert.Contains(t, metadata, "<release>1.0</release>")
+		assert.Contains(t, metadata, "<version>1.0</version>")
+		hash256 := sha256.Sum256(resp.Body.Bytes())
+		resp = MakeRequest(t, NewRequest(t, "GET", root+"/maven-metadata.xml.sha256"), http.StatusOK)
+		assert.Equal(t, hex.EncodeToString(hash256[:]), resp.Body.String())
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/maven/com/example/unknown/maven-metadata.xml"), http.StatusNotFound)
+
+		// The metadata of the version is read from the POM
+		req := NewRequest(t, "GET", "/api/v1/packages/user2/maven/com.example:my-lib/1.0")
+		resp = MakeRequest(t, req, http.StatusOK)
+		var p api.Package
+		DecodeJSON(t, resp, &p)
+		assert.EqualValues(t, "My Library", p.Metadata["name"])
+		assert.EqualValues(t, "A library for tests", p.Metadata["description"])
+		assert.Len(t, p.Files, 2)
+	})
+}
+
+func newPyPIUploadRequest(t *testing.T, name, version, filename, digest, token string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key, value := range map[string]string{
+		":action":          "file_upload",
+		"protocol_version": "1",
+		"name":             name,
+		"version":          version,
+		"summary":          "A test project",
+		"requires_python":  ">=3.6",
+		"sha256_digest":    digest,
+	} {
+		assert.NoError(t, writer.WriteField(key, value))
+	}
+	part, err := writer.CreateFormFile("content", filename)
+	assert.NoError(t, err)
+	_, err = part.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	req := newPackageRequest(t, "POST", "/api/packages/user2/pypi", "user2", token, body.Bytes())
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestPackagesPyPI(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer enablePackages(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		content := []byte("python wheel content")
+		hash := sha256.Sum256(content)
+		digest := hex.EncodeToString(hash[:])
+
+		MakeRequest(t, newPyPIUploadRequest(t, "Test_Project", "1.0", "test_project-1.0-py3-none-any.whl", digest, token, content), http.StatusCreated)
+		MakeRequest(t, newPyPIUploadRequest(t, "Test_Project", "1.0", "test_project-1.0-py3-none-any.whl", digest, token, content), http.StatusConflict)
+		MakeRequest(t, newPyPIUploadRequest(t, "Test_Project", "1.0", "test_project-1.0.tar.gz", "0000", token, content), http.StatusBadRequest)
+		MakeRequest(t, newPyPIUploadRequest(t, "Test_Project", "not a version", "test_project-1.0.tar.gz", digest, token, content), http.StatusBadRequest)
+		MakeRequest(t, newPyPIUploadRequest(t, "Test_Project", "1.0", "test_project-1.0.exe", digest, token, content), http.StatusBadRequest)
+
+		// Project names are normalized
+		resp := MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/pypi/simple/test.project/"), http.StatusOK)
+		index := resp.Body.String()
+		fileURL := setting.AppURL + "api/packages/user2/pypi/files/test-project/1.0/test_project-1.0-py3-none-any.whl"
+		assert.Contains(t, index, fileURL+"#sha256="+digest)
+		assert.Contains(t, index, `data-requires-python="&gt;=3.6"`)
+		assert.NotContains(t, index, "test_project-1.0.tar.gz")
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/pypi/simple/unknown"), http.StatusNotFound)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", fileURL[len(setting.AppURL)-1:]), http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+	})
+}
+
+func TestAPIPackages(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer enablePackages(t)()
+
+		token := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+		otherToken := getTokenForLoggedInUser(t, loginUser(t, "user4"))
+		content := []byte("generic package content")
+		for _, version := range []string{"1.0.0", "1.1.0"} {
+			MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user2/generic/tool/"+version+"/tool.bin", "user2", token, content), http.StatusCreated)
+		}
+		MakeRequest(t, newPackageRequest(t, "PUT", "/api/packages/user2/generic/other/1.0.0/other.bin", "user2", token, content), http.StatusCreated)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/generic/tool/1.0.0/tool.bin"), http.StatusOK)
+
+		resp := MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/user2?type=generic&q=tool"), http.StatusOK)
+		var list []*api.Package
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, "2", resp.Header().Get("X-Total-Count"))
+		if assert.Len(t, list, 2) {
+			assert.EqualValues(t, "1.1.0", list[0].Version)
+			assert.EqualValues(t, "1.0.0", list[1].Version)
+		}
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/user2?type=unknown"), http.StatusUnprocessableEntity)
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/privated_org"), http.StatusNotFound)
+
+		resp = MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/user2/generic/tool/1.0.0"), http.StatusOK)
+		var p api.Package
+		DecodeJSON(t, resp, &p)
+		assert.EqualValues(t, "tool", p.Name)
+		assert.EqualValues(t, "generic", p.Type)
+		assert.EqualValues(t, "user2", p.Owner.UserName)
+		assert.EqualValues(t, "user2", p.Creator.UserName)
+		assert.EqualValues(t, 1, p.DownloadCount)
+		assert.Nil(t, p.Repository)
+		if assert.Len(t, p.Files, 1) {
+			hash := sha256.Sum256(content)
+			assert.EqualValues(t, "tool.bin", p.Files[0].Name)
+			assert.EqualValues(t, len(content), p.Files[0].Size)
+			assert.EqualValues(t, hex.EncodeToString(hash[:]), p.Files[0].HashSHA256)
+		}
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/user2/generic/tool/2.0.0"), http.StatusNotFound)
+
+		// Link the version to a repository of the owner
+		repo1 := "repo1"
+		MakeRequest(t, NewRequestWithJSON(t, "PATCH", "/api/v1/packages/user2/generic/tool/1.0.0", &api.EditPackageOption{Repository: &repo1}), http.StatusUnauthorized)
+		req := NewRequestWithJSON(t, "PATCH", "/api/v1/packages/user2/generic/tool/1.0.0?token="+otherToken, &api.EditPackageOption{Repository: &repo1})
+		MakeRequest(t, req, http.StatusForbidden)
+		req = NewRequestWithJSON(t, "PATCH", "/api/v1/packages/user2/generic/tool/1.0.0?token="+token, &api.EditPackageOption{Repository: &repo1})
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &p)
+		if assert.NotNil(t, p.Repository) {
+			assert.EqualValues(t, "user2/repo1", p.Repository.FullName)
+		}
+		unknown := "unknown"
+		req = NewRequestWithJSON(t, "PATCH", "/api/v1/packages/user2/generic/tool/1.0.0?token="+token, &api.EditPackageOption{Repository: &unknown})
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		// The private repository is hidden from other users
+		repo2 := "repo2"
+		req = NewRequestWithJSON(t, "PATCH", "/api/v1/packages/user2/generic/tool/1.0.0?token="+token, &api.EditPackageOption{Repository: &repo2})
+		MakeRequest(t, req, http.StatusOK)
+		resp = MakeRequest(t, NewRequest(t, "GET", "/api/v1/packages/user2/generic/tool/1.0.0?token="+otherToken), http.StatusOK)
+		p = api.Package{}
+		DecodeJSON(t, resp, &p)
+		assert.Nil(t, p.Repository)
+
+		MakeRequest(t, NewRequest(t, "DELETE", "/api/v1/packages/user2/generic/tool/1.0.0?token="+otherToken), http.StatusForbidden)
+		MakeRequest(t, NewRequest(t, "DELETE", "/api/v1/packages/user2/generic/tool/1.0.0?token="+token), http.StatusNoContent)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/generic/tool/1.0.0/tool.bin"), http.StatusNotFound)
+		MakeRequest(t, NewRequest(t, "GET", "/api/packages/user2/generic/tool/1.1.0/tool.bin"), http.StatusOK)
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIPullReviewRequests(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	pull := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/pulls/3/requested_reviewers?token=" + token
+
+	req := NewRequestWithJSON(t, "POST", urlStr, &api.PullReviewRequestOptions{Reviewers: []string{"user4"}})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var requests api.PullReviewRequests
+	DecodeJSON(t, resp, &requests)
+	if assert.Len(t, requests.Users, 1) {
+		assert.Equal(t, "user4", requests.Users[0].UserName)
+	}
+	assert.Empty(t, requests.Teams)
+	models.AssertExistsAndLoadBean(t, &models.ReviewRequest{IssueID: pull.ID, ReviewerID: 4, DoerID: 2})
+	models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pull.ID, Type: models.CommentTypeReviewRequest, AssigneeID: 4})
+
+	// anyone can read them
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/pulls/3/requested_reviewers")
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &requests)
+	assert.Len(t, requests.Users, 1)
+
+	// the poster can't review the pull request
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.PullReviewRequestOptions{Reviewers: []string{"user1"}})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.PullReviewRequestOptions{Reviewers: []string{"nonexistent"}})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	// only the repositories of organizations have teams
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.PullReviewRequestOptions{TeamReviewers: []string{"owners"}})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	// issues aren't reviewed
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/pulls/1/requested_reviewers?token="+token,
+		&api.PullReviewRequestOptions{Reviewers: []string{"user4"}})
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// only the writers can request reviews
+	session4 := loginUser(t, "user4")
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/pulls/3/requested_reviewers?token="+getTokenForLoggedInUser(t, session4),
+		&api.PullReviewRequestOptions{Reviewers: []string{"user5"}})
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.PullReviewRequestOptions{Reviewers: []string{"user4"}})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.ReviewRequest{IssueID: pull.ID, ReviewerID: 4})
+	models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pull.ID, Type: models.CommentTypeReviewRequest, AssigneeID: 4, RemovedAssignee: true})
+}
+
+func TestAPIPullReviewRequestSatisfied(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/pulls/3/requested_reviewers?token="+token,
+		&api.PullReviewRequestOptions{Reviewers: []string{"user4"}})
+	session.MakeRequest(t, req, http.StatusCreated)
+
+	// the review of the user satisfies the request
+	session4 := loginUser(t, "user4")
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/pulls/3/files/reviews/submit", map[string]string{
+		"_csrf":     GetCSRF(t, session4, "/user2/repo1/pulls/3"),
+		"content":   "looks good",
+		"type":      "comment",
+		"commit_id": "",
+	})
+	session4.MakeRequest(t, req, http.StatusFound)
+	models.AssertNotExistsBean(t, &models.ReviewRequest{IssueID: 3, ReviewerID: 4})
+}
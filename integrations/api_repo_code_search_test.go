@@ -0,0 +1,97 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISearchCode(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user2", "repo1")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/search/code?q=repo:user2/repo1+Description")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var results api.CodeSearchResults
+	DecodeJSON(t, resp, &results)
+	assert.True(t, results.OK)
+	if assert.Len(t, results.Data, 1) {
+		result := results.Data[0]
+		assert.EqualValues(t, "user2/repo1", result.Repository.FullName)
+		assert.EqualValues(t, "README.md", result.Filename)
+		assert.EqualValues(t, "Markdown", result.Language)
+		assert.EqualValues(t, setting.AppURL+"user2/repo1/src/branch/master/README.md#L2", result.HTMLURL)
+		if assert.Len(t, result.Lines, 2) {
+			assert.EqualValues(t, 3, result.Lines[1].Number)
+			assert.EqualValues(t, "Description for repo1", result.Lines[1].Content)
+		}
+	}
+	assert.EqualValues(t, "1", resp.Header().Get("X-Total-Count"))
+
+	req = NewRequest(t, "GET", "/api/v1/repos/search/code?q=repo:user2/repo1+lang:go+Description")
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.CodeSearchResults{}
+	DecodeJSON(t, resp, &results)
+	assert.Len(t, results.Data, 0)
+	// the languages are counted without the language filter
+	if assert.Len(t, results.Languages, 1) {
+		assert.EqualValues(t, "Markdown", results.Languages[0].Language)
+		assert.EqualValues(t, 1, results.Languages[0].Count)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/search/code?q=repo:user2/repo1+Description&language=go")
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.CodeSearchResults{}
+	DecodeJSON(t, resp, &results)
+	assert.Len(t, results.Data, 0)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/search/code?q=%2F%5B%2F")
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPISearchOrgCode(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// repo3 of the organization user3 is private
+	repo, err := models.GetRepositoryByOwnerAndName("user3", "repo3")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+	repo, err = models.GetRepositoryByOwnerAndName("user2", "repo1")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+
+	searchOrgCode := func(t *testing.T, token string, expected []string) {
+		url := "/api/v1/orgs/user3/search/code?q=Description"
+		if len(token) > 0 {
+			url += "&token=" + token
+		}
+		resp := MakeRequest(t, NewRequest(t, "GET", url), http.StatusOK)
+		var results api.CodeSearchResults
+		DecodeJSON(t, resp, &results)
+
+		names := make([]string, 0, len(results.Data))
+		for _, result := range results.Data {
+			names = append(names, result.Repository.FullName+"/"+result.Filename)
+		}
+		assert.EqualValues(t, expected, names)
+	}
+
+	searchOrgCode(t, "", []string{})
+	searchOrgCode(t, getTokenForLoggedInUser(t, loginUser(t, "user2")), []string{"user3/repo3/README.md"})
+	searchOrgCode(t, getTokenForLoggedInUser(t, loginUser(t, "user5")), []string{})
+
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/user3/search/code?q=%2F%5B%2F"), http.StatusUnprocessableEntity)
+}
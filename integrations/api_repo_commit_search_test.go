@@ -0,0 +1,76 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPISearchCommits(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user2", "repo1")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, commit_indexer.ReindexRepo)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/search/commits?q=repo:user2/repo1+author:6543")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var results api.CommitSearchResults
+	DecodeJSON(t, resp, &results)
+	assert.True(t, results.OK)
+	if assert.Len(t, results.Data, 3) {
+		// the most recently committed first
+		result := results.Data[0]
+		assert.EqualValues(t, "user2/repo1", result.Repository.FullName)
+		assert.EqualValues(t, "62fb502a7172d4453f0322a2cc85bddffa57f07a", result.SHA)
+		assert.EqualValues(t, setting.AppURL+"user2/repo1/commit/62fb502a7172d4453f0322a2cc85bddffa57f07a", result.HTMLURL)
+		assert.EqualValues(t, "add WoW File", result.Message)
+		assert.EqualValues(t, "6543", result.AuthorName)
+		assert.EqualValues(t, "6543@obermui.de", result.AuthorEmail)
+		assert.EqualValues(t, 1579200695, result.Committed.Unix())
+		assert.EqualValues(t, "985f0301dba5e7b34be866819cd15ad3d8f508ee", results.Data[1].SHA)
+		assert.EqualValues(t, "5c050d3b6d2db231ab1f64e324f1b6b9a0b181c2", results.Data[2].SHA)
+	}
+	assert.EqualValues(t, "3", resp.Header().Get("X-Total-Count"))
+
+	req = NewRequest(t, "GET", "/api/v1/repos/search/commits?q=repo:user2/repo1+after:yesterday")
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPISearchRepoCommits(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user2", "repo1")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, commit_indexer.ReindexRepo)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/search/commits?q=path:File-WoW")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var results api.CommitSearchResults
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Data, 1) {
+		assert.EqualValues(t, "62fb502a7172d4453f0322a2cc85bddffa57f07a", results.Data[0].SHA)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/search/commits?q=before:2018-01-01")
+	resp = MakeRequest(t, req, http.StatusOK)
+	results = api.CommitSearchResults{}
+	DecodeJSON(t, resp, &results)
+	if assert.Len(t, results.Data, 1) {
+		assert.EqualValues(t, "65f1bf27bc3bf70f64657658635e66094edbcb4d", results.Data[0].SHA)
+	}
+
+	// the code of the private repo2 of user2 can't be read anonymously
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo2/search/commits?q=commit")
+	MakeRequest(t, req, http.StatusNotFound)
+}
@@ -0,0 +1,211 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSCIMRequest(t *testing.T, method, urlStr, token string, v interface{}) *http.Request {
+	var req *http.Request
+	if v != nil {
+		req = NewRequestWithJSON(t, method, urlStr, v)
+	} else {
+		req = NewRequest(t, method, urlStr)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestAPISCIMUsers(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer func(enabled bool) { setting.SCIM.Enabled = enabled }(setting.SCIM.Enabled)
+		setting.SCIM.Enabled = true
+
+		adminToken := getTokenForLoggedInUser(t, loginUser(t, "user1"))
+		userToken := getTokenForLoggedInUser(t, loginUser(t, "user2"))
+
+		// Only site administrators may provision
+		MakeRequest(t, NewRequest(t, "GET", "/scim/v2/Users"), http.StatusUnauthorized)
+		MakeRequest(t, newSCIMRequest(t, "GET", "/scim/v2/Users", userToken, nil), http.StatusForbidden)
+
+		active := true
+		newUser := &scim.User{
+			Schemas:  []string{scim.SchemaUser},
+			UserName: "scimuser",
+			Name:     &scim.Name{GivenName: "Scim", FamilyName: "User"},
+			Emails:   []*scim.MultiValue{{Value: "scimuser@example.com", Primary: true}},
+			Active:   &active,
+		}
+		req := newSCIMRequest(t, "POST", "/scim/v2/Users", adminToken, newUser)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		var created scim.User
+		DecodeJSON(t, resp, &created)
+		assert.EqualValues(t, "scimuser", created.UserName)
+		assert.EqualValues(t, "Scim User", created.DisplayName)
+		assert.True(t, *created.Active)
+		u := models.AssertExistsAndLoadBean(t, &models.User{Name: "scimuser"}).(*models.User)
+		assert.EqualValues(t, fmt.Sprint(u.ID), created.ID)
+
+		// Duplicates are reported as uniqueness conflicts
+		MakeRequest(t, newSCIMRequest(t, "POST", "/scim/v2/Users", adminToken, newUser), http.StatusConflict)
+
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName eq "SCIMUSER"`), adminToken, nil)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var list struct {
+			TotalResults int64
+			Resources    []*scim.User
+		}
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.EqualValues(t, created.ID, list.Resources[0].ID)
+		}
+
+		MakeRequest(t, newSCIMRequest(t, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`title eq "x"`), adminToken, nil), http.StatusBadRequest)
+
+		// Wildcards in the values are matched literally
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users?filter="+url.QueryEscape(`userName sw "user_"`), adminToken, nil)
+		resp = MakeRequest(t, req, http.StatusOK)
+		list.Resources = nil
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, 0, list.TotalResults)
+
+		// The start index is a 1-based offset rather than a page boundary
+		req = newSCIMRequest(t, "GET", "/scim/v2/Users?startIndex=2&count=2", adminToken, nil)
+		resp = MakeRequest(t, req, http.StatusOK)
+		list.Resources = nil
+		DecodeJSON(t, resp, &list)
+		if assert.Len(t, list.Resources, 2) {
+			assert.EqualValues(t, "2", list.Resources[0].ID)
+			assert.EqualValues(t, "4", list.Resources[1].ID)
+		}
+
+		// Deactivation through PATCH
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/"+created.ID, adminToken, &scim.PatchRequest{
+			Schemas: []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{
+				{Op: "replace", Value: map[string]interface{}{"active": false}},
+				{Op: "replace", Path: "displayName", Value: "Deactivated User"},
+			},
+		})
+		resp = MakeRequest(t, req, http.StatusOK)
+		var patched scim.User
+		DecodeJSON(t, resp, &patched)
+		assert.False(t, *patched.Active)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: u.ID, IsActive: false, ProhibitLogin: true, FullName: "Deactivated User"})
+
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/"+created.ID, adminToken, &scim.PatchRequest{
+			Schemas:    []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{{Op: "remove", Path: "displayName"}},
+		})
+		MakeRequest(t, req, http.StatusOK)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: u.ID, FullName: ""})
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/"+created.ID, adminToken, &scim.PatchRequest{
+			Schemas:    []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{{Op: "remove", Path: "userName"}},
+		})
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		// Reactivation and deprovisioning
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/"+created.ID, adminToken, &scim.PatchRequest{
+			Schemas:    []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{{Op: "replace", Path: "active", Value: "True"}},
+		})
+		MakeRequest(t, req, http.StatusOK)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: u.ID, IsActive: true, ProhibitLogin: false})
+
+		MakeRequest(t, newSCIMRequest(t, "DELETE", "/scim/v2/Users/"+created.ID, adminToken, nil), http.StatusNoContent)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: u.ID, IsActive: false, ProhibitLogin: true})
+
+		// The account of the token in use cannot be deactivated
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Users/1", adminToken, &scim.PatchRequest{
+			Schemas:    []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{{Op: "replace", Path: "active", Value: false}},
+		})
+		MakeRequest(t, req, http.StatusBadRequest)
+		active = false
+		req = newSCIMRequest(t, "PUT", "/scim/v2/Users/1", adminToken, &scim.User{
+			Schemas:  []string{scim.SchemaUser},
+			UserName: "user1",
+			Active:   &active,
+		})
+		MakeRequest(t, req, http.StatusBadRequest)
+		MakeRequest(t, newSCIMRequest(t, "DELETE", "/scim/v2/Users/1", adminToken, nil), http.StatusBadRequest)
+		models.AssertExistsAndLoadBean(t, &models.User{ID: 1, IsActive: true})
+
+		// Organizations are not users
+		MakeRequest(t, newSCIMRequest(t, "GET", "/scim/v2/Users/3", adminToken, nil), http.StatusNotFound)
+	})
+}
+
+func TestAPISCIMGroups(t *testing.T) {
+	onGiteaRun(t, func(*testing.T, *url.URL) {
+		defer func(enabled bool) { setting.SCIM.Enabled = enabled }(setting.SCIM.Enabled)
+		setting.SCIM.Enabled = true
+
+		adminToken := getTokenForLoggedInUser(t, loginUser(t, "user1"))
+
+		req := newSCIMRequest(t, "POST", "/scim/v2/Groups", adminToken, &scim.Group{
+			Schemas:     []string{scim.SchemaGroup},
+			DisplayName: "user3/provisioned",
+			Members:     []*scim.MultiValue{{Value: "4"}},
+		})
+		resp := MakeRequest(t, req, http.StatusCreated)
+		var group scim.Group
+		DecodeJSON(t, resp, &group)
+		assert.EqualValues(t, "user3/provisioned", group.DisplayName)
+		if assert.Len(t, group.Members, 1) {
+			assert.EqualValues(t, "4", group.Members[0].Value)
+		}
+		team := models.AssertExistsAndLoadBean(t, &models.Team{OrgID: 3, LowerName: "provisioned"}).(*models.Team)
+		models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: team.ID, UID: 4})
+
+		MakeRequest(t, newSCIMRequest(t, "POST", "/scim/v2/Groups", adminToken, &scim.Group{
+			DisplayName: "not-an-org",
+		}), http.StatusBadRequest)
+
+		req = newSCIMRequest(t, "GET", "/scim/v2/Groups?excludedAttributes=members&filter="+url.QueryEscape(`displayName eq "user3/Provisioned"`), adminToken, nil)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var list struct {
+			TotalResults int64
+			Resources    []*scim.Group
+		}
+		DecodeJSON(t, resp, &list)
+		assert.EqualValues(t, 1, list.TotalResults)
+		if assert.Len(t, list.Resources, 1) {
+			assert.EqualValues(t, group.ID, list.Resources[0].ID)
+			assert.Empty(t, list.Resources[0].Members)
+		}
+
+		req = newSCIMRequest(t, "PATCH", "/scim/v2/Groups/"+group.ID, adminToken, &scim.PatchRequest{
+			Schemas: []string{scim.SchemaPatchOp},
+			Operations: []*scim.PatchOperation{
+				{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "5"}}},
+				{Op: "remove", Path: `members[value eq "4"]`},
+				{Op: "replace", Path: "displayName", Value: "user3/renamed"},
+			},
+		})
+		MakeRequest(t, req, http.StatusOK)
+		models.AssertExistsAndLoadBean(t, &models.TeamUser{TeamID: team.ID, UID: 5})
+		models.AssertNotExistsBean(t, &models.TeamUser{TeamID: team.ID, UID: 4})
+		models.AssertExistsAndLoadBean(t, &models.Team{ID: team.ID, LowerName: "renamed"})
+
+		MakeRequest(t, newSCIMRequest(t, "DELETE", "/scim/v2/Groups/"+group.ID, adminToken, nil), http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.Team{ID: team.ID})
+
+		// The owner team is protected
+		MakeRequest(t, newSCIMRequest(t, "DELETE", "/scim/v2/Groups/1", adminToken, nil), http.StatusBadRequest)
+	})
+}
@@ -100,6 +100,19 @@ func TestAPITeam(t *testing.T) {
 	checkTeamBean(t, apiTeam.ID, teamToEdit.Name, *teamToEditDesc.Description, *teamToEdit.IncludesAllRepositories,
 		teamToEdit.Permission, teamToEdit.Units)
 
+	// Edit team review assignment only
+	teamToEditReview := api.EditTeamOption{ReviewAssignment: "load_balance"}
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/teams/%d?token=%s", teamID, token), teamToEditReview)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiTeam)
+	assert.Equal(t, "load_balance", apiTeam.ReviewAssignment)
+	assert.Equal(t, *teamToEditDesc.Description, apiTeam.Description)
+	models.AssertExistsAndLoadBean(t, &models.Team{ID: teamID, ReviewAssignment: models.TeamReviewAssignmentLoadBalance})
+
+	teamToEditReview.ReviewAssignment = "anyone"
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/teams/%d?token=%s", teamID, token), teamToEditReview)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
 	// Read team.
 	teamRead := models.AssertExistsAndLoadBean(t, &models.Team{ID: teamID}).(*models.Team)
 	req = NewRequestf(t, "GET", "/api/v1/teams/%d?token="+token, teamID)
@@ -0,0 +1,308 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// enableCI enables CI with an empty log directory, the returned function restores the
+// settings
+func enableCI(t *testing.T) func() {
+	enabled, logPath, pollTimeout := setting.CI.Enabled, setting.CI.LogPath, setting.CI.PollTimeout
+	dir, err := ioutil.TempDir("", "ci_logs")
+	assert.NoError(t, err)
+	setting.CI.Enabled = true
+	setting.CI.LogPath = dir
+	setting.CI.PollTimeout = 200 * time.Millisecond
+	return func() {
+		setting.CI.Enabled = enabled
+		setting.CI.LogPath = logPath
+		setting.CI.PollTimeout = pollTimeout
+		os.RemoveAll(dir)
+	}
+}
+
+func newRunnerRequest(t *testing.T, method, urlStr, runnerToken string, obj interface{}) *http.Request {
+	req := NewRequestWithJSON(t, method, urlStr, obj)
+	req.Header.Set(ci.RunnerTokenHeader, runnerToken)
+	return req
+}
+
+// runStubJob fetches a task like a runner would and runs its steps with the shell,
+// streaming their output back. It returns the task, or nil if there was none.
+func runStubJob(t *testing.T, runnerToken string) *ci.Task {
+	resp := MakeRequest(t, newRunnerRequest(t, "POST", "/api/ci/runners/fetch", runnerToken, nil), NoExpectedStatus)
+	if resp.Code == http.StatusNoContent {
+		return nil
+	}
+	assert.EqualValues(t, http.StatusOK, resp.Code)
+	var task ci.Task
+	DecodeJSON(t, resp, &task)
+
+	// the job clones the repository with its token while it runs
+	req := NewRequest(t, "GET", "/"+task.Repository+".git/info/refs")
+	req.SetBasicAuth("ci", task.CloneToken)
+	MakeRequest(t, req, http.StatusOK)
+
+	jobURL := fmt.Sprintf("/api/ci/jobs/%d", task.JobID)
+	status := "success"
+	for _, step := range task.Steps {
+		stepURL := fmt.Sprintf("%s/steps/%d", jobURL, step.Index)
+		MakeRequest(t, newRunnerRequest(t, "POST", stepURL, runnerToken, &ci.UpdateStatusOptions{Status: "running"}), http.StatusNoContent)
+
+		cmd := exec.Command("sh", "-c", step.Run)
+		cmd.Env = os.Environ()
+		for _, env := range []map[string]string{task.Env, step.Env} {
+			for k, v := range env {
+				cmd.Env = append(cmd.Env, k+"="+v)
+			}
+		}
+		output, err := cmd.CombinedOutput()
+
+		lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+		resp := MakeRequest(t, newRunnerRequest(t, "POST", jobURL+"/logs", runnerToken, &ci.AppendLogOptions{Step: step.Index, Lines: lines}), http.StatusOK)
+		var result ci.AppendLogResult
+		DecodeJSON(t, resp, &result)
+		assert.EqualValues(t, len(lines), result.Length)
+
+		// lines sent again are ignored
+		resp = MakeRequest(t, newRunnerRequest(t, "POST", jobURL+"/logs", runnerToken, &ci.AppendLogOptions{Step: step.Index, Lines: lines}), http.StatusOK)
+		DecodeJSON(t, resp, &result)
+		assert.EqualValues(t, len(lines), result.Length)
+
+		if err != nil {
+			status = "failure"
+			MakeRequest(t, newRunnerRequest(t, "POST", stepURL, runnerToken, &ci.UpdateStatusOptions{Status: status}), http.StatusNoContent)
+			break
+		}
+		MakeRequest(t, newRunnerRequest(t, "POST", stepURL, runnerToken, &ci.UpdateStatusOptions{Status: "success"}), http.StatusNoContent)
+	}
+	MakeRequest(t, newRunnerRequest(t, "POST", jobURL, runnerToken, &ci.UpdateStatusOptions{Status: status}), http.StatusNoContent)
+	return &task
+}
+
+// pushWorkflow creates the workflow file of repo1, or updates it if sha is set
+func pushWorkflow(t *testing.T, token, workflow, sha string) {
+	urlStr := "/api/v1/repos/user2/repo1/contents/.gitea/workflows/ci.yml?token=" + token
+	fileOptions := api.FileOptions{BranchName: "master", Message: "Update workflow"}
+	content := base64.StdEncoding.EncodeToString([]byte(workflow))
+	if len(sha) == 0 {
+		MakeRequest(t, NewRequestWithJSON(t, "POST", urlStr, &api.CreateFileOptions{
+			FileOptions: fileOptions,
+			Content:     content,
+		}), http.StatusCreated)
+		return
+	}
+	MakeRequest(t, NewRequestWithJSON(t, "PUT", urlStr, &api.UpdateFileOptions{
+		DeleteFileOptions: api.DeleteFileOptions{FileOptions: fileOptions, SHA: sha},
+		Content:           content,
+	}), http.StatusOK)
+}
+
+// getCIRunnerToken returns the registration token shown on the page of the runners,
+// empty if it is not shown
+func getCIRunnerToken(t *testing.T, session *TestSession, urlStr string) string {
+	resp := session.MakeRequest(t, NewRequest(t, "GET", urlStr), http.StatusOK)
+	return NewHTMLParser(t, resp.Body).GetInputValueByID("ci-runner-token")
+}
+
+func TestCI(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		defer enableCI(t)()
+
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		// jobs of private repositories clone them with the token of the job
+		private := true
+		session.MakeRequest(t, NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+token, &api.EditRepoOption{Private: &private}), http.StatusOK)
+
+		// register a runner with the registration token of the repository, it is only
+		// shown once created
+		regToken := getCIRunnerToken(t, session, "/user2/repo1/settings/ci/runners")
+		assert.NotEmpty(t, regToken)
+		assert.Empty(t, getCIRunnerToken(t, session, "/user2/repo1/settings/ci/runners"))
+
+		MakeRequest(t, NewRequestWithJSON(t, "POST", "/api/ci/runners/register", &ci.RegisterRunnerOptions{Token: "invalid", Name: "stub"}), http.StatusUnauthorized)
+		resp := MakeRequest(t, NewRequestWithJSON(t, "POST", "/api/ci/runners/register", &ci.RegisterRunnerOptions{
+			Token:  regToken,
+			Name:   "stub",
+			Labels: []string{"linux"},
+		}), http.StatusCreated)
+		var runner ci.RegisteredRunner
+		DecodeJSON(t, resp, &runner)
+		assert.Len(t, runner.UUID, 36)
+		assert.NotEmpty(t, runner.Token)
+
+		MakeRequest(t, newRunnerRequest(t, "POST", "/api/ci/runners/fetch", "invalid-token", nil), http.StatusUnauthorized)
+		assert.Nil(t, runStubJob(t, runner.Token))
+
+		// pushing a workflow runs it, the second job needs the first and fails
+		pushWorkflow(t, token, `
+name: build
+on: push
+jobs:
+  greet:
+    runs-on: linux
+    steps:
+      - name: Greet
+        run: echo "hello $GITEA_REPOSITORY $GREETING"
+        env:
+          GREETING: from CI
+  test:
+    needs: greet
+    steps:
+      - run: echo testing && exit 3
+      - run: echo never
+`, "")
+		run, err := models.GetCIRunByIndex(repo.ID, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, models.CIStatusWaiting, run.Status)
+		assert.Equal(t, "refs/heads/master", run.Ref)
+
+		task := runStubJob(t, runner.Token)
+		if assert.NotNil(t, task) {
+			assert.Equal(t, "greet", task.Job)
+			assert.Equal(t, run.CommitSHA, task.CommitSHA)
+			assert.Equal(t, "user2/repo1", task.Env["GITEA_REPOSITORY"])
+
+			// the token is not valid anymore once the job finished
+			req := NewRequest(t, "GET", "/user2/repo1.git/info/refs")
+			req.SetBasicAuth("ci", task.CloneToken)
+			MakeRequest(t, req, http.StatusUnauthorized)
+		}
+		statuses, err := models.GetLatestCommitStatus(repo, run.CommitSHA, 0)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 2)
+		for _, status := range statuses {
+			if strings.Contains(status.Context, "greet") {
+				assert.Equal(t, api.CommitStatusSuccess, status.State)
+			} else {
+				assert.Equal(t, api.CommitStatusPending, status.State)
+			}
+		}
+
+		task = runStubJob(t, runner.Token)
+		if assert.NotNil(t, task) {
+			assert.Equal(t, "test", task.Job)
+			assert.Len(t, task.Steps, 2)
+
+			// reports on a finished job are rejected
+			jobURL := fmt.Sprintf("/api/ci/jobs/%d", task.JobID)
+			MakeRequest(t, newRunnerRequest(t, "POST", jobURL+"/logs", runner.Token, &ci.AppendLogOptions{Lines: []string{"late"}}), http.StatusConflict)
+		}
+		assert.Nil(t, runStubJob(t, runner.Token))
+
+		run, err = models.GetCIRunByIndex(repo.ID, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, run.LoadAttributes())
+		assert.Equal(t, models.CIStatusFailure, run.Status)
+		statuses, err = models.GetLatestCommitStatus(repo, run.CommitSHA, 0)
+		assert.NoError(t, err)
+		for _, status := range statuses {
+			if strings.Contains(status.Context, "test") {
+				assert.Equal(t, api.CommitStatusFailure, status.State)
+				assert.Equal(t, run.HTMLURL(), status.TargetURL)
+			}
+		}
+
+		resp = session.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/ci"), http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "/user2/repo1/ci/runs/1")
+		resp = session.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1/ci/runs/1"), http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "hello user2/repo1 from CI")
+		jobs, err := models.GetCIJobs(run.ID)
+		assert.NoError(t, err)
+		resp = session.MakeRequest(t, NewRequest(t, "GET", fmt.Sprintf("/user2/repo1/ci/runs/1/jobs/%d/steps/0/log", jobs[1].ID)), http.StatusOK)
+		assert.Equal(t, "testing", resp.Body.String())
+
+		// a cancelled run is not picked up anymore
+		resp = MakeRequest(t, NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents/.gitea/workflows/ci.yml?token="+token), http.StatusOK)
+		var file api.ContentsResponse
+		DecodeJSON(t, resp, &file)
+		pushWorkflow(t, token, "on: push\njobs:\n  a:\n    steps:\n      - run: echo\n", file.SHA)
+		_, err = models.GetCIRunByIndex(repo.ID, 2)
+		assert.NoError(t, err)
+
+		other := loginUser(t, "user4")
+		other.MakeRequest(t, NewRequestWithValues(t, "POST", "/user2/repo1/ci/runs/2/cancel", map[string]string{
+			"_csrf": GetCSRF(t, other, "/user/settings"),
+		}), http.StatusNotFound)
+		session.MakeRequest(t, NewRequestWithValues(t, "POST", "/user2/repo1/ci/runs/2/cancel", map[string]string{
+			"_csrf": GetCSRF(t, session, "/user2/repo1/ci/runs/2"),
+		}), http.StatusFound)
+		run, err = models.GetCIRunByIndex(repo.ID, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, models.CIStatusCancelled, run.Status)
+		assert.Nil(t, runStubJob(t, runner.Token))
+	})
+}
+
+func TestCIForkPullRequest(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		defer enableCI(t)()
+
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session)
+		pushWorkflow(t, token, "on: pull_request\njobs:\n  build:\n    steps:\n      - run: echo\n", "")
+
+		admin := loginUser(t, "user1")
+		var runners []ci.RegisteredRunner
+		for _, regToken := range []string{
+			getCIRunnerToken(t, session, "/user2/repo1/settings/ci/runners"),
+			getCIRunnerToken(t, admin, "/admin/ci/runners"),
+		} {
+			resp := MakeRequest(t, NewRequestWithJSON(t, "POST", "/api/ci/runners/register", &ci.RegisterRunnerOptions{Token: regToken, Name: "stub"}), http.StatusCreated)
+			var runner ci.RegisteredRunner
+			DecodeJSON(t, resp, &runner)
+			runners = append(runners, runner)
+		}
+		repoRunner, instanceRunner := runners[0], runners[1]
+
+		// the runs of a pull request from a fork wait for the approval of a writer
+		forker := loginUser(t, "user4")
+		testRepoFork(t, forker, "user2", "repo1", "user4", "repo1")
+		testEditFileToNewBranch(t, forker, "user4", "repo1", "master", "fork", "README.md", "Hello from a fork\n")
+		testPullCreate(t, forker, "user4", "repo1", "fork", "Fork pull request")
+
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		run, err := models.GetCIRunByIndex(repo.ID, 1)
+		assert.NoError(t, err)
+		assert.True(t, run.IsForkPull)
+		assert.True(t, run.NeedApproval)
+		assert.Nil(t, runStubJob(t, instanceRunner.Token))
+
+		forker.MakeRequest(t, NewRequestWithValues(t, "POST", "/user2/repo1/ci/runs/1/approve", map[string]string{
+			"_csrf": GetCSRF(t, forker, "/user/settings"),
+		}), http.StatusNotFound)
+		session.MakeRequest(t, NewRequestWithValues(t, "POST", "/user2/repo1/ci/runs/1/approve", map[string]string{
+			"_csrf": GetCSRF(t, session, "/user2/repo1/ci/runs/1"),
+		}), http.StatusFound)
+
+		// runners of the repository never run the code of forks
+		assert.Nil(t, runStubJob(t, repoRunner.Token))
+		task := runStubJob(t, instanceRunner.Token)
+		if assert.NotNil(t, task) {
+			assert.Equal(t, run.CommitSHA, task.CommitSHA)
+		}
+	})
+}
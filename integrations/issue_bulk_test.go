@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkEditIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/issues")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	action, _ := htmlDoc.doc.Find("#bulk-edit-issues form").Attr("action")
+	assert.Equal(t, "/user2/repo1/issues/bulk", action)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/bulk", map[string]string{
+		"_csrf":         htmlDoc.GetCSRF(),
+		"issue_ids":     "1,5",
+		"add_label_ids": "2",
+		"milestone":     "3",
+		"state":         "closed",
+		"lock":          "lock",
+		"redirect_to":   "/user2/repo1/issues?state=closed",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user2/repo1/issues?state=closed", test.RedirectURL(resp))
+	for _, id := range []int64{1, 5} {
+		models.AssertExistsAndLoadBean(t, &models.Issue{ID: id, MilestoneID: 3, IsClosed: true, IsLocked: true})
+		models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: id, LabelID: 2})
+	}
+
+	// labels of other repositories are refused, and nothing is changed
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/bulk", map[string]string{
+		"_csrf":         htmlDoc.GetCSRF(),
+		"issue_ids":     "1,5",
+		"add_label_ids": "3",
+		"state":         "open",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user2/repo1/issues", test.RedirectURL(resp))
+	models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, IsClosed: true})
+	models.AssertNotExistsBean(t, &models.IssueLabel{IssueID: 1, LabelID: 3})
+}
+
+func TestAPIBulkEditIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	milestone := int64(3)
+	closed := "closed"
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/bulk?token="+token, &api.BulkEditIssuesOption{
+		Indexes:         []int64{1, 4},
+		AddLabels:       []int64{2},
+		RemoveLabels:    []int64{1},
+		AddAssignees:    []string{"user2"},
+		RemoveAssignees: []string{"user1"},
+		Milestone:       &milestone,
+		State:           &closed,
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiIssues []*api.Issue
+	DecodeJSON(t, resp, &apiIssues)
+	assert.Len(t, apiIssues, 2)
+	for _, apiIssue := range apiIssues {
+		assert.Equal(t, api.StateClosed, apiIssue.State)
+		assert.EqualValues(t, 3, apiIssue.Milestone.ID)
+		if assert.Len(t, apiIssue.Labels, 1) {
+			assert.EqualValues(t, 2, apiIssue.Labels[0].ID)
+		}
+		if assert.Len(t, apiIssue.Assignees, 1) {
+			assert.Equal(t, "user2", apiIssue.Assignees[0].UserName)
+		}
+	}
+
+	invalid := "merged"
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/bulk?token="+token, &api.BulkEditIssuesOption{
+		Indexes: []int64{1},
+		State:   &invalid,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/bulk?token="+token, &api.BulkEditIssuesOption{
+		Indexes: []int64{99},
+		State:   &closed,
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// the users without write access cannot edit the issues
+	session = loginUser(t, "user4")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/bulk?token="+token, &api.BulkEditIssuesOption{
+		Indexes: []int64{1},
+		State:   &closed,
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestAPIBulkEditIssuesWebhook(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	hook := &models.Webhook{
+		RepoID:       1,
+		URL:          "http://www.example.com/bulk",
+		HTTPMethod:   "POST",
+		ContentType:  models.ContentTypeJSON,
+		HookEvent:    &models.HookEvent{ChooseEvents: true, HookEvents: models.HookEvents{Issues: true}},
+		IsActive:     true,
+		HookTaskType: models.GITEA,
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, models.CreateWebhook(hook))
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	closed := "closed"
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/bulk?token="+token, &api.BulkEditIssuesOption{
+		Indexes: []int64{1, 4},
+		State:   &closed,
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+
+	// issue 4 was already closed, only the closing of issue 1 is sent
+	hookTasks, err := models.HookTasks(hook.ID, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, hookTasks, 1) {
+		assert.Equal(t, models.HookEventIssues, hookTasks[0].EventType)
+		var payload api.IssuePayload
+		assert.NoError(t, json.Unmarshal([]byte(hookTasks[0].PayloadContent), &payload))
+		assert.Equal(t, api.HookIssueClosed, payload.Action)
+		assert.EqualValues(t, 1, payload.Index)
+	}
+}
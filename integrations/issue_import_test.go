@@ -0,0 +1,149 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/issues")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 1, htmlDoc.doc.Find(`a[href^="/user2/repo1/issues/export?format=csv"]`).Length())
+
+	req = NewRequest(t, "GET", "/user2/repo1/issues/export?format=csv&state=closed")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "title", records[0][1])
+		assert.Equal(t, "issue5", records[1][1])
+		assert.Equal(t, "closed", records[1][3])
+	}
+
+	// only the administrators of the repository can export the issues
+	session = loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/user2/repo1/issues/export?format=csv")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestImportIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/user2/repo1/issues/import")
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "issues.json")
+	assert.NoError(t, err)
+	_, err = part.Write([]byte(`[{"title": "imported", "labels": ["label1"], "state": "closed"}, {"title": "", "milestone": "missing"}]`))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	req := NewRequestWithBody(t, "POST", "/user2/repo1/issues/import", body)
+	req.Header.Add("X-Csrf-Token", csrf)
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	resp := session.MakeRequest(t, req, http.StatusOK)
+
+	// the rows are previewed with their errors
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 4, htmlDoc.doc.Find(`#import-issues select[name="fields"]`).Length())
+	assert.EqualValues(t, 1, htmlDoc.doc.Find("#import-issues tr.negative").Length())
+	_, disabled := htmlDoc.doc.Find(`#import-issues button[value="import"]`).Attr("disabled")
+	assert.True(t, disabled)
+	models.AssertNotExistsBean(t, &models.Issue{RepoID: 1, Title: "imported"})
+
+	content := `[{"title": "imported", "labels": ["label1"], "state": "closed"}]`
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/import", map[string]string{
+		"_csrf":   csrf,
+		"format":  "json",
+		"content": content,
+		"action":  "import",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user2/repo1/issues", test.RedirectURL(resp))
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: 1, Title: "imported", IsClosed: true}).(*models.Issue)
+	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: 1})
+
+	// only the administrators of the repository can import issues
+	session = loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/user2/repo1/issues/import")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIImportIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/issues/export?format=json&state=all&type=issues&token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.True(t, strings.HasPrefix(resp.Header().Get("Content-Type"), "application/json"))
+	exported := resp.Body.String()
+	assert.Contains(t, exported, `"title": "issue1"`)
+
+	// the exported file can be imported back
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/import?token="+token, &api.ImportIssuesOption{
+		Format:  "json",
+		Content: exported,
+		DryRun:  true,
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var rows []*api.ImportedIssueRow
+	DecodeJSON(t, resp, &rows)
+	if assert.Len(t, rows, 2) {
+		assert.ElementsMatch(t, []string{"issue1", "issue5"}, []string{rows[0].Title, rows[1].Title})
+		for _, row := range rows {
+			assert.Empty(t, row.Errors)
+			assert.Nil(t, row.Issue)
+		}
+	}
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/import?token="+token, &api.ImportIssuesOption{
+		Format:  "csv",
+		Content: "Name,Tags\nimported,label2\n",
+		Mapping: map[string]string{"Name": "title", "Tags": "labels"},
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	rows = nil
+	DecodeJSON(t, resp, &rows)
+	if assert.Len(t, rows, 1) && assert.NotNil(t, rows[0].Issue) {
+		assert.Equal(t, "imported", rows[0].Issue.Title)
+		assert.Equal(t, "user2", rows[0].Issue.Poster.UserName)
+		if assert.Len(t, rows[0].Issue.Labels, 1) {
+			assert.Equal(t, "label2", rows[0].Issue.Labels[0].Name)
+		}
+	}
+
+	// nothing is created if a row is invalid
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/import?token="+token, &api.ImportIssuesOption{
+		Format:  "csv",
+		Content: "title,milestone\nvalid,\ninvalid,missing\n",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	models.AssertNotExistsBean(t, &models.Issue{RepoID: 1, Title: "valid"})
+
+	session = loginUser(t, "user4")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/issues/export?token="+token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+// searchRepo1Issues returns the indexes of the issues of user2/repo1 listed
+// by the page for the query
+func searchRepo1Issues(t *testing.T, session *TestSession, link, query string) []int64 {
+	req := NewRequest(t, "GET", link+"?q="+url.QueryEscape(query))
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+
+	indexes := make([]int64, 0, 10)
+	getIssuesSelection(t, htmlDoc).Each(func(_ int, selection *goquery.Selection) {
+		indexes = append(indexes, getIssue(t, 1, selection).Index)
+	})
+	return indexes
+}
+
+func TestViewIssuesQuery(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	assert.EqualValues(t, []int64{4}, searchRepo1Issues(t, session, "/user2/repo1/issues", "is:closed"))
+	assert.EqualValues(t, []int64{1}, searchRepo1Issues(t, session, "/user2/repo1/issues", "label:label1 -label:label2"))
+	assert.EqualValues(t, []int64{2}, searchRepo1Issues(t, session, "/user2/repo1/pulls", `milestone:"milestone1"`))
+	assert.EqualValues(t, []int64{}, searchRepo1Issues(t, session, "/user2/repo1/issues", "author:@me is:open"))
+	assert.EqualValues(t, []int64{4}, searchRepo1Issues(t, session, "/user2/repo1/issues", "author:@me is:closed"))
+
+	req := NewRequest(t, "GET", "/user2/repo1/issues?q="+url.QueryEscape("is:draft"))
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".negative.message").Text(), "is:")
+	assert.EqualValues(t, 0, getIssuesSelection(t, htmlDoc).Length())
+
+	// the dashboard searches all the repositories of the user
+	assert.EqualValues(t, []int64{1}, searchRepo1Issues(t, session, "/issues", "repo:user2/repo1 label:label1"))
+	assert.EqualValues(t, []int64{5, 3}, searchRepo1Issues(t, session, "/pulls", "repo:user2/* -label:label1 is:open sort:created-desc"))
+}
+
+func TestAPISearchIssuesQuery(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	search := func(link, query string, status int) []*api.Issue {
+		values := url.Values{}
+		values.Add("token", token)
+		values.Add("q", query)
+		req := NewRequest(t, "GET", link+"?"+values.Encode())
+		resp := session.MakeRequest(t, req, status)
+		var apiIssues []*api.Issue
+		if status == http.StatusOK {
+			DecodeJSON(t, resp, &apiIssues)
+		}
+		return apiIssues
+	}
+
+	// the state of the query takes precedence over the state parameter
+	apiIssues := search("/api/v1/repos/issues/search", "is:closed author:user2", http.StatusOK)
+	if assert.Len(t, apiIssues, 2) {
+		assert.EqualValues(t, 5, apiIssues[0].ID)
+		assert.EqualValues(t, 4, apiIssues[1].ID)
+	}
+
+	apiIssues = search("/api/v1/repos/user2/repo1/issues", "label:label1", http.StatusOK)
+	if assert.Len(t, apiIssues, 2) {
+		assert.EqualValues(t, 2, apiIssues[0].ID)
+		assert.EqualValues(t, 1, apiIssues[1].ID)
+	}
+
+	search("/api/v1/repos/issues/search", "is:draft", http.StatusUnprocessableEntity)
+	search("/api/v1/repos/user2/repo1/issues", "created:yesterday", http.StatusUnprocessableEntity)
+}
+
+func TestSavedIssueQueries(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	query := "is:open label:label1"
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/issues?q="+url.QueryEscape(query))
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, "#saved-query-form", true)
+
+	// a query can only be shared with the organizations of the user
+	req = NewRequestWithValues(t, "POST", "/issues/saved_queries", map[string]string{
+		"_csrf":  htmlDoc.GetCSRF(),
+		"name":   "Label one",
+		"query":  query,
+		"org_id": "6",
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithValues(t, "POST", "/issues/saved_queries", map[string]string{
+		"_csrf":  htmlDoc.GetCSRF(),
+		"name":   "Label one",
+		"query":  query,
+		"org_id": "3",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.EqualValues(t, "/issues?q="+url.QueryEscape(query), resp.Header().Get("Location"))
+	saved := models.AssertExistsAndLoadBean(t, &models.SavedIssueQuery{UserID: 2, OrgID: 3, Name: "Label one"}).(*models.SavedIssueQuery)
+	assert.Equal(t, query, saved.Query)
+
+	// the members of the organization see the query but can't delete it
+	session4 := loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/issues")
+	resp = session4.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".saved-query").Text(), "Label one")
+
+	deleteLink := fmt.Sprintf("/issues/saved_queries/%d/delete", saved.ID)
+	req = NewRequestWithValues(t, "POST", deleteLink, map[string]string{
+		"_csrf": GetCSRF(t, session4, "/user/settings"),
+	})
+	session4.MakeRequest(t, req, http.StatusNotFound)
+	models.AssertExistsAndLoadBean(t, &models.SavedIssueQuery{ID: saved.ID})
+
+	req = NewRequestWithValues(t, "POST", deleteLink, map[string]string{
+		"_csrf": GetCSRF(t, session, "/user/settings"),
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	models.AssertNotExistsBean(t, &models.SavedIssueQuery{ID: saved.ID})
+}
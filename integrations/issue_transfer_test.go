@@ -0,0 +1,147 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferIssue(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/issues/1")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	action, _ := htmlDoc.doc.Find("#transfer-issue form").Attr("action")
+	assert.Equal(t, "/user2/repo1/issues/1/transfer", action)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/1/transfer", map[string]string{
+		"_csrf":    htmlDoc.GetCSRF(),
+		"new_repo": "user2/missing",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, setting.AppURL+"user2/repo1/issues/1", test.RedirectURL(resp))
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/1/transfer", map[string]string{
+		"_csrf":    htmlDoc.GetCSRF(),
+		"new_repo": "user2/utf8",
+	})
+	resp = session.MakeRequest(t, req, http.StatusSeeOther)
+	assert.Equal(t, setting.AppURL+"user2/utf8/issues/1", test.RedirectURL(resp))
+	models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, RepoID: 33, Index: 1})
+
+	req = NewRequest(t, "GET", "/user2/utf8/issues/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "user2/repo1#1")
+
+	req = NewRequest(t, "GET", "/")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), `transferred issue <code>user2/repo1#1</code> to <a href="`+setting.AppURL+`user2/utf8/issues/1#issuecomment-`)
+
+	// the old index is redirected for the users who can read the new repository
+	req = NewRequest(t, "GET", "/user2/repo1/issues/1")
+	resp = session.MakeRequest(t, req, http.StatusMovedPermanently)
+	assert.Equal(t, setting.AppURL+"user2/utf8/issues/1", test.RedirectURL(resp))
+	req = NewRequest(t, "GET", "/user2/repo1/issues/1")
+	MakeRequest(t, req, http.StatusMovedPermanently)
+	req = NewRequest(t, "GET", "/user2/repo1/issues/99")
+	MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPITransferIssue(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/1/transfer?token="+token, &api.TransferIssueOption{
+		NewOwner: "user2",
+		NewRepo:  "repo2",
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var apiIssue api.Issue
+	DecodeJSON(t, resp, &apiIssue)
+	assert.EqualValues(t, 1, apiIssue.ID)
+	assert.EqualValues(t, 3, apiIssue.Index)
+	assert.Equal(t, "repo2", apiIssue.Repo.Name)
+
+	// pull requests cannot be transferred
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/2/transfer?token="+token, &api.TransferIssueOption{
+		NewOwner: "user2",
+		NewRepo:  "repo2",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo2/issues/3/transfer?token="+token, &api.TransferIssueOption{
+		NewOwner: "user2",
+		NewRepo:  "missing",
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// only the issue writers of both repositories can transfer issues
+	session = loginUser(t, "user4")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/5/transfer?token="+token, &api.TransferIssueOption{
+		NewOwner: "user4",
+		NewRepo:  "repo4",
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestAPITransferIssueWebhooks(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	hooks := make([]*models.Webhook, 0, 2)
+	for _, repoID := range []int64{1, 2} {
+		hook := &models.Webhook{
+			RepoID:       repoID,
+			URL:          "http://www.example.com/transfer",
+			HTTPMethod:   "POST",
+			ContentType:  models.ContentTypeJSON,
+			HookEvent:    &models.HookEvent{ChooseEvents: true, HookEvents: models.HookEvents{Issues: true}},
+			IsActive:     true,
+			HookTaskType: models.GITEA,
+		}
+		assert.NoError(t, hook.UpdateEvent())
+		assert.NoError(t, models.CreateWebhook(hook))
+		hooks = append(hooks, hook)
+	}
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/1/transfer?token="+token, &api.TransferIssueOption{
+		NewOwner: "user2",
+		NewRepo:  "repo2",
+	})
+	session.MakeRequest(t, req, http.StatusCreated)
+
+	// the issue leaves the old repository with its old index and is opened in the new one
+	for i, expected := range []struct {
+		action api.HookIssueAction
+		index  int64
+	}{
+		{api.HookIssueTransferred, 1},
+		{api.HookIssueOpened, 3},
+	} {
+		hookTasks, err := models.HookTasks(hooks[i].ID, 1)
+		assert.NoError(t, err)
+		if assert.Len(t, hookTasks, 1) {
+			var payload api.IssuePayload
+			assert.NoError(t, json.Unmarshal([]byte(hookTasks[0].PayloadContent), &payload))
+			assert.Equal(t, expected.action, payload.Action)
+			assert.EqualValues(t, expected.index, payload.Index)
+			assert.EqualValues(t, hooks[i].RepoID, payload.Repository.ID)
+			assert.Equal(t, "repo2", payload.Issue.Repo.Name)
+		}
+	}
+}
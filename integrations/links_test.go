@@ -137,6 +137,8 @@ func testLinksAsUser(userName string, t *testing.T) {
 		//"/settings/hooks/git/update",
 		//"/settings/hooks/git/post-receive",
 		"/settings/keys",
+		"/settings/reminders",
+		"/settings/stale",
 		"/releases",
 		"/releases/new",
 		//"/wiki/_pages",
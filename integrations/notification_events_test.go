@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/eventsource"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationEvents(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Comments on the followed issue while the stream is open, then closes
+	// the stream, once the events sent until then are written
+	go func() {
+		defer eventsource.GetManager().UnregisterAll()
+		for !eventsource.GetManager().IsConnected(2) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		testIssueAddComment(t, loginUser(t, "user1"), "/user2/repo1/issues/1", "Hello from the stream", "")
+	}()
+
+	req := NewRequest(t, "GET", "/user/events?issue=1").WithContext(ctx)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+	assert.NoError(t, ctx.Err(), "the stream wasn't closed before the timeout")
+
+	body := resp.Body.String()
+	assert.Contains(t, body, "retry: 5000\n")
+	assert.Contains(t, body, "event: notification-count\ndata: {\"count\":")
+	assert.Contains(t, body, "event: stopwatches\ndata: [{\"issue_id\":2,\"created\":1500988002}]\n")
+	assert.Contains(t, body, "event: issue-update\ndata: {\"issue_id\":1,\"doer_id\":1}\n")
+	assert.False(t, eventsource.GetManager().IsConnected(2))
+}
+
+func TestNotificationEventsPermission(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// issue 4 is in the private repo2 of user2
+	session := loginUser(t, "user4")
+	req := NewRequest(t, "GET", "/user/events?issue=4")
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "GET", "/user/events")
+	MakeRequest(t, req, http.StatusFound)
+}
+
+func TestWebPushSubscription(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(hosts []string) {
+		setting.WebPush.AllowedHosts = hosts
+	}(setting.WebPush.AllowedHosts)
+	setting.WebPush.AllowedHosts = []string{"push.example.com"}
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/notifications")
+	endpoint := "https://push.example.com/send/abc"
+
+	req := NewRequestWithValues(t, "POST", "/notifications/webpush/subscribe", map[string]string{
+		"_csrf":    csrf,
+		"endpoint": endpoint,
+		"p256dh":   "BNcRdreALRFXTkOOUHK1EtK2wtaz5Ry4YfYCA_0QTpQtUbVlUls0VJXg7A8u-Ts1XbjhazAkj7I99e8QcYP7DkM",
+		"auth":     "tBHItJI5svbpez7KI4CCXg",
+	})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertExistsAndLoadBean(t, &models.WebPushSubscription{UserID: 2, Endpoint: endpoint})
+
+	// only the https:// endpoints of the allowed push services are accepted
+	for _, invalid := range []string{"http://push.example.com/send/abc", "https://127.0.0.1/send/abc", "https://internal.example.com/"} {
+		req = NewRequestWithValues(t, "POST", "/notifications/webpush/subscribe", map[string]string{
+			"_csrf":    csrf,
+			"endpoint": invalid,
+			"p256dh":   "BNcRdreALRFXTkOOUHK1EtK2wtaz5Ry4YfYCA_0QTpQtUbVlUls0VJXg7A8u-Ts1XbjhazAkj7I99e8QcYP7DkM",
+			"auth":     "tBHItJI5svbpez7KI4CCXg",
+		})
+		session.MakeRequest(t, req, http.StatusBadRequest)
+	}
+
+	req = NewRequestWithValues(t, "POST", "/notifications/webpush/unsubscribe", map[string]string{
+		"_csrf":    csrf,
+		"endpoint": endpoint,
+	})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.WebPushSubscription{UserID: 2, Endpoint: endpoint})
+}
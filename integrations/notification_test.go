@@ -0,0 +1,80 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationFilters(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+
+	req := NewRequest(t, "GET", "/notifications")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 3, htmlDoc.doc.Find(".notification-select").Length())
+
+	req = NewRequest(t, "GET", "/notifications?reason=mention")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	ids := htmlDoc.doc.Find(".notification-select").Map(func(_ int, s *goquery.Selection) string {
+		return s.AttrOr("value", "")
+	})
+	assert.EqualValues(t, []string{"4"}, ids)
+
+	req = NewRequest(t, "GET", "/notifications?repo=2")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	ids = htmlDoc.doc.Find(".notification-select").Map(func(_ int, s *goquery.Selection) string {
+		return s.AttrOr("value", "")
+	})
+	assert.EqualValues(t, []string{"5"}, ids)
+
+	req = NewRequest(t, "GET", "/notifications?type=pull")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 0, htmlDoc.doc.Find(".notification-select").Length())
+}
+
+func TestNotificationBulk(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/notifications")
+
+	req := NewRequestWithValues(t, "POST", "/notifications/bulk", map[string]string{
+		"_csrf":       csrf,
+		"action":      "read",
+		"ids":         "4",
+		"redirect_to": "/notifications?q=unread",
+	})
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	assert.EqualValues(t, "/notifications?q=unread", resp.Header().Get("Location"))
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusRead})
+
+	req = NewRequestWithValues(t, "POST", "/notifications/bulk", map[string]string{
+		"_csrf":  csrf,
+		"action": "unsubscribe",
+		"ids":    "5",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 5, Status: models.NotificationStatusRead})
+	models.AssertExistsAndLoadBean(t, &models.IssueWatch{UserID: 2, IssueID: 4}, models.Cond("is_watching = ?", false))
+
+	req = NewRequestWithValues(t, "POST", "/notifications/bulk", map[string]string{
+		"_csrf":  csrf,
+		"action": "delete",
+		"ids":    "3",
+	})
+	session.MakeRequest(t, req, http.StatusBadRequest)
+}
@@ -6,6 +6,10 @@ package integrations
 import (
 	"net/http"
 	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPullView_ReviewerMissed(t *testing.T) {
@@ -18,3 +22,44 @@ func TestPullView_ReviewerMissed(t *testing.T) {
 	req = NewRequest(t, "GET", "/user2/repo1/pulls/3")
 	session.MakeRequest(t, req, http.StatusOK)
 }
+
+func TestPullReviewRequest(t *testing.T) {
+	defer prepareTestEnv(t)()
+	session := loginUser(t, "user2")
+
+	req := NewRequestWithValues(t, "POST", "/user2/repo1/issues/request_review", map[string]string{
+		"_csrf":     GetCSRF(t, session, "/user2/repo1/pulls/3"),
+		"issue_ids": "3",
+		"id":        "4",
+		"action":    "attach",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.ReviewRequest{IssueID: 3, ReviewerID: 4})
+
+	req = NewRequest(t, "GET", "/user2/repo1/pulls/3")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".ui.reviewers.list .selected").Text(), "user4")
+	assert.EqualValues(t, 1, htmlDoc.doc.Find(".select-reviewers .menu .checked.item[data-id='4']").Length())
+	// the poster can't be requested
+	assert.EqualValues(t, 0, htmlDoc.doc.Find(".select-reviewers .menu .item[data-id='1']").Length())
+
+	// the readers can't request reviews
+	session4 := loginUser(t, "user4")
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/request_review", map[string]string{
+		"_csrf":     GetCSRF(t, session4, "/user2/repo1/pulls/3"),
+		"issue_ids": "3",
+		"id":        "4",
+		"action":    "detach",
+	})
+	session4.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/issues/request_review", map[string]string{
+		"_csrf":     GetCSRF(t, session, "/user2/repo1/pulls/3"),
+		"issue_ids": "3",
+		"id":        "4",
+		"action":    "detach",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.ReviewRequest{IssueID: 3, ReviewerID: 4})
+}
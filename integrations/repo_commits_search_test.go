@@ -10,12 +10,15 @@ import (
 	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/models"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
 )
 
 func testRepoCommitsSearch(t *testing.T, query, commit string) {
-	defer prepareTestEnv(t)()
-
 	session := loginUser(t, "user2")
 
 	// Request repository commits page
@@ -27,7 +30,7 @@ func testRepoCommitsSearch(t *testing.T, query, commit string) {
 	assert.EqualValues(t, commit, strings.TrimSpace(sel.Text()))
 }
 
-func TestRepoCommitsSearch(t *testing.T) {
+func testRepoCommitsSearchQueries(t *testing.T) {
 	testRepoCommitsSearch(t, "e8eabd", "")
 	testRepoCommitsSearch(t, "38a9cb", "")
 	testRepoCommitsSearch(t, "6e8e", "6e8eabd9a7")
@@ -41,3 +44,49 @@ func TestRepoCommitsSearch(t *testing.T) {
 	testRepoCommitsSearch(t, "committer:alice commit before:2019-03-02", "6e8eabd9a7")
 	testRepoCommitsSearch(t, "committer:alice author:tom commit before:2019-03-04 after:2019-03-02", "0a8499a22a")
 }
+
+func TestRepoCommitsSearch(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	t.Run("Git", func(t *testing.T) {
+		defer func(enabled bool) {
+			setting.Indexer.CommitIndexerEnabled = enabled
+		}(setting.Indexer.CommitIndexerEnabled)
+		setting.Indexer.CommitIndexerEnabled = false
+
+		testRepoCommitsSearchQueries(t)
+	})
+
+	t.Run("Indexer", func(t *testing.T) {
+		repo, err := models.GetRepositoryByOwnerAndName("user2", "commits_search_test")
+		assert.NoError(t, err)
+		executeIndexer(t, repo, commit_indexer.ReindexRepo)
+
+		testRepoCommitsSearchQueries(t)
+		testRepoCommitsSearch(t, "path:readme commit-5", "9800fe78ca")
+		testRepoCommitsSearch(t, "path:license commit", "")
+
+		session := loginUser(t, "user2")
+		req := NewRequest(t, "GET", "/user2/commits_search_test/commits/branch/master/search?q=after%3Ayesterday")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		doc := NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, doc.doc.Find(".negative.message").Length())
+	})
+}
+
+func TestExploreCommits(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user2", "commits_search_test")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, commit_indexer.ReindexRepo)
+
+	req := NewRequest(t, "GET", "/explore/commits?q=repo%3Auser2%2Fcommits_search_test+author%3Abob")
+	resp := MakeRequest(t, req, http.StatusOK)
+	doc := NewHTMLParser(t, resp.Body)
+	var shas []string
+	doc.doc.Find("#commits-table tbody tr td.sha a").Each(func(i int, sel *goquery.Selection) {
+		shas = append(shas, strings.TrimSpace(sel.Text()))
+	})
+	assert.EqualValues(t, []string{"58e97d1a24", "c20caf78b5"}, shas)
+}
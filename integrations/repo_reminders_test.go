@@ -0,0 +1,91 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoReminderSettings(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/settings/reminders")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	_, dueDateEnabled := htmlDoc.doc.Find("input[name=enable_due_date]").Attr("checked")
+	assert.True(t, dueDateEnabled)
+	value, _ := htmlDoc.doc.Find("input[name=review_request_days]").Attr("value")
+	assert.Equal(t, "2", value)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/reminders", map[string]string{
+		"_csrf":                 htmlDoc.GetCSRF(),
+		"due_date_days":         "3",
+		"enable_review_request": "on",
+		"review_request_days":   "4",
+		"inactive_pull_days":    "14",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	rule := models.AssertExistsAndLoadBean(t, &models.ReminderRule{RepoID: 1, Type: models.ReminderRuleDueDate}).(*models.ReminderRule)
+	assert.False(t, rule.Enabled)
+	assert.EqualValues(t, 3, rule.Days)
+	rule = models.AssertExistsAndLoadBean(t, &models.ReminderRule{RepoID: 1, Type: models.ReminderRuleReviewRequest}).(*models.ReminderRule)
+	assert.True(t, rule.Enabled)
+	assert.EqualValues(t, 4, rule.Days)
+	rule = models.AssertExistsAndLoadBean(t, &models.ReminderRule{RepoID: 1, Type: models.ReminderRuleInactivePull}).(*models.ReminderRule)
+	assert.False(t, rule.Enabled)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/reminders", map[string]string{
+		"_csrf":               htmlDoc.GetCSRF(),
+		"due_date_days":       "3",
+		"review_request_days": "0",
+		"inactive_pull_days":  "14",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	rule = models.AssertExistsAndLoadBean(t, &models.ReminderRule{RepoID: 1, Type: models.ReminderRuleReviewRequest}).(*models.ReminderRule)
+	assert.True(t, rule.Enabled)
+
+	// only the admins of the repository can change its reminders
+	session = loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/user2/repo1/settings/reminders")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestSettingReminders(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequestWithValues(t, "POST", "/user/settings/account/email", map[string]string{
+		"_csrf":      GetCSRF(t, session, "/user/settings/account"),
+		"_method":    "NOTIFICATION",
+		"preference": models.EmailNotificationsEnabled,
+		"reminders":  "disabled",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	assert.True(t, user.DisableReminders)
+
+	req = NewRequest(t, "GET", "/user/settings/account")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	value, _ := htmlDoc.doc.Find("input[name=reminders]").Attr("value")
+	assert.Equal(t, "disabled", value)
+
+	req = NewRequestWithValues(t, "POST", "/user/settings/account/email", map[string]string{
+		"_csrf":      GetCSRF(t, session, "/user/settings/account"),
+		"_method":    "NOTIFICATION",
+		"preference": models.EmailNotificationsEnabled,
+		"reminders":  "enabled",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	user = models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	assert.False(t, user.DisableReminders)
+}
@@ -6,6 +6,7 @@ package integrations
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +39,10 @@ func TestSearchRepo(t *testing.T) {
 	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
 
 	testSearch(t, "/user2/repo1/search?q=Description&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=lang:markdown+Description&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=lang:go+Description&page=1", []string{})
+	testSearch(t, "/user2/repo1/search?q=path:readme&page=1", []string{"README.md"})
+	testSearch(t, "/user2/repo1/search?q=/Descr[a-z]*/&page=1", []string{"README.md"})
 
 	setting.Indexer.IncludePatterns = setting.IndexerGlobFromString("**.txt")
 	setting.Indexer.ExcludePatterns = setting.IndexerGlobFromString("**/y/**")
@@ -52,6 +57,40 @@ func TestSearchRepo(t *testing.T) {
 	testSearch(t, "/user2/glob/search?q=file3&page=1", []string{"x/b.txt"})
 	testSearch(t, "/user2/glob/search?q=file4&page=1", []string{})
 	testSearch(t, "/user2/glob/search?q=file5&page=1", []string{})
+
+	// a reindex drops the files which are not indexed anymore
+	setting.Indexer.ExcludePatterns = setting.IndexerGlobFromString("x/**")
+	executeIndexer(t, repo, code_indexer.ReindexRepo)
+
+	testSearch(t, "/user2/glob/search?q=loren&page=1", []string{"a.txt"})
+	testSearch(t, "/user2/glob/search?q=file3&page=1", []string{})
+}
+
+func TestSearchOrgCode(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	repo, err := models.GetRepositoryByOwnerAndName("user3", "repo3")
+	assert.NoError(t, err)
+	executeIndexer(t, repo, code_indexer.UpdateRepoIndexer)
+
+	// repo3 is private, only the members of the organization find its files
+	req := NewRequest(t, "GET", "/org/user3/code?q=Description")
+	resp := MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, 0, NewHTMLParser(t, resp.Body).doc.Find(".repo-search-result").Length())
+
+	session := loginUser(t, "user2")
+	req = NewRequest(t, "GET", "/org/user3/code?q=Description")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	doc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, []string{"user3/repo3 - README.md"}, resultFilenames(t, doc))
+	assert.EqualValues(t, "user3/repo3", strings.TrimSpace(doc.doc.Find(".repo-search-group").Text()))
+	assert.EqualValues(t, 1, doc.doc.Find(".code-search-languages .item[href*=Markdown]").Length())
+
+	req = NewRequest(t, "GET", "/org/user3/code?q=Description&l=Go")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	doc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 0, doc.doc.Find(".repo-search-result").Length())
+	assert.EqualValues(t, 1, doc.doc.Find(".code-search-languages .item[href*=Markdown]").Length())
 }
 
 func testSearch(t *testing.T, url string, expected []string) {
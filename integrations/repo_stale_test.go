@@ -0,0 +1,70 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	stale_service "code.gitea.io/gitea/services/stale"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoStaleSettings(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/settings/stale")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	_, enabled := htmlDoc.doc.Find("input[name=enabled]").Attr("checked")
+	assert.False(t, enabled)
+	value, _ := htmlDoc.doc.Find("input[name=days_until_stale]").Attr("value")
+	assert.Equal(t, "60", value)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/stale", map[string]string{
+		"_csrf":            htmlDoc.GetCSRF(),
+		"enabled":          "on",
+		"only":             "issues",
+		"days_until_stale": "30",
+		"days_until_close": "0",
+		"stale_label":      "inactive",
+		"mark_comment":     "This issue is stale.",
+		"limit_per_run":    "10",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	rule := models.AssertExistsAndLoadBean(t, &models.StaleRule{RepoID: 1}).(*models.StaleRule)
+	assert.True(t, rule.Enabled)
+	assert.Equal(t, "issues", rule.Only)
+	assert.EqualValues(t, 30, rule.DaysUntilStale)
+	assert.EqualValues(t, 0, rule.DaysUntilClose)
+	assert.Equal(t, "inactive", rule.StaleLabel)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/stale", map[string]string{
+		"_csrf":            htmlDoc.GetCSRF(),
+		"only":             "everything",
+		"days_until_stale": "30",
+		"stale_label":      "inactive",
+		"limit_per_run":    "10",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	rule = models.AssertExistsAndLoadBean(t, &models.StaleRule{RepoID: 1}).(*models.StaleRule)
+	assert.True(t, rule.Enabled)
+
+	// the comments of the system user are shown in the issue
+	stale_service.Run(context.Background())
+	models.AssertExistsAndLoadBean(t, &models.StaleIssue{RepoID: 1, IssueID: 1})
+	req = NewRequest(t, "GET", "/user2/repo1/issues/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "This issue is stale.")
+
+	// only the admins of the repository can change its stale rule
+	session = loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/user2/repo1/settings/stale")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
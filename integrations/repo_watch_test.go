@@ -5,11 +5,14 @@
 package integrations
 
 import (
+	"net/http"
 	"net/url"
 	"testing"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRepoWatch(t *testing.T) {
@@ -22,3 +25,25 @@ func TestRepoWatch(t *testing.T) {
 		models.AssertExistsAndLoadBean(t, &models.Watch{UserID: 2, RepoID: 3, Mode: models.RepoWatchModeAuto})
 	})
 }
+
+func TestRepoNotificationLevel(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, ".repo-buttons a[href*='/action/notify_releases']", true)
+
+	req = NewRequest(t, "GET", "/user2/repo1/action/notify_releases?redirect_to=/user2/repo1")
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.EqualValues(t, "/user2/repo1", resp.Header().Get("Location"))
+	models.AssertExistsAndLoadBean(t, &models.RepoNotificationPreference{UserID: 2, RepoID: 1, Level: models.RepoNotificationLevelReleases})
+
+	req = NewRequest(t, "GET", "/user2/repo1/action/notify_default")
+	session.MakeRequest(t, req, http.StatusFound)
+	models.AssertNotExistsBean(t, &models.RepoNotificationPreference{UserID: 2, RepoID: 1})
+
+	req = NewRequest(t, "GET", "/user2/repo1/action/notify_everything")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"testing"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/stretchr/testify/assert"
@@ -106,3 +107,32 @@ func TestSettingLandingPage(t *testing.T) {
 
 	setting.LandingPageURL = landingPage
 }
+
+func TestSettingEmailDigest(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequestWithValues(t, "POST", "/user/settings/account/email", map[string]string{
+		"_csrf":      GetCSRF(t, session, "/user/settings/account"),
+		"_method":    "NOTIFICATION",
+		"preference": models.EmailNotificationsEnabled,
+		"digest":     models.EmailDigestDaily,
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	assert.Equal(t, models.EmailDigestDaily, user.EmailDigest())
+
+	req = NewRequest(t, "GET", "/user/settings/account")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	value, _ := htmlDoc.doc.Find("input[name=digest]").Attr("value")
+	assert.Equal(t, models.EmailDigestDaily, value)
+
+	req = NewRequestWithValues(t, "POST", "/user/settings/account/email", map[string]string{
+		"_csrf":      GetCSRF(t, session, "/user/settings/account"),
+		"_method":    "NOTIFICATION",
+		"preference": models.EmailNotificationsEnabled,
+		"digest":     "weekly",
+	})
+	session.MakeRequest(t, req, http.StatusInternalServerError)
+}
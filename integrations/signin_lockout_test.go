@@ -0,0 +1,125 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/lockout"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknwon/i18n"
+)
+
+func testLoginLocked(t *testing.T, username, password, remoteAddr string) {
+	session := emptyTestSession(t)
+	req := NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+		"_csrf":     GetCSRF(t, session, "/user/login"),
+		"user_name": username,
+		"password":  password,
+	})
+	if len(remoteAddr) > 0 {
+		req.Header.Set("X-Real-IP", remoteAddr)
+	}
+	resp := session.MakeRequest(t, req, http.StatusOK)
+
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	resultMsg := htmlDoc.doc.Find(".ui.message>p").Text()
+	assert.Contains(t, resultMsg, "Too many failed sign-in attempts")
+}
+
+func TestSigninLockout(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(old bool) { setting.LoginLockout.Enabled = old }(setting.LoginLockout.Enabled)
+	setting.LoginLockout.Enabled = true
+	setting.LoginLockout.MaxAccountAttempts = 3
+	setting.LoginLockout.MaxIPAttempts = 100
+	setting.LoginLockout.DelayAfterAttempts = 100
+
+	// The attempts are counted per account whether the user name or the email is used
+	for _, loginName := range []string{"user2", "user2@example.com", "User2"} {
+		testLoginFailed(t, loginName, "wrongPassword", i18n.Tr("en", "form.username_password_incorrect"))
+	}
+	l := models.AssertExistsAndLoadBean(t, &models.LoginLockout{Type: models.LoginLockoutAccount, Target: "user2"}).(*models.LoginLockout)
+	assert.EqualValues(t, 3, l.FailedAttempts)
+
+	// The correct password is refused everywhere now
+	testLoginLocked(t, "user2", userPassword, "")
+	req := NewRequest(t, "GET", "/api/v1/user")
+	req.SetBasicAuth("user2", userPassword)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/refs")
+	req.SetBasicAuth("user2", userPassword)
+	resp := MakeRequest(t, req, http.StatusTooManyRequests)
+	assert.NotEmpty(t, resp.Header().Get("Retry-After"))
+
+	// Other accounts are not affected
+	loginUserWithPassword(t, "user4", userPassword)
+
+	// Admins can clear the lockout
+	session := loginUser(t, "user1")
+	resp = session.MakeRequest(t, NewRequest(t, "GET", "/admin/lockouts"), http.StatusOK)
+	assert.Contains(t, resp.Body.String(), fmt.Sprintf(`data-id="%d"`, l.ID))
+	req = NewRequestWithValues(t, "POST", "/admin/lockouts/delete", map[string]string{
+		"_csrf": GetCSRF(t, session, "/admin/ssh_cas"),
+		"id":    fmt.Sprint(l.ID),
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.LoginLockout{ID: l.ID})
+	loginUserWithPassword(t, "user2", userPassword)
+}
+
+func TestSigninLockoutDelay(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(old bool) { setting.LoginLockout.Enabled = old }(setting.LoginLockout.Enabled)
+	setting.LoginLockout.Enabled = true
+	setting.LoginLockout.MaxAccountAttempts = 10
+	setting.LoginLockout.DelayAfterAttempts = 2
+	setting.LoginLockout.Delay = time.Minute
+	setting.LoginLockout.MaxDelay = time.Hour
+	defer lockout.Succeed("user5")
+
+	testLoginFailed(t, "user5", "wrongPassword", i18n.Tr("en", "form.username_password_incorrect"))
+	loginUserWithPassword(t, "user5", userPassword)
+
+	// Successful sign-ins reset the failed attempts
+	testLoginFailed(t, "user5", "wrongPassword", i18n.Tr("en", "form.username_password_incorrect"))
+	testLoginFailed(t, "user5", "wrongPassword", i18n.Tr("en", "form.username_password_incorrect"))
+	testLoginLocked(t, "user5", userPassword, "")
+	models.AssertNotExistsBean(t, &models.LoginLockout{Type: models.LoginLockoutAccount, Target: "user5"})
+}
+
+func TestSigninLockoutIP(t *testing.T) {
+	defer prepareTestEnv(t)()
+	defer func(old bool) { setting.LoginLockout.Enabled = old }(setting.LoginLockout.Enabled)
+	setting.LoginLockout.Enabled = true
+	setting.LoginLockout.MaxAccountAttempts = 10
+	setting.LoginLockout.MaxIPAttempts = 3
+	setting.LoginLockout.DelayAfterAttempts = 100
+
+	const remoteAddr = "192.0.2.10"
+	for _, username := range []string{"user2", "user4", "user5"} {
+		req := NewRequest(t, "GET", "/api/v1/user")
+		req.SetBasicAuth(username, "wrongPassword")
+		req.Header.Set("X-Real-IP", remoteAddr)
+		MakeRequest(t, req, http.StatusUnauthorized)
+	}
+	l := models.AssertExistsAndLoadBean(t, &models.LoginLockout{Type: models.LoginLockoutIP, Target: remoteAddr}).(*models.LoginLockout)
+	defer func() {
+		assert.NoError(t, lockout.Clear(l))
+		for _, username := range []string{"user2", "user4", "user5"} {
+			lockout.Succeed(username)
+		}
+	}()
+
+	testLoginLocked(t, "user8", userPassword, remoteAddr)
+	// Requests from other addresses are not affected
+	loginUserWithPassword(t, "user8", userPassword)
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgTwoFactorRequired(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	// Owners must enroll before they can require two-factor authentication
+	req := NewRequestWithValues(t, "POST", "/org/user3/settings", map[string]string{
+		"_csrf":              GetCSRF(t, session, "/org/user3/settings"),
+		"name":               "user3",
+		"require_two_factor": "on",
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "You must enroll into two-factor authentication yourself")
+	org := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	assert.False(t, org.RequireTwoFactor)
+
+	org.RequireTwoFactor = true
+	assert.NoError(t, models.UpdateUserCols(org, "require_two_factor"))
+
+	// private repository of the organization
+	resp = session.MakeRequest(t, NewRequest(t, "GET", "/user3/repo3"), http.StatusForbidden)
+	assert.Contains(t, resp.Body.String(), "requires two-factor authentication to access its repositories")
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user3/repo3?token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusForbidden)
+	assert.Contains(t, resp.Body.String(), "organization user3 requires two-factor authentication")
+
+	req = NewRequest(t, "GET", "/user3/repo3.git/info/refs")
+	req.SetBasicAuth("user2", token)
+	resp = MakeRequest(t, req, http.StatusForbidden)
+	assert.Contains(t, resp.Body.String(), "organization user3 requires two-factor authentication")
+
+	// non-members are not told that the private repository exists
+	strangerSession := loginUser(t, "user5")
+	strangerSession.MakeRequest(t, NewRequest(t, "GET", "/user3/repo3"), http.StatusNotFound)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user3/repo3?token=%s", getTokenForLoggedInUser(t, strangerSession))
+	strangerSession.MakeRequest(t, req, http.StatusNotFound)
+
+	// the security settings list the organization
+	resp = session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/security"), http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, "user3", htmlDoc.doc.Find(".warning.message a").Text())
+
+	// admins can find the members without two-factor authentication
+	adminSession := loginUser(t, "user1")
+	resp = adminSession.MakeRequest(t, NewRequest(t, "GET", "/admin/twofa?type=org_members"), http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 3, htmlDoc.doc.Find("table tbody tr").Length())
+	assert.Contains(t, htmlDoc.doc.Find("table tbody").Text(), "user2")
+
+	// access is granted again after enrolling
+	assert.NoError(t, models.NewTwoFactor(&models.TwoFactor{UID: 2}))
+	session.MakeRequest(t, NewRequest(t, "GET", "/user3/repo3"), http.StatusOK)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user3/repo3?token=%s", token)
+	session.MakeRequest(t, req, http.StatusOK)
+}
+
+func TestInstanceTwoFactorRequired(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	defer func(old string) { setting.Service.RequireTwoFactor = old }(setting.Service.RequireTwoFactor)
+	setting.Service.RequireTwoFactor = setting.TwoFactorRequiredAll
+
+	resp := session.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1"), http.StatusFound)
+	assert.EqualValues(t, "/user/settings/security", resp.Header().Get("Location"))
+	resp = session.MakeRequest(t, NewRequest(t, "GET", "/user/settings/security"), http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "The site administrator requires you to enroll into two-factor authentication")
+
+	req := NewRequestf(t, "GET", "/api/v1/user?token=%s", token)
+	resp = MakeRequest(t, req, http.StatusForbidden)
+	assert.Contains(t, resp.Body.String(), "two-factor authentication is required")
+
+	req = NewRequest(t, "GET", "/user2/repo1.git/info/refs")
+	req.SetBasicAuth("user2", token)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// Anonymous access is not affected
+	MakeRequest(t, NewRequest(t, "GET", "/user2/repo1"), http.StatusOK)
+
+	setting.Service.RequireTwoFactor = setting.TwoFactorRequiredAdmins
+	session.MakeRequest(t, NewRequest(t, "GET", "/user2/repo1"), http.StatusOK)
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// AuditAction represents the kind of a security-relevant event
+type AuditAction string
+
+// All audited actions
+const (
+	AuditUserLogin       AuditAction = "user.login"
+	AuditUserLoginFailed AuditAction = "user.login_failed"
+
+	AuditUserAccessTokenCreate AuditAction = "user.access_token.create"
+	AuditUserAccessTokenDelete AuditAction = "user.access_token.delete"
+	AuditUserKeyAdd            AuditAction = "user.key.add"
+	AuditUserKeyDelete         AuditAction = "user.key.delete"
+	AuditUserGPGKeyAdd         AuditAction = "user.gpg_key.add"
+	AuditUserGPGKeyDelete      AuditAction = "user.gpg_key.delete"
+	AuditUserTwoFactorEnroll   AuditAction = "user.two_factor.enroll"
+	AuditUserTwoFactorDisable  AuditAction = "user.two_factor.disable"
+	AuditUserTwoFactorScratch  AuditAction = "user.two_factor.regenerate_scratch"
+	AuditUserU2FRegister       AuditAction = "user.u2f.register"
+	AuditUserU2FDelete         AuditAction = "user.u2f.delete"
+
+	AuditRepoVisibility           AuditAction = "repo.visibility"
+	AuditRepoTransfer             AuditAction = "repo.transfer"
+	AuditRepoDelete               AuditAction = "repo.delete"
+	AuditRepoCollaboratorAdd      AuditAction = "repo.collaborator.add"
+	AuditRepoCollaboratorMode     AuditAction = "repo.collaborator.mode"
+	AuditRepoCollaboratorRemove   AuditAction = "repo.collaborator.remove"
+	AuditRepoDeployKeyAdd         AuditAction = "repo.deploy_key.add"
+	AuditRepoDeployKeyDelete      AuditAction = "repo.deploy_key.delete"
+	AuditRepoBranchProtection     AuditAction = "repo.branch_protection.update"
+	AuditRepoBranchProtectionDrop AuditAction = "repo.branch_protection.delete"
+	AuditRepoCIRunnerTokenReset   AuditAction = "repo.ci_runner_token.reset"
+	AuditRepoCIRunnerDelete       AuditAction = "repo.ci_runner.delete"
+
+	AuditWebhookCreate AuditAction = "webhook.create"
+	AuditWebhookUpdate AuditAction = "webhook.update"
+	AuditWebhookDelete AuditAction = "webhook.delete"
+
+	AuditOrgSettings      AuditAction = "org.settings"
+	AuditTeamCreate       AuditAction = "team.create"
+	AuditTeamUpdate       AuditAction = "team.update"
+	AuditTeamDelete       AuditAction = "team.delete"
+	AuditTeamMemberAdd    AuditAction = "team.member.add"
+	AuditTeamMemberRemove AuditAction = "team.member.remove"
+	AuditTeamRepoAdd      AuditAction = "team.repo.add"
+	AuditTeamRepoRemove   AuditAction = "team.repo.remove"
+	AuditOrgMemberRemove  AuditAction = "org.member.remove"
+
+	AuditAdminUserCreate         AuditAction = "admin.user.create"
+	AuditAdminUserUpdate         AuditAction = "admin.user.update"
+	AuditAdminUserDelete         AuditAction = "admin.user.delete"
+	AuditAdminOrgCreate          AuditAction = "admin.org.create"
+	AuditAdminAuthSourceCreate   AuditAction = "admin.auth_source.create"
+	AuditAdminAuthSourceUpdate   AuditAction = "admin.auth_source.update"
+	AuditAdminAuthSourceDelete   AuditAction = "admin.auth_source.delete"
+	AuditAdminSSHCAAdd           AuditAction = "admin.ssh_ca.add"
+	AuditAdminSSHCADelete        AuditAction = "admin.ssh_ca.delete"
+	AuditAdminLockoutClear       AuditAction = "admin.lockout.clear"
+	AuditAdminOperation          AuditAction = "admin.operation"
+	AuditAdminCIRunnerTokenReset AuditAction = "admin.ci_runner_token.reset"
+	AuditAdminCIRunnerDelete     AuditAction = "admin.ci_runner.delete"
+)
+
+// AuditChange holds the old and the new value of a changed field
+type AuditChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// AuditEvent represents a security-relevant event. Events are only ever appended.
+type AuditEvent struct {
+	ID         int64       `xorm:"pk autoincr"`
+	Action     AuditAction `xorm:"VARCHAR(50) INDEX NOT NULL"`
+	ActorID    int64       `xorm:"INDEX"`
+	ActorName  string
+	IPAddress  string
+	TargetType string `xorm:"VARCHAR(50) INDEX"`
+	TargetID   int64  `xorm:"INDEX"`
+	TargetName string
+	// Diff is a JSON object describing the change, changed fields hold an AuditChange
+	Diff        string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// DiffMap decodes the diff of the event
+func (e *AuditEvent) DiffMap() map[string]interface{} {
+	if len(e.Diff) == 0 {
+		return nil
+	}
+	diff := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(e.Diff), &diff); err != nil {
+		return map[string]interface{}{"diff": e.Diff}
+	}
+	return diff
+}
+
+// CreateAuditEvent appends an event to the audit log
+func CreateAuditEvent(e *AuditEvent) error {
+	_, err := x.Insert(e)
+	return err
+}
+
+// FindAuditEventsOptions represents the options to search audit events
+type FindAuditEventsOptions struct {
+	ListOptions
+	Action     AuditAction
+	ActorID    int64
+	ActorName  string
+	TargetType string
+	TargetID   int64
+	Since      timeutil.TimeStamp
+	Before     timeutil.TimeStamp
+}
+
+func (opts *FindAuditEventsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if len(opts.Action) > 0 {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	if opts.ActorID > 0 {
+		cond = cond.And(builder.Eq{"actor_id": opts.ActorID})
+	}
+	if len(opts.ActorName) > 0 {
+		cond = cond.And(builder.Eq{"actor_name": opts.ActorName})
+	}
+	if len(opts.TargetType) > 0 {
+		cond = cond.And(builder.Eq{"target_type": opts.TargetType})
+	}
+	if opts.TargetID > 0 {
+		cond = cond.And(builder.Eq{"target_id": opts.TargetID})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	return cond
+}
+
+// FindAuditEvents returns the audit events matching the options, the most recent first
+func FindAuditEvents(opts *FindAuditEventsOptions) ([]*AuditEvent, int64, error) {
+	cond := opts.toConds()
+	count, err := x.Where(cond).Count(new(AuditEvent))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).Desc("id")
+	if opts.Page != 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	events := make([]*AuditEvent, 0, opts.PageSize)
+	return events, count, sess.Find(&events)
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindAuditEvents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, CreateAuditEvent(&AuditEvent{Action: AuditUserLogin, ActorID: 2, ActorName: "user2", TargetType: "user", TargetID: 2, TargetName: "user2"}))
+	assert.NoError(t, CreateAuditEvent(&AuditEvent{Action: AuditRepoDelete, ActorID: 2, ActorName: "user2", TargetType: "repo", TargetID: 1, TargetName: "user2/repo1"}))
+	assert.NoError(t, CreateAuditEvent(&AuditEvent{Action: AuditUserLoginFailed, TargetType: "user", TargetName: "user5", Diff: `{"reason":"invalid_credentials"}`}))
+
+	events, count, err := FindAuditEvents(&FindAuditEventsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, AuditUserLoginFailed, events[0].Action)
+		assert.Equal(t, map[string]interface{}{"reason": "invalid_credentials"}, events[0].DiffMap())
+		assert.Equal(t, AuditUserLogin, events[2].Action)
+		assert.Nil(t, events[2].DiffMap())
+	}
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{ActorName: "user2"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, events, 2)
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{TargetType: "user"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, events, 2)
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{Action: AuditRepoDelete, TargetID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "user2/repo1", events[0].TargetName)
+	}
+
+	events, count, err = FindAuditEvents(&FindAuditEventsOptions{ListOptions: ListOptions{Page: 2, PageSize: 2}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	assert.Len(t, events, 1)
+}
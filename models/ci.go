@@ -0,0 +1,597 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/satori/go.uuid"
+	"xorm.io/builder"
+)
+
+// CIStatus represents the state of a CI run, job or step
+type CIStatus int
+
+// Enumerate all the CI states
+const (
+	CIStatusWaiting   CIStatus = iota + 1 // 1 waits for a runner
+	CIStatusBlocked                       // 2 waits for the jobs it needs
+	CIStatusRunning                       // 3
+	CIStatusSuccess                       // 4
+	CIStatusFailure                       // 5
+	CIStatusCancelled                     // 6
+	CIStatusSkipped                       // 7 not run because a needed job failed
+)
+
+var ciStatusNames = map[CIStatus]string{
+	CIStatusWaiting:   "waiting",
+	CIStatusBlocked:   "blocked",
+	CIStatusRunning:   "running",
+	CIStatusSuccess:   "success",
+	CIStatusFailure:   "failure",
+	CIStatusCancelled: "cancelled",
+	CIStatusSkipped:   "skipped",
+}
+
+// String returns the name of the status
+func (s CIStatus) String() string {
+	return ciStatusNames[s]
+}
+
+// IsDone returns true if the status is final
+func (s CIStatus) IsDone() bool {
+	return s >= CIStatusSuccess
+}
+
+// ParseCIStatus returns the status with the given name, 0 if there is none
+func ParseCIStatus(name string) CIStatus {
+	for s, n := range ciStatusNames {
+		if n == name {
+			return s
+		}
+	}
+	return 0
+}
+
+// CIRun represents a run of a workflow triggered by an event of a repository
+type CIRun struct {
+	ID            int64       `xorm:"pk autoincr"`
+	RepoID        int64       `xorm:"UNIQUE(repo_index) INDEX NOT NULL"`
+	Repo          *Repository `xorm:"-"`
+	Index         int64       `xorm:"UNIQUE(repo_index) NOT NULL"`
+	WorkflowID    string      `xorm:"NOT NULL"` // file name of the workflow
+	WorkflowName  string
+	Title         string
+	TriggerUserID int64  `xorm:"INDEX"`
+	TriggerUser   *User  `xorm:"-"`
+	Event         string `xorm:"NOT NULL"`
+	Ref           string `xorm:"NOT NULL"`
+	CommitSHA     string `xorm:"VARCHAR(40) INDEX NOT NULL"`
+	IsForkPull    bool   `xorm:"INDEX NOT NULL DEFAULT false"` // runs code of a pull request from a fork
+	NeedApproval  bool   `xorm:"NOT NULL DEFAULT false"`       // its jobs wait for a writer of the repository
+	ApprovedByID  int64
+	Status        CIStatus           `xorm:"INDEX NOT NULL"`
+	StartedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+	StoppedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// CIJob represents a job of a run, it is run by a single runner whose labels contain
+// all the labels of the job. The runner clones the repository with the token of the job
+// while it runs.
+type CIJob struct {
+	ID             int64             `xorm:"pk autoincr"`
+	RunID          int64             `xorm:"INDEX NOT NULL"`
+	Run            *CIRun            `xorm:"-"`
+	RepoID         int64             `xorm:"INDEX NOT NULL"`
+	JobID          string            `xorm:"NOT NULL"` // key of the job in the workflow
+	Name           string            `xorm:"NOT NULL"`
+	Needs          []string          `xorm:"TEXT JSON"`
+	Labels         []string          `xorm:"TEXT JSON"`
+	Env            map[string]string `xorm:"TEXT JSON"`
+	Status         CIStatus          `xorm:"INDEX NOT NULL"`
+	RunnerID       int64             `xorm:"INDEX"`
+	Steps          []*CIStep         `xorm:"-"`
+	Token          string            `xorm:"-"`
+	TokenHash      string
+	TokenSalt      string
+	TokenLastEight string `xorm:"INDEX token_last_eight"`
+	StartedUnix    timeutil.TimeStamp
+	StoppedUnix    timeutil.TimeStamp
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// CIStep represents a shell command of a job, LogLength is the number of lines of its log
+type CIStep struct {
+	ID          int64             `xorm:"pk autoincr"`
+	JobID       int64             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Index       int               `xorm:"UNIQUE(s) NOT NULL"`
+	Name        string            `xorm:"NOT NULL"`
+	Command     string            `xorm:"TEXT"`
+	Env         map[string]string `xorm:"TEXT JSON"`
+	Status      CIStatus          `xorm:"NOT NULL"`
+	LogLength   int64             `xorm:"NOT NULL DEFAULT 0"`
+	LogSize     int64             `xorm:"NOT NULL DEFAULT 0"`
+	StartedUnix timeutil.TimeStamp
+	StoppedUnix timeutil.TimeStamp
+}
+
+// Duration returns how many seconds the run has been running
+func (run *CIRun) Duration() int64 {
+	return ciDuration(run.StartedUnix, run.StoppedUnix)
+}
+
+// Duration returns how many seconds the job has been running
+func (job *CIJob) Duration() int64 {
+	return ciDuration(job.StartedUnix, job.StoppedUnix)
+}
+
+func ciDuration(started, stopped timeutil.TimeStamp) int64 {
+	if started == 0 {
+		return 0
+	} else if stopped == 0 {
+		stopped = timeutil.TimeStampNow()
+	}
+	return int64(stopped - started)
+}
+
+// RefName returns the name of the branch or tag of the run, or its full ref for pull
+// requests
+func (run *CIRun) RefName() string {
+	if strings.HasPrefix(run.Ref, git.BranchPrefix) {
+		return strings.TrimPrefix(run.Ref, git.BranchPrefix)
+	}
+	return strings.TrimPrefix(run.Ref, git.TagPrefix)
+}
+
+// HTMLURL returns the URL of the run page
+func (run *CIRun) HTMLURL() string {
+	return fmt.Sprintf("%s/ci/runs/%d", run.Repo.HTMLURL(), run.Index)
+}
+
+// CILogDir returns the directory holding the logs of the CI jobs of the repository
+func CILogDir(repoID int64) string {
+	return filepath.Join(setting.CI.LogPath, strconv.FormatInt(repoID, 10))
+}
+
+// LogPath returns the path of the log file of a step of the job
+func (job *CIJob) LogPath(step int) string {
+	return filepath.Join(CILogDir(job.RepoID), strconv.FormatInt(job.ID, 10), strconv.Itoa(step)+".log")
+}
+
+// LoadAttributes loads the repository and the user who triggered the run
+func (run *CIRun) LoadAttributes() (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = GetRepositoryByID(run.RepoID); err != nil {
+			return err
+		}
+	}
+	if run.TriggerUser == nil {
+		if run.TriggerUser, err = GetUserByID(run.TriggerUserID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			run.TriggerUser = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// LoadRun loads the run of the job with its attributes
+func (job *CIJob) LoadRun() (err error) {
+	if job.Run == nil {
+		if job.Run, err = GetCIRunByID(job.RunID); err != nil {
+			return err
+		}
+	}
+	return job.Run.LoadAttributes()
+}
+
+// CreateCIRun creates the run with its jobs and their steps, the run gets the next index
+// of its repository. The jobs of a run needing approval stay blocked until it is approved.
+func CreateCIRun(run *CIRun, jobs []*CIJob) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	var maxIndex int64
+	if _, err := sess.Table("ci_run").Where("repo_id = ?", run.RepoID).Select("COALESCE(MAX(`index`), 0)").Get(&maxIndex); err != nil {
+		return err
+	}
+	run.Index = maxIndex + 1
+	run.Status = CIStatusWaiting
+	if _, err := sess.Insert(run); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		job.RunID = run.ID
+		job.RepoID = run.RepoID
+		job.Status = CIStatusWaiting
+		if len(job.Needs) > 0 || run.NeedApproval {
+			job.Status = CIStatusBlocked
+		}
+		if _, err := sess.Insert(job); err != nil {
+			return err
+		}
+		for i, step := range job.Steps {
+			step.JobID = job.ID
+			step.Index = i
+			step.Status = CIStatusWaiting
+			if _, err := sess.Insert(step); err != nil {
+				return err
+			}
+		}
+		job.Run = run
+	}
+	return sess.Commit()
+}
+
+// GetCIRunByID returns the run with the given id
+func GetCIRunByID(id int64) (*CIRun, error) {
+	run := new(CIRun)
+	has, err := x.ID(id).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIRunNotExist{ID: id}
+	}
+	return run, nil
+}
+
+// GetCIRunByIndex returns the run of the repository with the given index
+func GetCIRunByIndex(repoID, index int64) (*CIRun, error) {
+	run := &CIRun{RepoID: repoID, Index: index}
+	has, err := x.Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIRunNotExist{RepoID: repoID, Index: index}
+	}
+	return run, nil
+}
+
+// FindCIRunsOptions represents the options to search the runs of a repository
+type FindCIRunsOptions struct {
+	ListOptions
+	RepoID int64
+	Status CIStatus
+}
+
+// FindCIRuns returns the runs matching the options, the most recent first
+func FindCIRuns(opts *FindCIRunsOptions) ([]*CIRun, int64, error) {
+	cond := builder.NewCond().And(builder.Eq{"repo_id": opts.RepoID})
+	if opts.Status > 0 {
+		cond = cond.And(builder.Eq{"status": opts.Status})
+	}
+
+	count, err := x.Where(cond).Count(new(CIRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).Desc("id")
+	if opts.Page != 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	runs := make([]*CIRun, 0, opts.PageSize)
+	return runs, count, sess.Find(&runs)
+}
+
+// GetCIJobByID returns the job with the given id
+func GetCIJobByID(id int64) (*CIJob, error) {
+	job := new(CIJob)
+	has, err := x.ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIJobNotExist{ID: id}
+	}
+	return job, nil
+}
+
+// GetCIJobs returns the jobs of the run in the order of the workflow
+func GetCIJobs(runID int64) ([]*CIJob, error) {
+	return getCIJobs(x, runID)
+}
+
+func getCIJobs(e Engine, runID int64) ([]*CIJob, error) {
+	jobs := make([]*CIJob, 0, 5)
+	return jobs, e.Where("run_id = ?", runID).Asc("id").Find(&jobs)
+}
+
+// LoadSteps loads the steps of the job
+func (job *CIJob) LoadSteps() (err error) {
+	if job.Steps == nil {
+		job.Steps = make([]*CIStep, 0, 5)
+		err = x.Where("job_id = ?", job.ID).Asc("`index`").Find(&job.Steps)
+	}
+	return err
+}
+
+// UpdateCIStep updates the state and the log length of the step
+func UpdateCIStep(step *CIStep) error {
+	_, err := x.ID(step.ID).Cols("status", "log_length", "log_size", "started_unix", "stopped_unix").Update(step)
+	return err
+}
+
+// AssignCIJob assigns the oldest waiting job the runner can run to the runner and starts
+// it, it returns nil if there is no such job. Runners of a repository never run the code
+// of pull requests from forks.
+func AssignCIJob(runner *CIRunner) (*CIJob, error) {
+	cond := builder.NewCond().And(builder.Eq{"status": CIStatusWaiting})
+	if runner.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": runner.RepoID}).
+			And(builder.NotIn("run_id", builder.Select("id").From("ci_run").Where(builder.Eq{"is_fork_pull": true})))
+	}
+
+	for start := 0; ; start += 50 {
+		jobs := make([]*CIJob, 0, 50)
+		if err := x.Where(cond).Asc("id").Limit(50, start).Find(&jobs); err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			if !runner.CanRun(job) {
+				continue
+			}
+			if job, err := startCIJob(runner, job); err != nil || job != nil {
+				return job, err
+			}
+		}
+		if len(jobs) < 50 {
+			return nil, nil
+		}
+	}
+}
+
+// startCIJob starts the job on the runner unless another runner was faster, the job gets
+// a new token
+func startCIJob(runner *CIRunner, job *CIJob) (*CIJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+	job.Status = CIStatusRunning
+	job.RunnerID = runner.ID
+	job.StartedUnix = timeutil.TimeStampNow()
+	job.TokenSalt = salt
+	job.Token = base.EncodeSha1(gouuid.NewV4().String())
+	job.TokenHash = hashToken(job.Token, job.TokenSalt)
+	job.TokenLastEight = job.Token[len(job.Token)-8:]
+	affected, err := sess.ID(job.ID).And("status = ?", CIStatusWaiting).
+		Cols("status", "runner_id", "started_unix", "token_hash", "token_salt", "token_last_eight").Update(job)
+	if err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, nil
+	}
+
+	if _, err = sess.Table("ci_run").ID(job.RunID).And("status = ?", CIStatusWaiting).
+		Update(map[string]interface{}{"status": CIStatusRunning, "started_unix": job.StartedUnix}); err != nil {
+		return nil, err
+	}
+	return job, sess.Commit()
+}
+
+// GetRunningCIJobByToken returns the running job authenticating with the token
+func GetRunningCIJobByToken(token string) (*CIJob, error) {
+	if len(token) < 8 {
+		return nil, ErrCIJobNotExist{}
+	}
+	var jobs []*CIJob
+	if err := x.Where("token_last_eight = ? AND status = ?", token[len(token)-8:], CIStatusRunning).Find(&jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		if subtle.ConstantTimeCompare([]byte(job.TokenHash), []byte(hashToken(token, job.TokenSalt))) == 1 {
+			return job, nil
+		}
+	}
+	return nil, ErrCIJobNotExist{}
+}
+
+// FinishCIJob stops the job with the status and schedules the jobs depending on it, it
+// returns the jobs whose status changed including the job itself
+func FinishCIJob(job *CIJob, status CIStatus) ([]*CIJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if err := stopCIJob(sess, job, status); err != nil {
+		return nil, err
+	}
+	changed, err := scheduleCIJobs(sess, job.RunID)
+	if err != nil {
+		return nil, err
+	}
+	if err = updateCIRunStatus(sess, job.RunID); err != nil {
+		return nil, err
+	}
+	return append([]*CIJob{job}, changed...), sess.Commit()
+}
+
+// ApproveCIRun lets the jobs of the run needing approval run, it returns the jobs whose
+// status changed
+func ApproveCIRun(run *CIRun, doer *User) ([]*CIJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	run.NeedApproval = false
+	run.ApprovedByID = doer.ID
+	affected, err := sess.ID(run.ID).And("need_approval = ?", true).Cols("need_approval", "approved_by_id").Update(run)
+	if err != nil {
+		return nil, err
+	} else if affected == 0 {
+		return nil, nil
+	}
+	changed, err := scheduleCIJobs(sess, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	return changed, sess.Commit()
+}
+
+// CancelCIRun cancels the jobs of the run which are not done yet and returns them
+func CancelCIRun(run *CIRun) ([]*CIJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	jobs, err := getCIJobs(sess, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	cancelled := make([]*CIJob, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Status.IsDone() {
+			continue
+		}
+		if err = stopCIJob(sess, job, CIStatusCancelled); err != nil {
+			return nil, err
+		}
+		cancelled = append(cancelled, job)
+	}
+	if err = updateCIRunStatus(sess, run.ID); err != nil {
+		return nil, err
+	}
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	latest, err := GetCIRunByID(run.ID)
+	if err != nil {
+		return nil, err
+	}
+	run.Status, run.StoppedUnix = latest.Status, latest.StoppedUnix
+	return cancelled, nil
+}
+
+// stopCIJob sets the final status of the job, steps not done yet are cancelled or skipped
+// if the job did not start them
+func stopCIJob(e Engine, job *CIJob, status CIStatus) error {
+	job.Status = status
+	job.StoppedUnix = timeutil.TimeStampNow()
+	if _, err := e.ID(job.ID).Cols("status", "stopped_unix").Update(job); err != nil {
+		return err
+	}
+
+	if _, err := e.Table("ci_step").Where("job_id = ? AND status = ?", job.ID, CIStatusRunning).
+		Update(map[string]interface{}{"status": CIStatusCancelled, "stopped_unix": job.StoppedUnix}); err != nil {
+		return err
+	}
+	_, err := e.Table("ci_step").Where("job_id = ? AND status = ?", job.ID, CIStatusWaiting).
+		Update(map[string]interface{}{"status": CIStatusSkipped})
+	return err
+}
+
+// scheduleCIJobs queues the blocked jobs of the run whose needed jobs succeeded and skips
+// the ones whose needed jobs did not
+func scheduleCIJobs(e Engine, runID int64) ([]*CIJob, error) {
+	jobs, err := getCIJobs(e, runID)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[string]*CIJob, len(jobs))
+	for _, job := range jobs {
+		byKey[job.JobID] = job
+	}
+
+	var changed []*CIJob
+	for again := true; again; {
+		again = false
+		for _, job := range jobs {
+			if job.Status != CIStatusBlocked {
+				continue
+			}
+			status := CIStatusWaiting
+			for _, need := range job.Needs {
+				if needed := byKey[need]; needed == nil || !needed.Status.IsDone() {
+					status = CIStatusBlocked
+					break
+				} else if needed.Status != CIStatusSuccess {
+					status = CIStatusSkipped
+				}
+			}
+			if status == CIStatusBlocked {
+				continue
+			}
+
+			if status == CIStatusSkipped {
+				if err = stopCIJob(e, job, status); err != nil {
+					return nil, err
+				}
+				// Skipped jobs may decide the jobs depending on them
+				again = true
+			} else {
+				job.Status = status
+				if _, err = e.ID(job.ID).Cols("status").Update(job); err != nil {
+					return nil, err
+				}
+			}
+			changed = append(changed, job)
+		}
+	}
+	return changed, nil
+}
+
+// updateCIRunStatus derives the status of the run from its jobs
+func updateCIRunStatus(e Engine, runID int64) error {
+	jobs, err := getCIJobs(e, runID)
+	if err != nil {
+		return err
+	}
+
+	status := CIStatusSuccess
+	for _, job := range jobs {
+		if !job.Status.IsDone() {
+			status = CIStatusRunning
+			break
+		}
+		switch job.Status {
+		case CIStatusFailure:
+			status = CIStatusFailure
+		case CIStatusCancelled:
+			if status != CIStatusFailure {
+				status = CIStatusCancelled
+			}
+		}
+	}
+
+	run := &CIRun{Status: status}
+	cols := []string{"status"}
+	if status.IsDone() {
+		run.StoppedUnix = timeutil.TimeStampNow()
+		cols = append(cols, "stopped_unix")
+	}
+	_, err = e.ID(runID).Cols(cols...).Update(run)
+	return err
+}
@@ -0,0 +1,200 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+// CIRunnerToken represents the token runners register with. Tokens of a repository
+// register runners of the repository, tokens without repository register runners of the
+// whole instance. Only the latest token of a repository is active, only its hash is
+// stored so the token itself can only be read once created.
+type CIRunnerToken struct {
+	ID             int64  `xorm:"pk autoincr"`
+	RepoID         int64  `xorm:"INDEX"`
+	Token          string `xorm:"-"`
+	TokenHash      string `xorm:"UNIQUE"`
+	TokenSalt      string
+	TokenLastEight string             `xorm:"INDEX token_last_eight"`
+	IsActive       bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+}
+
+// CIRunner represents a runner picking up the jobs of a repository or of all repositories,
+// it authenticates with a token handed out on registration
+type CIRunner struct {
+	ID             int64    `xorm:"pk autoincr"`
+	UUID           string   `xorm:"CHAR(36) UNIQUE NOT NULL"`
+	Name           string   `xorm:"NOT NULL"`
+	RepoID         int64    `xorm:"INDEX"`
+	Labels         []string `xorm:"TEXT JSON"`
+	Token          string   `xorm:"-"`
+	TokenHash      string   `xorm:"UNIQUE"`
+	TokenSalt      string
+	TokenLastEight string             `xorm:"INDEX token_last_eight"`
+	LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// IsOnline returns true if the runner asked for jobs recently
+func (r *CIRunner) IsOnline() bool {
+	return r.LastOnlineUnix.AddDuration(time.Minute) > timeutil.TimeStampNow()
+}
+
+// CanRun returns true if the runner has all the labels the job requires
+func (r *CIRunner) CanRun(job *CIJob) bool {
+	if r.RepoID > 0 && r.RepoID != job.RepoID {
+		return false
+	}
+	for _, label := range job.Labels {
+		found := false
+		for _, l := range r.Labels {
+			if l == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCIRunnerToken returns the active registration token of the repository or of the
+// instance if repoID is 0, it is created on first use. Token is only set if it was just
+// created.
+func GetCIRunnerToken(repoID int64) (*CIRunnerToken, error) {
+	t := new(CIRunnerToken)
+	has, err := x.Where("repo_id = ? AND is_active = ?", repoID, true).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return NewCIRunnerToken(repoID)
+	}
+	return t, nil
+}
+
+// NewCIRunnerToken replaces the registration token of the repository or of the instance,
+// runners registered before keep working
+func NewCIRunnerToken(repoID int64) (*CIRunnerToken, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if _, err := sess.Where("repo_id = ?", repoID).Cols("is_active").Update(&CIRunnerToken{IsActive: false}); err != nil {
+		return nil, err
+	}
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+	t := &CIRunnerToken{RepoID: repoID, TokenSalt: salt, IsActive: true}
+	t.Token = base.EncodeSha1(gouuid.NewV4().String())
+	t.TokenHash = hashToken(t.Token, t.TokenSalt)
+	t.TokenLastEight = t.Token[len(t.Token)-8:]
+	if _, err = sess.Insert(t); err != nil {
+		return nil, err
+	}
+	return t, sess.Commit()
+}
+
+// GetActiveCIRunnerToken returns the active registration token with the given value
+func GetActiveCIRunnerToken(token string) (*CIRunnerToken, error) {
+	if len(token) < 8 {
+		return nil, ErrCIRunnerTokenNotExist{}
+	}
+	var tokens []*CIRunnerToken
+	if err := x.Where("token_last_eight = ? AND is_active = ?", token[len(token)-8:], true).Find(&tokens); err != nil {
+		return nil, err
+	}
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(hashToken(token, t.TokenSalt))) == 1 {
+			return t, nil
+		}
+	}
+	return nil, ErrCIRunnerTokenNotExist{}
+}
+
+// RegisterCIRunner creates the runner with a new UUID and token
+func RegisterCIRunner(r *CIRunner) error {
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return err
+	}
+	r.UUID = gouuid.NewV4().String()
+	r.TokenSalt = salt
+	r.Token = base.EncodeSha1(gouuid.NewV4().String())
+	r.TokenHash = hashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err = x.Insert(r)
+	return err
+}
+
+// GetCIRunnerByToken returns the runner authenticating with the token
+func GetCIRunnerByToken(token string) (*CIRunner, error) {
+	if len(token) < 8 {
+		return nil, ErrCIRunnerNotExist{}
+	}
+	var runners []*CIRunner
+	if err := x.Where("token_last_eight = ?", token[len(token)-8:]).Find(&runners); err != nil {
+		return nil, err
+	}
+	for _, r := range runners {
+		if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(hashToken(token, r.TokenSalt))) == 1 {
+			return r, nil
+		}
+	}
+	return nil, ErrCIRunnerNotExist{}
+}
+
+// GetCIRunnerByID returns the runner with the given id
+func GetCIRunnerByID(id int64) (*CIRunner, error) {
+	r := new(CIRunner)
+	has, err := x.ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrCIRunnerNotExist{ID: id}
+	}
+	return r, nil
+}
+
+// GetCIRunners returns the runners of the repository or of the instance if repoID is 0
+func GetCIRunners(repoID int64) ([]*CIRunner, error) {
+	runners := make([]*CIRunner, 0, 5)
+	return runners, x.Where("repo_id = ?", repoID).Asc("id").Find(&runners)
+}
+
+// UpdateCIRunnerLastOnline records that the runner asked for jobs
+func UpdateCIRunnerLastOnline(r *CIRunner) error {
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err := x.ID(r.ID).Cols("last_online_unix").NoAutoTime().Update(r)
+	return err
+}
+
+// DeleteCIRunner deletes the runner, jobs it is running are left to the caller
+func DeleteCIRunner(r *CIRunner) error {
+	_, err := x.ID(r.ID).Delete(new(CIRunner))
+	return err
+}
+
+// GetRunningCIJobs returns the jobs the runner is running
+func GetRunningCIJobs(runnerID int64) ([]*CIJob, error) {
+	jobs := make([]*CIJob, 0, 1)
+	return jobs, x.Where("runner_id = ? AND status = ?", runnerID, CIStatusRunning).Find(&jobs)
+}
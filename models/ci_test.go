@@ -0,0 +1,234 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestCIRun(t *testing.T) (*CIRun, []*CIJob) {
+	run := &CIRun{
+		RepoID:        1,
+		WorkflowID:    "build.yml",
+		WorkflowName:  "build",
+		TriggerUserID: 2,
+		Event:         "push",
+		Ref:           "refs/heads/master",
+		CommitSHA:     "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+	}
+	jobs := []*CIJob{
+		{JobID: "build", Name: "build", Labels: []string{"linux"}, Steps: []*CIStep{{Name: "make", Command: "make"}}},
+		{JobID: "test", Name: "test", Needs: []string{"build"}, Steps: []*CIStep{{Name: "unit", Command: "make test"}, {Name: "lint", Command: "make lint"}}},
+		{JobID: "deploy", Name: "deploy", Needs: []string{"test"}, Steps: []*CIStep{{Name: "deploy", Command: "make deploy"}}},
+	}
+	assert.NoError(t, CreateCIRun(run, jobs))
+	return run, jobs
+}
+
+func TestCreateCIRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run, jobs := createTestCIRun(t)
+	assert.EqualValues(t, 1, run.Index)
+	assert.Equal(t, CIStatusWaiting, run.Status)
+	assert.Equal(t, CIStatusWaiting, jobs[0].Status)
+	assert.Equal(t, CIStatusBlocked, jobs[1].Status)
+
+	run2, _ := createTestCIRun(t)
+	assert.EqualValues(t, 2, run2.Index)
+
+	loaded, err := GetCIRunByIndex(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, run2.ID, loaded.ID)
+	_, err = GetCIRunByIndex(1, 3)
+	assert.True(t, IsErrCIRunNotExist(err))
+
+	runs, count, err := FindCIRuns(&FindCIRunsOptions{RepoID: 1, ListOptions: ListOptions{Page: 1, PageSize: 1}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, run2.ID, runs[0].ID)
+
+	job, err := GetCIJobByID(jobs[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"build"}, job.Needs)
+	assert.NoError(t, job.LoadSteps())
+	assert.Len(t, job.Steps, 2)
+	assert.Equal(t, "make lint", job.Steps[1].Command)
+	assert.Equal(t, 1, job.Steps[1].Index)
+}
+
+func TestCIJobLifecycle(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run, jobs := createTestCIRun(t)
+	runner := &CIRunner{Name: "runner", Labels: []string{"linux"}}
+	assert.NoError(t, RegisterCIRunner(runner))
+	other := &CIRunner{Name: "other", RepoID: 2, Labels: []string{"linux"}}
+	assert.NoError(t, RegisterCIRunner(other))
+
+	job, err := AssignCIJob(other)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+
+	job, err = AssignCIJob(runner)
+	assert.NoError(t, err)
+	var token string
+	if assert.NotNil(t, job) {
+		assert.Equal(t, jobs[0].ID, job.ID)
+		assert.Equal(t, CIStatusRunning, job.Status)
+		token = job.Token
+		loaded, err := GetRunningCIJobByToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, job.ID, loaded.ID)
+	}
+	run, err = GetCIRunByID(run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, CIStatusRunning, run.Status)
+
+	job, err = AssignCIJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, job, "blocked jobs must not be assigned")
+
+	changed, err := FinishCIJob(jobs[0], CIStatusSuccess)
+	assert.NoError(t, err)
+	_, err = GetRunningCIJobByToken(token)
+	assert.True(t, IsErrCIJobNotExist(err), "tokens of finished jobs must not be valid")
+	if assert.Len(t, changed, 2) {
+		assert.Equal(t, jobs[1].ID, changed[1].ID)
+		assert.Equal(t, CIStatusWaiting, changed[1].Status)
+	}
+
+	job, err = AssignCIJob(runner)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, jobs[1].ID, job.ID)
+	}
+	running, err := GetRunningCIJobs(runner.ID)
+	assert.NoError(t, err)
+	assert.Len(t, running, 1)
+
+	changed, err = FinishCIJob(job, CIStatusFailure)
+	assert.NoError(t, err)
+	if assert.Len(t, changed, 2) {
+		assert.Equal(t, CIStatusSkipped, changed[1].Status)
+	}
+	assert.NoError(t, job.LoadSteps())
+	for _, step := range job.Steps {
+		assert.Equal(t, CIStatusSkipped, step.Status)
+	}
+
+	run, err = GetCIRunByID(run.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, CIStatusFailure, run.Status)
+	assert.NotZero(t, run.StoppedUnix)
+}
+
+func TestCancelCIRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run, _ := createTestCIRun(t)
+	cancelled, err := CancelCIRun(run)
+	assert.NoError(t, err)
+	assert.Len(t, cancelled, 3)
+	assert.Equal(t, CIStatusCancelled, run.Status)
+
+	cancelled, err = CancelCIRun(run)
+	assert.NoError(t, err)
+	assert.Len(t, cancelled, 0)
+}
+
+func TestApproveCIRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run := &CIRun{
+		RepoID:       1,
+		WorkflowID:   "build.yml",
+		Event:        "pull_request",
+		Ref:          "refs/pull/2/head",
+		CommitSHA:    "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+		IsForkPull:   true,
+		NeedApproval: true,
+	}
+	jobs := []*CIJob{
+		{JobID: "build", Name: "build"},
+		{JobID: "test", Name: "test", Needs: []string{"build"}},
+	}
+	assert.NoError(t, CreateCIRun(run, jobs))
+	assert.Equal(t, CIStatusBlocked, jobs[0].Status)
+
+	runner := &CIRunner{Name: "runner"}
+	assert.NoError(t, RegisterCIRunner(runner))
+	repoRunner := &CIRunner{Name: "repo", RepoID: 1}
+	assert.NoError(t, RegisterCIRunner(repoRunner))
+	job, err := AssignCIJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, job, "jobs needing approval must not be assigned")
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	changed, err := ApproveCIRun(run, doer)
+	assert.NoError(t, err)
+	if assert.Len(t, changed, 1) {
+		assert.Equal(t, jobs[0].ID, changed[0].ID)
+		assert.Equal(t, CIStatusWaiting, changed[0].Status)
+	}
+	loaded := AssertExistsAndLoadBean(t, &CIRun{ID: run.ID, ApprovedByID: doer.ID}).(*CIRun)
+	assert.False(t, loaded.NeedApproval)
+	changed, err = ApproveCIRun(run, doer)
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+
+	// runners of the repository never run the code of forks
+	job, err = AssignCIJob(repoRunner)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+	job, err = AssignCIJob(runner)
+	assert.NoError(t, err)
+	if assert.NotNil(t, job) {
+		assert.Equal(t, jobs[0].ID, job.ID)
+	}
+}
+
+func TestCIRunner(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	token, err := GetCIRunnerToken(1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token.Token)
+	same, err := GetCIRunnerToken(1)
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, same.ID)
+	assert.Empty(t, same.Token, "only the hash of the token is stored")
+	AssertNotExistsBean(t, &CIRunnerToken{TokenHash: token.Token})
+
+	reset, err := NewCIRunnerToken(1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, token.Token, reset.Token)
+	_, err = GetActiveCIRunnerToken(token.Token)
+	assert.True(t, IsErrCIRunnerTokenNotExist(err))
+	active, err := GetActiveCIRunnerToken(reset.Token)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, active.RepoID)
+
+	runner := &CIRunner{Name: "runner", RepoID: 1, Labels: []string{"linux", "docker"}}
+	assert.NoError(t, RegisterCIRunner(runner))
+	assert.Len(t, runner.UUID, 36)
+
+	loaded, err := GetCIRunnerByToken(runner.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, runner.ID, loaded.ID)
+	_, err = GetCIRunnerByToken("invalid-" + runner.Token[8:])
+	assert.True(t, IsErrCIRunnerNotExist(err))
+
+	assert.True(t, runner.CanRun(&CIJob{RepoID: 1, Labels: []string{"docker"}}))
+	assert.False(t, runner.CanRun(&CIJob{RepoID: 1, Labels: []string{"windows"}}))
+	assert.False(t, runner.CanRun(&CIJob{RepoID: 2}))
+
+	assert.NoError(t, DeleteCIRunner(runner))
+	_, err = GetCIRunnerByID(runner.ID)
+	assert.True(t, IsErrCIRunnerNotExist(err))
+}
@@ -0,0 +1,473 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ContainerImage represents an image repository of the container registry. Its full
+// name is the name of the owning user or organization followed by Name.
+type ContainerImage struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Owner       *User              `xorm:"-"`
+	Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+	Tags        []string           `xorm:"-"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// LoadTags loads the tag names of the image
+func (img *ContainerImage) LoadTags() (err error) {
+	if img.Tags != nil {
+		return nil
+	}
+	img.Tags, err = GetContainerTags(img.ID)
+	return err
+}
+
+// LoadOwner loads the owner of the image
+func (img *ContainerImage) LoadOwner() (err error) {
+	if img.Owner != nil {
+		return nil
+	}
+	img.Owner, err = GetUserByID(img.OwnerID)
+	return err
+}
+
+// FullName returns the name of the image including its owner, as used by container clients
+func (img *ContainerImage) FullName() string {
+	if img.Owner == nil {
+		return img.Name
+	}
+	return img.Owner.LowerName + "/" + img.Name
+}
+
+// ContainerBlob links a blob of the content store to an image. Blobs are addressed by
+// their digest and shared between images.
+type ContainerBlob struct {
+	ID          int64              `xorm:"pk autoincr"`
+	ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Digest      string             `xorm:"VARCHAR(100) UNIQUE(s) INDEX NOT NULL"`
+	Size        int64              `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// ContainerManifest represents an image manifest or an image index of an image
+type ContainerManifest struct {
+	ID          int64              `xorm:"pk autoincr"`
+	ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Digest      string             `xorm:"VARCHAR(100) UNIQUE(s) INDEX NOT NULL"`
+	MediaType   string             `xorm:"NOT NULL"`
+	Size        int64              `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// ContainerManifestReference represents a blob or a child manifest referenced by a manifest
+type ContainerManifestReference struct {
+	ID         int64  `xorm:"pk autoincr"`
+	ManifestID int64  `xorm:"INDEX NOT NULL"`
+	Digest     string `xorm:"VARCHAR(100) INDEX NOT NULL"`
+}
+
+// ContainerTag points a tag of an image to a manifest
+type ContainerTag struct {
+	ID          int64              `xorm:"pk autoincr"`
+	ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"VARCHAR(128) UNIQUE(s) NOT NULL"`
+	ManifestID  int64              `xorm:"INDEX NOT NULL"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// ContainerUpload represents a blob upload in progress, the uploaded data is kept by
+// the content store until the upload is completed.
+type ContainerUpload struct {
+	ID          int64              `xorm:"pk autoincr"`
+	UUID        string             `xorm:"uuid UNIQUE NOT NULL"`
+	ImageID     int64              `xorm:"INDEX NOT NULL"`
+	Size        int64              `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// GetContainerImage returns the image of the owner with the given name
+func GetContainerImage(ownerID int64, name string) (*ContainerImage, error) {
+	img := &ContainerImage{OwnerID: ownerID, Name: strings.ToLower(name)}
+	has, err := x.Get(img)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrContainerImageNotExist{OwnerID: ownerID, Name: name}
+	}
+	return img, nil
+}
+
+// GetOrCreateContainerImage returns the image of the owner with the given name, creating it
+// if it does not exist yet
+func GetOrCreateContainerImage(ownerID int64, name string) (*ContainerImage, error) {
+	img, err := GetContainerImage(ownerID, name)
+	if err == nil || !IsErrContainerImageNotExist(err) {
+		return img, err
+	}
+
+	img = &ContainerImage{OwnerID: ownerID, Name: strings.ToLower(name)}
+	if _, err = x.Insert(img); err != nil {
+		// The image may have been created by a concurrent push
+		if existing, getErr := GetContainerImage(ownerID, name); getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return img, nil
+}
+
+// FindContainerImagesOptions represents the options to search container images
+type FindContainerImagesOptions struct {
+	ListOptions
+	OwnerID int64
+	// Actor restricts the results to the images whose owner is visible to the actor
+	Actor *User
+}
+
+func (opts *FindContainerImagesOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"container_image.owner_id": opts.OwnerID})
+	}
+	if opts.Actor == nil {
+		cond = cond.And(builder.Eq{"`user`.visibility": structs.VisibleTypePublic})
+	} else if !opts.Actor.IsAdmin {
+		// Restricted users only see their own images and those of their organizations
+		visible := builder.Or(
+			builder.Eq{"container_image.owner_id": opts.Actor.ID},
+			builder.In("container_image.owner_id", builder.Select("org_id").From("org_user").Where(builder.Eq{"uid": opts.Actor.ID})),
+		)
+		if !opts.Actor.IsRestricted {
+			visible = visible.Or(builder.In("`user`.visibility", structs.VisibleTypePublic, structs.VisibleTypeLimited))
+		}
+		cond = cond.And(visible)
+	}
+	return cond
+}
+
+// FindContainerImages returns the images matching the options ordered by their full name,
+// with their owners loaded
+func FindContainerImages(opts *FindContainerImagesOptions) ([]*ContainerImage, int64, error) {
+	cond := opts.toConds()
+	count, err := x.Join("INNER", "`user`", "`user`.id = container_image.owner_id").
+		Where(cond).
+		Count(new(ContainerImage))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Join("INNER", "`user`", "`user`.id = container_image.owner_id").
+		Where(cond).
+		Asc("`user`.lower_name", "container_image.name")
+	if opts.Page != 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	images := make([]*ContainerImage, 0, opts.PageSize)
+	if err = sess.Find(&images); err != nil {
+		return nil, 0, err
+	}
+
+	owners := make(map[int64]*User)
+	for _, img := range images {
+		if owners[img.OwnerID] == nil {
+			if owners[img.OwnerID], err = GetUserByID(img.OwnerID); err != nil {
+				return nil, 0, err
+			}
+		}
+		img.Owner = owners[img.OwnerID]
+	}
+	return images, count, nil
+}
+
+// CountContainerImages returns the number of images of the owner
+func CountContainerImages(ownerID int64) (int64, error) {
+	return x.Count(&ContainerImage{OwnerID: ownerID})
+}
+
+// GetContainerBlob returns the blob with the given digest if it is linked to the image
+func GetContainerBlob(imageID int64, digest string) (*ContainerBlob, error) {
+	blob := &ContainerBlob{ImageID: imageID, Digest: digest}
+	has, err := x.Get(blob)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrContainerBlobNotExist{ImageID: imageID, Digest: digest}
+	}
+	return blob, nil
+}
+
+func linkContainerBlob(e Engine, imageID int64, digest string, size int64) error {
+	has, err := e.Get(&ContainerBlob{ImageID: imageID, Digest: digest})
+	if err != nil || has {
+		return err
+	}
+	_, err = e.Insert(&ContainerBlob{ImageID: imageID, Digest: digest, Size: size})
+	return err
+}
+
+// LinkContainerBlob links a blob of the content store to the image
+func LinkContainerBlob(imageID int64, digest string, size int64) error {
+	return linkContainerBlob(x, imageID, digest, size)
+}
+
+// DeleteContainerBlob unlinks a blob from the image, the content is removed by the
+// garbage collection once no image links it anymore
+func DeleteContainerBlob(imageID int64, digest string) error {
+	_, err := x.Delete(&ContainerBlob{ImageID: imageID, Digest: digest})
+	return err
+}
+
+// IsContainerBlobLinked returns true if any image links a blob with the given digest
+func IsContainerBlobLinked(digest string) (bool, error) {
+	return x.Exist(&ContainerBlob{Digest: digest})
+}
+
+// GetContainerManifest returns the manifest of the image with the given digest
+func GetContainerManifest(imageID int64, digest string) (*ContainerManifest, error) {
+	m := &ContainerManifest{ImageID: imageID, Digest: digest}
+	has, err := x.Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrContainerManifestNotExist{ImageID: imageID, Reference: digest}
+	}
+	return m, nil
+}
+
+// GetContainerManifestByTag returns the manifest the tag of the image points to
+func GetContainerManifestByTag(imageID int64, tag string) (*ContainerManifest, error) {
+	m := new(ContainerManifest)
+	has, err := x.Join("INNER", "container_tag", "container_tag.manifest_id = container_manifest.id").
+		Where("container_tag.image_id = ? AND container_tag.name = ?", imageID, tag).
+		Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrContainerManifestNotExist{ImageID: imageID, Reference: tag}
+	}
+	return m, nil
+}
+
+// PutContainerManifest stores a manifest of the image together with the digests it references
+// and points the tag to it if tag is not empty. The manifest content itself must already be
+// in the content store, it is linked to the image as a blob.
+func PutContainerManifest(m *ContainerManifest, references []string, tag string) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	existing := &ContainerManifest{ImageID: m.ImageID, Digest: m.Digest}
+	has, err := sess.Get(existing)
+	if err != nil {
+		return err
+	}
+	if has {
+		m.ID = existing.ID
+		if _, err = sess.ID(m.ID).Cols("media_type", "size").Update(m); err != nil {
+			return err
+		}
+		if _, err = sess.Delete(&ContainerManifestReference{ManifestID: m.ID}); err != nil {
+			return err
+		}
+	} else if _, err = sess.Insert(m); err != nil {
+		return err
+	}
+
+	if len(references) > 0 {
+		refs := make([]*ContainerManifestReference, 0, len(references))
+		for _, digest := range references {
+			refs = append(refs, &ContainerManifestReference{ManifestID: m.ID, Digest: digest})
+		}
+		if _, err = sess.Insert(&refs); err != nil {
+			return err
+		}
+	}
+
+	if err = linkContainerBlob(sess, m.ImageID, m.Digest, m.Size); err != nil {
+		return err
+	}
+
+	if len(tag) > 0 {
+		t := &ContainerTag{ImageID: m.ImageID, Name: tag}
+		if has, err = sess.Get(t); err != nil {
+			return err
+		}
+		t.ManifestID = m.ID
+		if has {
+			_, err = sess.ID(t.ID).Cols("manifest_id").Update(t)
+		} else {
+			_, err = sess.Insert(t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err = sess.ID(m.ImageID).Cols("updated_unix").Update(&ContainerImage{UpdatedUnix: timeutil.TimeStampNow()}); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// GetContainerManifestsReferencing returns the manifests of the image that reference the digest
+func GetContainerManifestsReferencing(imageID int64, digest string) ([]*ContainerManifest, error) {
+	manifests := make([]*ContainerManifest, 0, 2)
+	return manifests, x.Join("INNER", "container_manifest_reference", "container_manifest_reference.manifest_id = container_manifest.id").
+		Where("container_manifest.image_id = ? AND container_manifest_reference.digest = ?", imageID, digest).
+		Find(&manifests)
+}
+
+// DeleteContainerManifest deletes the manifest of the image and all tags pointing to it.
+// Blobs only referenced by the manifest are removed by the garbage collection.
+func DeleteContainerManifest(m *ContainerManifest) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = deleteBeans(sess,
+		&ContainerTag{ImageID: m.ImageID, ManifestID: m.ID},
+		&ContainerManifestReference{ManifestID: m.ID},
+		&ContainerManifest{ID: m.ID},
+	); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+// GetContainerTags returns the sorted tag names of the image
+func GetContainerTags(imageID int64) ([]string, error) {
+	tags := make([]string, 0, 10)
+	return tags, x.Table("container_tag").
+		Where("image_id = ?", imageID).
+		Asc("name").
+		Cols("name").
+		Find(&tags)
+}
+
+// DeleteContainerTag deletes the tag of the image, the manifest it points to is kept
+func DeleteContainerTag(imageID int64, name string) error {
+	n, err := x.Delete(&ContainerTag{ImageID: imageID, Name: name})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return ErrContainerManifestNotExist{ImageID: imageID, Reference: name}
+	}
+	return nil
+}
+
+// CreateContainerUpload starts a new blob upload
+func CreateContainerUpload(u *ContainerUpload) error {
+	_, err := x.Insert(u)
+	return err
+}
+
+// GetContainerUpload returns the upload of the image with the given UUID
+func GetContainerUpload(imageID int64, uuid string) (*ContainerUpload, error) {
+	u := &ContainerUpload{ImageID: imageID, UUID: uuid}
+	has, err := x.Get(u)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrContainerUploadNotExist{UUID: uuid}
+	}
+	return u, nil
+}
+
+// UpdateContainerUploadSize updates the number of bytes received by the upload
+func UpdateContainerUploadSize(u *ContainerUpload) error {
+	_, err := x.ID(u.ID).Cols("size").Update(u)
+	return err
+}
+
+// DeleteContainerUpload deletes the upload
+func DeleteContainerUpload(u *ContainerUpload) error {
+	_, err := x.ID(u.ID).Delete(new(ContainerUpload))
+	return err
+}
+
+// GetContainerUploadsBefore returns the uploads which have not been updated since the given time
+func GetContainerUploadsBefore(before timeutil.TimeStamp) ([]*ContainerUpload, error) {
+	uploads := make([]*ContainerUpload, 0, 10)
+	return uploads, x.Where("updated_unix < ?", before).Find(&uploads)
+}
+
+// DeleteUnreferencedContainerBlobs unlinks the blobs created before the given time that are
+// neither a manifest nor referenced by a manifest of their image and returns their number
+func DeleteUnreferencedContainerBlobs(before timeutil.TimeStamp) (int64, error) {
+	referenced := builder.Select("container_manifest_reference.digest").
+		From("container_manifest_reference").
+		InnerJoin("container_manifest", "container_manifest.id = container_manifest_reference.manifest_id").
+		Where(builder.Expr("container_manifest.image_id = container_blob.image_id"))
+	manifests := builder.Select("container_manifest.digest").
+		From("container_manifest").
+		Where(builder.Expr("container_manifest.image_id = container_blob.image_id"))
+
+	ids := make([]int64, 0, 10)
+	if err := x.Table("container_blob").
+		Where(builder.Lt{"created_unix": before}).
+		And(builder.NotIn("digest", referenced)).
+		And(builder.NotIn("digest", manifests)).
+		Cols("id").
+		Find(&ids); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return x.In("id", ids).Delete(new(ContainerBlob))
+}
+
+// DeleteEmptyContainerImages deletes the images without any manifests, blobs or uploads
+func DeleteEmptyContainerImages() (int64, error) {
+	ids := make([]int64, 0, 10)
+	if err := x.Table("container_image").
+		Where(builder.NotIn("id", builder.Select("image_id").From("container_manifest"))).
+		And(builder.NotIn("id", builder.Select("image_id").From("container_blob"))).
+		And(builder.NotIn("id", builder.Select("image_id").From("container_upload"))).
+		Cols("id").
+		Find(&ids); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return x.In("id", ids).Delete(new(ContainerImage))
+}
+
+// deleteContainerImagesByOwner deletes all images of the owner, their content is removed by
+// the garbage collection
+func deleteContainerImagesByOwner(e Engine, ownerID int64) error {
+	imageIDs := builder.Select("id").From("container_image").Where(builder.Eq{"owner_id": ownerID})
+	manifestIDs := builder.Select("id").From("container_manifest").Where(builder.In("image_id", imageIDs))
+
+	if _, err := e.Where(builder.In("manifest_id", manifestIDs)).Delete(new(ContainerManifestReference)); err != nil {
+		return fmt.Errorf("delete container manifest references: %v", err)
+	}
+	for _, bean := range []interface{}{new(ContainerTag), new(ContainerManifest), new(ContainerBlob), new(ContainerUpload)} {
+		if _, err := e.Where(builder.In("image_id", imageIDs)).Delete(bean); err != nil {
+			return fmt.Errorf("delete container images: %v", err)
+		}
+	}
+	_, err := e.Delete(&ContainerImage{OwnerID: ownerID})
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testConfigDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+	testLayerDigest  = "sha256:0000000000000000000000000000000000000000000000000000000000000002"
+	testOrphanDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000003"
+	testManifestHash = "sha256:0000000000000000000000000000000000000000000000000000000000000004"
+)
+
+func TestContainerManifests(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	img, err := GetOrCreateContainerImage(2, "App")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "app", img.Name)
+	same, err := GetOrCreateContainerImage(2, "app")
+	assert.NoError(t, err)
+	assert.EqualValues(t, img.ID, same.ID)
+
+	for _, digest := range []string{testConfigDigest, testLayerDigest, testOrphanDigest} {
+		assert.NoError(t, LinkContainerBlob(img.ID, digest, 10))
+	}
+	assert.NoError(t, LinkContainerBlob(img.ID, testLayerDigest, 10))
+	AssertCount(t, &ContainerBlob{ImageID: img.ID}, 3)
+
+	m := &ContainerManifest{ImageID: img.ID, Digest: testManifestHash, MediaType: "application/vnd.oci.image.manifest.v1+json", Size: 100}
+	assert.NoError(t, PutContainerManifest(m, []string{testConfigDigest, testLayerDigest}, "latest"))
+	assert.NoError(t, PutContainerManifest(m, []string{testConfigDigest, testLayerDigest}, "v1"))
+	AssertCount(t, &ContainerManifest{ImageID: img.ID}, 1)
+	AssertCount(t, &ContainerManifestReference{ManifestID: m.ID}, 2)
+
+	tags, err := GetContainerTags(img.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"latest", "v1"}, tags)
+
+	byTag, err := GetContainerManifestByTag(img.ID, "v1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, m.ID, byTag.ID)
+	_, err = GetContainerManifestByTag(img.ID, "v2")
+	assert.True(t, IsErrContainerManifestNotExist(err))
+
+	referencing, err := GetContainerManifestsReferencing(img.ID, testLayerDigest)
+	assert.NoError(t, err)
+	assert.Len(t, referencing, 1)
+
+	// Only the blob no manifest references is unlinked, the manifest itself is kept
+	n, err := DeleteUnreferencedContainerBlobs(timeutil.TimeStampNow() + 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	AssertNotExistsBean(t, &ContainerBlob{ImageID: img.ID, Digest: testOrphanDigest})
+	AssertExistsAndLoadBean(t, &ContainerBlob{ImageID: img.ID, Digest: testManifestHash})
+
+	assert.NoError(t, DeleteContainerTag(img.ID, "v1"))
+	assert.True(t, IsErrContainerManifestNotExist(DeleteContainerTag(img.ID, "v1")))
+
+	assert.NoError(t, DeleteContainerManifest(m))
+	AssertCount(t, &ContainerTag{ImageID: img.ID}, 0)
+	n, err = DeleteUnreferencedContainerBlobs(timeutil.TimeStampNow() + 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+
+	n, err = DeleteEmptyContainerImages()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	_, err = GetContainerImage(2, "app")
+	assert.True(t, IsErrContainerImageNotExist(err))
+}
+
+func TestFindContainerImages(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, owner := range []int64{2, 3, 23} {
+		_, err := GetOrCreateContainerImage(owner, "image")
+		assert.NoError(t, err)
+	}
+
+	names := func(actor *User) []string {
+		images, count, err := FindContainerImages(&FindContainerImagesOptions{Actor: actor})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(images), count)
+		result := make([]string, 0, len(images))
+		for _, img := range images {
+			result = append(result, img.FullName())
+		}
+		return result
+	}
+
+	// privated_org is only visible to site administrators, it has no members
+	assert.EqualValues(t, []string{"user2/image", "user3/image"}, names(nil))
+	assert.EqualValues(t, []string{"user2/image", "user3/image"}, names(AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)))
+	assert.EqualValues(t, []string{"privated_org/image", "user2/image", "user3/image"}, names(AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)))
+
+	images, count, err := FindContainerImages(&FindContainerImagesOptions{OwnerID: 3, Actor: nil})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.EqualValues(t, "user3/image", images[0].FullName())
+
+	assert.NoError(t, deleteContainerImagesByOwner(x, 3))
+	AssertNotExistsBean(t, &ContainerImage{OwnerID: 3})
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
 )
 
 // ErrNotExist represents a non-exist error.
@@ -186,6 +187,21 @@ func (err ErrUserOwnRepos) Error() string {
 	return fmt.Sprintf("user still has ownership of repositories [uid: %d]", err.UID)
 }
 
+// ErrUserOwnPackages represents a "UserOwnPackages" kind of error.
+type ErrUserOwnPackages struct {
+	UID int64
+}
+
+// IsErrUserOwnPackages checks if an error is a ErrUserOwnPackages.
+func IsErrUserOwnPackages(err error) bool {
+	_, ok := err.(ErrUserOwnPackages)
+	return ok
+}
+
+func (err ErrUserOwnPackages) Error() string {
+	return fmt.Sprintf("user still has ownership of packages [uid: %d]", err.UID)
+}
+
 // ErrUserHasOrgs represents a "UserHasOrgs" kind of error.
 type ErrUserHasOrgs struct {
 	UID int64
@@ -513,6 +529,69 @@ func (err ErrDeployKeyNameAlreadyUsed) Error() string {
 	return fmt.Sprintf("public key already exists [repo_id: %d, name: %s]", err.RepoID, err.Name)
 }
 
+// ErrSSHCertificateAuthorityNotExist represents a "SSHCertificateAuthorityNotExist" kind of error.
+type ErrSSHCertificateAuthorityNotExist struct {
+	ID          int64
+	Fingerprint string
+}
+
+// IsErrSSHCertificateAuthorityNotExist checks if an error is a ErrSSHCertificateAuthorityNotExist.
+func IsErrSSHCertificateAuthorityNotExist(err error) bool {
+	_, ok := err.(ErrSSHCertificateAuthorityNotExist)
+	return ok
+}
+
+func (err ErrSSHCertificateAuthorityNotExist) Error() string {
+	return fmt.Sprintf("SSH certificate authority does not exist [id: %d, fingerprint: %s]", err.ID, err.Fingerprint)
+}
+
+// ErrSSHCertificateAuthorityAlreadyExist represents a "SSHCertificateAuthorityAlreadyExist" kind of error.
+type ErrSSHCertificateAuthorityAlreadyExist struct {
+	Name        string
+	Fingerprint string
+}
+
+// IsErrSSHCertificateAuthorityAlreadyExist checks if an error is a ErrSSHCertificateAuthorityAlreadyExist.
+func IsErrSSHCertificateAuthorityAlreadyExist(err error) bool {
+	_, ok := err.(ErrSSHCertificateAuthorityAlreadyExist)
+	return ok
+}
+
+func (err ErrSSHCertificateAuthorityAlreadyExist) Error() string {
+	return fmt.Sprintf("SSH certificate authority already exists [name: %s, fingerprint: %s]", err.Name, err.Fingerprint)
+}
+
+// ErrSSHCertificateInvalid represents a "SSHCertificateInvalid" kind of error.
+type ErrSSHCertificateInvalid struct {
+	KeyID  string
+	Reason string
+}
+
+// IsErrSSHCertificateInvalid checks if an error is a ErrSSHCertificateInvalid.
+func IsErrSSHCertificateInvalid(err error) bool {
+	_, ok := err.(ErrSSHCertificateInvalid)
+	return ok
+}
+
+func (err ErrSSHCertificateInvalid) Error() string {
+	return fmt.Sprintf("SSH certificate is not valid [key_id: %s]: %s", err.KeyID, err.Reason)
+}
+
+// ErrLoginLockoutNotExist represents a "LoginLockoutNotExist" kind of error.
+type ErrLoginLockoutNotExist struct {
+	ID int64
+}
+
+// IsErrLoginLockoutNotExist checks if an error is a ErrLoginLockoutNotExist.
+func IsErrLoginLockoutNotExist(err error) bool {
+	_, ok := err.(ErrLoginLockoutNotExist)
+	return ok
+}
+
+func (err ErrLoginLockoutNotExist) Error() string {
+	return fmt.Sprintf("login lockout does not exist [id: %d]", err.ID)
+}
+
 //    _____                                   ___________     __
 //   /  _  \   ____  ____  ____   ______ _____\__    ___/___ |  | __ ____   ____
 //  /  /_\  \_/ ___\/ ___\/ __ \ /  ___//  ___/ |    | /  _ \|  |/ // __ \ /    \
@@ -1139,6 +1218,39 @@ func (err ErrIssueIsClosed) Error() string {
 	return fmt.Sprintf("issue is closed [id: %d, repo_id: %d, index: %d]", err.ID, err.RepoID, err.Index)
 }
 
+// ErrIssueRedirectNotExist represents a "IssueRedirectNotExist" kind of error.
+type ErrIssueRedirectNotExist struct {
+	RepoID int64
+	Index  int64
+}
+
+// IsErrIssueRedirectNotExist checks if an error is a ErrIssueRedirectNotExist.
+func IsErrIssueRedirectNotExist(err error) bool {
+	_, ok := err.(ErrIssueRedirectNotExist)
+	return ok
+}
+
+func (err ErrIssueRedirectNotExist) Error() string {
+	return fmt.Sprintf("issue redirect does not exist [repo_id: %d, index: %d]", err.RepoID, err.Index)
+}
+
+// ErrIssueCannotBeTransferred is used when transferring a pull request, or an issue to its
+// own repository or to a repository without issues
+type ErrIssueCannotBeTransferred struct {
+	IssueID int64
+	RepoID  int64
+}
+
+// IsErrIssueCannotBeTransferred checks if an error is a ErrIssueCannotBeTransferred.
+func IsErrIssueCannotBeTransferred(err error) bool {
+	_, ok := err.(ErrIssueCannotBeTransferred)
+	return ok
+}
+
+func (err ErrIssueCannotBeTransferred) Error() string {
+	return fmt.Sprintf("issue cannot be transferred [issue_id: %d, repo_id: %d]", err.IssueID, err.RepoID)
+}
+
 // ErrIssueLabelTemplateLoad represents a "ErrIssueLabelTemplateLoad" kind of error.
 type ErrIssueLabelTemplateLoad struct {
 	TemplateFile  string
@@ -1502,6 +1614,21 @@ func (err ErrLabelNotExist) Error() string {
 	return fmt.Sprintf("label does not exist [label_id: %d, repo_id: %d]", err.LabelID, err.RepoID)
 }
 
+// ErrSavedIssueQueryNotExist represents a "SavedIssueQueryNotExist" kind of error.
+type ErrSavedIssueQueryNotExist struct {
+	ID int64
+}
+
+// IsErrSavedIssueQueryNotExist checks if an error is a ErrSavedIssueQueryNotExist.
+func IsErrSavedIssueQueryNotExist(err error) bool {
+	_, ok := err.(ErrSavedIssueQueryNotExist)
+	return ok
+}
+
+func (err ErrSavedIssueQueryNotExist) Error() string {
+	return fmt.Sprintf("saved issue query does not exist [id: %d]", err.ID)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -1659,6 +1786,25 @@ func (err ErrTwoFactorNotEnrolled) Error() string {
 	return fmt.Sprintf("user not enrolled in 2FA [uid: %d]", err.UID)
 }
 
+// ErrTwoFactorRequired indicates that a user must enroll in two-factor authentication to
+// use the instance, or to access the repositories of the organization if OrgName is set.
+type ErrTwoFactorRequired struct {
+	OrgName string
+}
+
+// IsErrTwoFactorRequired checks if an error is a ErrTwoFactorRequired.
+func IsErrTwoFactorRequired(err error) bool {
+	_, ok := err.(ErrTwoFactorRequired)
+	return ok
+}
+
+func (err ErrTwoFactorRequired) Error() string {
+	if len(err.OrgName) == 0 {
+		return fmt.Sprintf("two-factor authentication is required, enroll at %suser/settings/security", setting.AppURL)
+	}
+	return fmt.Sprintf("organization %s requires two-factor authentication, enroll at %suser/settings/security", err.OrgName, setting.AppURL)
+}
+
 //  ____ ___        .__                    .___
 // |    |   \______ |  |   _________     __| _/
 // |    |   /\____ \|  |  /  _ \__  \   / __ |
@@ -1852,6 +1998,24 @@ func (err ErrReviewNotExist) Error() string {
 	return fmt.Sprintf("review does not exist [id: %d]", err.ID)
 }
 
+// ErrNotValidReviewRequest represents an invalid request to review a pull request
+type ErrNotValidReviewRequest struct {
+	Reason string
+	UserID int64
+	TeamID int64
+	RepoID int64
+}
+
+// IsErrNotValidReviewRequest checks if an error is a ErrNotValidReviewRequest.
+func IsErrNotValidReviewRequest(err error) bool {
+	_, ok := err.(ErrNotValidReviewRequest)
+	return ok
+}
+
+func (err ErrNotValidReviewRequest) Error() string {
+	return fmt.Sprintf("%s [user_id: %d, team_id: %d, repo_id: %d]", err.Reason, err.UserID, err.TeamID, err.RepoID)
+}
+
 //  ________      _____          __  .__
 //  \_____  \    /  _  \  __ ___/  |_|  |__
 //   /   |   \  /  /_\  \|  |  \   __\  |  \
@@ -1890,3 +2054,230 @@ func IsErrOAuthApplicationNotFound(err error) bool {
 func (err ErrOAuthApplicationNotFound) Error() string {
 	return fmt.Sprintf("OAuth application not found [ID: %d]", err.ID)
 }
+
+//  _________                __         .__
+//  \_   ___ \  ____   _____/  |______  |__| ____   ___________
+//  /    \  \/ /  _ \ /    \   __\__  \ |  |/    \_/ __ \_  __ \
+//  \     \___(  <_> )   |  \  |  / __ \|  |   |  \  ___/|  | \/
+//   \______  /\____/|___|  /__| (____  /__|___|  /\___  >__|
+//          \/            \/          \/        \/     \/
+
+// ErrContainerImageNotExist represents a "ContainerImageNotExist" kind of error.
+type ErrContainerImageNotExist struct {
+	OwnerID int64
+	Name    string
+}
+
+// IsErrContainerImageNotExist checks if an error is a ErrContainerImageNotExist.
+func IsErrContainerImageNotExist(err error) bool {
+	_, ok := err.(ErrContainerImageNotExist)
+	return ok
+}
+
+func (err ErrContainerImageNotExist) Error() string {
+	return fmt.Sprintf("container image does not exist [owner_id: %d, name: %s]", err.OwnerID, err.Name)
+}
+
+// ErrContainerBlobNotExist represents a "ContainerBlobNotExist" kind of error.
+type ErrContainerBlobNotExist struct {
+	ImageID int64
+	Digest  string
+}
+
+// IsErrContainerBlobNotExist checks if an error is a ErrContainerBlobNotExist.
+func IsErrContainerBlobNotExist(err error) bool {
+	_, ok := err.(ErrContainerBlobNotExist)
+	return ok
+}
+
+func (err ErrContainerBlobNotExist) Error() string {
+	return fmt.Sprintf("container blob does not exist [image_id: %d, digest: %s]", err.ImageID, err.Digest)
+}
+
+// ErrContainerManifestNotExist represents a "ContainerManifestNotExist" kind of error.
+type ErrContainerManifestNotExist struct {
+	ImageID   int64
+	Reference string
+}
+
+// IsErrContainerManifestNotExist checks if an error is a ErrContainerManifestNotExist.
+func IsErrContainerManifestNotExist(err error) bool {
+	_, ok := err.(ErrContainerManifestNotExist)
+	return ok
+}
+
+func (err ErrContainerManifestNotExist) Error() string {
+	return fmt.Sprintf("container manifest does not exist [image_id: %d, reference: %s]", err.ImageID, err.Reference)
+}
+
+// ErrContainerUploadNotExist represents a "ContainerUploadNotExist" kind of error.
+type ErrContainerUploadNotExist struct {
+	UUID string
+}
+
+// IsErrContainerUploadNotExist checks if an error is a ErrContainerUploadNotExist.
+func IsErrContainerUploadNotExist(err error) bool {
+	_, ok := err.(ErrContainerUploadNotExist)
+	return ok
+}
+
+func (err ErrContainerUploadNotExist) Error() string {
+	return fmt.Sprintf("container upload does not exist [uuid: %s]", err.UUID)
+}
+
+// __________                 __
+// \______   \_____    ____ |  | _______     ____   ____
+//  |     ___/\__  \ _/ ___\|  |/ /\__  \   / ___\_/ __ \
+//  |    |     / __ \\  \___|    <  / __ \_/ /_/  >  ___/
+//  |____|    (____  /\___  >__|_ \(____  /\___  / \___  >
+//                 \/     \/     \/     \//_____/      \/
+
+// ErrPackageNotExist represents a "PackageNotExist" kind of error.
+type ErrPackageNotExist struct {
+	ID      int64
+	OwnerID int64
+	Type    PackageType
+	Name    string
+}
+
+// IsErrPackageNotExist checks if an error is a ErrPackageNotExist.
+func IsErrPackageNotExist(err error) bool {
+	_, ok := err.(ErrPackageNotExist)
+	return ok
+}
+
+func (err ErrPackageNotExist) Error() string {
+	return fmt.Sprintf("package does not exist [id: %d, owner_id: %d, type: %s, name: %s]", err.ID, err.OwnerID, err.Type.Name(), err.Name)
+}
+
+// ErrPackageVersionNotExist represents a "PackageVersionNotExist" kind of error.
+type ErrPackageVersionNotExist struct {
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionNotExist checks if an error is a ErrPackageVersionNotExist.
+func IsErrPackageVersionNotExist(err error) bool {
+	_, ok := err.(ErrPackageVersionNotExist)
+	return ok
+}
+
+func (err ErrPackageVersionNotExist) Error() string {
+	return fmt.Sprintf("package version does not exist [package_id: %d, version: %s]", err.PackageID, err.Version)
+}
+
+// ErrPackageVersionAlreadyExist represents a "PackageVersionAlreadyExist" kind of error.
+type ErrPackageVersionAlreadyExist struct {
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionAlreadyExist checks if an error is a ErrPackageVersionAlreadyExist.
+func IsErrPackageVersionAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageVersionAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageVersionAlreadyExist) Error() string {
+	return fmt.Sprintf("package version already exists [package_id: %d, version: %s]", err.PackageID, err.Version)
+}
+
+// ErrPackageFileNotExist represents a "PackageFileNotExist" kind of error.
+type ErrPackageFileNotExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileNotExist checks if an error is a ErrPackageFileNotExist.
+func IsErrPackageFileNotExist(err error) bool {
+	_, ok := err.(ErrPackageFileNotExist)
+	return ok
+}
+
+func (err ErrPackageFileNotExist) Error() string {
+	return fmt.Sprintf("package file does not exist [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// ErrPackageFileAlreadyExist represents a "PackageFileAlreadyExist" kind of error.
+type ErrPackageFileAlreadyExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileAlreadyExist checks if an error is a ErrPackageFileAlreadyExist.
+func IsErrPackageFileAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageFileAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageFileAlreadyExist) Error() string {
+	return fmt.Sprintf("package file already exists [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// _________ .___
+// \_   ___ \|   |
+// /    \  \/|   |
+// \     \___|   |
+//  \______  /___|
+//         \/
+
+// ErrCIRunNotExist represents a "CIRunNotExist" kind of error.
+type ErrCIRunNotExist struct {
+	ID     int64
+	RepoID int64
+	Index  int64
+}
+
+// IsErrCIRunNotExist checks if an error is a ErrCIRunNotExist.
+func IsErrCIRunNotExist(err error) bool {
+	_, ok := err.(ErrCIRunNotExist)
+	return ok
+}
+
+func (err ErrCIRunNotExist) Error() string {
+	return fmt.Sprintf("CI run does not exist [id: %d, repo_id: %d, index: %d]", err.ID, err.RepoID, err.Index)
+}
+
+// ErrCIJobNotExist represents a "CIJobNotExist" kind of error.
+type ErrCIJobNotExist struct {
+	ID int64
+}
+
+// IsErrCIJobNotExist checks if an error is a ErrCIJobNotExist.
+func IsErrCIJobNotExist(err error) bool {
+	_, ok := err.(ErrCIJobNotExist)
+	return ok
+}
+
+func (err ErrCIJobNotExist) Error() string {
+	return fmt.Sprintf("CI job does not exist [id: %d]", err.ID)
+}
+
+// ErrCIRunnerNotExist represents a "CIRunnerNotExist" kind of error.
+type ErrCIRunnerNotExist struct {
+	ID int64
+}
+
+// IsErrCIRunnerNotExist checks if an error is a ErrCIRunnerNotExist.
+func IsErrCIRunnerNotExist(err error) bool {
+	_, ok := err.(ErrCIRunnerNotExist)
+	return ok
+}
+
+func (err ErrCIRunnerNotExist) Error() string {
+	return fmt.Sprintf("CI runner does not exist [id: %d]", err.ID)
+}
+
+// ErrCIRunnerTokenNotExist represents a "CIRunnerTokenNotExist" kind of error.
+type ErrCIRunnerTokenNotExist struct {
+}
+
+// IsErrCIRunnerTokenNotExist checks if an error is a ErrCIRunnerTokenNotExist.
+func IsErrCIRunnerTokenNotExist(err error) bool {
+	_, ok := err.(ErrCIRunnerTokenNotExist)
+	return ok
+}
+
+func (err ErrCIRunnerTokenNotExist) Error() string {
+	return "CI runner registration token does not exist"
+}
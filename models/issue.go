@@ -899,7 +899,7 @@ func newIssue(e *xorm.Session, doer *User, opts NewIssueOptions) (err error) {
 	}
 
 	// Milestone validation should happen before insert actual object.
-	if _, err := e.SetExpr("`index`", "coalesce(MAX(`index`),0)+1").
+	if _, err := e.SetExpr("`index`", nextIssueIndexExpr(opts.Issue.RepoID)).
 		Where("repo_id=?", opts.Issue.RepoID).
 		Insert(opts.Issue); err != nil {
 		return ErrNewIssueInsert{err}
@@ -1117,6 +1117,19 @@ type IssuesOptions struct {
 	IssueIDs    []int64
 	// prioritize issues from this repo
 	PriorityRepoID int64
+	// LabelNames and ExcludedLabelNames match the labels by name, ignoring
+	// the case, as the same label may have different IDs in each repository
+	LabelNames         []string
+	ExcludedLabelNames []string
+	// MilestoneName matches the milestone by name, ignoring the case
+	MilestoneName string
+	// CreatedAfterUnix, CreatedBeforeUnix, UpdatedAfterUnix and
+	// UpdatedBeforeUnix restrict the dates if not zero, the after bounds are
+	// inclusive and the before bounds exclusive
+	CreatedAfterUnix  int64
+	CreatedBeforeUnix int64
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
 }
 
 // sortIssuesSession sort an issues-related session based on the provided
@@ -1210,6 +1223,68 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) {
 			}
 		}
 	}
+
+	for _, name := range opts.LabelNames {
+		sess.And("issue.id IN (SELECT issue_label.issue_id FROM issue_label INNER JOIN label ON label.id = issue_label.label_id WHERE LOWER(label.name) = ?)",
+			strings.ToLower(name))
+	}
+
+	for _, name := range opts.ExcludedLabelNames {
+		sess.And("issue.id NOT IN (SELECT issue_label.issue_id FROM issue_label INNER JOIN label ON label.id = issue_label.label_id WHERE LOWER(label.name) = ?)",
+			strings.ToLower(name))
+	}
+
+	if len(opts.MilestoneName) > 0 {
+		sess.And("issue.milestone_id IN (SELECT id FROM milestone WHERE LOWER(name) = ?)", strings.ToLower(opts.MilestoneName))
+	}
+
+	if opts.CreatedAfterUnix > 0 {
+		sess.And("issue.created_unix >= ?", opts.CreatedAfterUnix)
+	}
+	if opts.CreatedBeforeUnix > 0 {
+		sess.And("issue.created_unix < ?", opts.CreatedBeforeUnix)
+	}
+	if opts.UpdatedAfterUnix > 0 {
+		sess.And("issue.updated_unix >= ?", opts.UpdatedAfterUnix)
+	}
+	if opts.UpdatedBeforeUnix > 0 {
+		sess.And("issue.updated_unix < ?", opts.UpdatedBeforeUnix)
+	}
+}
+
+// CountIssues returns the number of issues matching the options, ignoring
+// the pagination
+func CountIssues(opts *IssuesOptions) (int64, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+
+	countOpts := *opts
+	countOpts.ListOptions = ListOptions{}
+	countOpts.setupSession(sess)
+	return sess.Count(new(Issue))
+}
+
+// CountIssueStats returns the numbers of open and closed issues matching the
+// options
+func CountIssueStats(opts *IssuesOptions) (*IssueStats, error) {
+	stats := &IssueStats{}
+	for _, isClosed := range []bool{false, true} {
+		if !opts.IsClosed.IsNone() && opts.IsClosed.IsTrue() != isClosed {
+			continue
+		}
+		countOpts := *opts
+		countOpts.IsClosed = util.OptionalBoolOf(isClosed)
+		count, err := CountIssues(&countOpts)
+		if err != nil {
+			return nil, err
+		}
+		if isClosed {
+			stats.ClosedCount = count
+		} else {
+			stats.OpenCount = count
+		}
+	}
+	return stats, nil
 }
 
 // CountIssuesByRepo map from repoID to number of issues matching the options
@@ -1638,17 +1713,33 @@ func UpdateIssueDeadline(issue *Issue, deadlineUnix timeutil.TimeStamp, doer *Us
 		return err
 	}
 
+	if err = updateIssueDeadline(sess, issue, deadlineUnix, doer); err != nil {
+		return err
+	}
+
+	return sess.Commit()
+}
+
+func updateIssueDeadline(e *xorm.Session, issue *Issue, deadlineUnix timeutil.TimeStamp, doer *User) error {
 	// Update the deadline
-	if err = updateIssueCols(sess, &Issue{ID: issue.ID, DeadlineUnix: deadlineUnix}, "deadline_unix"); err != nil {
+	if err := updateIssueCols(e, &Issue{ID: issue.ID, DeadlineUnix: deadlineUnix}, "deadline_unix"); err != nil {
 		return err
 	}
 
 	// Make the comment
-	if _, err = createDeadlineComment(sess, doer, issue, deadlineUnix); err != nil {
+	if _, err := createDeadlineComment(e, doer, issue, deadlineUnix); err != nil {
 		return fmt.Errorf("createRemovedDueDateComment: %v", err)
 	}
+	return nil
+}
 
-	return sess.Commit()
+// UpdateIssueTimestamps overwrites the times of creation, last update and closing of the issue,
+// which are otherwise set automatically, e.g. to keep the times of imported issues.
+func UpdateIssueTimestamps(issue *Issue) error {
+	// xorm never updates the columns tagged as created
+	_, err := x.Exec("UPDATE `issue` SET created_unix = ?, updated_unix = ?, closed_unix = ? WHERE id = ?",
+		issue.CreatedUnix, issue.UpdatedUnix, issue.ClosedUnix, issue.ID)
+	return err
 }
 
 // DependencyInfo represents high level information about an issue which is a dependency of another issue.
@@ -1712,6 +1803,7 @@ func (issue *Issue) updateClosedNum(e Engine) (err error) {
 
 // ResolveMentionsByVisibility returns the users mentioned in an issue, removing those that
 // don't have access to reading it. Teams are expanded into their users, but organizations are ignored.
+// Teams are either mentioned by their name in the organization owning the repository, or as "org/team".
 func (issue *Issue) ResolveMentionsByVisibility(ctx DBContext, doer *User, mentions []string) (users []*User, err error) {
 	if len(mentions) == 0 {
 		return
@@ -1721,16 +1813,38 @@ func (issue *Issue) ResolveMentionsByVisibility(ctx DBContext, doer *User, menti
 	}
 	resolved := make(map[string]bool, 20)
 	names := make([]string, 0, 20)
+	teamNames := make([]string, 0, 5)
 	resolved[doer.LowerName] = true
 	for _, name := range mentions {
 		name := strings.ToLower(name)
 		if _, ok := resolved[name]; ok {
 			continue
 		}
+		if strings.Contains(name, "/") {
+			resolved[name] = true
+			teamNames = append(teamNames, name)
+			continue
+		}
 		resolved[name] = false
 		names = append(names, name)
 	}
 
+	for _, name := range teamNames {
+		members, err := issue.resolveTeamMention(ctx.e, doer, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			if !resolved[member.LowerName] {
+				users = append(users, member)
+				resolved[member.LowerName] = true
+			}
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
 	if err := issue.Repo.getOwner(ctx.e); err != nil {
 		return nil, err
 	}
@@ -1778,7 +1892,6 @@ func (issue *Issue) ResolveMentionsByVisibility(ctx DBContext, doer *User, menti
 					return nil, fmt.Errorf("get teams users: %v", err)
 				}
 				if len(teamusers) > 0 {
-					users = make([]*User, 0, len(teamusers))
 					for _, user := range teamusers {
 						if already, ok := resolved[user.LowerName]; !ok || !already {
 							users = append(users, user)
@@ -1827,6 +1940,49 @@ func (issue *Issue) ResolveMentionsByVisibility(ctx DBContext, doer *User, menti
 	return
 }
 
+// resolveTeamMention returns the members of the team mentioned as "org/team"
+// who can read the issue. The team is ignored if the doer can't see it, i.e.
+// isn't a member of its organization.
+func (issue *Issue) resolveTeamMention(e Engine, doer *User, name string) ([]*User, error) {
+	parts := strings.SplitN(name, "/", 2)
+	org, err := getUserByName(e, parts[0])
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !org.IsOrganization() || (!doer.IsAdmin && !org.isUserPartOfOrg(e, doer.ID)) {
+		return nil, nil
+	}
+	team, err := getTeam(e, org.ID, parts[1])
+	if err != nil {
+		if IsErrTeamNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	members, err := getTeamMembers(e, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0, len(members))
+	for _, member := range members {
+		if !member.IsActive || member.ProhibitLogin {
+			continue
+		}
+		perm, err := getUserRepoPermission(e, issue.Repo, member)
+		if err != nil {
+			return nil, fmt.Errorf("getUserRepoPermission [%d]: %v", member.ID, err)
+		}
+		if perm.CanReadIssuesOrPulls(issue.IsPull) {
+			users = append(users, member)
+		}
+	}
+	return users, nil
+}
+
 // UpdateIssuesMigrationsByType updates all migrated repositories' issues from gitServiceType to replace originalAuthorID to posterID
 func UpdateIssuesMigrationsByType(gitServiceType structs.GitServiceType, originalAuthorID string, posterID int64) error {
 	_, err := x.Table("issue").
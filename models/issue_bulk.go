@@ -0,0 +1,289 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// BulkEditIssuesOptions represents the changes applied to many issues at once,
+// the nil fields and the empty lists are left unchanged. The issues have no
+// projects to change since the repositories have none.
+type BulkEditIssuesOptions struct {
+	AddLabelIDs       []int64
+	RemoveLabelIDs    []int64
+	AddAssigneeIDs    []int64
+	RemoveAssigneeIDs []int64
+	// MilestoneID is the new milestone of the issues, 0 to remove it
+	MilestoneID *int64
+	IsClosed    *bool
+	IsLocked    *bool
+	// DeadlineUnix is the new deadline of the issues, 0 to remove it
+	DeadlineUnix *timeutil.TimeStamp
+}
+
+// IsEmpty returns whether the options change nothing
+func (opts *BulkEditIssuesOptions) IsEmpty() bool {
+	return len(opts.AddLabelIDs) == 0 && len(opts.RemoveLabelIDs) == 0 &&
+		len(opts.AddAssigneeIDs) == 0 && len(opts.RemoveAssigneeIDs) == 0 &&
+		opts.MilestoneID == nil && opts.IsClosed == nil && opts.IsLocked == nil && opts.DeadlineUnix == nil
+}
+
+// BulkEditedIssue represents an issue changed by a bulk edit, and what was changed
+type BulkEditedIssue struct {
+	*Issue
+	AddedLabels      []*Label
+	RemovedLabels    []*Label
+	AddedAssignees   []*User
+	RemovedAssignees []*User
+	// OldMilestoneID is the milestone of the issue before the edit, if IsMilestoneChanged
+	OldMilestoneID     int64
+	IsMilestoneChanged bool
+	// StatusComment is the comment of the closing or the reopening, nil if the status is unchanged
+	StatusComment *Comment
+}
+
+// bulkEdit holds the labels and the users of the changes once loaded
+type bulkEdit struct {
+	*BulkEditIssuesOptions
+	addLabels       []*Label
+	removeLabels    []*Label
+	addAssignees    []*User
+	removeAssignees []*User
+}
+
+// BulkEditIssues applies the changes to the issues and pull requests of the repository
+// in a single transaction, as if they were made one by one: either all of them are
+// applied or none. It returns the issues which were changed.
+func BulkEditIssues(doer *User, repo *Repository, issues []*Issue, opts *BulkEditIssuesOptions) ([]*BulkEditedIssue, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	edit := &bulkEdit{BulkEditIssuesOptions: opts}
+	var err error
+	if edit.addLabels, err = getLabelsInRepoByIDs(sess, repo.ID, opts.AddLabelIDs); err != nil {
+		return nil, err
+	}
+	if edit.removeLabels, err = getLabelsInRepoByIDs(sess, repo.ID, opts.RemoveLabelIDs); err != nil {
+		return nil, err
+	}
+	if edit.addAssignees, err = getAssignableUsersByIDs(sess, repo, opts.AddAssigneeIDs); err != nil {
+		return nil, err
+	}
+	for _, id := range opts.RemoveAssigneeIDs {
+		u, err := getUserByID(sess, id)
+		if err != nil {
+			return nil, err
+		}
+		edit.removeAssignees = append(edit.removeAssignees, u)
+	}
+	if opts.MilestoneID != nil && *opts.MilestoneID > 0 {
+		if _, err = getMilestoneByRepoID(sess, repo.ID, *opts.MilestoneID); err != nil {
+			return nil, err
+		}
+	}
+
+	edited := make([]*BulkEditedIssue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.RepoID != repo.ID {
+			return nil, ErrIssueNotExist{ID: issue.ID, RepoID: repo.ID}
+		}
+		issue.Repo = repo
+
+		changes, changed, err := edit.apply(sess, doer, issue)
+		if err != nil {
+			return nil, err
+		} else if changed {
+			edited = append(edited, changes)
+		}
+	}
+
+	return edited, sess.Commit()
+}
+
+func getLabelsInRepoByIDs(e Engine, repoID int64, ids []int64) ([]*Label, error) {
+	labels := make([]*Label, 0, len(ids))
+	for _, id := range ids {
+		label, err := getLabelInRepoByID(e, repoID, id)
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// getAssignableUsersByIDs returns the users, who must be able to be assigned in the repository
+func getAssignableUsersByIDs(e Engine, repo *Repository, ids []int64) ([]*User, error) {
+	users := make([]*User, 0, len(ids))
+	for _, id := range ids {
+		u, err := getUserByID(e, id)
+		if err != nil {
+			return nil, err
+		}
+		if u.IsOrganization() {
+			return nil, ErrUserDoesNotHaveAccessToRepo{UserID: u.ID, RepoName: repo.Name}
+		}
+		perm, err := getUserRepoPermission(e, repo, u)
+		if err != nil {
+			return nil, err
+		}
+		if !perm.CanAccessAny(AccessModeWrite, UnitTypeCode, UnitTypeIssues, UnitTypePullRequests) {
+			return nil, ErrUserDoesNotHaveAccessToRepo{UserID: u.ID, RepoName: repo.Name}
+		}
+		users = append(users, u)
+	}
+	return users, nil
+}
+
+// apply makes the changes to the issue, and returns them and whether it was changed
+func (edit *bulkEdit) apply(e *xorm.Session, doer *User, issue *Issue) (changes *BulkEditedIssue, changed bool, err error) {
+	changes = &BulkEditedIssue{Issue: issue}
+	for _, label := range edit.addLabels {
+		if hasIssueLabel(e, issue.ID, label.ID) {
+			continue
+		}
+		if err = newIssueLabel(e, issue, label, doer); err != nil {
+			return nil, false, err
+		}
+		changes.AddedLabels = append(changes.AddedLabels, label)
+		changed = true
+	}
+	for _, label := range edit.removeLabels {
+		if !hasIssueLabel(e, issue.ID, label.ID) {
+			continue
+		}
+		if err = deleteIssueLabel(e, issue, label, doer); err != nil {
+			return nil, false, err
+		}
+		changes.RemovedLabels = append(changes.RemovedLabels, label)
+		changed = true
+	}
+
+	for _, assignee := range edit.addAssignees {
+		toggled, err := setIssueAssignee(e, doer, issue, assignee, true)
+		if err != nil {
+			return nil, false, err
+		} else if toggled {
+			changes.AddedAssignees = append(changes.AddedAssignees, assignee)
+			changed = true
+		}
+	}
+	for _, assignee := range edit.removeAssignees {
+		toggled, err := setIssueAssignee(e, doer, issue, assignee, false)
+		if err != nil {
+			return nil, false, err
+		} else if toggled {
+			changes.RemovedAssignees = append(changes.RemovedAssignees, assignee)
+			changed = true
+		}
+	}
+
+	if edit.MilestoneID != nil && issue.MilestoneID != *edit.MilestoneID {
+		changes.OldMilestoneID = issue.MilestoneID
+		changes.IsMilestoneChanged = true
+		issue.MilestoneID = *edit.MilestoneID
+		if err = changeMilestoneAssign(e, doer, issue, changes.OldMilestoneID); err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+
+	if edit.IsClosed != nil && issue.IsClosed != *edit.IsClosed {
+		canChange, err := issue.canChangeStatus(e, *edit.IsClosed)
+		if err != nil {
+			return nil, false, err
+		}
+		if canChange {
+			if changes.StatusComment, err = issue.changeStatus(e, doer, *edit.IsClosed); err != nil {
+				return nil, false, err
+			}
+			changed = true
+		}
+	}
+
+	if edit.IsLocked != nil && issue.IsLocked != *edit.IsLocked {
+		if err = issue.updateLock(e, doer, *edit.IsLocked, ""); err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+
+	if edit.DeadlineUnix != nil && issue.DeadlineUnix != *edit.DeadlineUnix {
+		if err = updateIssueDeadline(e, issue, *edit.DeadlineUnix, doer); err != nil {
+			return nil, false, err
+		}
+		issue.DeadlineUnix = *edit.DeadlineUnix
+		changed = true
+	}
+
+	// The issue is notified with its labels and assignees once changed
+	if len(changes.AddedLabels) > 0 || len(changes.RemovedLabels) > 0 {
+		issue.Labels = nil
+		if err = issue.loadLabels(e); err != nil {
+			return nil, false, err
+		}
+	}
+	if len(changes.AddedAssignees) > 0 || len(changes.RemovedAssignees) > 0 {
+		if err = issue.loadAssignees(e); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return changes, changed, nil
+}
+
+// canChangeStatus returns whether the status of the issue can be changed as by its
+// page: the status of merged pull requests cannot be changed, and a pull request
+// cannot be reopened when another one of its branches is open.
+func (issue *Issue) canChangeStatus(e Engine, isClosed bool) (bool, error) {
+	if !issue.IsPull {
+		return true, nil
+	}
+	if err := issue.loadPullRequest(e); err != nil {
+		return false, err
+	}
+	pr := issue.PullRequest
+	if pr.HasMerged {
+		return false, nil
+	}
+	if isClosed {
+		return true, nil
+	}
+	numOpen, err := e.
+		Join("INNER", "issue", "issue.id = pull_request.issue_id").
+		Where("pull_request.head_repo_id = ? AND pull_request.head_branch = ?", pr.HeadRepoID, pr.HeadBranch).
+		And("pull_request.base_repo_id = ? AND pull_request.base_branch = ?", pr.BaseRepoID, pr.BaseBranch).
+		And("pull_request.has_merged = ? AND issue.is_closed = ?", false, false).
+		Count(new(PullRequest))
+	return numOpen == 0, err
+}
+
+// setIssueAssignee assigns or unassigns the user to the issue, and returns whether it was toggled
+func setIssueAssignee(e *xorm.Session, doer *User, issue *Issue, assignee *User, assign bool) (bool, error) {
+	// toggleAssignee relies on the loaded assignees
+	if err := issue.loadAssignees(e); err != nil {
+		return false, err
+	}
+	isAssigned := false
+	for _, u := range issue.Assignees {
+		if u.ID == assignee.ID {
+			isAssigned = true
+			break
+		}
+	}
+	if isAssigned == assign {
+		return false, nil
+	}
+	if _, _, err := issue.toggleAssignee(e, doer, assignee.ID, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
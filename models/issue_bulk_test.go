@@ -0,0 +1,125 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkEditIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	issue1 := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	issue5 := AssertExistsAndLoadBean(t, &Issue{ID: 5}).(*Issue)
+
+	milestoneID := int64(3)
+	isClosed := true
+	deadline := timeutil.TimeStamp(1600000000)
+	edited, err := BulkEditIssues(doer, repo, []*Issue{issue1, issue5}, &BulkEditIssuesOptions{
+		AddLabelIDs:       []int64{2},
+		RemoveLabelIDs:    []int64{1},
+		AddAssigneeIDs:    []int64{2},
+		RemoveAssigneeIDs: []int64{1},
+		MilestoneID:       &milestoneID,
+		IsClosed:          &isClosed,
+		DeadlineUnix:      &deadline,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, edited, 2) {
+		// issue 5 was already closed
+		assert.NotNil(t, edited[0].StatusComment)
+		assert.Nil(t, edited[1].StatusComment)
+		assert.True(t, edited[0].IsMilestoneChanged)
+		if assert.Len(t, edited[0].AddedLabels, 1) {
+			assert.EqualValues(t, 2, edited[0].AddedLabels[0].ID)
+		}
+	}
+
+	for _, id := range []int64{1, 5} {
+		issue := AssertExistsAndLoadBean(t, &Issue{ID: id}).(*Issue)
+		assert.True(t, issue.IsClosed)
+		assert.EqualValues(t, 3, issue.MilestoneID)
+		assert.Equal(t, deadline, issue.DeadlineUnix)
+		AssertExistsAndLoadBean(t, &IssueLabel{IssueID: id, LabelID: 2})
+		AssertNotExistsBean(t, &IssueLabel{IssueID: id, LabelID: 1})
+		AssertExistsAndLoadBean(t, &IssueAssignees{IssueID: id, AssigneeID: 2})
+		AssertNotExistsBean(t, &IssueAssignees{IssueID: id, AssigneeID: 1})
+	}
+	AssertExistsAndLoadBean(t, &Comment{IssueID: 1, Type: CommentTypeClose, PosterID: 2})
+	AssertNotExistsBean(t, &Comment{IssueID: 5, Type: CommentTypeClose, PosterID: 2})
+	milestone := AssertExistsAndLoadBean(t, &Milestone{ID: 3}).(*Milestone)
+	assert.EqualValues(t, 2, milestone.NumIssues)
+	assert.EqualValues(t, 2, milestone.NumClosedIssues)
+
+	// nothing left to change
+	edited, err = BulkEditIssues(doer, repo, []*Issue{issue1, issue5}, &BulkEditIssuesOptions{IsClosed: &isClosed})
+	assert.NoError(t, err)
+	assert.Len(t, edited, 0)
+	CheckConsistencyFor(t, &Issue{}, &Label{}, &Milestone{})
+}
+
+func TestBulkEditPullRequestsStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	merged := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	pull := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+
+	// the status of merged pull requests is left unchanged
+	isClosed := true
+	edited, err := BulkEditIssues(doer, repo, []*Issue{merged, pull}, &BulkEditIssuesOptions{IsClosed: &isClosed})
+	assert.NoError(t, err)
+	if assert.Len(t, edited, 1) {
+		assert.EqualValues(t, 3, edited[0].ID)
+	}
+	AssertExistsAndLoadBean(t, &Issue{ID: 2, IsClosed: false})
+	AssertExistsAndLoadBean(t, &Issue{ID: 3, IsClosed: true})
+
+	// a pull request is not reopened while another one of its branches is open
+	other := &Issue{RepoID: repo.ID, PosterID: doer.ID, Poster: doer, Title: "other", IsPull: true}
+	assert.NoError(t, NewPullRequest(repo, other, nil, nil, &PullRequest{
+		HeadRepoID: repo.ID,
+		BaseRepoID: repo.ID,
+		HeadBranch: "branch2",
+		BaseBranch: "master",
+		Type:       PullRequestGitea,
+	}))
+	isClosed = false
+	pull = AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+	edited, err = BulkEditIssues(doer, repo, []*Issue{pull}, &BulkEditIssuesOptions{IsClosed: &isClosed})
+	assert.NoError(t, err)
+	assert.Len(t, edited, 0)
+	AssertExistsAndLoadBean(t, &Issue{ID: 3, IsClosed: true})
+}
+
+func TestBulkEditIssuesRollback(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	issue1 := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	issue4 := AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue)
+
+	// the issues of other repositories cannot be edited
+	_, err := BulkEditIssues(doer, repo, []*Issue{issue1, issue4}, &BulkEditIssuesOptions{AddLabelIDs: []int64{2}})
+	assert.True(t, IsErrIssueNotExist(err))
+	AssertNotExistsBean(t, &IssueLabel{IssueID: 1, LabelID: 2})
+
+	// the labels, milestones and assignees must belong to the repository
+	milestoneID := int64(4)
+	_, err = BulkEditIssues(doer, repo, []*Issue{issue1}, &BulkEditIssuesOptions{MilestoneID: &milestoneID})
+	assert.True(t, IsErrMilestoneNotExist(err))
+	_, err = BulkEditIssues(doer, repo, []*Issue{issue1}, &BulkEditIssuesOptions{AddLabelIDs: []int64{3}})
+	assert.True(t, IsErrLabelNotExist(err))
+	_, err = BulkEditIssues(doer, repo, []*Issue{issue1}, &BulkEditIssuesOptions{AddAssigneeIDs: []int64{4}})
+	assert.True(t, IsErrUserDoesNotHaveAccessToRepo(err))
+}
@@ -86,6 +86,10 @@ const (
 	CommentTypeChangeTargetBranch
 	// Delete time manual for time tracking
 	CommentTypeDeleteTimeManual
+	// Request or withdraw the review of a user or a team
+	CommentTypeReviewRequest
+	// Transfer the issue from another repository
+	CommentTypeTransfer
 )
 
 // CommentTag defines comment tag type
@@ -118,6 +122,8 @@ type Comment struct {
 	AssigneeID       int64
 	RemovedAssignee  bool
 	Assignee         *User `xorm:"-"`
+	AssigneeTeamID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	AssigneeTeam     *Team `xorm:"-"`
 	OldTitle         string
 	NewTitle         string
 	OldRef           string
@@ -177,7 +183,13 @@ func (c *Comment) loadIssue(e Engine) (err error) {
 }
 
 func (c *Comment) loadPoster(e Engine) (err error) {
-	if c.PosterID <= 0 || c.Poster != nil {
+	if c.Poster != nil {
+		return nil
+	} else if c.PosterID == -1 {
+		// posted by the system
+		c.Poster = NewGhostUser()
+		return nil
+	} else if c.PosterID <= 0 {
 		return nil
 	}
 
@@ -416,6 +428,22 @@ func (c *Comment) LoadAssigneeUser() error {
 	return nil
 }
 
+// LoadAssigneeTeam if comment.Type is CommentTypeReviewRequest, then load the requested team
+func (c *Comment) LoadAssigneeTeam() error {
+	var err error
+
+	if c.AssigneeTeamID > 0 && c.AssigneeTeam == nil {
+		c.AssigneeTeam, err = getTeamByID(x, c.AssigneeTeamID)
+		if err != nil {
+			if !IsErrTeamNotExist(err) {
+				return err
+			}
+			c.AssigneeTeam = &Team{Name: "Ghost"}
+		}
+	}
+	return nil
+}
+
 // LoadDepIssueDetails loads Dependent Issue Details
 func (c *Comment) LoadDepIssueDetails() (err error) {
 	if c.DependentIssueID <= 0 || c.DependentIssue != nil {
@@ -533,6 +561,7 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		MilestoneID:      opts.MilestoneID,
 		RemovedAssignee:  opts.RemovedAssignee,
 		AssigneeID:       opts.AssigneeID,
+		AssigneeTeamID:   opts.AssigneeTeamID,
 		CommitID:         opts.CommitID,
 		CommitSHA:        opts.CommitSHA,
 		Line:             opts.LineNum,
@@ -694,6 +723,7 @@ type CreateCommentOptions struct {
 	OldMilestoneID   int64
 	MilestoneID      int64
 	AssigneeID       int64
+	AssigneeTeamID   int64
 	RemovedAssignee  bool
 	OldTitle         string
 	NewTitle         string
@@ -41,7 +41,10 @@ func (comments CommentList) loadPosters(e Engine) error {
 	}
 
 	for _, comment := range comments {
-		if comment.PosterID <= 0 {
+		if comment.PosterID == -1 {
+			comment.Poster = NewGhostUser()
+			continue
+		} else if comment.PosterID <= 0 {
 			continue
 		}
 		var ok bool
@@ -350,6 +350,15 @@ func GetLabelsByIssueID(issueID int64) ([]*Label, error) {
 	return getLabelsByIssueID(x, issueID)
 }
 
+// GetLabelIDsByIssueID returns the IDs of the labels of an issue
+func GetLabelIDsByIssueID(issueID int64) ([]int64, error) {
+	labelIDs := make([]int64, 0, 5)
+	return labelIDs, x.Table("issue_label").
+		Cols("label_id").
+		Where("issue_id = ?", issueID).
+		Find(&labelIDs)
+}
+
 func updateLabel(e Engine, l *Label) error {
 	_, err := e.ID(l.ID).
 		SetExpr("num_issues",
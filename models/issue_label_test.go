@@ -160,6 +160,17 @@ func TestGetLabelsByIssueID(t *testing.T) {
 	assert.Len(t, labels, 0)
 }
 
+func TestGetLabelIDsByIssueID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	labelIDs, err := GetLabelIDsByIssueID(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{1}, labelIDs)
+
+	labelIDs, err = GetLabelIDsByIssueID(NonexistentID)
+	assert.NoError(t, err)
+	assert.Len(t, labelIDs, 0)
+}
+
 func TestUpdateLabel(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	label := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
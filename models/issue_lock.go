@@ -4,6 +4,8 @@
 
 package models
 
+import "xorm.io/xorm"
+
 // IssueLockOptions defines options for locking and/or unlocking an issue/PR
 type IssueLockOptions struct {
 	Doer   *User
@@ -27,34 +29,39 @@ func updateIssueLock(opts *IssueLockOptions, lock bool) error {
 		return nil
 	}
 
-	opts.Issue.IsLocked = lock
-	var commentType CommentType
-	if opts.Issue.IsLocked {
-		commentType = CommentTypeLock
-	} else {
-		commentType = CommentTypeUnlock
-	}
-
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
 		return err
 	}
 
-	if err := updateIssueCols(sess, opts.Issue, "is_locked"); err != nil {
+	if err := opts.Issue.updateLock(sess, opts.Doer, lock, opts.Reason); err != nil {
 		return err
 	}
 
-	var opt = &CreateCommentOptions{
-		Doer:    opts.Doer,
-		Issue:   opts.Issue,
-		Repo:    opts.Issue.Repo,
-		Type:    commentType,
-		Content: opts.Reason,
+	return sess.Commit()
+}
+
+func (issue *Issue) updateLock(e *xorm.Session, doer *User, lock bool, reason string) error {
+	issue.IsLocked = lock
+	var commentType CommentType
+	if issue.IsLocked {
+		commentType = CommentTypeLock
+	} else {
+		commentType = CommentTypeUnlock
 	}
-	if _, err := createComment(sess, opt); err != nil {
+
+	if err := updateIssueCols(e, issue, "is_locked"); err != nil {
 		return err
 	}
 
-	return sess.Commit()
+	var opt = &CreateCommentOptions{
+		Doer:    doer,
+		Issue:   issue,
+		Repo:    issue.Repo,
+		Type:    commentType,
+		Content: reason,
+	}
+	_, err := createComment(e, opt)
+	return err
 }
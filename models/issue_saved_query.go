@@ -0,0 +1,101 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"net/url"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// SavedIssueQuery represents a named issue search query of a user, which may
+// be shared with the members of one of the organizations of the user
+type SavedIssueQuery struct {
+	ID     int64 `xorm:"pk autoincr"`
+	UserID int64 `xorm:"INDEX NOT NULL"`
+	// OrgID is the organization the query is shared with, 0 if it is private
+	OrgID       int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Name        string             `xorm:"NOT NULL"`
+	Query       string             `xorm:"TEXT NOT NULL"`
+	IsPull      bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+
+	User *User `xorm:"-"`
+	Org  *User `xorm:"-"`
+}
+
+// Link returns the link to the issues or pull requests dashboard listing the
+// results of the query
+func (q *SavedIssueQuery) Link() string {
+	page := "/issues"
+	if q.IsPull {
+		page = "/pulls"
+	}
+	return setting.AppSubURL + page + "?q=" + url.QueryEscape(q.Query)
+}
+
+// CreateSavedIssueQuery saves a new issue search query
+func CreateSavedIssueQuery(q *SavedIssueQuery) error {
+	_, err := x.Insert(q)
+	return err
+}
+
+// GetSavedIssueQueryByID returns the saved issue search query by given ID
+func GetSavedIssueQueryByID(id int64) (*SavedIssueQuery, error) {
+	q := new(SavedIssueQuery)
+	has, err := x.ID(id).Get(q)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSavedIssueQueryNotExist{id}
+	}
+	return q, nil
+}
+
+// GetSavedIssueQueries returns the issue or pull request search queries saved
+// by the user or shared with the organizations the user is a member of,
+// ordered by name
+func GetSavedIssueQueries(user *User, isPull bool) ([]*SavedIssueQuery, error) {
+	queries := make([]*SavedIssueQuery, 0, 10)
+	if err := x.
+		Where("is_pull = ?", isPull).
+		And("user_id = ? OR org_id IN (SELECT org_id FROM org_user WHERE uid = ?)", user.ID, user.ID).
+		Asc("name").
+		Find(&queries); err != nil {
+		return nil, err
+	}
+
+	users := make(map[int64]*User)
+	for _, q := range queries {
+		for _, id := range []int64{q.UserID, q.OrgID} {
+			if _, ok := users[id]; ok || id == 0 {
+				continue
+			}
+			u, err := GetUserByID(id)
+			if err != nil && !IsErrUserNotExist(err) {
+				return nil, err
+			}
+			users[id] = u
+		}
+		q.User = users[q.UserID]
+		if q.OrgID > 0 {
+			q.Org = users[q.OrgID]
+		}
+	}
+	return queries, nil
+}
+
+// DeleteSavedIssueQuery deletes an issue search query saved by the user
+func DeleteSavedIssueQuery(userID, id int64) error {
+	deleted, err := x.Delete(&SavedIssueQuery{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	} else if deleted == 0 {
+		return ErrSavedIssueQueryNotExist{id}
+	}
+	return nil
+}
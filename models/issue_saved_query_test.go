@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSavedIssueQueries(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	queryIDs := func(userID int64, isPull bool) []int64 {
+		user := AssertExistsAndLoadBean(t, &User{ID: userID}).(*User)
+		queries, err := GetSavedIssueQueries(user, isPull)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(queries))
+		for _, q := range queries {
+			ids = append(ids, q.ID)
+		}
+		return ids
+	}
+
+	// the queries shared with an organization are visible to its members
+	assert.EqualValues(t, []int64{1, 2}, queryIDs(2, false))
+	assert.EqualValues(t, []int64{4}, queryIDs(2, true))
+	assert.EqualValues(t, []int64{2}, queryIDs(4, false))
+	assert.EqualValues(t, []int64{3}, queryIDs(5, true))
+	assert.EqualValues(t, []int64{}, queryIDs(1, false))
+
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	queries, err := GetSavedIssueQueries(user, false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, queries[1].User.ID)
+	assert.EqualValues(t, 3, queries[1].Org.ID)
+	assert.Nil(t, queries[0].Org)
+}
+
+func TestCreateSavedIssueQuery(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	q := &SavedIssueQuery{UserID: 1, Name: "Closed pulls", Query: `is:closed milestone:"v1.0"`, IsPull: true}
+	assert.NoError(t, CreateSavedIssueQuery(q))
+	AssertExistsAndLoadBean(t, &SavedIssueQuery{ID: q.ID, UserID: 1, Name: "Closed pulls"})
+	assert.Equal(t, setting.AppSubURL+"/pulls?q=is%3Aclosed+milestone%3A%22v1.0%22", q.Link())
+
+	loaded, err := GetSavedIssueQueryByID(q.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, q.Query, loaded.Query)
+
+	_, err = GetSavedIssueQueryByID(100)
+	assert.True(t, IsErrSavedIssueQueryNotExist(err))
+}
+
+func TestDeleteSavedIssueQuery(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// only the owner can delete a query
+	assert.True(t, IsErrSavedIssueQueryNotExist(DeleteSavedIssueQuery(4, 1)))
+	AssertExistsAndLoadBean(t, &SavedIssueQuery{ID: 1})
+
+	assert.NoError(t, DeleteSavedIssueQuery(2, 1))
+	AssertNotExistsBean(t, &SavedIssueQuery{ID: 1})
+}
@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"code.gitea.io/gitea/modules/util"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -166,6 +168,31 @@ func TestIssues(t *testing.T) {
 			},
 			[]int64{}, // issues with **both** label 1 and 2, none of these issues matches, TODO: add more tests
 		},
+		{
+			IssuesOptions{
+				RepoIDs:            []int64{1},
+				LabelNames:         []string{"Label1"},
+				ExcludedLabelNames: []string{"label2"},
+				SortType:           "oldest",
+			},
+			[]int64{1, 2},
+		},
+		{
+			IssuesOptions{
+				RepoIDs:       []int64{1},
+				MilestoneName: "milestone1",
+			},
+			[]int64{2},
+		},
+		{
+			IssuesOptions{
+				RepoIDs:           []int64{1},
+				CreatedAfterUnix:  946684810,
+				CreatedBeforeUnix: 946684840,
+				SortType:          "oldest",
+			},
+			[]int64{2, 3},
+		},
 	} {
 		issues, err := Issues(&test.Opts)
 		assert.NoError(t, err)
@@ -177,6 +204,24 @@ func TestIssues(t *testing.T) {
 	}
 }
 
+func TestCountIssueStats(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	stats, err := CountIssueStats(&IssuesOptions{
+		RepoIDs:     []int64{1},
+		IsPull:      util.OptionalBoolFalse,
+		ListOptions: ListOptions{Page: 1, PageSize: 1},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, stats.OpenCount)
+	assert.EqualValues(t, 1, stats.ClosedCount)
+
+	stats, err = CountIssueStats(&IssuesOptions{RepoIDs: []int64{1}, IsClosed: util.OptionalBoolTrue})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, stats.OpenCount)
+	assert.EqualValues(t, 1, stats.ClosedCount)
+}
+
 func TestGetUserIssueStats(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	for _, test := range []struct {
@@ -355,4 +400,12 @@ func TestIssue_ResolveMentions(t *testing.T) {
 	testSuccess("user17", "big_test_private_4", "user20", []string{"user5"}, []int64{})
 	// Private repo, whole team
 	testSuccess("user17", "big_test_private_4", "user15", []string{"owners"}, []int64{18})
+	// Team of another organization, doer is a member of it
+	testSuccess("user2", "repo1", "user2", []string{"user3/Team1"}, []int64{4})
+	// Team of another organization, doer isn't a member of it
+	testSuccess("user2", "repo1", "user5", []string{"user3/team1"}, []int64{})
+	// Non-existing team
+	testSuccess("user2", "repo1", "user2", []string{"user3/nonexisting"}, []int64{})
+	// Private repo, team members without access are removed
+	testSuccess("user17", "big_test_private_4", "user2", []string{"user3/team1"}, []int64{})
 }
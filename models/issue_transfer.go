@@ -0,0 +1,192 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"xorm.io/builder"
+)
+
+// IssueRedirect represents that the index of an issue of a repository should be
+// redirected to an issue transferred to another repository
+type IssueRedirect struct {
+	ID         int64 `xorm:"pk autoincr"`
+	RepoID     int64 `xorm:"UNIQUE(s) NOT NULL"`
+	IssueIndex int64 `xorm:"UNIQUE(s) NOT NULL"`
+	IssueID    int64 `xorm:"INDEX NOT NULL"` // issue to redirect to
+}
+
+// LookupIssueRedirect looks up the issue transferred from the index of the repository
+func LookupIssueRedirect(repoID, index int64) (int64, error) {
+	redirect := &IssueRedirect{RepoID: repoID, IssueIndex: index}
+	if has, err := x.Get(redirect); err != nil {
+		return 0, err
+	} else if !has {
+		return 0, ErrIssueRedirectNotExist{RepoID: repoID, Index: index}
+	}
+	return redirect.IssueID, nil
+}
+
+// nextIssueIndexExpr returns the expression of the next index of the issues of the
+// repository, selected from its issues. The indexes redirected to transferred issues
+// are never given again, so that their links keep pointing to them.
+func nextIssueIndexExpr(repoID int64) string {
+	redirected := fmt.Sprintf("(SELECT coalesce(MAX(issue_index),0) FROM issue_redirect WHERE repo_id = %d)", repoID)
+	return fmt.Sprintf("CASE WHEN coalesce(MAX(`index`),0) < %[1]s THEN %[1]s ELSE coalesce(MAX(`index`),0) END + 1", redirected)
+}
+
+// TransferIssue moves the issue to another repository with its comments, reactions,
+// attachments, tracked times, stopwatches, subscriptions and dependencies. Its labels
+// and milestone are mapped by name to the ones of the new repository, and the assignees
+// who cannot be assigned in the new repository are removed. The old index of the issue
+// is redirected to it, and the comment of the transfer is returned.
+func TransferIssue(doer *User, issue *Issue, newRepo *Repository) (comment *Comment, err error) {
+	if err = issue.LoadRepo(); err != nil {
+		return nil, err
+	}
+	oldRepo := issue.Repo
+	if issue.IsPull || newRepo.ID == oldRepo.ID || newRepo.IsArchived || !newRepo.UnitEnabled(UnitTypeIssues) {
+		return nil, ErrIssueCannotBeTransferred{IssueID: issue.ID, RepoID: newRepo.ID}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	labels, err := getLabelsByIssueID(sess, issue.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getLabelsByIssueID: %v", err)
+	}
+	if err = issue.loadAssignees(sess); err != nil {
+		return nil, fmt.Errorf("loadAssignees: %v", err)
+	}
+	oldMilestoneID := issue.MilestoneID
+	if oldMilestoneID > 0 {
+		milestone, err := getMilestoneByRepoID(sess, oldRepo.ID, oldMilestoneID)
+		if err != nil && !IsErrMilestoneNotExist(err) {
+			return nil, fmt.Errorf("getMilestoneByRepoID: %v", err)
+		}
+		issue.MilestoneID = 0
+		if milestone != nil {
+			newMilestone := new(Milestone)
+			if has, err := sess.Where("repo_id = ? AND name = ?", newRepo.ID, milestone.Name).Get(newMilestone); err != nil {
+				return nil, err
+			} else if has {
+				issue.MilestoneID = newMilestone.ID
+			}
+		}
+	}
+
+	var newIndex int64
+	if _, err = sess.Table("issue").Select(nextIssueIndexExpr(newRepo.ID)).Where("repo_id = ?", newRepo.ID).Get(&newIndex); err != nil {
+		return nil, err
+	}
+	oldIndex := issue.Index
+	issue.RepoID = newRepo.ID
+	issue.Repo = newRepo
+	issue.Index = newIndex
+	// The branch of the issue belongs to the old repository
+	issue.Ref = ""
+	if err = updateIssueCols(sess, issue, "repo_id", "index", "ref", "milestone_id"); err != nil {
+		return nil, err
+	}
+
+	for _, label := range labels {
+		newLabel, err := getLabelInRepoByName(sess, newRepo.ID, label.Name)
+		if err != nil && !IsErrLabelNotExist(err) {
+			return nil, fmt.Errorf("getLabelInRepoByName: %v", err)
+		}
+		if newLabel == nil {
+			if _, err = sess.Delete(&IssueLabel{IssueID: issue.ID, LabelID: label.ID}); err != nil {
+				return nil, err
+			}
+		} else {
+			if _, err = sess.Where("issue_id = ? AND label_id = ?", issue.ID, label.ID).
+				Cols("label_id").
+				Update(&IssueLabel{LabelID: newLabel.ID}); err != nil {
+				return nil, err
+			}
+			if err = updateLabel(sess, newLabel); err != nil {
+				return nil, fmt.Errorf("updateLabel: %v", err)
+			}
+		}
+		if err = updateLabel(sess, label); err != nil {
+			return nil, fmt.Errorf("updateLabel: %v", err)
+		}
+	}
+
+	if oldMilestoneID > 0 {
+		for _, id := range []int64{oldMilestoneID, issue.MilestoneID} {
+			if id == 0 {
+				continue
+			}
+			if err = updateMilestoneTotalNum(sess, id); err != nil {
+				return nil, err
+			}
+			if err = updateMilestoneClosedNum(sess, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, assignee := range issue.Assignees {
+		perm, err := getUserRepoPermission(sess, newRepo, assignee)
+		if err != nil {
+			return nil, err
+		}
+		if !perm.CanAccessAny(AccessModeWrite, UnitTypeCode, UnitTypeIssues, UnitTypePullRequests) {
+			if _, err = sess.Delete(&IssueAssignees{IssueID: issue.ID, AssigneeID: assignee.ID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	issue.Assignees = nil
+
+	if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues - 1 WHERE id = ?", oldRepo.ID); err != nil {
+		return nil, err
+	}
+	if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues + 1 WHERE id = ?", newRepo.ID); err != nil {
+		return nil, err
+	}
+	if err = (&Issue{RepoID: oldRepo.ID}).updateClosedNum(sess); err != nil {
+		return nil, err
+	}
+	if err = issue.updateClosedNum(sess); err != nil {
+		return nil, err
+	}
+
+	// The records of the issue keeping the ID of its repository
+	for _, table := range []string{"notification", "mail_digest_item", "stale_issue"} {
+		if _, err = sess.Exec(builder.Update(builder.Eq{"repo_id": newRepo.ID}).
+			From(table).
+			Where(builder.Eq{"issue_id": issue.ID})); err != nil {
+			return nil, fmt.Errorf("update %s: %v", table, err)
+		}
+	}
+	if err = issue.moveCrossReferences(sess); err != nil {
+		return nil, fmt.Errorf("moveCrossReferences: %v", err)
+	}
+
+	if _, err = sess.Insert(&IssueRedirect{RepoID: oldRepo.ID, IssueIndex: oldIndex, IssueID: issue.ID}); err != nil {
+		return nil, err
+	}
+
+	if comment, err = createComment(sess, &CreateCommentOptions{
+		Type:   CommentTypeTransfer,
+		Doer:   doer,
+		Repo:   newRepo,
+		Issue:  issue,
+		OldRef: fmt.Sprintf("%s#%d", oldRepo.FullName(), oldIndex),
+	}); err != nil {
+		return nil, err
+	}
+
+	issue.Labels = nil
+	issue.Milestone = nil
+	return comment, sess.Commit()
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferIssue(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo2 := AssertExistsAndLoadBean(t, &Repository{ID: 2}).(*Repository)
+
+	label := &Label{RepoID: 2, Name: "label1", Color: "#abcdef"}
+	assert.NoError(t, NewLabel(label))
+	milestone := &Milestone{RepoID: 2, Name: "milestone1"}
+	assert.NoError(t, NewMilestone(milestone))
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	issue.MilestoneID = 1
+	assert.NoError(t, ChangeMilestoneAssign(issue, doer, 0))
+
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	comment, err := TransferIssue(doer, issue, repo2)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2/repo1#1", comment.OldRef)
+	assert.EqualValues(t, 2, issue.RepoID)
+	assert.EqualValues(t, 3, issue.Index)
+
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 1, RepoID: 2, Index: 3}).(*Issue)
+	assert.Equal(t, milestone.ID, issue.MilestoneID)
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: 1, LabelID: label.ID})
+	AssertNotExistsBean(t, &IssueLabel{IssueID: 1, LabelID: 1})
+	AssertExistsAndLoadBean(t, &IssueAssignees{IssueID: 1, AssigneeID: 1})
+	AssertExistsAndLoadBean(t, &Comment{IssueID: 1, Type: CommentTypeTransfer, PosterID: 2, OldRef: "user2/repo1#1"})
+
+	assert.EqualValues(t, 1, AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label).NumIssues)
+	assert.EqualValues(t, 1, AssertExistsAndLoadBean(t, &Label{ID: label.ID}).(*Label).NumIssues)
+	assert.EqualValues(t, 1, AssertExistsAndLoadBean(t, &Milestone{ID: 1}).(*Milestone).NumIssues)
+	assert.EqualValues(t, 1, AssertExistsAndLoadBean(t, &Milestone{ID: milestone.ID}).(*Milestone).NumIssues)
+	assert.Equal(t, repo1.NumIssues-1, AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository).NumIssues)
+	assert.Equal(t, repo2.NumIssues+1, AssertExistsAndLoadBean(t, &Repository{ID: 2}).(*Repository).NumIssues)
+
+	issueID, err := LookupIssueRedirect(1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, issueID)
+	_, err = LookupIssueRedirect(1, 2)
+	assert.True(t, IsErrIssueRedirectNotExist(err))
+
+	// back to the first repository, the redirect of the old index is kept
+	_, err = TransferIssue(doer, issue, repo1)
+	assert.NoError(t, err)
+	AssertExistsAndLoadBean(t, &Issue{ID: 1, RepoID: 1, Index: 6})
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: 1, LabelID: 1})
+	issueID, err = LookupIssueRedirect(2, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, issueID)
+	issueID, err = LookupIssueRedirect(1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, issueID)
+}
+
+func TestTransferIssueIndexNotReused(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo2 := AssertExistsAndLoadBean(t, &Repository{ID: 2}).(*Repository)
+
+	// the newest issue of the repository is transferred
+	issue := &Issue{RepoID: repo1.ID, PosterID: doer.ID, Poster: doer, Title: "newest"}
+	assert.NoError(t, NewIssue(repo1, issue, nil, nil))
+	oldIndex := issue.Index
+	_, err := TransferIssue(doer, issue, repo2)
+	assert.NoError(t, err)
+
+	// its old index keeps being redirected to it
+	next := &Issue{RepoID: repo1.ID, PosterID: doer.ID, Poster: doer, Title: "next"}
+	assert.NoError(t, NewIssue(repo1, next, nil, nil))
+	assert.EqualValues(t, oldIndex+1, next.Index)
+	issueID, err := LookupIssueRedirect(repo1.ID, oldIndex)
+	assert.NoError(t, err)
+	assert.Equal(t, issue.ID, issueID)
+
+	// as well as when the issue is transferred back
+	_, err = TransferIssue(doer, issue, repo1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, oldIndex+2, issue.Index)
+}
+
+func TestTransferIssueNotAllowed(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo2 := AssertExistsAndLoadBean(t, &Repository{ID: 2}).(*Repository)
+
+	pull := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	_, err := TransferIssue(doer, pull, repo2)
+	assert.True(t, IsErrIssueCannotBeTransferred(err))
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	_, err = TransferIssue(doer, issue, repo1)
+	assert.True(t, IsErrIssueCannotBeTransferred(err))
+	AssertExistsAndLoadBean(t, &Issue{ID: 1, RepoID: 1, Index: 1})
+}
@@ -235,6 +235,13 @@ func (issue *Issue) verifyReferencedIssue(e Engine, ctx *crossReferencesContext,
 	return refIssue, refAction, nil
 }
 
+// moveCrossReferences updates the references created by the issue and its comments
+// to the repository the issue was transferred to
+func (issue *Issue) moveCrossReferences(e Engine) error {
+	_, err := e.Exec("UPDATE `comment` SET ref_repo_id = ? WHERE ref_issue_id = ?", issue.RepoID, issue.ID)
+	return err
+}
+
 // _________                                       __
 // \_   ___ \  ____   _____   _____   ____   _____/  |_
 // /    \  \/ /  _ \ /     \ /     \_/ __ \ /    \   __\
@@ -323,7 +330,14 @@ func (comment *Comment) RefIssueIdent() string {
 		log.Error("LoadRefIssue(%d): %v", comment.RefCommentID, err)
 		return ""
 	}
-	// FIXME: check this name for cross-repository references (#7901 if it gets merged)
+	if err := comment.LoadIssue(); err != nil { // Silently dropping errors :unamused:
+		log.Error("LoadIssue(%d): %v", comment.IssueID, err)
+		return ""
+	}
+	// References from other repositories, e.g. to transferred issues
+	if comment.RefIssue.RepoID != comment.Issue.RepoID {
+		return comment.RefIssue.Repo.FullName() + "#" + com.ToStr(comment.RefIssue.Index)
+	}
 	return "#" + com.ToStr(comment.RefIssue.Index)
 }
 
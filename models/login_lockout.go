@@ -0,0 +1,119 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// LoginLockoutType represents what is locked out
+type LoginLockoutType int
+
+const (
+	// LoginLockoutAccount locks out sign-ins to an account
+	LoginLockoutAccount LoginLockoutType = iota + 1
+	// LoginLockoutIP locks out sign-ins from an IP address
+	LoginLockoutIP
+)
+
+// LoginLockout represents an account or IP address whose password sign-ins are
+// refused after too many failed attempts.
+type LoginLockout struct {
+	ID             int64            `xorm:"pk autoincr"`
+	Type           LoginLockoutType `xorm:"UNIQUE(s) NOT NULL"`
+	Target         string           `xorm:"UNIQUE(s) NOT NULL"`
+	FailedAttempts int64
+	LockedUntil    timeutil.TimeStamp `xorm:"INDEX NOT NULL"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+}
+
+// IsAccount returns true if the lockout is for an account
+func (l *LoginLockout) IsAccount() bool {
+	return l.Type == LoginLockoutAccount
+}
+
+// GetActiveLoginLockout returns the active lockout of the given account or IP address,
+// it returns nil if there is none.
+func GetActiveLoginLockout(tp LoginLockoutType, target string) (*LoginLockout, error) {
+	lockout := new(LoginLockout)
+	has, err := x.
+		Where("type = ? AND target = ? AND locked_until > ?", tp, target, timeutil.TimeStampNow()).
+		Get(lockout)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return lockout, nil
+}
+
+// LockLogin locks sign-ins for the given account or IP address until the given time.
+func LockLogin(tp LoginLockoutType, target string, failedAttempts int64, until timeutil.TimeStamp) (*LoginLockout, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	// Expired lockouts are of no interest anymore
+	if _, err := sess.Where("locked_until <= ?", timeutil.TimeStampNow()).Delete(new(LoginLockout)); err != nil {
+		return nil, err
+	}
+
+	lockout := new(LoginLockout)
+	has, err := sess.Where("type = ? AND target = ?", tp, target).Get(lockout)
+	if err != nil {
+		return nil, err
+	}
+	lockout.FailedAttempts = failedAttempts
+	lockout.LockedUntil = until
+	if has {
+		if _, err = sess.ID(lockout.ID).Cols("failed_attempts", "locked_until").Update(lockout); err != nil {
+			return nil, err
+		}
+	} else {
+		lockout.Type = tp
+		lockout.Target = target
+		if _, err = sess.Insert(lockout); err != nil {
+			return nil, err
+		}
+	}
+	return lockout, sess.Commit()
+}
+
+// FindActiveLoginLockouts returns the active lockouts, the most recent first
+func FindActiveLoginLockouts(listOptions ListOptions) ([]*LoginLockout, int64, error) {
+	now := timeutil.TimeStampNow()
+	count, err := x.Where("locked_until > ?", now).Count(new(LoginLockout))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where("locked_until > ?", now).Desc("updated_unix")
+	if listOptions.Page != 0 {
+		sess = listOptions.setSessionPagination(sess)
+	}
+	lockouts := make([]*LoginLockout, 0, 10)
+	return lockouts, count, sess.Find(&lockouts)
+}
+
+// GetLoginLockoutByID returns the lockout with given ID
+func GetLoginLockoutByID(id int64) (*LoginLockout, error) {
+	lockout := new(LoginLockout)
+	has, err := x.ID(id).Get(lockout)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrLoginLockoutNotExist{id}
+	}
+	return lockout, nil
+}
+
+// DeleteLoginLockout removes a lockout
+func DeleteLoginLockout(id int64) error {
+	_, err := x.ID(id).Delete(new(LoginLockout))
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// MailDigestItem is a notification mail withheld until the next digest of its recipient
+type MailDigestItem struct {
+	ID        int64 `xorm:"pk autoincr"`
+	UserID    int64 `xorm:"INDEX NOT NULL"`
+	RepoID    int64 `xorm:"INDEX NOT NULL"`
+	IssueID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	CommentID int64 `xorm:"NOT NULL DEFAULT 0"`
+	ReleaseID int64 `xorm:"NOT NULL DEFAULT 0"`
+	DoerID    int64 `xorm:"NOT NULL DEFAULT 0"`
+	// ActionName is the name of the action of the mail, e.g. "comment" or "close"
+	ActionName  string             `xorm:"VARCHAR(20)"`
+	Content     string             `xorm:"LONGTEXT"`
+	Link        string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// CreateMailDigestItems withholds notification mails until the next digests of their recipients
+func CreateMailDigestItems(items []*MailDigestItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	_, err := x.Insert(&items)
+	return err
+}
+
+// GetMailDigestUserIDs returns the IDs of the users with pending digest items
+// who get their digests at one of the frequencies
+func GetMailDigestUserIDs(frequencies ...string) ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("mail_digest_item").
+		Join("INNER", "`user`", "`user`.id = mail_digest_item.user_id").
+		Where(builder.In("`user`.email_notifications_digest", frequencies)).
+		Distinct("mail_digest_item.user_id").
+		Find(&ids)
+}
+
+// GetMailDigestItems returns the pending digest items of the user, oldest first
+func GetMailDigestItems(userID int64) ([]*MailDigestItem, error) {
+	items := make([]*MailDigestItem, 0, 10)
+	return items, x.Where("user_id = ?", userID).Asc("id").Find(&items)
+}
+
+// DeleteMailDigestItems deletes the digest items of the user up to the one with the ID
+func DeleteMailDigestItems(userID, maxID int64) error {
+	_, err := x.Where("user_id = ? AND id <= ?", userID, maxID).Delete(new(MailDigestItem))
+	return err
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailDigestItems(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, user2.SetEmailDigest(EmailDigestDaily))
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+	assert.NoError(t, user4.SetEmailDigest(EmailDigestHourly))
+
+	assert.NoError(t, CreateMailDigestItems([]*MailDigestItem{
+		{UserID: 2, RepoID: 1, IssueID: 1, ActionName: "comment"},
+		{UserID: 4, RepoID: 1, IssueID: 1, ActionName: "comment"},
+		{UserID: 2, RepoID: 1, IssueID: 2, ActionName: "close"},
+	}))
+
+	ids, err := GetMailDigestUserIDs(EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids)
+	ids, err = GetMailDigestUserIDs(EmailDigestHourly, EmailDigestNone)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4}, ids)
+
+	items, err := GetMailDigestItems(2)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.EqualValues(t, 1, items[0].IssueID)
+		assert.EqualValues(t, 2, items[1].IssueID)
+	}
+
+	// the items queued since the digest was composed are kept
+	assert.NoError(t, DeleteMailDigestItems(2, items[0].ID))
+	items, err = GetMailDigestItems(2)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
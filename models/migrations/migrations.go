@@ -188,6 +188,40 @@ var migrations = []Migration{
 	NewMigration("Fix topic repository count", fixTopicRepositoryCount),
 	// v127 -> v128
 	NewMigration("add repository code language statistics", addLanguageStats),
+	// v128 -> v129
+	NewMigration("add trusted SSH certificate authorities", addSSHCertificateAuthorities),
+	// v129 -> v130
+	NewMigration("add login lockouts", addLoginLockouts),
+	// v130 -> v131
+	NewMigration("add require_two_factor to user", addRequireTwoFactorColumnForUser),
+	// v131 -> v132
+	NewMigration("add audit events", addAuditEvents),
+	// v132 -> v133
+	NewMigration("add container registry", addContainerRegistry),
+	// v133 -> v134
+	NewMigration("add package registry", addPackageRegistry),
+	// v134 -> v135
+	NewMigration("add CI runs, jobs and runners", addCI),
+	// v135 -> v136
+	NewMigration("recalculate language stats honoring .gitattributes", recalculateLanguageStats),
+	// v136 -> v137
+	NewMigration("add commit indexer status", addCommitIndexerStatus),
+	// v137 -> v138
+	NewMigration("add saved issue queries", addSavedIssueQuery),
+	// v138 -> v139
+	NewMigration("add email notification digests and repository notification preferences", addEmailDigestsAndRepoNotificationPreferences),
+	// v139 -> v140
+	NewMigration("add web push subscriptions", addWebPushSubscriptions),
+	// v140 -> v141
+	NewMigration("add reason to notifications", addNotificationReason),
+	// v141 -> v142
+	NewMigration("add review requests", addReviewRequests),
+	// v142 -> v143
+	NewMigration("add reminders", addReminders),
+	// v143 -> v144
+	NewMigration("add stale rules", addStaleRules),
+	// v144 -> v145
+	NewMigration("add issue redirect", addIssueRedirect),
 }
 
 // Migrate database to current version
@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addSSHCertificateAuthorities(x *xorm.Engine) error {
+	// SSHCertificateAuthority see models/ssh_key_ca.go
+	type SSHCertificateAuthority struct {
+		ID          int64              `xorm:"pk autoincr"`
+		Name        string             `xorm:"UNIQUE NOT NULL"`
+		Fingerprint string             `xorm:"UNIQUE NOT NULL"`
+		Content     string             `xorm:"TEXT NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(SSHCertificateAuthority)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
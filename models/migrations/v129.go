@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addLoginLockouts(x *xorm.Engine) error {
+	// LoginLockout see models/login_lockout.go
+	type LoginLockout struct {
+		ID             int64  `xorm:"pk autoincr"`
+		Type           int    `xorm:"UNIQUE(s) NOT NULL"`
+		Target         string `xorm:"UNIQUE(s) NOT NULL"`
+		FailedAttempts int64
+		LockedUntil    timeutil.TimeStamp `xorm:"INDEX NOT NULL"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	if err := x.Sync2(new(LoginLockout)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
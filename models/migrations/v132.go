@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addContainerRegistry(x *xorm.Engine) error {
+	type ContainerImage struct {
+		ID          int64              `xorm:"pk autoincr"`
+		OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Name        string             `xorm:"UNIQUE(s) NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ContainerBlob struct {
+		ID          int64              `xorm:"pk autoincr"`
+		ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Digest      string             `xorm:"VARCHAR(100) UNIQUE(s) INDEX NOT NULL"`
+		Size        int64              `xorm:"NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	type ContainerManifest struct {
+		ID          int64              `xorm:"pk autoincr"`
+		ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Digest      string             `xorm:"VARCHAR(100) UNIQUE(s) INDEX NOT NULL"`
+		MediaType   string             `xorm:"NOT NULL"`
+		Size        int64              `xorm:"NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	type ContainerManifestReference struct {
+		ID         int64  `xorm:"pk autoincr"`
+		ManifestID int64  `xorm:"INDEX NOT NULL"`
+		Digest     string `xorm:"VARCHAR(100) INDEX NOT NULL"`
+	}
+
+	type ContainerTag struct {
+		ID          int64              `xorm:"pk autoincr"`
+		ImageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Name        string             `xorm:"VARCHAR(128) UNIQUE(s) NOT NULL"`
+		ManifestID  int64              `xorm:"INDEX NOT NULL"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ContainerUpload struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UUID        string             `xorm:"uuid UNIQUE NOT NULL"`
+		ImageID     int64              `xorm:"INDEX NOT NULL"`
+		Size        int64              `xorm:"NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	if err := x.Sync2(new(ContainerImage), new(ContainerBlob), new(ContainerManifest),
+		new(ContainerManifestReference), new(ContainerTag), new(ContainerUpload)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addPackageRegistry(x *xorm.Engine) error {
+	type Package struct {
+		ID          int64              `xorm:"pk autoincr"`
+		OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Type        int                `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Name        string             `xorm:"NOT NULL"`
+		LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type PackageVersion struct {
+		ID            int64              `xorm:"pk autoincr"`
+		PackageID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Version       string             `xorm:"NOT NULL"`
+		LowerVersion  string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		CreatorID     int64              `xorm:"INDEX NOT NULL"`
+		RepoID        int64              `xorm:"INDEX"`
+		Metadata      string             `xorm:"TEXT"`
+		DownloadCount int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type PackageFile struct {
+		ID          int64              `xorm:"pk autoincr"`
+		VersionID   int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Name        string             `xorm:"NOT NULL"`
+		LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Size        int64              `xorm:"NOT NULL"`
+		HashMD5     string             `xorm:"hash_md5 CHAR(32) NOT NULL"`
+		HashSHA1    string             `xorm:"hash_sha1 CHAR(40) NOT NULL"`
+		HashSHA256  string             `xorm:"hash_sha256 CHAR(64) INDEX NOT NULL"`
+		HashSHA512  string             `xorm:"hash_sha512 CHAR(128) NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(Package), new(PackageVersion), new(PackageFile)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
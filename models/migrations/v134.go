@@ -0,0 +1,99 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addCI(x *xorm.Engine) error {
+	type CIRun struct {
+		ID            int64  `xorm:"pk autoincr"`
+		RepoID        int64  `xorm:"UNIQUE(repo_index) INDEX NOT NULL"`
+		Index         int64  `xorm:"UNIQUE(repo_index) NOT NULL"`
+		WorkflowID    string `xorm:"NOT NULL"`
+		WorkflowName  string
+		Title         string
+		TriggerUserID int64  `xorm:"INDEX"`
+		Event         string `xorm:"NOT NULL"`
+		Ref           string `xorm:"NOT NULL"`
+		CommitSHA     string `xorm:"VARCHAR(40) INDEX NOT NULL"`
+		IsForkPull    bool   `xorm:"INDEX NOT NULL DEFAULT false"`
+		NeedApproval  bool   `xorm:"NOT NULL DEFAULT false"`
+		ApprovedByID  int64
+		Status        int                `xorm:"INDEX NOT NULL"`
+		StartedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+		StoppedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+		CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type CIJob struct {
+		ID             int64             `xorm:"pk autoincr"`
+		RunID          int64             `xorm:"INDEX NOT NULL"`
+		RepoID         int64             `xorm:"INDEX NOT NULL"`
+		JobID          string            `xorm:"NOT NULL"`
+		Name           string            `xorm:"NOT NULL"`
+		Needs          []string          `xorm:"TEXT JSON"`
+		Labels         []string          `xorm:"TEXT JSON"`
+		Env            map[string]string `xorm:"TEXT JSON"`
+		Status         int               `xorm:"INDEX NOT NULL"`
+		RunnerID       int64             `xorm:"INDEX"`
+		TokenHash      string
+		TokenSalt      string
+		TokenLastEight string `xorm:"INDEX token_last_eight"`
+		StartedUnix    timeutil.TimeStamp
+		StoppedUnix    timeutil.TimeStamp
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type CIStep struct {
+		ID          int64             `xorm:"pk autoincr"`
+		JobID       int64             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Index       int               `xorm:"UNIQUE(s) NOT NULL"`
+		Name        string            `xorm:"NOT NULL"`
+		Command     string            `xorm:"TEXT"`
+		Env         map[string]string `xorm:"TEXT JSON"`
+		Status      int               `xorm:"NOT NULL"`
+		LogLength   int64             `xorm:"NOT NULL DEFAULT 0"`
+		LogSize     int64             `xorm:"NOT NULL DEFAULT 0"`
+		StartedUnix timeutil.TimeStamp
+		StoppedUnix timeutil.TimeStamp
+	}
+
+	type CIRunnerToken struct {
+		ID             int64  `xorm:"pk autoincr"`
+		RepoID         int64  `xorm:"INDEX"`
+		TokenHash      string `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string             `xorm:"INDEX token_last_eight"`
+		IsActive       bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type CIRunner struct {
+		ID             int64    `xorm:"pk autoincr"`
+		UUID           string   `xorm:"CHAR(36) UNIQUE NOT NULL"`
+		Name           string   `xorm:"NOT NULL"`
+		RepoID         int64    `xorm:"INDEX"`
+		Labels         []string `xorm:"TEXT JSON"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string             `xorm:"INDEX token_last_eight"`
+		LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	if err := x.Sync2(new(CIRun), new(CIJob), new(CIStep), new(CIRunnerToken), new(CIRunner)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+func recalculateLanguageStats(x *xorm.Engine) error {
+	// the stats indexer queues the repositories without a status on start,
+	// the language stats now honor the linguist overrides of .gitattributes
+	_, err := x.Exec(builder.Delete(builder.Eq{"`indexer_type`": 1}).From("`repo_indexer_status`"))
+	return err
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addCommitIndexerStatus(x *xorm.Engine) error {
+	type CommitIndexerStatus struct {
+		ID          int64    `xorm:"pk autoincr"`
+		RepoID      int64    `xorm:"UNIQUE NOT NULL"`
+		BranchHeads []string `xorm:"TEXT JSON"`
+	}
+
+	if err := x.Sync2(new(CommitIndexerStatus)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addEmailDigestsAndRepoNotificationPreferences(x *xorm.Engine) error {
+	type User struct {
+		EmailNotificationsDigest string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'none'"`
+	}
+
+	type RepoNotificationPreference struct {
+		ID     int64 `xorm:"pk autoincr"`
+		UserID int64 `xorm:"UNIQUE(s)"`
+		RepoID int64 `xorm:"UNIQUE(s) INDEX"`
+		Level  int8  `xorm:"SMALLINT NOT NULL DEFAULT 0"`
+	}
+
+	type MailDigestItem struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"INDEX NOT NULL"`
+		RepoID      int64              `xorm:"INDEX NOT NULL"`
+		IssueID     int64              `xorm:"NOT NULL DEFAULT 0"`
+		CommentID   int64              `xorm:"NOT NULL DEFAULT 0"`
+		ReleaseID   int64              `xorm:"NOT NULL DEFAULT 0"`
+		DoerID      int64              `xorm:"NOT NULL DEFAULT 0"`
+		ActionName  string             `xorm:"VARCHAR(20)"`
+		Content     string             `xorm:"LONGTEXT"`
+		Link        string             `xorm:"TEXT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(User), new(RepoNotificationPreference), new(MailDigestItem)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
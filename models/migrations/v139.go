@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addWebPushSubscriptions(x *xorm.Engine) error {
+	type WebPushSubscription struct {
+		ID          int64              `xorm:"pk autoincr"`
+		UserID      int64              `xorm:"INDEX NOT NULL"`
+		Endpoint    string             `xorm:"TEXT NOT NULL"`
+		P256DH      string             `xorm:"'p256dh' VARCHAR(100) NOT NULL"`
+		Auth        string             `xorm:"VARCHAR(50) NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(WebPushSubscription)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addNotificationReason(x *xorm.Engine) error {
+	type Notification struct {
+		ID     int64 `xorm:"pk autoincr"`
+		Reason uint8 `xorm:"SMALLINT INDEX NOT NULL DEFAULT 1"`
+	}
+
+	// The existing notifications are considered subscription updates
+	if err := x.Sync2(new(Notification)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
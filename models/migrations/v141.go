@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addReviewRequests(x *xorm.Engine) error {
+	type ReviewRequest struct {
+		ID             int64              `xorm:"pk autoincr"`
+		IssueID        int64              `xorm:"INDEX NOT NULL"`
+		ReviewerID     int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		ReviewerTeamID int64              `xorm:"INDEX NOT NULL DEFAULT 0"`
+		DoerID         int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type Comment struct {
+		ID             int64 `xorm:"pk autoincr"`
+		AssigneeTeamID int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	type Team struct {
+		ID               int64 `xorm:"pk autoincr"`
+		ReviewAssignment int   `xorm:"NOT NULL DEFAULT 0"`
+		LastReviewerID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(ReviewRequest)); err != nil {
+		return fmt.Errorf("Sync2 ReviewRequest: %v", err)
+	}
+	if err := x.Sync2(new(Comment)); err != nil {
+		return fmt.Errorf("Sync2 Comment: %v", err)
+	}
+	if err := x.Sync2(new(Team)); err != nil {
+		return fmt.Errorf("Sync2 Team: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addReminders(x *xorm.Engine) error {
+	type ReminderRule struct {
+		ID          int64              `xorm:"pk autoincr"`
+		RepoID      int64              `xorm:"UNIQUE(s) NOT NULL"`
+		Type        int                `xorm:"UNIQUE(s) NOT NULL"`
+		Enabled     bool               `xorm:"NOT NULL DEFAULT false"`
+		Days        int                `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type SentReminder struct {
+		ID          int64              `xorm:"pk autoincr"`
+		IssueID     int64              `xorm:"UNIQUE(s) NOT NULL"`
+		UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		Name        string             `xorm:"UNIQUE(s) VARCHAR(50) NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type User struct {
+		ID               int64 `xorm:"pk autoincr"`
+		DisableReminders bool  `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	if err := x.Sync2(new(ReminderRule)); err != nil {
+		return fmt.Errorf("Sync2 ReminderRule: %v", err)
+	}
+	if err := x.Sync2(new(SentReminder)); err != nil {
+		return fmt.Errorf("Sync2 SentReminder: %v", err)
+	}
+	if err := x.Sync2(new(User)); err != nil {
+		return fmt.Errorf("Sync2 User: %v", err)
+	}
+	return nil
+}
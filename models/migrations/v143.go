@@ -0,0 +1,50 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addStaleRules(x *xorm.Engine) error {
+	type StaleRule struct {
+		ID               int64              `xorm:"pk autoincr"`
+		RepoID           int64              `xorm:"UNIQUE NOT NULL"`
+		Enabled          bool               `xorm:"NOT NULL DEFAULT false"`
+		Only             string             `xorm:"VARCHAR(10) NOT NULL DEFAULT ''"`
+		DaysUntilStale   int                `xorm:"NOT NULL DEFAULT 60"`
+		DaysUntilClose   int                `xorm:"NOT NULL DEFAULT 7"`
+		OnlyLabels       string             `xorm:"TEXT"`
+		ExemptLabels     string             `xorm:"TEXT"`
+		ExemptMilestones bool               `xorm:"NOT NULL DEFAULT false"`
+		ExemptAssignees  bool               `xorm:"NOT NULL DEFAULT false"`
+		StaleLabel       string             `xorm:"NOT NULL DEFAULT 'stale'"`
+		MarkComment      string             `xorm:"TEXT"`
+		CloseComment     string             `xorm:"TEXT"`
+		LimitPerRun      int                `xorm:"NOT NULL DEFAULT 30"`
+		CreatedUnix      timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix      timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type StaleIssue struct {
+		ID          int64              `xorm:"pk autoincr"`
+		RepoID      int64              `xorm:"INDEX NOT NULL"`
+		IssueID     int64              `xorm:"UNIQUE NOT NULL"`
+		MarkedUnix  timeutil.TimeStamp `xorm:"NOT NULL"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(StaleRule)); err != nil {
+		return fmt.Errorf("Sync2 StaleRule: %v", err)
+	}
+	if err := x.Sync2(new(StaleIssue)); err != nil {
+		return fmt.Errorf("Sync2 StaleIssue: %v", err)
+	}
+	return nil
+}
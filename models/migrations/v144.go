@@ -0,0 +1,25 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+func addIssueRedirect(x *xorm.Engine) error {
+	type IssueRedirect struct {
+		ID         int64 `xorm:"pk autoincr"`
+		RepoID     int64 `xorm:"UNIQUE(s) NOT NULL"`
+		IssueIndex int64 `xorm:"UNIQUE(s) NOT NULL"`
+		IssueID    int64 `xorm:"INDEX NOT NULL"`
+	}
+
+	if err := x.Sync2(new(IssueRedirect)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -60,6 +60,23 @@ func init() {
 	tables = append(tables,
 		new(User),
 		new(PublicKey),
+		new(SSHCertificateAuthority),
+		new(LoginLockout),
+		new(AuditEvent),
+		new(ContainerImage),
+		new(ContainerBlob),
+		new(ContainerManifest),
+		new(ContainerManifestReference),
+		new(ContainerTag),
+		new(ContainerUpload),
+		new(Package),
+		new(PackageVersion),
+		new(PackageFile),
+		new(CIRun),
+		new(CIJob),
+		new(CIStep),
+		new(CIRunnerToken),
+		new(CIRunner),
 		new(AccessToken),
 		new(Repository),
 		new(DeployKey),
@@ -105,6 +122,7 @@ func init() {
 		new(TrackedTime),
 		new(DeletedBranch),
 		new(RepoIndexerStatus),
+		new(CommitIndexerStatus),
 		new(IssueDependency),
 		new(LFSLock),
 		new(Reaction),
@@ -112,11 +130,21 @@ func init() {
 		new(U2FRegistration),
 		new(TeamUnit),
 		new(Review),
+		new(ReviewRequest),
+		new(ReminderRule),
+		new(SentReminder),
+		new(StaleRule),
+		new(StaleIssue),
+		new(IssueRedirect),
 		new(OAuth2Application),
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
 		new(Task),
 		new(LanguageStat),
+		new(SavedIssueQuery),
+		new(RepoNotificationPreference),
+		new(MailDigestItem),
+		new(WebPushSubscription),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
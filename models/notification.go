@@ -7,6 +7,7 @@ package models
 import (
 	"fmt"
 	"path"
+	"strings"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -22,6 +23,8 @@ type (
 	NotificationStatus uint8
 	// NotificationSource is the source of the notification (issue, PR, commit, etc)
 	NotificationSource uint8
+	// NotificationReason is the reason why the user was notified (subscription, mention, etc)
+	NotificationReason uint8
 )
 
 const (
@@ -42,6 +45,61 @@ const (
 	NotificationSourceCommit
 )
 
+// The reasons are ordered by precedence: when several apply, the notification
+// gets the highest one
+const (
+	// NotificationReasonSubscribed is for the watchers of the issue or of the repository
+	NotificationReasonSubscribed NotificationReason = iota + 1
+	// NotificationReasonAuthor is for the author of the issue
+	NotificationReasonAuthor
+	// NotificationReasonAssigned is for the assignees of the issue
+	NotificationReasonAssigned
+	// NotificationReasonTeamMention is for the members of a team mentioned in the issue
+	NotificationReasonTeamMention
+	// NotificationReasonMention is for the users mentioned in the issue
+	NotificationReasonMention
+	// NotificationReasonReviewRequested is for the users requested to review the pull request
+	NotificationReasonReviewRequested
+	// NotificationReasonReminder is for the users getting a scheduled reminder of the issue
+	NotificationReasonReminder
+)
+
+var notificationReasonNames = map[NotificationReason]string{
+	NotificationReasonSubscribed:      "subscribed",
+	NotificationReasonAuthor:          "author",
+	NotificationReasonAssigned:        "assign",
+	NotificationReasonTeamMention:     "team_mention",
+	NotificationReasonMention:         "mention",
+	NotificationReasonReviewRequested: "review_requested",
+	NotificationReasonReminder:        "reminder",
+}
+
+// NotificationReasons are the notification reasons, in the order they are shown
+var NotificationReasons = []NotificationReason{
+	NotificationReasonReminder,
+	NotificationReasonReviewRequested,
+	NotificationReasonMention,
+	NotificationReasonTeamMention,
+	NotificationReasonAssigned,
+	NotificationReasonAuthor,
+	NotificationReasonSubscribed,
+}
+
+// Name returns the name of the reason, as used by the API and the filters of the UI
+func (r NotificationReason) Name() string {
+	return notificationReasonNames[r]
+}
+
+// NotificationReasonFromName returns the reason of the name, and whether it exists
+func NotificationReasonFromName(name string) (NotificationReason, bool) {
+	for reason, n := range notificationReasonNames {
+		if n == name {
+			return reason, true
+		}
+	}
+	return 0, false
+}
+
 // Notification represents a notification
 type Notification struct {
 	ID     int64 `xorm:"pk autoincr"`
@@ -50,6 +108,7 @@ type Notification struct {
 
 	Status NotificationStatus `xorm:"SMALLINT INDEX NOT NULL"`
 	Source NotificationSource `xorm:"SMALLINT INDEX NOT NULL"`
+	Reason NotificationReason `xorm:"SMALLINT INDEX NOT NULL DEFAULT 1"`
 
 	IssueID   int64  `xorm:"INDEX NOT NULL"`
 	CommitID  string `xorm:"INDEX"`
@@ -73,6 +132,9 @@ type FindNotificationOptions struct {
 	RepoID            int64
 	IssueID           int64
 	Status            NotificationStatus
+	Statuses          []NotificationStatus
+	Reasons           []NotificationReason
+	Sources           []NotificationSource
 	UpdatedAfterUnix  int64
 	UpdatedBeforeUnix int64
 }
@@ -92,6 +154,15 @@ func (opts *FindNotificationOptions) ToCond() builder.Cond {
 	if opts.Status != 0 {
 		cond = cond.And(builder.Eq{"notification.status": opts.Status})
 	}
+	if len(opts.Statuses) > 0 {
+		cond = cond.And(builder.In("notification.status", opts.Statuses))
+	}
+	if len(opts.Reasons) > 0 {
+		cond = cond.And(builder.In("notification.reason", opts.Reasons))
+	}
+	if len(opts.Sources) > 0 {
+		cond = cond.And(builder.In("notification.source", opts.Sources))
+	}
 	if opts.UpdatedAfterUnix != 0 {
 		cond = cond.And(builder.Gte{"notification.updated_unix": opts.UpdatedAfterUnix})
 	}
@@ -116,93 +187,246 @@ func GetNotifications(opts FindNotificationOptions) (NotificationList, error) {
 	return getNotifications(x, opts)
 }
 
-// CreateOrUpdateIssueNotifications creates an issue notification
-// for each watcher, or updates it if already exists
-func CreateOrUpdateIssueNotifications(issueID, commentID int64, notificationAuthorID int64) error {
+// CountNotifications returns the number of notifications that fit to the given options.
+func CountNotifications(opts FindNotificationOptions) (int64, error) {
+	return x.Where(opts.ToCond()).Count(new(Notification))
+}
+
+// GetNotificationRepoIDs returns the IDs of the repositories of the
+// notifications that fit to the given options, ignoring the repository filter
+func GetNotificationRepoIDs(opts FindNotificationOptions) ([]int64, error) {
+	opts.RepoID = 0
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("notification").Where(opts.ToCond()).Distinct("repo_id").Find(&ids)
+}
+
+// CreateOrUpdateIssueNotifications creates an issue notification for each
+// watcher, participant and user mentioned by the new content, or updates it if
+// already exists, and returns the IDs of the notified users
+func CreateOrUpdateIssueNotifications(issueID, commentID int64, notificationAuthorID int64, mentions []string) ([]int64, error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err := sess.Begin(); err != nil {
-		return err
+		return nil, err
+	}
+
+	notified, err := createOrUpdateIssueNotifications(sess, issueID, commentID, notificationAuthorID, mentions)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := createOrUpdateIssueNotifications(sess, issueID, commentID, notificationAuthorID); err != nil {
+	return notified, sess.Commit()
+}
+
+// CreateOrUpdateIssueNotificationForUser creates an issue notification for
+// the user only, or updates it if already exists
+func CreateOrUpdateIssueNotificationForUser(issueID, commentID, notificationAuthorID, userID int64, reason NotificationReason) error {
+	if userID == notificationAuthorID {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
 		return err
 	}
 
+	issue, err := getIssueByID(sess, issueID)
+	if err != nil {
+		return err
+	}
+	notification, err := getIssueNotification(sess, userID, issueID)
+	if err != nil {
+		return err
+	}
+	if notification.ID > 0 {
+		err = updateIssueNotification(sess, notification, commentID, notificationAuthorID, reason)
+	} else {
+		err = createIssueNotification(sess, userID, issue, commentID, notificationAuthorID, reason)
+	}
+	if err != nil {
+		return err
+	}
 	return sess.Commit()
 }
 
-func createOrUpdateIssueNotifications(e Engine, issueID, commentID int64, notificationAuthorID int64) error {
+func createOrUpdateIssueNotifications(e Engine, issueID, commentID int64, notificationAuthorID int64, mentions []string) ([]int64, error) {
 	issueWatches, err := getIssueWatchers(e, issueID, ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	issue, err := getIssueByID(e, issueID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	watches, err := getWatchers(e, issue.RepoID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	notifications, err := getNotificationsByIssueID(e, issueID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	alreadyNotified := make(map[int64]struct{}, len(issueWatches)+len(watches))
+	err = issue.loadRepo(e)
+	if err != nil {
+		return nil, err
+	}
+	unitType := UnitTypeIssues
+	if issue.IsPull {
+		unitType = UnitTypePullRequests
+	}
+
+	unwatchedIDs := make([]int64, 0, 5)
+	if err := e.Table("issue_watch").Cols("user_id").
+		Where("issue_id = ? AND is_watching = ?", issueID, false).
+		Find(&unwatchedIDs); err != nil {
+		return nil, err
+	}
+	unwatched := make(map[int64]struct{}, len(unwatchedIDs))
+	for _, id := range unwatchedIDs {
+		unwatched[id] = struct{}{}
+	}
 
-	notifyUser := func(userID int64) error {
+	reasons := make(map[int64]NotificationReason, len(issueWatches)+len(watches))
+	notified := make([]int64, 0, len(issueWatches)+len(watches))
+	addReason := func(userID int64, reason NotificationReason) {
 		// do not send notification for the own issuer/commenter
 		if userID == notificationAuthorID {
-			return nil
-		}
-
-		if _, ok := alreadyNotified[userID]; ok {
-			return nil
+			return
 		}
-		alreadyNotified[userID] = struct{}{}
-
-		if notificationExists(notifications, issue.ID, userID) {
-			return updateIssueNotification(e, userID, issue.ID, commentID, notificationAuthorID)
+		if current, ok := reasons[userID]; !ok {
+			notified = append(notified, userID)
+		} else if current >= reason {
+			return
 		}
-		return createIssueNotification(e, userID, issue, commentID, notificationAuthorID)
+		reasons[userID] = reason
 	}
 
 	for _, issueWatch := range issueWatches {
+		addReason(issueWatch.UserID, NotificationReasonSubscribed)
+	}
+
+	for _, watch := range watches {
 		// ignore if user unwatched the issue
-		if !issueWatch.IsWatching {
-			alreadyNotified[issueWatch.UserID] = struct{}{}
+		if _, ok := unwatched[watch.UserID]; ok {
 			continue
 		}
-
-		if err := notifyUser(issueWatch.UserID); err != nil {
-			return err
+		issue.Repo.Units = nil
+		if !issue.Repo.checkUnitUser(e, watch.UserID, false, unitType) {
+			continue
 		}
+		addReason(watch.UserID, NotificationReasonSubscribed)
 	}
 
-	err = issue.loadRepo(e)
+	participants, err := getIssueParticipantReasons(e, issue)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	for _, watch := range watches {
-		issue.Repo.Units = nil
-		if issue.IsPull && !issue.Repo.checkUnitUser(e, watch.UserID, false, UnitTypePullRequests) {
+	for _, participant := range participants {
+		if _, ok := unwatched[participant.UserID]; ok {
 			continue
 		}
-		if !issue.IsPull && !issue.Repo.checkUnitUser(e, watch.UserID, false, UnitTypeIssues) {
+		issue.Repo.Units = nil
+		if !issue.Repo.checkUnitUser(e, participant.UserID, false, unitType) {
 			continue
 		}
+		addReason(participant.UserID, participant.Reason)
+	}
 
-		if err := notifyUser(watch.UserID); err != nil {
-			return err
+	// The mentioned users are notified even if they unwatched the issue
+	if len(mentions) > 0 {
+		doer, err := getUserByID(e, notificationAuthorID)
+		if err != nil {
+			return nil, err
+		}
+		mentioned, err := issue.ResolveMentionsByVisibility(DBContext{e}, doer, mentions)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]struct{}, len(mentions))
+		for _, name := range mentions {
+			names[strings.ToLower(name)] = struct{}{}
+		}
+		for _, u := range mentioned {
+			if _, ok := names[u.LowerName]; ok {
+				addReason(u.ID, NotificationReasonMention)
+			} else {
+				addReason(u.ID, NotificationReasonTeamMention)
+			}
 		}
 	}
-	return nil
+
+	for _, userID := range notified {
+		if notification := findIssueNotification(notifications, issue.ID, userID); notification != nil {
+			err = updateIssueNotification(e, notification, commentID, notificationAuthorID, reasons[userID])
+		} else {
+			err = createIssueNotification(e, userID, issue, commentID, notificationAuthorID, reasons[userID])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return notified, nil
+}
+
+type issueParticipant struct {
+	UserID int64
+	Reason NotificationReason
+}
+
+// getIssueParticipantReasons returns the active author, assignees and
+// requested reviewers of the issue with the reasons of their notifications
+func getIssueParticipantReasons(e Engine, issue *Issue) ([]issueParticipant, error) {
+	assigneeIDs := make([]int64, 0, 5)
+	if err := e.Table("issue_assignees").
+		Cols("assignee_id").
+		Where("issue_id = ?", issue.ID).
+		Find(&assigneeIDs); err != nil {
+		return nil, err
+	}
+
+	var reviewerIDs []int64
+	if issue.IsPull {
+		var err error
+		if reviewerIDs, err = getReviewRequestedUserIDs(e, issue.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	ids := make([]int64, 0, len(assigneeIDs)+len(reviewerIDs)+1)
+	ids = append(append(append(ids, assigneeIDs...), reviewerIDs...), issue.PosterID)
+	active := make([]int64, 0, len(ids))
+	if err := e.Table("`user`").Cols("id").
+		In("id", ids).
+		And("is_active = ?", true).
+		And("prohibit_login = ?", false).
+		Find(&active); err != nil {
+		return nil, err
+	}
+	isActive := make(map[int64]bool, len(active))
+	for _, id := range active {
+		isActive[id] = true
+	}
+
+	participants := make([]issueParticipant, 0, len(active))
+	if isActive[issue.PosterID] {
+		participants = append(participants, issueParticipant{UserID: issue.PosterID, Reason: NotificationReasonAuthor})
+	}
+	for _, id := range assigneeIDs {
+		if isActive[id] {
+			participants = append(participants, issueParticipant{UserID: id, Reason: NotificationReasonAssigned})
+		}
+	}
+	for _, id := range reviewerIDs {
+		if isActive[id] {
+			participants = append(participants, issueParticipant{UserID: id, Reason: NotificationReasonReviewRequested})
+		}
+	}
+	return participants, nil
 }
 
 func getNotificationsByIssueID(e Engine, issueID int64) (notifications []*Notification, err error) {
@@ -212,21 +436,22 @@ func getNotificationsByIssueID(e Engine, issueID int64) (notifications []*Notifi
 	return
 }
 
-func notificationExists(notifications []*Notification, issueID, userID int64) bool {
+func findIssueNotification(notifications []*Notification, issueID, userID int64) *Notification {
 	for _, notification := range notifications {
 		if notification.IssueID == issueID && notification.UserID == userID {
-			return true
+			return notification
 		}
 	}
 
-	return false
+	return nil
 }
 
-func createIssueNotification(e Engine, userID int64, issue *Issue, commentID, updatedByID int64) error {
+func createIssueNotification(e Engine, userID int64, issue *Issue, commentID, updatedByID int64, reason NotificationReason) error {
 	notification := &Notification{
 		UserID:    userID,
 		RepoID:    issue.RepoID,
 		Status:    NotificationStatusUnread,
+		Reason:    reason,
 		IssueID:   issue.ID,
 		CommentID: commentID,
 		UpdatedBy: updatedByID,
@@ -242,25 +467,27 @@ func createIssueNotification(e Engine, userID int64, issue *Issue, commentID, up
 	return err
 }
 
-func updateIssueNotification(e Engine, userID, issueID, commentID, updatedByID int64) error {
-	notification, err := getIssueNotification(e, userID, issueID)
-	if err != nil {
-		return err
-	}
-
+func updateIssueNotification(e Engine, notification *Notification, commentID, updatedByID int64, reason NotificationReason) error {
 	// NOTICE: Only update comment id when the before notification on this issue is read, otherwise you may miss some old comments.
 	// But we need update update_by so that the notification will be reorder
+	// The reason of a notification not read yet only gets more important,
+	// so that a mention isn't hidden by the following updates
 	var cols []string
 	if notification.Status == NotificationStatusRead {
 		notification.Status = NotificationStatusUnread
 		notification.CommentID = commentID
-		cols = []string{"status", "update_by", "comment_id"}
+		notification.Reason = reason
+		cols = []string{"status", "update_by", "comment_id", "reason"}
 	} else {
 		notification.UpdatedBy = updatedByID
 		cols = []string{"update_by"}
+		if reason > notification.Reason {
+			notification.Reason = reason
+			cols = append(cols, "reason")
+		}
 	}
 
-	_, err = e.ID(notification.ID).Cols(cols...).Update(notification)
+	_, err := e.ID(notification.ID).Cols(cols...).Update(notification)
 	return err
 }
 
@@ -316,6 +543,7 @@ func (n *Notification) APIFormat() *api.NotificationThread {
 		ID:        n.ID,
 		Unread:    !(n.Status == NotificationStatusRead || n.Status == NotificationStatusPinned),
 		Pinned:    n.Status == NotificationStatusPinned,
+		Reason:    n.Reason.Name(),
 		UpdatedAt: n.UpdatedUnix.AsTime(),
 		URL:       n.APIURL(),
 	}
@@ -666,6 +894,36 @@ func getNotificationCount(e Engine, user *User, status NotificationStatus) (coun
 	return
 }
 
+// GetUnreadNotificationCounts returns the unread notification counts of the users
+func GetUnreadNotificationCounts(userIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	type userCount struct {
+		UserID int64
+		Count  int64
+	}
+	results := make([]*userCount, 0, len(userIDs))
+	if err := x.Table("notification").
+		Select("user_id, count(*) AS count").
+		Where("status = ?", NotificationStatusUnread).
+		In("user_id", userIDs).
+		GroupBy("user_id").
+		Find(&results); err != nil {
+		return nil, err
+	}
+
+	for _, id := range userIDs {
+		counts[id] = 0
+	}
+	for _, result := range results {
+		counts[result.UserID] = result.Count
+	}
+	return counts, nil
+}
+
 func setNotificationStatusReadIfUnread(e Engine, userID, issueID int64) error {
 	notification, err := getIssueNotification(e, userID, issueID)
 	// ignore if not exists
@@ -722,6 +980,57 @@ func getNotificationByID(e Engine, notificationID int64) (*Notification, error)
 	return notification, nil
 }
 
+// SetNotificationsStatus changes the status of the notifications of the user
+// among the given ones
+func SetNotificationsStatus(user *User, ids []int64, status NotificationStatus) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := x.
+		Where("user_id = ?", user.ID).
+		In("id", ids).
+		Cols("status").
+		Update(&Notification{Status: status})
+	return err
+}
+
+// UnsubscribeNotifications unwatches the issues of the notifications of the
+// user among the given ones, and marks them as read
+func UnsubscribeNotifications(user *User, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	issueIDs := make([]int64, 0, len(ids))
+	if err := sess.Table("notification").
+		Where("user_id = ? AND issue_id > 0", user.ID).
+		In("id", ids).
+		Cols("issue_id").
+		Find(&issueIDs); err != nil {
+		return err
+	}
+	for _, issueID := range issueIDs {
+		if err := createOrUpdateIssueWatch(sess, user.ID, issueID, false); err != nil {
+			return err
+		}
+	}
+
+	if _, err := sess.
+		Where("user_id = ?", user.ID).
+		In("id", ids).
+		Cols("status").
+		Update(&Notification{Status: NotificationStatusRead}); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
 // UpdateNotificationStatuses updates the statuses of all of a user's notifications that are of the currentStatus type to the desiredStatus
 func UpdateNotificationStatuses(user *User, currentStatus NotificationStatus, desiredStatus NotificationStatus) error {
 	n := &Notification{Status: desiredStatus, UpdatedBy: user.ID}
@@ -14,15 +14,120 @@ func TestCreateOrUpdateIssueNotifications(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
 
-	assert.NoError(t, CreateOrUpdateIssueNotifications(issue.ID, 0, 2))
+	notified, err := CreateOrUpdateIssueNotifications(issue.ID, 0, 2, nil)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 4, 11}, notified)
 
 	// User 9 is inactive, thus notifications for user 1 and 4 are created
 	notf := AssertExistsAndLoadBean(t, &Notification{UserID: 1, IssueID: issue.ID}).(*Notification)
 	assert.Equal(t, NotificationStatusUnread, notf.Status)
+	// User 1 is both the author and an assignee of the issue
+	assert.Equal(t, NotificationReasonAssigned, notf.Reason)
 	CheckConsistencyFor(t, &Issue{ID: issue.ID})
 
 	notf = AssertExistsAndLoadBean(t, &Notification{UserID: 4, IssueID: issue.ID}).(*Notification)
 	assert.Equal(t, NotificationStatusUnread, notf.Status)
+	assert.Equal(t, NotificationReasonSubscribed, notf.Reason)
+}
+
+func TestCreateOrUpdateIssueNotificationsMentions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+
+	// Mentioned users are notified even if they unwatched the issue
+	assert.NoError(t, CreateOrUpdateIssueWatch(5, issue.ID, false))
+	assert.NoError(t, CreateOrUpdateIssueWatch(11, issue.ID, false))
+
+	notified, err := CreateOrUpdateIssueNotifications(issue.ID, 0, 2, []string{"user4", "User5", "user2"})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 4, 5}, notified)
+
+	notf := AssertExistsAndLoadBean(t, &Notification{UserID: 4, IssueID: issue.ID}).(*Notification)
+	assert.Equal(t, NotificationReasonMention, notf.Reason)
+	notf = AssertExistsAndLoadBean(t, &Notification{UserID: 5, IssueID: issue.ID}).(*Notification)
+	assert.Equal(t, NotificationReasonMention, notf.Reason)
+	AssertNotExistsBean(t, &Notification{UserID: 11, IssueID: issue.ID})
+
+	// The reason of an unread notification isn't lowered by the next updates
+	_, err = CreateOrUpdateIssueNotifications(issue.ID, 0, 2, nil)
+	assert.NoError(t, err)
+	notf = AssertExistsAndLoadBean(t, &Notification{UserID: 4, IssueID: issue.ID}).(*Notification)
+	assert.Equal(t, NotificationReasonMention, notf.Reason)
+}
+
+func TestCreateOrUpdateIssueNotificationsTeamMentions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue)
+
+	notified, err := CreateOrUpdateIssueNotifications(issue.ID, 0, 2, []string{"user3/team1"})
+	assert.NoError(t, err)
+	assert.Contains(t, notified, int64(4))
+	notf := AssertExistsAndLoadBean(t, &Notification{UserID: 4, IssueID: issue.ID}).(*Notification)
+	assert.Equal(t, NotificationReasonTeamMention, notf.Reason)
+}
+
+func TestCreateOrUpdateIssueNotificationForUser(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, CreateOrUpdateIssueNotificationForUser(2, 0, 1, 4, NotificationReasonReviewRequested))
+	notf := AssertExistsAndLoadBean(t, &Notification{UserID: 4, IssueID: 2}).(*Notification)
+	assert.Equal(t, NotificationReasonReviewRequested, notf.Reason)
+	assert.Equal(t, NotificationSourcePullRequest, notf.Source)
+
+	// the doer isn't notified
+	assert.NoError(t, CreateOrUpdateIssueNotificationForUser(2, 0, 1, 1, NotificationReasonReviewRequested))
+	AssertNotExistsBean(t, &Notification{UserID: 1, IssueID: 2})
+}
+
+func TestGetNotificationsFilters(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	opts := FindNotificationOptions{
+		UserID:   2,
+		Statuses: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned},
+		Reasons:  []NotificationReason{NotificationReasonMention, NotificationReasonAssigned},
+	}
+	nl, err := GetNotifications(opts)
+	assert.NoError(t, err)
+	if assert.Len(t, nl, 2) {
+		assert.EqualValues(t, 5, nl[0].ID)
+		assert.EqualValues(t, 4, nl[1].ID)
+	}
+	count, err := CountNotifications(opts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	opts.RepoID = 2
+	count, err = CountNotifications(opts)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	repoIDs, err := GetNotificationRepoIDs(opts)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, repoIDs)
+
+	count, err = CountNotifications(FindNotificationOptions{
+		UserID:  2,
+		Sources: []NotificationSource{NotificationSourcePullRequest},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+}
+
+func TestNotificationReasonFromName(t *testing.T) {
+	for _, reason := range NotificationReasons {
+		r, ok := NotificationReasonFromName(reason.Name())
+		assert.True(t, ok)
+		assert.Equal(t, reason, r)
+	}
+	_, ok := NotificationReasonFromName("unknown")
+	assert.False(t, ok)
+}
+
+func TestGetUnreadNotificationCounts(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	counts, err := GetUnreadNotificationCounts([]int64{1, 2, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 1, 2: 2, 4: 0}, counts)
 }
 
 func TestNotificationsForUser(t *testing.T) {
@@ -101,3 +206,25 @@ func TestUpdateNotificationStatuses(t *testing.T) {
 	AssertExistsAndLoadBean(t,
 		&Notification{ID: notfPinned.ID, Status: NotificationStatusPinned})
 }
+
+func TestSetNotificationsStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	// notification 1 belongs to user 1 and is left untouched
+	assert.NoError(t, SetNotificationsStatus(user, []int64{1, 4, 5}, NotificationStatusPinned))
+	AssertExistsAndLoadBean(t, &Notification{ID: 1, Status: NotificationStatusUnread})
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusPinned})
+	AssertExistsAndLoadBean(t, &Notification{ID: 5, Status: NotificationStatusPinned})
+}
+
+func TestUnsubscribeNotifications(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	assert.NoError(t, UnsubscribeNotifications(user, []int64{1, 4}))
+	AssertExistsAndLoadBean(t, &Notification{ID: 1, Status: NotificationStatusUnread})
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusRead})
+	AssertExistsAndLoadBean(t, &IssueWatch{UserID: 2, IssueID: 5}, Cond("is_watching = ?", false))
+	AssertNotExistsBean(t, &IssueWatch{UserID: 2, IssueID: 1})
+}
@@ -268,7 +268,7 @@ func DeleteOrganization(org *User) (err error) {
 	}
 
 	if err = deleteOrg(sess, org); err != nil {
-		if IsErrUserOwnRepos(err) {
+		if IsErrUserOwnRepos(err) || IsErrUserOwnPackages(err) {
 			return err
 		} else if err != nil {
 			return fmt.Errorf("deleteOrg: %v", err)
@@ -287,15 +287,28 @@ func deleteOrg(e *xorm.Session, u *User) error {
 		return ErrUserOwnRepos{UID: u.ID}
 	}
 
+	// Check ownership of packages.
+	count, err = countPackages(e, u.ID)
+	if err != nil {
+		return fmt.Errorf("countPackages: %v", err)
+	} else if count > 0 {
+		return ErrUserOwnPackages{UID: u.ID}
+	}
+
 	if err := deleteBeans(e,
 		&Team{OrgID: u.ID},
 		&OrgUser{OrgID: u.ID},
 		&TeamUser{OrgID: u.ID},
 		&TeamUnit{OrgID: u.ID},
+		&SavedIssueQuery{OrgID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err := deleteContainerImagesByOwner(e, u.ID); err != nil {
+		return err
+	}
+
 	if _, err = e.ID(u.ID).Delete(new(User)); err != nil {
 		return fmt.Errorf("Delete: %v", err)
 	}
@@ -630,6 +643,9 @@ func removeOrgUser(sess *xorm.Session, orgID, userID int64) error {
 		if err = watchRepo(sess, userID, repoID, false); err != nil {
 			return err
 		}
+		if err = deleteRepoNotificationPreference(sess, userID, repoID); err != nil {
+			return err
+		}
 	}
 
 	if len(repoIDs) > 0 {
@@ -35,6 +35,43 @@ type Team struct {
 	Units                   []*TeamUnit `xorm:"-"`
 	IncludesAllRepositories bool        `xorm:"NOT NULL DEFAULT false"`
 	CanCreateOrgRepo        bool        `xorm:"NOT NULL DEFAULT false"`
+	// ReviewAssignment tells whether a specific member is picked to review
+	// the pull requests the team is requested to review
+	ReviewAssignment TeamReviewAssignment `xorm:"NOT NULL DEFAULT 0"`
+	LastReviewerID   int64                `xorm:"NOT NULL DEFAULT 0"`
+}
+
+// TeamReviewAssignment is the way a member of a team is picked to review a pull request
+type TeamReviewAssignment int
+
+const (
+	// TeamReviewAssignmentNone requests the review of the whole team
+	TeamReviewAssignmentNone TeamReviewAssignment = iota
+	// TeamReviewAssignmentRoundRobin picks the members in turn
+	TeamReviewAssignmentRoundRobin
+	// TeamReviewAssignmentLoadBalance picks the member with the fewest pending review requests
+	TeamReviewAssignmentLoadBalance
+)
+
+var teamReviewAssignmentNames = map[TeamReviewAssignment]string{
+	TeamReviewAssignmentNone:        "none",
+	TeamReviewAssignmentRoundRobin:  "round_robin",
+	TeamReviewAssignmentLoadBalance: "load_balance",
+}
+
+// Name returns the name of the review assignment, as used by the API and the forms
+func (a TeamReviewAssignment) Name() string {
+	return teamReviewAssignmentNames[a]
+}
+
+// TeamReviewAssignmentFromName returns the review assignment of the name, and whether it exists
+func TeamReviewAssignmentFromName(name string) (TeamReviewAssignment, bool) {
+	for a, n := range teamReviewAssignmentNames {
+		if n == name {
+			return a, true
+		}
+	}
+	return TeamReviewAssignmentNone, false
 }
 
 // SearchTeamOptions holds the search options
@@ -51,7 +88,8 @@ type SearchMembersOptions struct {
 	ListOptions
 }
 
-// SearchTeam search for teams. Caller is responsible to check permissions.
+// SearchTeam search for teams, in all organizations if no OrgID is given.
+// Caller is responsible to check permissions.
 func SearchTeam(opts *SearchTeamOptions) ([]*Team, int64, error) {
 	if opts.Page <= 0 {
 		opts.Page = 1
@@ -72,7 +110,9 @@ func SearchTeam(opts *SearchTeamOptions) ([]*Team, int64, error) {
 		cond = cond.And(keywordCond)
 	}
 
-	cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	if opts.OrgID > 0 {
+		cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	}
 
 	sess := x.NewSession()
 	defer sess.Close()
@@ -337,6 +377,10 @@ func (t *Team) removeAllRepositories(e Engine) (err error) {
 			if err = removeIssueWatchersByRepoID(e, user.ID, repo.ID); err != nil {
 				return err
 			}
+
+			if err = deleteRepoNotificationPreference(e, user.ID, repo.ID); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -394,6 +438,10 @@ func (t *Team) removeRepository(e Engine, repo *Repository, recalculate bool) (e
 		if err := removeIssueWatchersByRepoID(e, teamUser.UID, repo.ID); err != nil {
 			return err
 		}
+
+		if err = deleteRepoNotificationPreference(e, teamUser.UID, repo.ID); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -636,7 +684,7 @@ func UpdateTeam(t *Team, authChanged bool, includeAllChanged bool) (err error) {
 	}
 
 	if _, err = sess.ID(t.ID).Cols("name", "lower_name", "description",
-		"can_create_org_repo", "authorize", "includes_all_repositories").Update(t); err != nil {
+		"can_create_org_repo", "authorize", "includes_all_repositories", "review_assignment").Update(t); err != nil {
 		return fmt.Errorf("update: %v", err)
 	}
 
@@ -720,6 +768,13 @@ func DeleteTeam(t *Team) error {
 		return err
 	}
 
+	// Delete the pending review requests of the team.
+	if _, err := sess.
+		Where("reviewer_team_id=?", t.ID).
+		Delete(new(ReviewRequest)); err != nil {
+		return err
+	}
+
 	// Delete team.
 	if _, err := sess.ID(t.ID).Delete(new(Team)); err != nil {
 		return err
@@ -932,6 +987,10 @@ func removeTeamMember(e *xorm.Session, team *Team, userID int64) error {
 		if err := removeIssueWatchersByRepoID(e, userID, repo.ID); err != nil {
 			return err
 		}
+
+		if err = deleteRepoNotificationPreference(e, userID, repo.ID); err != nil {
+			return err
+		}
 	}
 
 	// Check if the user is a member of any team in the organization.
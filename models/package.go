@@ -0,0 +1,402 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// PackageType defines the format of a package and the protocol it is published with
+type PackageType int
+
+// Enumerate all the package types
+const (
+	PackageGeneric PackageType = iota + 1 // 1
+	PackageNpm                            // 2
+	PackageMaven                          // 3
+	PackagePyPI                           // 4
+)
+
+var packageTypeNames = map[PackageType]string{
+	PackageGeneric: "generic",
+	PackageNpm:     "npm",
+	PackageMaven:   "maven",
+	PackagePyPI:    "pypi",
+}
+
+// Name returns the name of the package type as used in URLs
+func (t PackageType) Name() string {
+	return packageTypeNames[t]
+}
+
+// ParsePackageType returns the package type with the given name, 0 if there is none
+func ParsePackageType(name string) PackageType {
+	for t, n := range packageTypeNames {
+		if n == strings.ToLower(name) {
+			return t
+		}
+	}
+	return 0
+}
+
+// Package represents a package of a user or an organization. Names are unique per
+// owner and package type.
+type Package struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Owner       *User              `xorm:"-"`
+	Type        PackageType        `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// PackageVersion represents a published version of a package. Metadata holds the
+// JSON encoded metadata of the version in a format specific to the package type.
+type PackageVersion struct {
+	ID            int64       `xorm:"pk autoincr"`
+	PackageID     int64       `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Package       *Package    `xorm:"-"`
+	Version       string      `xorm:"NOT NULL"`
+	LowerVersion  string      `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatorID     int64       `xorm:"INDEX NOT NULL"`
+	Creator       *User       `xorm:"-"`
+	RepoID        int64       `xorm:"INDEX"`
+	Repo          *Repository `xorm:"-"`
+	Metadata      string      `xorm:"TEXT"`
+	DownloadCount int64       `xorm:"NOT NULL DEFAULT 0"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// PackageFile represents a file of a package version. The content is kept by the
+// package content store and addressed by its SHA256 hash.
+type PackageFile struct {
+	ID          int64              `xorm:"pk autoincr"`
+	VersionID   int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Size        int64              `xorm:"NOT NULL"`
+	HashMD5     string             `xorm:"hash_md5 CHAR(32) NOT NULL"`
+	HashSHA1    string             `xorm:"hash_sha1 CHAR(40) NOT NULL"`
+	HashSHA256  string             `xorm:"hash_sha256 CHAR(64) INDEX NOT NULL"`
+	HashSHA512  string             `xorm:"hash_sha512 CHAR(128) NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// LoadAttributes loads the package, its owner, the creator and the linked repository
+// of the version
+func (pv *PackageVersion) LoadAttributes() (err error) {
+	if pv.Package == nil {
+		if pv.Package, err = GetPackageByID(pv.PackageID); err != nil {
+			return err
+		}
+	}
+	if pv.Package.Owner == nil {
+		if pv.Package.Owner, err = GetUserByID(pv.Package.OwnerID); err != nil {
+			return err
+		}
+	}
+	if pv.Creator == nil {
+		if pv.Creator, err = GetUserByID(pv.CreatorID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			pv.Creator = NewGhostUser()
+		}
+	}
+	if pv.Repo == nil && pv.RepoID > 0 {
+		if pv.Repo, err = GetRepositoryByID(pv.RepoID); err != nil {
+			if !IsErrRepoNotExist(err) {
+				return err
+			}
+			pv.RepoID = 0
+		}
+	}
+	return nil
+}
+
+// GetPackageByID returns the package with the given id
+func GetPackageByID(id int64) (*Package, error) {
+	p := new(Package)
+	has, err := x.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetPackage returns the package of the owner with the given type and name
+func GetPackage(ownerID int64, t PackageType, name string) (*Package, error) {
+	p := &Package{OwnerID: ownerID, Type: t, LowerName: strings.ToLower(name)}
+	has, err := x.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{OwnerID: ownerID, Type: t, Name: name}
+	}
+	return p, nil
+}
+
+// GetPackageVersion returns the version of the package
+func GetPackageVersion(packageID int64, version string) (*PackageVersion, error) {
+	pv := &PackageVersion{PackageID: packageID, LowerVersion: strings.ToLower(version)}
+	has, err := x.Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{PackageID: packageID, Version: version}
+	}
+	return pv, nil
+}
+
+// GetPackageVersionByName returns the version of the package of the owner with the given
+// type and name
+func GetPackageVersionByName(ownerID int64, t PackageType, name, version string) (*PackageVersion, error) {
+	p, err := GetPackage(ownerID, t, name)
+	if err != nil {
+		if IsErrPackageNotExist(err) {
+			return nil, ErrPackageVersionNotExist{Version: version}
+		}
+		return nil, err
+	}
+	pv, err := GetPackageVersion(p.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	pv.Package = p
+	return pv, nil
+}
+
+// GetPackageVersions returns the versions of the package, the oldest first
+func GetPackageVersions(packageID int64) ([]*PackageVersion, error) {
+	versions := make([]*PackageVersion, 0, 10)
+	return versions, x.Where("package_id = ?", packageID).Asc("created_unix", "id").Find(&versions)
+}
+
+// GetPackageFiles returns the files of the package version ordered by their name
+func GetPackageFiles(versionID int64) ([]*PackageFile, error) {
+	files := make([]*PackageFile, 0, 5)
+	return files, x.Where("version_id = ?", versionID).Asc("lower_name").Find(&files)
+}
+
+// GetPackageFile returns the file of the package version with the given name
+func GetPackageFile(versionID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{VersionID: versionID, LowerName: strings.ToLower(name)}
+	has, err := x.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist{VersionID: versionID, Name: name}
+	}
+	return pf, nil
+}
+
+// IsPackageContentReferenced returns true if any package file has the given content
+func IsPackageContentReferenced(hashSHA256 string) (bool, error) {
+	return x.Exist(&PackageFile{HashSHA256: hashSHA256})
+}
+
+// PackageUpload describes a file published to a version of a package, the package and
+// the version are created if they do not exist yet.
+type PackageUpload struct {
+	OwnerID   int64
+	Type      PackageType
+	Name      string
+	Version   string
+	CreatorID int64
+	// Metadata is stored on new versions and replaces the metadata of existing
+	// versions unless it is empty
+	Metadata string
+	// NewVersion fails the upload if the version exists already
+	NewVersion bool
+	File       *PackageFile
+}
+
+// PublishPackageFile adds the file of the upload to its package version
+func PublishPackageFile(u *PackageUpload) (*PackageVersion, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	p := &Package{OwnerID: u.OwnerID, Type: u.Type, LowerName: strings.ToLower(u.Name)}
+	has, err := sess.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		p.Name = u.Name
+		if _, err = sess.Insert(p); err != nil {
+			return nil, err
+		}
+	}
+
+	pv := &PackageVersion{PackageID: p.ID, LowerVersion: strings.ToLower(u.Version)}
+	if has, err = sess.Get(pv); err != nil {
+		return nil, err
+	} else if has && u.NewVersion {
+		return nil, ErrPackageVersionAlreadyExist{PackageID: p.ID, Version: u.Version}
+	} else if !has {
+		pv.Version = u.Version
+		pv.CreatorID = u.CreatorID
+		pv.Metadata = u.Metadata
+		if _, err = sess.Insert(pv); err != nil {
+			return nil, err
+		}
+	} else if len(u.Metadata) > 0 {
+		pv.Metadata = u.Metadata
+		if _, err = sess.ID(pv.ID).Cols("metadata").Update(pv); err != nil {
+			return nil, err
+		}
+	}
+
+	if has, err = sess.Exist(&PackageFile{VersionID: pv.ID, LowerName: strings.ToLower(u.File.Name)}); err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrPackageFileAlreadyExist{VersionID: pv.ID, Name: u.File.Name}
+	}
+	u.File.VersionID = pv.ID
+	u.File.LowerName = strings.ToLower(u.File.Name)
+	if _, err = sess.Insert(u.File); err != nil {
+		return nil, err
+	}
+
+	if _, err = sess.ID(p.ID).Cols("updated_unix").Update(&Package{UpdatedUnix: timeutil.TimeStampNow()}); err != nil {
+		return nil, err
+	}
+
+	pv.Package = p
+	return pv, sess.Commit()
+}
+
+// IncrementPackageDownloadCount increments the download count of the package version
+func IncrementPackageDownloadCount(versionID int64) error {
+	_, err := x.Exec("UPDATE `package_version` SET download_count = download_count + 1 WHERE id = ?", versionID)
+	return err
+}
+
+// SetPackageVersionRepo links the package version to a repository, 0 removes the link
+func SetPackageVersionRepo(pv *PackageVersion, repoID int64) error {
+	pv.RepoID = repoID
+	pv.Repo = nil
+	_, err := x.ID(pv.ID).Cols("repo_id").Update(pv)
+	return err
+}
+
+// deletePackageIfEmpty deletes the package if it has no versions anymore
+func deletePackageIfEmpty(e Engine, packageID int64) error {
+	count, err := e.Count(&PackageVersion{PackageID: packageID})
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = e.ID(packageID).Delete(new(Package))
+	return err
+}
+
+// DeletePackageVersion deletes the version with its files and the package once it has
+// no versions anymore. The content of the files is left to the caller.
+func DeletePackageVersion(pv *PackageVersion) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Delete(&PackageFile{VersionID: pv.ID}); err != nil {
+		return err
+	}
+	if _, err := sess.ID(pv.ID).Delete(new(PackageVersion)); err != nil {
+		return err
+	}
+	if err := deletePackageIfEmpty(sess, pv.PackageID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// DeletePackageFile deletes the file and its version once it has no files anymore
+func DeletePackageFile(pv *PackageVersion, pf *PackageFile) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(pf.ID).Delete(new(PackageFile)); err != nil {
+		return err
+	}
+	if has, err := sess.Exist(&PackageFile{VersionID: pv.ID}); err != nil {
+		return err
+	} else if !has {
+		if _, err = sess.ID(pv.ID).Delete(new(PackageVersion)); err != nil {
+			return err
+		}
+		if err = deletePackageIfEmpty(sess, pv.PackageID); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// FindPackageVersionsOptions represents the options to search package versions
+type FindPackageVersionsOptions struct {
+	ListOptions
+	OwnerID int64
+	Type    PackageType
+	// Query matches the package name
+	Query string
+}
+
+func (opts *FindPackageVersionsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"package.owner_id": opts.OwnerID})
+	}
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"package.type": opts.Type})
+	}
+	if len(opts.Query) > 0 {
+		cond = cond.And(builder.Like{"package.lower_name", strings.ToLower(opts.Query)})
+	}
+	return cond
+}
+
+// FindPackageVersions returns the package versions matching the options, the most recent
+// first, with their attributes loaded
+func FindPackageVersions(opts *FindPackageVersionsOptions) ([]*PackageVersion, int64, error) {
+	cond := opts.toConds()
+	count, err := x.Join("INNER", "package", "package.id = package_version.package_id").
+		Where(cond).
+		Count(new(PackageVersion))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Join("INNER", "package", "package.id = package_version.package_id").
+		Where(cond).
+		Desc("package_version.created_unix", "package_version.id")
+	if opts.Page != 0 {
+		sess = opts.setSessionPagination(sess)
+	}
+	versions := make([]*PackageVersion, 0, opts.PageSize)
+	if err = sess.Find(&versions); err != nil {
+		return nil, 0, err
+	}
+
+	return versions, count, PackageVersionList(versions).loadAttributes(x)
+}
+
+func countPackages(e Engine, ownerID int64) (int64, error) {
+	return e.Count(&Package{OwnerID: ownerID})
+}
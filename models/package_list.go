@@ -0,0 +1,162 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+)
+
+// PackageVersionList is a list of package versions
+type PackageVersionList []*PackageVersion
+
+func (versions PackageVersionList) loadPackages(e Engine) error {
+	packageIDs := make(map[int64]struct{}, len(versions))
+	for _, pv := range versions {
+		if pv.Package == nil {
+			packageIDs[pv.PackageID] = struct{}{}
+		}
+	}
+	if len(packageIDs) == 0 {
+		return nil
+	}
+
+	packages := make(map[int64]*Package, len(packageIDs))
+	if err := findInBatches(e, keysInt64(packageIDs), &packages); err != nil {
+		return fmt.Errorf("find package: %v", err)
+	}
+	for _, pv := range versions {
+		if pv.Package != nil {
+			continue
+		}
+		var ok bool
+		if pv.Package, ok = packages[pv.PackageID]; !ok {
+			return ErrPackageNotExist{ID: pv.PackageID}
+		}
+	}
+	return nil
+}
+
+func (versions PackageVersionList) loadUsers(e Engine) error {
+	userIDs := make(map[int64]struct{}, len(versions))
+	for _, pv := range versions {
+		if pv.Package.Owner == nil {
+			userIDs[pv.Package.OwnerID] = struct{}{}
+		}
+		if pv.Creator == nil {
+			userIDs[pv.CreatorID] = struct{}{}
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	users := make(map[int64]*User, len(userIDs))
+	if err := findInBatches(e, keysInt64(userIDs), &users); err != nil {
+		return fmt.Errorf("find user: %v", err)
+	}
+	for _, pv := range versions {
+		var ok bool
+		if pv.Package.Owner == nil {
+			if pv.Package.Owner, ok = users[pv.Package.OwnerID]; !ok {
+				return ErrUserNotExist{pv.Package.OwnerID, "", 0}
+			}
+		}
+		if pv.Creator == nil {
+			if pv.Creator, ok = users[pv.CreatorID]; !ok {
+				pv.Creator = NewGhostUser()
+			}
+		}
+	}
+	return nil
+}
+
+func (versions PackageVersionList) loadRepositories(e Engine) error {
+	repoIDs := make(map[int64]struct{}, len(versions))
+	for _, pv := range versions {
+		if pv.Repo == nil && pv.RepoID > 0 {
+			repoIDs[pv.RepoID] = struct{}{}
+		}
+	}
+	if len(repoIDs) == 0 {
+		return nil
+	}
+
+	repos := make(map[int64]*Repository, len(repoIDs))
+	if err := findInBatches(e, keysInt64(repoIDs), &repos); err != nil {
+		return fmt.Errorf("find repository: %v", err)
+	}
+	for _, pv := range versions {
+		if pv.Repo == nil && pv.RepoID > 0 {
+			var ok bool
+			if pv.Repo, ok = repos[pv.RepoID]; !ok {
+				pv.RepoID = 0
+			}
+		}
+	}
+	return nil
+}
+
+func (versions PackageVersionList) loadAttributes(e Engine) error {
+	if len(versions) == 0 {
+		return nil
+	}
+	if err := versions.loadPackages(e); err != nil {
+		return err
+	}
+	if err := versions.loadUsers(e); err != nil {
+		return err
+	}
+	return versions.loadRepositories(e)
+}
+
+// LoadAttributes loads the packages, their owners, the creators and the linked
+// repositories of the versions
+func (versions PackageVersionList) LoadAttributes() error {
+	return versions.loadAttributes(x)
+}
+
+// LoadFiles loads the files of the versions, mapped by version ID
+func (versions PackageVersionList) LoadFiles() (map[int64][]*PackageFile, error) {
+	files := make(map[int64][]*PackageFile, len(versions))
+	if len(versions) == 0 {
+		return files, nil
+	}
+
+	versionIDs := make([]int64, len(versions))
+	for i, pv := range versions {
+		versionIDs[i] = pv.ID
+	}
+	for left := versionIDs; len(left) > 0; {
+		limit := defaultMaxInSize
+		if len(left) < limit {
+			limit = len(left)
+		}
+		batch := make([]*PackageFile, 0, limit)
+		if err := x.In("version_id", left[:limit]).Asc("lower_name").Find(&batch); err != nil {
+			return nil, fmt.Errorf("find package file: %v", err)
+		}
+		for _, pf := range batch {
+			files[pf.VersionID] = append(files[pf.VersionID], pf)
+		}
+		left = left[limit:]
+	}
+	return files, nil
+}
+
+// findInBatches finds the beans with the given IDs into the map, the IDs are split
+// into batches to limit the number of variables of the IN clauses
+func findInBatches(e Engine, ids []int64, beans interface{}) error {
+	for len(ids) > 0 {
+		limit := defaultMaxInSize
+		if len(ids) < limit {
+			limit = len(ids)
+		}
+		if err := e.In("id", ids[:limit]).Find(beans); err != nil {
+			return err
+		}
+		ids = ids[limit:]
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePackageType(t *testing.T) {
+	for _, typ := range []PackageType{PackageGeneric, PackageNpm, PackageMaven, PackagePyPI} {
+		assert.Equal(t, typ, ParsePackageType(typ.Name()))
+	}
+	assert.EqualValues(t, 0, ParsePackageType("unknown"))
+}
+
+func TestPublishPackageFile(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	upload := func(version, filename string, newVersion bool) (*PackageVersion, error) {
+		return PublishPackageFile(&PackageUpload{
+			OwnerID:    2,
+			Type:       PackageGeneric,
+			Name:       "Tool",
+			Version:    version,
+			CreatorID:  2,
+			Metadata:   `{"file":"` + filename + `"}`,
+			NewVersion: newVersion,
+			File:       &PackageFile{Name: filename, Size: 10, HashSHA256: "hash-" + filename},
+		})
+	}
+
+	pv, err := upload("1.0", "a.bin", true)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "tool", pv.Package.LowerName)
+	_, err = upload("1.0", "b.bin", true)
+	assert.True(t, IsErrPackageVersionAlreadyExist(err))
+	_, err = upload("1.0", "A.bin", false)
+	assert.True(t, IsErrPackageFileAlreadyExist(err))
+
+	same, err := upload("1.0", "b.bin", false)
+	assert.NoError(t, err)
+	assert.EqualValues(t, pv.ID, same.ID)
+	pv, err = GetPackageVersionByName(2, PackageGeneric, "TOOL", "1.0")
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"file":"b.bin"}`, pv.Metadata)
+	AssertCount(t, &PackageFile{VersionID: pv.ID}, 2)
+
+	referenced, err := IsPackageContentReferenced("hash-a.bin")
+	assert.NoError(t, err)
+	assert.True(t, referenced)
+
+	_, err = upload("2.0", "a.bin", true)
+	assert.NoError(t, err)
+	versions, count, err := FindPackageVersions(&FindPackageVersionsOptions{OwnerID: 2, Query: "oo"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, versions, 2) {
+		assert.EqualValues(t, "2.0", versions[0].Version)
+		assert.EqualValues(t, "user2", versions[0].Creator.Name)
+		assert.EqualValues(t, "user2", versions[1].Package.Owner.Name)
+	}
+	versionFiles, err := PackageVersionList(versions).LoadFiles()
+	assert.NoError(t, err)
+	if assert.Len(t, versionFiles[pv.ID], 2) {
+		assert.EqualValues(t, "a.bin", versionFiles[pv.ID][0].LowerName)
+	}
+
+	files, err := GetPackageFiles(pv.ID)
+	assert.NoError(t, err)
+	for _, pf := range files {
+		assert.NoError(t, DeletePackageFile(pv, pf))
+	}
+	_, err = GetPackageVersion(pv.PackageID, "1.0")
+	assert.True(t, IsErrPackageVersionNotExist(err))
+	assert.NoError(t, DeletePackageVersion(versions[0]))
+	_, err = GetPackage(2, PackageGeneric, "tool")
+	assert.True(t, IsErrPackageNotExist(err))
+	referenced, err = IsPackageContentReferenced("hash-a.bin")
+	assert.NoError(t, err)
+	assert.False(t, referenced)
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"xorm.io/builder"
 )
@@ -70,6 +71,11 @@ func (r *Release) APIURL() string {
 		setting.AppURL, r.Repo.FullName(), r.ID)
 }
 
+// HTMLURL the url of the page of a release. release must have attributes loaded
+func (r *Release) HTMLURL() string {
+	return r.Repo.HTMLURL() + "/releases/tag/" + util.PathEscapeSegments(r.TagName)
+}
+
 // ZipURL the zip url for a release. release must have attributes loaded
 func (r *Release) ZipURL() string {
 	return fmt.Sprintf("%s/archive/%s.zip", r.Repo.HTMLURL(), r.TagName)
@@ -0,0 +1,203 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ReminderRuleType is the kind of the reminders sent by a rule
+type ReminderRuleType int
+
+const (
+	// ReminderRuleDueDate reminds the assignees of the issues some days before and on their due date
+	ReminderRuleDueDate ReminderRuleType = iota + 1
+	// ReminderRuleReviewRequest reminds the reviewers of the review requests pending for some business days
+	ReminderRuleReviewRequest
+	// ReminderRuleInactivePull reminds the authors of the pull requests without activity for some days
+	ReminderRuleInactivePull
+)
+
+var reminderRuleTypeNames = map[ReminderRuleType]string{
+	ReminderRuleDueDate:       "due_date",
+	ReminderRuleReviewRequest: "review_request",
+	ReminderRuleInactivePull:  "inactive_pull",
+}
+
+// ReminderRuleTypes are the types of the reminder rules
+var ReminderRuleTypes = []ReminderRuleType{
+	ReminderRuleDueDate,
+	ReminderRuleReviewRequest,
+	ReminderRuleInactivePull,
+}
+
+// Name returns the name of the rule type
+func (t ReminderRuleType) Name() string {
+	return reminderRuleTypeNames[t]
+}
+
+// ReminderRule represents the configuration of a kind of reminders for a repository.
+// A repository without a rule uses the default one of the type.
+type ReminderRule struct {
+	ID      int64            `xorm:"pk autoincr"`
+	RepoID  int64            `xorm:"UNIQUE(s) NOT NULL"`
+	Type    ReminderRuleType `xorm:"UNIQUE(s) NOT NULL"`
+	Enabled bool             `xorm:"NOT NULL DEFAULT false"`
+	Days    int              `xorm:"NOT NULL DEFAULT 0"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// DefaultReminderRule returns the rule of the type used by the repositories which did not configure it:
+// only the due date reminders are enabled by default
+func DefaultReminderRule(repoID int64, t ReminderRuleType) *ReminderRule {
+	rule := &ReminderRule{RepoID: repoID, Type: t}
+	switch t {
+	case ReminderRuleDueDate:
+		rule.Enabled = true
+		rule.Days = setting.Cron.SendReminders.DueDateDays
+	case ReminderRuleReviewRequest:
+		rule.Days = 2
+	case ReminderRuleInactivePull:
+		rule.Days = 14
+	}
+	return rule
+}
+
+// GetReminderRule returns the rule of the type of the repository, or the default one
+func GetReminderRule(repoID int64, t ReminderRuleType) (*ReminderRule, error) {
+	rule := new(ReminderRule)
+	has, err := x.Where("repo_id = ? AND type = ?", repoID, t).Get(rule)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return DefaultReminderRule(repoID, t), nil
+	}
+	return rule, nil
+}
+
+// GetReminderRules returns the rules of the repository indexed by type, including the default ones
+func GetReminderRules(repoID int64) (map[ReminderRuleType]*ReminderRule, error) {
+	rules := make([]*ReminderRule, 0, len(ReminderRuleTypes))
+	if err := x.Where("repo_id = ?", repoID).Find(&rules); err != nil {
+		return nil, err
+	}
+	byType := make(map[ReminderRuleType]*ReminderRule, len(ReminderRuleTypes))
+	for _, rule := range rules {
+		byType[rule.Type] = rule
+	}
+	for _, t := range ReminderRuleTypes {
+		if _, ok := byType[t]; !ok {
+			byType[t] = DefaultReminderRule(repoID, t)
+		}
+	}
+	return byType, nil
+}
+
+// GetEnabledReminderRules returns the rules of the type enabled by the repositories,
+// not including the default ones
+func GetEnabledReminderRules(t ReminderRuleType) ([]*ReminderRule, error) {
+	rules := make([]*ReminderRule, 0, 10)
+	return rules, x.Where("type = ? AND enabled = ?", t, true).Asc("repo_id").Find(&rules)
+}
+
+// GetDisabledReminderRepoIDs returns the IDs of the repositories which disabled the rules of the type
+func GetDisabledReminderRepoIDs(t ReminderRuleType) (map[int64]bool, error) {
+	ids := make([]int64, 0, 10)
+	if err := x.Table("reminder_rule").
+		Where("type = ? AND enabled = ?", t, false).
+		Cols("repo_id").
+		Find(&ids); err != nil {
+		return nil, err
+	}
+	disabled := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		disabled[id] = true
+	}
+	return disabled, nil
+}
+
+// SetReminderRule creates or updates the rule of the repository
+func SetReminderRule(rule *ReminderRule) error {
+	existing := new(ReminderRule)
+	has, err := x.Where("repo_id = ? AND type = ?", rule.RepoID, rule.Type).Get(existing)
+	if err != nil {
+		return err
+	} else if !has {
+		_, err = x.Insert(rule)
+		return err
+	}
+	rule.ID = existing.ID
+	_, err = x.ID(rule.ID).Cols("enabled", "days").Update(rule)
+	return err
+}
+
+// SentReminder records a reminder sent to a user, so that it is sent only once.
+// The name identifies the reminder among the ones of the issue.
+type SentReminder struct {
+	ID          int64              `xorm:"pk autoincr"`
+	IssueID     int64              `xorm:"UNIQUE(s) NOT NULL"`
+	UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"UNIQUE(s) VARCHAR(50) NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// MarkReminderSent records the reminder of the issue sent to the user, and
+// returns false if it was already sent
+func MarkReminderSent(issueID, userID int64, name string) (bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return false, err
+	}
+
+	has, err := sess.Where("issue_id = ? AND user_id = ? AND name = ?", issueID, userID, name).Exist(new(SentReminder))
+	if err != nil {
+		return false, err
+	} else if has {
+		return false, nil
+	}
+	if _, err = sess.Insert(&SentReminder{IssueID: issueID, UserID: userID, Name: name}); err != nil {
+		return false, err
+	}
+	return true, sess.Commit()
+}
+
+// GetOpenIssuesDueBetween returns the open issues and pull requests whose due
+// date is in the range (from, to]
+func GetOpenIssuesDueBetween(from, to timeutil.TimeStamp) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 10)
+	return issues, x.Where("is_closed = ? AND deadline_unix > ? AND deadline_unix <= ?", false, from, to).
+		Asc("id").
+		Find(&issues)
+}
+
+// GetPendingReviewRequests returns the review requests of the open pull requests of the
+// repository created before the time
+func GetPendingReviewRequests(repoID int64, before timeutil.TimeStamp) ([]*ReviewRequest, error) {
+	requests := make([]*ReviewRequest, 0, 10)
+	if err := x.Join("INNER", "issue", "issue.id = review_request.issue_id").
+		Where("issue.repo_id = ? AND issue.is_closed = ? AND review_request.created_unix < ?", repoID, false, before).
+		Asc("review_request.id").
+		Find(&requests); err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if err := request.loadAttributes(x); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// GetInactivePulls returns the open pull requests of the repository last updated before the time
+func GetInactivePulls(repoID int64, before timeutil.TimeStamp) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 10)
+	return issues, x.Where("repo_id = ? AND is_pull = ? AND is_closed = ? AND updated_unix < ?", repoID, true, false, before).
+		Asc("id").
+		Find(&issues)
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAndSetReminderRules(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	rules, err := GetReminderRules(1)
+	assert.NoError(t, err)
+	assert.Len(t, rules, len(ReminderRuleTypes))
+	assert.True(t, rules[ReminderRuleDueDate].Enabled)
+	assert.False(t, rules[ReminderRuleReviewRequest].Enabled)
+	assert.False(t, rules[ReminderRuleInactivePull].Enabled)
+
+	assert.NoError(t, SetReminderRule(&ReminderRule{RepoID: 1, Type: ReminderRuleReviewRequest, Enabled: true, Days: 3}))
+	assert.NoError(t, SetReminderRule(&ReminderRule{RepoID: 1, Type: ReminderRuleDueDate, Enabled: false, Days: 2}))
+	AssertCount(t, &ReminderRule{RepoID: 1}, 2)
+
+	rule, err := GetReminderRule(1, ReminderRuleReviewRequest)
+	assert.NoError(t, err)
+	assert.True(t, rule.Enabled)
+	assert.EqualValues(t, 3, rule.Days)
+
+	assert.NoError(t, SetReminderRule(&ReminderRule{RepoID: 1, Type: ReminderRuleReviewRequest, Enabled: true, Days: 5}))
+	AssertCount(t, &ReminderRule{RepoID: 1}, 2)
+	rule, err = GetReminderRule(1, ReminderRuleReviewRequest)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, rule.Days)
+
+	enabled, err := GetEnabledReminderRules(ReminderRuleReviewRequest)
+	assert.NoError(t, err)
+	if assert.Len(t, enabled, 1) {
+		assert.EqualValues(t, 1, enabled[0].RepoID)
+	}
+	disabled, err := GetDisabledReminderRepoIDs(ReminderRuleDueDate)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{1: true}, disabled)
+}
+
+func TestMarkReminderSent(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	sent, err := MarkReminderSent(1, 2, "due-1")
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	sent, err = MarkReminderSent(1, 2, "due-1")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	sent, err = MarkReminderSent(1, 2, "due-2")
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	AssertCount(t, &SentReminder{IssueID: 1, UserID: 2}, 2)
+}
+
+func TestGetOpenIssuesDueBetween(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	now := timeutil.TimeStampNow()
+	_, err := x.ID(1).Cols("deadline_unix").Update(&Issue{DeadlineUnix: now + 3600})
+	assert.NoError(t, err)
+	_, err = x.ID(2).Cols("deadline_unix").Update(&Issue{DeadlineUnix: now - 3600})
+	assert.NoError(t, err)
+
+	issues, err := GetOpenIssuesDueBetween(now, now+86400)
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 1) {
+		assert.EqualValues(t, 1, issues[0].ID)
+	}
+}
+
+func TestGetPendingReviewRequests(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	reviewer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	_, err := AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+
+	now := timeutil.TimeStampNow()
+	requests, err := GetPendingReviewRequests(1, now-3600)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 0)
+
+	requests, err = GetPendingReviewRequests(1, now+3600)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.EqualValues(t, reviewer.ID, requests[0].Reviewer.ID)
+	}
+}
+
+func TestGetInactivePulls(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issues, err := GetInactivePulls(1, 978307185)
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 1) {
+		assert.EqualValues(t, 3, issues[0].ID)
+	}
+}
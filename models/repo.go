@@ -1493,6 +1493,12 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Access{RepoID: repo.ID},
 		&Action{RepoID: repo.ID},
 		&Watch{RepoID: repoID},
+		&RepoNotificationPreference{RepoID: repoID},
+		&MailDigestItem{RepoID: repoID},
+		&ReminderRule{RepoID: repoID},
+		&StaleRule{RepoID: repoID},
+		&StaleIssue{RepoID: repoID},
+		&IssueRedirect{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
 		&Milestone{RepoID: repoID},
@@ -1506,13 +1512,25 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Notification{RepoID: repoID},
 		&CommitStatus{RepoID: repoID},
 		&RepoIndexerStatus{RepoID: repoID},
+		&CommitIndexerStatus{RepoID: repoID},
 		&LanguageStat{RepoID: repoID},
 		&Comment{RefRepoID: repoID},
 		&Task{RepoID: repoID},
+		&CIRunnerToken{RepoID: repoID},
+		&CIRunner{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	// Delete CI runs with their jobs and steps
+	if _, err = sess.In("job_id", builder.Select("id").From("ci_job").Where(builder.Eq{"repo_id": repoID})).
+		Delete(&CIStep{}); err != nil {
+		return err
+	}
+	if err = deleteBeans(sess, &CIJob{RepoID: repoID}, &CIRun{RepoID: repoID}); err != nil {
+		return fmt.Errorf("deleteBeans: %v", err)
+	}
+
 	deleteCond := builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})
 	// Delete comments and attachments
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1540,6 +1558,16 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 
+	if _, err = sess.In("issue_id", deleteCond).
+		Delete(&ReviewRequest{}); err != nil {
+		return err
+	}
+
+	if _, err = sess.In("issue_id", deleteCond).
+		Delete(&SentReminder{}); err != nil {
+		return err
+	}
+
 	attachments = attachments[:0]
 	if err = sess.Join("INNER", "issue", "issue.id = attachment.issue_id").
 		Where("issue.repo_id = ?", repoID).
@@ -1643,6 +1671,11 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		removeAllWithNotice(x, "Delete release attachment", releaseAttachments[i])
 	}
 
+	// Remove CI logs.
+	if len(setting.CI.LogPath) > 0 {
+		removeAllWithNotice(x, "Delete CI logs", CILogDir(repoID))
+	}
+
 	if len(repo.Avatar) > 0 {
 		avatarPath := repo.CustomAvatarPath()
 		if com.IsExist(avatarPath) {
@@ -1675,6 +1708,34 @@ func getRepositoryByOwnerAndName(e Engine, ownerName, repoName string) (*Reposit
 	return &repo, nil
 }
 
+// FilterRepositoryIDsByFullNames restricts repoIDs, nil meaning all repositories, to
+// the repositories with the given owner/name full names. Unknown names are ignored,
+// repoIDs is returned as is if there is no name.
+func FilterRepositoryIDsByFullNames(repoIDs []int64, fullNames []string) ([]int64, error) {
+	if len(fullNames) == 0 {
+		return repoIDs, nil
+	}
+
+	filtered := make([]int64, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		parts := strings.SplitN(fullName, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		repo, err := GetRepositoryByOwnerAndName(parts[0], parts[1])
+		if err != nil {
+			if IsErrRepoNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if repoIDs == nil || util.IsInt64InSlice(repo.ID, repoIDs) {
+			filtered = append(filtered, repo.ID)
+		}
+	}
+	return filtered, nil
+}
+
 // GetRepositoryByName returns the repository by given name under user if exists.
 func GetRepositoryByName(ownerID int64, name string) (*Repository, error) {
 	repo := &Repository{
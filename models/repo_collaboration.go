@@ -194,6 +194,10 @@ func (repo *Repository) DeleteCollaboration(uid int64) (err error) {
 		return err
 	}
 
+	if err = deleteRepoNotificationPreference(sess, uid, repo.ID); err != nil {
+		return err
+	}
+
 	return sess.Commit()
 }
 
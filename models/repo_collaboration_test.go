@@ -84,8 +84,10 @@ func TestRepository_DeleteCollaboration(t *testing.T) {
 
 	repo := AssertExistsAndLoadBean(t, &Repository{ID: 4}).(*Repository)
 	assert.NoError(t, repo.GetOwner())
+	assert.NoError(t, SetRepoNotificationLevel(4, repo.ID, RepoNotificationLevelAll))
 	assert.NoError(t, repo.DeleteCollaboration(4))
 	AssertNotExistsBean(t, &Collaboration{RepoID: repo.ID, UserID: 4})
+	AssertNotExistsBean(t, &RepoNotificationPreference{RepoID: repo.ID, UserID: 4})
 
 	assert.NoError(t, repo.DeleteCollaboration(4))
 	AssertNotExistsBean(t, &Collaboration{RepoID: repo.ID, UserID: 4})
@@ -115,3 +115,70 @@ func (repo *Repository) updateIndexerStatus(e Engine, indexerType RepoIndexerTyp
 func (repo *Repository) UpdateIndexerStatus(indexerType RepoIndexerType, sha string) error {
 	return repo.updateIndexerStatus(x, indexerType, sha)
 }
+
+// DeleteIndexerStatus removes the indexer status of a repository, so the next
+// update indexes it from scratch
+func DeleteIndexerStatus(repoID int64, indexerType RepoIndexerType) error {
+	_, err := x.Where("repo_id = ? AND indexer_type = ?", repoID, indexerType).Delete(new(RepoIndexerStatus))
+	return err
+}
+
+// DeleteAllIndexerStatus removes the indexer status of all repositories for the
+// given indexer type
+func DeleteAllIndexerStatus(indexerType RepoIndexerType) error {
+	_, err := x.Where("indexer_type = ?", indexerType).Delete(new(RepoIndexerStatus))
+	return err
+}
+
+// CommitIndexerStatus is the state of a repository in the commit indexer, the
+// heads of its branches when it was last indexed
+type CommitIndexerStatus struct {
+	ID          int64    `xorm:"pk autoincr"`
+	RepoID      int64    `xorm:"UNIQUE NOT NULL"`
+	BranchHeads []string `xorm:"TEXT JSON"`
+}
+
+// GetCommitIndexerStatus returns the commit indexer status of a repository,
+// with no branch heads if it was never indexed
+func GetCommitIndexerStatus(repoID int64) (*CommitIndexerStatus, error) {
+	status := &CommitIndexerStatus{RepoID: repoID}
+	if _, err := x.Get(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// UpdateCommitIndexerStatus saves the commit indexer status of a repository
+func UpdateCommitIndexerStatus(status *CommitIndexerStatus) error {
+	if status.ID == 0 {
+		_, err := x.Insert(status)
+		return err
+	}
+	_, err := x.ID(status.ID).Cols("branch_heads").Update(status)
+	return err
+}
+
+// DeleteCommitIndexerStatus removes the commit indexer status of a repository,
+// so the next update indexes it from scratch
+func DeleteCommitIndexerStatus(repoID int64) error {
+	_, err := x.Where("repo_id = ?", repoID).Delete(new(CommitIndexerStatus))
+	return err
+}
+
+// GetCommitUnindexedRepos returns the IDs of the repositories, up to
+// maxRepoID if not 0, which do not have a commit indexer status
+func GetCommitUnindexedRepos(maxRepoID int64, pageSize int) ([]int64, error) {
+	ids := make([]int64, 0, pageSize)
+	cond := builder.Cond(builder.IsNull{"commit_indexer_status.id"})
+	if maxRepoID > 0 {
+		cond = cond.And(builder.Lte{"repository.id": maxRepoID})
+	}
+	err := x.Table("repository").
+		Join("LEFT OUTER", "commit_indexer_status", "repository.id = commit_indexer_status.repo_id").
+		Where(cond).
+		Cols("repository.id").
+		Desc("repository.id").
+		Limit(pageSize).
+		Find(&ids)
+	return ids, err
+}
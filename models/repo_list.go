@@ -399,3 +399,87 @@ func FindUserAccessibleRepoIDs(user *User) ([]int64, error) {
 	}
 	return repoIDs, nil
 }
+
+// codeReadableRepositoryCondition narrows down the accessible repositories to
+// the ones whose code the user can read, following getUserRepoPermission
+func codeReadableRepositoryCondition(user *User, hasTwoFactor bool) builder.Cond {
+	cond := builder.And(
+		accessibleRepositoryCondition(user),
+		builder.In("`repository`.id", builder.Select("repo_id").From("repo_unit").Where(builder.Eq{"type": UnitTypeCode})))
+
+	orgIDs := builder.Select("id").From("`user`").Where(builder.Eq{"type": UserTypeOrganization})
+	// The code of an accessible repository is readable if the owner is not an
+	// organization, or if the repository is public and the user isn't restricted
+	readable := builder.NewCond().Or(builder.NotIn("`repository`.owner_id", orgIDs))
+	if user == nil || !user.IsRestricted {
+		readable = readable.Or(builder.Eq{"`repository`.is_private": false})
+	}
+	if user == nil {
+		return cond.And(readable)
+	}
+
+	// Otherwise the user has to be a collaborator, or in a team of the repository
+	// which is an owner team or has the code unit. Only the members of private
+	// organizations, or of any organization for restricted users, see them.
+	visibleOrg := builder.In("`repository`.owner_id", builder.Select("org_id").From("org_user").Where(builder.Eq{"uid": user.ID}))
+	if !user.IsRestricted {
+		visibleOrg = builder.Or(visibleOrg, builder.NotIn("`repository`.owner_id",
+			builder.Select("id").From("`user`").Where(builder.Eq{"visibility": structs.VisibleTypePrivate})))
+	}
+	readable = readable.Or(
+		builder.In("`repository`.id", builder.Select("repo_id").From("collaboration").Where(builder.Eq{"user_id": user.ID})),
+		builder.And(
+			visibleOrg,
+			builder.In("`repository`.id", builder.Select("`team_repo`.repo_id").
+				From("team_repo").
+				Join("INNER", "team_user", "`team_user`.team_id = `team_repo`.team_id").
+				Where(builder.Eq{"`team_user`.uid": user.ID}.And(builder.Or(
+					builder.In("`team_repo`.team_id", builder.Select("id").From("team").Where(builder.Gte{"authorize": AccessModeOwner})),
+					builder.In("`team_repo`.team_id", builder.Select("team_id").From("team_unit").Where(builder.Eq{"type": UnitTypeCode}))))))))
+	cond = cond.And(readable)
+
+	// Organizations requiring two-factor authentication only grant what everyone else gets
+	if !hasTwoFactor {
+		twoFactorOrgIDs := builder.Select("id").From("`user`").Where(builder.Eq{"type": UserTypeOrganization, "require_two_factor": true})
+		if user.IsRestricted {
+			cond = cond.And(builder.NotIn("`repository`.owner_id", twoFactorOrgIDs))
+		} else {
+			cond = cond.And(builder.Not{builder.And(
+				builder.Eq{"`repository`.is_private": true},
+				builder.In("`repository`.owner_id", twoFactorOrgIDs))})
+		}
+	}
+	return cond
+}
+
+// FindUserCodeAccessibleRepoIDs finds the IDs of the repositories whose code the
+// user can read, among the repositories of ownerID if it isn't 0. It returns
+// nil for site admins searching all repositories, who can read all of them.
+func FindUserCodeAccessibleRepoIDs(user *User, ownerID int64) ([]int64, error) {
+	var cond builder.Cond = builder.NewCond()
+	if user == nil || !user.IsAdmin {
+		var hasTwoFactor bool
+		if user != nil {
+			var err error
+			if hasTwoFactor, err = hasTwoFactorByUID(x, user.ID); err != nil {
+				return nil, err
+			}
+		}
+		cond = codeReadableRepositoryCondition(user, hasTwoFactor)
+	} else if ownerID == 0 {
+		return nil, nil
+	}
+	if ownerID > 0 {
+		cond = cond.And(builder.Eq{"`repository`.owner_id": ownerID})
+	}
+
+	repoIDs := make([]int64, 0, 10)
+	if err := x.
+		Table("repository").
+		Cols("id").
+		Where(cond).
+		Find(&repoIDs); err != nil {
+		return nil, fmt.Errorf("FindUserCodeAccessibleRepoIDs: %v", err)
+	}
+	return repoIDs, nil
+}
@@ -293,3 +293,65 @@ func TestSearchRepositoryByTopicName(t *testing.T) {
 		})
 	}
 }
+
+func TestFindUserCodeAccessibleRepoIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// the repositories found have to be the ones readable according to the permissions
+	test := func(user *User) {
+		var repos []*Repository
+		assert.NoError(t, x.Find(&repos))
+
+		repoIDs, err := FindUserCodeAccessibleRepoIDs(user, 0)
+		assert.NoError(t, err)
+		accessibleIDs, err := FindUserAccessibleRepoIDs(user)
+		assert.NoError(t, err)
+
+		expected := make([]int64, 0, len(accessibleIDs))
+		for _, repo := range repos {
+			if !util.IsInt64InSlice(repo.ID, accessibleIDs) {
+				continue
+			}
+			perm, err := GetUserRepoPermission(repo, user)
+			assert.NoError(t, err)
+			if perm.CanRead(UnitTypeCode) {
+				expected = append(expected, repo.ID)
+			}
+		}
+		assert.ElementsMatch(t, expected, repoIDs)
+	}
+
+	testAll := func() {
+		test(nil)
+		var users []*User
+		assert.NoError(t, x.Where("type = ?", UserTypeIndividual).Find(&users))
+		for _, user := range users {
+			if user.IsAdmin {
+				repoIDs, err := FindUserCodeAccessibleRepoIDs(user, 0)
+				assert.NoError(t, err)
+				assert.Nil(t, repoIDs)
+				continue
+			}
+			test(user)
+			user.IsRestricted = true
+			test(user)
+		}
+	}
+	testAll()
+
+	// organizations requiring two-factor authentication
+	_, err := x.Where("type = ?", UserTypeOrganization).Cols("require_two_factor").Update(&User{RequireTwoFactor: true})
+	assert.NoError(t, err)
+	testAll()
+
+	// only the repositories of the owner
+	repoIDs, err := FindUserCodeAccessibleRepoIDs(nil, 3)
+	assert.NoError(t, err)
+	for _, repoID := range repoIDs {
+		repo := AssertExistsAndLoadBean(t, &Repository{ID: repoID}).(*Repository)
+		assert.EqualValues(t, 3, repo.OwnerID)
+	}
+	repoIDs, err = FindUserCodeAccessibleRepoIDs(AssertExistsAndLoadBean(t, &User{ID: 1}).(*User), 3)
+	assert.NoError(t, err)
+	assert.Len(t, repoIDs, int(AssertExistsAndLoadBean(t, &User{ID: 3}).(*User).NumRepos))
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+// RepoNotificationLevel is the level of the email notifications a user gets
+// about a repository, on top of the email notification preference of the user
+type RepoNotificationLevel int8
+
+const (
+	// RepoNotificationLevelDefault gets the mails of the watched repositories
+	// and of the threads the user participates in
+	RepoNotificationLevelDefault RepoNotificationLevel = iota // 0
+	// RepoNotificationLevelAll gets the mails of all the activity and the releases, even if not watching
+	RepoNotificationLevelAll // 1
+	// RepoNotificationLevelParticipating only gets the mails of the threads the user participates in
+	RepoNotificationLevelParticipating // 2
+	// RepoNotificationLevelReleases only gets the mails of the releases
+	RepoNotificationLevelReleases // 3
+	// RepoNotificationLevelMuted gets no mail
+	RepoNotificationLevelMuted // 4
+)
+
+// Name returns the name of the level, used by its locale keys and routes
+func (level RepoNotificationLevel) Name() string {
+	switch level {
+	case RepoNotificationLevelAll:
+		return "all"
+	case RepoNotificationLevelParticipating:
+		return "participating"
+	case RepoNotificationLevelReleases:
+		return "releases"
+	case RepoNotificationLevelMuted:
+		return "muted"
+	}
+	return "default"
+}
+
+// RepoNotificationLevelFromName returns the level of the name
+func RepoNotificationLevelFromName(name string) (RepoNotificationLevel, bool) {
+	for _, level := range RepoNotificationLevels {
+		if level.Name() == name {
+			return level, true
+		}
+	}
+	return RepoNotificationLevelDefault, false
+}
+
+// RepoNotificationLevels are all the levels, in the order they are listed
+var RepoNotificationLevels = []RepoNotificationLevel{
+	RepoNotificationLevelDefault,
+	RepoNotificationLevelAll,
+	RepoNotificationLevelParticipating,
+	RepoNotificationLevelReleases,
+	RepoNotificationLevelMuted,
+}
+
+// RepoNotificationPreference is the level of the email notifications a user
+// chose for a repository; there is no record for the default level
+type RepoNotificationPreference struct {
+	ID     int64                 `xorm:"pk autoincr"`
+	UserID int64                 `xorm:"UNIQUE(s)"`
+	RepoID int64                 `xorm:"UNIQUE(s) INDEX"`
+	Level  RepoNotificationLevel `xorm:"SMALLINT NOT NULL DEFAULT 0"`
+}
+
+// GetRepoNotificationLevel returns the level of the email notifications the user gets about the repository
+func GetRepoNotificationLevel(userID, repoID int64) (RepoNotificationLevel, error) {
+	pref := &RepoNotificationPreference{}
+	has, err := x.Where("user_id = ? AND repo_id = ?", userID, repoID).Get(pref)
+	if err != nil || !has {
+		return RepoNotificationLevelDefault, err
+	}
+	return pref.Level, nil
+}
+
+// GetRepoNotificationLevels returns the levels of the users who chose another
+// level than the default one for the repository
+func GetRepoNotificationLevels(repoID int64) (map[int64]RepoNotificationLevel, error) {
+	prefs := make([]*RepoNotificationPreference, 0, 10)
+	if err := x.Where("repo_id = ?", repoID).Find(&prefs); err != nil {
+		return nil, err
+	}
+	levels := make(map[int64]RepoNotificationLevel, len(prefs))
+	for _, pref := range prefs {
+		levels[pref.UserID] = pref.Level
+	}
+	return levels, nil
+}
+
+// SetRepoNotificationLevel sets the level of the email notifications the user gets about the repository
+func SetRepoNotificationLevel(userID, repoID int64, level RepoNotificationLevel) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Delete(&RepoNotificationPreference{UserID: userID, RepoID: repoID}); err != nil {
+		return err
+	}
+	if level != RepoNotificationLevelDefault {
+		if _, err := sess.Insert(&RepoNotificationPreference{UserID: userID, RepoID: repoID, Level: level}); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// deleteRepoNotificationPreference resets the level of the user for the
+// repository, when the user loses the access to it
+func deleteRepoNotificationPreference(e Engine, userID, repoID int64) error {
+	_, err := e.Delete(&RepoNotificationPreference{UserID: userID, RepoID: repoID})
+	return err
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoNotificationLevelFromName(t *testing.T) {
+	for _, level := range RepoNotificationLevels {
+		parsed, ok := RepoNotificationLevelFromName(level.Name())
+		assert.True(t, ok)
+		assert.Equal(t, level, parsed)
+	}
+	_, ok := RepoNotificationLevelFromName("everything")
+	assert.False(t, ok)
+}
+
+func TestSetRepoNotificationLevel(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	level, err := GetRepoNotificationLevel(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, RepoNotificationLevelDefault, level)
+
+	assert.NoError(t, SetRepoNotificationLevel(2, 1, RepoNotificationLevelReleases))
+	assert.NoError(t, SetRepoNotificationLevel(4, 1, RepoNotificationLevelMuted))
+	assert.NoError(t, SetRepoNotificationLevel(2, 1, RepoNotificationLevelParticipating))
+	level, err = GetRepoNotificationLevel(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, RepoNotificationLevelParticipating, level)
+
+	levels, err := GetRepoNotificationLevels(1)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]RepoNotificationLevel{
+		2: RepoNotificationLevelParticipating,
+		4: RepoNotificationLevelMuted,
+	}, levels)
+
+	// the default level isn't stored
+	assert.NoError(t, SetRepoNotificationLevel(2, 1, RepoNotificationLevelDefault))
+	AssertNotExistsBean(t, &RepoNotificationPreference{UserID: 2, RepoID: 1})
+}
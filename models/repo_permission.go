@@ -15,6 +15,10 @@ type Permission struct {
 	AccessMode AccessMode
 	Units      []*RepoUnit
 	UnitsMode  map[UnitType]AccessMode
+
+	// TwoFactorRequired is true if the access granted by the organization was withheld
+	// because the user has not enrolled two-factor authentication
+	TwoFactorRequired bool
 }
 
 // IsOwner returns true if current user is the owner of repository.
@@ -216,6 +220,42 @@ func getUserRepoPermission(e Engine, repo *Repository, user *User) (perm Permiss
 
 	perm.UnitsMode = make(map[UnitType]AccessMode)
 
+	// get units mode from teams
+	teams, err := getUserRepoTeams(e, repo.OwnerID, user.ID, repo.ID)
+	if err != nil {
+		return
+	}
+
+	// Organizations requiring two-factor authentication only grant what everyone else gets.
+	// Only the access of members, including the ones of the teams of the repository, and
+	// collaborators is withheld, other users are granted nothing by the organization and
+	// must not learn that its private repositories exist.
+	if repo.Owner.RequireTwoFactor {
+		isMember := isCollaborator || len(teams) > 0
+		if !isMember {
+			if isMember, err = isOrganizationMember(e, repo.OwnerID, user.ID); err != nil {
+				return
+			}
+		}
+		var hasTwoFactor bool
+		if isMember {
+			if hasTwoFactor, err = hasTwoFactorByUID(e, user.ID); err != nil {
+				return
+			}
+		}
+		if isMember && !hasTwoFactor {
+			perm.TwoFactorRequired = true
+			perm.AccessMode = AccessModeNone
+			if !repo.IsPrivate && !user.IsRestricted {
+				perm.AccessMode = AccessModeRead
+				for _, u := range repo.Units {
+					perm.UnitsMode[u.Type] = AccessModeRead
+				}
+			}
+			return
+		}
+	}
+
 	// Collaborators on organization
 	if isCollaborator {
 		for _, u := range repo.Units {
@@ -223,12 +263,6 @@ func getUserRepoPermission(e Engine, repo *Repository, user *User) (perm Permiss
 		}
 	}
 
-	// get units mode from teams
-	teams, err := getUserRepoTeams(e, repo.OwnerID, user.ID, repo.ID)
-	if err != nil {
-		return
-	}
-
 	// if user in an owner team
 	for _, team := range teams {
 		if team.Authorize >= AccessModeOwner {
@@ -255,3 +255,64 @@ func TestRepoPermissionPrivateOrgRepo(t *testing.T) {
 		assert.True(t, perm.CanWrite(unit.Type))
 	}
 }
+
+func TestRepoPermissionTwoFactorRequired(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	org.RequireTwoFactor = true
+	assert.NoError(t, UpdateUserCols(org, "require_two_factor"))
+
+	// private organization repo
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	assert.NoError(t, repo.getUnits(x))
+
+	// owner without two-factor authentication
+	owner := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	perm, err := GetUserRepoPermission(repo, owner)
+	assert.NoError(t, err)
+	assert.True(t, perm.TwoFactorRequired)
+	assert.False(t, perm.HasAccess())
+	for _, unit := range repo.Units {
+		assert.False(t, perm.CanRead(unit.Type))
+	}
+
+	// public organization repo
+	publicRepo := AssertExistsAndLoadBean(t, &Repository{ID: 32}).(*Repository)
+	assert.NoError(t, publicRepo.getUnits(x))
+	perm, err = GetUserRepoPermission(publicRepo, owner)
+	assert.NoError(t, err)
+	assert.True(t, perm.TwoFactorRequired)
+	for _, unit := range publicRepo.Units {
+		assert.True(t, perm.CanRead(unit.Type))
+		assert.False(t, perm.CanWrite(unit.Type))
+	}
+
+	// non-member without two-factor authentication
+	stranger := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+	perm, err = GetUserRepoPermission(repo, stranger)
+	assert.NoError(t, err)
+	assert.False(t, perm.TwoFactorRequired)
+	assert.False(t, perm.HasAccess())
+	perm, err = GetUserRepoPermission(publicRepo, stranger)
+	assert.NoError(t, err)
+	assert.False(t, perm.TwoFactorRequired)
+	for _, unit := range publicRepo.Units {
+		assert.True(t, perm.CanRead(unit.Type))
+		assert.False(t, perm.CanWrite(unit.Type))
+	}
+
+	// admin
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	perm, err = GetUserRepoPermission(repo, admin)
+	assert.NoError(t, err)
+	assert.False(t, perm.TwoFactorRequired)
+	assert.True(t, perm.IsOwner())
+
+	// owner after enrolling two-factor authentication
+	assert.NoError(t, NewTwoFactor(&TwoFactor{UID: owner.ID}))
+	perm, err = GetUserRepoPermission(repo, owner)
+	assert.NoError(t, err)
+	assert.False(t, perm.TwoFactorRequired)
+	assert.True(t, perm.IsOwner())
+}
@@ -133,6 +133,21 @@ func TestGetUserFork(t *testing.T) {
 	assert.Nil(t, repo)
 }
 
+func TestFilterRepositoryIDsByFullNames(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	repoIDs, err := FilterRepositoryIDsByFullNames(nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, repoIDs)
+	repoIDs, err = FilterRepositoryIDsByFullNames(nil, []string{"User2/Repo1", "user2/missing", "invalid"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, repoIDs)
+	repoIDs, err = FilterRepositoryIDsByFullNames([]int64{2}, []string{"user2/repo1"})
+	assert.NoError(t, err)
+	assert.NotNil(t, repoIDs)
+	assert.Empty(t, repoIDs)
+}
+
 func TestRepoAPIURL(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	repo := AssertExistsAndLoadBean(t, &Repository{ID: 10}).(*Repository)
@@ -252,7 +252,7 @@ func notifyWatchers(e Engine, actions ...*Action) error {
 				if !permCode[i] {
 					continue
 				}
-			case ActionCreateIssue, ActionCommentIssue, ActionCloseIssue, ActionReopenIssue:
+			case ActionCreateIssue, ActionCommentIssue, ActionCloseIssue, ActionReopenIssue, ActionTransferIssue:
 				if !permIssue[i] {
 					continue
 				}
@@ -342,6 +342,11 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content, comm
 		}
 	}
 
+	// Any review of the user satisfies the requests for the user and its teams
+	if err := removeSatisfiedReviewRequests(sess, issue.ID, doer.ID); err != nil {
+		return nil, nil, err
+	}
+
 	comm, err := createComment(sess, &CreateCommentOptions{
 		Type:     CommentTypeReview,
 		Doer:     doer,
@@ -0,0 +1,313 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"sort"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// ReviewRequest represents a pending request for a user or a team to review a
+// pull request. The request of a team is satisfied by the review of any of its members.
+type ReviewRequest struct {
+	ID             int64 `xorm:"pk autoincr"`
+	IssueID        int64 `xorm:"INDEX NOT NULL"`
+	ReviewerID     int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	ReviewerTeamID int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	DoerID         int64 `xorm:"NOT NULL DEFAULT 0"`
+
+	Reviewer     *User `xorm:"-"`
+	ReviewerTeam *Team `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+func (r *ReviewRequest) loadAttributes(e Engine) (err error) {
+	if r.ReviewerID > 0 && r.Reviewer == nil {
+		if r.Reviewer, err = getUserByID(e, r.ReviewerID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return err
+			}
+			r.Reviewer = NewGhostUser()
+		}
+	}
+	if r.ReviewerTeamID > 0 && r.ReviewerTeam == nil {
+		if r.ReviewerTeam, err = getTeamByID(e, r.ReviewerTeamID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getReviewRequests(e Engine, issueID int64) ([]*ReviewRequest, error) {
+	requests := make([]*ReviewRequest, 0, 5)
+	if err := e.Where("issue_id = ?", issueID).Asc("id").Find(&requests); err != nil {
+		return nil, err
+	}
+	for _, request := range requests {
+		if err := request.loadAttributes(e); err != nil {
+			return nil, err
+		}
+	}
+	return requests, nil
+}
+
+// GetReviewRequests returns the pending review requests of a pull request
+func GetReviewRequests(issueID int64) ([]*ReviewRequest, error) {
+	return getReviewRequests(x, issueID)
+}
+
+// IsValidReviewRequest checks whether the user can be requested to review the pull request
+func IsValidReviewRequest(issue *Issue, reviewer *User) error {
+	if err := issue.LoadRepo(); err != nil {
+		return err
+	}
+	if !issue.IsPull {
+		return ErrNotValidReviewRequest{Reason: "issue is not a pull request", UserID: reviewer.ID, RepoID: issue.RepoID}
+	}
+	if reviewer.ID == issue.PosterID {
+		return ErrNotValidReviewRequest{Reason: "poster of the pull request can't review it", UserID: reviewer.ID, RepoID: issue.RepoID}
+	}
+	if reviewer.IsOrganization() || !reviewer.IsActive || reviewer.ProhibitLogin {
+		return ErrNotValidReviewRequest{Reason: "reviewer can't sign in", UserID: reviewer.ID, RepoID: issue.RepoID}
+	}
+	perm, err := GetUserRepoPermission(issue.Repo, reviewer)
+	if err != nil {
+		return err
+	}
+	if !perm.CanRead(UnitTypePullRequests) {
+		return ErrNotValidReviewRequest{Reason: "reviewer can't read the pull request", UserID: reviewer.ID, RepoID: issue.RepoID}
+	}
+	return nil
+}
+
+// IsValidTeamReviewRequest checks whether the team can be requested to review the pull request
+func IsValidTeamReviewRequest(issue *Issue, team *Team) error {
+	if err := issue.LoadRepo(); err != nil {
+		return err
+	}
+	if !issue.IsPull {
+		return ErrNotValidReviewRequest{Reason: "issue is not a pull request", TeamID: team.ID, RepoID: issue.RepoID}
+	}
+	if team.OrgID != issue.Repo.OwnerID {
+		return ErrNotValidReviewRequest{Reason: "team doesn't belong to the owner of the repository", TeamID: team.ID, RepoID: issue.RepoID}
+	}
+	if team.Authorize < AccessModeOwner && (!team.HasRepository(issue.RepoID) || !team.UnitEnabled(UnitTypePullRequests)) {
+		return ErrNotValidReviewRequest{Reason: "team can't read the pull request", TeamID: team.ID, RepoID: issue.RepoID}
+	}
+	return nil
+}
+
+// AddReviewRequest requests a user to review a pull request, and returns the
+// comment telling so, or nil if the review was already requested
+func AddReviewRequest(issue *Issue, reviewer, doer *User) (*Comment, error) {
+	return addReviewRequest(issue, &ReviewRequest{IssueID: issue.ID, ReviewerID: reviewer.ID, DoerID: doer.ID}, doer)
+}
+
+// AddTeamReviewRequest requests a team to review a pull request, and returns
+// the comment telling so, or nil if the review was already requested
+func AddTeamReviewRequest(issue *Issue, team *Team, doer *User) (*Comment, error) {
+	return addReviewRequest(issue, &ReviewRequest{IssueID: issue.ID, ReviewerTeamID: team.ID, DoerID: doer.ID}, doer)
+}
+
+func addReviewRequest(issue *Issue, request *ReviewRequest, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if has, err := sess.Exist(&ReviewRequest{
+		IssueID:        request.IssueID,
+		ReviewerID:     request.ReviewerID,
+		ReviewerTeamID: request.ReviewerTeamID,
+	}); err != nil {
+		return nil, err
+	} else if has {
+		return nil, nil
+	}
+	if _, err := sess.Insert(request); err != nil {
+		return nil, err
+	}
+
+	comment, err := createReviewRequestComment(sess, issue, doer, request, false)
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+// RemoveReviewRequest withdraws the request for a user to review a pull
+// request, and returns the comment telling so, or nil if it wasn't requested
+func RemoveReviewRequest(issue *Issue, reviewer, doer *User) (*Comment, error) {
+	return removeReviewRequest(issue, &ReviewRequest{IssueID: issue.ID, ReviewerID: reviewer.ID}, doer)
+}
+
+// RemoveTeamReviewRequest withdraws the request for a team to review a pull
+// request, and returns the comment telling so, or nil if it wasn't requested
+func RemoveTeamReviewRequest(issue *Issue, team *Team, doer *User) (*Comment, error) {
+	return removeReviewRequest(issue, &ReviewRequest{IssueID: issue.ID, ReviewerTeamID: team.ID}, doer)
+}
+
+func removeReviewRequest(issue *Issue, request *ReviewRequest, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if deleted, err := sess.
+		Where("issue_id = ? AND reviewer_id = ? AND reviewer_team_id = ?", request.IssueID, request.ReviewerID, request.ReviewerTeamID).
+		Delete(new(ReviewRequest)); err != nil {
+		return nil, err
+	} else if deleted == 0 {
+		return nil, nil
+	}
+
+	comment, err := createReviewRequestComment(sess, issue, doer, request, true)
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+func createReviewRequestComment(e *xorm.Session, issue *Issue, doer *User, request *ReviewRequest, removed bool) (*Comment, error) {
+	if err := issue.loadRepo(e); err != nil {
+		return nil, err
+	}
+	return createComment(e, &CreateCommentOptions{
+		Type:            CommentTypeReviewRequest,
+		Doer:            doer,
+		Repo:            issue.Repo,
+		Issue:           issue,
+		AssigneeID:      request.ReviewerID,
+		AssigneeTeamID:  request.ReviewerTeamID,
+		RemovedAssignee: removed,
+	})
+}
+
+// removeSatisfiedReviewRequests removes the review requests satisfied by the
+// review of the user, the ones of the user and of the teams the user belongs to
+func removeSatisfiedReviewRequests(e Engine, issueID, reviewerID int64) error {
+	_, err := e.
+		Where(builder.Eq{"issue_id": issueID}.And(
+			builder.Eq{"reviewer_id": reviewerID}.Or(
+				builder.In("reviewer_team_id", builder.Select("team_id").From("team_user").Where(builder.Eq{"uid": reviewerID}))))).
+		Delete(new(ReviewRequest))
+	return err
+}
+
+// getReviewRequestedUserIDs returns the IDs of the users requested to review
+// the pull request, either directly or as members of a requested team for
+// which no specific member is picked
+func getReviewRequestedUserIDs(e Engine, issueID int64) ([]int64, error) {
+	ids := make([]int64, 0, 5)
+	if err := e.Table("review_request").
+		Where("issue_id = ? AND reviewer_id > 0", issueID).
+		Cols("reviewer_id").
+		Find(&ids); err != nil {
+		return nil, err
+	}
+
+	memberIDs := make([]int64, 0, 10)
+	if err := e.Table("team_user").
+		Join("INNER", "review_request", "review_request.reviewer_team_id = team_user.team_id").
+		Join("INNER", "team", "team.id = team_user.team_id").
+		Where("review_request.issue_id = ? AND team.review_assignment = ?", issueID, TeamReviewAssignmentNone).
+		Cols("team_user.uid").
+		Find(&memberIDs); err != nil {
+		return nil, err
+	}
+	return append(ids, memberIDs...), nil
+}
+
+// PickTeamReviewer picks the member of the team to review the pull request
+// following the review assignment of the team, or returns nil if there is
+// none or no member can review it
+func PickTeamReviewer(issue *Issue, team *Team, doer *User) (*User, error) {
+	if team.ReviewAssignment == TeamReviewAssignmentNone {
+		return nil, nil
+	}
+
+	members, err := GetTeamMembers(team.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*User, 0, len(members))
+	for _, member := range members {
+		if member.ID == doer.ID {
+			continue
+		}
+		if err := IsValidReviewRequest(issue, member); err != nil {
+			if IsErrNotValidReviewRequest(err) {
+				continue
+			}
+			return nil, err
+		}
+		candidates = append(candidates, member)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// The members are taken in turn, starting after the last picked one
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID < candidates[j].ID
+	})
+	start := sort.Search(len(candidates), func(i int) bool {
+		return candidates[i].ID > team.LastReviewerID
+	}) % len(candidates)
+	candidates = append(candidates[start:], candidates[:start]...)
+
+	picked := candidates[0]
+	if team.ReviewAssignment == TeamReviewAssignmentLoadBalance {
+		ids := make([]int64, len(candidates))
+		for i, candidate := range candidates {
+			ids[i] = candidate.ID
+		}
+		counts, err := countOpenReviewRequests(x, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates[1:] {
+			if counts[candidate.ID] < counts[picked.ID] {
+				picked = candidate
+			}
+		}
+	}
+
+	team.LastReviewerID = picked.ID
+	if _, err := x.ID(team.ID).Cols("last_reviewer_id").Update(team); err != nil {
+		return nil, err
+	}
+	return picked, nil
+}
+
+// countOpenReviewRequests returns the number of review requests of open pull requests of each of the users
+func countOpenReviewRequests(e Engine, userIDs []int64) (map[int64]int64, error) {
+	type reviewerCount struct {
+		ReviewerID int64
+		Count      int64
+	}
+	results := make([]*reviewerCount, 0, len(userIDs))
+	if err := e.Table("review_request").
+		Join("INNER", "issue", "issue.id = review_request.issue_id").
+		Where("issue.is_closed = ?", false).
+		In("review_request.reviewer_id", userIDs).
+		GroupBy("review_request.reviewer_id").
+		Select("review_request.reviewer_id, count(*) AS count").
+		Find(&results); err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(results))
+	for _, result := range results {
+		counts[result.ReviewerID] = result.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// orgPullIssue returns the pull request 2 as if it was opened in the repository 3 of org3
+func orgPullIssue(t *testing.T) *Issue {
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	issue.RepoID = 3
+	issue.Repo = AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	return issue
+}
+
+func TestIsValidReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	poster := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	org3 := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+
+	assert.NoError(t, IsValidReviewRequest(issue, user2))
+	assert.True(t, IsErrNotValidReviewRequest(IsValidReviewRequest(issue, poster)))
+	assert.True(t, IsErrNotValidReviewRequest(IsValidReviewRequest(issue, org3)))
+
+	notPull := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.True(t, IsErrNotValidReviewRequest(IsValidReviewRequest(notPull, user2)))
+}
+
+func TestIsValidTeamReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := orgPullIssue(t)
+	assert.NoError(t, IsValidTeamReviewRequest(issue, AssertExistsAndLoadBean(t, &Team{ID: 2}).(*Team)))
+	// team of another organization
+	assert.True(t, IsErrNotValidReviewRequest(IsValidTeamReviewRequest(issue, AssertExistsAndLoadBean(t, &Team{ID: 3}).(*Team))))
+	// team without access to the repository
+	assert.True(t, IsErrNotValidReviewRequest(IsValidTeamReviewRequest(issue, AssertExistsAndLoadBean(t, &Team{ID: 7}).(*Team))))
+}
+
+func TestAddAndRemoveReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	reviewer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	comment, err := AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, comment) {
+		assert.EqualValues(t, CommentTypeReviewRequest, comment.Type)
+		assert.EqualValues(t, reviewer.ID, comment.AssigneeID)
+		assert.False(t, comment.RemovedAssignee)
+	}
+	AssertExistsAndLoadBean(t, &ReviewRequest{IssueID: issue.ID, ReviewerID: reviewer.ID, DoerID: doer.ID})
+
+	// requesting it again changes nothing
+	comment, err = AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+
+	requests, err := GetReviewRequests(issue.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.EqualValues(t, reviewer.ID, requests[0].Reviewer.ID)
+	}
+
+	comment, err = RemoveReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, comment) {
+		assert.True(t, comment.RemovedAssignee)
+	}
+	AssertNotExistsBean(t, &ReviewRequest{IssueID: issue.ID, ReviewerID: reviewer.ID})
+
+	comment, err = RemoveReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+}
+
+func TestTeamReviewRequestSatisfaction(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := orgPullIssue(t)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	team := AssertExistsAndLoadBean(t, &Team{ID: 2}).(*Team)
+
+	comment, err := AddTeamReviewRequest(issue, team, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, comment) {
+		assert.EqualValues(t, team.ID, comment.AssigneeTeamID)
+	}
+
+	// the members of the team are requested to review it
+	ids, err := getReviewRequestedUserIDs(x, issue.ID)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{2, 4}, ids)
+
+	// but not if the team picks the member to review it
+	team.ReviewAssignment = TeamReviewAssignmentRoundRobin
+	assert.NoError(t, UpdateTeam(team, false, false))
+	ids, err = getReviewRequestedUserIDs(x, issue.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	// the review of a member satisfies the request of the team
+	assert.NoError(t, removeSatisfiedReviewRequests(x, issue.ID, 4))
+	AssertNotExistsBean(t, &ReviewRequest{IssueID: issue.ID, ReviewerTeamID: team.ID})
+}
+
+func TestPickTeamReviewer(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := orgPullIssue(t)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	team := AssertExistsAndLoadBean(t, &Team{ID: 2}).(*Team)
+
+	reviewer, err := PickTeamReviewer(issue, team, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, reviewer)
+
+	team.ReviewAssignment = TeamReviewAssignmentRoundRobin
+	for _, expected := range []int64{2, 4, 2} {
+		reviewer, err = PickTeamReviewer(issue, team, doer)
+		assert.NoError(t, err)
+		if assert.NotNil(t, reviewer) {
+			assert.EqualValues(t, expected, reviewer.ID)
+		}
+	}
+	AssertExistsAndLoadBean(t, &Team{ID: team.ID, LastReviewerID: 2})
+
+	// the member with the fewest pending review requests is picked,
+	// whoever is next in turn
+	team.ReviewAssignment = TeamReviewAssignmentLoadBalance
+	_, err = AddReviewRequest(AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue), AssertExistsAndLoadBean(t, &User{ID: 4}).(*User), doer)
+	assert.NoError(t, err)
+	reviewer, err = PickTeamReviewer(issue, team, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, reviewer) {
+		assert.EqualValues(t, 2, reviewer.ID)
+	}
+
+	// the doer can't be picked
+	reviewer, err = PickTeamReviewer(issue, team, AssertExistsAndLoadBean(t, &User{ID: 2}).(*User))
+	assert.NoError(t, err)
+	if assert.NotNil(t, reviewer) {
+		assert.EqualValues(t, 4, reviewer.ID)
+	}
+}
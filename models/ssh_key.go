@@ -49,6 +49,9 @@ const (
 	KeyTypeUser = iota + 1
 	// KeyTypeDeploy specifies the deploy key
 	KeyTypeDeploy
+	// KeyTypePrincipal specifies the key a user authenticates with by an SSH certificate,
+	// its content is the principal rather than a public key
+	KeyTypePrincipal
 )
 
 // PublicKey represents a user or deploy SSH public key.
@@ -538,6 +541,7 @@ func searchPublicKeyByContentWithEngine(e Engine, content string) (*PublicKey, e
 	key := new(PublicKey)
 	has, err := e.
 		Where("content like ?", content+"%").
+		And("type != ?", KeyTypePrincipal).
 		Get(key)
 	if err != nil {
 		return nil, err
@@ -556,7 +560,7 @@ func SearchPublicKeyByContent(content string) (*PublicKey, error) {
 // SearchPublicKey returns a list of public keys matching the provided arguments.
 func SearchPublicKey(uid int64, fingerprint string) ([]*PublicKey, error) {
 	keys := make([]*PublicKey, 0, 5)
-	cond := builder.NewCond()
+	cond := builder.NewCond().And(builder.Neq{"type": KeyTypePrincipal})
 	if uid != 0 {
 		cond = cond.And(builder.Eq{"owner_id": uid})
 	}
@@ -568,7 +572,7 @@ func SearchPublicKey(uid int64, fingerprint string) ([]*PublicKey, error) {
 
 // ListPublicKeys returns a list of public keys belongs to given user.
 func ListPublicKeys(uid int64, listOptions ListOptions) ([]*PublicKey, error) {
-	sess := x.Where("owner_id = ?", uid)
+	sess := x.Where("owner_id = ? AND type != ?", uid, KeyTypePrincipal)
 	if listOptions.Page != 0 {
 		sess = listOptions.setSessionPagination(sess)
 
@@ -693,7 +697,7 @@ func rewriteAllPublicKeys(e Engine) error {
 		}
 	}
 
-	err = e.Iterate(new(PublicKey), func(idx int, bean interface{}) (err error) {
+	err = e.Where("type != ?", KeyTypePrincipal).Iterate(new(PublicKey), func(idx int, bean interface{}) (err error) {
 		_, err = t.WriteString((bean.(*PublicKey)).AuthorizedString())
 		return err
 	})
@@ -0,0 +1,277 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHCertificateAuthority represents a certificate authority trusted by administrators
+// to sign SSH user certificates.
+type SSHCertificateAuthority struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Name        string             `xorm:"UNIQUE NOT NULL"`
+	Fingerprint string             `xorm:"UNIQUE NOT NULL"`
+	Content     string             `xorm:"TEXT NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// sshCertificateSupportedCriticalOptions are the critical options we are able to enforce,
+// certificates carrying any other critical option (e.g. force-command) are rejected.
+var sshCertificateSupportedCriticalOptions = []string{"source-address"}
+
+// parseSSHCertificateAuthorityKey parses the public key of a certificate authority
+// and returns it in authorized_keys format without comment.
+func parseSSHCertificateAuthorityKey(content string) (ssh.PublicKey, string, error) {
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		return nil, "", ErrKeyUnableVerify{err.Error()}
+	}
+	if _, ok := pk.(*ssh.Certificate); ok {
+		return nil, "", ErrKeyUnableVerify{"certificates cannot be used as certificate authority"}
+	}
+	return pk, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pk))), nil
+}
+
+// AddSSHCertificateAuthority adds a new trusted certificate authority.
+func AddSSHCertificateAuthority(name, content string) (*SSHCertificateAuthority, error) {
+	pk, content, err := parseSSHCertificateAuthorityKey(content)
+	if err != nil {
+		return nil, err
+	}
+	ca := &SSHCertificateAuthority{
+		Name:        name,
+		Fingerprint: ssh.FingerprintSHA256(pk),
+		Content:     content,
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Where("name = ? OR fingerprint = ?", ca.Name, ca.Fingerprint).Get(new(SSHCertificateAuthority))
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrSSHCertificateAuthorityAlreadyExist{ca.Name, ca.Fingerprint}
+	}
+
+	if _, err = sess.Insert(ca); err != nil {
+		return nil, err
+	}
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ca, RewriteTrustedUserCAKeys()
+}
+
+// GetSSHCertificateAuthorityByID returns the certificate authority by given ID.
+func GetSSHCertificateAuthorityByID(id int64) (*SSHCertificateAuthority, error) {
+	ca := new(SSHCertificateAuthority)
+	has, err := x.ID(id).Get(ca)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSSHCertificateAuthorityNotExist{ID: id}
+	}
+	return ca, nil
+}
+
+// getSSHCertificateAuthorityByFingerprint returns the certificate authority with given fingerprint.
+func getSSHCertificateAuthorityByFingerprint(e Engine, fingerprint string) (*SSHCertificateAuthority, error) {
+	ca := new(SSHCertificateAuthority)
+	has, err := e.Where("fingerprint = ?", fingerprint).Get(ca)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrSSHCertificateAuthorityNotExist{Fingerprint: fingerprint}
+	}
+	return ca, nil
+}
+
+// ListSSHCertificateAuthorities returns all trusted certificate authorities.
+func ListSSHCertificateAuthorities() ([]*SSHCertificateAuthority, error) {
+	cas := make([]*SSHCertificateAuthority, 0, 5)
+	return cas, x.Asc("id").Find(&cas)
+}
+
+// DeleteSSHCertificateAuthority removes a trusted certificate authority, certificates
+// signed by it are no longer accepted.
+func DeleteSSHCertificateAuthority(id int64) error {
+	if _, err := GetSSHCertificateAuthorityByID(id); err != nil {
+		return err
+	}
+	if _, err := x.ID(id).Delete(new(SSHCertificateAuthority)); err != nil {
+		return err
+	}
+	return RewriteTrustedUserCAKeys()
+}
+
+// RewriteTrustedUserCAKeys writes all trusted certificate authorities to the file
+// configured to be used as TrustedUserCAKeys by OpenSSH.
+func RewriteTrustedUserCAKeys() error {
+	if setting.SSH.StartBuiltinServer || len(setting.SSH.TrustedUserCAKeysFile) == 0 {
+		return nil
+	}
+
+	cas, err := ListSSHCertificateAuthorities()
+	if err != nil {
+		return err
+	}
+
+	sshOpLocker.Lock()
+	defer sshOpLocker.Unlock()
+
+	fPath := setting.SSH.TrustedUserCAKeysFile
+	if err = os.MkdirAll(filepath.Dir(fPath), 0700); err != nil {
+		return err
+	}
+	tmpPath := fPath + ".tmp"
+	t, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		t.Close()
+		os.Remove(tmpPath)
+	}()
+
+	for _, ca := range cas {
+		if _, err = fmt.Fprintf(t, "%s %s\n", ca.Content, ca.Name); err != nil {
+			return err
+		}
+	}
+	t.Close()
+	return os.Rename(tmpPath, fPath)
+}
+
+// CheckSSHCertificate verifies a user certificate against the trusted certificate authorities,
+// its validity window and critical options, and returns the principal key of the user the
+// first acceptable principal maps to, with that principal as content. Principals map to
+// users by their username. remoteAddr may be nil when the SSH server already enforced the
+// source-address option.
+func CheckSSHCertificate(cert *ssh.Certificate, remoteAddr net.Addr) (*PublicKey, error) {
+	if cert.CertType != ssh.UserCert {
+		return nil, ErrSSHCertificateInvalid{cert.KeyId, "not a user certificate"}
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return nil, ErrSSHCertificateInvalid{cert.KeyId, "certificate has no principals"}
+	}
+
+	ca, err := getSSHCertificateAuthorityByFingerprint(x, ssh.FingerprintSHA256(cert.SignatureKey))
+	if err != nil {
+		if IsErrSSHCertificateAuthorityNotExist(err) {
+			return nil, ErrSSHCertificateInvalid{cert.KeyId, "not signed by a trusted certificate authority"}
+		}
+		return nil, err
+	}
+
+	checker := &ssh.CertChecker{
+		SupportedCriticalOptions: sshCertificateSupportedCriticalOptions,
+	}
+	// Validity, signature and critical options do not depend on the principal
+	if err = checker.CheckCert(cert.ValidPrincipals[0], cert); err != nil {
+		return nil, ErrSSHCertificateInvalid{cert.KeyId, err.Error()}
+	}
+	if remoteAddr != nil {
+		if err = checkSSHCertificateSourceAddress(cert, remoteAddr); err != nil {
+			return nil, ErrSSHCertificateInvalid{cert.KeyId, err.Error()}
+		}
+	}
+
+	for _, principal := range cert.ValidPrincipals {
+		u, err := GetUserByName(principal)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		if u.IsOrganization() || !u.IsActive || u.ProhibitLogin {
+			continue
+		}
+		log.Trace("SSH certificate %q signed by %q accepted for principal %s", cert.KeyId, ca.Name, principal)
+		key, err := getOrCreatePrincipalKey(x, u)
+		if err != nil {
+			return nil, err
+		}
+		// OpenSSH requires the principal exactly as listed in the certificate
+		key.Content = principal
+		return key, nil
+	}
+	return nil, ErrSSHCertificateInvalid{cert.KeyId, "no principal matches an active user"}
+}
+
+// checkSSHCertificateSourceAddress enforces the source-address critical option
+func checkSSHCertificateSourceAddress(cert *ssh.Certificate, remoteAddr net.Addr) error {
+	sourceAddress, ok := cert.CriticalOptions["source-address"]
+	if !ok {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr.String())
+	if err != nil {
+		host = remoteAddr.String()
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("cannot parse remote address %q", remoteAddr.String())
+	}
+
+	for _, addr := range strings.Split(sourceAddress, ",") {
+		addr = strings.TrimSpace(addr)
+		if allowed := net.ParseIP(addr); allowed != nil {
+			if allowed.Equal(ip) {
+				return nil
+			}
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return fmt.Errorf("invalid source-address %q", addr)
+		}
+		if ipNet.Contains(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("remote address %s is not allowed by source-address", ip)
+}
+
+// getOrCreatePrincipalKey returns the key users authenticated by certificate use for
+// their git commands, it is created on first use.
+func getOrCreatePrincipalKey(e Engine, u *User) (*PublicKey, error) {
+	key := new(PublicKey)
+	has, err := e.Where("owner_id = ? AND type = ?", u.ID, KeyTypePrincipal).Get(key)
+	if err != nil {
+		return nil, err
+	} else if has {
+		return key, nil
+	}
+
+	key = &PublicKey{
+		OwnerID: u.ID,
+		Name:    "SSH certificate principal",
+		Content: u.LowerName,
+		Mode:    AccessModeWrite,
+		Type:    KeyTypePrincipal,
+	}
+	if _, err = e.Insert(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSSHSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	return signer
+}
+
+func newTestSSHCertificate(t *testing.T, ca ssh.Signer, modify func(cert *ssh.Certificate)) *ssh.Certificate {
+	cert := &ssh.Certificate{
+		Key:             newTestSSHSigner(t).PublicKey(),
+		KeyId:           "test",
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"user2"},
+		ValidAfter:      uint64(time.Now().Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	if modify != nil {
+		modify(cert)
+	}
+	assert.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+func TestAddSSHCertificateAuthority(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	signer := newTestSSHSigner(t)
+	content := string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+
+	ca, err := AddSSHCertificateAuthority("test-ca", content+" comment")
+	assert.NoError(t, err)
+	assert.EqualValues(t, ssh.FingerprintSHA256(signer.PublicKey()), ca.Fingerprint)
+	AssertExistsAndLoadBean(t, &SSHCertificateAuthority{ID: ca.ID, Name: "test-ca"})
+
+	_, err = AddSSHCertificateAuthority("other-ca", content)
+	assert.True(t, IsErrSSHCertificateAuthorityAlreadyExist(err))
+	_, err = AddSSHCertificateAuthority("test-ca", string(ssh.MarshalAuthorizedKey(newTestSSHSigner(t).PublicKey())))
+	assert.True(t, IsErrSSHCertificateAuthorityAlreadyExist(err))
+
+	cert := newTestSSHCertificate(t, signer, nil)
+	_, err = AddSSHCertificateAuthority("cert", string(ssh.MarshalAuthorizedKey(cert)))
+	assert.True(t, IsErrKeyUnableVerify(err))
+
+	assert.NoError(t, DeleteSSHCertificateAuthority(ca.ID))
+	AssertNotExistsBean(t, &SSHCertificateAuthority{ID: ca.ID})
+	assert.True(t, IsErrSSHCertificateAuthorityNotExist(DeleteSSHCertificateAuthority(ca.ID)))
+}
+
+func TestCheckSSHCertificate(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	signer := newTestSSHSigner(t)
+	_, err := AddSSHCertificateAuthority("test-ca", string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+	assert.NoError(t, err)
+
+	cert := newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+		cert.ValidPrincipals = []string{"unknown", "user3", "User2"}
+	})
+	key, err := CheckSSHCertificate(cert, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, key.OwnerID)
+	assert.EqualValues(t, KeyTypePrincipal, key.Type)
+	assert.EqualValues(t, "User2", key.Content)
+
+	// The principal key is reused and not listed as public key
+	key2, err := CheckSSHCertificate(newTestSSHCertificate(t, signer, nil), nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, key.ID, key2.ID)
+	keys, err := ListPublicKeys(2, ListOptions{})
+	assert.NoError(t, err)
+	for _, k := range keys {
+		assert.NotEqual(t, key.ID, k.ID)
+	}
+
+	remoteAddr := &net.TCPAddr{IP: net.ParseIP("192.168.1.10"), Port: 22}
+	cert = newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+		cert.CriticalOptions = map[string]string{"source-address": "10.0.0.0/8,192.168.1.10"}
+	})
+	_, err = CheckSSHCertificate(cert, remoteAddr)
+	assert.NoError(t, err)
+
+	for name, cert := range map[string]*ssh.Certificate{
+		"untrusted": newTestSSHCertificate(t, newTestSSHSigner(t), nil),
+		"expired": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.ValidBefore = uint64(time.Now().Add(-time.Minute).Unix())
+		}),
+		"not yet valid": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.ValidAfter = uint64(time.Now().Add(time.Hour).Unix())
+		}),
+		"host certificate": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.CertType = ssh.HostCert
+		}),
+		"no principals": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.ValidPrincipals = nil
+		}),
+		"unknown principal": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.ValidPrincipals = []string{"unknown", "user3"}
+		}),
+		"force-command": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.CriticalOptions = map[string]string{"force-command": "/bin/sh"}
+		}),
+		"source-address": newTestSSHCertificate(t, signer, func(cert *ssh.Certificate) {
+			cert.CriticalOptions = map[string]string{"source-address": "10.0.0.0/8"}
+		}),
+	} {
+		_, err = CheckSSHCertificate(cert, remoteAddr)
+		assert.True(t, IsErrSSHCertificateInvalid(err), name)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/stale"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// StaleRule represents the settings of the stale automation of a repository,
+// the labels are separated by commas
+type StaleRule struct {
+	ID               int64  `xorm:"pk autoincr"`
+	RepoID           int64  `xorm:"UNIQUE NOT NULL"`
+	Enabled          bool   `xorm:"NOT NULL DEFAULT false"`
+	Only             string `xorm:"VARCHAR(10) NOT NULL DEFAULT ''"`
+	DaysUntilStale   int    `xorm:"NOT NULL DEFAULT 60"`
+	DaysUntilClose   int    `xorm:"NOT NULL DEFAULT 7"`
+	OnlyLabels       string `xorm:"TEXT"`
+	ExemptLabels     string `xorm:"TEXT"`
+	ExemptMilestones bool   `xorm:"NOT NULL DEFAULT false"`
+	ExemptAssignees  bool   `xorm:"NOT NULL DEFAULT false"`
+	StaleLabel       string `xorm:"NOT NULL DEFAULT 'stale'"`
+	MarkComment      string `xorm:"TEXT"`
+	CloseComment     string `xorm:"TEXT"`
+	LimitPerRun      int    `xorm:"NOT NULL DEFAULT 30"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// Config returns the configuration of the rule
+func (r *StaleRule) Config() *stale.Config {
+	return &stale.Config{
+		Only:             r.Only,
+		DaysUntilStale:   r.DaysUntilStale,
+		DaysUntilClose:   r.DaysUntilClose,
+		OnlyLabels:       splitLabelNames(r.OnlyLabels),
+		ExemptLabels:     splitLabelNames(r.ExemptLabels),
+		ExemptMilestones: r.ExemptMilestones,
+		ExemptAssignees:  r.ExemptAssignees,
+		StaleLabel:       r.StaleLabel,
+		MarkComment:      r.MarkComment,
+		CloseComment:     r.CloseComment,
+		LimitPerRun:      r.LimitPerRun,
+	}
+}
+
+func splitLabelNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// GetStaleRule returns the stale rule of the repository, a disabled rule with the
+// default configuration if it has none
+func GetStaleRule(repoID int64) (*StaleRule, error) {
+	rule := new(StaleRule)
+	has, err := x.Where("repo_id = ?", repoID).Get(rule)
+	if err != nil {
+		return nil, err
+	} else if has {
+		return rule, nil
+	}
+
+	c := stale.DefaultConfig()
+	return &StaleRule{
+		RepoID:         repoID,
+		DaysUntilStale: c.DaysUntilStale,
+		DaysUntilClose: c.DaysUntilClose,
+		StaleLabel:     c.StaleLabel,
+		MarkComment:    c.MarkComment,
+		LimitPerRun:    c.LimitPerRun,
+	}, nil
+}
+
+// GetEnabledStaleRules returns the enabled stale rules
+func GetEnabledStaleRules() ([]*StaleRule, error) {
+	rules := make([]*StaleRule, 0, 10)
+	return rules, x.Where("enabled = ?", true).Asc("repo_id").Find(&rules)
+}
+
+// SetStaleRule creates or updates the stale rule of the repository
+func SetStaleRule(rule *StaleRule) error {
+	existing := new(StaleRule)
+	has, err := x.Where("repo_id = ?", rule.RepoID).Get(existing)
+	if err != nil {
+		return err
+	} else if !has {
+		_, err = x.Insert(rule)
+		return err
+	}
+	rule.ID = existing.ID
+	_, err = x.ID(rule.ID).AllCols().Omit("created_unix").Update(rule)
+	return err
+}
+
+// StaleIssue records an issue or a pull request marked as stale. Its activity
+// is the update of the issue after the time it was marked.
+type StaleIssue struct {
+	ID          int64              `xorm:"pk autoincr"`
+	RepoID      int64              `xorm:"INDEX NOT NULL"`
+	IssueID     int64              `xorm:"UNIQUE NOT NULL"`
+	MarkedUnix  timeutil.TimeStamp `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// GetStaleIssues returns the issues and pull requests of the repository marked as stale
+func GetStaleIssues(repoID int64) ([]*StaleIssue, error) {
+	issues := make([]*StaleIssue, 0, 10)
+	return issues, x.Where("repo_id = ?", repoID).Asc("id").Find(&issues)
+}
+
+// IsIssueStale returns whether the issue is marked as stale
+func IsIssueStale(issueID int64) (bool, error) {
+	return x.Where("issue_id = ?", issueID).Exist(new(StaleIssue))
+}
+
+// MarkIssueStale records the issue as marked as stale at its update time
+func MarkIssueStale(issue *Issue) error {
+	_, err := x.Insert(&StaleIssue{RepoID: issue.RepoID, IssueID: issue.ID, MarkedUnix: issue.UpdatedUnix})
+	return err
+}
+
+// UnmarkIssueStale removes the stale mark of the issue
+func UnmarkIssueStale(issueID int64) error {
+	_, err := x.Where("issue_id = ?", issueID).Delete(new(StaleIssue))
+	return err
+}
+
+// FindStaleCandidatesOptions represents the options to find the issues or
+// pull requests to mark as stale
+type FindStaleCandidatesOptions struct {
+	RepoID           int64
+	IsPull           bool
+	UpdatedBefore    timeutil.TimeStamp
+	OnlyLabelIDs     []int64
+	ExemptLabelIDs   []int64
+	ExemptMilestones bool
+	ExemptAssignees  bool
+	Limit            int
+}
+
+// FindStaleCandidates returns the open issues or pull requests without activity which
+// are not marked as stale yet, the least recently updated first
+func FindStaleCandidates(opts *FindStaleCandidatesOptions) ([]*Issue, error) {
+	cond := builder.NewCond().
+		And(builder.Eq{"issue.repo_id": opts.RepoID, "issue.is_pull": opts.IsPull, "issue.is_closed": false}).
+		And(builder.Lt{"issue.updated_unix": opts.UpdatedBefore}).
+		And(builder.NotIn("issue.id", builder.Select("issue_id").From("stale_issue").Where(builder.Eq{"repo_id": opts.RepoID})))
+	if len(opts.OnlyLabelIDs) > 0 {
+		cond = cond.And(builder.In("issue.id", builder.Select("issue_id").From("issue_label").Where(builder.In("label_id", opts.OnlyLabelIDs))))
+	}
+	if len(opts.ExemptLabelIDs) > 0 {
+		cond = cond.And(builder.NotIn("issue.id", builder.Select("issue_id").From("issue_label").Where(builder.In("label_id", opts.ExemptLabelIDs))))
+	}
+	if opts.ExemptMilestones {
+		cond = cond.And(builder.Eq{"issue.milestone_id": 0}.Or(builder.IsNull{"issue.milestone_id"}))
+	}
+	if opts.ExemptAssignees {
+		cond = cond.And(builder.NotIn("issue.id", builder.Select("issue_id").From("issue_assignees")))
+	}
+
+	sess := x.Where(cond).Asc("issue.updated_unix", "issue.id")
+	if opts.Limit > 0 {
+		sess.Limit(opts.Limit)
+	}
+	issues := make([]*Issue, 0, 10)
+	return issues, sess.Find(&issues)
+}
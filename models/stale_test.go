@@ -0,0 +1,94 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/stale"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAndSetStaleRule(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	rule, err := GetStaleRule(1)
+	assert.NoError(t, err)
+	assert.False(t, rule.Enabled)
+	assert.EqualValues(t, 0, rule.ID)
+	assert.Equal(t, stale.DefaultConfig(), rule.Config())
+
+	rule.Enabled = true
+	rule.Only = stale.OnlyIssues
+	rule.ExemptLabels = "label1, ,label2"
+	assert.NoError(t, SetStaleRule(rule))
+	AssertExistsAndLoadBean(t, &StaleRule{RepoID: 1, Enabled: true})
+
+	rule, err = GetStaleRule(1)
+	assert.NoError(t, err)
+	assert.NotZero(t, rule.ID)
+	assert.Equal(t, []string{"label1", "label2"}, rule.Config().ExemptLabels)
+
+	assert.NoError(t, SetStaleRule(&StaleRule{RepoID: 1, DaysUntilStale: 10, StaleLabel: "inactive", LimitPerRun: 5}))
+	AssertCount(t, &StaleRule{RepoID: 1}, 1)
+	rules, err := GetEnabledStaleRules()
+	assert.NoError(t, err)
+	assert.Len(t, rules, 0)
+	rule, err = GetStaleRule(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "inactive", rule.StaleLabel)
+	assert.Empty(t, rule.ExemptLabels)
+}
+
+func TestMarkIssueStale(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	assert.NoError(t, MarkIssueStale(issue))
+	stale, err := IsIssueStale(1)
+	assert.NoError(t, err)
+	assert.True(t, stale)
+
+	issues, err := GetStaleIssues(1)
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 1) {
+		assert.EqualValues(t, 1, issues[0].IssueID)
+		assert.Equal(t, issue.UpdatedUnix, issues[0].MarkedUnix)
+	}
+
+	assert.NoError(t, UnmarkIssueStale(1))
+	stale, err = IsIssueStale(1)
+	assert.NoError(t, err)
+	assert.False(t, stale)
+}
+
+func TestFindStaleCandidates(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	test := func(expected []int64, opts FindStaleCandidatesOptions) {
+		opts.RepoID = 1
+		opts.UpdatedBefore = timeutil.TimeStamp(1500000000)
+		issues, err := FindStaleCandidates(&opts)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(issues))
+		for _, issue := range issues {
+			ids = append(ids, issue.ID)
+		}
+		assert.Equal(t, expected, ids)
+	}
+
+	test([]int64{1}, FindStaleCandidatesOptions{})
+	test([]int64{}, FindStaleCandidatesOptions{ExemptAssignees: true})
+	test([]int64{3, 2}, FindStaleCandidatesOptions{IsPull: true})
+	test([]int64{3}, FindStaleCandidatesOptions{IsPull: true, Limit: 1})
+	test([]int64{3}, FindStaleCandidatesOptions{IsPull: true, ExemptMilestones: true})
+	test([]int64{2}, FindStaleCandidatesOptions{IsPull: true, OnlyLabelIDs: []int64{1}})
+	test([]int64{3}, FindStaleCandidatesOptions{IsPull: true, ExemptLabelIDs: []int64{1}})
+
+	assert.NoError(t, MarkIssueStale(AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)))
+	test([]int64{2}, FindStaleCandidatesOptions{IsPull: true})
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/pquerna/otp/totp"
 	"golang.org/x/crypto/pbkdf2"
+	"xorm.io/builder"
 )
 
 // TwoFactor represents a two-factor authentication token.
@@ -152,6 +153,87 @@ func GetTwoFactorByUID(uid int64) (*TwoFactor, error) {
 	return twofa, nil
 }
 
+// HasTwoFactorByUID returns true if the user has enrolled two-factor authentication
+func HasTwoFactorByUID(uid int64) (bool, error) {
+	return hasTwoFactorByUID(x, uid)
+}
+
+func hasTwoFactorByUID(e Engine, uid int64) (bool, error) {
+	return e.Where("uid = ?", uid).Exist(new(TwoFactor))
+}
+
+// IsTwoFactorRequired returns true if the instance requires the user to enroll two-factor authentication
+func (u *User) IsTwoFactorRequired() bool {
+	switch setting.Service.RequireTwoFactor {
+	case setting.TwoFactorRequiredAll:
+		return !u.IsOrganization()
+	case setting.TwoFactorRequiredAdmins:
+		return u.IsAdmin
+	}
+	return false
+}
+
+// MustEnrollTwoFactor returns true if the instance requires the user to enroll
+// two-factor authentication and the user has not done so yet
+func (u *User) MustEnrollTwoFactor() (bool, error) {
+	if !u.IsTwoFactorRequired() {
+		return false, nil
+	}
+	has, err := HasTwoFactorByUID(u.ID)
+	return !has, err
+}
+
+// GetOrgsRequiringTwoFactorByUserID returns the organizations of the user which
+// require two-factor authentication
+func GetOrgsRequiringTwoFactorByUserID(uid int64) ([]*User, error) {
+	orgs := make([]*User, 0, 2)
+	return orgs, x.
+		Join("INNER", "org_user", "`org_user`.org_id = `user`.id").
+		Where("`org_user`.uid = ? AND `user`.require_two_factor = ?", uid, true).
+		Asc("`user`.name").
+		Find(&orgs)
+}
+
+// TwoFactorReportType selects the users listed by FindUsersWithoutTwoFactor
+type TwoFactorReportType int
+
+const (
+	// TwoFactorReportAll lists all users
+	TwoFactorReportAll TwoFactorReportType = iota
+	// TwoFactorReportAdmins lists site administrators
+	TwoFactorReportAdmins
+	// TwoFactorReportOrgMembers lists members of organizations requiring two-factor authentication
+	TwoFactorReportOrgMembers
+)
+
+// FindUsersWithoutTwoFactor returns the users who have not enrolled two-factor authentication
+func FindUsersWithoutTwoFactor(tp TwoFactorReportType, listOptions ListOptions) (UserList, int64, error) {
+	cond := builder.NewCond().And(
+		builder.Eq{"type": UserTypeIndividual},
+		builder.NotIn("id", builder.Select("uid").From("two_factor")),
+	)
+	switch tp {
+	case TwoFactorReportAdmins:
+		cond = cond.And(builder.Eq{"is_admin": true})
+	case TwoFactorReportOrgMembers:
+		cond = cond.And(builder.In("id", builder.Select("uid").From("org_user").Where(
+			builder.In("org_id", builder.Select("id").From("`user`").Where(builder.Eq{"require_two_factor": true})),
+		)))
+	}
+
+	count, err := x.Where(cond).Count(new(User))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).OrderBy("lower_name")
+	if listOptions.Page != 0 {
+		sess = listOptions.setSessionPagination(sess)
+	}
+	users := make(UserList, 0, listOptions.PageSize)
+	return users, count, sess.Find(&users)
+}
+
 // DeleteTwoFactorByID deletes two-factor authentication token by given ID.
 func DeleteTwoFactorByID(id, userID int64) error {
 	cnt, err := x.ID(id).Delete(&TwoFactor{
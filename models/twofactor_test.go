@@ -0,0 +1,73 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMustEnrollTwoFactor(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer func(old string) { setting.Service.RequireTwoFactor = old }(setting.Service.RequireTwoFactor)
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	enrolled := AssertExistsAndLoadBean(t, &User{ID: 24}).(*User)
+
+	test := func(requirement string, adminMustEnroll, userMustEnroll bool) {
+		setting.Service.RequireTwoFactor = requirement
+		for u, expected := range map[*User]bool{admin: adminMustEnroll, user: userMustEnroll, enrolled: false} {
+			mustEnroll, err := u.MustEnrollTwoFactor()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, mustEnroll, "%s: %s", requirement, u.Name)
+		}
+	}
+	test(setting.TwoFactorRequiredNone, false, false)
+	test(setting.TwoFactorRequiredAdmins, true, false)
+	test(setting.TwoFactorRequiredAll, true, true)
+}
+
+func TestFindUsersWithoutTwoFactor(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	users, count, err := FindUsersWithoutTwoFactor(TwoFactorReportAll, ListOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(users), count)
+	for _, u := range users {
+		assert.False(t, u.IsOrganization())
+		assert.NotEqual(t, int64(24), u.ID)
+	}
+
+	users, _, err = FindUsersWithoutTwoFactor(TwoFactorReportAdmins, ListOptions{})
+	assert.NoError(t, err)
+	if assert.Len(t, users, 1) {
+		assert.EqualValues(t, 1, users[0].ID)
+	}
+
+	users, _, err = FindUsersWithoutTwoFactor(TwoFactorReportOrgMembers, ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, users, 0)
+
+	org := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	org.RequireTwoFactor = true
+	assert.NoError(t, UpdateUserCols(org, "require_two_factor"))
+	users, count, err = FindUsersWithoutTwoFactor(TwoFactorReportOrgMembers, ListOptions{Page: 1, PageSize: 2})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+	if assert.Len(t, users, 2) {
+		assert.Equal(t, "user2", users[0].Name)
+		assert.Equal(t, "user28", users[1].Name)
+	}
+
+	orgs, err := GetOrgsRequiringTwoFactorByUserID(2)
+	assert.NoError(t, err)
+	if assert.Len(t, orgs, 1) {
+		assert.EqualValues(t, 3, orgs[0].ID)
+	}
+}
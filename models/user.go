@@ -67,6 +67,13 @@ const (
 	EmailNotificationsOnMention = "onmention"
 	// EmailNotificationsDisabled indicates that the user would not like to be notified via email.
 	EmailNotificationsDisabled = "disabled"
+
+	// EmailDigestNone indicates that the user would like to receive email notifications as they happen
+	EmailDigestNone = "none"
+	// EmailDigestHourly indicates that the user would like to receive email notifications in an hourly digest
+	EmailDigestHourly = "hourly"
+	// EmailDigestDaily indicates that the user would like to receive email notifications in a daily digest
+	EmailDigestDaily = "daily"
 )
 
 var (
@@ -99,6 +106,7 @@ type User struct {
 	Email                        string `xorm:"NOT NULL"`
 	KeepEmailPrivate             bool
 	EmailNotificationsPreference string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'enabled'"`
+	EmailNotificationsDigest     string `xorm:"VARCHAR(20) NOT NULL DEFAULT 'none'"`
 	Passwd                       string `xorm:"NOT NULL"`
 	PasswdHashAlgo               string `xorm:"NOT NULL DEFAULT 'pbkdf2'"`
 
@@ -157,10 +165,12 @@ type User struct {
 	MembersIsPublic           map[int64]bool      `xorm:"-"`
 	Visibility                structs.VisibleType `xorm:"NOT NULL DEFAULT 0"`
 	RepoAdminChangeTeamAccess bool                `xorm:"NOT NULL DEFAULT false"`
+	RequireTwoFactor          bool                `xorm:"NOT NULL DEFAULT false"`
 
 	// Preferences
-	DiffViewStyle string `xorm:"NOT NULL DEFAULT ''"`
-	Theme         string `xorm:"NOT NULL DEFAULT ''"`
+	DiffViewStyle    string `xorm:"NOT NULL DEFAULT ''"`
+	Theme            string `xorm:"NOT NULL DEFAULT ''"`
+	DisableReminders bool   `xorm:"NOT NULL DEFAULT false"`
 }
 
 // SearchOrganizationsOptions options to filter organizations
@@ -788,6 +798,35 @@ func (u *User) SetEmailNotifications(set string) error {
 	return nil
 }
 
+// EmailDigest returns the frequency of the email notification digests of the user,
+// EmailDigestNone if the notifications are sent as they happen
+func (u *User) EmailDigest() string {
+	if u.EmailNotificationsDigest == EmailDigestHourly || u.EmailNotificationsDigest == EmailDigestDaily {
+		return u.EmailNotificationsDigest
+	}
+	return EmailDigestNone
+}
+
+// SetEmailDigest sets the frequency of the email notification digests of the user
+func (u *User) SetEmailDigest(frequency string) error {
+	u.EmailNotificationsDigest = frequency
+	if err := UpdateUserCols(u, "email_notifications_digest"); err != nil {
+		log.Error("SetEmailDigest: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetReminders sets whether the user gets the scheduled reminders of the repositories
+func (u *User) SetReminders(enabled bool) error {
+	u.DisableReminders = !enabled
+	if err := UpdateUserCols(u, "disable_reminders"); err != nil {
+		log.Error("SetReminders: %v", err)
+		return err
+	}
+	return nil
+}
+
 func isUserExist(e Engine, uid int64, name string) (bool, error) {
 	if len(name) == 0 {
 		return false, nil
@@ -864,6 +903,7 @@ var (
 		"raw",
 		"repo",
 		"stars",
+		"scim",
 		"template",
 		"user",
 		"vendor",
@@ -873,6 +913,7 @@ var (
 		"..",
 		".well-known",
 		"search",
+		"v2",
 	}
 	reservedUserPatterns = []string{"*.keys", "*.gpg"}
 )
@@ -957,6 +998,7 @@ func CreateUser(u *User) (err error) {
 	u.HashPassword(u.Passwd)
 	u.AllowCreateOrganization = setting.Service.DefaultAllowCreateOrganization && !setting.Admin.DisableRegularOrgCreation
 	u.EmailNotificationsPreference = setting.Admin.DefaultEmailNotification
+	u.EmailNotificationsDigest = EmailDigestNone
 	u.MaxRepoCreation = -1
 	u.Theme = setting.UI.DefaultTheme
 
@@ -1132,6 +1174,14 @@ func deleteUser(e *xorm.Session, u *User) error {
 		return ErrUserOwnRepos{UID: u.ID}
 	}
 
+	// Check ownership of packages.
+	count, err = countPackages(e, u.ID)
+	if err != nil {
+		return fmt.Errorf("countPackages: %v", err)
+	} else if count > 0 {
+		return ErrUserOwnPackages{UID: u.ID}
+	}
+
 	// Check membership of organization.
 	count, err = u.getOrganizationCount(e)
 	if err != nil {
@@ -1195,10 +1245,20 @@ func deleteUser(e *xorm.Session, u *User) error {
 		&TeamUser{UID: u.ID},
 		&Collaboration{UserID: u.ID},
 		&Stopwatch{UserID: u.ID},
+		&SavedIssueQuery{UserID: u.ID},
+		&RepoNotificationPreference{UserID: u.ID},
+		&MailDigestItem{UserID: u.ID},
+		&WebPushSubscription{UserID: u.ID},
+		&ReviewRequest{ReviewerID: u.ID},
+		&SentReminder{UserID: u.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteContainerImagesByOwner(e, u.ID); err != nil {
+		return err
+	}
+
 	// ***** START: PublicKey *****
 	if _, err = e.Delete(&PublicKey{OwnerID: u.ID}); err != nil {
 		return fmt.Errorf("deletePublicKeys: %v", err)
@@ -1284,7 +1344,7 @@ func DeleteInactivateUsers() (err error) {
 	for _, u := range users {
 		if err = DeleteUser(u); err != nil {
 			// Ignore users that were set inactive by admin.
-			if IsErrUserOwnRepos(err) || IsErrUserHasOrgs(err) {
+			if IsErrUserOwnRepos(err) || IsErrUserOwnPackages(err) || IsErrUserHasOrgs(err) {
 				continue
 			}
 			return err
@@ -1372,17 +1432,22 @@ func getUserEmailsByNames(e Engine, names []string) []string {
 	return mails
 }
 
-// GetMaileableUsersByIDs gets users from ids, but only if they can receive mails
-func GetMaileableUsersByIDs(ids []int64) ([]*User, error) {
+// GetMaileableUsersByIDs gets users from ids, but only if they can receive mails,
+// which users only notified on mention can only if the mails are for a mention
+func GetMaileableUsersByIDs(ids []int64, isMention bool) ([]*User, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
+	preferences := []string{EmailNotificationsEnabled}
+	if isMention {
+		preferences = append(preferences, EmailNotificationsOnMention)
+	}
 	ous := make([]*User, 0, len(ids))
 	return ous, x.In("id", ids).
 		Where("`type` = ?", UserTypeIndividual).
 		And("`prohibit_login` = ?", false).
 		And("`is_active` = ?", true).
-		And("`email_notifications_preference` = ?", EmailNotificationsEnabled).
+		In("`email_notifications_preference`", preferences).
 		Find(&ous)
 }
 
@@ -1539,6 +1604,7 @@ type SearchUserOptions struct {
 	Actor         *User // The user doing the search
 	IsActive      util.OptionalBool
 	SearchByEmail bool // Search by email as well as username/full name
+	ExtraCond     builder.Cond
 }
 
 func (opts *SearchUserOptions) toConds() builder.Cond {
@@ -1592,6 +1658,10 @@ func (opts *SearchUserOptions) toConds() builder.Cond {
 		cond = cond.And(builder.Eq{"is_active": opts.IsActive.IsTrue()})
 	}
 
+	if opts.ExtraCond != nil {
+		cond = cond.And(opts.ExtraCond)
+	}
+
 	return cond
 }
 
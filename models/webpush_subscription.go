@@ -0,0 +1,70 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// WebPushSubscription is the push subscription of a browser of a user
+type WebPushSubscription struct {
+	ID       int64  `xorm:"pk autoincr"`
+	UserID   int64  `xorm:"INDEX NOT NULL"`
+	Endpoint string `xorm:"TEXT NOT NULL"`
+	// P256DH is the public key of the browser, to encrypt the messages
+	P256DH string `xorm:"'p256dh' VARCHAR(100) NOT NULL"`
+	// Auth is the authentication secret of the browser
+	Auth        string             `xorm:"VARCHAR(50) NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+// CreateWebPushSubscription creates the push subscription, replacing the one
+// with the same endpoint, which may belong to the previous user of the browser.
+// The oldest subscriptions of the user are deleted beyond the maximum number.
+func CreateWebPushSubscription(sub *WebPushSubscription) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("endpoint = ?", sub.Endpoint).Delete(new(WebPushSubscription)); err != nil {
+		return err
+	}
+	if max := setting.WebPush.MaxSubscriptions; max > 0 {
+		subs := make([]*WebPushSubscription, 0, max)
+		if err := sess.Where("user_id = ?", sub.UserID).Desc("id").Find(&subs); err != nil {
+			return err
+		}
+		for i := max - 1; i < len(subs); i++ {
+			if _, err := sess.ID(subs[i].ID).Delete(new(WebPushSubscription)); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := sess.Insert(sub); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetWebPushSubscriptions returns the push subscriptions of the user
+func GetWebPushSubscriptions(userID int64) ([]*WebPushSubscription, error) {
+	subs := make([]*WebPushSubscription, 0, 2)
+	return subs, x.Where("user_id = ?", userID).Find(&subs)
+}
+
+// DeleteWebPushSubscription deletes the push subscription of the user with the endpoint
+func DeleteWebPushSubscription(userID int64, endpoint string) error {
+	_, err := x.Where("user_id = ? AND endpoint = ?", userID, endpoint).Delete(new(WebPushSubscription))
+	return err
+}
+
+// DeleteWebPushSubscriptionByID deletes the push subscription, e.g. once it expired
+func DeleteWebPushSubscriptionByID(id int64) error {
+	_, err := x.ID(id).Delete(new(WebPushSubscription))
+	return err
+}
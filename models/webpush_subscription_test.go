@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebPushSubscriptions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, CreateWebPushSubscription(&WebPushSubscription{UserID: 1, Endpoint: "https://push.example.com/a", P256DH: "key", Auth: "auth"}))
+	assert.NoError(t, CreateWebPushSubscription(&WebPushSubscription{UserID: 1, Endpoint: "https://push.example.com/b", P256DH: "key", Auth: "auth"}))
+	// the browser is now used by another user
+	assert.NoError(t, CreateWebPushSubscription(&WebPushSubscription{UserID: 2, Endpoint: "https://push.example.com/b", P256DH: "key", Auth: "auth"}))
+
+	subs, err := GetWebPushSubscriptions(1)
+	assert.NoError(t, err)
+	if assert.Len(t, subs, 1) {
+		assert.Equal(t, "https://push.example.com/a", subs[0].Endpoint)
+	}
+
+	// a user can't unsubscribe the browser of another user
+	assert.NoError(t, DeleteWebPushSubscription(1, "https://push.example.com/b"))
+	subs, err = GetWebPushSubscriptions(2)
+	assert.NoError(t, err)
+	if assert.Len(t, subs, 1) {
+		assert.NoError(t, DeleteWebPushSubscriptionByID(subs[0].ID))
+	}
+	AssertNotExistsBean(t, &WebPushSubscription{UserID: 2})
+
+	assert.NoError(t, DeleteWebPushSubscription(1, "https://push.example.com/a"))
+	AssertNotExistsBean(t, &WebPushSubscription{UserID: 1})
+}
+
+func TestWebPushSubscriptionsLimit(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer func(max int) {
+		setting.WebPush.MaxSubscriptions = max
+	}(setting.WebPush.MaxSubscriptions)
+	setting.WebPush.MaxSubscriptions = 2
+
+	// the oldest subscriptions make room for the new ones
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, CreateWebPushSubscription(&WebPushSubscription{UserID: 1, Endpoint: fmt.Sprintf("https://push.example.com/%d", i), P256DH: "key", Auth: "auth"}))
+	}
+	subs, err := GetWebPushSubscriptions(1)
+	assert.NoError(t, err)
+	if assert.Len(t, subs, 2) {
+		assert.Equal(t, "https://push.example.com/1", subs[0].Endpoint)
+		assert.Equal(t, "https://push.example.com/2", subs[1].Endpoint)
+	}
+}
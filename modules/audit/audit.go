@@ -0,0 +1,254 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package audit records security-relevant events. Events are stored in the database and,
+// if enabled, written as JSON lines to the "audit" logger.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Target describes the object an event applies to
+type Target struct {
+	Type string
+	ID   int64
+	Name string
+}
+
+// Diff describes what an event changed, changed fields hold a models.AuditChange
+type Diff map[string]interface{}
+
+// Changed adds the old and new value of field to the diff if they differ
+func (d Diff) Changed(field string, oldValue, newValue interface{}) Diff {
+	if !reflect.DeepEqual(oldValue, newValue) && !(isEmptySlice(oldValue) && isEmptySlice(newValue)) {
+		d[field] = models.AuditChange{Old: oldValue, New: newValue}
+	}
+	return d
+}
+
+func isEmptySlice(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Slice && rv.Len() == 0
+}
+
+// ProtectedBranchDiff returns the changed protection settings of a branch, before is nil
+// if the branch was not protected yet
+func ProtectedBranchDiff(before, after *models.ProtectedBranch) Diff {
+	if before == nil {
+		before = &models.ProtectedBranch{}
+	}
+	return Diff{"branch": after.BranchName}.
+		Changed("can_push", before.CanPush, after.CanPush).
+		Changed("enable_whitelist", before.EnableWhitelist, after.EnableWhitelist).
+		Changed("whitelist_user_ids", before.WhitelistUserIDs, after.WhitelistUserIDs).
+		Changed("whitelist_team_ids", before.WhitelistTeamIDs, after.WhitelistTeamIDs).
+		Changed("whitelist_deploy_keys", before.WhitelistDeployKeys, after.WhitelistDeployKeys).
+		Changed("enable_merge_whitelist", before.EnableMergeWhitelist, after.EnableMergeWhitelist).
+		Changed("merge_whitelist_user_ids", before.MergeWhitelistUserIDs, after.MergeWhitelistUserIDs).
+		Changed("merge_whitelist_team_ids", before.MergeWhitelistTeamIDs, after.MergeWhitelistTeamIDs).
+		Changed("enable_status_check", before.EnableStatusCheck, after.EnableStatusCheck).
+		Changed("status_check_contexts", before.StatusCheckContexts, after.StatusCheckContexts).
+		Changed("required_approvals", before.RequiredApprovals, after.RequiredApprovals).
+		Changed("enable_approvals_whitelist", before.EnableApprovalsWhitelist, after.EnableApprovalsWhitelist).
+		Changed("approvals_whitelist_user_ids", before.ApprovalsWhitelistUserIDs, after.ApprovalsWhitelistUserIDs).
+		Changed("approvals_whitelist_team_ids", before.ApprovalsWhitelistTeamIDs, after.ApprovalsWhitelistTeamIDs).
+		Changed("block_on_rejected_reviews", before.BlockOnRejectedReviews, after.BlockOnRejectedReviews).
+		Changed("dismiss_stale_approvals", before.DismissStaleApprovals, after.DismissStaleApprovals).
+		Changed("require_signed_commits", before.RequireSignedCommits, after.RequireSignedCommits)
+}
+
+// webhookTargetName keeps the scheme and host of a webhook URL, credentials are often
+// embedded in the user info, the query string or the path (e.g. Slack and Discord)
+func webhookTargetName(hookURL string) string {
+	u, err := url.Parse(hookURL)
+	if err != nil || len(u.Host) == 0 {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func toTarget(target interface{}) Target {
+	switch t := target.(type) {
+	case Target:
+		return t
+	case *models.User:
+		if t.IsOrganization() {
+			return Target{"org", t.ID, t.Name}
+		}
+		return Target{"user", t.ID, t.Name}
+	case *models.Repository:
+		return Target{"repo", t.ID, t.FullName()}
+	case *models.Team:
+		return Target{"team", t.ID, t.Name}
+	case *models.PublicKey:
+		return Target{"public_key", t.ID, fmt.Sprintf("%s (%s)", t.Name, t.Fingerprint)}
+	case *models.GPGKey:
+		return Target{"gpg_key", t.ID, t.KeyID}
+	case *models.AccessToken:
+		return Target{"access_token", t.ID, t.Name}
+	case *models.DeployKey:
+		return Target{"deploy_key", t.ID, fmt.Sprintf("%s (%s)", t.Name, t.Fingerprint)}
+	case *models.Webhook:
+		return Target{"webhook", t.ID, webhookTargetName(t.URL)}
+	case *models.ProtectedBranch:
+		return Target{"protected_branch", t.ID, t.BranchName}
+	case *models.LoginSource:
+		return Target{"auth_source", t.ID, t.Name}
+	case *models.SSHCertificateAuthority:
+		return Target{"ssh_ca", t.ID, t.Name}
+	case *models.LoginLockout:
+		return Target{"login_lockout", t.ID, t.Target}
+	case *models.U2FRegistration:
+		return Target{"u2f", t.ID, t.Name}
+	case *models.CIRunner:
+		return Target{"ci_runner", t.ID, t.Name}
+	case nil:
+		return Target{}
+	default:
+		return Target{Type: fmt.Sprintf("%T", target)}
+	}
+}
+
+// UserDiff returns the changed security-relevant settings of a user account, before is nil
+// for a new account. Passwords are never recorded, only whether they changed.
+func UserDiff(before, after *models.User) Diff {
+	if before == nil {
+		before = &models.User{}
+	}
+	d := Diff{}.
+		Changed("login_source", before.LoginSource, after.LoginSource).
+		Changed("login_name", before.LoginName, after.LoginName).
+		Changed("email", before.Email, after.Email).
+		Changed("active", before.IsActive, after.IsActive).
+		Changed("admin", before.IsAdmin, after.IsAdmin).
+		Changed("restricted", before.IsRestricted, after.IsRestricted).
+		Changed("prohibit_login", before.ProhibitLogin, after.ProhibitLogin).
+		Changed("allow_git_hook", before.AllowGitHook, after.AllowGitHook).
+		Changed("allow_import_local", before.AllowImportLocal, after.AllowImportLocal).
+		Changed("allow_create_organization", before.AllowCreateOrganization, after.AllowCreateOrganization).
+		Changed("max_repo_creation", before.MaxRepoCreation, after.MaxRepoCreation)
+	if before.Passwd != after.Passwd {
+		d["password_changed"] = true
+	}
+	return d
+}
+
+// OrgSettingsDiff returns the changed security-relevant settings of an organization
+func OrgSettingsDiff(before, after *models.User) Diff {
+	return Diff{}.
+		Changed("name", before.Name, after.Name).
+		Changed("visibility", before.Visibility.String(), after.Visibility.String()).
+		Changed("max_repo_creation", before.MaxRepoCreation, after.MaxRepoCreation).
+		Changed("repo_admin_change_team_access", before.RepoAdminChangeTeamAccess, after.RepoAdminChangeTeamAccess).
+		Changed("require_two_factor", before.RequireTwoFactor, after.RequireTwoFactor)
+}
+
+// TeamDiff returns the changed settings of a team, before is nil for a new team
+func TeamDiff(before, after *models.Team) Diff {
+	if before == nil {
+		before = &models.Team{}
+	}
+	return Diff{}.
+		Changed("name", before.Name, after.Name).
+		Changed("permission", before.Authorize.String(), after.Authorize.String()).
+		Changed("includes_all_repositories", before.IncludesAllRepositories, after.IncludesAllRepositories).
+		Changed("can_create_org_repo", before.CanCreateOrgRepo, after.CanCreateOrgRepo).
+		Changed("review_assignment", before.ReviewAssignment.Name(), after.ReviewAssignment.Name())
+}
+
+// WebhookDiff describes the settings of a webhook
+func WebhookDiff(w *models.Webhook) Diff {
+	d := Diff{
+		"type":   w.HookTaskType.Name(),
+		"active": w.IsActive,
+	}
+	if w.RepoID > 0 {
+		d["repo_id"] = w.RepoID
+	}
+	if w.OrgID > 0 {
+		d["org_id"] = w.OrgID
+	}
+	return d
+}
+
+type logLine struct {
+	Time       time.Time       `json:"time"`
+	Action     string          `json:"action"`
+	ActorID    int64           `json:"actor_id"`
+	Actor      string          `json:"actor"`
+	IPAddress  string          `json:"ip"`
+	TargetType string          `json:"target_type"`
+	TargetID   int64           `json:"target_id"`
+	Target     string          `json:"target"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+}
+
+func writeLog(e *models.AuditEvent) {
+	line := logLine{
+		Time:       e.CreatedUnix.AsTime().UTC(),
+		Action:     string(e.Action),
+		ActorID:    e.ActorID,
+		Actor:      e.ActorName,
+		IPAddress:  e.IPAddress,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Target:     e.TargetName,
+	}
+	if len(e.Diff) > 0 {
+		line.Diff = json.RawMessage(e.Diff)
+	}
+	bs, err := json.Marshal(line)
+	if err != nil {
+		log.Error("Unable to encode audit event %d: %v", e.ID, err)
+		return
+	}
+	if err = log.GetLogger("audit").SendLog(log.INFO, "", "", 0, string(bs), ""); err != nil {
+		log.Error("Unable to write audit event %d: %v", e.ID, err)
+	}
+}
+
+// Record appends an event done by doer from remoteAddr to the audit log. The target is
+// either a Target or a models object, doer is nil if the actor is not known.
+func Record(action models.AuditAction, doer *models.User, remoteAddr string, target interface{}, diff Diff) {
+	event := &models.AuditEvent{
+		Action:    action,
+		IPAddress: remoteAddr,
+	}
+	if doer != nil {
+		event.ActorID = doer.ID
+		event.ActorName = doer.Name
+	}
+	t := toTarget(target)
+	event.TargetType, event.TargetID, event.TargetName = t.Type, t.ID, t.Name
+
+	if len(diff) > 0 {
+		bs, err := json.Marshal(diff)
+		if err != nil {
+			log.Error("Unable to encode diff of audit event %s: %v", action, err)
+		} else {
+			event.Diff = string(bs)
+		}
+	}
+
+	if err := models.CreateAuditEvent(event); err != nil {
+		log.Error("Unable to record audit event %s by %s on %s %s: %v", action, event.ActorName, t.Type, t.Name, err)
+	}
+	if setting.EnableAuditLog {
+		writeLog(event)
+	}
+}
+
+// RecordLoginFailed appends a failed sign-in attempt for loginName from remoteAddr to the audit log
+func RecordLoginFailed(loginName, remoteAddr, reason string) {
+	Record(models.AuditUserLoginFailed, nil, remoteAddr, Target{Type: "user", Name: loginName}, Diff{"reason": reason})
+}
@@ -48,3 +48,14 @@ type AdminEditUserForm struct {
 func (f *AdminEditUserForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+// AdminAddSSHCertificateAuthorityForm form for admin to add a trusted SSH certificate authority
+type AdminAddSSHCertificateAuthorityForm struct {
+	Name    string `binding:"Required;MaxSize(255)"`
+	Content string `binding:"Required"`
+}
+
+// Validate validates form fields
+func (f *AdminAddSSHCertificateAuthorityForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
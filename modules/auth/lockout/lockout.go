@@ -0,0 +1,235 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package lockout protects password sign-ins against brute-force attacks. Failed attempts are
+// counted per account and per IP address in the cache; further attempts for an account are
+// delayed progressively and accounts or IP addresses with too many failures are locked for a while.
+package lockout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/mailer"
+)
+
+// ErrLocked represents a "Locked" kind of error, sign-ins are refused until the given time.
+type ErrLocked struct {
+	Until time.Time
+}
+
+// IsErrLocked checks if an error is a ErrLocked.
+func IsErrLocked(err error) bool {
+	_, ok := err.(ErrLocked)
+	return ok
+}
+
+func (err ErrLocked) Error() string {
+	return fmt.Sprintf("too many failed sign-in attempts, try again after %s", err.Until.Format(time.RFC3339))
+}
+
+// RetryAfter returns the number of seconds to wait before the next attempt
+func (err ErrLocked) RetryAfter() int64 {
+	seconds := int64(time.Until(err.Until).Seconds()) + 1
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+type target struct {
+	Type models.LoginLockoutType
+	Name string
+	// User is the account signed in to, nil for IP addresses and unknown accounts
+	User *models.User
+}
+
+// accountTarget resolves the login name, a user name or an email address, to the account
+// so that the attempts are counted once per account whatever name is used to sign in.
+func accountTarget(loginName string) (target, error) {
+	loginName = strings.ToLower(strings.TrimSpace(loginName))
+	var u *models.User
+	var err error
+	if strings.Contains(loginName, "@") {
+		u, err = models.GetUserByEmail(loginName)
+	} else {
+		u, err = models.GetUserByName(loginName)
+	}
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return target{models.LoginLockoutAccount, loginName, nil}, nil
+		}
+		return target{}, err
+	}
+	return target{models.LoginLockoutAccount, u.LowerName, u}, nil
+}
+
+func targets(loginName, remoteAddr string) ([]target, error) {
+	ts := make([]target, 0, 2)
+	if len(strings.TrimSpace(loginName)) > 0 {
+		t, err := accountTarget(loginName)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	if len(remoteAddr) > 0 {
+		ts = append(ts, target{Type: models.LoginLockoutIP, Name: remoteAddr})
+	}
+	return ts, nil
+}
+
+// cacheName identifies the target in the cache, accounts by ID so renames do not reset them
+func (t target) cacheName() string {
+	if t.User != nil {
+		return fmt.Sprintf("uid:%d", t.User.ID)
+	}
+	return t.Name
+}
+
+func failuresKey(t target) string {
+	return fmt.Sprintf("login_lockout:failures:%d:%s", t.Type, t.cacheName())
+}
+
+func delayKey(t target) string {
+	return "login_lockout:delay:" + t.cacheName()
+}
+
+// Check returns an ErrLocked error if password sign-ins for the account or from the IP
+// address are currently refused.
+func Check(loginName, remoteAddr string) error {
+	if !setting.LoginLockout.Enabled {
+		return nil
+	}
+
+	ts, err := targets(loginName, remoteAddr)
+	if err != nil {
+		return err
+	}
+	for _, t := range ts {
+		if t.Type == models.LoginLockoutAccount {
+			if until, has := cache.GetInt64Value(delayKey(t)); has && until > time.Now().Unix() {
+				return ErrLocked{Until: time.Unix(until, 0)}
+			}
+		}
+
+		lockout, err := models.GetActiveLoginLockout(t.Type, t.Name)
+		if err != nil {
+			return err
+		} else if lockout != nil {
+			return ErrLocked{Until: lockout.LockedUntil.AsTime()}
+		}
+	}
+	return nil
+}
+
+// Fail records a failed password sign-in attempt
+func Fail(loginName, remoteAddr string) {
+	if !setting.LoginLockout.Enabled {
+		return
+	}
+
+	ts, err := targets(loginName, remoteAddr)
+	if err != nil {
+		log.Error("Unable to resolve the account of %s: %v", loginName, err)
+		return
+	}
+	window := int64(setting.LoginLockout.FailureWindow.Seconds())
+	for _, t := range ts {
+		failures, err := cache.Incr(failuresKey(t), window)
+		if err != nil {
+			log.Error("Unable to count failed sign-in attempt for %s: %v", t.Name, err)
+			continue
+		}
+
+		max := setting.LoginLockout.MaxAccountAttempts
+		if t.Type == models.LoginLockoutIP {
+			max = setting.LoginLockout.MaxIPAttempts
+		}
+		if max > 0 && failures >= int64(max) {
+			lock(t, remoteAddr, failures)
+		} else if t.Type == models.LoginLockoutAccount {
+			delay(t, failures)
+		}
+	}
+}
+
+// delay makes the account wait before the next attempt, the delay doubles with every failure
+func delay(t target, failures int64) {
+	after := int64(setting.LoginLockout.DelayAfterAttempts)
+	if setting.LoginLockout.Delay <= 0 || failures < after {
+		return
+	}
+
+	d := setting.LoginLockout.Delay
+	for i := after; i < failures && d < setting.LoginLockout.MaxDelay; i++ {
+		d *= 2
+	}
+	if setting.LoginLockout.MaxDelay > 0 && d > setting.LoginLockout.MaxDelay {
+		d = setting.LoginLockout.MaxDelay
+	}
+
+	until := time.Now().Add(d)
+	if err := cache.PutInt64(delayKey(t), until.Unix(), int64(d.Seconds())+1); err != nil {
+		log.Error("Unable to delay sign-ins for %s: %v", t.Name, err)
+	}
+}
+
+func lock(t target, remoteAddr string, failures int64) {
+	until := time.Now().Add(setting.LoginLockout.LockoutDuration)
+	if _, err := models.LockLogin(t.Type, t.Name, failures, timeutil.TimeStamp(until.Unix())); err != nil {
+		log.Error("LockLogin: %v", err)
+		return
+	}
+	cache.Remove(failuresKey(t))
+
+	if t.Type == models.LoginLockoutIP {
+		log.Warn("Sign-ins from %s are locked until %v after %d failed attempts", t.Name, until, failures)
+		return
+	}
+	log.Warn("Sign-ins to account %s are locked until %v after %d failed attempts, the last from %s", t.Name, until, failures, remoteAddr)
+
+	if setting.LoginLockout.NotifyUser && t.User != nil {
+		mailer.SendLoginLockoutMail(t.User, remoteAddr, failures, until)
+	}
+}
+
+// Succeed resets the failed attempts of an account after a successful sign-in
+func Succeed(loginName string) {
+	if !setting.LoginLockout.Enabled {
+		return
+	}
+
+	t, err := accountTarget(loginName)
+	if err != nil {
+		log.Error("Unable to resolve the account of %s: %v", loginName, err)
+		return
+	}
+	cache.Remove(failuresKey(t))
+	cache.Remove(delayKey(t))
+}
+
+// Clear lifts a lockout and resets the failed attempts counted for it
+func Clear(lockout *models.LoginLockout) error {
+	if err := models.DeleteLoginLockout(lockout.ID); err != nil {
+		return err
+	}
+
+	t := target{Type: lockout.Type, Name: lockout.Target}
+	if lockout.IsAccount() {
+		var err error
+		if t, err = accountTarget(lockout.Target); err != nil {
+			return err
+		}
+	}
+	cache.Remove(failuresKey(t))
+	cache.Remove(delayKey(t))
+	return nil
+}
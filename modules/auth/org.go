@@ -42,6 +42,7 @@ type UpdateOrgSettingForm struct {
 	Visibility                structs.VisibleType
 	MaxRepoCreation           int
 	RepoAdminChangeTeamAccess bool
+	RequireTwoFactor          bool
 }
 
 // Validate validates the fields
@@ -64,6 +65,7 @@ type CreateTeamForm struct {
 	Units            []models.UnitType
 	RepoAccess       string
 	CanCreateOrgRepo bool
+	ReviewAssignment string `binding:"In(,none,round_robin,load_balance)"`
 }
 
 // Validate validates the fields
@@ -6,6 +6,7 @@
 package auth
 
 import (
+	"mime/multipart"
 	"net/url"
 	"strings"
 
@@ -148,6 +149,42 @@ func (f *RepoSettingForm) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// RepoRemindersForm form for changing the reminder rules of a repository
+type RepoRemindersForm struct {
+	EnableDueDate       bool
+	DueDateDays         int `binding:"Range(0,30)"`
+	EnableReviewRequest bool
+	ReviewRequestDays   int `binding:"Range(1,30)"`
+	EnableInactivePull  bool
+	InactivePullDays    int `binding:"Range(1,365)"`
+}
+
+// Validate validates the fields
+func (f *RepoRemindersForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// RepoStaleForm form for changing the stale rule of a repository
+type RepoStaleForm struct {
+	Enabled          bool
+	Only             string `binding:"In(,issues,pulls)"`
+	DaysUntilStale   int    `binding:"Range(1,3650)"`
+	DaysUntilClose   int    `binding:"Range(0,3650)"`
+	OnlyLabels       string
+	ExemptLabels     string
+	ExemptMilestones bool
+	ExemptAssignees  bool
+	StaleLabel       string `binding:"Required;MaxSize(50)"`
+	MarkComment      string
+	CloseComment     string
+	LimitPerRun      int `binding:"Range(1,1000)"`
+}
+
+// Validate validates the fields
+func (f *RepoStaleForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // __________                             .__
 // \______   \____________    ____   ____ |  |__
 //  |    |  _/\_  __ \__  \  /    \_/ ___\|  |  \
@@ -396,6 +433,53 @@ func (i IssueLockForm) HasValidReason() bool {
 	return false
 }
 
+// TransferIssueForm form for transferring an issue to another repository
+type TransferIssueForm struct {
+	NewRepo string `binding:"Required"`
+}
+
+// Validate validates the fields
+func (f *TransferIssueForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// BulkEditIssuesForm form for editing many issues at once, the empty fields are left unchanged
+type BulkEditIssuesForm struct {
+	IssueIDs          string  `form:"issue_ids" binding:"Required"`
+	AddLabelIDs       []int64 `form:"add_label_ids"`
+	RemoveLabelIDs    []int64 `form:"remove_label_ids"`
+	AddAssigneeIDs    []int64 `form:"add_assignee_ids"`
+	RemoveAssigneeIDs []int64 `form:"remove_assignee_ids"`
+	// Milestone is the ID of the new milestone, 0 to remove it
+	Milestone      string
+	State          string `binding:"In(,open,closed)"`
+	Lock           string `binding:"In(,lock,unlock)"`
+	Deadline       string
+	RemoveDeadline bool
+	RedirectTo     string
+}
+
+// Validate validates the fields
+func (f *BulkEditIssuesForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// ImportIssuesForm form for importing issues, the file is uploaded first
+// and its content is then sent back along with the mapping of its columns
+type ImportIssuesForm struct {
+	File    *multipart.FileHeader
+	Format  string `binding:"In(,csv,json)"`
+	Content string
+	// Fields are the issue fields of the columns
+	Fields []string
+	Action string `binding:"In(,preview,import)"`
+}
+
+// Validate validates the fields
+func (f *ImportIssuesForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
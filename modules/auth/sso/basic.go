@@ -9,6 +9,8 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/auth/lockout"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -110,13 +112,25 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 	}
 
 	if u == nil {
+		if err = lockout.Check(uname, ctx.RemoteAddr()); err != nil {
+			if lockout.IsErrLocked(err) {
+				log.Info("Refused authentication attempt for %s from %s: %v", uname, ctx.RemoteAddr(), err)
+			} else {
+				log.Error("Check: %v", err)
+			}
+			return nil
+		}
 		u, err = models.UserSignIn(uname, passwd)
 		if err != nil {
-			if !models.IsErrUserNotExist(err) {
+			if models.IsErrUserNotExist(err) {
+				lockout.Fail(uname, ctx.RemoteAddr())
+				audit.RecordLoginFailed(uname, ctx.RemoteAddr(), "invalid_credentials")
+			} else {
 				log.Error("UserSignIn: %v", err)
 			}
 			return nil
 		}
+		lockout.Succeed(uname)
 	} else {
 		ctx.Data["IsApiToken"] = true
 	}
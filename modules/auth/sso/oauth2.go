@@ -121,7 +121,7 @@ func (o *OAuth2) VerifyAuthData(ctx *macaron.Context, sess session.Store) *model
 		return nil
 	}
 
-	if !isAPIPath(ctx) && !isAttachmentDownload(ctx) {
+	if !isAPIPath(ctx) && !isAttachmentDownload(ctx) && !isContainerRegistryPath(ctx) {
 		return nil
 	}
 
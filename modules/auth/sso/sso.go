@@ -100,6 +100,11 @@ func isAPIPath(ctx *macaron.Context) bool {
 	return strings.HasPrefix(ctx.Req.URL.Path, "/api/")
 }
 
+// isContainerRegistryPath returns true if the specified URL is a path of the container registry
+func isContainerRegistryPath(ctx *macaron.Context) bool {
+	return ctx.Req.URL.Path == "/v2" || strings.HasPrefix(ctx.Req.URL.Path, "/v2/")
+}
+
 // isAttachmentDownload check if request is a file download (GET) with URL to an attachment
 func isAttachmentDownload(ctx *macaron.Context) bool {
 	return strings.HasPrefix(ctx.Req.URL.Path, "/attachments/") && ctx.Req.Method == "GET"
@@ -359,3 +359,40 @@ type U2FDeleteForm struct {
 func (f *U2FDeleteForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+// SavedIssueQueryForm for saving an issue search query on the dashboard
+type SavedIssueQueryForm struct {
+	Name   string `binding:"Required;MaxSize(255)"`
+	Query  string `binding:"Required"`
+	OrgID  int64
+	IsPull bool
+}
+
+// Validate validates the fields
+func (f *SavedIssueQueryForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// NotificationBulkForm for changing several notifications at once
+type NotificationBulkForm struct {
+	Action     string  `binding:"Required;In(read,unread,pin,unpin,unsubscribe)"`
+	IDs        []int64 `form:"ids"`
+	RedirectTo string
+}
+
+// Validate validates the fields
+func (f *NotificationBulkForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// WebPushSubscriptionForm for subscribing a browser to the push notifications
+type WebPushSubscriptionForm struct {
+	Endpoint string `binding:"Required;ValidUrl"`
+	P256DH   string `form:"p256dh" binding:"MaxSize(100)"`
+	Auth     string `binding:"MaxSize(50)"`
+}
+
+// Validate validates the fields
+func (f *WebPushSubscriptionForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
@@ -7,6 +7,7 @@ package cache
 import (
 	"fmt"
 	"strconv"
+	"sync"
 
 	"code.gitea.io/gitea/modules/setting"
 
@@ -17,7 +18,8 @@ import (
 )
 
 var (
-	conn mc.Cache
+	conn     mc.Cache
+	incrLock sync.Mutex
 )
 
 func newCache(cacheConfig setting.Cache) (mc.Cache, error) {
@@ -105,6 +107,65 @@ func GetInt64(key string, getFunc func() (int64, error)) (int64, error) {
 	}
 }
 
+// toInt64 converts a cached value to int64, adapters storing values as strings are supported
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	default:
+		return 0, fmt.Errorf("Unsupported cached value type: %v", value)
+	}
+}
+
+// Incr increases the counter stored at key by one and returns its new value. A counter that does
+// not exist yet starts at one and expires after timeout seconds, later increments keep that expiry.
+func Incr(key string, timeout int64) (int64, error) {
+	if conn == nil {
+		return 0, fmt.Errorf("cache is not enabled")
+	}
+
+	// Existing counters are incremented by the adapter, atomically on the redis and memcache
+	// servers. The lock serializes the creation of the counters and the increments of the
+	// memory adapter, which are not atomic on their own.
+	incrLock.Lock()
+	defer incrLock.Unlock()
+	if err := conn.Incr(key); err == nil {
+		// An expired counter of the memory adapter may be incremented before it is collected
+		if value := conn.Get(key); value != nil {
+			return toInt64(value)
+		}
+	}
+	if err := conn.Put(key, int64(1), timeout); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
+
+// PutInt64 stores value at key for timeout seconds
+func PutInt64(key string, value int64, timeout int64) error {
+	if conn == nil {
+		return fmt.Errorf("cache is not enabled")
+	}
+	return conn.Put(key, value, timeout)
+}
+
+// GetInt64Value returns the value stored at key, has is false if there is none
+func GetInt64Value(key string) (value int64, has bool) {
+	if conn == nil {
+		return 0, false
+	}
+	v := conn.Get(key)
+	if v == nil {
+		return 0, false
+	}
+	value, err := toInt64(v)
+	return value, err == nil
+}
+
 // Remove key from cache
 func Remove(key string) {
 	if conn == nil {
@@ -0,0 +1,372 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package ci runs the workflows of repositories defined in .gitea/workflows. Events
+// create runs whose jobs are picked up by registered runners, the runners report the
+// progress and the logs of the steps back.
+package ci
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// WorkflowsDir is the directory of a repository holding the workflow files
+const WorkflowsDir = ".gitea/workflows"
+
+// maxWorkflowSize is the maximum size of a workflow file
+const maxWorkflowSize = 1024 * 1024
+
+// WorkflowFile represents a workflow of a commit, the ID is the file name
+type WorkflowFile struct {
+	ID       string
+	Workflow *Workflow
+}
+
+// DetectWorkflows returns the valid workflows of the commit, invalid workflows are
+// logged and ignored
+func DetectWorkflows(commit *git.Commit) ([]*WorkflowFile, error) {
+	tree, err := commit.SubTree(WorkflowsDir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	var workflows []*WorkflowFile
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if !entry.IsRegular() || (ext != ".yml" && ext != ".yaml") || entry.Size() > maxWorkflowSize {
+			continue
+		}
+		reader, err := entry.Blob().DataAsync()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		w, err := ParseWorkflow(data)
+		if err != nil {
+			log.Warn("Invalid workflow %s of commit %s: %v", entry.Name(), commit.ID, err)
+			continue
+		}
+		if len(w.Name) == 0 {
+			w.Name = strings.TrimSuffix(entry.Name(), ext)
+		}
+		workflows = append(workflows, &WorkflowFile{ID: entry.Name(), Workflow: w})
+	}
+	return workflows, nil
+}
+
+// TriggerPush runs the workflows of the pushed commit triggered by the push of the branch
+// or the tag
+func TriggerPush(doer *models.User, repo *models.Repository, ref, commitID string) error {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(commitID)
+	if err != nil {
+		return err
+	}
+	return trigger(doer, repo, commit, EventPush, ref, ref, commit.Summary(), false)
+}
+
+// TriggerPullRequest runs the workflows of the head commit of the pull request, the base
+// branch decides which workflows are triggered. Runs of pull requests from forks wait
+// for the approval of a writer of the repository unless the doer is one.
+func TriggerPullRequest(doer *models.User, pr *models.PullRequest) error {
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	} else if err = pr.LoadBaseRepo(); err != nil {
+		return err
+	} else if err = pr.LoadHeadRepo(); err != nil {
+		return err
+	} else if pr.HeadRepo == nil {
+		return nil
+	}
+
+	gitRepo, err := git.OpenRepository(pr.HeadRepo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.HeadBranch)
+	if err != nil {
+		return err
+	}
+	return trigger(doer, pr.BaseRepo, commit, EventPullRequest, git.BranchPrefix+pr.BaseBranch, pr.GetGitRefName(), pr.Issue.Title, pr.HeadRepoID != pr.BaseRepoID)
+}
+
+// trigger creates a run for every workflow of the commit triggered by the event, the
+// workflows are matched against matchRef and run for ref. fork tells if the commit comes
+// from a fork of the repository.
+func trigger(doer *models.User, repo *models.Repository, commit *git.Commit, event, matchRef, ref, title string, fork bool) error {
+	workflows, err := DetectWorkflows(commit)
+	if err != nil {
+		return fmt.Errorf("DetectWorkflows: %v", err)
+	}
+
+	needApproval := false
+	if fork {
+		perm, err := models.GetUserRepoPermission(repo, doer)
+		if err != nil {
+			return fmt.Errorf("GetUserRepoPermission: %v", err)
+		}
+		needApproval = !perm.CanWrite(models.UnitTypeCode)
+	}
+
+	numJobs := 0
+	for _, wf := range workflows {
+		if !wf.Workflow.Matches(event, matchRef) {
+			continue
+		}
+		if numJobs += len(wf.Workflow.Jobs); numJobs > setting.CI.MaxJobsPerEvent {
+			log.Warn("Too many CI jobs for %s of %s, skipping workflow %s", ref, repo.FullName(), wf.ID)
+			continue
+		}
+
+		run := &models.CIRun{
+			RepoID:        repo.ID,
+			Repo:          repo,
+			WorkflowID:    wf.ID,
+			WorkflowName:  wf.Workflow.Name,
+			Title:         title,
+			TriggerUserID: doer.ID,
+			TriggerUser:   doer,
+			Event:         event,
+			Ref:           ref,
+			CommitSHA:     commit.ID.String(),
+			IsForkPull:    fork,
+			NeedApproval:  needApproval,
+		}
+		jobs := make([]*models.CIJob, 0, len(wf.Workflow.Jobs))
+		for _, j := range wf.Workflow.Jobs {
+			job := &models.CIJob{
+				JobID:  j.ID,
+				Name:   j.Name,
+				Needs:  j.Needs,
+				Labels: j.RunsOn,
+				Env:    mergeEnv(wf.Workflow.Env, j.Env),
+			}
+			for _, s := range j.Steps {
+				job.Steps = append(job.Steps, &models.CIStep{Name: s.Name, Command: s.Run, Env: s.Env})
+			}
+			jobs = append(jobs, job)
+		}
+		if err = models.CreateCIRun(run, jobs); err != nil {
+			return fmt.Errorf("CreateCIRun: %v", err)
+		}
+		publishJobStatuses(jobs...)
+	}
+	if numJobs > 0 && !needApproval {
+		notifyJobsQueued()
+	}
+	return nil
+}
+
+func mergeEnv(envs ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, env := range envs {
+		for k, v := range env {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// JobEnv returns the environment of the job including the variables describing the run
+func JobEnv(job *models.CIJob) map[string]string {
+	run := job.Run
+	return mergeEnv(job.Env, map[string]string{
+		"CI":               "true",
+		"GITEA_CI":         "true",
+		"GITEA_SERVER_URL": strings.TrimSuffix(setting.AppURL, "/"),
+		"GITEA_REPOSITORY": run.Repo.FullName(),
+		"GITEA_WORKFLOW":   run.WorkflowName,
+		"GITEA_RUN_NUMBER": strconv.FormatInt(run.Index, 10),
+		"GITEA_JOB":        job.JobID,
+		"GITEA_EVENT":      run.Event,
+		"GITEA_REF":        run.Ref,
+		"GITEA_SHA":        run.CommitSHA,
+		"GITEA_ACTOR":      run.TriggerUser.Name,
+	})
+}
+
+// publishJobStatuses publishes the status of the jobs as commit statuses of the commit
+// of their run
+func publishJobStatuses(jobs ...*models.CIJob) {
+	for _, job := range jobs {
+		if err := job.LoadRun(); err != nil {
+			log.Error("LoadRun: %v", err)
+			continue
+		}
+		run := job.Run
+
+		status := &models.CommitStatus{
+			TargetURL: run.HTMLURL(),
+			Context:   fmt.Sprintf("%s / %s (%s)", run.WorkflowName, job.Name, run.Event),
+		}
+		switch job.Status {
+		case models.CIStatusWaiting, models.CIStatusBlocked:
+			status.State, status.Description = api.CommitStatusPending, "Waiting to run"
+			if run.NeedApproval {
+				status.Description = "Waiting for approval"
+			}
+		case models.CIStatusRunning:
+			status.State, status.Description = api.CommitStatusPending, "Running"
+		case models.CIStatusSuccess:
+			status.State, status.Description = api.CommitStatusSuccess, "Successful"
+		case models.CIStatusFailure:
+			status.State, status.Description = api.CommitStatusFailure, "Failing"
+		case models.CIStatusCancelled:
+			status.State, status.Description = api.CommitStatusError, "Cancelled"
+		case models.CIStatusSkipped:
+			status.State, status.Description = api.CommitStatusWarning, "Skipped"
+		}
+
+		if err := models.NewCommitStatus(models.NewCommitStatusOptions{
+			Repo:         run.Repo,
+			Creator:      run.TriggerUser,
+			SHA:          run.CommitSHA,
+			CommitStatus: status,
+		}); err != nil {
+			log.Error("NewCommitStatus: %v", err)
+		}
+	}
+}
+
+var (
+	queuedLock sync.Mutex
+	queued     = make(chan struct{})
+)
+
+// notifyJobsQueued wakes the runners waiting for jobs
+func notifyJobsQueued() {
+	queuedLock.Lock()
+	close(queued)
+	queued = make(chan struct{})
+	queuedLock.Unlock()
+}
+
+func jobsQueued() <-chan struct{} {
+	queuedLock.Lock()
+	defer queuedLock.Unlock()
+	return queued
+}
+
+// FetchJob assigns a job to the runner, it waits for a job until the poll timeout
+// expires or the context is done and returns nil if there was none
+func FetchJob(ctx context.Context, runner *models.CIRunner) (*models.CIJob, error) {
+	if err := models.UpdateCIRunnerLastOnline(runner); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(setting.CI.PollTimeout)
+	defer timeout.Stop()
+	for {
+		wakeup := jobsQueued()
+		job, err := models.AssignCIJob(runner)
+		if err != nil {
+			return nil, err
+		} else if job != nil {
+			if err = job.LoadRun(); err != nil {
+				return nil, err
+			} else if err = job.LoadSteps(); err != nil {
+				return nil, err
+			}
+			publishJobStatuses(job)
+			return job, nil
+		}
+
+		select {
+		case <-wakeup:
+		case <-timeout.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+// FinishJob stops the job with the final status and queues the jobs depending on it
+func FinishJob(job *models.CIJob, status models.CIStatus) error {
+	changed, err := models.FinishCIJob(job, status)
+	if err != nil {
+		return err
+	}
+	publishJobStatuses(changed...)
+	for _, j := range changed {
+		if j.Status == models.CIStatusWaiting {
+			notifyJobsQueued()
+			break
+		}
+	}
+	return nil
+}
+
+// ApproveRun lets the jobs of the run of a pull request from a fork run
+func ApproveRun(run *models.CIRun, doer *models.User) error {
+	changed, err := models.ApproveCIRun(run, doer)
+	if err != nil {
+		return err
+	}
+	publishJobStatuses(changed...)
+	if len(changed) > 0 {
+		notifyJobsQueued()
+	}
+	return nil
+}
+
+// CancelRun cancels the jobs of the run which are not done yet, runners stop running
+// them on their next report
+func CancelRun(run *models.CIRun) error {
+	cancelled, err := models.CancelCIRun(run)
+	if err != nil {
+		return err
+	}
+	publishJobStatuses(cancelled...)
+	return nil
+}
+
+// DeleteRunner deletes the runner, the jobs it is running fail
+func DeleteRunner(runner *models.CIRunner) error {
+	jobs, err := models.GetRunningCIJobs(runner.ID)
+	if err != nil {
+		return err
+	}
+	if err = models.DeleteCIRunner(runner); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err = FinishJob(job, models.CIStatusFailure); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+var (
+	// ErrStepNotExist is returned for an unknown step index
+	ErrStepNotExist = errors.New("step does not exist")
+	// ErrLogOffset is returned if lines are appended behind the end of the log
+	ErrLogOffset = errors.New("log offset is behind the end of the log")
+	// ErrLogTooLarge is returned if the log would exceed the maximum size
+	ErrLogTooLarge = errors.New("log is too large")
+)
+
+var logLock sync.Mutex
+
+// getStep returns the step of the job with the index
+func getStep(job *models.CIJob, index int) (*models.CIStep, error) {
+	if err := job.LoadSteps(); err != nil {
+		return nil, err
+	}
+	for _, step := range job.Steps {
+		if step.Index == index {
+			return step, nil
+		}
+	}
+	return nil, ErrStepNotExist
+}
+
+// AppendLog appends the lines to the log of the step. Offset is the number of lines the
+// runner already sent, lines sent again are ignored. It returns the new number of lines.
+func AppendLog(job *models.CIJob, index int, offset int64, lines []string) (int64, error) {
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	step, err := getStep(job, index)
+	if err != nil {
+		return 0, err
+	}
+	if offset > step.LogLength {
+		return step.LogLength, ErrLogOffset
+	}
+	if skip := step.LogLength - offset; skip >= int64(len(lines)) {
+		return step.LogLength, nil
+	} else if skip > 0 {
+		lines = lines[skip:]
+	}
+
+	var size int64
+	for _, line := range lines {
+		size += int64(len(line)) + 1
+	}
+	if step.LogSize+size > setting.CI.MaxLogSize {
+		return step.LogLength, ErrLogTooLarge
+	}
+
+	p := job.LogPath(index)
+	if err = os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err = f.WriteString(strings.TrimRight(line, "\r\n") + "\n"); err != nil {
+			return 0, err
+		}
+	}
+
+	step.LogLength += int64(len(lines))
+	step.LogSize += size
+	if step.Status == models.CIStatusWaiting {
+		step.Status = models.CIStatusRunning
+		step.StartedUnix = timeutil.TimeStampNow()
+	}
+	return step.LogLength, models.UpdateCIStep(step)
+}
+
+// UpdateStep sets the status of the step, steps are started by setting them running and
+// stopped by setting a final status
+func UpdateStep(job *models.CIJob, index int, status models.CIStatus) error {
+	logLock.Lock()
+	defer logLock.Unlock()
+
+	step, err := getStep(job, index)
+	if err != nil {
+		return err
+	}
+	if step.Status == status {
+		return nil
+	}
+	step.Status = status
+	if step.StartedUnix == 0 {
+		step.StartedUnix = timeutil.TimeStampNow()
+	}
+	if status.IsDone() {
+		step.StoppedUnix = timeutil.TimeStampNow()
+	}
+	return models.UpdateCIStep(step)
+}
+
+// ReadLog returns the lines of the log of the step
+func ReadLog(job *models.CIJob, index int) ([]string, error) {
+	f, err := os.Open(job.LogPath(index))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), int(setting.CI.MaxLogSize))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import "code.gitea.io/gitea/models"
+
+// RunnerTokenHeader is the header runners authenticate with
+const RunnerTokenHeader = "X-Gitea-Runner-Token"
+
+// RegisterRunnerOptions are the options to register a runner with a registration token
+type RegisterRunnerOptions struct {
+	Token  string   `json:"token" binding:"Required"`
+	Name   string   `json:"name" binding:"Required;MaxSize(255)"`
+	Labels []string `json:"labels"`
+}
+
+// RegisteredRunner is the answer to the registration of a runner, the token
+// authenticates the following requests of the runner
+type RegisteredRunner struct {
+	ID    int64  `json:"id"`
+	UUID  string `json:"uuid"`
+	Token string `json:"token"`
+}
+
+// Task is a job assigned to a runner, the clone token is the password of HTTP basic
+// authentication to clone the repository while the job runs
+type Task struct {
+	JobID      int64             `json:"job_id"`
+	RunID      int64             `json:"run_id"`
+	RunNumber  int64             `json:"run_number"`
+	Repository string            `json:"repository"`
+	CloneURL   string            `json:"clone_url"`
+	CloneToken string            `json:"clone_token"`
+	Workflow   string            `json:"workflow"`
+	Job        string            `json:"job"`
+	Event      string            `json:"event"`
+	Ref        string            `json:"ref"`
+	CommitSHA  string            `json:"sha"`
+	Env        map[string]string `json:"env"`
+	Steps      []*TaskStep       `json:"steps"`
+}
+
+// TaskStep is a step of a task, steps run in the order of their index
+type TaskStep struct {
+	Index int               `json:"index"`
+	Name  string            `json:"name"`
+	Run   string            `json:"run"`
+	Env   map[string]string `json:"env"`
+}
+
+// AppendLogOptions are the options to append lines to the log of a step, offset is the
+// number of lines already sent
+type AppendLogOptions struct {
+	Step   int      `json:"step"`
+	Offset int64    `json:"offset"`
+	Lines  []string `json:"lines"`
+}
+
+// AppendLogResult is the answer to appending lines, length is the number of lines of
+// the log stored by the server
+type AppendLogResult struct {
+	Length int64 `json:"length"`
+}
+
+// UpdateStatusOptions are the options to report the status of a step or a job
+type UpdateStatusOptions struct {
+	Status string `json:"status" binding:"Required"`
+}
+
+// ToTask returns the task of a job just assigned to a runner, the run and the steps of the
+// job must be loaded
+func ToTask(job *models.CIJob) *Task {
+	run := job.Run
+	task := &Task{
+		JobID:      job.ID,
+		RunID:      run.ID,
+		RunNumber:  run.Index,
+		Repository: run.Repo.FullName(),
+		CloneURL:   run.Repo.CloneLink().HTTPS,
+		CloneToken: job.Token,
+		Workflow:   run.WorkflowName,
+		Job:        job.Name,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		CommitSHA:  run.CommitSHA,
+		Env:        JobEnv(job),
+		Steps:      make([]*TaskStep, 0, len(job.Steps)),
+	}
+	for _, step := range job.Steps {
+		task.Steps = append(task.Steps, &TaskStep{
+			Index: step.Index,
+			Name:  step.Name,
+			Run:   step.Command,
+			Env:   step.Env,
+		})
+	}
+	return task
+}
@@ -0,0 +1,278 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// Events triggering workflows
+const (
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+)
+
+var jobIDPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Workflow represents a workflow defined in .gitea/workflows, its jobs are in the order
+// of the file
+type Workflow struct {
+	Name string
+	On   map[string]*EventFilter
+	Env  map[string]string
+	Jobs []*Job
+}
+
+// EventFilter restricts the refs an event triggers a workflow for, an empty filter
+// matches all branches. Tags are only matched by push events with tag patterns or
+// without any pattern.
+type EventFilter struct {
+	Branches       []string `yaml:"branches"`
+	BranchesIgnore []string `yaml:"branches-ignore"`
+	Tags           []string `yaml:"tags"`
+	TagsIgnore     []string `yaml:"tags-ignore"`
+}
+
+// Job represents a job of a workflow
+type Job struct {
+	ID     string
+	Name   string            `yaml:"name"`
+	RunsOn stringList        `yaml:"runs-on"`
+	Needs  stringList        `yaml:"needs"`
+	Env    map[string]string `yaml:"env"`
+	Steps  []*Step           `yaml:"steps"`
+}
+
+// Step represents a shell command of a job
+type Step struct {
+	Name string            `yaml:"name"`
+	Run  string            `yaml:"run"`
+	Env  map[string]string `yaml:"env"`
+}
+
+// stringList accepts a single string or a list of strings
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = []string{s}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// ParseWorkflow parses and validates a workflow file
+func ParseWorkflow(data []byte) (*Workflow, error) {
+	var raw struct {
+		Name string            `yaml:"name"`
+		On   interface{}       `yaml:"on"`
+		Env  map[string]string `yaml:"env"`
+		Jobs yaml.MapSlice     `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{Name: raw.Name, Env: raw.Env}
+	on, err := parseEvents(raw.On)
+	if err != nil {
+		return nil, err
+	}
+	w.On = on
+
+	if len(raw.Jobs) == 0 {
+		return nil, fmt.Errorf("workflow has no jobs")
+	}
+	ids := make(map[string]bool, len(raw.Jobs))
+	for _, item := range raw.Jobs {
+		id, _ := item.Key.(string)
+		if !jobIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("invalid job id %q", id)
+		}
+		job, err := parseJob(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %v", id, err)
+		}
+		job.ID = id
+		if len(job.Name) == 0 {
+			job.Name = id
+		}
+		ids[id] = true
+		w.Jobs = append(w.Jobs, job)
+	}
+	for _, job := range w.Jobs {
+		for _, need := range job.Needs {
+			if !ids[need] {
+				return nil, fmt.Errorf("job %s needs unknown job %s", job.ID, need)
+			}
+		}
+	}
+	if err = checkCycles(w.Jobs); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func parseJob(value interface{}) (*Job, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	job := new(Job)
+	if err = yaml.UnmarshalStrict(data, job); err != nil {
+		return nil, err
+	}
+	if len(job.Steps) == 0 {
+		return nil, fmt.Errorf("no steps")
+	}
+	for i, step := range job.Steps {
+		if len(strings.TrimSpace(step.Run)) == 0 {
+			return nil, fmt.Errorf("step %d has nothing to run", i+1)
+		}
+		if len(step.Name) == 0 {
+			step.Name = strings.SplitN(strings.TrimSpace(step.Run), "\n", 2)[0]
+		}
+	}
+	return job, nil
+}
+
+// parseEvents reads the events triggering the workflow, they are listed as a single
+// event, a list of events or a map of events to their filters
+func parseEvents(on interface{}) (map[string]*EventFilter, error) {
+	events := make(map[string]*EventFilter)
+	switch v := on.(type) {
+	case string:
+		events[v] = &EventFilter{}
+	case []interface{}:
+		for _, e := range v {
+			name, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid event %v", e)
+			}
+			events[name] = &EventFilter{}
+		}
+	case map[interface{}]interface{}:
+		for e, filter := range v {
+			name, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid event %v", e)
+			}
+			f := &EventFilter{}
+			if filter != nil {
+				data, err := yaml.Marshal(filter)
+				if err != nil {
+					return nil, err
+				}
+				if err = yaml.UnmarshalStrict(data, f); err != nil {
+					return nil, fmt.Errorf("event %s: %v", name, err)
+				}
+			}
+			events[name] = f
+		}
+	default:
+		return nil, fmt.Errorf("workflow has no events")
+	}
+
+	for name := range events {
+		if name != EventPush && name != EventPullRequest {
+			return nil, fmt.Errorf("unsupported event %s", name)
+		}
+	}
+	return events, nil
+}
+
+// checkCycles returns an error if jobs need each other
+func checkCycles(jobs []*Job) error {
+	byID := make(map[string]*Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(jobs))
+	var visit func(job *Job) error
+	visit = func(job *Job) error {
+		switch state[job.ID] {
+		case visiting:
+			return fmt.Errorf("job %s needs itself", job.ID)
+		case visited:
+			return nil
+		}
+		state[job.ID] = visiting
+		for _, need := range job.Needs {
+			if err := visit(byID[need]); err != nil {
+				return err
+			}
+		}
+		state[job.ID] = visited
+		return nil
+	}
+	for _, job := range jobs {
+		if err := visit(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches returns true if the event for the ref triggers the workflow. The ref of pull
+// requests is their base branch.
+func (w *Workflow) Matches(event, ref string) bool {
+	filter, ok := w.On[event]
+	if !ok {
+		return false
+	}
+
+	if strings.HasPrefix(ref, git.TagPrefix) {
+		if event != EventPush || (len(filter.Tags) == 0 && (len(filter.Branches) > 0 || len(filter.BranchesIgnore) > 0)) {
+			return false
+		}
+		return matchRef(strings.TrimPrefix(ref, git.TagPrefix), filter.Tags, filter.TagsIgnore)
+	}
+
+	if len(filter.Tags) > 0 && len(filter.Branches) == 0 && len(filter.BranchesIgnore) == 0 {
+		return false
+	}
+	return matchRef(strings.TrimPrefix(ref, git.BranchPrefix), filter.Branches, filter.BranchesIgnore)
+}
+
+// matchRef returns true if the name matches one of the patterns, if any, and none of the
+// ignored patterns
+func matchRef(name string, patterns, ignored []string) bool {
+	for _, pattern := range ignored {
+		if matchPattern(pattern, name) {
+			return false
+		}
+	}
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPattern(pattern, name string) bool {
+	g, err := glob.Compile(pattern, '/')
+	return err == nil && g.Match(name)
+}
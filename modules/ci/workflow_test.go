@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow([]byte(`
+name: build
+on:
+  push:
+    branches: [master, "release/*"]
+  pull_request:
+env:
+  GOFLAGS: -mod=vendor
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - run: make test
+  build:
+    name: Build binaries
+    runs-on: [linux, docker]
+    needs: test
+    env:
+      TAGS: sqlite
+    steps:
+      - name: Compile
+        run: |
+          make build
+          make release
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "build", w.Name)
+	assert.Equal(t, map[string]string{"GOFLAGS": "-mod=vendor"}, w.Env)
+	assert.Len(t, w.On, 2)
+	assert.Equal(t, []string{"master", "release/*"}, w.On[EventPush].Branches)
+
+	if assert.Len(t, w.Jobs, 2) {
+		test, build := w.Jobs[0], w.Jobs[1]
+		assert.Equal(t, "test", test.ID)
+		assert.Equal(t, "test", test.Name)
+		assert.EqualValues(t, []string{"linux"}, test.RunsOn)
+		assert.Equal(t, "make test", test.Steps[0].Name)
+
+		assert.Equal(t, "build", build.ID)
+		assert.Equal(t, "Build binaries", build.Name)
+		assert.EqualValues(t, []string{"linux", "docker"}, build.RunsOn)
+		assert.EqualValues(t, []string{"test"}, build.Needs)
+		assert.Equal(t, "sqlite", build.Env["TAGS"])
+		assert.Equal(t, "make build\nmake release\n", build.Steps[0].Run)
+	}
+
+	w, err = ParseWorkflow([]byte("on: [push, pull_request]\njobs:\n  a:\n    steps:\n      - run: echo\n"))
+	assert.NoError(t, err)
+	assert.Len(t, w.On, 2)
+
+	for name, data := range map[string]string{
+		"no events":         "jobs:\n  a:\n    steps:\n      - run: echo\n",
+		"unknown event":     "on: issues\njobs:\n  a:\n    steps:\n      - run: echo\n",
+		"no jobs":           "on: push\n",
+		"no steps":          "on: push\njobs:\n  a:\n    runs-on: linux\n",
+		"empty step":        "on: push\njobs:\n  a:\n    steps:\n      - name: nothing\n",
+		"invalid job id":    "on: push\njobs:\n  a b:\n    steps:\n      - run: echo\n",
+		"unknown key":       "on: push\njobs:\n  a:\n    uses: docker\n    steps:\n      - run: echo\n",
+		"unknown need":      "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: echo\n",
+		"cyclic needs":      "on: push\njobs:\n  a:\n    needs: b\n    steps:\n      - run: echo\n  b:\n    needs: a\n    steps:\n      - run: echo\n",
+		"unknown filter":    "on:\n  push:\n    paths: [docs]\njobs:\n  a:\n    steps:\n      - run: echo\n",
+		"invalid yaml":      "on: [push\n",
+		"invalid step list": "on: push\njobs:\n  a:\n    steps: echo\n",
+	} {
+		_, err := ParseWorkflow([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWorkflowMatches(t *testing.T) {
+	w := &Workflow{On: map[string]*EventFilter{
+		EventPush:        {Branches: []string{"master", "release/*"}, Tags: []string{"v*"}},
+		EventPullRequest: {BranchesIgnore: []string{"wip-*"}},
+	}}
+	for _, c := range []struct {
+		event, ref string
+		matches    bool
+	}{
+		{EventPush, "refs/heads/master", true},
+		{EventPush, "refs/heads/release/1.12", true},
+		{EventPush, "refs/heads/release/1.12/fix", false},
+		{EventPush, "refs/heads/feature", false},
+		{EventPush, "refs/tags/v1.0.0", true},
+		{EventPush, "refs/tags/latest", false},
+		{EventPullRequest, "refs/heads/feature", true},
+		{EventPullRequest, "refs/heads/wip-feature", false},
+		{"release", "refs/heads/master", false},
+	} {
+		assert.Equal(t, c.matches, w.Matches(c.event, c.ref), "%s %s", c.event, c.ref)
+	}
+
+	// a filter with only branches does not trigger on tags, a filter with only tags does
+	// not trigger on branches and an empty filter triggers on both
+	assert.False(t, (&Workflow{On: map[string]*EventFilter{EventPush: {Branches: []string{"*"}}}}).Matches(EventPush, "refs/tags/v1"))
+	assert.False(t, (&Workflow{On: map[string]*EventFilter{EventPush: {Tags: []string{"*"}}}}).Matches(EventPush, "refs/heads/master"))
+	assert.True(t, (&Workflow{On: map[string]*EventFilter{EventPush: {}}}).Matches(EventPush, "refs/tags/v1"))
+	assert.False(t, (&Workflow{On: map[string]*EventFilter{EventPullRequest: {}}}).Matches(EventPullRequest, "refs/tags/v1"))
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package container implements the storage and the data formats of the OCI container
+// registry, https://github.com/opencontainers/distribution-spec
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Media types of manifests and indexes
+const (
+	MediaTypeImageManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeImageIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Error codes defined by the distribution spec
+const (
+	ErrorCodeBlobUnknown         = "BLOB_UNKNOWN"
+	ErrorCodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	ErrorCodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	ErrorCodeDigestInvalid       = "DIGEST_INVALID"
+	ErrorCodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	ErrorCodeManifestInvalid     = "MANIFEST_INVALID"
+	ErrorCodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	ErrorCodeNameInvalid         = "NAME_INVALID"
+	ErrorCodeNameUnknown         = "NAME_UNKNOWN"
+	ErrorCodeSizeInvalid         = "SIZE_INVALID"
+	ErrorCodeUnauthorized        = "UNAUTHORIZED"
+	ErrorCodeDenied              = "DENIED"
+	ErrorCodeUnsupported         = "UNSUPPORTED"
+)
+
+var (
+	nameComponent = `[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*`
+	namePattern   = regexp.MustCompile(`^` + nameComponent + `(?:/` + nameComponent + `)*$`)
+	tagPattern    = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Error is a single error of an error response
+type Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Detail  interface{} `json:"detail,omitempty"`
+}
+
+// ErrorResponse is the body of an error response
+type ErrorResponse struct {
+	Errors []Error `json:"errors"`
+}
+
+// Descriptor describes the content a manifest references
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// Manifest holds the fields of image manifests and image indexes needed by the registry
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        *Descriptor  `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+// IsIndex returns true if the manifest is an image index referencing other manifests
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeImageIndex || m.MediaType == MediaTypeDockerList
+}
+
+// Blobs returns the digests of the blobs referenced by an image manifest
+func (m *Manifest) Blobs() []string {
+	digests := make([]string, 0, len(m.Layers)+1)
+	if m.Config != nil {
+		digests = append(digests, m.Config.Digest)
+	}
+	for _, layer := range m.Layers {
+		digests = append(digests, layer.Digest)
+	}
+	return digests
+}
+
+// Children returns the digests of the manifests referenced by an image index
+func (m *Manifest) Children() []string {
+	digests := make([]string, 0, len(m.Manifests))
+	for _, child := range m.Manifests {
+		digests = append(digests, child.Digest)
+	}
+	return digests
+}
+
+// ParseManifest parses the content of a manifest uploaded with the given content type
+func ParseManifest(contentType string, content []byte) (*Manifest, error) {
+	m := new(Manifest)
+	if err := json.Unmarshal(content, m); err != nil {
+		return nil, err
+	}
+	if m.SchemaVersion != 2 {
+		return nil, fmt.Errorf("unsupported schema version %d", m.SchemaVersion)
+	}
+	if len(m.MediaType) == 0 {
+		m.MediaType = contentType
+	} else if len(contentType) > 0 && contentType != m.MediaType {
+		return nil, fmt.Errorf("media type %s does not match content type %s", m.MediaType, contentType)
+	}
+
+	switch m.MediaType {
+	case MediaTypeImageManifest, MediaTypeDockerManifest:
+		if m.Config == nil {
+			return nil, fmt.Errorf("image manifest has no config")
+		}
+	case MediaTypeImageIndex, MediaTypeDockerList:
+	default:
+		return nil, fmt.Errorf("unsupported media type %q", m.MediaType)
+	}
+
+	for _, digest := range append(m.Blobs(), m.Children()...) {
+		if !IsValidDigest(digest) {
+			return nil, fmt.Errorf("invalid digest %q", digest)
+		}
+	}
+	return m, nil
+}
+
+// IsValidName returns true if name is a valid repository name including its namespace
+func IsValidName(name string) bool {
+	return len(name) <= 255 && namePattern.MatchString(name)
+}
+
+// IsValidTag returns true if tag is a valid tag name
+func IsValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// IsValidDigest returns true if digest is a supported digest
+func IsValidDigest(digest string) bool {
+	return digestPattern.MatchString(digest)
+}
+
+// SplitName splits a repository name into the name of the owner and the image name
+func SplitName(name string) (owner, image string) {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func splitDigest(digest string) (algorithm, encoded string) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 {
+		return "", parts[0]
+	}
+	return parts[0], parts[1]
+}
+
+// NewContentStore returns the content store of the registry
+func NewContentStore() *ContentStore {
+	return &ContentStore{BasePath: setting.ContainerRegistry.ContentPath}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const helloDigest = "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func TestIsValidName(t *testing.T) {
+	for _, name := range []string{"user/image", "org/group/image", "a/b.c_d__e--f"} {
+		assert.True(t, IsValidName(name), name)
+	}
+	for _, name := range []string{"", "User/image", "user/image/", "user//image", "user/-image", "user/image_"} {
+		assert.False(t, IsValidName(name), name)
+	}
+
+	owner, image := SplitName("org/group/image")
+	assert.EqualValues(t, "org", owner)
+	assert.EqualValues(t, "group/image", image)
+}
+
+func TestParseManifest(t *testing.T) {
+	config := `{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"` + helloDigest + `","size":5}`
+	m, err := ParseManifest(MediaTypeImageManifest, []byte(`{"schemaVersion":2,"config":`+config+`,"layers":[`+config+`]}`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, MediaTypeImageManifest, m.MediaType)
+	assert.False(t, m.IsIndex())
+	assert.EqualValues(t, []string{helloDigest, helloDigest}, m.Blobs())
+
+	m, err = ParseManifest("", []byte(`{"schemaVersion":2,"mediaType":"`+MediaTypeDockerList+`","manifests":[`+config+`]}`))
+	assert.NoError(t, err)
+	assert.True(t, m.IsIndex())
+	assert.EqualValues(t, []string{helloDigest}, m.Children())
+
+	for _, content := range []string{
+		`{"schemaVersion":1}`,
+		`{"schemaVersion":2,"mediaType":"` + MediaTypeImageManifest + `"}`,
+		`{"schemaVersion":2,"mediaType":"` + MediaTypeImageIndex + `","manifests":[{"digest":"md5:00"}]}`,
+		`{"schemaVersion":2,"mediaType":"text/plain"}`,
+	} {
+		_, err = ParseManifest("", []byte(content))
+		assert.Error(t, err, content)
+	}
+	_, err = ParseManifest(MediaTypeImageIndex, []byte(`{"schemaVersion":2,"mediaType":"`+MediaTypeImageManifest+`","config":`+config+`}`))
+	assert.Error(t, err)
+}
+
+func TestContentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "container-store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	store := &ContentStore{BasePath: dir}
+
+	_, err = store.Put(helloDigest, 5, strings.NewReader("world"))
+	assert.Equal(t, ErrDigestMismatch, err)
+	_, err = store.Put(helloDigest, 4, strings.NewReader("hello"))
+	assert.Equal(t, ErrSizeMismatch, err)
+	assert.False(t, store.Exists(helloDigest))
+
+	_, err = store.AppendUpload("upload", strings.NewReader("hel"))
+	assert.NoError(t, err)
+	_, err = store.AppendUpload("upload", strings.NewReader("lo"))
+	assert.NoError(t, err)
+	written, err := store.FinishUpload("upload", helloDigest)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, written)
+	assert.True(t, store.Exists(helloDigest))
+	_, err = os.Stat(store.UploadPath("upload"))
+	assert.True(t, os.IsNotExist(err))
+
+	var digests []string
+	assert.NoError(t, store.Walk(func(digest string, _ os.FileInfo) error {
+		digests = append(digests, digest)
+		return nil
+	}))
+	assert.EqualValues(t, []string{helloDigest}, digests)
+
+	f, err := store.Get(helloDigest)
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "hello", string(content))
+
+	assert.NoError(t, store.Delete(helloDigest))
+	assert.NoError(t, store.Delete(helloDigest))
+	assert.False(t, store.Exists(helloDigest))
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDigestMismatch is returned if the content does not match its digest
+	ErrDigestMismatch = errors.New("Content does not match digest")
+	// ErrSizeMismatch is returned if the content does not have the expected size
+	ErrSizeMismatch = errors.New("Content size does not match")
+)
+
+const uploadsDir = "_uploads"
+
+// ContentStore provides a simple file system based storage of blobs, addressed by their
+// digest like the LFS content store.
+type ContentStore struct {
+	BasePath string
+}
+
+// Get returns the content of the blob with the given digest
+func (s *ContentStore) Get(digest string) (*os.File, error) {
+	return os.Open(s.path(digest))
+}
+
+// Stat returns the file info of the blob with the given digest
+func (s *ContentStore) Stat(digest string) (os.FileInfo, error) {
+	return os.Stat(s.path(digest))
+}
+
+// Exists returns true if the blob with the given digest is in the store. The modification
+// time of an existing blob is updated so that the garbage collection keeps it while it is
+// being linked again.
+func (s *ContentStore) Exists(digest string) bool {
+	now := time.Now()
+	return os.Chtimes(s.path(digest), now, now) == nil
+}
+
+// Put writes the content of r to the store if it matches the digest and the size, a
+// negative size is not checked.
+func (s *ContentStore) Put(digest string, size int64, r io.Reader) (int64, error) {
+	path := s.path(digest)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return 0, err
+	}
+
+	// Concurrent uploads of the same blob write to different temporary files
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := s.write(file, digest, r)
+	if err != nil {
+		return written, err
+	} else if size >= 0 && written != size {
+		return written, ErrSizeMismatch
+	}
+	return written, os.Rename(file.Name(), path)
+}
+
+func (s *ContentStore) write(file *os.File, digest string, r io.Reader) (int64, error) {
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(hash, file), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, err
+	}
+	if "sha256:"+hex.EncodeToString(hash.Sum(nil)) != digest {
+		return written, ErrDigestMismatch
+	}
+	return written, nil
+}
+
+// Delete removes the blob with the given digest from the store
+func (s *ContentStore) Delete(digest string) error {
+	err := os.Remove(s.path(digest))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// Walk calls fn for the digest and the file info of every blob in the store
+func (s *ContentStore) Walk(fn func(digest string, info os.FileInfo) error) error {
+	return filepath.Walk(s.BasePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == uploadsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(s.BasePath, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 4 || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		return fn(parts[0]+":"+parts[1]+parts[2]+parts[3], info)
+	})
+}
+
+// path returns the location of a blob, algorithm/ab/cd/ef01...
+func (s *ContentStore) path(digest string) string {
+	algorithm, encoded := splitDigest(digest)
+	if len(encoded) < 5 {
+		return filepath.Join(s.BasePath, algorithm, encoded)
+	}
+	return filepath.Join(s.BasePath, algorithm, encoded[0:2], encoded[2:4], encoded[4:])
+}
+
+// UploadPath returns the location of the data of an upload in progress
+func (s *ContentStore) UploadPath(uuid string) string {
+	return filepath.Join(s.BasePath, uploadsDir, uuid)
+}
+
+// AppendUpload appends the content of r to an upload in progress and returns the number of
+// bytes written
+func (s *ContentStore) AppendUpload(uuid string, r io.Reader) (int64, error) {
+	path := s.UploadPath(uuid)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return 0, err
+	}
+	written, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return written, err
+}
+
+// FinishUpload moves the data of an upload in progress into the store if it matches the digest
+func (s *ContentStore) FinishUpload(uuid, digest string) (int64, error) {
+	file, err := os.Open(s.UploadPath(uuid))
+	if os.IsNotExist(err) {
+		// Nothing has been uploaded yet
+		return s.Put(digest, 0, strings.NewReader(""))
+	} else if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	written, err := s.Put(digest, -1, file)
+	if err != nil {
+		return written, err
+	}
+	return written, s.DeleteUpload(uuid)
+}
+
+// DeleteUpload removes the data of an upload in progress
+func (s *ContentStore) DeleteUpload(uuid string) error {
+	err := os.Remove(s.UploadPath(uuid))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// GarbageCollect removes abandoned uploads, blobs no longer referenced by any manifest of their
+// image, content no image links anymore and empty images. Only data older than olderThan is
+// removed so that pushes in progress are not affected.
+func GarbageCollect(ctx context.Context, olderThan time.Duration) error {
+	if !setting.ContainerRegistry.Enabled {
+		return nil
+	}
+	log.Trace("Doing: ContainerRegistryGC")
+
+	store := NewContentStore()
+	cutoff := time.Now().Add(-olderThan)
+
+	uploads, err := models.GetContainerUploadsBefore(timeutil.TimeStamp(cutoff.Unix()))
+	if err != nil {
+		return fmt.Errorf("GetContainerUploadsBefore: %v", err)
+	}
+	for _, u := range uploads {
+		if err = store.DeleteUpload(u.UUID); err != nil {
+			return fmt.Errorf("DeleteUpload: %v", err)
+		}
+		if err = models.DeleteContainerUpload(u); err != nil {
+			return fmt.Errorf("DeleteContainerUpload: %v", err)
+		}
+	}
+
+	unlinked, err := models.DeleteUnreferencedContainerBlobs(timeutil.TimeStamp(cutoff.Unix()))
+	if err != nil {
+		return fmt.Errorf("DeleteUnreferencedContainerBlobs: %v", err)
+	}
+
+	var removed int
+	if err = store.Walk(func(digest string, info os.FileInfo) error {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted due to shutdown:\nin container registry garbage collection at %s", digest)
+		default:
+		}
+
+		if info.ModTime().After(cutoff) {
+			return nil
+		}
+		linked, err := models.IsContainerBlobLinked(digest)
+		if err != nil || linked {
+			return err
+		}
+		removed++
+		return store.Delete(digest)
+	}); err != nil {
+		return fmt.Errorf("Walk: %v", err)
+	}
+
+	images, err := models.DeleteEmptyContainerImages()
+	if err != nil {
+		return fmt.Errorf("DeleteEmptyContainerImages: %v", err)
+	}
+
+	log.Info("Container registry garbage collection: removed %d uploads, unlinked %d blobs, deleted %d blobs and %d empty images",
+		len(uploads), unlinked, removed, images)
+	return nil
+}
@@ -6,6 +6,8 @@
 package context
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/log"
@@ -58,6 +60,27 @@ func Toggle(options *ToggleOptions) macaron.Handler {
 				ctx.Redirect(setting.AppSubURL + "/")
 				return
 			}
+
+			// Users who must enroll two-factor authentication can only access their security settings
+			if !strings.HasPrefix(ctx.Req.URL.Path, "/user/settings/security") {
+				mustEnroll, err := ctx.User.MustEnrollTwoFactor()
+				if err != nil {
+					ctx.ServerError("MustEnrollTwoFactor", err)
+					return
+				} else if mustEnroll {
+					if auth.IsAPIPath(ctx.Req.URL.Path) {
+						ctx.JSON(403, map[string]string{
+							"message": models.ErrTwoFactorRequired{}.Error(),
+						})
+						return
+					} else if ctx.IsBasicAuth {
+						ctx.PlainText(403, []byte(models.ErrTwoFactorRequired{}.Error()))
+						return
+					}
+					ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+					return
+				}
+			}
 		}
 
 		// Redirect to dashboard if user tries to visit any non-login page.
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
@@ -225,6 +226,11 @@ func (ctx *Context) ServeContent(name string, r io.ReadSeeker, params ...interfa
 	http.ServeContent(ctx.Resp, ctx.Req.Request, name, modtime, r)
 }
 
+// Audit records a security-relevant event done by the signed in user to the audit log
+func (ctx *Context) Audit(action models.AuditAction, target interface{}, diff audit.Diff) {
+	audit.Record(action, ctx.User, ctx.RemoteAddr(), target, diff)
+}
+
 // Contexter initializes a classic context for a request.
 func Contexter() macaron.Handler {
 	return func(c *macaron.Context, l i18n.Locale, cache cache.Cache, sess session.Store, f *session.Flash, x csrf.CSRF) {
@@ -340,7 +346,11 @@ func Contexter() macaron.Handler {
 		ctx.Data["ShowFooterVersion"] = setting.ShowFooterVersion
 
 		ctx.Data["EnableSwagger"] = setting.API.EnableSwagger
+		ctx.Data["EnableCI"] = setting.CI.Enabled
 		ctx.Data["EnableOpenIDSignIn"] = setting.Service.EnableOpenIDSignIn
+		ctx.Data["EnableNotificationEvents"] = setting.UI.Notification.EventSourceEnabled
+		ctx.Data["EnableWebPush"] = setting.WebPush.Enabled
+		ctx.Data["WebPushPublicKey"] = setting.WebPush.VAPIDPublicKey
 
 		c.Map(ctx)
 	}
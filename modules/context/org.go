@@ -115,6 +115,7 @@ func HandleOrgAssignment(ctx *Context, args ...bool) {
 
 	ctx.Org.OrgLink = setting.AppSubURL + "/org/" + org.Name
 	ctx.Data["OrgLink"] = ctx.Org.OrgLink
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
 
 	// Team.
 	if ctx.Org.IsMember {
@@ -327,6 +327,12 @@ func repoAssignment(ctx *Context, repo *models.Repository) {
 			EarlyResponseForGoGetMeta(ctx)
 			return
 		}
+		if ctx.Repo.Permission.TwoFactorRequired {
+			ctx.Data["Title"] = ctx.Tr("auth.twofa_required")
+			ctx.Data["TwoFactorRequiredOrg"] = repo.Owner
+			ctx.HTML(403, "user/auth/twofa_required")
+			return
+		}
 		ctx.NotFound("no access right", nil)
 		return
 	}
@@ -474,6 +480,15 @@ func RepoAssignment() macaron.Handler {
 		if ctx.IsSigned {
 			ctx.Data["IsWatchingRepo"] = models.IsWatching(ctx.User.ID, repo.ID)
 			ctx.Data["IsStaringRepo"] = models.IsStaring(ctx.User.ID, repo.ID)
+			if setting.Service.EnableNotifyMail {
+				level, err := models.GetRepoNotificationLevel(ctx.User.ID, repo.ID)
+				if err != nil {
+					ctx.ServerError("GetRepoNotificationLevel", err)
+					return
+				}
+				ctx.Data["RepoNotificationLevel"] = level
+				ctx.Data["RepoNotificationLevels"] = models.RepoNotificationLevels
+			}
 		}
 
 		if repo.IsFork {
@@ -297,6 +297,7 @@ func ToOrganization(org *models.User) *api.Organization {
 		Location:                  org.Location,
 		Visibility:                org.Visibility.String(),
 		RepoAdminChangeTeamAccess: org.RepoAdminChangeTeamAccess,
+		RequireTwoFactor:          org.RequireTwoFactor,
 	}
 }
 
@@ -310,6 +311,7 @@ func ToTeam(team *models.Team) *api.Team {
 		CanCreateOrgRepo:        team.CanCreateOrgRepo,
 		Permission:              team.Authorize.String(),
 		Units:                   team.GetUnitNames(),
+		ReviewAssignment:        team.ReviewAssignment.Name(),
 	}
 }
 
@@ -388,3 +390,32 @@ func ToTopicResponse(topic *models.Topic) *api.TopicResponse {
 		Updated:   topic.UpdatedUnix.AsTime(),
 	}
 }
+
+// ToAuditEvent convert from models.AuditEvent to api.AuditEvent
+func ToAuditEvent(e *models.AuditEvent) *api.AuditEvent {
+	return &api.AuditEvent{
+		ID:         e.ID,
+		Action:     string(e.Action),
+		ActorID:    e.ActorID,
+		Actor:      e.ActorName,
+		IPAddress:  e.IPAddress,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Target:     e.TargetName,
+		Diff:       e.DiffMap(),
+		Created:    e.CreatedUnix.AsTime(),
+	}
+}
+
+// ToPackageFile convert from models.PackageFile to api.PackageFile
+func ToPackageFile(pf *models.PackageFile) *api.PackageFile {
+	return &api.PackageFile{
+		ID:         pf.ID,
+		Name:       pf.Name,
+		Size:       pf.Size,
+		HashMD5:    pf.HashMD5,
+		HashSHA1:   pf.HashSHA1,
+		HashSHA256: pf.HashSHA256,
+		HashSHA512: pf.HashSHA512,
+	}
+}
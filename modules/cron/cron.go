@@ -10,13 +10,17 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/migrations"
 	repo_module "code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/sync"
+	"code.gitea.io/gitea/services/mailer"
 	mirror_service "code.gitea.io/gitea/services/mirror"
+	reminder_service "code.gitea.io/gitea/services/reminder"
+	stale_service "code.gitea.io/gitea/services/stale"
 
 	"github.com/gogs/cron"
 )
@@ -29,6 +33,11 @@ const (
 	syncExternalUsers       = "sync_external_users"
 	deletedBranchesCleanup  = "deleted_branches_cleanup"
 	updateMigrationPosterID = "update_migration_post_id"
+	containerRegistryGC     = "container_registry_gc"
+	sendHourlyEmailDigests  = "send_hourly_email_digests"
+	sendDailyEmailDigests   = "send_daily_email_digests"
+	sendReminders           = "send_reminders"
+	staleIssues             = "stale_issues"
 )
 
 var c = cron.New()
@@ -133,6 +142,66 @@ func NewContext() {
 		}
 	}
 
+	if setting.ContainerRegistry.Enabled && setting.Cron.ContainerRegistryGC.Enabled {
+		entry, err = c.AddFunc("Container registry garbage collection", setting.Cron.ContainerRegistryGC.Schedule, WithUnique(containerRegistryGC, containerGarbageCollect))
+		if err != nil {
+			log.Fatal("Cron[Container registry garbage collection]: %v", err)
+		}
+		if setting.Cron.ContainerRegistryGC.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(containerRegistryGC, containerGarbageCollect)()
+		}
+	}
+
+	if setting.Cron.SendHourlyEmailDigests.Enabled {
+		entry, err = c.AddFunc("Send hourly email digests", setting.Cron.SendHourlyEmailDigests.Schedule, WithUnique(sendHourlyEmailDigests, hourlyEmailDigests))
+		if err != nil {
+			log.Fatal("Cron[Send hourly email digests]: %v", err)
+		}
+		if setting.Cron.SendHourlyEmailDigests.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(sendHourlyEmailDigests, hourlyEmailDigests)()
+		}
+	}
+
+	if setting.Cron.SendDailyEmailDigests.Enabled {
+		entry, err = c.AddFunc("Send daily email digests", setting.Cron.SendDailyEmailDigests.Schedule, WithUnique(sendDailyEmailDigests, dailyEmailDigests))
+		if err != nil {
+			log.Fatal("Cron[Send daily email digests]: %v", err)
+		}
+		if setting.Cron.SendDailyEmailDigests.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(sendDailyEmailDigests, dailyEmailDigests)()
+		}
+	}
+
+	if setting.Cron.SendReminders.Enabled {
+		entry, err = c.AddFunc("Send reminders", setting.Cron.SendReminders.Schedule, WithUnique(sendReminders, reminder_service.SendReminders))
+		if err != nil {
+			log.Fatal("Cron[Send reminders]: %v", err)
+		}
+		if setting.Cron.SendReminders.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(sendReminders, reminder_service.SendReminders)()
+		}
+	}
+
+	if setting.Cron.StaleIssues.Enabled {
+		entry, err = c.AddFunc("Mark and close stale issues", setting.Cron.StaleIssues.Schedule, WithUnique(staleIssues, stale_service.Run))
+		if err != nil {
+			log.Fatal("Cron[Mark and close stale issues]: %v", err)
+		}
+		if setting.Cron.StaleIssues.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(staleIssues, stale_service.Run)()
+		}
+	}
+
 	entry, err = c.AddFunc("Update migrated repositories' issues and comments' posterid", setting.Cron.UpdateMigrationPosterID.Schedule, WithUnique(updateMigrationPosterID, migrations.UpdateMigrationPosterID))
 	if err != nil {
 		log.Fatal("Cron[Update migrated repositories]: %v", err)
@@ -145,6 +214,22 @@ func NewContext() {
 	graceful.GetManager().RunAtShutdown(context.Background(), c.Stop)
 }
 
+func containerGarbageCollect(ctx context.Context) {
+	if err := container.GarbageCollect(ctx, setting.Cron.ContainerRegistryGC.OlderThan); err != nil {
+		log.Error("ContainerRegistryGC: %v", err)
+	}
+}
+
+// hourlyEmailDigests also flushes the pending items of the users who stopped
+// getting digests since
+func hourlyEmailDigests(ctx context.Context) {
+	mailer.SendEmailDigests(ctx, models.EmailDigestHourly, models.EmailDigestNone)
+}
+
+func dailyEmailDigests(ctx context.Context) {
+	mailer.SendEmailDigests(ctx, models.EmailDigestDaily)
+}
+
 // ListTasks returns all running cron tasks.
 func ListTasks() []*cron.Entry {
 	return c.Entries()
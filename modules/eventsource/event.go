@@ -0,0 +1,66 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventsource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is an event pushed to the event stream of a user
+type Event struct {
+	// Name is the name of the event, the default "message" if empty
+	Name string
+	// Data is sent as is if it's a string, encoded in JSON otherwise
+	Data interface{}
+	// ID is the ID of the event, if any
+	ID string
+	// Retry tells the client how long to wait before reconnecting, if set
+	Retry time.Duration
+}
+
+// WriteTo writes the event to the stream in the text/event-stream format
+func (e *Event) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	if e.Name != "" {
+		buf.WriteString("event: " + sanitizeField(e.Name) + "\n")
+	}
+	if e.ID != "" {
+		buf.WriteString("id: " + sanitizeField(e.ID) + "\n")
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", e.Retry/time.Millisecond)
+	}
+	if e.Data != nil {
+		var data string
+		switch v := e.Data.(type) {
+		case string:
+			data = v
+		case []byte:
+			data = string(v)
+		default:
+			bs, err := json.Marshal(v)
+			if err != nil {
+				return 0, err
+			}
+			data = string(bs)
+		}
+		// Each line of the data needs its own field
+		for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+			buf.WriteString("data: " + line + "\n")
+		}
+	}
+	buf.WriteString("\n")
+	return buf.WriteTo(w)
+}
+
+// sanitizeField prevents a field from spanning several lines
+func sanitizeField(field string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(field)
+}
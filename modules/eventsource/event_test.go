@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventsource
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWriteTo(t *testing.T) {
+	for expected, event := range map[string]*Event{
+		"data: hello\n\n":                              {Data: "hello"},
+		"event: ping\n\n":                              {Name: "ping"},
+		"event: count\ndata: {\"Count\":3}\n\n":        {Name: "count", Data: struct{ Count int }{3}},
+		"id: 1\nretry: 2000\ndata: a\ndata: b\n\n":     {ID: "1", Retry: 2 * time.Second, Data: "a\r\nb"},
+		"event: injectiondata: x\ndata: x\ndata: \n\n": {Name: "injection\ndata: x", Data: "x\n"},
+	} {
+		var buf bytes.Buffer
+		n, err := event.WriteTo(&buf)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(expected), n)
+		assert.Equal(t, expected, buf.String())
+	}
+}
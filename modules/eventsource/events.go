@@ -0,0 +1,64 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventsource
+
+import (
+	"code.gitea.io/gitea/models"
+)
+
+// Names of the events sent to the streams
+const (
+	EventNotificationCount = "notification-count"
+	EventStopwatches       = "stopwatches"
+	EventIssueUpdate       = "issue-update"
+)
+
+type notificationCount struct {
+	Count int64 `json:"count"`
+}
+
+type stopwatch struct {
+	IssueID int64 `json:"issue_id"`
+	Created int64 `json:"created"`
+}
+
+type issueUpdate struct {
+	IssueID int64 `json:"issue_id"`
+	DoerID  int64 `json:"doer_id"`
+}
+
+// NewNotificationCountEvent creates the event updating the count of the
+// unread notifications of a user
+func NewNotificationCountEvent(count int64) *Event {
+	return &Event{
+		Name: EventNotificationCount,
+		Data: notificationCount{Count: count},
+	}
+}
+
+// NewStopwatchesEvent creates the event listing the running stopwatches of a user
+func NewStopwatchesEvent(userID int64) (*Event, error) {
+	sws, err := models.GetUserStopwatches(userID, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data := make([]stopwatch, 0, len(*sws))
+	for _, sw := range *sws {
+		data = append(data, stopwatch{IssueID: sw.IssueID, Created: int64(sw.CreatedUnix)})
+	}
+	return &Event{
+		Name: EventStopwatches,
+		Data: data,
+	}, nil
+}
+
+// NewIssueUpdateEvent creates the event telling the streams following an
+// issue that it was updated
+func NewIssueUpdateEvent(issueID, doerID int64) *Event {
+	return &Event{
+		Name: EventIssueUpdate,
+		Data: issueUpdate{IssueID: issueID, DoerID: doerID},
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventsource
+
+import (
+	"sync"
+)
+
+// streamBufferSize is the number of events a stream buffers before the
+// following ones are dropped, in case its client doesn't keep up
+const streamBufferSize = 10
+
+// Stream is an open event stream of a user
+type Stream struct {
+	userID  int64
+	issueID int64
+	events  chan *Event
+}
+
+// Events returns the channel of the events to send, closed once the stream is unregistered
+func (s *Stream) Events() <-chan *Event {
+	return s.events
+}
+
+// Manager dispatches the events to the open event streams of the users
+type Manager struct {
+	mutex   sync.Mutex
+	streams map[int64]map[*Stream]struct{}
+	issues  map[int64]map[*Stream]struct{}
+}
+
+var (
+	manager     *Manager
+	managerOnce sync.Once
+)
+
+// GetManager returns the event stream manager
+func GetManager() *Manager {
+	managerOnce.Do(func() {
+		manager = NewManager()
+	})
+	return manager
+}
+
+// NewManager creates a new event stream manager
+func NewManager() *Manager {
+	return &Manager{
+		streams: make(map[int64]map[*Stream]struct{}),
+		issues:  make(map[int64]map[*Stream]struct{}),
+	}
+}
+
+// Register opens a new stream for the user, which also gets the updates of
+// the issue if its ID isn't 0
+func (m *Manager) Register(userID, issueID int64) *Stream {
+	s := &Stream{
+		userID:  userID,
+		issueID: issueID,
+		events:  make(chan *Event, streamBufferSize),
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	addStream(m.streams, userID, s)
+	if issueID != 0 {
+		addStream(m.issues, issueID, s)
+	}
+	return s
+}
+
+// Unregister closes the stream
+func (m *Manager) Unregister(s *Stream) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if _, ok := m.streams[s.userID][s]; !ok {
+		return
+	}
+	removeStream(m.streams, s.userID, s)
+	if s.issueID != 0 {
+		removeStream(m.issues, s.issueID, s)
+	}
+	close(s.events)
+}
+
+// UnregisterAll closes all the streams
+func (m *Manager) UnregisterAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, streams := range m.streams {
+		for s := range streams {
+			close(s.events)
+		}
+	}
+	m.streams = make(map[int64]map[*Stream]struct{})
+	m.issues = make(map[int64]map[*Stream]struct{})
+}
+
+// IsConnected returns whether the user has an open stream
+func (m *Manager) IsConnected(userID int64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.streams[userID]) > 0
+}
+
+// SendMessage sends the event to the open streams of the user
+func (m *Manager) SendMessage(userID int64, event *Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sendAll(m.streams[userID], event)
+}
+
+// SendIssueMessage sends the event to the open streams following the issue
+func (m *Manager) SendIssueMessage(issueID int64, event *Event) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	sendAll(m.issues[issueID], event)
+}
+
+func addStream(streams map[int64]map[*Stream]struct{}, id int64, s *Stream) {
+	if streams[id] == nil {
+		streams[id] = make(map[*Stream]struct{})
+	}
+	streams[id][s] = struct{}{}
+}
+
+func removeStream(streams map[int64]map[*Stream]struct{}, id int64, s *Stream) {
+	delete(streams[id], s)
+	if len(streams[id]) == 0 {
+		delete(streams, id)
+	}
+}
+
+func sendAll(streams map[*Stream]struct{}, event *Event) {
+	for s := range streams {
+		select {
+		case s.events <- event:
+		default:
+			// the client doesn't keep up, it will get the next events
+		}
+	}
+}
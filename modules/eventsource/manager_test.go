@@ -0,0 +1,51 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package eventsource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	first := m.Register(1, 0)
+	second := m.Register(1, 10)
+	other := m.Register(2, 10)
+	assert.True(t, m.IsConnected(1))
+	assert.False(t, m.IsConnected(3))
+
+	count := &Event{Name: "notification-count"}
+	m.SendMessage(1, count)
+	assert.Equal(t, count, <-first.Events())
+	assert.Equal(t, count, <-second.Events())
+	assert.Len(t, other.Events(), 0)
+
+	update := &Event{Name: "issue-update"}
+	m.SendIssueMessage(10, update)
+	assert.Len(t, first.Events(), 0)
+	assert.Equal(t, update, <-second.Events())
+	assert.Equal(t, update, <-other.Events())
+
+	// the events a client can't keep up with are dropped
+	for i := 0; i < streamBufferSize+5; i++ {
+		m.SendMessage(2, count)
+	}
+	assert.Len(t, other.Events(), streamBufferSize)
+
+	m.Unregister(first)
+	m.Unregister(first)
+	_, ok := <-first.Events()
+	assert.False(t, ok)
+	assert.True(t, m.IsConnected(1))
+
+	m.UnregisterAll()
+	assert.False(t, m.IsConnected(1))
+	_, ok = <-second.Events()
+	assert.False(t, ok)
+	// nothing is sent to the closed streams
+	m.SendIssueMessage(10, update)
+}
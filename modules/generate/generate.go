@@ -6,6 +6,8 @@
 package generate
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/base64"
 	"io"
@@ -77,6 +79,23 @@ func NewSecretKey() (string, error) {
 	return secretKey, nil
 }
 
+// NewVAPIDKeys generate a new key pair intended to be used by VAPID_PUBLIC_KEY
+// and VAPID_PRIVATE_KEY: the uncompressed public point and the private scalar
+// of a P-256 key.
+func NewVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	// the private scalar is left padded to its full size
+	d := make([]byte, 32)
+	b := key.D.Bytes()
+	copy(d[32-len(b):], b)
+	return base64.RawURLEncoding.EncodeToString(elliptic.Marshal(elliptic.P256(), key.X, key.Y)),
+		base64.RawURLEncoding.EncodeToString(d), nil
+}
+
 func randomInt(max *big.Int) (int, error) {
 	rand, err := rand.Int(rand.Reader, max)
 	if err != nil {
@@ -18,3 +18,11 @@ func TestGetRandomString(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, randomString, 4)
 }
+
+func TestNewVAPIDKeys(t *testing.T) {
+	publicKey, privateKey, err := NewVAPIDKeys()
+	assert.NoError(t, err)
+	// 65 bytes for the uncompressed point, 32 for the scalar
+	assert.Len(t, publicKey, 87)
+	assert.Len(t, privateKey, 43)
+}
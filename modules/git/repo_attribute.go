@@ -7,6 +7,7 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"os"
 
 	"github.com/mcuadros/go-version"
 )
@@ -17,6 +18,9 @@ type CheckAttributeOpts struct {
 	AllAttributes bool
 	Attributes    []string
 	Filenames     []string
+	// IndexFile reads the attributes from this index file instead of the
+	// index of the repository, it implies CachedOnly
+	IndexFile string
 }
 
 // CheckAttribute return the Blame object of file
@@ -42,21 +46,28 @@ func (repo *Repository) CheckAttribute(opts CheckAttributeOpts) (map[string]map[
 	}
 
 	// git check-attr --cached first appears in git 1.7.8
-	if opts.CachedOnly && version.Compare(binVersion, "1.7.8", ">=") {
+	if (opts.CachedOnly || opts.IndexFile != "") && version.Compare(binVersion, "1.7.8", ">=") {
 		cmdArgs = append(cmdArgs, "--cached")
 	}
 
-	cmdArgs = append(cmdArgs, "--")
+	var env []string
+	if opts.IndexFile != "" {
+		env = append(os.Environ(), "GIT_INDEX_FILE="+opts.IndexFile)
+	}
 
-	for _, arg := range opts.Filenames {
-		if arg != "" {
-			cmdArgs = append(cmdArgs, arg)
+	// the filenames are passed on stdin, there may be too many for the command line
+	cmdArgs = append(cmdArgs, "--stdin")
+	stdIn := new(bytes.Buffer)
+	for _, filename := range opts.Filenames {
+		if filename != "" {
+			stdIn.WriteString(filename)
+			stdIn.WriteByte('\000')
 		}
 	}
 
 	cmd := NewCommand(cmdArgs...)
 
-	if err := cmd.RunInDirPipeline(repo.Path, stdOut, stdErr); err != nil {
+	if err := cmd.RunInDirTimeoutEnvFullPipeline(env, -1, repo.Path, stdOut, stdErr, stdIn); err != nil {
 		return nil, fmt.Errorf("Failed to run check-attr: %v\n%s\n%s", err, stdOut.String(), stdErr.String())
 	}
 
@@ -6,11 +6,14 @@ package git
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
-// ReadTreeToIndex reads a treeish to the index
-func (repo *Repository) ReadTreeToIndex(treeish string) error {
+// ReadTreeToIndex reads a treeish to the index, or to the given index file
+func (repo *Repository) ReadTreeToIndex(treeish string, indexFilename ...string) error {
 	if len(treeish) != 40 {
 		res, err := NewCommand("rev-parse", "--verify", treeish).RunInDir(repo.Path)
 		if err != nil {
@@ -24,17 +27,40 @@ func (repo *Repository) ReadTreeToIndex(treeish string) error {
 	if err != nil {
 		return err
 	}
-	return repo.readTreeToIndex(id)
+	return repo.readTreeToIndex(id, indexFilename...)
 }
 
-func (repo *Repository) readTreeToIndex(id SHA1) error {
-	_, err := NewCommand("read-tree", id.String()).RunInDir(repo.Path)
+func (repo *Repository) readTreeToIndex(id SHA1, indexFilename ...string) error {
+	var env []string
+	if len(indexFilename) > 0 {
+		env = append(os.Environ(), "GIT_INDEX_FILE="+indexFilename[0])
+	}
+	_, err := NewCommand("read-tree", id.String()).RunInDirWithEnv(repo.Path, env)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// ReadTreeToTemporaryIndex reads a treeish to a temporary index file, which
+// also works for bare repositories. The returned cancel function removes it.
+func (repo *Repository) ReadTreeToTemporaryIndex(treeish string) (filename string, cancel func(), err error) {
+	tmpDir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		return "", nil, err
+	}
+	cancel = func() {
+		_ = os.RemoveAll(tmpDir)
+	}
+
+	filename = filepath.Join(tmpDir, "index")
+	if err = repo.ReadTreeToIndex(treeish, filename); err != nil {
+		cancel()
+		return "", nil, err
+	}
+	return filename, cancel, nil
+}
+
 // EmptyIndex empties the index
 func (repo *Repository) EmptyIndex() error {
 	_, err := NewCommand("read-tree", "--empty").RunInDir(repo.Path)
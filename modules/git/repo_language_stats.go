@@ -19,6 +19,27 @@ import (
 
 const fileSizeLimit int64 = 16 * 1024 * 1024
 
+// linguistAttributes are the .gitattributes overrides of the language stats
+var linguistAttributes = []string{
+	"linguist-vendored",
+	"linguist-generated",
+	"linguist-documentation",
+	"linguist-detectable",
+	"linguist-language",
+}
+
+const attributeUnspecified = "unspecified"
+
+// isAttributeSet returns true if an attribute value is set, as in `attr` or `attr=true`
+func isAttributeSet(value string) bool {
+	return value == "set" || value == "true"
+}
+
+// isAttributeUnset returns true if an attribute value is unset, as in `-attr` or `attr=false`
+func isAttributeUnset(value string) bool {
+	return value == "unset" || value == "false"
+}
+
 // GetLanguageStats calculates language stats for git repository at specified commit
 func (repo *Repository) GetLanguageStats(commitID string) (map[string]float32, error) {
 	r, err := git.PlainOpen(repo.Path)
@@ -41,27 +62,63 @@ func (repo *Repository) GetLanguageStats(commitID string) (map[string]float32, e
 		return nil, err
 	}
 
-	sizes := make(map[string]int64)
-	var total int64
+	var files []*object.File
+	hasAttributes := false
 	err = tree.Files().ForEach(func(f *object.File) error {
-		if enry.IsVendor(f.Name) || enry.IsDotFile(f.Name) ||
-			enry.IsDocumentation(f.Name) || enry.IsConfiguration(f.Name) {
-			return nil
+		files = append(files, f)
+		if filepath.Base(f.Name) == ".gitattributes" {
+			hasAttributes = true
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// TODO: Use .gitattributes file for linguist overrides
+	var attributes map[string]map[string]string
+	if hasAttributes {
+		if attributes, err = repo.checkLinguistAttributes(commitID, files); err != nil {
+			return nil, err
+		}
+	}
 
-		language, ok := enry.GetLanguageByExtension(f.Name)
-		if !ok {
-			if language, ok = enry.GetLanguageByFilename(f.Name); !ok {
-				content, err := readFile(f, fileSizeLimit)
-				if err != nil {
-					return nil
-				}
+	sizes := make(map[string]int64)
+	var total int64
+	for _, f := range files {
+		attrs := attributes[f.Name]
+		vendored := attrs["linguist-vendored"]
+		documentation := attrs["linguist-documentation"]
+		detectable := attrs["linguist-detectable"]
+		if isAttributeSet(vendored) || isAttributeSet(attrs["linguist-generated"]) ||
+			isAttributeSet(documentation) || isAttributeUnset(detectable) {
+			continue
+		}
+		if (!isAttributeUnset(vendored) && enry.IsVendor(f.Name)) ||
+			(!isAttributeUnset(documentation) && enry.IsDocumentation(f.Name)) ||
+			(!isAttributeSet(detectable) && (enry.IsDotFile(f.Name) || enry.IsConfiguration(f.Name))) {
+			continue
+		}
+
+		language := attrs["linguist-language"]
+		if isAttributeSet(language) || isAttributeUnset(language) || language == attributeUnspecified {
+			language = ""
+		} else if canonical, ok := enry.GetLanguageByAlias(language); ok {
+			language = canonical
+		}
 
-				language = enry.GetLanguage(filepath.Base(f.Name), content)
-				if language == enry.OtherLanguage {
-					return nil
+		if language == "" {
+			var ok bool
+			if language, ok = enry.GetLanguageByExtension(f.Name); !ok {
+				if language, ok = enry.GetLanguageByFilename(f.Name); !ok {
+					content, err := readFile(f, fileSizeLimit)
+					if err != nil {
+						continue
+					}
+
+					language = enry.GetLanguage(filepath.Base(f.Name), content)
+					if language == enry.OtherLanguage {
+						continue
+					}
 				}
 			}
 		}
@@ -70,11 +127,6 @@ func (repo *Repository) GetLanguageStats(commitID string) (map[string]float32, e
 			sizes[language] += f.Size
 			total += f.Size
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	stats := make(map[string]float32)
@@ -94,6 +146,26 @@ func (repo *Repository) GetLanguageStats(commitID string) (map[string]float32, e
 	return stats, nil
 }
 
+// checkLinguistAttributes returns the linguist attributes of the files as
+// defined by the .gitattributes files of the commit
+func (repo *Repository) checkLinguistAttributes(commitID string, files []*object.File) (map[string]map[string]string, error) {
+	indexFilename, cancel, err := repo.ReadTreeToTemporaryIndex(commitID)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	filenames := make([]string, len(files))
+	for i, f := range files {
+		filenames[i] = f.Name
+	}
+	return repo.CheckAttribute(CheckAttributeOpts{
+		Attributes: linguistAttributes,
+		Filenames:  filenames,
+		IndexFile:  indexFilename,
+	})
+}
+
 func readFile(f *object.File, limit int64) ([]byte, error) {
 	r, err := f.Reader()
 	if err != nil {
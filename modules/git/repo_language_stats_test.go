@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_GetLanguageStats(t *testing.T) {
+	repoPath, err := ioutil.TempDir("", "repo_language_stats")
+	assert.NoError(t, err)
+	defer os.RemoveAll(repoPath)
+	assert.NoError(t, InitRepository(repoPath, false))
+
+	commit := func(files map[string]string) string {
+		for name, content := range files {
+			filename := filepath.Join(repoPath, name)
+			assert.NoError(t, os.MkdirAll(filepath.Dir(filename), os.ModePerm))
+			assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+		}
+		assert.NoError(t, AddChanges(repoPath, true))
+		assert.NoError(t, CommitChanges(repoPath, CommitChangesOptions{
+			Committer: &Signature{Name: "Test", Email: "test@example.com"},
+			Message:   "update",
+		}))
+		commitID, err := NewCommand("rev-parse", "HEAD").RunInDir(repoPath)
+		assert.NoError(t, err)
+		return strings.TrimSpace(commitID)
+	}
+
+	// content of the given size, padded with empty lines
+	content := func(header string, size int) string {
+		return header + strings.Repeat("\n", size-len(header))
+	}
+	commitID := commit(map[string]string{
+		"main.go":       content("package main\n", 100),
+		"vendor/lib.go": content("package lib\n", 200),
+		"gen.go":        content("package main\n", 400),
+		"tool.script":   content("import os\n", 300),
+		"skip.py":       content("import os\n", 100),
+	})
+
+	repo, err := OpenRepository(repoPath)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	stats, err := repo.GetLanguageStats(commitID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]float32{"Go": 83.3, "Python": 16.7}, stats)
+
+	commitID = commit(map[string]string{
+		".gitattributes": "vendor/** -linguist-vendored\n" +
+			"gen.go linguist-generated\n" +
+			"*.script linguist-language=python\n" +
+			"skip.py linguist-detectable=false\n",
+	})
+
+	stats, err = repo.GetLanguageStats(commitID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]float32{"Go": 50, "Python": 50}, stats)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/blevesearch/bleve/analysis/analyzer/custom"
 	"github.com/blevesearch/bleve/analysis/token/lowercase"
 	"github.com/blevesearch/bleve/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/analysis/tokenizer/single"
 	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/index/upsidedown"
 	"github.com/blevesearch/bleve/mapping"
@@ -86,8 +87,11 @@ func openIndexer(path string, latestVersion int) (bleve.Index, error) {
 
 // RepoIndexerData data stored in the repo indexer
 type RepoIndexerData struct {
-	RepoID  int64
-	Content string
+	RepoID   int64
+	Content  string
+	Filename string
+	Language string
+	Symbols  []string
 }
 
 // Type returns the document type, for bleve's mapping.Classifier interface.
@@ -116,10 +120,15 @@ func addUpdate(update fileUpdate, repo *models.Repository, batch rupture.Flushin
 		return nil
 	}
 
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := detectLanguage(update.Filename, fileContents)
 	id := filenameIndexerID(repo.ID, update.Filename)
 	return batch.Index(id, &RepoIndexerData{
-		RepoID:  repo.ID,
-		Content: string(charset.ToUTF8DropErrors(fileContents)),
+		RepoID:   repo.ID,
+		Content:  content,
+		Filename: update.Filename,
+		Language: language,
+		Symbols:  symbolNames(ExtractSymbols(language, content)),
 	})
 }
 
@@ -129,9 +138,10 @@ func addDelete(filename string, repo *models.Repository, batch rupture.FlushingB
 }
 
 const (
-	repoIndexerAnalyzer      = "repoIndexerAnalyzer"
-	repoIndexerDocType       = "repoIndexerDocType"
-	repoIndexerLatestVersion = 4
+	repoIndexerAnalyzer        = "repoIndexerAnalyzer"
+	repoIndexerKeywordAnalyzer = "repoIndexerKeywordAnalyzer"
+	repoIndexerDocType         = "repoIndexerDocType"
+	repoIndexerLatestVersion   = 5
 )
 
 // createRepoIndexer create a repo indexer if one does not already exist
@@ -145,6 +155,14 @@ func createRepoIndexer(path string, latestVersion int) (bleve.Index, error) {
 	textFieldMapping.IncludeInAll = false
 	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
 
+	// the file name, language and symbols are matched as a whole, ignoring case
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.IncludeInAll = false
+	keywordFieldMapping.Analyzer = repoIndexerKeywordAnalyzer
+	docMapping.AddFieldMappingsAt("Filename", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("Language", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("Symbols", keywordFieldMapping)
+
 	mapping := bleve.NewIndexMapping()
 	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
 		return nil, err
@@ -155,6 +173,13 @@ func createRepoIndexer(path string, latestVersion int) (bleve.Index, error) {
 		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
 	}); err != nil {
 		return nil, err
+	} else if err := mapping.AddCustomAnalyzer(repoIndexerKeywordAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, err
 	}
 	mapping.DefaultAnalyzer = repoIndexerAnalyzer
 	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
@@ -287,36 +312,96 @@ func (b *BleveIndexer) Delete(repoID int64) error {
 	return batch.Flush()
 }
 
-// Search searches for files in the specified repo.
-// Returns the matching file-paths
-func (b *BleveIndexer) Search(repoIDs []int64, keyword string, page, pageSize int) (int64, []*SearchResult, error) {
-	phraseQuery := bleve.NewMatchPhraseQuery(keyword)
-	phraseQuery.FieldVal = "Content"
-	phraseQuery.Analyzer = repoIndexerAnalyzer
-
-	var indexerQuery query.Query
-	if len(repoIDs) > 0 {
-		var repoQueries = make([]query.Query, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
+// termsQuery returns a query matching any of the values for the field
+func termsQuery(field string, values []string, newQuery func(value string) query.FieldableQuery) query.Query {
+	queries := make([]query.Query, 0, len(values))
+	for _, value := range values {
+		q := newQuery(value)
+		q.SetField(field)
+		queries = append(queries, q)
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// searchQuery returns the query of the options, except for the regular
+// expression and the languages if withLanguages is false
+func searchQuery(opts *SearchOptions, withLanguages bool) query.Query {
+	var queries []query.Query
+	for _, keyword := range opts.Keywords {
+		phraseQuery := bleve.NewMatchPhraseQuery(keyword)
+		phraseQuery.FieldVal = "Content"
+		phraseQuery.Analyzer = repoIndexerAnalyzer
+		queries = append(queries, phraseQuery)
+	}
+	if len(opts.RepoIDs) > 0 {
+		var repoQueries = make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
 			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
 		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	if withLanguages && len(opts.Languages) > 0 {
+		queries = append(queries, termsQuery("Language", opts.Languages, func(value string) query.FieldableQuery {
+			return bleve.NewTermQuery(value)
+		}))
+	}
+	if len(opts.Paths) > 0 {
+		queries = append(queries, termsQuery("Filename", opts.Paths, func(value string) query.FieldableQuery {
+			return bleve.NewWildcardQuery("*" + value + "*")
+		}))
+	}
+	if len(opts.Symbols) > 0 {
+		queries = append(queries, termsQuery("Symbols", opts.Symbols, func(value string) query.FieldableQuery {
+			return bleve.NewTermQuery(value)
+		}))
+	}
 
-		indexerQuery = bleve.NewConjunctionQuery(
-			bleve.NewDisjunctionQuery(repoQueries...),
-			phraseQuery,
-		)
-	} else {
-		indexerQuery = phraseQuery
+	switch len(queries) {
+	case 0:
+		return bleve.NewMatchAllQuery()
+	case 1:
+		return queries[0]
+	default:
+		return bleve.NewConjunctionQuery(queries...)
 	}
+}
+
+// Search searches for files matching the options, except for the regular
+// expression. Returns the matching file-paths and the languages facets
+func (b *BleveIndexer) Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	indexerQuery := searchQuery(opts, true)
 
 	from := (page - 1) * pageSize
 	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, pageSize, from, false)
-	searchRequest.Fields = []string{"Content", "RepoID"}
+	searchRequest.Fields = []string{"Content", "RepoID", "Language"}
 	searchRequest.IncludeLocations = true
 
+	// the languages are counted without the language filter, in a separate
+	// request if there is one
+	facetRequest := searchRequest
+	if len(opts.Languages) > 0 {
+		facetRequest = bleve.NewSearchRequestOptions(searchQuery(opts, false), 0, 0, false)
+	}
+	facetRequest.AddFacet("languages", bleve.NewFacetRequest("Language", maxLanguageFacets))
+
 	result, err := b.indexer.Search(searchRequest)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
+	}
+	facetResult := result
+	if facetRequest != searchRequest {
+		if facetResult, err = b.indexer.Search(facetRequest); err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	var languages []*SearchResultLanguages
+	if facet, ok := facetResult.Facets["languages"]; ok {
+		for _, term := range facet.Terms {
+			if len(term.Term) > 0 {
+				languages = append(languages, languageFacet(term.Term, term.Count))
+			}
+		}
 	}
 
 	searchResults := make([]*SearchResult, len(result.Hits))
@@ -333,13 +418,18 @@ func (b *BleveIndexer) Search(repoIDs []int64, keyword string, page, pageSize in
 				endIndex = locationEnd
 			}
 		}
+		if startIndex < 0 {
+			startIndex, endIndex = 0, 0
+		}
+		language, _ := hit.Fields["Language"].(string)
 		searchResults[i] = &SearchResult{
 			RepoID:     int64(hit.Fields["RepoID"].(float64)),
 			StartIndex: startIndex,
 			EndIndex:   endIndex,
 			Filename:   filenameOfIndexerID(hit.ID),
+			Language:   language,
 			Content:    hit.Fields["Content"].(string),
 		}
 	}
-	return int64(result.Total), searchResults, nil
+	return int64(result.Total), searchResults, languages, nil
 }
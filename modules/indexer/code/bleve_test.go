@@ -47,33 +47,68 @@ func TestIndexAndSearch(t *testing.T) {
 
 	var (
 		keywords = []struct {
-			Keyword string
-			IDs     []int64
+			Opts      *SearchOptions
+			IDs       []int64
+			Languages []string
 		}{
 			{
-				Keyword: "Description",
-				IDs:     []int64{1},
+				Opts:      &SearchOptions{Keywords: []string{"Description"}},
+				IDs:       []int64{1},
+				Languages: []string{"Markdown"},
 			},
 			{
-				Keyword: "repo1",
-				IDs:     []int64{1},
+				Opts:      &SearchOptions{Keywords: []string{"repo1"}},
+				IDs:       []int64{1},
+				Languages: []string{"Markdown"},
 			},
 			{
-				Keyword: "non-exist",
-				IDs:     []int64{},
+				Opts: &SearchOptions{Keywords: []string{"non-exist"}},
+				IDs:  []int64{},
+			},
+			{
+				Opts:      &SearchOptions{Keywords: []string{"repo1"}, Languages: []string{"markdown"}},
+				IDs:       []int64{1},
+				Languages: []string{"Markdown"},
+			},
+			{
+				Opts:      &SearchOptions{Keywords: []string{"repo1"}, Languages: []string{"go"}},
+				IDs:       []int64{},
+				Languages: []string{"Markdown"},
+			},
+			{
+				Opts:      &SearchOptions{Paths: []string{"readme"}},
+				IDs:       []int64{1},
+				Languages: []string{"Markdown"},
+			},
+			{
+				Opts: &SearchOptions{Paths: []string{"routers/"}},
+				IDs:  []int64{},
+			},
+			{
+				Opts: &SearchOptions{Keywords: []string{"repo1"}, RepoIDs: []int64{2}},
+				IDs:  []int64{},
 			},
 		}
 	)
 
 	for _, kw := range keywords {
-		total, res, err := idx.Search(nil, kw.Keyword, 1, 10)
+		total, res, languages, err := idx.Search(kw.Opts, 1, 10)
 		assert.NoError(t, err)
 		assert.EqualValues(t, len(kw.IDs), total)
 
 		var ids = make([]int64, 0, len(res))
 		for _, hit := range res {
 			ids = append(ids, hit.RepoID)
+			assert.Equal(t, "Markdown", hit.Language)
 		}
 		assert.EqualValues(t, kw.IDs, ids)
+
+		// the languages are counted without the language filter
+		var names []string
+		for _, language := range languages {
+			names = append(names, language.Language)
+			assert.EqualValues(t, 1, language.Count)
+		}
+		assert.EqualValues(t, kw.Languages, names)
 	}
 }
@@ -0,0 +1,385 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/charset"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	// highlight tags are private use characters, so they can't be confused
+	// with the content of the files
+	highlightPreTag  = "\ue000"
+	highlightPostTag = "\ue001"
+
+	// esRepoIndexerLatestVersion is part of the index name, so that changes to
+	// the mapping create a new index
+	esRepoIndexerLatestVersion = 1
+
+	elasticMapping = `{
+		"settings": {
+			"analysis": {
+				"normalizer": {
+					"lowercase_keyword": {
+						"type": "custom",
+						"filter": ["lowercase"]
+					}
+				}
+			}
+		},
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"index": true,
+					"term_vector": "with_positions_offsets"
+				},
+				"filename": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"language": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"symbols": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				}
+			}
+		}
+	}`
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client      *elastic.Client
+	indexerName string
+}
+
+type elasticLogger struct {
+	*log.Logger
+}
+
+func (l elasticLogger) Printf(format string, args ...interface{}) {
+	_ = l.Logger.Log(2, l.Logger.GetLevel(), format, args...)
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, creating the
+// index if it does not exist yet
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheckInterval(10 * time.Second),
+		elastic.SetGzip(false),
+	}
+
+	logger := elasticLogger{log.GetLogger(log.DEFAULT)}
+
+	if logger.GetLevel() == log.TRACE || logger.GetLevel() == log.DEBUG {
+		opts = append(opts, elastic.SetTraceLog(logger))
+	} else if logger.GetLevel() == log.ERROR || logger.GetLevel() == log.CRITICAL || logger.GetLevel() == log.FATAL {
+		opts = append(opts, elastic.SetErrorLog(logger))
+	} else if logger.GetLevel() == log.INFO || logger.GetLevel() == log.WARN {
+		opts = append(opts, elastic.SetInfoLog(logger))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:      client,
+		indexerName: fmt.Sprintf("%s.v%d", indexerName, esRepoIndexerLatestVersion),
+	}
+	created, err := indexer.init()
+	return indexer, created, err
+}
+
+// init creates the index if it does not exist
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	ctx := context.Background()
+	exists, err := b.client.IndexExists(b.indexerName).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	createIndex, err := b.client.CreateIndex(b.indexerName).BodyString(elasticMapping).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !createIndex.Acknowledged {
+		return false, errors.New("init failed")
+	}
+	return true, nil
+}
+
+// addUpdate returns the request updating a file, or nil if the file is not indexed
+func (b *ElasticSearchIndexer) addUpdate(update fileUpdate, repo *models.Repository) (elastic.BulkableRequest, error) {
+	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
+		RunInDir(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	if size, err := strconv.Atoi(strings.TrimSpace(stdout)); err != nil {
+		return nil, fmt.Errorf("Misformatted git cat-file output: %v", err)
+	} else if int64(size) > setting.Indexer.MaxIndexerFileSize {
+		return b.addDelete(update.Filename, repo), nil
+	}
+
+	fileContents, err := git.NewCommand("cat-file", "blob", update.BlobSha).
+		RunInDirBytes(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	} else if !base.IsTextFile(fileContents) {
+		// FIXME: UTF-16 files will probably fail here
+		return nil, nil
+	}
+
+	content := string(charset.ToUTF8DropErrors(fileContents))
+	language := detectLanguage(update.Filename, fileContents)
+	id := filenameIndexerID(repo.ID, update.Filename)
+	return elastic.NewBulkIndexRequest().
+		Index(b.indexerName).
+		Id(id).
+		Doc(map[string]interface{}{
+			"repo_id":  repo.ID,
+			"content":  content,
+			"filename": update.Filename,
+			"language": language,
+			"symbols":  symbolNames(ExtractSymbols(language, content)),
+		}), nil
+}
+
+func (b *ElasticSearchIndexer) addDelete(filename string, repo *models.Repository) elastic.BulkableRequest {
+	id := filenameIndexerID(repo.ID, filename)
+	return elastic.NewBulkDeleteRequest().
+		Index(b.indexerName).
+		Id(id)
+}
+
+// flush sends the requests in one bulk, waiting for them to be searchable
+func (b *ElasticSearchIndexer) flush(reqs []elastic.BulkableRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	resp, err := b.client.Bulk().
+		Index(b.indexerName).
+		Add(reqs...).
+		Refresh("wait_for").
+		Do(context.Background())
+	if err != nil {
+		return err
+	}
+	if failed := resp.Failed(); len(failed) > 0 && failed[0].Error != nil {
+		return fmt.Errorf("%s: %s", failed[0].Error.Type, failed[0].Error.Reason)
+	}
+	return nil
+}
+
+// Index indexes the changes of the default branch since the last update
+func (b *ElasticSearchIndexer) Index(repoID int64) error {
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+
+	sha, err := getDefaultBranchSha(repo)
+	if err != nil {
+		return err
+	}
+	changes, err := getRepoChanges(repo, sha)
+	if err != nil {
+		return err
+	} else if changes == nil {
+		return nil
+	}
+
+	reqs := make([]elastic.BulkableRequest, 0, maxBatchSize)
+	for _, update := range changes.Updates {
+		req, err := b.addUpdate(update, repo)
+		if err != nil {
+			return err
+		} else if req == nil {
+			continue
+		}
+		reqs = append(reqs, req)
+		if len(reqs) >= maxBatchSize {
+			if err = b.flush(reqs); err != nil {
+				return err
+			}
+			reqs = reqs[:0]
+		}
+	}
+	for _, filename := range changes.RemovedFilenames {
+		reqs = append(reqs, b.addDelete(filename, repo))
+	}
+	if err = b.flush(reqs); err != nil {
+		return err
+	}
+	return repo.UpdateIndexerStatus(models.RepoIndexerTypeCode, sha)
+}
+
+// Delete deletes all the entries of a repository
+func (b *ElasticSearchIndexer) Delete(repoID int64) error {
+	_, err := b.client.DeleteByQuery(b.indexerName).
+		Query(elastic.NewTermsQuery("repo_id", repoID)).
+		Refresh("true").
+		Do(context.Background())
+	return err
+}
+
+// highlightedSpan returns the start and end offsets of the first match in a
+// content highlighted with highlightPreTag and highlightPostTag, relative to
+// the content without the tags. Highlighted words following each other on
+// the same line, as phrase queries produce, belong to the same match.
+func highlightedSpan(highlighted string) (int, int) {
+	start, end := -1, -1
+	removed := 0
+	for pos := 0; pos < len(highlighted); {
+		i := strings.Index(highlighted[pos:], highlightPreTag)
+		if i < 0 {
+			break
+		}
+		if start >= 0 && strings.ContainsRune(highlighted[pos:pos+i], '\n') {
+			break
+		}
+		matchStart := pos + i + len(highlightPreTag)
+		j := strings.Index(highlighted[matchStart:], highlightPostTag)
+		if j < 0 {
+			break
+		}
+		if start < 0 {
+			start = pos + i - removed
+		}
+		removed += len(highlightPreTag)
+		end = matchStart + j - removed
+		removed += len(highlightPostTag)
+		pos = matchStart + j + len(highlightPostTag)
+	}
+	if start < 0 {
+		return 0, 0
+	}
+	return start, end
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value)
+	}
+	return result
+}
+
+// Search searches for files matching the options, except for the regular
+// expression. Returns the matching file-paths and the languages facets
+func (b *ElasticSearchIndexer) Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	query := elastic.NewBoolQuery()
+	for _, keyword := range opts.Keywords {
+		query = query.Must(elastic.NewMatchPhraseQuery("content", keyword))
+	}
+	if len(opts.RepoIDs) > 0 {
+		var repoStrs = make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoStrs = append(repoStrs, repoID)
+		}
+		query = query.Filter(elastic.NewTermsQuery("repo_id", repoStrs...))
+	}
+	if len(opts.Paths) > 0 {
+		pathQuery := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+		for _, path := range opts.Paths {
+			pathQuery = pathQuery.Should(elastic.NewWildcardQuery("filename", "*"+path+"*"))
+		}
+		query = query.Filter(pathQuery)
+	}
+	if len(opts.Symbols) > 0 {
+		query = query.Filter(elastic.NewTermsQuery("symbols", stringsToInterfaces(opts.Symbols)...))
+	}
+
+	search := b.client.Search().
+		Index(b.indexerName).
+		Query(query).
+		Aggregation("language", elastic.NewTermsAggregation().Field("language").Size(maxLanguageFacets)).
+		Highlight(elastic.NewHighlight().
+			Field("content").
+			NumOfFragments(0).
+			PreTags(highlightPreTag).
+			PostTags(highlightPostTag)).
+		From((page - 1) * pageSize).Size(pageSize)
+	// the languages are filtered after the aggregation, which counts all of them
+	if len(opts.Languages) > 0 {
+		search = search.PostFilter(elastic.NewTermsQuery("language", stringsToInterfaces(opts.Languages)...))
+	}
+	searchResult, err := search.Do(context.Background())
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	var languages []*SearchResultLanguages
+	if terms, found := searchResult.Aggregations.Terms("language"); found {
+		for _, bucket := range terms.Buckets {
+			if language, ok := bucket.Key.(string); ok && len(language) > 0 {
+				languages = append(languages, languageFacet(language, int(bucket.DocCount)))
+			}
+		}
+	}
+
+	searchResults := make([]*SearchResult, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		var doc struct {
+			RepoID   int64  `json:"repo_id"`
+			Content  string `json:"content"`
+			Language string `json:"language"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return 0, nil, nil, err
+		}
+
+		var startIndex, endIndex int
+		if highlighted := hit.Highlight["content"]; len(highlighted) > 0 {
+			startIndex, endIndex = highlightedSpan(highlighted[0])
+		}
+		searchResults = append(searchResults, &SearchResult{
+			RepoID:     doc.RepoID,
+			StartIndex: startIndex,
+			EndIndex:   endIndex,
+			Filename:   filenameOfIndexerID(hit.Id),
+			Language:   doc.Language,
+			Content:    doc.Content,
+		})
+	}
+	return searchResult.TotalHits(), searchResults, languages, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
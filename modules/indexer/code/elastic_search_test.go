@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightedSpan(t *testing.T) {
+	highlight := func(s string) string {
+		return highlightPreTag + s + highlightPostTag
+	}
+	for _, c := range []struct {
+		highlighted string
+		start, end  int
+	}{
+		{"no match", 0, 0},
+		{highlight("foo") + " bar", 0, 3},
+		{"a " + highlight("foo") + " " + highlight("bar") + " b", 2, 9},
+		{"a " + highlight("foo") + "\n" + highlight("foo"), 2, 5},
+		{"été " + highlight("café") + " " + highlight("crème"), 6, 18},
+	} {
+		start, end := highlightedSpan(c.highlighted)
+		assert.Equal(t, c.start, start, c.highlighted)
+		assert.Equal(t, c.end, end, c.highlighted)
+	}
+}
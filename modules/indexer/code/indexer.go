@@ -20,14 +20,25 @@ type SearchResult struct {
 	StartIndex int
 	EndIndex   int
 	Filename   string
+	Language   string
 	Content    string
 }
 
+// SearchResultLanguages is the number of files of a language matching a search
+type SearchResultLanguages struct {
+	Language string
+	Color    string
+	Count    int
+}
+
 // Indexer defines an interface to indexer issues contents
 type Indexer interface {
 	Index(repoID int64) error
 	Delete(repoID int64) error
-	Search(repoIDs []int64, keyword string, page, pageSize int) (int64, []*SearchResult, error)
+	// Search returns the files matching the options, except for the regular
+	// expression, and the number of matching files per language without the
+	// language filter
+	Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error)
 	Close()
 }
 
@@ -51,18 +62,42 @@ func Init() {
 	waitChannel := make(chan time.Duration)
 	go func() {
 		start := time.Now()
-		log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
-		bleveIndexer, created, err := NewBleveIndexer(setting.Indexer.RepoPath)
-		if err != nil {
-			if bleveIndexer != nil {
-				bleveIndexer.Close()
+		var (
+			rIndexer Indexer
+			created  bool
+			err      error
+		)
+		switch setting.Indexer.RepoType {
+		case "bleve":
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
+			var bleveIndexer *BleveIndexer
+			bleveIndexer, created, err = NewBleveIndexer(setting.Indexer.RepoPath)
+			if err != nil {
+				if bleveIndexer != nil {
+					bleveIndexer.Close()
+				}
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			}
+			rIndexer = bleveIndexer
+		case "elasticsearch":
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoConnStr)
+			rIndexer, created, err = NewElasticSearchIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at: %s Error: %v", os.Getpid(), setting.Indexer.RepoConnStr, err)
 			}
+		default:
 			cancel()
 			indexer.Close()
 			close(waitChannel)
-			log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			log.Fatal("PID: %d Unknown Repository Indexer type: %s", os.Getpid(), setting.Indexer.RepoType)
 		}
-		indexer.set(bleveIndexer)
+		indexer.set(rIndexer)
 
 		go processRepoIndexerOperationQueue(indexer)
 
@@ -0,0 +1,74 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/src-d/enry/v2"
+)
+
+// maxLanguageFacets is the maximum number of languages counted for a search
+const maxLanguageFacets = 10
+
+// detectLanguage returns the language of a file, or an empty string if it is unknown
+func detectLanguage(filename string, content []byte) string {
+	if language, ok := enry.GetLanguageByExtension(filename); ok {
+		return language
+	}
+	if language, ok := enry.GetLanguageByFilename(filename); ok {
+		return language
+	}
+	language := enry.GetLanguage(filepath.Base(filename), content)
+	if language == enry.OtherLanguage {
+		return ""
+	}
+	return language
+}
+
+// languageFacet returns the facet of a language as indexed, in lower case
+func languageFacet(indexed string, count int) *SearchResultLanguages {
+	language, ok := enry.GetLanguageByAlias(indexed)
+	if !ok {
+		language = indexed
+	}
+	return &SearchResultLanguages{
+		Language: language,
+		Color:    enry.GetColor(language),
+		Count:    count,
+	}
+}
+
+// countLanguages returns the facets of the languages of the results, the most
+// frequent first
+func countLanguages(results []*SearchResult) []*SearchResultLanguages {
+	counts := make(map[string]int)
+	for _, result := range results {
+		if len(result.Language) > 0 {
+			counts[result.Language]++
+		}
+	}
+
+	languages := make([]*SearchResultLanguages, 0, len(counts))
+	for language, count := range counts {
+		languages = append(languages, &SearchResultLanguages{
+			Language: language,
+			Color:    enry.GetColor(language),
+			Count:    count,
+		})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Count != languages[j].Count {
+			return languages[i].Count > languages[j].Count
+		}
+		return strings.ToLower(languages[i].Language) < strings.ToLower(languages[j].Language)
+	})
+	if len(languages) > maxLanguageFacets {
+		languages = languages[:maxLanguageFacets]
+	}
+	return languages
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidQuery represents a code search query which can't be parsed
+type ErrInvalidQuery struct {
+	Reason string
+}
+
+// IsErrInvalidQuery checks if an error is a ErrInvalidQuery
+func IsErrInvalidQuery(err error) bool {
+	_, ok := err.(ErrInvalidQuery)
+	return ok
+}
+
+func (err ErrInvalidQuery) Error() string {
+	return fmt.Sprintf("invalid query: %s", err.Reason)
+}
+
+// SearchOptions are the conditions of a code search
+type SearchOptions struct {
+	// RepoIDs restricts the search to these repositories, nil means all
+	// repositories
+	RepoIDs []int64
+	// Keywords are words or phrases which must all appear in the files
+	Keywords []string
+	// Regexp must match the content of the files
+	Regexp *regexp.Regexp
+	// Languages, Paths, Repos and Symbols are filters, a file has to match
+	// one of the values of each non empty filter
+	Languages []string
+	Paths     []string
+	Repos     []string
+	Symbols   []string
+}
+
+// IsEmpty returns true if the options have no condition on the files
+func (opts *SearchOptions) IsEmpty() bool {
+	return len(opts.Keywords) == 0 && opts.Regexp == nil && len(opts.Languages) == 0 &&
+		len(opts.Paths) == 0 && len(opts.Repos) == 0 && len(opts.Symbols) == 0
+}
+
+// ParseQuery parses a code search query. Besides words, a query may contain
+// "exact phrases", a /regular expression/ and the filters lang:, path:,
+// repo: (as owner/name) and sym: to search for the definition of a symbol.
+func ParseQuery(query string) (*SearchOptions, error) {
+	opts := &SearchOptions{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		switch runes[i] {
+		case '"', '/':
+			end := closingDelimiter(runes, i)
+			if end < 0 {
+				break
+			}
+			value := string(runes[i+1 : end])
+			i = end + 1
+			if len(strings.TrimSpace(value)) == 0 {
+				continue
+			}
+			if runes[end] == '"' {
+				opts.Keywords = append(opts.Keywords, strings.ReplaceAll(value, `\"`, `"`))
+				continue
+			}
+			if opts.Regexp != nil {
+				return nil, ErrInvalidQuery{Reason: "only one regular expression is allowed"}
+			}
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return nil, ErrInvalidQuery{Reason: err.Error()}
+			}
+			opts.Regexp = re
+			continue
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		term := string(runes[start:i])
+		if idx := strings.IndexByte(term, ':'); idx > 0 && idx < len(term)-1 {
+			value := term[idx+1:]
+			switch strings.ToLower(term[:idx]) {
+			case "lang":
+				opts.Languages = append(opts.Languages, strings.ToLower(value))
+				continue
+			case "path":
+				opts.Paths = append(opts.Paths, strings.ToLower(value))
+				continue
+			case "repo":
+				opts.Repos = append(opts.Repos, value)
+				continue
+			case "sym":
+				opts.Symbols = append(opts.Symbols, strings.ToLower(value))
+				continue
+			}
+		}
+		opts.Keywords = append(opts.Keywords, term)
+	}
+	return opts, nil
+}
+
+// closingDelimiter returns the index of the delimiter closing the one at
+// start, skipping delimiters escaped with a backslash, or -1 if there is none
+func closingDelimiter(runes []rune, start int) int {
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case runes[start]:
+			return i
+		}
+	}
+	return -1
+}
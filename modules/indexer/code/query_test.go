@@ -0,0 +1,45 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	opts, err := ParseQuery(`lang:Go path:services/ repo:org/name "exact  phrase" Deliver sym:Notify`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exact  phrase", "Deliver"}, opts.Keywords)
+	assert.Equal(t, []string{"go"}, opts.Languages)
+	assert.Equal(t, []string{"services/"}, opts.Paths)
+	assert.Equal(t, []string{"org/name"}, opts.Repos)
+	assert.Equal(t, []string{"notify"}, opts.Symbols)
+	assert.Nil(t, opts.Regexp)
+	assert.False(t, opts.IsEmpty())
+
+	opts, err = ParseQuery(`/func \w+\(ctx \*context\.Context\)/ lang:go lang:c`)
+	assert.NoError(t, err)
+	assert.Empty(t, opts.Keywords)
+	assert.Equal(t, []string{"go", "c"}, opts.Languages)
+	if assert.NotNil(t, opts.Regexp) {
+		assert.True(t, opts.Regexp.MatchString("func Home(ctx *context.Context)"))
+	}
+
+	// unknown filters and unterminated quotes are words
+	opts, err = ParseQuery(`http://example.com "unterminated`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://example.com", `"unterminated`}, opts.Keywords)
+
+	opts, err = ParseQuery(`  "" `)
+	assert.NoError(t, err)
+	assert.True(t, opts.IsEmpty())
+
+	_, err = ParseQuery(`/[a-/`)
+	assert.True(t, IsErrInvalidQuery(err))
+	_, err = ParseQuery(`/a/ /b/`)
+	assert.True(t, IsErrInvalidQuery(err))
+}
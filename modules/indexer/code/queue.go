@@ -16,6 +16,7 @@ import (
 type repoIndexerOperation struct {
 	repoID   int64
 	deleted  bool
+	reindex  bool
 	watchers []chan<- error
 }
 
@@ -34,6 +35,10 @@ func processRepoIndexerOperationQueue(indexer Indexer) {
 				if err = indexer.Delete(op.repoID); err != nil {
 					log.Error("indexer.Delete: %v", err)
 				}
+			} else if op.reindex {
+				if err = reindexRepo(indexer, op.repoID); err != nil {
+					log.Error("reindexRepo: %v", err)
+				}
 			} else {
 				if err = indexer.Index(op.repoID); err != nil {
 					log.Error("indexer.Index: %v", err)
@@ -59,6 +64,36 @@ func UpdateRepoIndexer(repo *models.Repository, watchers ...chan<- error) {
 	addOperationToQueue(repoIndexerOperation{repoID: repo.ID, deleted: false, watchers: watchers})
 }
 
+// ReindexRepo drops a repository's entries from the indexer and indexes it again
+// from scratch
+func ReindexRepo(repo *models.Repository, watchers ...chan<- error) {
+	addOperationToQueue(repoIndexerOperation{repoID: repo.ID, reindex: true, watchers: watchers})
+}
+
+// ReindexAllRepos drops the entries of all repositories from the indexer and
+// indexes them again in the background
+func ReindexAllRepos() {
+	if !setting.Indexer.RepoIndexerEnabled {
+		return
+	}
+	go func() {
+		log.Info("Reindexing all repositories")
+		if err := queueExistingRepos(true); err != nil {
+			log.Error("ReindexAllRepos: %v", err)
+		}
+	}()
+}
+
+func reindexRepo(indexer Indexer, repoID int64) error {
+	if err := indexer.Delete(repoID); err != nil {
+		return err
+	}
+	if err := models.DeleteIndexerStatus(repoID, models.RepoIndexerTypeCode); err != nil {
+		return err
+	}
+	return indexer.Index(repoID)
+}
+
 func addOperationToQueue(op repoIndexerOperation) {
 	if !setting.Indexer.RepoIndexerEnabled {
 		return
@@ -77,26 +112,39 @@ func addOperationToQueue(op repoIndexerOperation) {
 // should only be run when the indexer is created for the first time.
 func populateRepoIndexer() {
 	log.Info("Populating the repo indexer with existing repositories")
+	if err := queueExistingRepos(false); err != nil {
+		log.Fatal("System error: %v", err)
+	}
+}
 
+// queueExistingRepos queues an update of every repository, dropping their
+// previous entries first if reindex is set
+func queueExistingRepos(reindex bool) error {
 	isShutdown := graceful.GetManager().IsShutdown()
 
 	exist, err := models.IsTableNotEmpty("repository")
 	if err != nil {
-		log.Fatal("System error: %v", err)
+		return err
 	} else if !exist {
-		return
+		return nil
 	}
 
 	// if there is any existing repo indexer metadata in the DB, delete it
 	// since we are starting afresh. Also, xorm requires deletes to have a
-	// condition, and we want to delete everything, thus 1=1.
-	if err := models.DeleteAllRecords("repo_indexer_status"); err != nil {
-		log.Fatal("System error: %v", err)
+	// condition, and we want to delete everything, thus 1=1. A reindex only
+	// forgets the status of the code indexer.
+	if reindex {
+		err = models.DeleteAllIndexerStatus(models.RepoIndexerTypeCode)
+	} else {
+		err = models.DeleteAllRecords("repo_indexer_status")
+	}
+	if err != nil {
+		return err
 	}
 
 	var maxRepoID int64
 	if maxRepoID, err = models.GetMaxID("repository"); err != nil {
-		log.Fatal("System error: %v", err)
+		return err
 	}
 
 	// start with the maximum existing repo ID and work backwards, so that we
@@ -106,13 +154,12 @@ func populateRepoIndexer() {
 		select {
 		case <-isShutdown:
 			log.Info("Repository Indexer population shutdown before completion")
-			return
+			return nil
 		default:
 		}
 		ids, err := models.GetUnindexedRepos(models.RepoIndexerTypeCode, maxRepoID, 0, 50)
 		if err != nil {
-			log.Error("populateRepoIndexer: %v", err)
-			return
+			return err
 		} else if len(ids) == 0 {
 			break
 		}
@@ -120,15 +167,16 @@ func populateRepoIndexer() {
 			select {
 			case <-isShutdown:
 				log.Info("Repository Indexer population shutdown before completion")
-				return
+				return nil
 			default:
 			}
 			repoIndexerOperationQueue <- repoIndexerOperation{
 				repoID:  id,
-				deleted: false,
+				reindex: reindex,
 			}
 			maxRepoID = id - 1
 		}
 	}
 	log.Info("Done (re)populating the repo indexer with existing repositories")
+	return nil
 }
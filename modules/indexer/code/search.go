@@ -10,16 +10,28 @@ import (
 	gotemplate "html/template"
 	"strings"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/util"
 )
 
+const (
+	// regexpBatchSize is the number of files fetched at once from the indexer
+	// to be matched against a regular expression
+	regexpBatchSize = 50
+	// maxRegexpCandidates is the maximum number of files matched against a
+	// regular expression for a search
+	maxRegexpCandidates = 1000
+)
+
 // Result a search result to display
 type Result struct {
 	RepoID         int64
 	Filename       string
+	Language       string
 	HighlightClass string
 	LineNumbers    []int
+	Lines          []string
 	FormattedLines gotemplate.HTML
 }
 
@@ -66,6 +78,7 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 
 	contentLines := strings.SplitAfter(result.Content[startIndex:endIndex], "\n")
 	lineNumbers := make([]int, len(contentLines))
+	lines := make([]string, len(contentLines))
 	index := startIndex
 	for i, line := range contentLines {
 		var err error
@@ -95,36 +108,116 @@ func searchResult(result *SearchResult, startIndex, endIndex int) (*Result, erro
 		}
 
 		lineNumbers[i] = startLineNum + i
+		lines[i] = strings.TrimSuffix(line, "\n")
 		index += len(line)
 	}
 	return &Result{
 		RepoID:         result.RepoID,
 		Filename:       result.Filename,
+		Language:       result.Language,
 		HighlightClass: highlight.FileNameToHighlightClass(result.Filename),
 		LineNumbers:    lineNumbers,
+		Lines:          lines,
 		FormattedLines: gotemplate.HTML(formattedLinesBuffer.String()),
 	}, nil
 }
 
-// PerformSearch perform a search on a repository
-func PerformSearch(repoIDs []int64, keyword string, page, pageSize int) (int, []*Result, error) {
-	if len(keyword) == 0 {
-		return 0, nil, nil
+// searchRegexp searches for the files matching the regular expression of the
+// options among the files the indexer finds for the other options
+func searchRegexp(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
+	// the languages are filtered after counting them, as the indexer does
+	candidateOpts := *opts
+	candidateOpts.Languages = nil
+
+	var matches []*SearchResult
+	for candidates := 0; candidates < maxRegexpCandidates; {
+		total, results, _, err := indexer.Search(&candidateOpts, candidates/regexpBatchSize+1, regexpBatchSize)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		for _, result := range results {
+			if loc := opts.Regexp.FindStringIndex(result.Content); loc != nil {
+				result.StartIndex, result.EndIndex = loc[0], loc[1]
+				matches = append(matches, result)
+			}
+		}
+		candidates += len(results)
+		if len(results) < regexpBatchSize || int64(candidates) >= total {
+			break
+		}
+	}
+
+	languages := countLanguages(matches)
+	if len(opts.Languages) > 0 {
+		filtered := matches[:0]
+		for _, match := range matches {
+			if util.IsStringInSlice(strings.ToLower(match.Language), opts.Languages) {
+				filtered = append(filtered, match)
+			}
+		}
+		matches = filtered
 	}
 
-	total, results, err := indexer.Search(repoIDs, keyword, page, pageSize)
+	start := util.Min((page-1)*pageSize, len(matches))
+	end := util.Min(start+pageSize, len(matches))
+	return int64(len(matches)), matches[start:end], languages, nil
+}
+
+// locateSymbol moves the match of a result to the definition of one of the
+// searched symbols
+func locateSymbol(result *SearchResult, symbols []string) {
+	for _, symbol := range ExtractSymbols(result.Language, result.Content) {
+		for _, name := range symbols {
+			if strings.EqualFold(symbol.Name, name) {
+				result.StartIndex, result.EndIndex = symbol.Start, symbol.End
+				return
+			}
+		}
+	}
+}
+
+// PerformSearch performs a code search query (see ParseQuery) on the given
+// repositories, nil meaning all repositories. A non empty language replaces
+// the lang: filters of the query. Besides the results, it returns the number
+// of matching files per language, ignoring the language filters.
+func PerformSearch(repoIDs []int64, language, query string, page, pageSize int) (int, []*Result, []*SearchResultLanguages, error) {
+	opts, err := ParseQuery(query)
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
+	} else if opts.IsEmpty() {
+		return 0, nil, nil, nil
+	}
+	if len(language) > 0 {
+		opts.Languages = []string{strings.ToLower(language)}
+	}
+	if opts.RepoIDs, err = models.FilterRepositoryIDsByFullNames(repoIDs, opts.Repos); err != nil || (opts.RepoIDs != nil && len(opts.RepoIDs) == 0) {
+		return 0, nil, nil, err
 	}
 
-	displayResults := make([]*Result, len(results))
+	var (
+		total     int64
+		results   []*SearchResult
+		languages []*SearchResultLanguages
+	)
+	if opts.Regexp != nil {
+		total, results, languages, err = searchRegexp(opts, page, pageSize)
+	} else {
+		total, results, languages, err = indexer.Search(opts, page, pageSize)
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
 
+	displayResults := make([]*Result, len(results))
 	for i, result := range results {
+		if len(opts.Symbols) > 0 && len(opts.Keywords) == 0 && opts.Regexp == nil {
+			locateSymbol(result, opts.Symbols)
+		}
 		startIndex, endIndex := indices(result.Content, result.StartIndex, result.EndIndex)
 		displayResults[i], err = searchResult(result, startIndex, endIndex)
 		if err != nil {
-			return 0, nil, err
+			return 0, nil, nil, err
 		}
 	}
-	return int(total), displayResults, nil
+	return int(total), displayResults, languages, nil
 }
@@ -0,0 +1,117 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Symbol is the definition of a function, type or class in a file
+type Symbol struct {
+	Name string
+	// Start and End are the offsets of the name in the file
+	Start int
+	End   int
+}
+
+// symbolPatterns are the patterns of the definitions for each language, the
+// first group of each pattern is the name of the symbol. They don't try to
+// be exhaustive, only to find the definitions written the usual way.
+var symbolPatterns = map[string][]*regexp.Regexp{
+	"Go": {
+		regexp.MustCompile(`(?m)^func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*type\s+([A-Za-z_]\w*)`),
+	},
+	"Python": {
+		regexp.MustCompile(`(?m)^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*class\s+([A-Za-z_]\w*)`),
+	},
+	"JavaScript": {
+		regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`),
+		regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?class\s+([A-Za-z_$][\w$]*)`),
+	},
+	"TypeScript": {
+		regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`),
+		regexp.MustCompile(`(?m)^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?(?:class|interface|enum|type)\s+([A-Za-z_$][\w$]*)`),
+	},
+	"Java": {
+		regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|static|final|abstract|sealed)\s+)*(?:class|interface|enum|record|@interface)\s+([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|static|final|abstract|synchronized|native|default)\s+)+[\w<>\[\],.?\s]*?\s([A-Za-z_]\w*)\s*\(`),
+	},
+	"C#": {
+		regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|internal|static|abstract|sealed|partial)\s+)*(?:class|interface|enum|struct|record)\s+([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|internal|static|virtual|override|abstract|async|extern)\s+)+[\w<>\[\],.?\s]*?\s([A-Za-z_]\w*)\s*\(`),
+	},
+	"C": {
+		regexp.MustCompile(`(?m)^(?:typedef\s+)?(?:struct|enum|union)\s+([A-Za-z_]\w*)\s*\{`),
+		regexp.MustCompile(`(?m)^[A-Za-z_][\w \t\*]*?[\s\*]([A-Za-z_]\w*)\s*\([^;{]*\)\s*\{?\s*$`),
+	},
+	"C++": {
+		regexp.MustCompile(`(?m)^\s*(?:template\s*<[^>]*>\s*)?(?:class|struct|enum(?:\s+class)?|union|namespace)\s+([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^[A-Za-z_][\w \t\*&:<>,]*?[\s\*&:]([A-Za-z_]\w*)\s*\([^;{]*\)\s*(?:const\s*)?\{?\s*$`),
+	},
+	"Rust": {
+		regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?(?:async\s+)?(?:unsafe\s+)?(?:const\s+)?fn\s+([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|trait|type|union|mod)\s+([A-Za-z_]\w*)`),
+	},
+	"Ruby": {
+		regexp.MustCompile(`(?m)^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`),
+		regexp.MustCompile(`(?m)^\s*(?:class|module)\s+([A-Z]\w*)`),
+	},
+	"PHP": {
+		regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|private|static|abstract|final)\s+)*function\s+&?\s*([A-Za-z_]\w*)`),
+		regexp.MustCompile(`(?m)^\s*(?:(?:abstract|final)\s+)?(?:class|interface|trait)\s+([A-Za-z_]\w*)`),
+	},
+}
+
+// symbolKeywords are names the patterns may match which are never symbols
+var symbolKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "return": true,
+	"catch": true, "sizeof": true, "new": true, "else": true,
+}
+
+// ExtractSymbols returns the symbols defined in the content of a file of the
+// given language, in the order they appear
+func ExtractSymbols(language, content string) []Symbol {
+	patterns, ok := symbolPatterns[language]
+	if !ok {
+		return nil
+	}
+
+	var symbols []Symbol
+	seen := make(map[int]bool)
+	for _, pattern := range patterns {
+		for _, loc := range pattern.FindAllStringSubmatchIndex(content, -1) {
+			start, end := loc[2], loc[3]
+			name := content[start:end]
+			if seen[start] || symbolKeywords[name] {
+				continue
+			}
+			seen[start] = true
+			symbols = append(symbols, Symbol{Name: name, Start: start, End: end})
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].Start < symbols[j].Start
+	})
+	return symbols
+}
+
+// symbolNames returns the distinct names of the symbols in lower case, as
+// they are indexed
+func symbolNames(symbols []Symbol) []string {
+	names := make([]string, 0, len(symbols))
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		name := strings.ToLower(symbol.Name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
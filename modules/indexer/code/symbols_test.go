@@ -0,0 +1,62 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractSymbols(t *testing.T) {
+	names := func(language, content string) []string {
+		var names []string
+		for _, symbol := range ExtractSymbols(language, content) {
+			assert.Equal(t, symbol.Name, content[symbol.Start:symbol.End])
+			names = append(names, symbol.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Mailer", "Deliver", "newMailer"}, names("Go", `package mail
+
+type Mailer struct{}
+
+func (m *Mailer) Deliver(msg string) error {
+	if len(msg) == 0 {
+		return nil
+	}
+	return nil
+}
+
+func newMailer() *Mailer { return &Mailer{} }
+`))
+	assert.Equal(t, []string{"Client", "fetch", "run"}, names("Python", `class Client:
+    async def fetch(self):
+        pass
+
+def run():
+    pass
+`))
+	assert.Equal(t, []string{"parse", "Parser"}, names("JavaScript", `export function parse(text) {}
+export default class Parser {}
+`))
+	assert.Equal(t, []string{"Config", "load"}, names("Rust", `pub struct Config {}
+pub(crate) fn load() -> Config {}
+`))
+	assert.Equal(t, []string{"list", "main"}, names("C", `struct list {
+	int len;
+};
+
+int main(int argc, char **argv)
+{
+	if (argc > 1)
+		return 1;
+	return 0;
+}
+`))
+	assert.Empty(t, names("Markdown", "# func Title"))
+	assert.Equal(t, []string{"deliver"}, symbolNames([]Symbol{{Name: "Deliver"}, {Name: "deliver"}}))
+}
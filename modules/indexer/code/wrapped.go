@@ -71,12 +71,12 @@ func (w *wrappedIndexer) Delete(repoID int64) error {
 	return indexer.Delete(repoID)
 }
 
-func (w *wrappedIndexer) Search(repoIDs []int64, keyword string, page, pageSize int) (int64, []*SearchResult, error) {
+func (w *wrappedIndexer) Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, []*SearchResultLanguages, error) {
 	indexer, err := w.get()
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, nil, err
 	}
-	return indexer.Search(repoIDs, keyword, page, pageSize)
+	return indexer.Search(opts, page, pageSize)
 
 }
 
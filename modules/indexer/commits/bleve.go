@@ -0,0 +1,355 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/unicodenorm"
+	"github.com/blevesearch/bleve/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/index/upsidedown"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	unicodeNormalizeName         = "unicodeNormalize"
+	maxBatchSize                 = 16
+	commitIndexerAnalyzer        = "commitIndexerAnalyzer"
+	commitIndexerKeywordAnalyzer = "commitIndexerKeywordAnalyzer"
+	commitIndexerDocType         = "commitIndexerDocType"
+	commitIndexerLatestVersion   = 1
+)
+
+// commitIndexerID a bleve-compatible unique identifier for a commit of a repository
+func commitIndexerID(repoID int64, sha string) string {
+	return strconv.FormatInt(repoID, 36) + "_" + sha
+}
+
+// numericEqualityQuery a numeric equality query for the given value and field
+func numericEqualityQuery(value int64, field string) *query.NumericRangeQuery {
+	f := float64(value)
+	tru := true
+	q := bleve.NewNumericRangeInclusiveQuery(&f, &f, &tru, &tru)
+	q.SetField(field)
+	return q
+}
+
+func addUnicodeNormalizeTokenFilter(m *mapping.IndexMappingImpl) error {
+	return m.AddCustomTokenFilter(unicodeNormalizeName, map[string]interface{}{
+		"type": unicodenorm.Name,
+		"form": unicodenorm.NFC,
+	})
+}
+
+// openIndexer open the index at the specified path, checking for metadata
+// updates and bleve version updates.  If index needs to be created (or
+// re-created), returns (nil, nil)
+func openIndexer(path string, latestVersion int) (bleve.Index, error) {
+	_, err := os.Stat(path)
+	if err != nil && os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	metadata, err := rupture.ReadIndexMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Version < latestVersion {
+		// the indexer is using a previous version, so we should delete it and
+		// re-populate
+		return nil, os.RemoveAll(path)
+	}
+
+	index, err := bleve.Open(path)
+	if err != nil && err == upsidedown.IncompatibleVersion {
+		// the indexer was built with a previous version of bleve, so we should
+		// delete it and re-populate
+		return nil, os.RemoveAll(path)
+	} else if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// BleveCommitData is a commit as stored in the bleve index
+type BleveCommitData CommitData
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (d *BleveCommitData) Type() string {
+	return commitIndexerDocType
+}
+
+// createCommitIndexer create a commit indexer if one does not already exist
+func createCommitIndexer(path string, latestVersion int) (bleve.Index, error) {
+	docMapping := bleve.NewDocumentMapping()
+
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorUnix", numericFieldMapping)
+	docMapping.AddFieldMappingsAt("CommitterUnix", numericFieldMapping)
+
+	unstoredNumericFieldMapping := bleve.NewNumericFieldMapping()
+	unstoredNumericFieldMapping.IncludeInAll = false
+	unstoredNumericFieldMapping.Store = false
+	docMapping.AddFieldMappingsAt("Issues", unstoredNumericFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Message", textFieldMapping)
+
+	// the commit ID, names, emails and paths are matched as a whole, ignoring case
+	keywordFieldMapping := bleve.NewTextFieldMapping()
+	keywordFieldMapping.IncludeInAll = false
+	keywordFieldMapping.Analyzer = commitIndexerKeywordAnalyzer
+	docMapping.AddFieldMappingsAt("SHA", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorName", keywordFieldMapping)
+	docMapping.AddFieldMappingsAt("AuthorEmail", keywordFieldMapping)
+
+	unstoredKeywordFieldMapping := bleve.NewTextFieldMapping()
+	unstoredKeywordFieldMapping.IncludeInAll = false
+	unstoredKeywordFieldMapping.Store = false
+	unstoredKeywordFieldMapping.Analyzer = commitIndexerKeywordAnalyzer
+	docMapping.AddFieldMappingsAt("CommitterName", unstoredKeywordFieldMapping)
+	docMapping.AddFieldMappingsAt("CommitterEmail", unstoredKeywordFieldMapping)
+	docMapping.AddFieldMappingsAt("Paths", unstoredKeywordFieldMapping)
+
+	mapping := bleve.NewIndexMapping()
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err := mapping.AddCustomAnalyzer(commitIndexerAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	} else if err := mapping.AddCustomAnalyzer(commitIndexerKeywordAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+	mapping.DefaultAnalyzer = commitIndexerAnalyzer
+	mapping.AddDocumentMapping(commitIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	indexer, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	}); err != nil {
+		return nil, err
+	}
+	return indexer, nil
+}
+
+var (
+	_ Indexer = &BleveIndexer{}
+)
+
+// BleveIndexer implements Indexer interface
+type BleveIndexer struct {
+	indexDir string
+	indexer  bleve.Index
+}
+
+// NewBleveIndexer creates a new bleve local indexer, returning whether the
+// index was created
+func NewBleveIndexer(indexDir string) (*BleveIndexer, bool, error) {
+	indexer := &BleveIndexer{
+		indexDir: indexDir,
+	}
+	created, err := indexer.init()
+	return indexer, created, err
+}
+
+// init opens the index, or creates it if it does not exist yet
+func (b *BleveIndexer) init() (bool, error) {
+	var err error
+	b.indexer, err = openIndexer(b.indexDir, commitIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.indexer != nil {
+		return false, nil
+	}
+
+	b.indexer, err = createCommitIndexer(b.indexDir, commitIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Close closes the indexer
+func (b *BleveIndexer) Close() {
+	if b.indexer != nil {
+		if err := b.indexer.Close(); err != nil {
+			log.Error("Error whilst closing the commit indexer: %v", err)
+		}
+	}
+}
+
+// Index indexes the commits
+func (b *BleveIndexer) Index(commits []*CommitData) error {
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	for _, commit := range commits {
+		if err := batch.Index(commitIndexerID(commit.RepoID, commit.SHA), (*BleveCommitData)(commit)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete deletes the given commits of a repository, all of them if none is given
+func (b *BleveIndexer) Delete(repoID int64, shas ...string) error {
+	batch := rupture.NewFlushingBatch(b.indexer, maxBatchSize)
+	if len(shas) > 0 {
+		for _, sha := range shas {
+			if err := batch.Delete(commitIndexerID(repoID, sha)); err != nil {
+				return err
+			}
+		}
+		return batch.Flush()
+	}
+
+	searchRequest := bleve.NewSearchRequestOptions(numericEqualityQuery(repoID, "RepoID"), 2147483647, 0, false)
+	result, err := b.indexer.Search(searchRequest)
+	if err != nil {
+		return err
+	}
+	for _, hit := range result.Hits {
+		if err = batch.Delete(hit.ID); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// wildcardsQuery returns a query matching any of the values anywhere in any
+// of the fields
+func wildcardsQuery(values []string, fields ...string) query.Query {
+	queries := make([]query.Query, 0, len(values)*len(fields))
+	for _, value := range values {
+		for _, field := range fields {
+			q := bleve.NewWildcardQuery("*" + value + "*")
+			q.SetField(field)
+			queries = append(queries, q)
+		}
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// searchQuery returns the query of the search options
+func searchQuery(opts *SearchOptions) query.Query {
+	var queries []query.Query
+	for _, keyword := range opts.Keywords {
+		phraseQuery := bleve.NewMatchPhraseQuery(keyword)
+		phraseQuery.FieldVal = "Message"
+		phraseQuery.Analyzer = commitIndexerAnalyzer
+		if !isSHAPrefix(keyword) {
+			queries = append(queries, phraseQuery)
+			continue
+		}
+		shaQuery := bleve.NewPrefixQuery(strings.ToLower(keyword))
+		shaQuery.SetField("SHA")
+		queries = append(queries, bleve.NewDisjunctionQuery(phraseQuery, shaQuery))
+	}
+	if len(opts.RepoIDs) > 0 {
+		repoQueries := make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	if len(opts.Authors) > 0 {
+		queries = append(queries, wildcardsQuery(opts.Authors, "AuthorName", "AuthorEmail"))
+	}
+	if len(opts.Committers) > 0 {
+		queries = append(queries, wildcardsQuery(opts.Committers, "CommitterName", "CommitterEmail"))
+	}
+	if len(opts.Paths) > 0 {
+		queries = append(queries, wildcardsQuery(opts.Paths, "Paths"))
+	}
+	for _, index := range opts.Issues {
+		queries = append(queries, numericEqualityQuery(index, "Issues"))
+	}
+	if !opts.After.IsZero() || !opts.Before.IsZero() {
+		var min, max *float64
+		if !opts.After.IsZero() {
+			after := float64(opts.After.Unix())
+			min = &after
+		}
+		if !opts.Before.IsZero() {
+			before := float64(opts.Before.Unix())
+			max = &before
+		}
+		dateQuery := bleve.NewNumericRangeQuery(min, max)
+		dateQuery.SetField("CommitterUnix")
+		queries = append(queries, dateQuery)
+	}
+
+	switch len(queries) {
+	case 0:
+		return bleve.NewMatchAllQuery()
+	case 1:
+		return queries[0]
+	default:
+		return bleve.NewConjunctionQuery(queries...)
+	}
+}
+
+// Search searches for commits matching the options, the most recently
+// committed first
+func (b *BleveIndexer) Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, error) {
+	searchRequest := bleve.NewSearchRequestOptions(searchQuery(opts), pageSize, (page-1)*pageSize, false)
+	searchRequest.Fields = []string{"RepoID", "SHA", "Message", "AuthorName", "AuthorEmail", "AuthorUnix", "CommitterUnix"}
+	searchRequest.SortBy([]string{"-CommitterUnix", "-_score"})
+
+	result, err := b.indexer.Search(searchRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results := make([]*SearchResult, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		stringField := func(name string) string {
+			value, _ := hit.Fields[name].(string)
+			return value
+		}
+		numericField := func(name string) int64 {
+			value, _ := hit.Fields[name].(float64)
+			return int64(value)
+		}
+		results = append(results, &SearchResult{
+			RepoID:        numericField("RepoID"),
+			SHA:           stringField("SHA"),
+			Message:       stringField("Message"),
+			AuthorName:    stringField("AuthorName"),
+			AuthorEmail:   stringField("AuthorEmail"),
+			AuthorUnix:    numericField("AuthorUnix"),
+			CommitterUnix: numericField("CommitterUnix"),
+		})
+	}
+	return int64(result.Total), results, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	models.MainTest(m, filepath.Join("..", "..", ".."))
+}
+
+func TestIndexAndSearch(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	dir, err := ioutil.TempDir("", "bleve.index")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	idx, created, err := NewBleveIndexer(dir)
+	if err != nil {
+		assert.Fail(t, "Unable to create indexer Error: %v", err)
+		if idx != nil {
+			idx.Close()
+		}
+		return
+	}
+	defer idx.Close()
+	assert.True(t, created)
+
+	search := func(query string) []string {
+		opts, err := ParseQuery(query)
+		assert.NoError(t, err)
+		opts.RepoIDs = []int64{1}
+		total, results, err := idx.Search(opts, 1, 10)
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(results), total)
+		shas := make([]string, 0, len(results))
+		for _, result := range results {
+			assert.EqualValues(t, 1, result.RepoID)
+			shas = append(shas, result.SHA[:7])
+		}
+		return shas
+	}
+
+	// index the repository as if only the initial commit had been indexed
+	initialCommit := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	assert.NoError(t, models.UpdateCommitIndexerStatus(&models.CommitIndexerStatus{
+		RepoID:      1,
+		BranchHeads: []string{initialCommit},
+	}))
+	assert.NoError(t, indexRepo(idx, 1, false))
+	assert.Equal(t, []string{"62fb502", "985f030", "5c050d3"}, search("author:6543"))
+	assert.Empty(t, search("Initial"))
+
+	// a reindex starts from scratch
+	assert.NoError(t, indexRepo(idx, 1, true))
+	status, err := models.GetCommitIndexerStatus(1)
+	assert.NoError(t, err)
+	assert.Contains(t, status.BranchHeads, "62fb502a7172d4453f0322a2cc85bddffa57f07a")
+
+	for query, shas := range map[string][]string{
+		"change":                       {"5c050d3"},
+		`"make pull5"`:                 {"985f030"},
+		"65f1bf27":                     {"65f1bf2"},
+		"author:example.com":           {"65f1bf2"},
+		"committer:koenig":             {"65f1bf2"},
+		"path:readme":                  {"985f030", "5c050d3", "65f1bf2"},
+		"path:readme after:2020-01-01": {"985f030", "5c050d3"},
+		"before:2020-01-01":            {"65f1bf2"},
+		"path:wow":                     {"62fb502"},
+		"author:nobody":                {},
+		"non-exist":                    {},
+	} {
+		assert.Equal(t, shas, search(query), query)
+	}
+
+	assert.NoError(t, idx.Delete(1, "62fb502a7172d4453f0322a2cc85bddffa57f07a"))
+	assert.Equal(t, []string{"985f030", "5c050d3"}, search("author:6543"))
+	assert.NoError(t, idx.Delete(1))
+	assert.Empty(t, search("path:readme"))
+}
@@ -0,0 +1,314 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const (
+	// esCommitIndexerLatestVersion is part of the index name, so that changes
+	// to the mapping create a new index
+	esCommitIndexerLatestVersion = 1
+
+	elasticMapping = `{
+		"settings": {
+			"analysis": {
+				"normalizer": {
+					"lowercase_keyword": {
+						"type": "custom",
+						"filter": ["lowercase"]
+					}
+				}
+			}
+		},
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"sha": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"message": {
+					"type": "text",
+					"index": true
+				},
+				"author_name": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"author_email": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"author_unix": {
+					"type": "long",
+					"index": true
+				},
+				"committer_name": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"committer_email": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"committer_unix": {
+					"type": "long",
+					"index": true
+				},
+				"paths": {
+					"type": "keyword",
+					"normalizer": "lowercase_keyword"
+				},
+				"issues": {
+					"type": "long",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client      *elastic.Client
+	indexerName string
+}
+
+type elasticLogger struct {
+	*log.Logger
+}
+
+func (l elasticLogger) Printf(format string, args ...interface{}) {
+	_ = l.Logger.Log(2, l.Logger.GetLevel(), format, args...)
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, creating the
+// index if it does not exist yet
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	opts := []elastic.ClientOptionFunc{
+		elastic.SetURL(url),
+		elastic.SetSniff(false),
+		elastic.SetHealthcheckInterval(10 * time.Second),
+		elastic.SetGzip(false),
+	}
+
+	logger := elasticLogger{log.GetLogger(log.DEFAULT)}
+
+	if logger.GetLevel() == log.TRACE || logger.GetLevel() == log.DEBUG {
+		opts = append(opts, elastic.SetTraceLog(logger))
+	} else if logger.GetLevel() == log.ERROR || logger.GetLevel() == log.CRITICAL || logger.GetLevel() == log.FATAL {
+		opts = append(opts, elastic.SetErrorLog(logger))
+	} else if logger.GetLevel() == log.INFO || logger.GetLevel() == log.WARN {
+		opts = append(opts, elastic.SetInfoLog(logger))
+	}
+
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:      client,
+		indexerName: fmt.Sprintf("%s.v%d", indexerName, esCommitIndexerLatestVersion),
+	}
+	created, err := indexer.init()
+	return indexer, created, err
+}
+
+// init creates the index if it does not exist
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	ctx := context.Background()
+	exists, err := b.client.IndexExists(b.indexerName).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+
+	createIndex, err := b.client.CreateIndex(b.indexerName).BodyString(elasticMapping).Do(ctx)
+	if err != nil {
+		return false, err
+	}
+	if !createIndex.Acknowledged {
+		return false, errors.New("init failed")
+	}
+	return true, nil
+}
+
+// flush sends the requests in one bulk, waiting for them to be searchable
+func (b *ElasticSearchIndexer) flush(reqs []elastic.BulkableRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	resp, err := b.client.Bulk().
+		Index(b.indexerName).
+		Add(reqs...).
+		Refresh("wait_for").
+		Do(context.Background())
+	if err != nil {
+		return err
+	}
+	if failed := resp.Failed(); len(failed) > 0 && failed[0].Error != nil {
+		return fmt.Errorf("%s: %s", failed[0].Error.Type, failed[0].Error.Reason)
+	}
+	return nil
+}
+
+// Index indexes the commits
+func (b *ElasticSearchIndexer) Index(commits []*CommitData) error {
+	reqs := make([]elastic.BulkableRequest, 0, len(commits))
+	for _, commit := range commits {
+		reqs = append(reqs, elastic.NewBulkIndexRequest().
+			Index(b.indexerName).
+			Id(commitIndexerID(commit.RepoID, commit.SHA)).
+			Doc(map[string]interface{}{
+				"repo_id":         commit.RepoID,
+				"sha":             commit.SHA,
+				"message":         commit.Message,
+				"author_name":     commit.AuthorName,
+				"author_email":    commit.AuthorEmail,
+				"author_unix":     commit.AuthorUnix,
+				"committer_name":  commit.CommitterName,
+				"committer_email": commit.CommitterEmail,
+				"committer_unix":  commit.CommitterUnix,
+				"paths":           commit.Paths,
+				"issues":          commit.Issues,
+			}))
+	}
+	return b.flush(reqs)
+}
+
+// Delete deletes the given commits of a repository, all of them if none is given
+func (b *ElasticSearchIndexer) Delete(repoID int64, shas ...string) error {
+	if len(shas) > 0 {
+		reqs := make([]elastic.BulkableRequest, 0, len(shas))
+		for _, sha := range shas {
+			reqs = append(reqs, elastic.NewBulkDeleteRequest().
+				Index(b.indexerName).
+				Id(commitIndexerID(repoID, sha)))
+		}
+		return b.flush(reqs)
+	}
+
+	_, err := b.client.DeleteByQuery(b.indexerName).
+		Query(elastic.NewTermsQuery("repo_id", repoID)).
+		Refresh("true").
+		Do(context.Background())
+	return err
+}
+
+// elasticWildcardsQuery returns a query matching any of the values anywhere
+// in any of the fields
+func elasticWildcardsQuery(values []string, fields ...string) elastic.Query {
+	q := elastic.NewBoolQuery().MinimumNumberShouldMatch(1)
+	for _, value := range values {
+		for _, field := range fields {
+			q = q.Should(elastic.NewWildcardQuery(field, "*"+value+"*"))
+		}
+	}
+	return q
+}
+
+// Search searches for commits matching the options, the most recently
+// committed first
+func (b *ElasticSearchIndexer) Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, error) {
+	query := elastic.NewBoolQuery()
+	for _, keyword := range opts.Keywords {
+		phraseQuery := elastic.NewMatchPhraseQuery("message", keyword)
+		if !isSHAPrefix(keyword) {
+			query = query.Must(phraseQuery)
+			continue
+		}
+		query = query.Must(elastic.NewBoolQuery().MinimumNumberShouldMatch(1).
+			Should(phraseQuery, elastic.NewPrefixQuery("sha", strings.ToLower(keyword))))
+	}
+	if len(opts.RepoIDs) > 0 {
+		repoIDs := make([]interface{}, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoIDs = append(repoIDs, repoID)
+		}
+		query = query.Filter(elastic.NewTermsQuery("repo_id", repoIDs...))
+	}
+	if len(opts.Authors) > 0 {
+		query = query.Filter(elasticWildcardsQuery(opts.Authors, "author_name", "author_email"))
+	}
+	if len(opts.Committers) > 0 {
+		query = query.Filter(elasticWildcardsQuery(opts.Committers, "committer_name", "committer_email"))
+	}
+	if len(opts.Paths) > 0 {
+		query = query.Filter(elasticWildcardsQuery(opts.Paths, "paths"))
+	}
+	for _, index := range opts.Issues {
+		query = query.Filter(elastic.NewTermQuery("issues", index))
+	}
+	if !opts.After.IsZero() || !opts.Before.IsZero() {
+		dateQuery := elastic.NewRangeQuery("committer_unix")
+		if !opts.After.IsZero() {
+			dateQuery = dateQuery.Gte(opts.After.Unix())
+		}
+		if !opts.Before.IsZero() {
+			dateQuery = dateQuery.Lt(opts.Before.Unix())
+		}
+		query = query.Filter(dateQuery)
+	}
+
+	searchResult, err := b.client.Search().
+		Index(b.indexerName).
+		Query(query).
+		Sort("committer_unix", false).
+		From((page - 1) * pageSize).Size(pageSize).
+		Do(context.Background())
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results := make([]*SearchResult, 0, len(searchResult.Hits.Hits))
+	for _, hit := range searchResult.Hits.Hits {
+		var doc struct {
+			RepoID        int64  `json:"repo_id"`
+			SHA           string `json:"sha"`
+			Message       string `json:"message"`
+			AuthorName    string `json:"author_name"`
+			AuthorEmail   string `json:"author_email"`
+			AuthorUnix    int64  `json:"author_unix"`
+			CommitterUnix int64  `json:"committer_unix"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return 0, nil, err
+		}
+		results = append(results, &SearchResult{
+			RepoID:        doc.RepoID,
+			SHA:           doc.SHA,
+			Message:       doc.Message,
+			AuthorName:    doc.AuthorName,
+			AuthorEmail:   doc.AuthorEmail,
+			AuthorUnix:    doc.AuthorUnix,
+			CommitterUnix: doc.CommitterUnix,
+		})
+	}
+	return searchResult.TotalHits(), results, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
@@ -0,0 +1,191 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/references"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// readBatchSize is the number of commits read from git at once
+const readBatchSize = 100
+
+// logFormat separates the commits with \x1e and their fields with \x1f, the
+// changed files follow the message
+const logFormat = "--format=%x1e%H%x1f%an%x1f%ae%x1f%at%x1f%cn%x1f%ce%x1f%ct%x1f%B%x1f"
+
+// getBranchHeads returns the distinct commit IDs the branches point to
+func getBranchHeads(repoPath string) ([]string, error) {
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(objectname)", git.BranchPrefix).RunInDir(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var heads []string
+	for _, head := range strings.Fields(stdout) {
+		if !util.IsStringInSlice(head, heads) {
+			heads = append(heads, head)
+		}
+	}
+	sort.Strings(heads)
+	return heads, nil
+}
+
+// revList returns the commits reachable from the include commits but not from
+// the exclude ones, commits which no longer exist are ignored
+func revList(repoPath string, include, exclude []string) ([]string, error) {
+	if len(include) == 0 {
+		return nil, nil
+	}
+	stdin := new(bytes.Buffer)
+	for _, sha := range include {
+		stdin.WriteString(sha + "\n")
+	}
+	for _, sha := range exclude {
+		stdin.WriteString("^" + sha + "\n")
+	}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	if err := git.NewCommand("rev-list", "--ignore-missing", "--stdin").
+		RunInDirFullPipeline(repoPath, stdout, stderr, stdin); err != nil {
+		return nil, fmt.Errorf("rev-list: %v - %s", err, stderr)
+	}
+	return strings.Fields(stdout.String()), nil
+}
+
+// issueIndexes returns the indexes of the issues of the repository a message
+// refers to
+func issueIndexes(repo *models.Repository, message string) []int64 {
+	var indexes []int64
+	for _, ref := range references.FindAllIssueReferences(message) {
+		if len(ref.Owner) > 0 && (!strings.EqualFold(ref.Owner, repo.OwnerName) || !strings.EqualFold(ref.Name, repo.Name)) {
+			continue
+		}
+		if !util.IsInt64InSlice(ref.Index, indexes) {
+			indexes = append(indexes, ref.Index)
+		}
+	}
+	return indexes
+}
+
+// parseLog parses the output of git log with logFormat, --name-only and -z
+func parseLog(repo *models.Repository, stdout []byte) ([]*CommitData, error) {
+	records := bytes.Split(stdout, []byte{'\x1e'})
+	commits := make([]*CommitData, 0, len(records))
+	for _, record := range records[1:] {
+		fields := bytes.SplitN(record, []byte{'\x1f'}, 9)
+		if len(fields) != 9 {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+		authorUnix, err := strconv.ParseInt(string(fields[3]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		committerUnix, err := strconv.ParseInt(string(fields[6]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		message := strings.TrimSpace(string(fields[7]))
+
+		var paths []string
+		for _, path := range bytes.Split(fields[8], []byte{'\x00'}) {
+			if path := string(bytes.TrimPrefix(path, []byte{'\n'})); len(path) > 0 {
+				paths = append(paths, path)
+			}
+		}
+
+		commits = append(commits, &CommitData{
+			RepoID:         repo.ID,
+			SHA:            string(fields[0]),
+			Message:        message,
+			AuthorName:     string(fields[1]),
+			AuthorEmail:    string(fields[2]),
+			AuthorUnix:     authorUnix,
+			CommitterName:  string(fields[4]),
+			CommitterEmail: string(fields[5]),
+			CommitterUnix:  committerUnix,
+			Paths:          paths,
+			Issues:         issueIndexes(repo, message),
+		})
+	}
+	return commits, nil
+}
+
+// readCommits reads the given commits of a repository from git
+func readCommits(repo *models.Repository, shas []string) ([]*CommitData, error) {
+	stdin := strings.NewReader(strings.Join(shas, "\n") + "\n")
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	if err := git.NewCommand("log", "--stdin", "--no-walk=unsorted", "--name-only", "-z", logFormat).
+		RunInDirFullPipeline(repo.RepoPath(), stdout, stderr, stdin); err != nil {
+		return nil, fmt.Errorf("log: %v - %s", err, stderr)
+	}
+	return parseLog(repo, stdout.Bytes())
+}
+
+// indexRepo indexes the commits added to the branches of a repository since
+// its last update and deletes the ones no longer on any branch, starting from
+// scratch if reindex is set
+func indexRepo(indexer Indexer, repoID int64, reindex bool) error {
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+
+	if reindex {
+		if err = indexer.Delete(repo.ID); err != nil {
+			return err
+		}
+		if err = models.DeleteCommitIndexerStatus(repo.ID); err != nil {
+			return err
+		}
+	}
+	status, err := models.GetCommitIndexerStatus(repo.ID)
+	if err != nil {
+		return err
+	}
+
+	heads, err := getBranchHeads(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	added, err := revList(repo.RepoPath(), heads, status.BranchHeads)
+	if err != nil {
+		return err
+	}
+	removed, err := revList(repo.RepoPath(), status.BranchHeads, heads)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(added); start += readBatchSize {
+		end := start + readBatchSize
+		if end > len(added) {
+			end = len(added)
+		}
+		commits, err := readCommits(repo, added[start:end])
+		if err != nil {
+			return err
+		}
+		if err = indexer.Index(commits); err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		if err = indexer.Delete(repo.ID, removed...); err != nil {
+			return err
+		}
+	}
+
+	status.BranchHeads = heads
+	return models.UpdateCommitIndexerStatus(status)
+}
@@ -0,0 +1,19 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueIndexes(t *testing.T) {
+	repo := &models.Repository{OwnerName: "user2", Name: "repo1"}
+	assert.Equal(t, []int64{3, 5}, issueIndexes(repo, "Fix #3, see User2/Repo1#5, other/repo#7 and #3"))
+	assert.Empty(t, issueIndexes(repo, "no reference"))
+}
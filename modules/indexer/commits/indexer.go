@@ -0,0 +1,155 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// CommitData is a commit of a repository stored in the commit indexer
+type CommitData struct {
+	RepoID         int64
+	SHA            string
+	Message        string
+	AuthorName     string
+	AuthorEmail    string
+	AuthorUnix     int64
+	CommitterName  string
+	CommitterEmail string
+	CommitterUnix  int64
+	// Paths are the files changed by the commit
+	Paths []string
+	// Issues are the indexes of the issues of the repository the message
+	// refers to
+	Issues []int64
+}
+
+// SearchResult is a commit matching a search
+type SearchResult struct {
+	RepoID        int64
+	SHA           string
+	Message       string
+	AuthorName    string
+	AuthorEmail   string
+	AuthorUnix    int64
+	CommitterUnix int64
+}
+
+// Indexer defines an interface to index the commits of repositories
+type Indexer interface {
+	Index(commits []*CommitData) error
+	// Delete deletes the given commits of a repository, all of them if none
+	// is given
+	Delete(repoID int64, shas ...string) error
+	// Search returns the commits matching the options, the most recently
+	// committed first
+	Search(opts *SearchOptions, page, pageSize int) (int64, []*SearchResult, error)
+	Close()
+}
+
+// Init initializes the commit indexer
+func Init() {
+	if !setting.Indexer.CommitIndexerEnabled {
+		indexer.Close()
+		return
+	}
+
+	initQueue(setting.Indexer.UpdateQueueLength)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	graceful.GetManager().RunAtTerminate(ctx, func() {
+		log.Debug("Closing commit indexer")
+		indexer.Close()
+		log.Info("PID: %d Commit Indexer closed", os.Getpid())
+	})
+
+	waitChannel := make(chan time.Duration)
+	go func() {
+		start := time.Now()
+		var (
+			cIndexer Indexer
+			created  bool
+			err      error
+		)
+		switch setting.Indexer.CommitType {
+		case "bleve":
+			log.Info("PID: %d Initializing Commit Indexer at: %s", os.Getpid(), setting.Indexer.CommitPath)
+			var bleveIndexer *BleveIndexer
+			bleveIndexer, created, err = NewBleveIndexer(setting.Indexer.CommitPath)
+			if err != nil {
+				if bleveIndexer != nil {
+					bleveIndexer.Close()
+				}
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Commit Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.CommitPath, err)
+			}
+			cIndexer = bleveIndexer
+		case "elasticsearch":
+			log.Info("PID: %d Initializing Commit Indexer at: %s", os.Getpid(), setting.Indexer.CommitConnStr)
+			cIndexer, created, err = NewElasticSearchIndexer(setting.Indexer.CommitConnStr, setting.Indexer.CommitIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Commit Indexer at: %s Error: %v", os.Getpid(), setting.Indexer.CommitConnStr, err)
+			}
+		default:
+			cancel()
+			indexer.Close()
+			close(waitChannel)
+			log.Fatal("PID: %d Unknown Commit Indexer type: %s", os.Getpid(), setting.Indexer.CommitType)
+		}
+		indexer.set(cIndexer)
+
+		go processCommitIndexerOperationQueue(indexer)
+
+		// a new index has to be populated from scratch, otherwise only the
+		// repositories which were never indexed are
+		go populateCommitIndexer(created)
+
+		select {
+		case waitChannel <- time.Since(start):
+		case <-graceful.GetManager().IsShutdown():
+		}
+
+		close(waitChannel)
+	}()
+
+	if setting.Indexer.StartupTimeout > 0 {
+		go func() {
+			timeout := setting.Indexer.StartupTimeout
+			if graceful.GetManager().IsChild() && setting.GracefulHammerTime > 0 {
+				timeout += setting.GracefulHammerTime
+			}
+			select {
+			case <-graceful.GetManager().IsShutdown():
+				log.Warn("Shutdown before Commit Indexer completed initialization")
+				cancel()
+				indexer.Close()
+			case duration, ok := <-waitChannel:
+				if !ok {
+					log.Warn("Commit Indexer Initialization failed")
+					cancel()
+					indexer.Close()
+					return
+				}
+				log.Info("Commit Indexer Initialization took %v", duration)
+			case <-time.After(timeout):
+				cancel()
+				indexer.Close()
+				log.Fatal("Commit Indexer Initialization Timed-Out after: %v", timeout)
+			}
+		}()
+	}
+}
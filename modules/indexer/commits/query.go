@@ -0,0 +1,160 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrInvalidQuery represents a commit search query which can't be parsed
+type ErrInvalidQuery struct {
+	Reason string
+}
+
+// IsErrInvalidQuery checks if an error is a ErrInvalidQuery
+func IsErrInvalidQuery(err error) bool {
+	_, ok := err.(ErrInvalidQuery)
+	return ok
+}
+
+func (err ErrInvalidQuery) Error() string {
+	return fmt.Sprintf("invalid query: %s", err.Reason)
+}
+
+// SearchOptions are the conditions of a commit search
+type SearchOptions struct {
+	// RepoIDs restricts the search to these repositories, nil means all
+	// repositories
+	RepoIDs []int64
+	// Keywords are words or phrases which must all appear in the messages, a
+	// keyword which looks like a commit ID also matches the commits it prefixes
+	Keywords []string
+	// Authors, Committers, Paths and Repos are filters, a commit has to match
+	// one of the values of each non empty filter
+	Authors    []string
+	Committers []string
+	Paths      []string
+	Repos      []string
+	// Issues are the indexes of issues of the repository the messages must
+	// all refer to
+	Issues []int64
+	// After and Before restrict the commit dates if not zero
+	After  time.Time
+	Before time.Time
+}
+
+// IsEmpty returns true if the options have no condition on the commits
+func (opts *SearchOptions) IsEmpty() bool {
+	return len(opts.Keywords) == 0 && len(opts.Authors) == 0 && len(opts.Committers) == 0 &&
+		len(opts.Paths) == 0 && len(opts.Repos) == 0 && len(opts.Issues) == 0 &&
+		opts.After.IsZero() && opts.Before.IsZero()
+}
+
+var shaPrefixPattern = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+// isSHAPrefix returns true if a keyword may be the prefix of a commit ID
+func isSHAPrefix(keyword string) bool {
+	return shaPrefixPattern.MatchString(keyword)
+}
+
+// parseDate parses the date of the after: and before: filters
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidQuery{Reason: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", value)}
+}
+
+// ParseQuery parses a commit search query. Besides words and "exact phrases",
+// a query may contain #123 to find the commits referring to an issue and the
+// filters author:, committer:, path:, repo: (as owner/name), after: and
+// before: (as YYYY-MM-DD).
+func ParseQuery(query string) (*SearchOptions, error) {
+	opts := &SearchOptions{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		if runes[i] == '"' {
+			if end := closingQuote(runes, i); end > 0 {
+				value := strings.ReplaceAll(string(runes[i+1:end]), `\"`, `"`)
+				i = end + 1
+				if len(strings.TrimSpace(value)) > 0 {
+					opts.Keywords = append(opts.Keywords, value)
+				}
+				continue
+			}
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		term := string(runes[start:i])
+		if len(term) > 1 && term[0] == '#' {
+			if index, err := strconv.ParseInt(term[1:], 10, 64); err == nil && index > 0 {
+				opts.Issues = append(opts.Issues, index)
+				continue
+			}
+		}
+		if idx := strings.IndexByte(term, ':'); idx > 0 && idx < len(term)-1 {
+			value := term[idx+1:]
+			switch strings.ToLower(term[:idx]) {
+			case "author":
+				opts.Authors = append(opts.Authors, strings.ToLower(value))
+				continue
+			case "committer":
+				opts.Committers = append(opts.Committers, strings.ToLower(value))
+				continue
+			case "path":
+				opts.Paths = append(opts.Paths, strings.ToLower(value))
+				continue
+			case "repo":
+				opts.Repos = append(opts.Repos, value)
+				continue
+			case "after":
+				date, err := parseDate(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.After = date
+				continue
+			case "before":
+				date, err := parseDate(value)
+				if err != nil {
+					return nil, err
+				}
+				opts.Before = date
+				continue
+			}
+		}
+		opts.Keywords = append(opts.Keywords, term)
+	}
+	return opts, nil
+}
+
+// closingQuote returns the index of the quote closing the one at start,
+// skipping quotes escaped with a backslash, or -1 if there is none
+func closingQuote(runes []rune, start int) int {
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	opts, err := ParseQuery(`author:Alice committer:bob path:models/ repo:org/name "exact  phrase" revert #12 after:2019-04-01 before:2019-05-01`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exact  phrase", "revert"}, opts.Keywords)
+	assert.Equal(t, []string{"alice"}, opts.Authors)
+	assert.Equal(t, []string{"bob"}, opts.Committers)
+	assert.Equal(t, []string{"models/"}, opts.Paths)
+	assert.Equal(t, []string{"org/name"}, opts.Repos)
+	assert.Equal(t, []int64{12}, opts.Issues)
+	assert.Equal(t, time.Date(2019, 4, 1, 0, 0, 0, 0, time.Local), opts.After)
+	assert.Equal(t, time.Date(2019, 5, 1, 0, 0, 0, 0, time.Local), opts.Before)
+	assert.False(t, opts.IsEmpty())
+
+	// unknown filters, #words and unterminated quotes are words
+	opts, err = ParseQuery(`http://example.com #tag "unterminated`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"http://example.com", "#tag", `"unterminated`}, opts.Keywords)
+	assert.Empty(t, opts.Issues)
+
+	opts, err = ParseQuery(`  "" `)
+	assert.NoError(t, err)
+	assert.True(t, opts.IsEmpty())
+
+	_, err = ParseQuery(`after:yesterday`)
+	assert.True(t, IsErrInvalidQuery(err))
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import (
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+type commitIndexerOperation struct {
+	repoID   int64
+	deleted  bool
+	reindex  bool
+	watchers []chan<- error
+}
+
+var commitIndexerOperationQueue chan commitIndexerOperation
+
+func initQueue(queueLength int) {
+	commitIndexerOperationQueue = make(chan commitIndexerOperation, queueLength)
+}
+
+func processCommitIndexerOperationQueue(indexer Indexer) {
+	for {
+		select {
+		case op := <-commitIndexerOperationQueue:
+			var err error
+			if op.deleted {
+				if err = indexer.Delete(op.repoID); err != nil {
+					log.Error("indexer.Delete: %v", err)
+				}
+			} else {
+				if err = indexRepo(indexer, op.repoID, op.reindex); err != nil {
+					log.Error("indexRepo: %v", err)
+				}
+			}
+			for _, watcher := range op.watchers {
+				watcher <- err
+			}
+		case <-graceful.GetManager().IsShutdown():
+			log.Info("PID: %d Commit indexer queue processing stopped", os.Getpid())
+			return
+		}
+	}
+}
+
+// DeleteRepoFromIndexer removes all the commits of a repository from the indexer
+func DeleteRepoFromIndexer(repo *models.Repository, watchers ...chan<- error) {
+	addOperationToQueue(commitIndexerOperation{repoID: repo.ID, deleted: true, watchers: watchers})
+}
+
+// UpdateRepoIndexer indexes the commits added to the branches of a repository
+// and removes the ones no longer on any branch
+func UpdateRepoIndexer(repo *models.Repository, watchers ...chan<- error) {
+	addOperationToQueue(commitIndexerOperation{repoID: repo.ID, watchers: watchers})
+}
+
+// ReindexRepo drops the commits of a repository from the indexer and indexes
+// them again from scratch
+func ReindexRepo(repo *models.Repository, watchers ...chan<- error) {
+	addOperationToQueue(commitIndexerOperation{repoID: repo.ID, reindex: true, watchers: watchers})
+}
+
+func addOperationToQueue(op commitIndexerOperation) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		return
+	}
+	select {
+	case commitIndexerOperationQueue <- op:
+		break
+	default:
+		go func() {
+			commitIndexerOperationQueue <- op
+		}()
+	}
+}
+
+// ReindexAllRepos drops the commits of all repositories from the indexer and
+// indexes them again in the background
+func ReindexAllRepos() {
+	if !setting.Indexer.CommitIndexerEnabled {
+		return
+	}
+	go func() {
+		log.Info("Reindexing the commits of all repositories")
+		if err := models.DeleteAllRecords("commit_indexer_status"); err != nil {
+			log.Error("ReindexAllRepos: %v", err)
+			return
+		}
+		if err := queueUnindexedRepos(true); err != nil {
+			log.Error("ReindexAllRepos: %v", err)
+		}
+	}()
+}
+
+// populateCommitIndexer queues the repositories which were never indexed, all
+// of them when the index was just created
+func populateCommitIndexer(created bool) {
+	if created {
+		// the statuses of a previous index are meaningless
+		if err := models.DeleteAllRecords("commit_indexer_status"); err != nil {
+			log.Error("populateCommitIndexer: %v", err)
+			return
+		}
+	}
+
+	log.Info("Populating the commit indexer with existing repositories")
+	if err := queueUnindexedRepos(false); err != nil {
+		log.Error("populateCommitIndexer: %v", err)
+		return
+	}
+	log.Info("Done populating the commit indexer with existing repositories")
+}
+
+// queueUnindexedRepos queues the repositories without a commit indexer status
+func queueUnindexedRepos(reindex bool) error {
+	isShutdown := graceful.GetManager().IsShutdown()
+
+	maxRepoID, err := models.GetMaxID("repository")
+	if err != nil {
+		return err
+	}
+
+	// start with the maximum existing repo ID and work backwards, so that we
+	// don't include repos that are created after gitea starts; such repos will
+	// already be added to the indexer, and we don't need to add them again.
+	for maxRepoID > 0 {
+		ids, err := models.GetCommitUnindexedRepos(maxRepoID, 50)
+		if err != nil {
+			return err
+		} else if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			select {
+			case <-isShutdown:
+				log.Info("Commit Indexer population shutdown before completion")
+				return nil
+			case commitIndexerOperationQueue <- commitIndexerOperation{repoID: id, reindex: reindex}:
+			}
+			maxRepoID = id - 1
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package commits
+
+import "code.gitea.io/gitea/models"
+
+// PerformSearch performs a commit search query (see ParseQuery) on the given
+// repositories, nil meaning all repositories. The most recently committed
+// commits come first.
+func PerformSearch(repoIDs []int64, query string, page, pageSize int) (int64, []*SearchResult, error) {
+	opts, err := ParseQuery(query)
+	if err != nil {
+		return 0, nil, err
+	} else if opts.IsEmpty() {
+		return 0, nil, nil
+	}
+	if opts.RepoIDs, err = models.FilterRepositoryIDsByFullNames(repoIDs, opts.Repos); err != nil || (opts.RepoIDs != nil && len(opts.RepoIDs) == 0) {
+		return 0, nil, err
+	}
+	return indexer.Search(opts, page, pageSize)
+}
@@ -21,13 +21,18 @@ import (
 
 // IndexerData data stored in the issue indexer
 type IndexerData struct {
-	ID       int64    `json:"id"`
-	RepoID   int64    `json:"repo_id"`
-	Title    string   `json:"title"`
-	Content  string   `json:"content"`
-	Comments []string `json:"comments"`
-	IsDelete bool     `json:"is_delete"`
-	IDs      []int64  `json:"ids"`
+	ID          int64    `json:"id"`
+	RepoID      int64    `json:"repo_id"`
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	Comments    []string `json:"comments"`
+	IsPull      bool     `json:"is_pull"`
+	IsClosed    bool     `json:"is_closed"`
+	PosterID    int64    `json:"poster_id"`
+	LabelIDs    []int64  `json:"label_ids"`
+	AssigneeIDs []int64  `json:"assignee_ids"`
+	IsDelete    bool     `json:"is_delete"`
+	IDs         []int64  `json:"ids"`
 }
 
 // Match represents on search result
@@ -51,6 +56,30 @@ type Indexer interface {
 	Close()
 }
 
+// SearchOptions are the conditions the issues found by keyword have to match
+// as well, zero values match all issues
+type SearchOptions struct {
+	IsPull     util.OptionalBool
+	IsClosed   util.OptionalBool
+	PosterID   int64
+	AssigneeID int64
+	LabelIDs   []int64
+}
+
+// FilterIndexer is an Indexer which restricts the issues it finds to the ones
+// matching search options, it indexes the state, poster, labels and assignees
+// of the issues
+type FilterIndexer interface {
+	Indexer
+	SearchWithOptions(kw string, repoIDs []int64, opts *SearchOptions, limit, start int) (*SearchResult, error)
+}
+
+// IndexesFilters returns true if the configured indexer is a FilterIndexer,
+// the attributes it filters on only have to be indexed then
+func IndexesFilters() bool {
+	return setting.Indexer.IssueType == "meilisearch"
+}
+
 type indexerHolder struct {
 	indexer   Indexer
 	mutex     sync.RWMutex
@@ -100,7 +129,7 @@ func InitIssueIndexer(syncReindex bool) {
 
 	// Create the Queue
 	switch setting.Indexer.IssueType {
-	case "bleve", "elasticsearch":
+	case "bleve", "elasticsearch", "meilisearch":
 		handler := func(data ...queue.Data) {
 			indexer := holder.get()
 			if indexer == nil {
@@ -173,6 +202,19 @@ func InitIssueIndexer(syncReindex bool) {
 				populate = !exist
 				holder.set(issueIndexer)
 			})
+		case "meilisearch":
+			graceful.GetManager().RunWithShutdownFns(func(_, atTerminate func(context.Context, func())) {
+				issueIndexer, err := NewMeilisearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+				if err != nil {
+					log.Fatal("Unable to initialize Meilisearch Issue Indexer: %v", err)
+				}
+				exist, err := issueIndexer.Init()
+				if err != nil {
+					log.Fatal("Unable to issueIndexer.Init: %v", err)
+				}
+				populate = !exist
+				holder.set(issueIndexer)
+			})
 		case "db":
 			issueIndexer := &DBIndexer{}
 			holder.set(issueIndexer)
@@ -292,6 +334,20 @@ func UpdateIssueIndexer(issue *models.Issue) {
 		Title:    issue.Title,
 		Content:  issue.Content,
 		Comments: comments,
+		IsPull:   issue.IsPull,
+		IsClosed: issue.IsClosed,
+		PosterID: issue.PosterID,
+	}
+	if IndexesFilters() {
+		var err error
+		if indexerData.LabelIDs, err = models.GetLabelIDsByIssueID(issue.ID); err != nil {
+			log.Error("GetLabelIDsByIssueID: %v", err)
+			return
+		}
+		if indexerData.AssigneeIDs, err = models.GetAssigneeIDsByIssue(issue.ID); err != nil {
+			log.Error("GetAssigneeIDsByIssue: %v", err)
+			return
+		}
 	}
 	log.Debug("Adding to channel: %v", indexerData)
 	if err := issueIndexerQueue.Push(indexerData); err != nil {
@@ -320,9 +376,10 @@ func DeleteRepoIssueIndexer(repo *models.Repository) {
 	}
 }
 
-// SearchIssuesByKeyword search issue ids by keywords and repo id
+// SearchIssuesByKeyword search issue ids by keywords and repo id, indexers
+// which can filter the issues also restrict them to the ones matching opts
 // WARNNING: You have to ensure user have permission to visit repoIDs' issues
-func SearchIssuesByKeyword(repoIDs []int64, keyword string) ([]int64, error) {
+func SearchIssuesByKeyword(repoIDs []int64, keyword string, opts *SearchOptions) ([]int64, error) {
 	var issueIDs []int64
 	indexer := holder.get()
 
@@ -330,7 +387,13 @@ func SearchIssuesByKeyword(repoIDs []int64, keyword string) ([]int64, error) {
 		log.Error("SearchIssuesByKeyword(): unable to get indexer!")
 		return nil, fmt.Errorf("unable to get issue indexer")
 	}
-	res, err := indexer.Search(keyword, repoIDs, 50, 0)
+	var res *SearchResult
+	var err error
+	if filterIndexer, ok := indexer.(FilterIndexer); ok && opts != nil {
+		res, err = filterIndexer.SearchWithOptions(keyword, repoIDs, opts, 50, 0)
+	} else {
+		res, err = indexer.Search(keyword, repoIDs, 50, 0)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -55,19 +55,19 @@ func TestBleveSearchIssues(t *testing.T) {
 
 	time.Sleep(5 * time.Second)
 
-	ids, err := SearchIssuesByKeyword([]int64{1}, "issue2")
+	ids, err := SearchIssuesByKeyword([]int64{1}, "issue2", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{2}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "first")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "first", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "for")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "for", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1, 2, 3, 5, 11}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "good")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "good", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
 
@@ -79,19 +79,19 @@ func TestDBSearchIssues(t *testing.T) {
 	setting.Indexer.IssueType = "db"
 	InitIssueIndexer(true)
 
-	ids, err := SearchIssuesByKeyword([]int64{1}, "issue2")
+	ids, err := SearchIssuesByKeyword([]int64{1}, "issue2", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{2}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "first")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "first", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "for")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "for", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1, 2, 3, 5, 11}, ids)
 
-	ids, err = SearchIssuesByKeyword([]int64{1}, "good")
+	ids, err = SearchIssuesByKeyword([]int64{1}, "good", nil)
 	assert.NoError(t, err)
 	assert.EqualValues(t, []int64{1}, ids)
 }
@@ -0,0 +1,312 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_ FilterIndexer = &MeilisearchIndexer{}
+
+	// meilisearchTaskTimeout is how long to wait for Meilisearch to process a task
+	meilisearchTaskTimeout = time.Minute
+	// meilisearchTaskPollInterval is how often the status of a task is checked
+	meilisearchTaskPollInterval = 100 * time.Millisecond
+
+	// meilisearchSettings are the settings of the index, applied on each start
+	meilisearchSettings = map[string]interface{}{
+		"searchableAttributes": []string{"title", "content", "comments"},
+		"filterableAttributes": []string{"repo_id", "is_pull", "is_closed", "poster_id", "label_ids", "assignee_ids"},
+		"typoTolerance": map[string]interface{}{
+			"enabled": true,
+		},
+	}
+)
+
+// MeilisearchIndexer implements Indexer interface
+type MeilisearchIndexer struct {
+	client    *http.Client
+	url       string
+	apiKey    string
+	indexName string
+}
+
+// meilisearchError is an error returned by Meilisearch
+type meilisearchError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+func (err *meilisearchError) Error() string {
+	return fmt.Sprintf("meilisearch: %s (%s)", err.Message, err.Code)
+}
+
+// meilisearchTask is an asynchronous operation of Meilisearch
+type meilisearchTask struct {
+	TaskUID int64             `json:"taskUid"`
+	Status  string            `json:"status"`
+	Error   *meilisearchError `json:"error"`
+}
+
+// meilisearchDocument is an issue as stored in Meilisearch
+type meilisearchDocument struct {
+	ID          int64    `json:"id"`
+	RepoID      int64    `json:"repo_id"`
+	Title       string   `json:"title"`
+	Content     string   `json:"content"`
+	Comments    []string `json:"comments"`
+	IsPull      bool     `json:"is_pull"`
+	IsClosed    bool     `json:"is_closed"`
+	PosterID    int64    `json:"poster_id"`
+	LabelIDs    []int64  `json:"label_ids"`
+	AssigneeIDs []int64  `json:"assignee_ids"`
+}
+
+// NewMeilisearchIndexer creates a new meilisearch indexer, the API key is
+// given as the password of the connection string,
+// e.g. http://:apikey@localhost:7700
+func NewMeilisearchIndexer(connStr, indexName string) (*MeilisearchIndexer, error) {
+	u, err := url.Parse(connStr)
+	if err != nil {
+		return nil, err
+	}
+	var apiKey string
+	if u.User != nil {
+		apiKey, _ = u.User.Password()
+		u.User = nil
+	}
+	return &MeilisearchIndexer{
+		client:    &http.Client{Timeout: 30 * time.Second},
+		url:       strings.TrimSuffix(u.String(), "/"),
+		apiKey:    apiKey,
+		indexName: indexName,
+	}, nil
+}
+
+// do sends a request to Meilisearch, decoding the response into result if it
+// isn't nil, and returns the status code of the response
+func (m *MeilisearchIndexer) do(method, path string, body, result interface{}) (int, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	req, err := http.NewRequest(method, m.url+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(m.apiKey) > 0 {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		merr := &meilisearchError{}
+		if err := json.Unmarshal(data, merr); err != nil || len(merr.Message) == 0 {
+			return resp.StatusCode, fmt.Errorf("meilisearch: %s %s returned %d", method, path, resp.StatusCode)
+		}
+		return resp.StatusCode, merr
+	}
+	if result != nil {
+		if err := json.Unmarshal(data, result); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// doTask sends a request enqueuing a task and waits for it to be processed
+func (m *MeilisearchIndexer) doTask(method, path string, body interface{}) error {
+	var task meilisearchTask
+	if _, err := m.do(method, path, body, &task); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(meilisearchTaskTimeout)
+	for {
+		switch task.Status {
+		case "succeeded":
+			return nil
+		case "failed", "canceled":
+			if task.Error != nil {
+				return task.Error
+			}
+			return fmt.Errorf("meilisearch: task %d %s", task.TaskUID, task.Status)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("meilisearch: task %d not processed after %v", task.TaskUID, meilisearchTaskTimeout)
+		}
+		time.Sleep(meilisearchTaskPollInterval)
+		if _, err := m.do("GET", fmt.Sprintf("/tasks/%d", task.TaskUID), nil, &task); err != nil {
+			return err
+		}
+	}
+}
+
+// Init creates the index if it does not exist and updates its settings
+func (m *MeilisearchIndexer) Init() (bool, error) {
+	status, err := m.do("GET", "/indexes/"+url.PathEscape(m.indexName), nil, nil)
+	if err != nil && status != http.StatusNotFound {
+		return false, err
+	}
+	exists := status == http.StatusOK
+
+	if !exists {
+		err = m.doTask("POST", "/indexes", map[string]interface{}{
+			"uid":        m.indexName,
+			"primaryKey": "id",
+		})
+		// another instance may have created it in the meantime
+		if merr, ok := err.(*meilisearchError); ok && merr.Code == "index_already_exists" {
+			err = nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// updating the settings to the same values is a no-op
+	if err = m.doTask("PATCH", "/indexes/"+url.PathEscape(m.indexName)+"/settings", meilisearchSettings); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// Index will save the index data
+func (m *MeilisearchIndexer) Index(issues []*IndexerData) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	docs := make([]*meilisearchDocument, 0, len(issues))
+	for _, issue := range issues {
+		docs = append(docs, &meilisearchDocument{
+			ID:          issue.ID,
+			RepoID:      issue.RepoID,
+			Title:       issue.Title,
+			Content:     issue.Content,
+			Comments:    issue.Comments,
+			IsPull:      issue.IsPull,
+			IsClosed:    issue.IsClosed,
+			PosterID:    issue.PosterID,
+			LabelIDs:    issue.LabelIDs,
+			AssigneeIDs: issue.AssigneeIDs,
+		})
+	}
+	return m.doTask("POST", "/indexes/"+url.PathEscape(m.indexName)+"/documents", docs)
+}
+
+// Delete deletes indexes by ids
+func (m *MeilisearchIndexer) Delete(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.doTask("POST", "/indexes/"+url.PathEscape(m.indexName)+"/documents/delete-batch", ids)
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (m *MeilisearchIndexer) Search(keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
+	return m.SearchWithOptions(keyword, repoIDs, nil, limit, start)
+}
+
+// meilisearchFilters returns the filter expressions of the search, all of
+// them have to match
+func meilisearchFilters(repoIDs []int64, opts *SearchOptions) []string {
+	var filters []string
+	if len(repoIDs) > 0 {
+		repoStrs := make([]string, 0, len(repoIDs))
+		for _, repoID := range repoIDs {
+			repoStrs = append(repoStrs, strconv.FormatInt(repoID, 10))
+		}
+		filters = append(filters, fmt.Sprintf("repo_id IN [%s]", strings.Join(repoStrs, ", ")))
+	}
+	if opts == nil {
+		return filters
+	}
+	if !opts.IsPull.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_pull = %t", opts.IsPull.IsTrue()))
+	}
+	if !opts.IsClosed.IsNone() {
+		filters = append(filters, fmt.Sprintf("is_closed = %t", opts.IsClosed.IsTrue()))
+	}
+	if opts.PosterID > 0 {
+		filters = append(filters, fmt.Sprintf("poster_id = %d", opts.PosterID))
+	}
+	if opts.AssigneeID > 0 {
+		filters = append(filters, fmt.Sprintf("assignee_ids = %d", opts.AssigneeID))
+	}
+	// negative IDs are the labels the issues must not have
+	for _, labelID := range opts.LabelIDs {
+		if labelID > 0 {
+			filters = append(filters, fmt.Sprintf("label_ids = %d", labelID))
+		} else if labelID < 0 {
+			filters = append(filters, fmt.Sprintf("NOT label_ids = %d", -labelID))
+		}
+	}
+	return filters
+}
+
+// SearchWithOptions searches for issues by given conditions, which also
+// match the options.
+// Returns the matching issue IDs
+func (m *MeilisearchIndexer) SearchWithOptions(keyword string, repoIDs []int64, opts *SearchOptions, limit, start int) (*SearchResult, error) {
+	query := map[string]interface{}{
+		"q":                    keyword,
+		"offset":               start,
+		"limit":                limit,
+		"attributesToRetrieve": []string{"id"},
+	}
+	if filters := meilisearchFilters(repoIDs, opts); len(filters) > 0 {
+		query["filter"] = filters
+	}
+
+	var result struct {
+		Hits []struct {
+			ID int64 `json:"id"`
+		} `json:"hits"`
+		EstimatedTotalHits int64 `json:"estimatedTotalHits"`
+	}
+	if _, err := m.do("POST", "/indexes/"+url.PathEscape(m.indexName)+"/search", query, &result); err != nil {
+		return nil, err
+	}
+
+	hits := make([]Match, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		hits = append(hits, Match{
+			ID: hit.ID,
+		})
+	}
+	return &SearchResult{
+		Total: result.EstimatedTotalHits,
+		Hits:  hits,
+	}, nil
+}
+
+// Close implements indexer
+func (m *MeilisearchIndexer) Close() {}
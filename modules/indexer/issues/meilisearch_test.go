@@ -0,0 +1,286 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMeilisearch is an in-memory Meilisearch serving the calls of the
+// indexer, matching documents whose title, content or comments contain the
+// query
+type fakeMeilisearch struct {
+	mutex         sync.Mutex
+	apiKey        string
+	indexes       map[string]map[int64]*meilisearchDocument
+	settings      map[string]map[string]interface{}
+	tasks         []*meilisearchTask
+	indexCreation int
+}
+
+var fakeFilter = regexp.MustCompile(`^(NOT )?([a-z_]+) (?:IN \[([0-9, ]*)\]|= (\w+))$`)
+
+func newFakeMeilisearch(apiKey string) *fakeMeilisearch {
+	return &fakeMeilisearch{
+		apiKey:   apiKey,
+		indexes:  make(map[string]map[int64]*meilisearchDocument),
+		settings: make(map[string]map[string]interface{}),
+	}
+}
+
+func (f *fakeMeilisearch) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeMeilisearch) writeError(w http.ResponseWriter, status int, code string) {
+	f.writeJSON(w, status, &meilisearchError{Message: code, Code: code})
+}
+
+// enqueue records a task, processing it at once
+func (f *fakeMeilisearch) enqueue(w http.ResponseWriter, err *meilisearchError) {
+	task := &meilisearchTask{TaskUID: int64(len(f.tasks)), Status: "succeeded"}
+	if err != nil {
+		task.Status = "failed"
+		task.Error = err
+	}
+	f.tasks = append(f.tasks, task)
+	// tasks are reported as enqueued first, like a real instance does
+	f.writeJSON(w, http.StatusAccepted, &meilisearchTask{TaskUID: task.TaskUID, Status: "enqueued"})
+}
+
+func (f *fakeMeilisearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+f.apiKey {
+		f.writeError(w, http.StatusUnauthorized, "invalid_api_key")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "tasks" && r.Method == "GET":
+		uid, _ := strconv.Atoi(parts[1])
+		if uid >= len(f.tasks) {
+			f.writeError(w, http.StatusNotFound, "task_not_found")
+			return
+		}
+		f.writeJSON(w, http.StatusOK, f.tasks[uid])
+	case len(parts) == 1 && parts[0] == "indexes" && r.Method == "POST":
+		var body struct {
+			UID string `json:"uid"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := f.indexes[body.UID]; ok {
+			f.enqueue(w, &meilisearchError{Message: "index already exists", Code: "index_already_exists"})
+			return
+		}
+		f.indexCreation++
+		f.indexes[body.UID] = make(map[int64]*meilisearchDocument)
+		f.enqueue(w, nil)
+	case len(parts) >= 2 && parts[0] == "indexes":
+		docs, ok := f.indexes[parts[1]]
+		if !ok {
+			f.writeError(w, http.StatusNotFound, "index_not_found")
+			return
+		}
+		f.serveIndex(w, r, parts[1], docs, strings.Join(parts[2:], "/"))
+	default:
+		f.writeError(w, http.StatusNotFound, "not_found")
+	}
+}
+
+func (f *fakeMeilisearch) serveIndex(w http.ResponseWriter, r *http.Request, name string, docs map[int64]*meilisearchDocument, path string) {
+	switch {
+	case path == "" && r.Method == "GET":
+		f.writeJSON(w, http.StatusOK, map[string]interface{}{"uid": name, "primaryKey": "id"})
+	case path == "settings" && r.Method == "PATCH":
+		var settings map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&settings)
+		f.settings[name] = settings
+		f.enqueue(w, nil)
+	case path == "documents" && r.Method == "POST":
+		var added []*meilisearchDocument
+		_ = json.NewDecoder(r.Body).Decode(&added)
+		for _, doc := range added {
+			docs[doc.ID] = doc
+		}
+		f.enqueue(w, nil)
+	case path == "documents/delete-batch" && r.Method == "POST":
+		var ids []int64
+		_ = json.NewDecoder(r.Body).Decode(&ids)
+		for _, id := range ids {
+			delete(docs, id)
+		}
+		f.enqueue(w, nil)
+	case path == "search" && r.Method == "POST":
+		var query struct {
+			Q      string   `json:"q"`
+			Filter []string `json:"filter"`
+			Offset int      `json:"offset"`
+			Limit  int      `json:"limit"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&query)
+		for _, filter := range query.Filter {
+			if !fakeFilter.MatchString(filter) {
+				f.writeError(w, http.StatusBadRequest, "invalid_search_filter")
+				return
+			}
+		}
+
+		var ids []int64
+		for _, doc := range docs {
+			text := strings.ToLower(doc.Title + "\n" + doc.Content + "\n" + strings.Join(doc.Comments, "\n"))
+			if strings.Contains(text, strings.ToLower(query.Q)) && fakeMatches(doc, query.Filter) {
+				ids = append(ids, doc.ID)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		total := len(ids)
+		if query.Offset > len(ids) {
+			query.Offset = len(ids)
+		}
+		ids = ids[query.Offset:]
+		if query.Limit < len(ids) {
+			ids = ids[:query.Limit]
+		}
+		hits := make([]map[string]int64, 0, len(ids))
+		for _, id := range ids {
+			hits = append(hits, map[string]int64{"id": id})
+		}
+		f.writeJSON(w, http.StatusOK, map[string]interface{}{"hits": hits, "estimatedTotalHits": total})
+	default:
+		f.writeError(w, http.StatusNotFound, "not_found")
+	}
+}
+
+// fakeMatches returns true if the document matches all the filters, array
+// attributes match if one of their values does
+func fakeMatches(doc *meilisearchDocument, filters []string) bool {
+	data, _ := json.Marshal(doc)
+	var attrs map[string]interface{}
+	_ = json.Unmarshal(data, &attrs)
+
+	for _, filter := range filters {
+		m := fakeFilter.FindStringSubmatch(filter)
+		values := strings.Split(m[3], ", ")
+		if len(m[4]) > 0 {
+			values = []string{m[4]}
+		}
+		attr, ok := attrs[m[2]].([]interface{})
+		if !ok {
+			attr = []interface{}{attrs[m[2]]}
+		}
+		found := false
+		for _, v := range attr {
+			for _, value := range values {
+				found = found || fmt.Sprint(v) == value
+			}
+		}
+		if found == (len(m[1]) > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func searchIDs(t *testing.T, indexer Indexer, keyword string, repoIDs []int64) []int64 {
+	res, err := indexer.Search(keyword, repoIDs, 50, 0)
+	assert.NoError(t, err)
+	ids := make([]int64, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestMeilisearchIndexer(t *testing.T) {
+	defer func(interval time.Duration) {
+		meilisearchTaskPollInterval = interval
+	}(meilisearchTaskPollInterval)
+	meilisearchTaskPollInterval = 0
+
+	fake := newFakeMeilisearch("secret")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	indexer, err := NewMeilisearchIndexer(strings.Replace(server.URL, "://", "://:secret@", 1), "gitea_issues")
+	assert.NoError(t, err)
+
+	// the bootstrap can be repeated, only the settings are applied again
+	exists, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	exists, err = indexer.Init()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.EqualValues(t, 1, fake.indexCreation)
+	assert.EqualValues(t, []interface{}{"repo_id", "is_pull", "is_closed", "poster_id", "label_ids", "assignee_ids"},
+		fake.settings["gitea_issues"]["filterableAttributes"])
+
+	assert.NoError(t, indexer.Index([]*IndexerData{
+		{ID: 1, RepoID: 2, Title: "Crash on startup", Content: "the server crashes", PosterID: 3, LabelIDs: []int64{4}},
+		{ID: 2, RepoID: 2, Title: "Slow search", Comments: []string{"it crashes too"}, IsClosed: true, AssigneeIDs: []int64{5}},
+		{ID: 3, RepoID: 6, Title: "Another crash"},
+	}))
+	assert.EqualValues(t, 4, fake.indexes["gitea_issues"][1].LabelIDs[0])
+	assert.True(t, fake.indexes["gitea_issues"][2].IsClosed)
+
+	assert.EqualValues(t, []int64{1, 2, 3}, searchIDs(t, indexer, "crash", nil))
+	assert.EqualValues(t, []int64{1, 2}, searchIDs(t, indexer, "crash", []int64{2}))
+	assert.EqualValues(t, []int64{1, 2, 3}, searchIDs(t, indexer, "crash", []int64{2, 6}))
+	assert.EqualValues(t, []int64{2}, searchIDs(t, indexer, "slow", []int64{2}))
+
+	// the issues are filtered by state, poster, labels and assignees
+	search := func(opts *SearchOptions) []int64 {
+		res, err := indexer.SearchWithOptions("crash", []int64{2}, opts, 50, 0)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+	assert.EqualValues(t, []int64{1, 2}, search(&SearchOptions{}))
+	assert.EqualValues(t, []int64{2}, search(&SearchOptions{IsClosed: util.OptionalBoolTrue}))
+	assert.EqualValues(t, []int64{1}, search(&SearchOptions{IsClosed: util.OptionalBoolFalse, IsPull: util.OptionalBoolFalse}))
+	assert.EqualValues(t, []int64{1}, search(&SearchOptions{PosterID: 3}))
+	assert.EqualValues(t, []int64{2}, search(&SearchOptions{AssigneeID: 5}))
+	assert.EqualValues(t, []int64{1}, search(&SearchOptions{LabelIDs: []int64{4}}))
+	assert.EqualValues(t, []int64{2}, search(&SearchOptions{LabelIDs: []int64{-4}}))
+	assert.Empty(t, search(&SearchOptions{LabelIDs: []int64{4}, AssigneeID: 5}))
+
+	res, err := indexer.Search("crash", nil, 1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, res.Total)
+	if assert.Len(t, res.Hits, 1) {
+		assert.EqualValues(t, 2, res.Hits[0].ID)
+	}
+
+	assert.NoError(t, indexer.Delete(1, 3))
+	assert.EqualValues(t, []int64{2}, searchIDs(t, indexer, "crash", nil))
+
+	// the errors of Meilisearch are reported
+	indexer.apiKey = "wrong"
+	_, err = indexer.Search("crash", nil, 50, 0)
+	assert.EqualError(t, err, "meilisearch: invalid_api_key (invalid_api_key)")
+}
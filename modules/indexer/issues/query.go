@@ -0,0 +1,428 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrInvalidQuery represents an issue search query which can't be parsed
+type ErrInvalidQuery struct {
+	Reason string
+}
+
+// IsErrInvalidQuery checks if an error is a ErrInvalidQuery
+func IsErrInvalidQuery(err error) bool {
+	_, ok := err.(ErrInvalidQuery)
+	return ok
+}
+
+func (err ErrInvalidQuery) Error() string {
+	return fmt.Sprintf("invalid query: %s", err.Reason)
+}
+
+// querySortTypes maps the values of the sort: qualifier to the sort types of
+// models.IssuesOptions
+var querySortTypes = map[string]string{
+	"created":       "newest",
+	"created-desc":  "newest",
+	"created-asc":   "oldest",
+	"updated":       "recentupdate",
+	"updated-desc":  "recentupdate",
+	"updated-asc":   "leastupdate",
+	"comments":      "mostcomment",
+	"comments-desc": "mostcomment",
+	"comments-asc":  "leastcomment",
+}
+
+// Query is a parsed issue search query
+type Query struct {
+	// Keywords are words or phrases searched with the issue indexer
+	Keywords []string
+	IsClosed util.OptionalBool
+	IsPull   util.OptionalBool
+	// Author, Assignee and Mentions are user names, @me being the doer
+	Author   string
+	Assignee string
+	Mentions string
+	// Labels must all be set on the issues and ExcludedLabels none of them
+	Labels         []string
+	ExcludedLabels []string
+	Milestone      string
+	// Repos are owner/name patterns, which may contain wildcards, matched by
+	// the repositories of the issues
+	Repos    []string
+	SortType string
+	// CreatedAfter, CreatedBefore, UpdatedAfter and UpdatedBefore restrict the
+	// dates if not zero, the after bounds are inclusive and the before bounds
+	// exclusive
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+}
+
+// setOnce sets a single-valued qualifier, failing if it is given twice with
+// different values
+func setOnce(field *string, qualifier, value string) error {
+	if len(*field) > 0 && !strings.EqualFold(*field, value) {
+		return ErrInvalidQuery{Reason: fmt.Sprintf("%s: can only be given once", qualifier)}
+	}
+	*field = value
+	return nil
+}
+
+// setOptionalBool sets the state or the type of the issues, failing if it
+// contradicts a previous is: qualifier
+func setOptionalBool(field *util.OptionalBool, value util.OptionalBool) error {
+	if !field.IsNone() && *field != value {
+		return ErrInvalidQuery{Reason: "contradictory is: qualifiers"}
+	}
+	*field = value
+	return nil
+}
+
+// parseDate parses a YYYY-MM-DD date, returning the beginning of the day
+func parseDate(value string) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, ErrInvalidQuery{Reason: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", value)}
+	}
+	return t, nil
+}
+
+// parseDateRange parses the value of the created: and updated: qualifiers,
+// either a day, >, >=, < or <= followed by a day, or a day..day range
+func parseDateRange(value string) (after, before time.Time, err error) {
+	day := 24 * time.Hour
+	switch {
+	case strings.HasPrefix(value, ">="):
+		after, err = parseDate(value[2:])
+	case strings.HasPrefix(value, ">"):
+		after, err = parseDate(value[1:])
+		after = after.Add(day)
+	case strings.HasPrefix(value, "<="):
+		before, err = parseDate(value[2:])
+		before = before.Add(day)
+	case strings.HasPrefix(value, "<"):
+		before, err = parseDate(value[1:])
+	case strings.Contains(value, ".."):
+		bounds := strings.SplitN(value, "..", 2)
+		if after, err = parseDate(bounds[0]); err == nil {
+			before, err = parseDate(bounds[1])
+			before = before.Add(day)
+		}
+	default:
+		after, err = parseDate(value)
+		before = after.Add(day)
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return after, before, nil
+}
+
+// ParseQuery parses an issue search query. Besides words and "exact phrases",
+// a query may contain the qualifiers is: (open, closed, issue or pr),
+// author:, assignee: and mentions: (a user name or @me), label: (negated as
+// -label:), milestone:, repo: (as owner/name, with wildcards), sort:
+// (created, updated or comments, followed by -asc or -desc), created: and
+// updated: (as YYYY-MM-DD, prefixed by >, >=, < or <=, or as a range
+// YYYY-MM-DD..YYYY-MM-DD). Values containing spaces can be quoted.
+func ParseQuery(query string) (*Query, error) {
+	q := &Query{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		if runes[i] == '"' {
+			if end := closingQuote(runes, i); end > 0 {
+				value := strings.ReplaceAll(string(runes[i+1:end]), `\"`, `"`)
+				i = end + 1
+				if len(strings.TrimSpace(value)) > 0 {
+					q.Keywords = append(q.Keywords, value)
+				}
+				continue
+			}
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			// a quoted value may contain spaces, e.g. milestone:"Release 2"
+			if runes[i] == '"' && i > start && runes[i-1] == ':' {
+				if end := closingQuote(runes, i); end > 0 {
+					i = end
+				}
+			}
+			i++
+		}
+		term := string(runes[start:i])
+
+		if err := q.parseQualifier(term); err != nil {
+			if err == errNotQualifier {
+				q.Keywords = append(q.Keywords, term)
+				continue
+			}
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+var errNotQualifier = errors.New("not a qualifier")
+
+// parseQualifier applies a qualifier to the query, returning errNotQualifier
+// if the term is not one
+func (q *Query) parseQualifier(term string) error {
+	negated := strings.HasPrefix(term, "-")
+	if negated {
+		term = term[1:]
+	}
+	idx := strings.IndexByte(term, ':')
+	if idx <= 0 || idx == len(term)-1 {
+		return errNotQualifier
+	}
+	qualifier, value := strings.ToLower(term[:idx]), term[idx+1:]
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	}
+
+	switch qualifier {
+	case "is", "author", "assignee", "mentions", "milestone", "repo", "sort", "created", "updated":
+		if negated {
+			return ErrInvalidQuery{Reason: fmt.Sprintf("%s: can't be negated", qualifier)}
+		}
+	case "label":
+	default:
+		return errNotQualifier
+	}
+
+	switch qualifier {
+	case "is":
+		switch strings.ToLower(value) {
+		case "open":
+			return setOptionalBool(&q.IsClosed, util.OptionalBoolFalse)
+		case "closed":
+			return setOptionalBool(&q.IsClosed, util.OptionalBoolTrue)
+		case "issue":
+			return setOptionalBool(&q.IsPull, util.OptionalBoolFalse)
+		case "pr", "pull":
+			return setOptionalBool(&q.IsPull, util.OptionalBoolTrue)
+		}
+		return ErrInvalidQuery{Reason: fmt.Sprintf("unknown value %q of is:", value)}
+	case "author":
+		return setOnce(&q.Author, qualifier, value)
+	case "assignee":
+		return setOnce(&q.Assignee, qualifier, value)
+	case "mentions":
+		return setOnce(&q.Mentions, qualifier, value)
+	case "milestone":
+		return setOnce(&q.Milestone, qualifier, value)
+	case "label":
+		if negated {
+			q.ExcludedLabels = append(q.ExcludedLabels, value)
+		} else {
+			q.Labels = append(q.Labels, value)
+		}
+	case "repo":
+		if _, err := path.Match(value, ""); err != nil || !strings.Contains(value, "/") {
+			return ErrInvalidQuery{Reason: fmt.Sprintf("invalid repository %q, expected owner/name", value)}
+		}
+		q.Repos = append(q.Repos, strings.ToLower(value))
+	case "sort":
+		sortType, ok := querySortTypes[strings.ToLower(value)]
+		if !ok {
+			return ErrInvalidQuery{Reason: fmt.Sprintf("unknown value %q of sort:", value)}
+		}
+		return setOnce(&q.SortType, qualifier, sortType)
+	case "created", "updated":
+		after, before, err := parseDateRange(value)
+		if err != nil {
+			return err
+		}
+		if qualifier == "created" {
+			q.CreatedAfter, q.CreatedBefore = after, before
+		} else {
+			q.UpdatedAfter, q.UpdatedBefore = after, before
+		}
+	}
+	return nil
+}
+
+// closingQuote returns the index of the quote closing the one at start,
+// skipping quotes escaped with a backslash, or -1 if there is none
+func closingQuote(runes []rune, start int) int {
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveUser returns the ID of the user a name of the query refers to, 0 if
+// there is no such user
+func resolveUser(doer *models.User, name string) (int64, error) {
+	if strings.EqualFold(name, "@me") {
+		if doer == nil {
+			return 0, ErrInvalidQuery{Reason: "@me requires to be signed in"}
+		}
+		return doer.ID, nil
+	}
+	u, err := models.GetUserByName(strings.TrimPrefix(name, "@"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return u.ID, nil
+}
+
+// mergeOptionalBool sets a state or type condition, returning false if it
+// contradicts the one already set
+func mergeOptionalBool(field *util.OptionalBool, value util.OptionalBool) bool {
+	if value.IsNone() {
+		return true
+	}
+	if !field.IsNone() && *field != value {
+		return false
+	}
+	*field = value
+	return true
+}
+
+// mergeUser sets a user condition, returning false if the user doesn't exist
+// or contradicts the one already set
+func mergeUser(field *int64, doer *models.User, name string) (bool, error) {
+	if len(name) == 0 {
+		return true, nil
+	}
+	id, err := resolveUser(doer, name)
+	if err != nil || id == 0 {
+		return false, err
+	}
+	if *field > 0 && *field != id {
+		return false, nil
+	}
+	*field = id
+	return true, nil
+}
+
+// filterRepos restricts repoIDs to the repositories whose full name matches
+// one of the patterns
+func filterRepos(repoIDs []int64, patterns []string) ([]int64, error) {
+	repos, err := models.GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		return nil, err
+	}
+	filtered := make([]int64, 0, len(repos))
+	for _, repoID := range repoIDs {
+		repo, ok := repos[repoID]
+		if !ok {
+			continue
+		}
+		fullName := strings.ToLower(repo.OwnerName + "/" + repo.Name)
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, fullName); matched {
+				filtered = append(filtered, repoID)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// Apply adds the conditions of the query to the options, whose RepoIDs have to
+// be the repositories the doer can read, doer being the user @me refers to
+// (nil if not signed in). If the query can't match any issue, IssueIDs is set
+// so that no issue is found.
+func (q *Query) Apply(opts *models.IssuesOptions, doer *models.User) error {
+	found, err := q.apply(opts, doer)
+	if err != nil {
+		return err
+	}
+	if !found {
+		opts.IssueIDs = []int64{-1}
+	}
+	return nil
+}
+
+func (q *Query) apply(opts *models.IssuesOptions, doer *models.User) (bool, error) {
+	if !mergeOptionalBool(&opts.IsClosed, q.IsClosed) || !mergeOptionalBool(&opts.IsPull, q.IsPull) {
+		return false, nil
+	}
+	for _, user := range []struct {
+		id   *int64
+		name string
+	}{
+		{&opts.PosterID, q.Author},
+		{&opts.AssigneeID, q.Assignee},
+		{&opts.MentionedID, q.Mentions},
+	} {
+		if found, err := mergeUser(user.id, doer, user.name); err != nil || !found {
+			return false, err
+		}
+	}
+
+	if len(q.Repos) > 0 {
+		repoIDs, err := filterRepos(opts.RepoIDs, q.Repos)
+		if err != nil || len(repoIDs) == 0 {
+			return false, err
+		}
+		opts.RepoIDs = repoIDs
+	}
+
+	opts.LabelNames = append(opts.LabelNames, q.Labels...)
+	opts.ExcludedLabelNames = append(opts.ExcludedLabelNames, q.ExcludedLabels...)
+	if len(q.Milestone) > 0 {
+		opts.MilestoneName = q.Milestone
+	}
+	if len(q.SortType) > 0 {
+		opts.SortType = q.SortType
+	}
+	for _, date := range []struct {
+		unix *int64
+		time time.Time
+	}{
+		{&opts.CreatedAfterUnix, q.CreatedAfter},
+		{&opts.CreatedBeforeUnix, q.CreatedBefore},
+		{&opts.UpdatedAfterUnix, q.UpdatedAfter},
+		{&opts.UpdatedBeforeUnix, q.UpdatedBefore},
+	} {
+		if !date.time.IsZero() {
+			*date.unix = date.time.Unix()
+		}
+	}
+
+	if len(q.Keywords) > 0 {
+		issueIDs, err := SearchIssuesByKeyword(opts.RepoIDs, strings.Join(q.Keywords, " "), &SearchOptions{
+			IsPull:     opts.IsPull,
+			IsClosed:   opts.IsClosed,
+			PosterID:   opts.PosterID,
+			AssigneeID: opts.AssigneeID,
+			LabelIDs:   opts.LabelIDs,
+		})
+		if err != nil || len(issueIDs) == 0 {
+			return false, err
+		}
+		opts.IssueIDs = issueIDs
+	}
+	return true, nil
+}
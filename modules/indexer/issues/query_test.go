@@ -0,0 +1,112 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	day := func(value string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		assert.NoError(t, err)
+		return d
+	}
+
+	for query, expected := range map[string]*Query{
+		"":                   {},
+		"crash on startup":   {Keywords: []string{"crash", "on", "startup"}},
+		`"crash on" startup`: {Keywords: []string{"crash on", "startup"}},
+		"is:open is:pr":      {IsClosed: util.OptionalBoolFalse, IsPull: util.OptionalBoolTrue},
+		"is:closed is:issue": {IsClosed: util.OptionalBoolTrue, IsPull: util.OptionalBoolFalse},
+		"author:user2 assignee:@me mentions:user1": {Author: "user2", Assignee: "@me", Mentions: "user1"},
+		`label:bug -label:wontfix label:"help wanted"`: {
+			Labels:         []string{"bug", "help wanted"},
+			ExcludedLabels: []string{"wontfix"},
+		},
+		`milestone:"Release 2" crash`: {Milestone: "Release 2", Keywords: []string{"crash"}},
+		"repo:Org/* repo:user2/repo1": {Repos: []string{"org/*", "user2/repo1"}},
+		"sort:updated-desc":           {SortType: "recentupdate"},
+		"sort:comments-asc":           {SortType: "leastcomment"},
+		"created:>2020-01-01":         {CreatedAfter: day("2020-01-02")},
+		"created:>=2020-01-01":        {CreatedAfter: day("2020-01-01")},
+		"updated:<2020-01-01":         {UpdatedBefore: day("2020-01-01")},
+		"updated:<=2020-01-01":        {UpdatedBefore: day("2020-01-02")},
+		"created:2020-01-01":          {CreatedAfter: day("2020-01-01"), CreatedBefore: day("2020-01-02")},
+		"created:2020-01-01..2020-01-31": {
+			CreatedAfter:  day("2020-01-01"),
+			CreatedBefore: day("2020-02-01"),
+		},
+		"http://example.com -crash": {Keywords: []string{"http://example.com", "-crash"}},
+	} {
+		q, err := ParseQuery(query)
+		assert.NoError(t, err, query)
+		assert.EqualValues(t, expected, q, query)
+	}
+
+	for _, query := range []string{
+		"is:open is:closed",
+		"is:draft",
+		"author:user1 author:user2",
+		"-author:user1",
+		"sort:stars",
+		"created:2020-13-01",
+		"repo:gitea",
+	} {
+		_, err := ParseQuery(query)
+		assert.True(t, IsErrInvalidQuery(err), query)
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	user1 := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+
+	search := func(query string, doer *models.User) []int64 {
+		q, err := ParseQuery(query)
+		assert.NoError(t, err, query)
+		opts := &models.IssuesOptions{RepoIDs: []int64{1}, SortType: "oldest"}
+		assert.NoError(t, q.Apply(opts, doer), query)
+		issues, err := models.Issues(opts)
+		assert.NoError(t, err, query)
+		ids := make([]int64, 0, len(issues))
+		for _, issue := range issues {
+			ids = append(ids, issue.ID)
+		}
+		return ids
+	}
+
+	assert.EqualValues(t, []int64{1, 2, 3, 5, 11}, search("", nil))
+	assert.EqualValues(t, []int64{1, 5}, search("is:issue", nil))
+	assert.EqualValues(t, []int64{5}, search("is:closed", nil))
+	assert.EqualValues(t, []int64{1, 2}, search("label:LABEL1", nil))
+	assert.EqualValues(t, []int64{1, 2}, search("label:label1 -label:label2", nil))
+	assert.EqualValues(t, []int64{3, 5, 11}, search("-label:label1", nil))
+	assert.EqualValues(t, []int64{2}, search(`milestone:"Milestone1"`, nil))
+	assert.EqualValues(t, []int64{5}, search("author:user2", nil))
+	assert.EqualValues(t, []int64{1, 2, 3, 11}, search("author:@me", user1))
+	assert.EqualValues(t, []int64{}, search("author:nonexistent", nil))
+	assert.EqualValues(t, []int64{11}, search("created:>=2020-01-01", nil))
+	assert.EqualValues(t, []int64{1, 2, 3, 5, 11}, search("repo:user2/*", nil))
+	assert.EqualValues(t, []int64{}, search("repo:org3/*", nil))
+	assert.EqualValues(t, []int64{11, 5, 3, 2, 1}, search("sort:created-desc", nil))
+
+	q, err := ParseQuery("assignee:@me")
+	assert.NoError(t, err)
+	assert.True(t, IsErrInvalidQuery(q.Apply(&models.IssuesOptions{RepoIDs: []int64{1}}, nil)))
+
+	// the conditions of the query can't widen the ones of the options
+	q, err = ParseQuery("is:pr")
+	assert.NoError(t, err)
+	opts := &models.IssuesOptions{RepoIDs: []int64{1}, IsPull: util.OptionalBoolFalse}
+	assert.NoError(t, q.Apply(opts, nil))
+	assert.EqualValues(t, []int64{-1}, opts.IssueIDs)
+}
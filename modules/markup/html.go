@@ -447,6 +447,10 @@ func mentionProcessor(ctx *postProcessCtx, node *html.Node) {
 		return
 	}
 	mention := node.Data[loc.Start:loc.End]
+	if idx := strings.IndexByte(mention, '/'); idx > 0 {
+		replaceContent(node, loc.Start, loc.End, createLink(util.URLJoin(setting.AppURL, "org", mention[1:idx], "teams", mention[idx+1:]), mention, "mention"))
+		return
+	}
 	var teams string
 	teams, ok := ctx.metas["teams"]
 	if ok && strings.Contains(teams, ","+strings.ToLower(mention[1:])+",") {
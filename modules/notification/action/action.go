@@ -86,6 +86,40 @@ func (a *actionNotifier) NotifyIssueChangeStatus(doer *models.User, issue *model
 	}
 }
 
+// NotifyBulkEditIssues notifies the issues closed or reopened at once to notifiers
+func (a *actionNotifier) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	for _, issue := range issues {
+		if issue.StatusComment != nil {
+			a.NotifyIssueChangeStatus(doer, issue.Issue, issue.StatusComment, issue.IsClosed)
+		}
+	}
+}
+
+// NotifyTransferIssue records the transfer of an issue in the feeds of both
+// repositories, without naming a private new repository in a public feed
+func (a *actionNotifier) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	newRef := fmt.Sprintf("%s#%d", issue.Repo.FullName(), issue.Index)
+	for _, repo := range []*models.Repository{oldRepo, issue.Repo} {
+		content := comment.OldRef + "|" + newRef
+		if issue.Repo.IsPrivate && !repo.IsPrivate {
+			content = comment.OldRef + "|"
+		}
+		if err := models.NotifyWatchers(&models.Action{
+			ActUserID: doer.ID,
+			ActUser:   doer,
+			OpType:    models.ActionTransferIssue,
+			RepoID:    repo.ID,
+			Repo:      repo,
+			Comment:   comment,
+			CommentID: comment.ID,
+			IsPrivate: repo.IsPrivate,
+			Content:   content,
+		}); err != nil {
+			log.Error("NotifyWatchers: %v", err)
+		}
+	}
+}
+
 // NotifyCreateIssueComment notifies comment on an issue to notifiers
 func (a *actionNotifier) NotifyCreateIssueComment(doer *models.User, repo *models.Repository,
 	issue *models.Issue, comment *models.Comment) {
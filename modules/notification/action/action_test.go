@@ -45,3 +45,37 @@ func TestRenameRepoAction(t *testing.T) {
 	models.AssertExistsAndLoadBean(t, actionBean)
 	models.CheckConsistencyFor(t, &models.Action{})
 }
+
+func TestTransferIssueAction(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	oldRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	newRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 2}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+
+	comment, err := models.TransferIssue(user, issue, newRepo)
+	assert.NoError(t, err)
+	assert.NoError(t, issue.LoadRepo())
+
+	NewNotifier().NotifyTransferIssue(user, issue, oldRepo, comment)
+
+	// repo2 is private, it is not named in the feed of the public repo1
+	models.AssertExistsAndLoadBean(t, &models.Action{
+		OpType:    models.ActionTransferIssue,
+		UserID:    user.ID,
+		RepoID:    oldRepo.ID,
+		CommentID: comment.ID,
+		IsPrivate: false,
+		Content:   "user2/repo1#1|",
+	})
+	models.AssertExistsAndLoadBean(t, &models.Action{
+		OpType:    models.ActionTransferIssue,
+		UserID:    user.ID,
+		RepoID:    newRepo.ID,
+		CommentID: comment.ID,
+		IsPrivate: true,
+		Content:   "user2/repo1#1|user2/repo2#3",
+	})
+	models.CheckConsistencyFor(t, &models.Action{})
+}
@@ -29,12 +29,17 @@ type Notifier interface {
 	NotifyIssueChangeTitle(doer *models.User, issue *models.Issue, oldTitle string)
 	NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
 		addedLabels []*models.Label, removedLabels []*models.Label)
+	NotifyIssueChangeStopwatch(doer *models.User, issue *models.Issue)
+	NotifyIssueReminder(doer *models.User, issue *models.Issue, receiver *models.User, content string)
+	NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment)
+	NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue)
 
 	NotifyNewPullRequest(*models.PullRequest)
 	NotifyMergePullRequest(*models.PullRequest, *models.User)
 	NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest)
 	NotifyPullRequestReview(*models.PullRequest, *models.Review, *models.Comment)
 	NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullRequest, oldBranch string)
+	NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment)
 
 	NotifyCreateIssueComment(*models.User, *models.Repository,
 		*models.Issue, *models.Comment)
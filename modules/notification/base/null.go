@@ -54,6 +54,10 @@ func (*NullNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models
 func (*NullNotifier) NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullRequest, oldBranch string) {
 }
 
+// NotifyPullReviewRequest places a place holder function
+func (*NullNotifier) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment) {
+}
+
 // NotifyUpdateComment places a place holder function
 func (*NullNotifier) NotifyUpdateComment(doer *models.User, c *models.Comment, oldContent string) {
 }
@@ -99,6 +103,22 @@ func (*NullNotifier) NotifyIssueChangeLabels(doer *models.User, issue *models.Is
 	addedLabels []*models.Label, removedLabels []*models.Label) {
 }
 
+// NotifyIssueChangeStopwatch places a place holder function
+func (*NullNotifier) NotifyIssueChangeStopwatch(doer *models.User, issue *models.Issue) {
+}
+
+// NotifyIssueReminder places a place holder function
+func (*NullNotifier) NotifyIssueReminder(doer *models.User, issue *models.Issue, receiver *models.User, content string) {
+}
+
+// NotifyTransferIssue places a place holder function
+func (*NullNotifier) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+}
+
+// NotifyBulkEditIssues places a place holder function
+func (*NullNotifier) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+}
+
 // NotifyCreateRepository places a place holder function
 func (*NullNotifier) NotifyCreateRepository(doer *models.User, u *models.User, repo *models.Repository) {
 }
@@ -0,0 +1,68 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ci
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/repository"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+type ciNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &ciNotifier{}
+)
+
+// NewNotifier create a new ciNotifier notifier
+func NewNotifier() base.Notifier {
+	return &ciNotifier{}
+}
+
+func (n *ciNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
+	if !setting.CI.Enabled || newCommitID == git.EmptySHA || repo.IsMirror {
+		return
+	}
+	if !strings.HasPrefix(refName, git.BranchPrefix) && !strings.HasPrefix(refName, git.TagPrefix) {
+		return
+	}
+	if err := ci.TriggerPush(pusher, repo, refName, newCommitID); err != nil {
+		log.Error("TriggerPush [%s, %s]: %v", repo.FullName(), refName, err)
+	}
+}
+
+func (n *ciNotifier) NotifyNewPullRequest(pr *models.PullRequest) {
+	if !setting.CI.Enabled {
+		return
+	}
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("pr.LoadIssue: %v", err)
+		return
+	}
+	if err := pr.Issue.LoadPoster(); err != nil {
+		log.Error("pr.Issue.LoadPoster: %v", err)
+		return
+	}
+	if err := ci.TriggerPullRequest(pr.Issue.Poster, pr); err != nil {
+		log.Error("TriggerPullRequest [%d]: %v", pr.ID, err)
+	}
+}
+
+func (n *ciNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	if !setting.CI.Enabled {
+		return
+	}
+	if err := ci.TriggerPullRequest(doer, pr); err != nil {
+		log.Error("TriggerPullRequest [%d]: %v", pr.ID, err)
+	}
+}
@@ -5,9 +5,12 @@
 package indexer
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	stats_indexer "code.gitea.io/gitea/modules/indexer/stats"
 	"code.gitea.io/gitea/modules/log"
@@ -111,6 +114,7 @@ func (r *indexerNotifier) NotifyDeleteRepository(doer *models.User, repo *models
 	if setting.Indexer.RepoIndexerEnabled {
 		code_indexer.DeleteRepoFromIndexer(repo)
 	}
+	commit_indexer.DeleteRepoFromIndexer(repo)
 }
 
 func (r *indexerNotifier) NotifyMigrateRepository(doer *models.User, u *models.User, repo *models.Repository) {
@@ -118,6 +122,7 @@ func (r *indexerNotifier) NotifyMigrateRepository(doer *models.User, u *models.U
 	if setting.Indexer.RepoIndexerEnabled && !repo.IsEmpty {
 		code_indexer.UpdateRepoIndexer(repo)
 	}
+	commit_indexer.UpdateRepoIndexer(repo)
 	if err := stats_indexer.UpdateRepoIndexer(repo); err != nil {
 		log.Error("stats_indexer.UpdateRepoIndexer(%d) failed: %v", repo.ID, err)
 	}
@@ -127,11 +132,35 @@ func (r *indexerNotifier) NotifyPushCommits(pusher *models.User, repo *models.Re
 	if setting.Indexer.RepoIndexerEnabled && refName == git.BranchPrefix+repo.DefaultBranch {
 		code_indexer.UpdateRepoIndexer(repo)
 	}
+	if strings.HasPrefix(refName, git.BranchPrefix) {
+		commit_indexer.UpdateRepoIndexer(repo)
+	}
 	if err := stats_indexer.UpdateRepoIndexer(repo); err != nil {
 		log.Error("stats_indexer.UpdateRepoIndexer(%d) failed: %v", repo.ID, err)
 	}
 }
 
+func (r *indexerNotifier) NotifyDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	if refType == "branch" {
+		commit_indexer.UpdateRepoIndexer(repo)
+	}
+}
+
+// the references of mirrors aren't always full names, the commit indexer
+// ignores the updates which don't change the branches anyway
+
+func (r *indexerNotifier) NotifySyncPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
+	commit_indexer.UpdateRepoIndexer(repo)
+}
+
+func (r *indexerNotifier) NotifySyncCreateRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	commit_indexer.UpdateRepoIndexer(repo)
+}
+
+func (r *indexerNotifier) NotifySyncDeleteRef(doer *models.User, repo *models.Repository, refType, refFullName string) {
+	commit_indexer.UpdateRepoIndexer(repo)
+}
+
 func (r *indexerNotifier) NotifyIssueChangeContent(doer *models.User, issue *models.Issue, oldContent string) {
 	issue_indexer.UpdateIssueIndexer(issue)
 }
@@ -139,3 +168,46 @@ func (r *indexerNotifier) NotifyIssueChangeContent(doer *models.User, issue *mod
 func (r *indexerNotifier) NotifyIssueChangeTitle(doer *models.User, issue *models.Issue, oldTitle string) {
 	issue_indexer.UpdateIssueIndexer(issue)
 }
+
+// updateIssueFilters updates an issue in the issue indexer after a change of
+// its filterable attributes, loading its comments so that they are kept. Only
+// indexers filtering the issues index these attributes.
+func updateIssueFilters(issue *models.Issue) {
+	if !issue_indexer.IndexesFilters() {
+		return
+	}
+	if issue.Comments == nil {
+		if err := issue.LoadDiscussComments(); err != nil {
+			log.Error("LoadComments failed: %v", err)
+			return
+		}
+	}
+	issue_indexer.UpdateIssueIndexer(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeStatus(doer *models.User, issue *models.Issue, actionComment *models.Comment, isClosed bool) {
+	updateIssueFilters(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeAssignee(doer *models.User, issue *models.Issue, assignee *models.User, removed bool, comment *models.Comment) {
+	updateIssueFilters(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueClearLabels(doer *models.User, issue *models.Issue) {
+	updateIssueFilters(issue)
+}
+
+func (r *indexerNotifier) NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
+	addedLabels []*models.Label, removedLabels []*models.Label) {
+	updateIssueFilters(issue)
+}
+
+func (r *indexerNotifier) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	updateIssueFilters(issue)
+}
+
+func (r *indexerNotifier) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	for _, issue := range issues {
+		updateIssueFilters(issue.Issue)
+	}
+}
@@ -96,7 +96,48 @@ func (m *mailNotifier) NotifyIssueChangeAssignee(doer *models.User, issue *model
 	// mail only sent to added assignees and not self-assignee
 	if !removed && doer.ID != assignee.ID && assignee.EmailNotifications() == models.EmailNotificationsEnabled {
 		ct := fmt.Sprintf("Assigned #%d.", issue.Index)
-		mailer.SendIssueAssignedMail(issue, doer, ct, comment, []string{assignee.Email})
+		if err := mailer.SendIssueAssignedMail(issue, doer, ct, comment, []*models.User{assignee}); err != nil {
+			log.Error("SendIssueAssignedMail: %v", err)
+		}
+	}
+}
+
+func (m *mailNotifier) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment) {
+	if isRequest && doer.ID != reviewer.ID && reviewer.EmailNotifications() == models.EmailNotificationsEnabled {
+		ct := fmt.Sprintf("Requested to review #%d.", issue.Index)
+		if err := mailer.SendReviewRequestMail(issue, doer, ct, comment, []*models.User{reviewer}); err != nil {
+			log.Error("SendReviewRequestMail: %v", err)
+		}
+	}
+}
+
+func (m *mailNotifier) NotifyIssueReminder(doer *models.User, issue *models.Issue, receiver *models.User, content string) {
+	if receiver.EmailNotifications() != models.EmailNotificationsEnabled {
+		return
+	}
+	if err := mailer.SendIssueReminderMail(issue, doer, content, []*models.User{receiver}); err != nil {
+		log.Error("SendIssueReminderMail: %v", err)
+	}
+}
+
+func (m *mailNotifier) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	if err := mailer.MailIssueTransferred(issue, doer, comment); err != nil {
+		log.Error("MailIssueTransferred: %v", err)
+	}
+}
+
+func (m *mailNotifier) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	if err := mailer.MailIssuesBulkEdited(doer, repo, issues); err != nil {
+		log.Error("MailIssuesBulkEdited: %v", err)
+	}
+}
+
+func (m *mailNotifier) NotifyNewRelease(rel *models.Release) {
+	if rel.IsDraft {
+		return
+	}
+	if err := mailer.MailNewRelease(rel); err != nil {
+		log.Error("MailNewRelease: %v", err)
 	}
 }
 
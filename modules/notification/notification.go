@@ -8,6 +8,7 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/notification/action"
 	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/notification/ci"
 	"code.gitea.io/gitea/modules/notification/indexer"
 	"code.gitea.io/gitea/modules/notification/mail"
 	"code.gitea.io/gitea/modules/notification/ui"
@@ -35,6 +36,7 @@ func NewContext() {
 	RegisterNotifier(indexer.NewNotifier())
 	RegisterNotifier(webhook.NewNotifier())
 	RegisterNotifier(action.NewNotifier())
+	RegisterNotifier(ci.NewNotifier())
 }
 
 // NotifyCreateIssueComment notifies issue comment related message to notifiers
@@ -94,6 +96,13 @@ func NotifyPullRequestChangeTargetBranch(doer *models.User, pr *models.PullReque
 	}
 }
 
+// NotifyPullReviewRequest notifies when a user is requested to review a pull request, or the request is withdrawn
+func NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment) {
+	for _, notifier := range notifiers {
+		notifier.NotifyPullReviewRequest(doer, issue, reviewer, isRequest, comment)
+	}
+}
+
 // NotifyUpdateComment notifies update comment to notifiers
 func NotifyUpdateComment(doer *models.User, c *models.Comment, oldContent string) {
 	for _, notifier := range notifiers {
@@ -172,6 +181,34 @@ func NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
 	}
 }
 
+// NotifyIssueChangeStopwatch notifies a started, stopped or cancelled stopwatch to notifiers
+func NotifyIssueChangeStopwatch(doer *models.User, issue *models.Issue) {
+	for _, notifier := range notifiers {
+		notifier.NotifyIssueChangeStopwatch(doer, issue)
+	}
+}
+
+// NotifyIssueReminder notifies a scheduled reminder of an issue sent to a user
+func NotifyIssueReminder(doer *models.User, issue *models.Issue, receiver *models.User, content string) {
+	for _, notifier := range notifiers {
+		notifier.NotifyIssueReminder(doer, issue, receiver, content)
+	}
+}
+
+// NotifyTransferIssue notifies the transfer of an issue to another repository to notifiers
+func NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	for _, notifier := range notifiers {
+		notifier.NotifyTransferIssue(doer, issue, oldRepo, comment)
+	}
+}
+
+// NotifyBulkEditIssues notifies the changes of many issues at once to notifiers
+func NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	for _, notifier := range notifiers {
+		notifier.NotifyBulkEditIssues(doer, repo, issues)
+	}
+}
+
 // NotifyCreateRepository notifies create repository to notifiers
 func NotifyCreateRepository(doer *models.User, u *models.User, repo *models.Repository) {
 	for _, notifier := range notifiers {
@@ -5,9 +5,16 @@
 package ui
 
 import (
+	"fmt"
+
 	"code.gitea.io/gitea/models"
+	modulebase "code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/eventsource"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/notification/base"
+	"code.gitea.io/gitea/modules/references"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/webpush"
 )
 
 type (
@@ -20,6 +27,15 @@ type (
 		issueID              int64
 		commentID            int64
 		notificationAuthorID int64
+		// mentions are the names mentioned by the new content, if any
+		mentions []string
+		// receiverID restricts the notification to a single user, for the reason
+		receiverID int64
+		reason     models.NotificationReason
+		// bulkIssueIDs are the issues of the repository edited at once, notified
+		// together instead of the issue
+		bulkIssueIDs []int64
+		repoID       int64
 	}
 )
 
@@ -36,12 +52,166 @@ func NewNotifier() base.Notifier {
 
 func (ns *notificationService) Run() {
 	for opts := range ns.issueQueue {
-		if err := models.CreateOrUpdateIssueNotifications(opts.issueID, opts.commentID, opts.notificationAuthorID); err != nil {
+		if len(opts.bulkIssueIDs) > 0 {
+			notifyBulkEdit(opts)
+			continue
+		}
+		if opts.receiverID != 0 {
+			if err := models.CreateOrUpdateIssueNotificationForUser(opts.issueID, opts.commentID, opts.notificationAuthorID, opts.receiverID, opts.reason); err != nil {
+				log.Error("Was unable to create issue notification: %v", err)
+				continue
+			}
+			deliverNotifications(opts, []int64{opts.receiverID})
+			continue
+		}
+
+		notified, err := models.CreateOrUpdateIssueNotifications(opts.issueID, opts.commentID, opts.notificationAuthorID, opts.mentions)
+		if err != nil {
+			log.Error("Was unable to create issue notification: %v", err)
+			continue
+		}
+		deliverNotifications(opts, notified)
+	}
+}
+
+// notifyBulkEdit updates the notifications of the issues edited at once, and
+// delivers a single one to each notified user. The notifications themselves
+// stay one per issue, so the users still get a thread for each edited issue:
+// only the event stream updates and the push messages are merged into one.
+func notifyBulkEdit(opts issueNotificationOpts) {
+	notified := make([]int64, 0, 10)
+	seen := make(map[int64]bool)
+	for _, issueID := range opts.bulkIssueIDs {
+		ids, err := models.CreateOrUpdateIssueNotifications(issueID, 0, opts.notificationAuthorID, nil)
+		if err != nil {
 			log.Error("Was unable to create issue notification: %v", err)
+			continue
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				notified = append(notified, id)
+			}
+		}
+	}
+	deliverNotifications(opts, notified)
+}
+
+// deliverNotifications updates the unread notification counts of the notified
+// users having an open event stream, and pushes the notification to the
+// browsers of the other ones
+func deliverNotifications(opts issueNotificationOpts, notified []int64) {
+	if len(notified) == 0 {
+		return
+	}
+
+	manager := eventsource.GetManager()
+	connected := make([]int64, 0, len(notified))
+	disconnected := make([]int64, 0, len(notified))
+	for _, id := range notified {
+		if manager.IsConnected(id) {
+			connected = append(connected, id)
+		} else {
+			disconnected = append(disconnected, id)
+		}
+	}
+
+	if len(connected) > 0 {
+		counts, err := models.GetUnreadNotificationCounts(connected)
+		if err != nil {
+			log.Error("GetUnreadNotificationCounts: %v", err)
+		}
+		for id, count := range counts {
+			manager.SendMessage(id, eventsource.NewNotificationCountEvent(count))
+		}
+	}
+
+	if len(disconnected) > 0 && setting.WebPush.Enabled {
+		msg, err := composePushMessage(opts)
+		if err != nil {
+			log.Error("composePushMessage: %v", err)
+			return
+		}
+		for _, id := range disconnected {
+			webpush.Send(id, msg)
 		}
 	}
 }
 
+// pushContentLength is the maximum length of the content shown by a push
+// notification, which must fit in a single encrypted record
+const pushContentLength = 200
+
+func composePushMessage(opts issueNotificationOpts) (*webpush.Message, error) {
+	if len(opts.bulkIssueIDs) > 0 {
+		return composeBulkPushMessage(opts)
+	}
+	issue, err := models.GetIssueByID(opts.issueID)
+	if err != nil {
+		return nil, fmt.Errorf("GetIssueByID(%d): %v", opts.issueID, err)
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return nil, fmt.Errorf("LoadRepo: %v", err)
+	}
+	doer, err := models.GetUserByID(opts.notificationAuthorID)
+	if err != nil {
+		if !models.IsErrUserNotExist(err) {
+			return nil, fmt.Errorf("GetUserByID(%d): %v", opts.notificationAuthorID, err)
+		}
+		doer = models.NewGhostUser()
+	}
+
+	msg := &webpush.Message{
+		Title: fmt.Sprintf("[%s] %s (#%d)", issue.Repo.FullName(), issue.Title, issue.Index),
+		Body:  fmt.Sprintf("Updated by @%s", doer.Name),
+		URL:   issue.HTMLURL(),
+		Tag:   fmt.Sprintf("issue-%d", issue.ID),
+	}
+	content := issue.Content
+	if opts.commentID != 0 {
+		comment, err := models.GetCommentByID(opts.commentID)
+		if err != nil {
+			return nil, fmt.Errorf("GetCommentByID(%d): %v", opts.commentID, err)
+		}
+		comment.Issue = issue
+		content = comment.Content
+		msg.URL = comment.HTMLURL()
+	} else if issue.PosterID != opts.notificationAuthorID {
+		// the issue was closed, reopened or merged, its content is old news
+		content = ""
+	}
+	if content != "" {
+		msg.Body = fmt.Sprintf("@%s: %s", doer.Name, modulebase.EllipsisString(content, pushContentLength))
+	}
+	return msg, nil
+}
+
+func composeBulkPushMessage(opts issueNotificationOpts) (*webpush.Message, error) {
+	repo, err := models.GetRepositoryByID(opts.repoID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRepositoryByID(%d): %v", opts.repoID, err)
+	}
+	doer, err := models.GetUserByID(opts.notificationAuthorID)
+	if err != nil {
+		if !models.IsErrUserNotExist(err) {
+			return nil, fmt.Errorf("GetUserByID(%d): %v", opts.notificationAuthorID, err)
+		}
+		doer = models.NewGhostUser()
+	}
+
+	return &webpush.Message{
+		Title: fmt.Sprintf("[%s] %d issues updated", repo.FullName(), len(opts.bulkIssueIDs)),
+		Body:  fmt.Sprintf("Updated by @%s", doer.Name),
+		URL:   repo.HTMLURL() + "/issues",
+		Tag:   fmt.Sprintf("bulk-%d", repo.ID),
+	}, nil
+}
+
+// issueUpdated tells the streams following the issue that it was updated
+func issueUpdated(issue *models.Issue, doer *models.User) {
+	eventsource.GetManager().SendIssueMessage(issue.ID, eventsource.NewIssueUpdateEvent(issue.ID, doer.ID))
+}
+
 func (ns *notificationService) NotifyCreateIssueComment(doer *models.User, repo *models.Repository,
 	issue *models.Issue, comment *models.Comment) {
 	var opts = issueNotificationOpts{
@@ -50,14 +220,17 @@ func (ns *notificationService) NotifyCreateIssueComment(doer *models.User, repo
 	}
 	if comment != nil {
 		opts.commentID = comment.ID
+		opts.mentions = references.FindAllMentionsMarkdown(comment.Content)
 	}
 	ns.issueQueue <- opts
+	issueUpdated(issue, doer)
 }
 
 func (ns *notificationService) NotifyNewIssue(issue *models.Issue) {
 	ns.issueQueue <- issueNotificationOpts{
 		issueID:              issue.ID,
 		notificationAuthorID: issue.Poster.ID,
+		mentions:             references.FindAllMentionsMarkdown(issue.Content),
 	}
 }
 
@@ -66,6 +239,7 @@ func (ns *notificationService) NotifyIssueChangeStatus(doer *models.User, issue
 		issueID:              issue.ID,
 		notificationAuthorID: doer.ID,
 	}
+	issueUpdated(issue, doer)
 }
 
 func (ns *notificationService) NotifyMergePullRequest(pr *models.PullRequest, doer *models.User) {
@@ -73,12 +247,14 @@ func (ns *notificationService) NotifyMergePullRequest(pr *models.PullRequest, do
 		issueID:              pr.Issue.ID,
 		notificationAuthorID: doer.ID,
 	}
+	issueUpdated(pr.Issue, doer)
 }
 
 func (ns *notificationService) NotifyNewPullRequest(pr *models.PullRequest) {
 	ns.issueQueue <- issueNotificationOpts{
 		issueID:              pr.Issue.ID,
 		notificationAuthorID: pr.Issue.PosterID,
+		mentions:             references.FindAllMentionsMarkdown(pr.Issue.Content),
 	}
 }
 
@@ -86,9 +262,113 @@ func (ns *notificationService) NotifyPullRequestReview(pr *models.PullRequest, r
 	var opts = issueNotificationOpts{
 		issueID:              pr.Issue.ID,
 		notificationAuthorID: r.Reviewer.ID,
+		mentions:             references.FindAllMentionsMarkdown(r.Content),
 	}
 	if c != nil {
 		opts.commentID = c.ID
 	}
 	ns.issueQueue <- opts
+	issueUpdated(pr.Issue, r.Reviewer)
+}
+
+func (ns *notificationService) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	issueUpdated(pr.Issue, doer)
+}
+
+func (ns *notificationService) NotifyUpdateComment(doer *models.User, c *models.Comment, oldContent string) {
+	eventsource.GetManager().SendIssueMessage(c.IssueID, eventsource.NewIssueUpdateEvent(c.IssueID, doer.ID))
+}
+
+func (ns *notificationService) NotifyDeleteComment(doer *models.User, c *models.Comment) {
+	eventsource.GetManager().SendIssueMessage(c.IssueID, eventsource.NewIssueUpdateEvent(c.IssueID, doer.ID))
+}
+
+func (ns *notificationService) NotifyIssueChangeMilestone(doer *models.User, issue *models.Issue, oldMilestoneID int64) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueChangeAssignee(doer *models.User, issue *models.Issue, assignee *models.User, removed bool, comment *models.Comment) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyPullReviewRequest(doer *models.User, issue *models.Issue, reviewer *models.User, isRequest bool, comment *models.Comment) {
+	if isRequest && doer.ID != reviewer.ID {
+		var opts = issueNotificationOpts{
+			issueID:              issue.ID,
+			notificationAuthorID: doer.ID,
+			receiverID:           reviewer.ID,
+			reason:               models.NotificationReasonReviewRequested,
+		}
+		if comment != nil {
+			opts.commentID = comment.ID
+		}
+		ns.issueQueue <- opts
+	}
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueChangeContent(doer *models.User, issue *models.Issue, oldContent string) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueChangeTitle(doer *models.User, issue *models.Issue, oldTitle string) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueClearLabels(doer *models.User, issue *models.Issue) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueChangeLabels(doer *models.User, issue *models.Issue,
+	addedLabels []*models.Label, removedLabels []*models.Label) {
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyIssueChangeStopwatch(doer *models.User, issue *models.Issue) {
+	event, err := eventsource.NewStopwatchesEvent(doer.ID)
+	if err != nil {
+		log.Error("NewStopwatchesEvent(%d): %v", doer.ID, err)
+		return
+	}
+	eventsource.GetManager().SendMessage(doer.ID, event)
+}
+
+func (ns *notificationService) NotifyIssueReminder(doer *models.User, issue *models.Issue, receiver *models.User, content string) {
+	ns.issueQueue <- issueNotificationOpts{
+		issueID:              issue.ID,
+		notificationAuthorID: doer.ID,
+		receiverID:           receiver.ID,
+		reason:               models.NotificationReasonReminder,
+	}
+}
+
+func (ns *notificationService) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	ns.issueQueue <- issueNotificationOpts{
+		issueID:              issue.ID,
+		commentID:            comment.ID,
+		notificationAuthorID: doer.ID,
+	}
+	issueUpdated(issue, doer)
+}
+
+func (ns *notificationService) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	opts := issueNotificationOpts{
+		notificationAuthorID: doer.ID,
+		repoID:               repo.ID,
+	}
+	if len(issues) == 1 {
+		opts.issueID = issues[0].ID
+	} else {
+		opts.bulkIssueIDs = make([]int64, 0, len(issues))
+		for _, issue := range issues {
+			opts.bulkIssueIDs = append(opts.bulkIssueIDs, issue.ID)
+		}
+	}
+	ns.issueQueue <- opts
+	for _, issue := range issues {
+		issueUpdated(issue.Issue, doer)
+	}
 }
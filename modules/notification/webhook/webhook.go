@@ -5,6 +5,8 @@
 package webhook
 
 import (
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -14,6 +16,8 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	webhook_module "code.gitea.io/gitea/modules/webhook"
+
+	"github.com/unknwon/com"
 )
 
 type webhookNotifier struct {
@@ -275,6 +279,39 @@ func (m *webhookNotifier) NotifyNewIssue(issue *models.Issue) {
 	}
 }
 
+// NotifyTransferIssue sends an issue event leaving the old repository with the
+// old index and an issue event opened in the new repository
+func (m *webhookNotifier) NotifyTransferIssue(doer *models.User, issue *models.Issue, oldRepo *models.Repository, comment *models.Comment) {
+	if err := issue.LoadPoster(); err != nil {
+		log.Error("issue.LoadPoster: %v", err)
+		return
+	}
+	apiIssue := issue.APIFormat()
+
+	oldIndex := com.StrTo(comment.OldRef[strings.LastIndex(comment.OldRef, "#")+1:]).MustInt64()
+	mode, _ := models.AccessLevel(issue.Poster, oldRepo)
+	if err := webhook_module.PrepareWebhooks(oldRepo, models.HookEventIssues, &api.IssuePayload{
+		Action:     api.HookIssueTransferred,
+		Index:      oldIndex,
+		Issue:      apiIssue,
+		Repository: oldRepo.APIFormat(mode),
+		Sender:     doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareWebhooks [repo: %d]: %v", oldRepo.ID, err)
+	}
+
+	mode, _ = models.AccessLevel(issue.Poster, issue.Repo)
+	if err := webhook_module.PrepareWebhooks(issue.Repo, models.HookEventIssues, &api.IssuePayload{
+		Action:     api.HookIssueOpened,
+		Index:      issue.Index,
+		Issue:      apiIssue,
+		Repository: issue.Repo.APIFormat(mode),
+		Sender:     doer.APIFormat(),
+	}); err != nil {
+		log.Error("PrepareWebhooks [repo: %d]: %v", issue.Repo.ID, err)
+	}
+}
+
 func (m *webhookNotifier) NotifyNewPullRequest(pull *models.PullRequest) {
 	if err := pull.LoadIssue(); err != nil {
 		log.Error("pull.LoadIssue: %v", err)
@@ -501,6 +538,31 @@ func (m *webhookNotifier) NotifyIssueChangeMilestone(doer *models.User, issue *m
 	}
 }
 
+func (m *webhookNotifier) NotifyBulkEditIssues(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) {
+	// Send the same payloads as when the issues are changed one by one
+	for _, issue := range issues {
+		if err := issue.LoadPoster(); err != nil {
+			log.Error("LoadPoster: %v", err)
+			continue
+		}
+		if issue.StatusComment != nil {
+			m.NotifyIssueChangeStatus(doer, issue.Issue, issue.StatusComment, issue.IsClosed)
+		}
+		if len(issue.AddedLabels) > 0 || len(issue.RemovedLabels) > 0 {
+			m.NotifyIssueChangeLabels(doer, issue.Issue, issue.AddedLabels, issue.RemovedLabels)
+		}
+		for _, assignee := range issue.AddedAssignees {
+			m.NotifyIssueChangeAssignee(doer, issue.Issue, assignee, false, nil)
+		}
+		for _, assignee := range issue.RemovedAssignees {
+			m.NotifyIssueChangeAssignee(doer, issue.Issue, assignee, true, nil)
+		}
+		if issue.IsMilestoneChanged {
+			m.NotifyIssueChangeMilestone(doer, issue.Issue, issue.OldMilestoneID)
+		}
+	}
+}
+
 func (m *webhookNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *repository.PushCommits) {
 	apiPusher := pusher.APIFormat()
 	apiCommits, err := commits.ToAPIPayloadCommits(repo.RepoPath(), repo.HTMLURL())
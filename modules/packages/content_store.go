@@ -0,0 +1,98 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/models"
+)
+
+// ErrFileTooLarge is returned if the content exceeds the maximum size of a package file
+var ErrFileTooLarge = errors.New("File is too large")
+
+// ContentStore provides a simple file system based storage of package files, addressed
+// by the SHA256 hash of their content like the LFS content store.
+type ContentStore struct {
+	BasePath string
+	// MaxSize is the maximum size of a file in bytes, negative values mean unlimited
+	MaxSize int64
+}
+
+// Get returns the content with the given SHA256 hash
+func (s *ContentStore) Get(hashSHA256 string) (*os.File, error) {
+	return os.Open(s.path(hashSHA256))
+}
+
+// Put writes the content of r to the store and returns a package file with the size and
+// the hashes of the content, the name of the file is left to the caller.
+func (s *ContentStore) Put(r io.Reader) (*models.PackageFile, error) {
+	if err := os.MkdirAll(s.BasePath, 0750); err != nil {
+		return nil, err
+	}
+	file, err := ioutil.TempFile(s.BasePath, "upload-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+
+	if s.MaxSize >= 0 {
+		r = io.LimitReader(r, s.MaxSize+1)
+	}
+	hashes := []hash.Hash{md5.New(), sha1.New(), sha256.New(), sha512.New()}
+	writers := []io.Writer{file}
+	for _, h := range hashes {
+		writers = append(writers, h)
+	}
+	written, err := io.Copy(io.MultiWriter(writers...), r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	} else if s.MaxSize >= 0 && written > s.MaxSize {
+		return nil, ErrFileTooLarge
+	}
+
+	pf := &models.PackageFile{
+		Size:       written,
+		HashMD5:    hex.EncodeToString(hashes[0].Sum(nil)),
+		HashSHA1:   hex.EncodeToString(hashes[1].Sum(nil)),
+		HashSHA256: hex.EncodeToString(hashes[2].Sum(nil)),
+		HashSHA512: hex.EncodeToString(hashes[3].Sum(nil)),
+	}
+	path := s.path(pf.HashSHA256)
+	if err = os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	return pf, os.Rename(file.Name(), path)
+}
+
+// Delete removes the content with the given SHA256 hash from the store
+func (s *ContentStore) Delete(hashSHA256 string) error {
+	err := os.Remove(s.path(hashSHA256))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path returns the location of the content, ab/cd/ef01...
+func (s *ContentStore) path(hashSHA256 string) string {
+	if len(hashSHA256) < 5 {
+		return filepath.Join(s.BasePath, hashSHA256)
+	}
+	return filepath.Join(s.BasePath, hashSHA256[0:2], hashSHA256[2:4], hashSHA256[4:])
+}
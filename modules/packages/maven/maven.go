@@ -0,0 +1,166 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package maven implements the layout and the metadata formats of Maven repositories,
+// https://maven.apache.org/repository/layout.html
+package maven
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MetadataFilename is the name of the file listing the versions of an artifact
+const MetadataFilename = "maven-metadata.xml"
+
+// ErrInvalidPath is returned if a path does not address a file of an artifact
+var ErrInvalidPath = errors.New("Invalid path")
+
+var segmentPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.\-+]*$`)
+
+// checksumExtensions are the extensions of checksum files, named after their hash algorithms
+var checksumExtensions = []string{".md5", ".sha1", ".sha256", ".sha512"}
+
+// Path represents the location of a file in a Maven repository. The version is empty if the
+// path addresses the metadata of an artifact.
+type Path struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Filename   string
+	// Checksum is the hash algorithm if the path addresses the checksum of the file
+	Checksum string
+}
+
+// IsMetadata returns true if the path addresses the metadata of the artifact
+func (p *Path) IsMetadata() bool {
+	return len(p.Version) == 0
+}
+
+// PackageName returns the name of the package of the artifact, groupId:artifactId
+func (p *Path) PackageName() string {
+	return p.GroupID + ":" + p.ArtifactID
+}
+
+// ParsePath parses a path like org/example/artifact/1.0/artifact-1.0.jar or
+// org/example/artifact/maven-metadata.xml.sha1
+func ParsePath(path string) (*Path, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for _, part := range parts {
+		if !segmentPattern.MatchString(part) {
+			return nil, ErrInvalidPath
+		}
+	}
+
+	p := &Path{Filename: parts[len(parts)-1]}
+	for _, ext := range checksumExtensions {
+		if strings.HasSuffix(p.Filename, ext) && len(p.Filename) > len(ext) {
+			p.Filename = strings.TrimSuffix(p.Filename, ext)
+			p.Checksum = ext[1:]
+			break
+		}
+	}
+
+	if p.Filename == MetadataFilename {
+		if len(parts) < 3 {
+			return nil, ErrInvalidPath
+		}
+		p.ArtifactID = parts[len(parts)-2]
+		p.GroupID = strings.Join(parts[:len(parts)-2], ".")
+		return p, nil
+	}
+
+	if len(parts) < 4 {
+		return nil, ErrInvalidPath
+	}
+	p.Version = parts[len(parts)-2]
+	p.ArtifactID = parts[len(parts)-3]
+	p.GroupID = strings.Join(parts[:len(parts)-3], ".")
+	return p, nil
+}
+
+// Dependency represents a dependency declared by a POM
+type Dependency struct {
+	GroupID    string `json:"group_id" xml:"groupId"`
+	ArtifactID string `json:"artifact_id" xml:"artifactId"`
+	Version    string `json:"version,omitempty" xml:"version"`
+	Scope      string `json:"scope,omitempty" xml:"scope"`
+}
+
+// Metadata represents the metadata of a version read from its POM
+type Metadata struct {
+	Name         string        `json:"name,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	ProjectURL   string        `json:"project_url,omitempty"`
+	Licenses     []string      `json:"licenses,omitempty"`
+	Dependencies []*Dependency `json:"dependencies,omitempty"`
+}
+
+type pom struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	URL         string `xml:"url"`
+	Licenses    []struct {
+		Name string `xml:"name"`
+	} `xml:"licenses>license"`
+	Dependencies []*Dependency `xml:"dependencies>dependency"`
+}
+
+// ParsePOM reads the metadata of a version from its POM
+func ParsePOM(r io.Reader) (*Metadata, error) {
+	var p pom
+	if err := xml.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{
+		Name:         p.Name,
+		Description:  p.Description,
+		ProjectURL:   p.URL,
+		Dependencies: p.Dependencies,
+	}
+	for _, l := range p.Licenses {
+		m.Licenses = append(m.Licenses, l.Name)
+	}
+	return m, nil
+}
+
+type metadataXML struct {
+	XMLName    xml.Name `xml:"metadata"`
+	GroupID    string   `xml:"groupId"`
+	ArtifactID string   `xml:"artifactId"`
+	Versioning struct {
+		Latest      string   `xml:"latest"`
+		Release     string   `xml:"release"`
+		Versions    []string `xml:"versions>version"`
+		LastUpdated string   `xml:"lastUpdated"`
+	} `xml:"versioning"`
+}
+
+// NewMetadataXML returns the maven-metadata.xml of an artifact, the versions must be
+// ordered from the oldest to the most recent one
+func NewMetadataXML(groupID, artifactID string, versions []string, updated time.Time) ([]byte, error) {
+	m := &metadataXML{GroupID: groupID, ArtifactID: artifactID}
+	m.Versioning.Versions = versions
+	m.Versioning.LastUpdated = updated.UTC().Format("20060102150405")
+	if len(versions) > 0 {
+		m.Versioning.Latest = versions[len(versions)-1]
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(versions[i], "-SNAPSHOT") {
+			m.Versioning.Release = versions[i]
+			break
+		}
+	}
+
+	data, err := xml.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePath(t *testing.T) {
+	kases := map[string]*Path{
+		"org/example/lib/1.0/lib-1.0.jar":           {GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Filename: "lib-1.0.jar"},
+		"/org/example/lib/1.0/lib-1.0.pom.sha1":     {GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Filename: "lib-1.0.pom", Checksum: "sha1"},
+		"org/example/lib/maven-metadata.xml":        {GroupID: "org.example", ArtifactID: "lib", Filename: MetadataFilename},
+		"org/lib/maven-metadata.xml.sha256":         {GroupID: "org", ArtifactID: "lib", Filename: MetadataFilename, Checksum: "sha256"},
+		"org/lib/1.0-SNAPSHOT/lib-1.0-SNAPSHOT.jar": {GroupID: "org", ArtifactID: "lib", Version: "1.0-SNAPSHOT", Filename: "lib-1.0-SNAPSHOT.jar"},
+	}
+	for path, expected := range kases {
+		p, err := ParsePath(path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, p, path)
+	}
+
+	for _, path := range []string{"", "lib/maven-metadata.xml", "lib/1.0/lib-1.0.jar", "org/../lib/1.0/lib.jar", "org/lib/1.0/.jar"} {
+		_, err := ParsePath(path)
+		assert.Equal(t, ErrInvalidPath, err, path)
+	}
+
+	p, _ := ParsePath("org/example/lib/1.0/lib-1.0.jar")
+	assert.EqualValues(t, "org.example:lib", p.PackageName())
+	assert.False(t, p.IsMetadata())
+}
+
+func TestParsePOM(t *testing.T) {
+	m, err := ParsePOM(strings.NewReader(`<project>
+  <name>Library</name>
+  <description>A library</description>
+  <url>https://example.org</url>
+  <licenses><license><name>MIT</name></license></licenses>
+  <dependencies>
+    <dependency><groupId>junit</groupId><artifactId>junit</artifactId><version>4.12</version><scope>test</scope></dependency>
+  </dependencies>
+</project>`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Library", m.Name)
+	assert.EqualValues(t, "A library", m.Description)
+	assert.EqualValues(t, "https://example.org", m.ProjectURL)
+	assert.EqualValues(t, []string{"MIT"}, m.Licenses)
+	assert.Equal(t, []*Dependency{{GroupID: "junit", ArtifactID: "junit", Version: "4.12", Scope: "test"}}, m.Dependencies)
+
+	_, err = ParsePOM(strings.NewReader("not xml"))
+	assert.Error(t, err)
+}
+
+func TestNewMetadataXML(t *testing.T) {
+	data, err := NewMetadataXML("org.example", "lib", []string{"1.0", "1.1", "1.2-SNAPSHOT"}, time.Date(2020, 5, 1, 10, 30, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	metadata := string(data)
+	assert.Contains(t, metadata, "<latest>1.2-SNAPSHOT</latest>")
+	assert.Contains(t, metadata, "<release>1.1</release>")
+	assert.Contains(t, metadata, "<lastUpdated>20200501103000</lastUpdated>")
+	assert.Contains(t, metadata, "<version>1.0</version>")
+}
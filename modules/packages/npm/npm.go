@@ -0,0 +1,183 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package npm implements the format of npm packages, https://docs.npmjs.com/cli/v6/using-npm/registry
+package npm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPackage is returned if the upload is not a valid package
+	ErrInvalidPackage = errors.New("Invalid package")
+	// ErrInvalidPackageName is returned if the package name is invalid
+	ErrInvalidPackageName = errors.New("Invalid package name")
+	// ErrInvalidPackageVersion is returned if the package version is invalid
+	ErrInvalidPackageVersion = errors.New("Invalid package version")
+	// ErrInvalidIntegrity is returned if the tarball does not match its integrity
+	ErrInvalidIntegrity = errors.New("Failed to verify integrity")
+)
+
+var (
+	namePattern    = regexp.MustCompile(`^(@[a-z0-9-~][a-z0-9-._~]*/)?[a-z0-9-~][a-z0-9-._~]*$`)
+	versionPattern = regexp.MustCompile(`^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+)
+
+// IsValidName returns true if the name is a valid package name, scoped names have the
+// form @scope/name
+func IsValidName(name string) bool {
+	return len(name) <= 214 && namePattern.MatchString(name)
+}
+
+// IsValidVersion returns true if the version is a valid semantic version
+func IsValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// Metadata represents the metadata of a package version as published by the client,
+// fields which can be strings or objects are kept as they are
+type Metadata struct {
+	Description          string            `json:"description,omitempty"`
+	Keywords             []string          `json:"keywords,omitempty"`
+	Homepage             string            `json:"homepage,omitempty"`
+	License              interface{}       `json:"license,omitempty"`
+	Author               interface{}       `json:"author,omitempty"`
+	Repository           interface{}       `json:"repository,omitempty"`
+	Bin                  interface{}       `json:"bin,omitempty"`
+	Engines              map[string]string `json:"engines,omitempty"`
+	Dependencies         map[string]string `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string `json:"devDependencies,omitempty"`
+	PeerDependencies     map[string]string `json:"peerDependencies,omitempty"`
+	OptionalDependencies map[string]string `json:"optionalDependencies,omitempty"`
+	Readme               string            `json:"readme,omitempty"`
+}
+
+// Distribution describes the tarball of a package version
+type Distribution struct {
+	Integrity string `json:"integrity"`
+	Shasum    string `json:"shasum"`
+	Tarball   string `json:"tarball"`
+}
+
+// PackageVersion represents a version in the package document
+type PackageVersion struct {
+	Metadata
+	ID      string        `json:"_id"`
+	Name    string        `json:"name"`
+	Version string        `json:"version"`
+	Dist    *Distribution `json:"dist"`
+}
+
+type upload struct {
+	Name        string                     `json:"name"`
+	Versions    map[string]*PackageVersion `json:"versions"`
+	Attachments map[string]*struct {
+		Data   string `json:"data"`
+		Length int    `json:"length"`
+	} `json:"_attachments"`
+}
+
+// Package represents a package version published by a client
+type Package struct {
+	Name     string
+	Version  string
+	Filename string
+	Metadata *Metadata
+	Data     []byte
+}
+
+// ParsePackage parses the document of a publish request, it must contain a single version
+// with its tarball
+func ParsePackage(r io.Reader) (*Package, error) {
+	var u upload
+	if err := json.NewDecoder(r).Decode(&u); err != nil {
+		return nil, ErrInvalidPackage
+	}
+	if !IsValidName(u.Name) {
+		return nil, ErrInvalidPackageName
+	}
+	if len(u.Versions) != 1 || len(u.Attachments) != 1 {
+		return nil, ErrInvalidPackage
+	}
+
+	p := &Package{Name: u.Name}
+	var v *PackageVersion
+	for version, pv := range u.Versions {
+		p.Version, v = version, pv
+	}
+	if v == nil || v.Name != u.Name || v.Version != p.Version {
+		return nil, ErrInvalidPackage
+	} else if !IsValidVersion(p.Version) {
+		return nil, ErrInvalidPackageVersion
+	}
+	p.Metadata = &v.Metadata
+
+	for filename, attachment := range u.Attachments {
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil || (attachment.Length > 0 && len(data) != attachment.Length) {
+			return nil, ErrInvalidPackage
+		}
+		p.Filename, p.Data = path.Base(filename), data
+	}
+	if !strings.HasSuffix(p.Filename, ".tgz") {
+		return nil, ErrInvalidPackage
+	}
+
+	if v.Dist != nil && len(v.Dist.Integrity) > 0 {
+		if !VerifyIntegrity(v.Dist.Integrity, p.Data) {
+			return nil, ErrInvalidIntegrity
+		}
+	}
+	return p, nil
+}
+
+// VerifyIntegrity returns true if the data matches the subresource integrity string,
+// only SHA1 and SHA512 are supported
+func VerifyIntegrity(integrity string, data []byte) bool {
+	parts := strings.SplitN(integrity, "-", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	var sum []byte
+	switch parts[0] {
+	case "sha512":
+		hash := sha512.Sum512(data)
+		sum = hash[:]
+	case "sha1":
+		hash := sha1.Sum(data)
+		sum = hash[:]
+	default:
+		return false
+	}
+	expected, err := base64.StdEncoding.DecodeString(parts[1])
+	return err == nil && bytes.Equal(sum, expected)
+}
+
+// Integrity returns the subresource integrity string of content with the given SHA512 hash
+func Integrity(hashSHA512 string) string {
+	sum, _ := hex.DecodeString(hashSHA512)
+	return "sha512-" + base64.StdEncoding.EncodeToString(sum)
+}
+
+// PackageDocument represents the document clients load to resolve the versions of a package
+type PackageDocument struct {
+	ID          string                     `json:"_id"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	DistTags    map[string]string          `json:"dist-tags"`
+	Versions    map[string]*PackageVersion `json:"versions"`
+	Time        map[string]time.Time       `json:"time"`
+}
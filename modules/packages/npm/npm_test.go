@@ -0,0 +1,73 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidName(t *testing.T) {
+	for _, name := range []string{"package", "@scope/package", "package.js", "my-package_1"} {
+		assert.True(t, IsValidName(name), name)
+	}
+	for _, name := range []string{"", "Package", "@scope", "@scope/", "a/b", ".package", "_package", strings.Repeat("a", 215)} {
+		assert.False(t, IsValidName(name), name)
+	}
+}
+
+func TestIsValidVersion(t *testing.T) {
+	for _, version := range []string{"1.0.0", "0.1.2-beta.1", "1.0.0+build.5"} {
+		assert.True(t, IsValidVersion(version), version)
+	}
+	for _, version := range []string{"", "1.0", "01.0.0", "v1.0.0"} {
+		assert.False(t, IsValidVersion(version), version)
+	}
+}
+
+func TestParsePackage(t *testing.T) {
+	data := []byte("tarball")
+	hash := sha512.Sum512(data)
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(hash[:])
+	document := func(name, version, integrity, filename string) string {
+		return `{"name":"` + name + `","versions":{"` + version + `":{"name":"` + name + `","version":"` + version +
+			`","description":"test","dist":{"integrity":"` + integrity + `"}}},"_attachments":{"` + filename +
+			`":{"data":"` + base64.StdEncoding.EncodeToString(data) + `","length":7}}}`
+	}
+
+	p, err := ParsePackage(strings.NewReader(document("@scope/package", "1.0.0", integrity, "@scope/package-1.0.0.tgz")))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "@scope/package", p.Name)
+	assert.EqualValues(t, "1.0.0", p.Version)
+	assert.EqualValues(t, "package-1.0.0.tgz", p.Filename)
+	assert.EqualValues(t, "test", p.Metadata.Description)
+	assert.Equal(t, data, p.Data)
+
+	_, err = ParsePackage(strings.NewReader("invalid"))
+	assert.Equal(t, ErrInvalidPackage, err)
+	_, err = ParsePackage(strings.NewReader(document("Package", "1.0.0", integrity, "package-1.0.0.tgz")))
+	assert.Equal(t, ErrInvalidPackageName, err)
+	_, err = ParsePackage(strings.NewReader(document("package", "1.0", integrity, "package-1.0.tgz")))
+	assert.Equal(t, ErrInvalidPackageVersion, err)
+	_, err = ParsePackage(strings.NewReader(document("package", "1.0.0", integrity, "package-1.0.0.zip")))
+	assert.Equal(t, ErrInvalidPackage, err)
+	_, err = ParsePackage(strings.NewReader(document("package", "1.0.0", "sha512-AAAA", "package-1.0.0.tgz")))
+	assert.Equal(t, ErrInvalidIntegrity, err)
+}
+
+func TestIntegrity(t *testing.T) {
+	data := []byte("tarball")
+	hash := sha512.Sum512(data)
+	integrity := Integrity(hex.EncodeToString(hash[:]))
+	assert.True(t, VerifyIntegrity(integrity, data))
+	assert.False(t, VerifyIntegrity(integrity, []byte("other")))
+	assert.False(t, VerifyIntegrity("md5-AAAA", data))
+	assert.False(t, VerifyIntegrity("invalid", data))
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package packages implements the storage of the package registry, the formats of the
+// package types are implemented by its sub packages.
+package packages
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// NewContentStore returns the content store of the package registry
+func NewContentStore() *ContentStore {
+	return &ContentStore{
+		BasePath: setting.Packages.ContentPath,
+		MaxSize:  setting.Packages.MaxFileSize,
+	}
+}
+
+// Publish adds the file of the upload, stored by the content store before, to its
+// package version. The content is removed again if the file can't be added.
+func Publish(u *models.PackageUpload) (*models.PackageVersion, error) {
+	pv, err := models.PublishPackageFile(u)
+	if err != nil {
+		if removeErr := RemoveUnreferencedContent(u.File.HashSHA256); removeErr != nil {
+			log.Error("RemoveUnreferencedContent: %v", removeErr)
+		}
+		return nil, err
+	}
+	return pv, nil
+}
+
+// DeleteVersion deletes the package version and the content of its files
+func DeleteVersion(pv *models.PackageVersion) error {
+	files, err := models.GetPackageFiles(pv.ID)
+	if err != nil {
+		return fmt.Errorf("GetPackageFiles: %v", err)
+	}
+	if err = models.DeletePackageVersion(pv); err != nil {
+		return fmt.Errorf("DeletePackageVersion: %v", err)
+	}
+	for _, pf := range files {
+		if err = RemoveUnreferencedContent(pf.HashSHA256); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteFile deletes the file of the package version and its content, the version is
+// deleted once it has no files anymore
+func DeleteFile(pv *models.PackageVersion, pf *models.PackageFile) error {
+	if err := models.DeletePackageFile(pv, pf); err != nil {
+		return fmt.Errorf("DeletePackageFile: %v", err)
+	}
+	return RemoveUnreferencedContent(pf.HashSHA256)
+}
+
+// RemoveUnreferencedContent removes the content from the store unless a package file
+// still references it, files with the same content share it
+func RemoveUnreferencedContent(hashSHA256 string) error {
+	referenced, err := models.IsPackageContentReferenced(hashSHA256)
+	if err != nil {
+		return fmt.Errorf("IsPackageContentReferenced: %v", err)
+	} else if referenced {
+		return nil
+	}
+	return NewContentStore().Delete(hashSHA256)
+}
+
+// CanRead returns true if the doer, nil for anonymous clients, can download the packages
+// of the owner. Packages are visible like their owner.
+func CanRead(owner, doer *models.User) bool {
+	if doer != nil && (doer.IsAdmin || doer.ID == owner.ID) {
+		return true
+	}
+	if owner.IsOrganization() {
+		return models.HasOrgVisible(owner, doer)
+	}
+	switch owner.Visibility {
+	case structs.VisibleTypePublic:
+		return true
+	case structs.VisibleTypeLimited:
+		return doer != nil
+	}
+	return false
+}
+
+// CanWrite returns true if the doer can publish and delete packages of the owner, members
+// of organizations need the permission to create repositories
+func CanWrite(owner, doer *models.User) (bool, error) {
+	if doer == nil {
+		return false, nil
+	} else if doer.IsAdmin || doer.ID == owner.ID {
+		return true, nil
+	} else if owner.IsOrganization() {
+		return owner.CanCreateOrgRepo(doer.ID)
+	}
+	return false, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package pypi implements the format of Python packages, https://www.python.org/dev/peps/pep-0503/
+package pypi
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	normalizePattern = regexp.MustCompile(`[-_.]+`)
+	namePattern      = regexp.MustCompile(`(?i)^([A-Z0-9]|[A-Z0-9][A-Z0-9._-]*[A-Z0-9])$`)
+	// versionPattern matches versions as defined by PEP 440
+	versionPattern = regexp.MustCompile(`(?i)^v?(\d+!)?\d+(\.\d+)*((a|b|rc|alpha|beta|c|pre|preview)\d*)?(\.?(post|rev|r)\d*)?(\.?dev\d*)?(\+[a-z0-9]+([._-][a-z0-9]+)*)?$`)
+	// filenamePattern matches the distributions accepted by the registry, source
+	// distributions, wheels and eggs
+	filenamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\-+!]+\.(tar\.gz|tar\.bz2|zip|whl|egg)$`)
+)
+
+// NormalizeName returns the normalized form of a project name used to compare names
+func NormalizeName(name string) string {
+	return strings.ToLower(normalizePattern.ReplaceAllString(name, "-"))
+}
+
+// IsValidName returns true if the project name is valid
+func IsValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// IsValidVersion returns true if the version is valid
+func IsValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// IsValidFilename returns true if the name of a distribution file is accepted
+func IsValidFilename(filename string) bool {
+	return filenamePattern.MatchString(filename)
+}
+
+// Metadata represents the metadata of a version sent with the upload of a distribution
+type Metadata struct {
+	Summary        string   `json:"summary,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Author         string   `json:"author,omitempty"`
+	License        string   `json:"license,omitempty"`
+	HomepageURL    string   `json:"homepage_url,omitempty"`
+	RequiresPython string   `json:"requires_python,omitempty"`
+	RequiresDist   []string `json:"requires_dist,omitempty"`
+	Keywords       string   `json:"keywords,omitempty"`
+}
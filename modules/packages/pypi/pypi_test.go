@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pypi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeName(t *testing.T) {
+	for _, name := range []string{"Test_Project", "test.project", "test--project", "TEST-PROJECT"} {
+		assert.EqualValues(t, "test-project", NormalizeName(name), name)
+	}
+}
+
+func TestIsValidName(t *testing.T) {
+	for _, name := range []string{"a", "Test_Project", "project2.0"} {
+		assert.True(t, IsValidName(name), name)
+	}
+	for _, name := range []string{"", "-project", "project-", "test project"} {
+		assert.False(t, IsValidName(name), name)
+	}
+}
+
+func TestIsValidVersion(t *testing.T) {
+	for _, version := range []string{"1", "1.0", "1.0a1", "1.0.post1", "1.0.dev2", "1!2.0", "1.0+local.1"} {
+		assert.True(t, IsValidVersion(version), version)
+	}
+	for _, version := range []string{"", "1.0-", "version", "1..0"} {
+		assert.False(t, IsValidVersion(version), version)
+	}
+}
+
+func TestIsValidFilename(t *testing.T) {
+	for _, filename := range []string{"project-1.0.tar.gz", "project-1.0-py3-none-any.whl", "project-1.0.zip"} {
+		assert.True(t, IsValidFilename(filename), filename)
+	}
+	for _, filename := range []string{"project-1.0.exe", "../project-1.0.tar.gz", "project 1.0.whl"} {
+		assert.False(t, IsValidFilename(filename), filename)
+	}
+}
@@ -81,3 +81,43 @@ func FlushQueues(timeout time.Duration, nonBlocking bool) (int, string) {
 
 	return http.StatusOK, "Flushed"
 }
+
+// ReindexOptions represents the options for the reindex-code and reindex-commits calls
+type ReindexOptions struct {
+	Repo string
+}
+
+// ReindexCode calls the internal reindex-code function
+func ReindexCode(repo string) (int, string) {
+	return reindex("reindex-code", repo)
+}
+
+// ReindexCommits calls the internal reindex-commits function
+func ReindexCommits(repo string) (int, string) {
+	return reindex("reindex-commits", repo)
+}
+
+func reindex(call, repo string) (int, string) {
+	reqURL := setting.LocalURL + "api/internal/manager/" + call
+
+	req := newInternalRequest(reqURL, "POST")
+	req = req.Header("Content-Type", "application/json")
+	jsonBytes, _ := json.Marshal(ReindexOptions{
+		Repo: repo,
+	})
+	req.Body(jsonBytes)
+	resp, err := req.Response()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Sprintf("Unable to contact gitea: %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, decodeJSONError(resp).Err
+	}
+
+	if len(repo) > 0 {
+		return http.StatusOK, fmt.Sprintf("Reindexing %s", repo)
+	}
+	return http.StatusOK, "Reindexing all repositories"
+}
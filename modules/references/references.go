@@ -26,8 +26,8 @@ var (
 	// While fast, this is also incorrect and lead to false positives.
 	// TODO: fix invalid linking issue
 
-	// mentionPattern matches all mentions in the form of "@user"
-	mentionPattern = regexp.MustCompile(`(?:\s|^|\(|\[)(@[0-9a-zA-Z-_]+|@[0-9a-zA-Z-_][0-9a-zA-Z-_.]+[0-9a-zA-Z-_])(?:\s|[:,;.?!]\s|[:,;.?!]?$|\)|\])`)
+	// mentionPattern matches all mentions in the form of "@user" or "@org/team"
+	mentionPattern = regexp.MustCompile(`(?:\s|^|\(|\[)(@(?:[0-9a-zA-Z-_]+|[0-9a-zA-Z-_][0-9a-zA-Z-_.]+[0-9a-zA-Z-_])(?:/(?:[0-9a-zA-Z-_]+|[0-9a-zA-Z-_][0-9a-zA-Z-_.]+[0-9a-zA-Z-_]))?)(?:\s|[:,;.?!]\s|[:,;.?!]?$|\)|\])`)
 	// issueNumericPattern matches string that references to a numeric issue, e.g. #1287
 	issueNumericPattern = regexp.MustCompile(`(?:\s|^|\(|\[)([#!][0-9]+)(?:\s|$|\)|\]|[:;,.?!]\s|[:;,.?!]$)`)
 	// issueAlphanumericPattern matches string that references to an alphanumeric issue, e.g. ABC-1234
@@ -160,7 +160,8 @@ func getGiteaHostName() string {
 }
 
 // FindAllMentionsMarkdown matches mention patterns in given content and
-// returns a list of found unvalidated user names **not including** the @ prefix.
+// returns a list of found unvalidated user names or "org/team" team names
+// **not including** the @ prefix.
 func FindAllMentionsMarkdown(content string) []string {
 	bcontent, _ := mdstripper.StripMarkdownBytes([]byte(content))
 	locations := FindAllMentionsBytes(bcontent)
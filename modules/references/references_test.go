@@ -260,6 +260,10 @@ func TestFindAllMentions(t *testing.T) {
 	}, res)
 }
 
+func TestFindAllMentionsMarkdown(t *testing.T) {
+	assert.EqualValues(t, []string{"tasha", "org/team", "lucy"}, FindAllMentionsMarkdown("@tasha, @org/team: see @lucy"))
+}
+
 func TestRegExp_mentionPattern(t *testing.T) {
 	trueTestCases := []struct {
 		pat string
@@ -287,6 +291,10 @@ func TestRegExp_mentionPattern(t *testing.T) {
 		{"@gitea.", "@gitea"},
 		{"@gitea,", "@gitea"},
 		{"@gitea;", "@gitea"},
+		{"@org/team", "@org/team"},
+		{"@my.org/the-team, this", "@my.org/the-team"},
+		{"(@org/team_1)", "@org/team_1"},
+		{"@org/team.", "@org/team"},
 	}
 	falseTestCases := []string{
 		"@ 0",
@@ -302,6 +310,9 @@ func TestRegExp_mentionPattern(t *testing.T) {
 		"@gitea?this",
 		"@gitea,this",
 		"@gitea;this",
+		"@org/",
+		"@org/team/sub",
+		"@/team",
 	}
 
 	for _, testCase := range trueTestCases {
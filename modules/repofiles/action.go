@@ -38,6 +38,7 @@ func changeIssueStatus(repo *models.Repository, issue *models.Issue, doer *model
 			if err := models.CreateOrStopIssueStopwatch(doer, issue); err != nil {
 				return err
 			}
+			notification.NotifyIssueChangeStopwatch(doer, issue)
 		}
 
 		return nil
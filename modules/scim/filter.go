@@ -0,0 +1,423 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"xorm.io/builder"
+)
+
+// ErrInvalidFilter represents an error of a filter which could not be parsed or applied
+type ErrInvalidFilter struct {
+	Filter string
+	Reason string
+}
+
+// IsErrInvalidFilter checks if an error is a ErrInvalidFilter.
+func IsErrInvalidFilter(err error) bool {
+	_, ok := err.(ErrInvalidFilter)
+	return ok
+}
+
+func (err ErrInvalidFilter) Error() string {
+	return fmt.Sprintf("invalid filter [filter: %s]: %s", err.Filter, err.Reason)
+}
+
+// Operators supported in filter comparisons
+const (
+	OpEqual      = "eq"
+	OpNotEqual   = "ne"
+	OpContains   = "co"
+	OpStartsWith = "sw"
+	OpEndsWith   = "ew"
+	OpPresent    = "pr"
+	OpGreater    = "gt"
+	OpGreaterEq  = "ge"
+	OpLess       = "lt"
+	OpLessEq     = "le"
+)
+
+// Filter is a parsed SCIM filter expression
+type Filter interface {
+	// Cond converts the filter into a database condition. columns maps the lower-cased
+	// attribute paths which can be filtered on to their column names.
+	Cond(columns map[string]string) (builder.Cond, error)
+	// Match evaluates the filter against a resource whose attributes are resolved by get.
+	Match(get func(attr string) (interface{}, bool)) bool
+}
+
+// LogicalFilter combines two filters with "and" or "or"
+type LogicalFilter struct {
+	Op    string
+	Left  Filter
+	Right Filter
+}
+
+// NotFilter negates a filter
+type NotFilter struct {
+	Filter Filter
+}
+
+// AttributeFilter compares an attribute with a value
+type AttributeFilter struct {
+	Attribute string
+	Op        string
+	Value     interface{}
+}
+
+// Cond implements Filter
+func (f *LogicalFilter) Cond(columns map[string]string) (builder.Cond, error) {
+	left, err := f.Left.Cond(columns)
+	if err != nil {
+		return nil, err
+	}
+	right, err := f.Right.Cond(columns)
+	if err != nil {
+		return nil, err
+	}
+	if f.Op == "or" {
+		return builder.Or(left, right), nil
+	}
+	return builder.And(left, right), nil
+}
+
+// Match implements Filter
+func (f *LogicalFilter) Match(get func(attr string) (interface{}, bool)) bool {
+	if f.Op == "or" {
+		return f.Left.Match(get) || f.Right.Match(get)
+	}
+	return f.Left.Match(get) && f.Right.Match(get)
+}
+
+// Cond implements Filter
+func (f *NotFilter) Cond(columns map[string]string) (builder.Cond, error) {
+	cond, err := f.Filter.Cond(columns)
+	if err != nil {
+		return nil, err
+	}
+	return builder.Not{cond}, nil
+}
+
+// Match implements Filter
+func (f *NotFilter) Match(get func(attr string) (interface{}, bool)) bool {
+	return !f.Filter.Match(get)
+}
+
+// Cond implements Filter
+func (f *AttributeFilter) Cond(columns map[string]string) (builder.Cond, error) {
+	column, ok := columns[strings.ToLower(f.Attribute)]
+	if !ok {
+		return nil, ErrInvalidFilter{f.Attribute, "attribute is not filterable"}
+	}
+
+	if f.Op == OpPresent {
+		return builder.And(builder.NotNull{column}, builder.Neq{column: ""}), nil
+	}
+
+	value, isString := f.Value.(string)
+	if !isString {
+		switch f.Op {
+		case OpEqual:
+			return builder.Eq{column: f.Value}, nil
+		case OpNotEqual:
+			return builder.Neq{column: f.Value}, nil
+		case OpGreater:
+			return builder.Gt{column: f.Value}, nil
+		case OpGreaterEq:
+			return builder.Gte{column: f.Value}, nil
+		case OpLess:
+			return builder.Lt{column: f.Value}, nil
+		case OpLessEq:
+			return builder.Lte{column: f.Value}, nil
+		}
+		return nil, ErrInvalidFilter{f.Attribute, "operator " + f.Op + " requires a string value"}
+	}
+
+	// String attributes of the core schemas are case-insensitive
+	column = "LOWER(" + column + ")"
+	value = strings.ToLower(value)
+	switch f.Op {
+	case OpEqual:
+		return builder.Eq{column: value}, nil
+	case OpNotEqual:
+		return builder.Neq{column: value}, nil
+	case OpContains:
+		return likeCond(column, "%"+escapeLike(value)+"%"), nil
+	case OpStartsWith:
+		return likeCond(column, escapeLike(value)+"%"), nil
+	case OpEndsWith:
+		return likeCond(column, "%"+escapeLike(value)), nil
+	case OpGreater:
+		return builder.Gt{column: value}, nil
+	case OpGreaterEq:
+		return builder.Gte{column: value}, nil
+	case OpLess:
+		return builder.Lt{column: value}, nil
+	case OpLessEq:
+		return builder.Lte{column: value}, nil
+	}
+	return nil, ErrInvalidFilter{f.Attribute, "unknown operator " + f.Op}
+}
+
+// likeEscape escapes the wildcards in the LIKE patterns, a backslash is not
+// used as it must itself be escaped differently in the SQL dialects
+const likeEscape = "!"
+
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// escapeLike escapes the wildcards of a value matched literally in a LIKE pattern
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// likeCond matches the column against the pattern, builder.Like cannot be used
+// as it neither supports empty patterns nor escaped wildcards
+func likeCond(column, pattern string) builder.Cond {
+	return builder.Expr(column+" LIKE ? ESCAPE '"+likeEscape+"'", pattern)
+}
+
+// Match implements Filter
+func (f *AttributeFilter) Match(get func(attr string) (interface{}, bool)) bool {
+	actual, has := get(strings.ToLower(f.Attribute))
+	if f.Op == OpPresent {
+		return has && actual != nil && actual != ""
+	}
+	if !has {
+		return f.Op == OpNotEqual
+	}
+
+	if expected, ok := f.Value.(string); ok {
+		str, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		str, expected = strings.ToLower(str), strings.ToLower(expected)
+		switch f.Op {
+		case OpEqual:
+			return str == expected
+		case OpNotEqual:
+			return str != expected
+		case OpContains:
+			return strings.Contains(str, expected)
+		case OpStartsWith:
+			return strings.HasPrefix(str, expected)
+		case OpEndsWith:
+			return strings.HasSuffix(str, expected)
+		case OpGreater:
+			return str > expected
+		case OpGreaterEq:
+			return str >= expected
+		case OpLess:
+			return str < expected
+		case OpLessEq:
+			return str <= expected
+		}
+		return false
+	}
+
+	switch f.Op {
+	case OpEqual:
+		return actual == f.Value
+	case OpNotEqual:
+		return actual != f.Value
+	}
+	return false
+}
+
+type filterParser struct {
+	filter string
+	tokens []string
+	pos    int
+}
+
+// ParseFilter parses a filter expression such as `userName eq "john" and active eq true`
+// as defined in RFC 7644 section 3.4.2.2. Complex attribute filter groups are not supported.
+func ParseFilter(filter string) (Filter, error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, ErrInvalidFilter{filter, "empty filter"}
+	}
+
+	p := &filterParser{filter: filter, tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, ErrInvalidFilter{filter, "unexpected token " + p.tokens[p.pos]}
+	}
+	return f, nil
+}
+
+func tokenizeFilter(filter string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for ; j < len(filter); j++ {
+				if filter[j] == '\\' {
+					j++
+					continue
+				}
+				if filter[j] == '"' {
+					break
+				}
+			}
+			if j >= len(filter) {
+				return nil, ErrInvalidFilter{filter, "unterminated string"}
+			}
+			tokens = append(tokens, filter[i:j+1])
+			i = j + 1
+		default:
+			j := i
+			for j < len(filter) && filter[j] != ' ' && filter[j] != '\t' && filter[j] != '(' && filter[j] != ')' {
+				j++
+			}
+			tokens = append(tokens, filter[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", ErrInvalidFilter{p.filter, "unexpected end of filter"}
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalFilter{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "and") {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &LogicalFilter{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseFactor() (Filter, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(token, "not") {
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &NotFilter{Filter: inner}, nil
+	}
+
+	if token == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, err := p.next(); err != nil || closing != ")" {
+			return nil, ErrInvalidFilter{p.filter, "missing closing parenthesis"}
+		}
+		return inner, nil
+	}
+
+	if strings.ContainsAny(token, "[]\"") {
+		return nil, ErrInvalidFilter{p.filter, "unsupported attribute path " + token}
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op = strings.ToLower(op)
+	if op == OpPresent {
+		return &AttributeFilter{Attribute: token, Op: op}, nil
+	}
+
+	switch op {
+	case OpEqual, OpNotEqual, OpContains, OpStartsWith, OpEndsWith, OpGreater, OpGreaterEq, OpLess, OpLessEq:
+	default:
+		return nil, ErrInvalidFilter{p.filter, "unknown operator " + op}
+	}
+
+	raw, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := parseFilterValue(raw)
+	if err != nil {
+		return nil, ErrInvalidFilter{p.filter, err.Error()}
+	}
+	return &AttributeFilter{Attribute: token, Op: op, Value: value}, nil
+}
+
+func parseFilterValue(raw string) (interface{}, error) {
+	switch strings.ToLower(raw) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if strings.HasPrefix(raw, "\"") {
+		var str string
+		if err := json.Unmarshal([]byte(raw), &str); err != nil {
+			return nil, err
+		}
+		return str, nil
+	}
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("invalid value %s", raw)
+}
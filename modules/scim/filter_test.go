@@ -0,0 +1,109 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"xorm.io/builder"
+)
+
+var testColumns = map[string]string{
+	"username":     "lower_name",
+	"emails.value": "email",
+	"active":       "is_active",
+}
+
+func TestParseFilter(t *testing.T) {
+	kases := []struct {
+		filter string
+		sql    string
+		args   []interface{}
+	}{
+		{
+			filter: `userName eq "John"`,
+			sql:    "LOWER(lower_name)=?",
+			args:   []interface{}{"john"},
+		},
+		{
+			filter: `userName sw "j" and active eq true`,
+			sql:    "(LOWER(lower_name) LIKE ? ESCAPE '!') AND is_active=?",
+			args:   []interface{}{"j%", true},
+		},
+		{
+			filter: `emails.value co "example.com" or (userName eq "a" and not (active eq false))`,
+			sql:    "(LOWER(email) LIKE ? ESCAPE '!') OR (LOWER(lower_name)=? AND NOT is_active=?)",
+			args:   []interface{}{"%example.com%", "a", false},
+		},
+		{
+			filter: `userName co "" or userName ew "50%_off!"`,
+			sql:    "(LOWER(lower_name) LIKE ? ESCAPE '!') OR (LOWER(lower_name) LIKE ? ESCAPE '!')",
+			args:   []interface{}{"%%", "%50!%!_off!!"},
+		},
+		{
+			filter: `emails.value pr`,
+			sql:    "email IS NOT NULL AND email<>?",
+			args:   []interface{}{""},
+		},
+	}
+
+	for _, kase := range kases {
+		f, err := ParseFilter(kase.filter)
+		assert.NoError(t, err, kase.filter)
+		cond, err := f.Cond(testColumns)
+		assert.NoError(t, err, kase.filter)
+		sql, args, err := builder.ToSQL(cond)
+		assert.NoError(t, err, kase.filter)
+		assert.EqualValues(t, kase.sql, sql, kase.filter)
+		assert.EqualValues(t, kase.args, args, kase.filter)
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, filter := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName foo "a"`,
+		`userName eq "a`,
+		`(userName eq "a"`,
+		`emails[type eq "work"].value eq "a"`,
+		`userName eq "a" extra`,
+	} {
+		_, err := ParseFilter(filter)
+		assert.True(t, IsErrInvalidFilter(err), filter)
+	}
+
+	f, err := ParseFilter(`title eq "a"`)
+	assert.NoError(t, err)
+	_, err = f.Cond(testColumns)
+	assert.True(t, IsErrInvalidFilter(err))
+}
+
+func TestFilter_Match(t *testing.T) {
+	attrs := map[string]interface{}{
+		"displayname": "myorg/Developers",
+		"id":          "3",
+	}
+	get := func(attr string) (interface{}, bool) {
+		v, ok := attrs[attr]
+		return v, ok
+	}
+
+	for filter, expected := range map[string]bool{
+		`displayName eq "myorg/developers"`:        true,
+		`displayName sw "myorg/"`:                  true,
+		`displayName ew "/admins"`:                 false,
+		`displayName eq "x" or id eq "3"`:          true,
+		`displayName co "dev" and not (id eq "3")`: false,
+		`externalId pr`:                            false,
+		`externalId ne "a"`:                        true,
+	} {
+		f, err := ParseFilter(filter)
+		assert.NoError(t, err, filter)
+		assert.Equal(t, expected, f.Match(get), filter)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// CI defines the built-in CI running the workflows of repositories on registered runners
+	CI = struct {
+		Enabled         bool
+		LogPath         string        `ini:"LOG_PATH"`
+		PollTimeout     time.Duration `ini:"POLL_TIMEOUT"`
+		MaxLogSize      int64         `ini:"MAX_LOG_SIZE"`
+		MaxJobsPerEvent int           `ini:"MAX_JOBS_PER_EVENT"`
+	}{
+		Enabled:         false,
+		PollTimeout:     30 * time.Second,
+		MaxLogSize:      10 * 1024 * 1024,
+		MaxJobsPerEvent: 64,
+	}
+)
+
+func newCI() {
+	sec := Cfg.Section("ci")
+	if err := sec.MapTo(&CI); err != nil {
+		log.Fatal("Failed to map CI settings: %v", err)
+	}
+
+	CI.LogPath = sec.Key("LOG_PATH").MustString(filepath.Join(AppDataPath, "ci_logs"))
+	if !filepath.IsAbs(CI.LogPath) {
+		CI.LogPath = filepath.Join(AppWorkPath, CI.LogPath)
+	}
+
+	if CI.Enabled {
+		if err := os.MkdirAll(CI.LogPath, 0700); err != nil {
+			log.Fatal("Failed to create '%s': %v", CI.LogPath, err)
+		}
+	}
+}
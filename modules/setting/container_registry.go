@@ -0,0 +1,50 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// ContainerRegistry defines the OCI container registry served under /v2/
+	ContainerRegistry = struct {
+		Enabled         bool
+		ContentPath     string `ini:"CONTENT_PATH"`
+		MaxManifestSize int64  `ini:"MAX_MANIFEST_SIZE"`
+		// Host is the registry part of image names, images are pulled as Host/owner/name
+		Host string `ini:"-"`
+	}{
+		Enabled:         false,
+		MaxManifestSize: 4 * 1024 * 1024,
+	}
+)
+
+func newContainerRegistry() {
+	sec := Cfg.Section("container_registry")
+	if err := sec.MapTo(&ContainerRegistry); err != nil {
+		log.Fatal("Failed to map container registry settings: %v", err)
+	}
+
+	ContainerRegistry.ContentPath = sec.Key("CONTENT_PATH").MustString(filepath.Join(AppDataPath, "container_registry"))
+	if !filepath.IsAbs(ContainerRegistry.ContentPath) {
+		ContainerRegistry.ContentPath = filepath.Join(AppWorkPath, ContainerRegistry.ContentPath)
+	}
+
+	ContainerRegistry.Host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(AppURL, "https://"), "http://"), "/")
+
+	if ContainerRegistry.Enabled {
+		if AppSubURL != "" {
+			log.Warn("Container clients require the registry to be served at the root of the domain, which is not the case for ROOT_URL %s", AppURL)
+		}
+		if err := os.MkdirAll(ContainerRegistry.ContentPath, 0700); err != nil {
+			log.Fatal("Failed to create '%s': %v", ContainerRegistry.ContentPath, err)
+		}
+	}
+}
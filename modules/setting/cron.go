@@ -52,6 +52,33 @@ var (
 		UpdateMigrationPosterID struct {
 			Schedule string
 		} `ini:"cron.update_migration_poster_id"`
+		ContainerRegistryGC struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		} `ini:"cron.container_registry_gc"`
+		SendHourlyEmailDigests struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.send_hourly_email_digests"`
+		SendDailyEmailDigests struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.send_daily_email_digests"`
+		SendReminders struct {
+			Enabled     bool
+			RunAtStart  bool
+			Schedule    string
+			DueDateDays int
+		} `ini:"cron.send_reminders"`
+		StaleIssues struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.stale_issues"`
 	}{
 		UpdateMirror: struct {
 			Enabled    bool
@@ -122,6 +149,55 @@ var (
 		}{
 			Schedule: "@every 24h",
 		},
+		ContainerRegistryGC: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			OlderThan  time.Duration
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+			OlderThan:  24 * time.Hour,
+		},
+		SendHourlyEmailDigests: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 1h",
+		},
+		SendDailyEmailDigests: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 24h",
+		},
+		SendReminders: struct {
+			Enabled     bool
+			RunAtStart  bool
+			Schedule    string
+			DueDateDays int
+		}{
+			Enabled:     true,
+			RunAtStart:  false,
+			Schedule:    "@every 1h",
+			DueDateDays: 1,
+		},
+		StaleIssues: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 1h",
+		},
 	}
 )
 
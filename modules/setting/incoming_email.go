@@ -0,0 +1,56 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"net/mail"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+// IncomingEmailTokenPlaceholder is replaced by the reply token in the reply
+// address of the outgoing mails
+const IncomingEmailTokenPlaceholder = "%{token}"
+
+// IncomingEmail settings of the replies to the notification mails
+var IncomingEmail = struct {
+	Enabled            bool
+	ReplyToAddress     string
+	Protocol           string
+	ListenAddr         string
+	MaximumMessageSize int64
+}{
+	Enabled:            false,
+	ReplyToAddress:     "",
+	Protocol:           "smtp",
+	ListenAddr:         "127.0.0.1:2525",
+	MaximumMessageSize: 10 * 1024 * 1024,
+}
+
+func newIncomingEmailService() {
+	sec := Cfg.Section("email.incoming")
+	IncomingEmail.Enabled = sec.Key("ENABLED").MustBool(false)
+	if !IncomingEmail.Enabled {
+		return
+	}
+
+	IncomingEmail.ReplyToAddress = sec.Key("REPLY_TO_ADDRESS").String()
+	if strings.Count(IncomingEmail.ReplyToAddress, IncomingEmailTokenPlaceholder) != 1 {
+		log.Fatal("email.incoming.REPLY_TO_ADDRESS must contain %s exactly once", IncomingEmailTokenPlaceholder)
+	}
+	if _, err := mail.ParseAddress(strings.Replace(IncomingEmail.ReplyToAddress, IncomingEmailTokenPlaceholder, "token", 1)); err != nil {
+		log.Fatal("Invalid email.incoming.REPLY_TO_ADDRESS (%s): %v", IncomingEmail.ReplyToAddress, err)
+	}
+
+	IncomingEmail.Protocol = sec.Key("PROTOCOL").In("smtp", []string{"smtp", "lmtp"})
+	IncomingEmail.ListenAddr = sec.Key("LISTEN_ADDR").MustString(IncomingEmail.ListenAddr)
+	IncomingEmail.MaximumMessageSize = sec.Key("MAXIMUM_MESSAGE_SIZE").MustInt64(IncomingEmail.MaximumMessageSize)
+
+	if MailService == nil {
+		log.Warn("Incoming Email Service: Mail Service is not enabled, no mail can be replied to")
+	}
+	log.Info("Incoming Email Service Enabled")
+}
@@ -36,11 +36,20 @@ var (
 		StartupTimeout        time.Duration
 
 		RepoIndexerEnabled bool
+		RepoType           string
 		RepoPath           string
+		RepoConnStr        string
+		RepoIndexerName    string
 		UpdateQueueLength  int
 		MaxIndexerFileSize int64
 		IncludePatterns    []glob.Glob
 		ExcludePatterns    []glob.Glob
+
+		CommitIndexerEnabled bool
+		CommitType           string
+		CommitPath           string
+		CommitConnStr        string
+		CommitIndexerName    string
 	}{
 		IssueType:             "bleve",
 		IssuePath:             "indexers/issues.bleve",
@@ -51,6 +60,16 @@ var (
 		IssueQueueConnStr:     "",
 		IssueQueueBatchNumber: 20,
 
+		RepoType:        "bleve",
+		RepoPath:        "indexers/repos.bleve",
+		RepoConnStr:     "",
+		RepoIndexerName: "gitea_codes",
+
+		CommitType:        "bleve",
+		CommitPath:        "indexers/commits.bleve",
+		CommitConnStr:     "",
+		CommitIndexerName: "gitea_commits",
+
 		MaxIndexerFileSize: 1024 * 1024,
 	}
 )
@@ -71,12 +90,25 @@ func newIndexerService() {
 	Indexer.IssueQueueBatchNumber = sec.Key("ISSUE_INDEXER_QUEUE_BATCH_NUMBER").MustInt(20)
 
 	Indexer.RepoIndexerEnabled = sec.Key("REPO_INDEXER_ENABLED").MustBool(false)
+	Indexer.RepoType = sec.Key("REPO_INDEXER_TYPE").MustString("bleve")
 	Indexer.RepoPath = sec.Key("REPO_INDEXER_PATH").MustString(path.Join(AppDataPath, "indexers/repos.bleve"))
 	if !filepath.IsAbs(Indexer.RepoPath) {
 		Indexer.RepoPath = path.Join(AppWorkPath, Indexer.RepoPath)
 	}
+	Indexer.RepoConnStr = sec.Key("REPO_INDEXER_CONN_STR").MustString(Indexer.RepoConnStr)
+	Indexer.RepoIndexerName = sec.Key("REPO_INDEXER_NAME").MustString(Indexer.RepoIndexerName)
 	Indexer.IncludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_INCLUDE").MustString(""))
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
+
+	Indexer.CommitIndexerEnabled = sec.Key("COMMIT_INDEXER_ENABLED").MustBool(false)
+	Indexer.CommitType = sec.Key("COMMIT_INDEXER_TYPE").MustString("bleve")
+	Indexer.CommitPath = sec.Key("COMMIT_INDEXER_PATH").MustString(path.Join(AppDataPath, "indexers/commits.bleve"))
+	if !filepath.IsAbs(Indexer.CommitPath) {
+		Indexer.CommitPath = path.Join(AppWorkPath, Indexer.CommitPath)
+	}
+	Indexer.CommitConnStr = sec.Key("COMMIT_INDEXER_CONN_STR").MustString(Indexer.CommitConnStr)
+	Indexer.CommitIndexerName = sec.Key("COMMIT_INDEXER_NAME").MustString(Indexer.CommitIndexerName)
+
 	Indexer.UpdateQueueLength = sec.Key("UPDATE_BUFFER_LEN").MustInt(20)
 	Indexer.MaxIndexerFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(1024 * 1024)
 	Indexer.StartupTimeout = sec.Key("STARTUP_TIMEOUT").MustDuration(30 * time.Second)
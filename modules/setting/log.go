@@ -215,6 +215,18 @@ func newAccessLogService() {
 	}
 }
 
+func newAuditLogService() {
+	EnableAuditLog = Cfg.Section("log").Key("ENABLE_AUDIT_LOG").MustBool(false)
+	Cfg.Section("log").Key("AUDIT").MustString("file")
+	if EnableAuditLog {
+		options := newDefaultLogOptions()
+		options.filename = filepath.Join(LogRootPath, "audit.log")
+		options.flags = "" // Every line is a JSON object
+		options.bufferLength = Cfg.Section("log").Key("BUFFER_LEN").MustInt64(10000)
+		generateNamedLogger("audit", options)
+	}
+}
+
 func newRouterLogService() {
 	Cfg.Section("log").Key("ROUTER").MustString("console")
 	// Allow [log]  DISABLE_ROUTER_LOG to override [server] DISABLE_ROUTER_LOG
@@ -296,6 +308,7 @@ func NewLogServices(disableConsole bool) {
 	newMacaronLogService()
 	newRouterLogService()
 	newAccessLogService()
+	newAuditLogService()
 	NewXORMLogService(disableConsole)
 }
 
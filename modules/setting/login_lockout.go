@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// LoginLockout defines the brute-force protection of password sign-ins
+	LoginLockout = struct {
+		Enabled            bool
+		MaxAccountAttempts int           `ini:"MAX_ACCOUNT_ATTEMPTS"`
+		MaxIPAttempts      int           `ini:"MAX_IP_ATTEMPTS"`
+		FailureWindow      time.Duration `ini:"FAILURE_WINDOW"`
+		LockoutDuration    time.Duration `ini:"LOCKOUT_DURATION"`
+		DelayAfterAttempts int           `ini:"DELAY_AFTER_ATTEMPTS"`
+		Delay              time.Duration `ini:"DELAY"`
+		MaxDelay           time.Duration `ini:"MAX_DELAY"`
+		NotifyUser         bool          `ini:"NOTIFY_USER"`
+	}{
+		Enabled:            true,
+		MaxAccountAttempts: 10,
+		MaxIPAttempts:      50,
+		FailureWindow:      15 * time.Minute,
+		LockoutDuration:    15 * time.Minute,
+		DelayAfterAttempts: 3,
+		Delay:              time.Second,
+		MaxDelay:           30 * time.Second,
+		NotifyUser:         true,
+	}
+)
+
+func newLoginLockoutService() {
+	sec := Cfg.Section("security.login_lockout")
+	if err := sec.MapTo(&LoginLockout); err != nil {
+		log.Fatal("Failed to map login lockout settings: %v", err)
+	}
+
+	if LoginLockout.Enabled && !CacheService.Enabled {
+		log.Warn("Login lockout requires the cache service, failed sign-in attempts will not be tracked")
+		LoginLockout.Enabled = false
+	}
+
+	if LoginLockout.Enabled {
+		log.Info("Login Lockout Service Enabled")
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// Packages defines the package registry served under /api/packages/
+	Packages = struct {
+		Enabled     bool
+		ContentPath string `ini:"CONTENT_PATH"`
+		MaxFileSize int64  `ini:"MAX_FILE_SIZE"`
+	}{
+		Enabled:     false,
+		MaxFileSize: 100 * 1024 * 1024,
+	}
+)
+
+func newPackages() {
+	sec := Cfg.Section("packages")
+	if err := sec.MapTo(&Packages); err != nil {
+		log.Fatal("Failed to map packages settings: %v", err)
+	}
+
+	Packages.ContentPath = sec.Key("CONTENT_PATH").MustString(filepath.Join(AppDataPath, "packages"))
+	if !filepath.IsAbs(Packages.ContentPath) {
+		Packages.ContentPath = filepath.Join(AppWorkPath, Packages.ContentPath)
+	}
+
+	if Packages.Enabled {
+		if err := os.MkdirAll(Packages.ContentPath, 0700); err != nil {
+			log.Fatal("Failed to create '%s': %v", Packages.ContentPath, err)
+		}
+	}
+}
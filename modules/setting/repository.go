@@ -75,7 +75,9 @@ var (
 
 		// Issue Setting
 		Issue struct {
-			LockReasons []string
+			LockReasons   []string
+			ImportMaxSize int64
+			ImportMaxRows int
 		} `ini:"repository.issue"`
 
 		Signing struct {
@@ -161,9 +163,13 @@ var (
 
 		// Issue settings
 		Issue: struct {
-			LockReasons []string
+			LockReasons   []string
+			ImportMaxSize int64
+			ImportMaxRows int
 		}{
-			LockReasons: strings.Split("Too heated,Off-topic,Spam,Resolved", ","),
+			LockReasons:   strings.Split("Too heated,Off-topic,Spam,Resolved", ","),
+			ImportMaxSize: 10,
+			ImportMaxRows: 1000,
 		},
 
 		// Signing settings
@@ -10,6 +10,13 @@ import (
 	"code.gitea.io/gitea/modules/structs"
 )
 
+// enumerates all the values of [service] REQUIRE_TWO_FACTOR
+const (
+	TwoFactorRequiredNone   = "none"
+	TwoFactorRequiredAdmins = "admins"
+	TwoFactorRequiredAll    = "all"
+)
+
 // Service settings
 var Service struct {
 	DefaultOrgVisibility                    string
@@ -47,6 +54,7 @@ var Service struct {
 	AutoWatchNewRepos                       bool
 	AutoWatchOnChanges                      bool
 	DefaultOrgMemberVisible                 bool
+	RequireTwoFactor                        string
 
 	// OpenID settings
 	EnableOpenIDSignIn bool
@@ -92,6 +100,8 @@ func newService() {
 	Service.DefaultOrgVisibility = sec.Key("DEFAULT_ORG_VISIBILITY").In("public", structs.ExtractKeysFromMapString(structs.VisibilityModes))
 	Service.DefaultOrgVisibilityMode = structs.VisibilityModes[Service.DefaultOrgVisibility]
 	Service.DefaultOrgMemberVisible = sec.Key("DEFAULT_ORG_MEMBER_VISIBLE").MustBool()
+	Service.RequireTwoFactor = sec.Key("REQUIRE_TWO_FACTOR").In(TwoFactorRequiredNone,
+		[]string{TwoFactorRequiredNone, TwoFactorRequiredAdmins, TwoFactorRequiredAll})
 
 	sec = Cfg.Section("openid")
 	Service.EnableOpenIDSignIn = sec.Key("ENABLE_OPENID_SIGNIN").MustBool(!InstallLock)
@@ -121,6 +121,7 @@ var (
 		MinimumKeySizeCheck      bool           `ini:"-"`
 		MinimumKeySizes          map[string]int `ini:"-"`
 		CreateAuthorizedKeysFile bool           `ini:"SSH_CREATE_AUTHORIZED_KEYS_FILE"`
+		TrustedUserCAKeysFile    string         `ini:"SSH_TRUSTED_USER_CA_KEYS_FILENAME"`
 		ExposeAnonymous          bool           `ini:"SSH_EXPOSE_ANONYMOUS"`
 	}{
 		Disabled:           false,
@@ -192,6 +193,10 @@ var (
 			Description string
 			Keywords    string
 		} `ini:"ui.meta"`
+		Notification struct {
+			EventSourceEnabled   bool
+			EventSourceKeepAlive time.Duration
+		} `ini:"ui.notification"`
 	}{
 		ExplorePagingNum:    20,
 		IssuePagingNum:      10,
@@ -231,6 +236,13 @@ var (
 			Description: "Gitea (Git with a cup of tea) is a painless self-hosted Git service written in Go",
 			Keywords:    "go,git,self-hosted,gitea",
 		},
+		Notification: struct {
+			EventSourceEnabled   bool
+			EventSourceKeepAlive time.Duration
+		}{
+			EventSourceEnabled:   true,
+			EventSourceKeepAlive: 30 * time.Second,
+		},
 	}
 
 	// Markdown settings
@@ -274,6 +286,7 @@ var (
 	RouterLogMode      string
 	EnableAccessLog    bool
 	AccessLogTemplate  string
+	EnableAuditLog     bool
 	EnableXORMLog      bool
 
 	// Attachment settings
@@ -342,6 +355,13 @@ var (
 		Token:   "",
 	}
 
+	// SCIM settings
+	SCIM = struct {
+		Enabled bool
+	}{
+		Enabled: false,
+	}
+
 	// I18n settings
 	Langs     []string
 	Names     []string
@@ -953,6 +973,8 @@ func NewContext() {
 		log.Fatal("Failed to map API settings: %v", err)
 	} else if err = Cfg.Section("metrics").MapTo(&Metrics); err != nil {
 		log.Fatal("Failed to map Metrics settings: %v", err)
+	} else if err = Cfg.Section("scim").MapTo(&SCIM); err != nil {
+		log.Fatal("Failed to map SCIM settings: %v", err)
 	}
 
 	u := *appURL
@@ -961,6 +983,9 @@ func NewContext() {
 
 	newCron()
 	newGit()
+	newContainerRegistry()
+	newPackages()
+	newCI()
 
 	sec = Cfg.Section("mirror")
 	Mirror.MinInterval = sec.Key("MIN_INTERVAL").MustDuration(10 * time.Minute)
@@ -998,6 +1023,9 @@ func NewContext() {
 	UI.DefaultShowFullName = Cfg.Section("ui").Key("DEFAULT_SHOW_FULL_NAME").MustBool(false)
 	UI.SearchRepoDescription = Cfg.Section("ui").Key("SEARCH_REPO_DESCRIPTION").MustBool(true)
 	UI.UseServiceWorker = Cfg.Section("ui").Key("USE_SERVICE_WORKER").MustBool(true)
+	if UI.Notification.EventSourceKeepAlive <= 0 {
+		UI.Notification.EventSourceKeepAlive = 30 * time.Second
+	}
 
 	HasRobotsTxt = com.IsFile(path.Join(CustomPath, "robots.txt"))
 
@@ -1091,11 +1119,14 @@ func NewServices() {
 	newService()
 	NewLogServices(false)
 	newCacheService()
+	newLoginLockoutService()
 	newSessionService()
 	newCORSService()
 	newMailService()
 	newRegisterMailService()
 	newNotifyMailService()
+	newIncomingEmailService()
+	newWebPushService()
 	newWebhookService()
 	newMigrationsService()
 	newIndexerService()
@@ -0,0 +1,112 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/unknwon/com"
+	ini "gopkg.in/ini.v1"
+)
+
+// WebPush settings of the browser push notifications
+var WebPush = struct {
+	Enabled         bool
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	Subject         string
+	TTL             time.Duration
+	// AllowedHosts are the patterns of the hosts of the push services the
+	// subscriptions of the browsers may point to
+	AllowedHosts     []string
+	MaxSubscriptions int
+}{
+	Enabled: false,
+	TTL:     24 * time.Hour,
+	AllowedHosts: []string{
+		"fcm.googleapis.com",
+		"*.push.services.mozilla.com",
+		"*.notify.windows.com",
+		"*.push.apple.com",
+	},
+	MaxSubscriptions: 10,
+}
+
+func newWebPushService() {
+	sec := Cfg.Section("webpush")
+	WebPush.Enabled = sec.Key("ENABLED").MustBool(false)
+	if !WebPush.Enabled {
+		return
+	}
+
+	WebPush.VAPIDPublicKey = sec.Key("VAPID_PUBLIC_KEY").String()
+	WebPush.VAPIDPrivateKey = sec.Key("VAPID_PRIVATE_KEY").String()
+	if WebPush.VAPIDPublicKey == "" && WebPush.VAPIDPrivateKey == "" {
+		generateVAPIDKeys()
+	}
+	if !isValidVAPIDKey(WebPush.VAPIDPublicKey, 65) || !isValidVAPIDKey(WebPush.VAPIDPrivateKey, 32) {
+		log.Fatal("Invalid webpush.VAPID_PUBLIC_KEY or webpush.VAPID_PRIVATE_KEY, remove both to generate new ones")
+	}
+
+	WebPush.Subject = sec.Key("SUBJECT").String()
+	if WebPush.Subject == "" {
+		WebPush.Subject = AppURL
+		if MailService != nil {
+			WebPush.Subject = "mailto:" + MailService.FromEmail
+		}
+	} else if !strings.HasPrefix(WebPush.Subject, "mailto:") && !strings.HasPrefix(WebPush.Subject, "https://") {
+		log.Fatal("webpush.SUBJECT must be a mailto: or https:// URL")
+	}
+	WebPush.TTL = sec.Key("TTL").MustDuration(WebPush.TTL)
+	if hosts := sec.Key("ALLOWED_HOSTS").Strings(","); len(hosts) > 0 {
+		WebPush.AllowedHosts = make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			WebPush.AllowedHosts = append(WebPush.AllowedHosts, strings.ToLower(host))
+		}
+	}
+	WebPush.MaxSubscriptions = sec.Key("MAX_SUBSCRIPTIONS_PER_USER").MustInt(WebPush.MaxSubscriptions)
+
+	log.Info("Web Push Service Enabled")
+}
+
+func isValidVAPIDKey(key string, size int) bool {
+	bs, err := base64.RawURLEncoding.DecodeString(key)
+	return err == nil && len(bs) == size
+}
+
+// generateVAPIDKeys generates the VAPID keys and saves them, since the
+// subscriptions of the browsers are bound to them
+func generateVAPIDKeys() {
+	var err error
+	WebPush.VAPIDPublicKey, WebPush.VAPIDPrivateKey, err = generate.NewVAPIDKeys()
+	if err != nil {
+		log.Fatal("Error generating VAPID keys: %v", err)
+	}
+
+	cfg := ini.Empty()
+	if com.IsFile(CustomConf) {
+		// Keeps custom settings if there is already something.
+		if err := cfg.Append(CustomConf); err != nil {
+			log.Error("Failed to load custom conf '%s': %v", CustomConf, err)
+		}
+	}
+
+	cfg.Section("webpush").Key("VAPID_PUBLIC_KEY").SetValue(WebPush.VAPIDPublicKey)
+	cfg.Section("webpush").Key("VAPID_PRIVATE_KEY").SetValue(WebPush.VAPIDPrivateKey)
+
+	if err := os.MkdirAll(filepath.Dir(CustomConf), os.ModePerm); err != nil {
+		log.Fatal("Failed to create '%s': %v", CustomConf, err)
+	}
+	if err := cfg.SaveTo(CustomConf); err != nil {
+		log.Fatal("Error saving generated VAPID keys to custom config: %v", err)
+	}
+}
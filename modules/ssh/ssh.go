@@ -136,6 +136,22 @@ func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
 		return false
 	}
 
+	if cert, ok := key.(*gossh.Certificate); ok {
+		pkey, err := models.CheckSSHCertificate(cert, ctx.RemoteAddr())
+		if err != nil {
+			if models.IsErrSSHCertificateInvalid(err) {
+				log.Warn("Rejected SSH certificate from %s: %v", ctx.RemoteAddr(), err)
+			} else {
+				log.Error("CheckSSHCertificate: %v", err)
+			}
+			return false
+		}
+
+		ctx.SetValue(giteaKeyID, pkey.ID)
+
+		return true
+	}
+
 	pkey, err := models.SearchPublicKeyByContent(strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))))
 	if err != nil {
 		log.Error("SearchPublicKeyByContent: %v", err)
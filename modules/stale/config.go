@@ -0,0 +1,144 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package stale defines the configuration of the automation marking the inactive issues
+// and pull requests of repositories as stale, then closing them after a grace period.
+// Repositories configure it in their settings, which the .gitea/stale.yml file of their
+// default branch can override.
+package stale
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFile is the path of the configuration file in the default branch of the repositories
+const ConfigFile = ".gitea/stale.yml"
+
+// MaxConfigSize is the maximum size of the configuration file
+const MaxConfigSize = 64 * 1024
+
+// Values of Config.Only
+const (
+	OnlyIssues = "issues"
+	OnlyPulls  = "pulls"
+)
+
+// Config represents the configuration of the stale automation, the keys of the
+// configuration file are the yaml tags
+type Config struct {
+	// Only restricts the automation to the issues or to the pull requests
+	Only string `yaml:"only"`
+	// DaysUntilStale is the number of days without activity before an item is marked as stale
+	DaysUntilStale int `yaml:"daysUntilStale"`
+	// DaysUntilClose is the number of days without activity before a stale item is closed,
+	// 0 to never close them
+	DaysUntilClose int `yaml:"daysUntilClose"`
+	// OnlyLabels restricts the automation to the items with one of the labels
+	OnlyLabels []string `yaml:"onlyLabels"`
+	// ExemptLabels are the labels of the items never marked as stale
+	ExemptLabels []string `yaml:"exemptLabels"`
+	// ExemptMilestones exempts the items of milestones
+	ExemptMilestones bool `yaml:"exemptMilestones"`
+	// ExemptAssignees exempts the items with assignees
+	ExemptAssignees bool `yaml:"exemptAssignees"`
+	// StaleLabel is the label marking the stale items
+	StaleLabel string `yaml:"staleLabel"`
+	// MarkComment is posted when an item is marked as stale, none if empty
+	MarkComment string `yaml:"markComment"`
+	// CloseComment is posted when a stale item is closed, none if empty
+	CloseComment string `yaml:"closeComment"`
+	// LimitPerRun is the maximum number of items marked as stale by each run
+	LimitPerRun int `yaml:"limitPerRun"`
+}
+
+// DefaultConfig returns the default configuration
+func DefaultConfig() *Config {
+	return &Config{
+		DaysUntilStale: 60,
+		DaysUntilClose: 7,
+		StaleLabel:     "stale",
+		MarkComment: "This has been automatically marked as stale because it has not had recent activity. " +
+			"It will be closed if no further activity occurs.",
+		LimitPerRun: 30,
+	}
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	switch {
+	case c.Only != "" && c.Only != OnlyIssues && c.Only != OnlyPulls:
+		return fmt.Errorf("only must be %s or %s", OnlyIssues, OnlyPulls)
+	case c.DaysUntilStale < 1:
+		return fmt.Errorf("daysUntilStale must be at least 1")
+	case c.DaysUntilClose < 0:
+		return fmt.Errorf("daysUntilClose must not be negative")
+	case c.StaleLabel == "":
+		return fmt.Errorf("staleLabel must not be empty")
+	case c.LimitPerRun < 1:
+		return fmt.Errorf("limitPerRun must be at least 1")
+	}
+	return nil
+}
+
+// Split returns the configurations of the issues and of the pull requests, nil for
+// the ones it does not apply to
+func (c *Config) Split() (issues, pulls *Config) {
+	if c.Only != OnlyPulls {
+		issues = c
+	}
+	if c.Only != OnlyIssues {
+		pulls = c
+	}
+	return issues, pulls
+}
+
+// ParseConfig parses the configuration file on top of the base configuration, the
+// issues and pulls sections of the file override the configuration of the issues and
+// of the pull requests. It returns them as Split does.
+func ParseConfig(data []byte, base *Config) (issues, pulls *Config, err error) {
+	if len(data) > MaxConfigSize {
+		return nil, nil, fmt.Errorf("%s is larger than %d bytes", ConfigFile, MaxConfigSize)
+	}
+
+	c := *base
+	if err = yaml.Unmarshal(data, &c); err != nil {
+		return nil, nil, err
+	}
+	var sections struct {
+		Issues interface{} `yaml:"issues"`
+		Pulls  interface{} `yaml:"pulls"`
+	}
+	if err = yaml.Unmarshal(data, &sections); err != nil {
+		return nil, nil, err
+	}
+
+	issues, pulls = c.Split()
+	if issues, err = override(issues, sections.Issues); err != nil {
+		return nil, nil, fmt.Errorf("issues: %v", err)
+	}
+	if pulls, err = override(pulls, sections.Pulls); err != nil {
+		return nil, nil, fmt.Errorf("pulls: %v", err)
+	}
+	return issues, pulls, nil
+}
+
+// override returns a copy of the configuration with the values of the section
+func override(c *Config, section interface{}) (*Config, error) {
+	if c == nil {
+		return nil, nil
+	}
+	overridden := *c
+	if section != nil {
+		data, err := yaml.Marshal(section)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.Unmarshal(data, &overridden); err != nil {
+			return nil, err
+		}
+	}
+	return &overridden, overridden.Validate()
+}
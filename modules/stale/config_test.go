@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package stale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	base := DefaultConfig()
+	base.ExemptLabels = []string{"security"}
+
+	issues, pulls, err := ParseConfig([]byte(`
+daysUntilStale: 30
+exemptLabels:
+  - pinned
+  - roadmap
+closeComment: Closed for inactivity.
+pulls:
+  daysUntilStale: 14
+  daysUntilClose: 0
+  markComment: ""
+`), base)
+	assert.NoError(t, err)
+	if assert.NotNil(t, issues) && assert.NotNil(t, pulls) {
+		assert.Equal(t, 30, issues.DaysUntilStale)
+		assert.Equal(t, 7, issues.DaysUntilClose)
+		assert.Equal(t, []string{"pinned", "roadmap"}, issues.ExemptLabels)
+		assert.Equal(t, "stale", issues.StaleLabel)
+		assert.Equal(t, base.MarkComment, issues.MarkComment)
+		assert.Equal(t, "Closed for inactivity.", issues.CloseComment)
+
+		assert.Equal(t, 14, pulls.DaysUntilStale)
+		assert.Equal(t, 0, pulls.DaysUntilClose)
+		assert.Equal(t, []string{"pinned", "roadmap"}, pulls.ExemptLabels)
+		assert.Empty(t, pulls.MarkComment)
+		assert.Equal(t, "Closed for inactivity.", pulls.CloseComment)
+	}
+	// the base configuration is left untouched
+	assert.Equal(t, 60, base.DaysUntilStale)
+
+	issues, pulls, err = ParseConfig([]byte("only: pulls\n"), base)
+	assert.NoError(t, err)
+	assert.Nil(t, issues)
+	assert.NotNil(t, pulls)
+
+	_, _, err = ParseConfig([]byte("only: commits\n"), base)
+	assert.Error(t, err)
+	_, _, err = ParseConfig([]byte("issues:\n  daysUntilStale: 0\n"), base)
+	assert.Error(t, err)
+	_, _, err = ParseConfig([]byte("daysUntilStale: [1]\n"), base)
+	assert.Error(t, err)
+}
+
+func TestConfigSplit(t *testing.T) {
+	c := DefaultConfig()
+	issues, pulls := c.Split()
+	assert.Equal(t, c, issues)
+	assert.Equal(t, c, pulls)
+
+	c.Only = OnlyIssues
+	issues, pulls = c.Split()
+	assert.Equal(t, c, issues)
+	assert.Nil(t, pulls)
+}
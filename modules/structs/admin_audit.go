@@ -0,0 +1,25 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// AuditEvent represents a security-relevant event of the audit log
+type AuditEvent struct {
+	ID         int64  `json:"id"`
+	Action     string `json:"action"`
+	ActorID    int64  `json:"actor_id"`
+	Actor      string `json:"actor"`
+	IPAddress  string `json:"ip_address"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	Target     string `json:"target"`
+	// Diff describes the change, changed fields hold an object with the old and the new value
+	Diff map[string]interface{} `json:"diff"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
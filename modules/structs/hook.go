@@ -374,6 +374,8 @@ const (
 	HookIssueMilestoned HookIssueAction = "milestoned"
 	// HookIssueDemilestoned is an issue action for when a milestone is cleared on an issue.
 	HookIssueDemilestoned HookIssueAction = "demilestoned"
+	// HookIssueTransferred is an issue action for when an issue is moved to another repository.
+	HookIssueTransferred HookIssueAction = "transferred"
 )
 
 // IssuePayload represents the payload information that is sent along with an issue event.
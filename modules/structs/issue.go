@@ -112,6 +112,44 @@ type EditDeadlineOption struct {
 	Deadline *time.Time `json:"due_date"`
 }
 
+// TransferIssueOption options for transferring an issue to another repository
+// swagger:model
+type TransferIssueOption struct {
+	// owner of the repository to transfer the issue to
+	// required: true
+	NewOwner string `json:"new_owner" binding:"Required"`
+	// name of the repository to transfer the issue to
+	// required: true
+	NewRepo string `json:"new_repo" binding:"Required"`
+}
+
+// BulkEditIssuesOption options for editing many issues and pull requests at once,
+// the omitted fields are left unchanged
+// swagger:model
+type BulkEditIssuesOption struct {
+	// indexes of the issues and pull requests to edit
+	// required: true
+	Indexes []int64 `json:"indexes" binding:"Required"`
+	// IDs of the labels to add
+	AddLabels []int64 `json:"add_labels"`
+	// IDs of the labels to remove
+	RemoveLabels []int64 `json:"remove_labels"`
+	// usernames of the users to assign
+	AddAssignees []string `json:"add_assignees"`
+	// usernames of the users to unassign
+	RemoveAssignees []string `json:"remove_assignees"`
+	// ID of the milestone, 0 to remove the milestone
+	Milestone *int64 `json:"milestone"`
+	// state of the issues and pull requests, the state of merged pull requests and of
+	// pull requests whose branches have another open one is left unchanged
+	// enum: open,closed
+	State  *string `json:"state"`
+	Locked *bool   `json:"locked"`
+	// swagger:strfmt date-time
+	Deadline       *time.Time `json:"due_date"`
+	RemoveDeadline *bool      `json:"unset_due_date"`
+}
+
 // IssueDeadline represents an issue deadline
 // swagger:model
 type IssueDeadline struct {
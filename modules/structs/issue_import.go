@@ -0,0 +1,32 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// ImportIssuesOption options for importing issues from a CSV or JSON file
+type ImportIssuesOption struct {
+	// required: true
+	// enum: csv,json
+	Format string `json:"format" binding:"Required;In(csv,json)"`
+	// content of the file, a CSV file starts with the names of its columns
+	// and a JSON file is a list of objects whose keys are the columns
+	// required: true
+	Content string `json:"content" binding:"Required"`
+	// issue field of each column among title, body, state, labels, assignees, milestone,
+	// deadline, created, updated and closed, the columns named after a field are mapped
+	// to it if omitted
+	Mapping map[string]string `json:"mapping"`
+	// only validate the rows without creating the issues
+	DryRun bool `json:"dry_run"`
+}
+
+// ImportedIssueRow represents a row of an imported file
+type ImportedIssueRow struct {
+	// position of the row in the file, starting at 1 after the header
+	Row    int      `json:"row"`
+	Title  string   `json:"title"`
+	Errors []string `json:"errors"`
+	// the created issue, omitted on a dry run
+	Issue *Issue `json:"issue,omitempty"`
+}
@@ -15,6 +15,7 @@ type NotificationThread struct {
 	Subject    *NotificationSubject `json:"subject"`
 	Unread     bool                 `json:"unread"`
 	Pinned     bool                 `json:"pinned"`
+	Reason     string               `json:"reason"`
 	UpdatedAt  time.Time            `json:"updated_at"`
 	URL        string               `json:"url"`
 }
@@ -27,6 +28,16 @@ type NotificationSubject struct {
 	Type             string `json:"type" binding:"In(Issue,Pull,Commit)"`
 }
 
+// EditNotificationThreadsOption options for changing several notification threads at once
+type EditNotificationThreadsOption struct {
+	// the threads not belonging to the user are ignored
+	// required: true
+	IDs []int64 `json:"ids" binding:"Required"`
+	// required: true
+	// enum: read,unread,pin,unpin,unsubscribe
+	Action string `json:"action" binding:"Required;In(read,unread,pin,unpin,unsubscribe)"`
+}
+
 // NotificationCount number of unread notifications
 type NotificationCount struct {
 	New int64 `json:"new"`
@@ -15,6 +15,7 @@ type Organization struct {
 	Location                  string `json:"location"`
 	Visibility                string `json:"visibility"`
 	RepoAdminChangeTeamAccess bool   `json:"repo_admin_change_team_access"`
+	RequireTwoFactor          bool   `json:"require_two_factor"`
 }
 
 // CreateOrgOption options for creating an organization
@@ -41,4 +42,6 @@ type EditOrgOption struct {
 	// enum: public,limited,private
 	Visibility                string `json:"visibility" binding:"In(,public,limited,private)"`
 	RepoAdminChangeTeamAccess bool   `json:"repo_admin_change_team_access"`
+	// require members and collaborators to enroll two-factor authentication to access the repositories
+	RequireTwoFactor *bool `json:"require_two_factor"`
 }
@@ -17,6 +17,9 @@ type Team struct {
 	// example: ["repo.code","repo.issues","repo.ext_issues","repo.wiki","repo.pulls","repo.releases","repo.ext_wiki"]
 	Units            []string `json:"units"`
 	CanCreateOrgRepo bool     `json:"can_create_org_repo"`
+	// how a member is picked to review the pull requests the team is requested to review
+	// enum: none,round_robin,load_balance
+	ReviewAssignment string `json:"review_assignment"`
 }
 
 // CreateTeamOption options for creating a team
@@ -30,6 +33,8 @@ type CreateTeamOption struct {
 	// example: ["repo.code","repo.issues","repo.ext_issues","repo.wiki","repo.pulls","repo.releases","repo.ext_wiki"]
 	Units            []string `json:"units"`
 	CanCreateOrgRepo bool     `json:"can_create_org_repo"`
+	// enum: none,round_robin,load_balance
+	ReviewAssignment string `json:"review_assignment" binding:"In(,none,round_robin,load_balance)"`
 }
 
 // EditTeamOption options for editing a team
@@ -43,4 +48,6 @@ type EditTeamOption struct {
 	// example: ["repo.code","repo.issues","repo.ext_issues","repo.wiki","repo.pulls","repo.releases","repo.ext_wiki"]
 	Units            []string `json:"units"`
 	CanCreateOrgRepo *bool    `json:"can_create_org_repo"`
+	// enum: none,round_robin,load_balance
+	ReviewAssignment string `json:"review_assignment" binding:"In(,none,round_robin,load_balance)"`
 }
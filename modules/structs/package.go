@@ -0,0 +1,45 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Package represents a published version of a package
+type Package struct {
+	ID      int64  `json:"id"`
+	Owner   *User  `json:"owner"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Creator *User  `json:"creator"`
+	// Repository is the repository the version is linked to, if any
+	Repository    *Repository `json:"repository"`
+	DownloadCount int64       `json:"download_count"`
+	// Metadata holds the metadata of the version, its fields depend on the package type
+	Metadata map[string]interface{} `json:"metadata"`
+	Files    []*PackageFile         `json:"files"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// PackageFile represents a file of a package version
+type PackageFile struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	HashMD5    string `json:"md5"`
+	HashSHA1   string `json:"sha1"`
+	HashSHA256 string `json:"sha256"`
+	HashSHA512 string `json:"sha512"`
+}
+
+// EditPackageOption options for editing a package version
+type EditPackageOption struct {
+	// Name of a repository of the package owner to link the version to, an empty name
+	// removes the link
+	Repository *string `json:"repository"`
+}
@@ -91,3 +91,17 @@ type EditPullRequestOption struct {
 	Deadline       *time.Time `json:"due_date"`
 	RemoveDeadline *bool      `json:"unset_due_date"`
 }
+
+// PullReviewRequestOptions are options to request or withdraw the review of a pull request
+type PullReviewRequestOptions struct {
+	// names of the users
+	Reviewers []string `json:"reviewers"`
+	// names of the teams of the organization owning the repository
+	TeamReviewers []string `json:"team_reviewers"`
+}
+
+// PullReviewRequests represents the pending review requests of a pull request
+type PullReviewRequests struct {
+	Users []*User `json:"users"`
+	Teams []*Team `json:"teams"`
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// CodeSearchLine a line of a file matching a code search
+type CodeSearchLine struct {
+	Number  int    `json:"number"`
+	Content string `json:"content"`
+}
+
+// CodeSearchResult a file matching a code search
+type CodeSearchResult struct {
+	Repository *Repository       `json:"repository"`
+	Filename   string            `json:"filename"`
+	Language   string            `json:"language"`
+	HTMLURL    string            `json:"html_url"`
+	Lines      []*CodeSearchLine `json:"lines"`
+}
+
+// CodeSearchLanguage the number of files of a language matching a code search
+type CodeSearchLanguage struct {
+	Language string `json:"language"`
+	Color    string `json:"color"`
+	Count    int    `json:"count"`
+}
+
+// CodeSearchResults results of a successful code search
+type CodeSearchResults struct {
+	OK   bool                `json:"ok"`
+	Data []*CodeSearchResult `json:"data"`
+	// Languages are counted without the language filters
+	Languages []*CodeSearchLanguage `json:"languages"`
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// CommitSearchResult a commit matching a commit search
+type CommitSearchResult struct {
+	Repository  *Repository `json:"repository"`
+	SHA         string      `json:"sha"`
+	HTMLURL     string      `json:"html_url"`
+	Message     string      `json:"message"`
+	AuthorName  string      `json:"author_name"`
+	AuthorEmail string      `json:"author_email"`
+	// swagger:strfmt date-time
+	Authored time.Time `json:"authored"`
+	// swagger:strfmt date-time
+	Committed time.Time `json:"committed"`
+}
+
+// CommitSearchResults results of a successful commit search
+type CommitSearchResults struct {
+	OK   bool                  `json:"ok"`
+	Data []*CommitSearchResult `json:"data"`
+}
@@ -585,6 +585,8 @@ func ActionIcon(opType models.ActionType) string {
 		return "eye"
 	case models.ActionRejectPullRequest:
 		return "x"
+	case models.ActionTransferIssue:
+		return "arrow-right"
 	default:
 		return "invalid type"
 	}
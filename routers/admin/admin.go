@@ -16,7 +16,9 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/cron"
 	"code.gitea.io/gitea/modules/git"
@@ -138,6 +140,7 @@ const (
 	syncExternalUsers
 	gitFsck
 	deleteGeneratedRepositoryAvatars
+	containerRegistryGC
 )
 
 // Dashboard show admin panel dashboard
@@ -184,6 +187,9 @@ func Dashboard(ctx *context.Context) {
 		case deleteGeneratedRepositoryAvatars:
 			success = ctx.Tr("admin.dashboard.delete_generated_repository_avatars_success")
 			err = models.RemoveRandomAvatars()
+		case containerRegistryGC:
+			success = ctx.Tr("admin.dashboard.container_registry_gc_success")
+			err = container.GarbageCollect(shutdownCtx, setting.Cron.ContainerRegistryGC.OlderThan)
 		}
 
 		if err != nil {
@@ -191,11 +197,13 @@ func Dashboard(ctx *context.Context) {
 		} else {
 			ctx.Flash.Success(success)
 		}
+		ctx.Audit(models.AuditAdminOperation, nil, audit.Diff{"operation": op, "succeeded": err == nil})
 		ctx.Redirect(setting.AppSubURL + "/admin")
 		return
 	}
 
 	ctx.Data["Stats"] = models.GetStatistic()
+	ctx.Data["ContainerRegistryEnabled"] = setting.ContainerRegistry.Enabled
 	// FIXME: update periodically
 	updateSystemStatus()
 	ctx.Data["SysStatus"] = sysStatus
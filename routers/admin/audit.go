@@ -0,0 +1,61 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplAuditLog base.TplName = "admin/audit"
+)
+
+// AuditLog shows the security-relevant events, the most recent first
+func AuditLog(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.audit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAuditLog"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	action := strings.TrimSpace(ctx.Query("action"))
+	actor := strings.TrimSpace(ctx.Query("actor"))
+	targetType := strings.TrimSpace(ctx.Query("target_type"))
+
+	events, total, err := models.FindAuditEvents(&models.FindAuditEventsOptions{
+		ListOptions: models.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.Admin.NoticePagingNum,
+		},
+		Action:     models.AuditAction(action),
+		ActorName:  actor,
+		TargetType: targetType,
+	})
+	if err != nil {
+		ctx.ServerError("FindAuditEvents", err)
+		return
+	}
+	ctx.Data["Events"] = events
+	ctx.Data["Total"] = total
+	ctx.Data["Action"] = action
+	ctx.Data["Actor"] = actor
+	ctx.Data["TargetType"] = targetType
+
+	pager := context.NewPagination(int(total), setting.UI.Admin.NoticePagingNum, page, 5)
+	pager.AddParam(ctx, "action", "Action")
+	pager.AddParam(ctx, "actor", "Actor")
+	pager.AddParam(ctx, "target_type", "TargetType")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplAuditLog)
+}
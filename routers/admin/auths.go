@@ -10,6 +10,7 @@ import (
 	"regexp"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/auth/ldap"
 	"code.gitea.io/gitea/modules/auth/oauth2"
@@ -251,13 +252,14 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 
-	if err := models.CreateLoginSource(&models.LoginSource{
+	source := &models.LoginSource{
 		Type:          models.LoginType(form.Type),
 		Name:          form.Name,
 		IsActived:     form.IsActive,
 		IsSyncEnabled: form.IsSyncEnabled,
 		Cfg:           config,
-	}); err != nil {
+	}
+	if err := models.CreateLoginSource(source); err != nil {
 		if models.IsErrLoginSourceAlreadyExist(err) {
 			ctx.Data["Err_Name"] = true
 			ctx.RenderWithErr(ctx.Tr("admin.auths.login_source_exist", err.(models.ErrLoginSourceAlreadyExist).Name), tplAuthNew, form)
@@ -268,6 +270,7 @@ func NewAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 	}
 
 	log.Trace("Authentication created by admin(%s): %s", ctx.User.Name, form.Name)
+	ctx.Audit(models.AuditAdminAuthSourceCreate, source, audit.Diff{"type": source.TypeName(), "active": source.IsActived})
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.new_success", form.Name))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths")
@@ -344,6 +347,7 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 
+	before := *source
 	source.Name = form.Name
 	source.IsActived = form.IsActive
 	source.IsSyncEnabled = form.IsSyncEnabled
@@ -358,6 +362,11 @@ func EditAuthSourcePost(ctx *context.Context, form auth.AuthenticationForm) {
 		return
 	}
 	log.Trace("Authentication changed by admin(%s): %d", ctx.User.Name, source.ID)
+	// The configuration may hold credentials, so only the switches are recorded
+	ctx.Audit(models.AuditAdminAuthSourceUpdate, source, audit.Diff{}.
+		Changed("name", before.Name, source.Name).
+		Changed("active", before.IsActived, source.IsActived).
+		Changed("sync_enabled", before.IsSyncEnabled, source.IsSyncEnabled))
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.update_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/auths/" + com.ToStr(form.ID))
@@ -383,6 +392,7 @@ func DeleteAuthSource(ctx *context.Context) {
 		return
 	}
 	log.Trace("Authentication deleted by admin(%s): %d", ctx.User.Name, source.ID)
+	ctx.Audit(models.AuditAdminAuthSourceDelete, source, nil)
 
 	ctx.Flash.Success(ctx.Tr("admin.auths.deletion_success"))
 	ctx.JSON(200, map[string]interface{}{
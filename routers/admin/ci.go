@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplCIRunners base.TplName = "admin/ci_runners"
+)
+
+// CIRunners shows the registration token and the runners of the whole instance
+func CIRunners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.ci_runners")
+	ctx.Data["PageIsAdminCIRunners"] = true
+	ctx.Data["BaseLink"] = setting.AppSubURL + "/admin/ci/runners"
+
+	token, err := models.GetCIRunnerToken(0)
+	if err != nil {
+		ctx.ServerError("GetCIRunnerToken", err)
+		return
+	}
+	ctx.Data["CIRunnerToken"] = token.Token
+	ctx.Data["CIRegisterURL"] = setting.AppURL + "api/ci/runners/register"
+
+	runners, err := models.GetCIRunners(0)
+	if err != nil {
+		ctx.ServerError("GetCIRunners", err)
+		return
+	}
+	ctx.Data["CIRunners"] = runners
+
+	ctx.HTML(200, tplCIRunners)
+}
+
+// ResetCIRunnerToken replaces the registration token of the instance
+func ResetCIRunnerToken(ctx *context.Context) {
+	token, err := models.NewCIRunnerToken(0)
+	if err != nil {
+		ctx.ServerError("NewCIRunnerToken", err)
+		return
+	}
+	log.Trace("CI runner token reset by admin (%s)", ctx.User.Name)
+	ctx.Audit(models.AuditAdminCIRunnerTokenReset, nil, nil)
+	ctx.Flash.Success(ctx.Tr("repo.settings.ci_runners.reset_token_success"))
+	ctx.Flash.Info(token.Token)
+	ctx.Redirect(setting.AppSubURL + "/admin/ci/runners")
+}
+
+// DeleteCIRunner deletes a runner of the instance
+func DeleteCIRunner(ctx *context.Context) {
+	runner, err := models.GetCIRunnerByID(ctx.QueryInt64("id"))
+	if err == nil && runner.RepoID != 0 {
+		err = models.ErrCIRunnerNotExist{ID: runner.ID}
+	}
+	if err == nil {
+		err = ci.DeleteRunner(runner)
+	}
+	if err != nil {
+		ctx.Flash.Error("DeleteCIRunner: " + err.Error())
+	} else {
+		log.Trace("CI runner deleted by admin (%s): %s", ctx.User.Name, runner.Name)
+		ctx.Audit(models.AuditAdminCIRunnerDelete, runner, nil)
+		ctx.Flash.Success(ctx.Tr("repo.settings.ci_runners.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/ci/runners",
+	})
+}
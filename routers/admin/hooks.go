@@ -6,6 +6,7 @@ package admin
 
 import (
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
@@ -35,9 +36,11 @@ func DefaultWebhooks(ctx *context.Context) {
 
 // DeleteDefaultWebhook response for delete admin-default webhook
 func DeleteDefaultWebhook(ctx *context.Context) {
-	if err := models.DeleteDefaultWebhook(ctx.QueryInt64("id")); err != nil {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteDefaultWebhook(id); err != nil {
 		ctx.Flash.Error("DeleteDefaultWebhook: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditWebhookDelete, audit.Target{Type: "webhook", ID: id}, nil)
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
@@ -0,0 +1,65 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/lockout"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplLoginLockouts base.TplName = "admin/lockouts"
+)
+
+// LoginLockouts shows the accounts and IP addresses currently locked out
+func LoginLockouts(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.lockouts")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminLoginLockouts"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	lockouts, total, err := models.FindActiveLoginLockouts(models.ListOptions{
+		Page:     page,
+		PageSize: setting.UI.Admin.NoticePagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("FindActiveLoginLockouts", err)
+		return
+	}
+	ctx.Data["Lockouts"] = lockouts
+	ctx.Data["Total"] = total
+	ctx.Data["LockoutEnabled"] = setting.LoginLockout.Enabled
+
+	ctx.Data["Page"] = context.NewPagination(int(total), setting.UI.Admin.NoticePagingNum, page, 5)
+
+	ctx.HTML(200, tplLoginLockouts)
+}
+
+// ClearLoginLockout lifts a lockout
+func ClearLoginLockout(ctx *context.Context) {
+	l, err := models.GetLoginLockoutByID(ctx.QueryInt64("id"))
+	if err == nil {
+		err = lockout.Clear(l)
+	}
+	if err != nil {
+		ctx.Flash.Error("ClearLoginLockout: " + err.Error())
+	} else {
+		log.Trace("Login lockout of %s cleared by admin (%s)", l.Target, ctx.User.Name)
+		ctx.Audit(models.AuditAdminLockoutClear, l, nil)
+		ctx.Flash.Success(ctx.Tr("admin.lockouts.clear_success", l.Target))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/lockouts",
+	})
+}
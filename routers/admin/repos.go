@@ -44,6 +44,7 @@ func DeleteRepo(ctx *context.Context) {
 		return
 	}
 	log.Trace("Repository deleted: %s", repo.FullName())
+	ctx.Audit(models.AuditRepoDelete, repo, nil)
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.deletion_success"))
 	ctx.JSON(200, map[string]interface{}{
@@ -0,0 +1,90 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplSSHCertificateAuthorities base.TplName = "admin/ssh_cas"
+)
+
+func loadSSHCertificateAuthorities(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.ssh_cas")
+	ctx.Data["PageIsAdminSSHCertificateAuthorities"] = true
+
+	cas, err := models.ListSSHCertificateAuthorities()
+	if err != nil {
+		ctx.ServerError("ListSSHCertificateAuthorities", err)
+		return
+	}
+	ctx.Data["CertificateAuthorities"] = cas
+}
+
+// SSHCertificateAuthorities shows the trusted SSH certificate authorities
+func SSHCertificateAuthorities(ctx *context.Context) {
+	loadSSHCertificateAuthorities(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tplSSHCertificateAuthorities)
+}
+
+// NewSSHCertificateAuthorityPost adds a trusted SSH certificate authority
+func NewSSHCertificateAuthorityPost(ctx *context.Context, form auth.AdminAddSSHCertificateAuthorityForm) {
+	loadSSHCertificateAuthorities(ctx)
+	if ctx.Written() {
+		return
+	}
+	if ctx.HasError() {
+		ctx.Data["HasCAError"] = true
+		ctx.HTML(200, tplSSHCertificateAuthorities)
+		return
+	}
+
+	ca, err := models.AddSSHCertificateAuthority(form.Name, form.Content)
+	if err != nil {
+		ctx.Data["HasCAError"] = true
+		switch {
+		case models.IsErrKeyUnableVerify(err):
+			ctx.Data["Err_Content"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.ssh_cas.invalid_key", err.Error()), tplSSHCertificateAuthorities, &form)
+		case models.IsErrSSHCertificateAuthorityAlreadyExist(err):
+			ctx.Data["Err_Name"] = true
+			ctx.RenderWithErr(ctx.Tr("admin.ssh_cas.already_exist"), tplSSHCertificateAuthorities, &form)
+		default:
+			ctx.ServerError("AddSSHCertificateAuthority", err)
+		}
+		return
+	}
+	log.Trace("SSH certificate authority added by admin (%s): %s [%s]", ctx.User.Name, ca.Name, ca.Fingerprint)
+	ctx.Audit(models.AuditAdminSSHCAAdd, ca, audit.Diff{"fingerprint": ca.Fingerprint})
+
+	ctx.Flash.Success(ctx.Tr("admin.ssh_cas.add_success", ca.Name))
+	ctx.Redirect(setting.AppSubURL + "/admin/ssh_cas")
+}
+
+// DeleteSSHCertificateAuthority removes a trusted SSH certificate authority
+func DeleteSSHCertificateAuthority(ctx *context.Context) {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteSSHCertificateAuthority(id); err != nil {
+		ctx.Flash.Error("DeleteSSHCertificateAuthority: " + err.Error())
+	} else {
+		log.Trace("SSH certificate authority deleted by admin (%s): %d", ctx.User.Name, id)
+		ctx.Audit(models.AuditAdminSSHCADelete, audit.Target{Type: "ssh_ca", ID: id}, nil)
+		ctx.Flash.Success(ctx.Tr("admin.ssh_cas.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/ssh_cas",
+	})
+}
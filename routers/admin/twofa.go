@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplTwoFactorReport base.TplName = "admin/twofa"
+)
+
+// TwoFactorReport lists the users who have not enrolled two-factor authentication
+func TwoFactorReport(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.twofa")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminTwoFactor"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	reportType := ctx.Query("type")
+	tp := models.TwoFactorReportAll
+	switch reportType {
+	case "admins":
+		tp = models.TwoFactorReportAdmins
+	case "org_members":
+		tp = models.TwoFactorReportOrgMembers
+	default:
+		reportType = "all"
+	}
+
+	users, total, err := models.FindUsersWithoutTwoFactor(tp, models.ListOptions{
+		Page:     page,
+		PageSize: setting.UI.Admin.UserPagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("FindUsersWithoutTwoFactor", err)
+		return
+	}
+	ctx.Data["Users"] = users
+	ctx.Data["Total"] = total
+	ctx.Data["ReportType"] = reportType
+	ctx.Data["RequireTwoFactor"] = setting.Service.RequireTwoFactor
+
+	pager := context.NewPagination(int(total), setting.UI.Admin.UserPagingNum, page, 5)
+	pager.AddParam(ctx, "type", "ReportType")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplTwoFactorReport)
+}
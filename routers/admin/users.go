@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -130,6 +131,7 @@ func NewUserPost(ctx *context.Context, form auth.AdminCreateUserForm) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	ctx.Audit(models.AuditAdminUserCreate, u, audit.UserDiff(nil, u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -198,6 +200,7 @@ func EditUserPost(ctx *context.Context, form auth.AdminEditUserForm) {
 		ctx.HTML(200, tplUserEdit)
 		return
 	}
+	before := *u
 
 	fields := strings.Split(form.LoginType, "-")
 	if len(fields) == 2 {
@@ -252,6 +255,9 @@ func EditUserPost(ctx *context.Context, form auth.AdminEditUserForm) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	if diff := audit.UserDiff(&before, u); len(diff) > 0 {
+		ctx.Audit(models.AuditAdminUserUpdate, u, diff)
+	}
 
 	ctx.Flash.Success(ctx.Tr("admin.users.update_profile_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"))
@@ -272,6 +278,11 @@ func DeleteUser(ctx *context.Context) {
 			ctx.JSON(200, map[string]interface{}{
 				"redirect": setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"),
 			})
+		case models.IsErrUserOwnPackages(err):
+			ctx.Flash.Error(ctx.Tr("admin.users.still_own_packages"))
+			ctx.JSON(200, map[string]interface{}{
+				"redirect": setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"),
+			})
 		case models.IsErrUserHasOrgs(err):
 			ctx.Flash.Error(ctx.Tr("admin.users.still_has_org"))
 			ctx.JSON(200, map[string]interface{}{
@@ -283,6 +294,7 @@ func DeleteUser(ctx *context.Context) {
 		return
 	}
 	log.Trace("Account deleted by admin (%s): %s", ctx.User.Name, u.Name)
+	ctx.Audit(models.AuditAdminUserDelete, u, nil)
 
 	ctx.Flash.Success(ctx.Tr("admin.users.deletion_success"))
 	ctx.JSON(200, map[string]interface{}{
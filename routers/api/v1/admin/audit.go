@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListAuditEvents lists the events of the audit log
+func ListAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit admin adminListAuditEvents
+	// ---
+	// summary: List the events of the audit log, the most recent first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: action
+	//   in: query
+	//   description: only show events of this action, e.g. user.login
+	//   type: string
+	// - name: actor
+	//   in: query
+	//   description: only show events done by this user
+	//   type: string
+	// - name: target_type
+	//   in: query
+	//   description: only show events on targets of this type, e.g. repo
+	//   type: string
+	// - name: target_id
+	//   in: query
+	//   description: only show events on the target with this id
+	//   type: integer
+	//   format: int64
+	// - name: since
+	//   in: query
+	//   description: Only show events recorded after the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: Only show events recorded before the given time. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AuditEventList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	before, since, err := utils.GetQueryBeforeSince(ctx)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return
+	}
+
+	opts := &models.FindAuditEventsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		Action:      models.AuditAction(ctx.Query("action")),
+		ActorName:   ctx.Query("actor"),
+		TargetType:  ctx.Query("target_type"),
+		TargetID:    ctx.QueryInt64("target_id"),
+		Since:       timeutil.TimeStamp(since),
+		Before:      timeutil.TimeStamp(before),
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	events, count, err := models.FindAuditEvents(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindAuditEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, len(events))
+	for i := range events {
+		apiEvents[i] = convert.ToAuditEvent(events[i])
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, &apiEvents)
+}
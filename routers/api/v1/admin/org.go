@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -74,6 +75,7 @@ func CreateOrg(ctx *context.APIContext, form api.CreateOrgOption) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditAdminOrgCreate, org, audit.Diff{"owner": u.Name})
 
 	ctx.JSON(http.StatusCreated, convert.ToOrganization(org))
 }
@@ -11,6 +11,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
@@ -99,6 +100,7 @@ func CreateUser(ctx *context.APIContext, form api.CreateUserOption) {
 		return
 	}
 	log.Trace("Account created by admin (%s): %s", ctx.User.Name, u.Name)
+	ctx.Audit(models.AuditAdminUserCreate, u, audit.UserDiff(nil, u))
 
 	// Send email notification.
 	if form.SendNotify {
@@ -138,6 +140,7 @@ func EditUser(ctx *context.APIContext, form api.EditUserOption) {
 	if ctx.Written() {
 		return
 	}
+	before := *u
 
 	parseLoginSource(ctx, u, form.SourceID, form.LoginName)
 	if ctx.Written() {
@@ -198,6 +201,9 @@ func EditUser(ctx *context.APIContext, form api.EditUserOption) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	if diff := audit.UserDiff(&before, u); len(diff) > 0 {
+		ctx.Audit(models.AuditAdminUserUpdate, u, diff)
+	}
 
 	ctx.JSON(http.StatusOK, convert.ToUser(u, ctx.IsSigned, ctx.User.IsAdmin))
 }
@@ -235,6 +241,7 @@ func DeleteUser(ctx *context.APIContext) {
 
 	if err := models.DeleteUser(u); err != nil {
 		if models.IsErrUserOwnRepos(err) ||
+			models.IsErrUserOwnPackages(err) ||
 			models.IsErrUserHasOrgs(err) {
 			ctx.Error(http.StatusUnprocessableEntity, "", err)
 		} else {
@@ -243,6 +250,7 @@ func DeleteUser(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Account deleted by admin(%s): %s", ctx.User.Name, u.Name)
+	ctx.Audit(models.AuditAdminUserDelete, u, nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -313,7 +321,8 @@ func DeleteUserPublicKey(ctx *context.APIContext) {
 		return
 	}
 
-	if err := models.DeletePublicKey(u, ctx.ParamsInt64(":id")); err != nil {
+	id := ctx.ParamsInt64(":id")
+	if err := models.DeletePublicKey(u, id); err != nil {
 		if models.IsErrKeyNotExist(err) {
 			ctx.NotFound()
 		} else if models.IsErrKeyAccessDenied(err) {
@@ -324,6 +333,7 @@ func DeleteUserPublicKey(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Key deleted by admin(%s): %s", ctx.User.Name, u.Name)
+	ctx.Audit(models.AuditUserKeyDelete, audit.Target{Type: "public_key", ID: id}, audit.Diff{"owner": u.Name})
 
 	ctx.Status(http.StatusNoContent)
 }
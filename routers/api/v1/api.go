@@ -72,6 +72,7 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
+	"code.gitea.io/gitea/routers/api/v1/packages"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
 	"code.gitea.io/gitea/routers/api/v1/user"
@@ -164,6 +165,10 @@ func repoAssignment() macaron.Handler {
 		}
 
 		if !ctx.Repo.HasAccess() {
+			if ctx.Repo.Permission.TwoFactorRequired {
+				ctx.Error(http.StatusForbidden, "", models.ErrTwoFactorRequired{OrgName: owner.Name})
+				return
+			}
 			ctx.NotFound()
 			return
 		}
@@ -519,6 +524,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				Get(notify.ListNotifications).
 				Put(notify.ReadNotifications)
 			m.Get("/new", notify.NewAvailable)
+			m.Patch("/threads", bind(api.EditNotificationThreadsOption{}), notify.EditThreads)
 			m.Combo("/threads/:id").
 				Get(notify.GetThread).
 				Patch(notify.ReadThread)
@@ -611,6 +617,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 		m.Group("/repos", func() {
 			m.Get("/search", repo.Search)
+			m.Get("/search/code", repo.SearchCode)
+			m.Get("/search/commits", repo.SearchCommits)
 
 			m.Get("/issues/search", repo.SearchIssues)
 
@@ -681,6 +689,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Group("/issues", func() {
 					m.Combo("").Get(repo.ListIssues).
 						Post(reqToken(), mustNotBeArchived, bind(api.CreateIssueOption{}), repo.CreateIssue)
+					m.Post("/bulk", reqToken(), mustNotBeArchived, bind(api.BulkEditIssuesOption{}), repo.BulkEditIssues)
+					m.Get("/export", reqToken(), reqAdmin(), repo.ExportIssues)
+					m.Post("/import", reqToken(), reqAdmin(), mustNotBeArchived, bind(api.ImportIssuesOption{}), repo.ImportIssues)
 					m.Group("/comments", func() {
 						m.Get("", repo.ListRepoIssueComments)
 						m.Group("/:id", func() {
@@ -718,6 +729,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 							m.Delete("/:id", repo.DeleteTime)
 						}, reqToken())
 						m.Combo("/deadline").Post(reqToken(), bind(api.EditDeadlineOption{}), repo.UpdateIssueDeadline)
+						m.Post("/transfer", reqToken(), mustNotBeArchived, bind(api.TransferIssueOption{}), repo.TransferIssue)
 						m.Group("/stopwatch", func() {
 							m.Post("/start", reqToken(), repo.StartIssueStopwatch)
 							m.Post("/stop", reqToken(), repo.StopIssueStopwatch)
@@ -783,6 +795,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
 							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+						m.Combo("/requested_reviewers").Get(repo.GetPullReviewRequests).
+							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(api.PullReviewRequestOptions{}), repo.CreatePullReviewRequests).
+							Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(api.PullReviewRequestOptions{}), repo.DeletePullReviewRequests)
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
@@ -797,6 +812,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 						m.Get("/statuses", repo.GetCommitStatusesByRef)
 					})
 				}, reqRepoReader(models.UnitTypeCode))
+				m.Get("/search/commits", reqRepoReader(models.UnitTypeCode), repo.SearchRepoCommits)
 				m.Group("/git", func() {
 					m.Group("/commits", func() {
 						m.Get("/:sha", repo.GetSingleCommit)
@@ -839,6 +855,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				Delete(reqToken(), reqOrgOwnership(), org.Delete)
 			m.Combo("/repos").Get(user.ListOrgRepos).
 				Post(reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+			m.Get("/search/code", repo.SearchOrgCode)
 			m.Group("/members", func() {
 				m.Get("", org.ListMembers)
 				m.Combo("/:username").Get(org.IsMember).
@@ -882,11 +899,19 @@ func RegisterRoutes(m *macaron.Macaron) {
 			})
 		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
 
+		m.Group("/packages/:username", func() {
+			m.Get("", packages.ListPackages)
+			m.Combo("/:type/*").Get(packages.GetPackage).
+				Patch(reqToken(), packages.ReqPackageWriter, bind(api.EditPackageOption{}), packages.EditPackage).
+				Delete(reqToken(), packages.ReqPackageWriter, packages.DeletePackage)
+		}, packages.OwnerAssignment)
+
 		m.Any("/*", func(ctx *context.APIContext) {
 			ctx.NotFound()
 		})
 
 		m.Group("/admin", func() {
+			m.Get("/audit", admin.ListAuditEvents)
 			m.Get("/orgs", admin.GetAllOrgs)
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
@@ -5,7 +5,9 @@
 package notify
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
@@ -31,3 +33,31 @@ func NewAvailable(ctx *context.APIContext) {
 		ctx.Status(http.StatusNoContent)
 	}
 }
+
+// setNotificationFilters sets the reasons and the subject types the
+// notifications are filtered by from the query, and responds with an error if
+// one of them is unknown
+func setNotificationFilters(ctx *context.APIContext, opts *models.FindNotificationOptions) bool {
+	for _, name := range ctx.QueryStrings("reason") {
+		reason, ok := models.NotificationReasonFromName(strings.ToLower(name))
+		if !ok {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown notification reason: %s", name))
+			return false
+		}
+		opts.Reasons = append(opts.Reasons, reason)
+	}
+	for _, typ := range ctx.QueryStrings("subject_type") {
+		switch strings.ToLower(typ) {
+		case "issue":
+			opts.Sources = append(opts.Sources, models.NotificationSourceIssue)
+		case "pull":
+			opts.Sources = append(opts.Sources, models.NotificationSourcePullRequest)
+		case "commit":
+			opts.Sources = append(opts.Sources, models.NotificationSourceCommit)
+		default:
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown notification subject type: %s", typ))
+			return false
+		}
+	}
+	return true
+}
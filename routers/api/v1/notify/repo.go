@@ -51,6 +51,22 @@ func ListRepoNotifications(ctx *context.APIContext) {
 	//   type: string
 	//   format: date-time
 	//   required: false
+	// - name: reason
+	//   in: query
+	//   description: "Only show notifications of the given reasons: subscribed, author, assign, team_mention, mention, review_requested or reminder"
+	//   type: array
+	//   collectionFormat: multi
+	//   items:
+	//     type: string
+	//   required: false
+	// - name: subject_type
+	//   in: query
+	//   description: "Only show notifications of the given subject types: issue, pull or commit"
+	//   type: array
+	//   collectionFormat: multi
+	//   items:
+	//     type: string
+	//   required: false
 	// - name: page
 	//   in: query
 	//   description: page number of results to return (1-based)
@@ -62,6 +78,8 @@ func ListRepoNotifications(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/NotificationThreadList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
 
 	before, since, err := utils.GetQueryBeforeSince(ctx)
 	if err != nil {
@@ -79,6 +97,9 @@ func ListRepoNotifications(ctx *context.APIContext) {
 	if qAll != "true" {
 		opts.Status = models.NotificationStatusUnread
 	}
+	if !setNotificationFilters(ctx, &opts) {
+		return
+	}
 	nl, err := models.GetNotifications(opts)
 	if err != nil {
 		ctx.InternalServerError(err)
@@ -10,6 +10,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
 )
 
 // GetThread get notification by ID
@@ -83,6 +84,44 @@ func ReadThread(ctx *context.APIContext) {
 	ctx.Status(http.StatusResetContent)
 }
 
+// EditThreads changes several notification threads at once
+func EditThreads(ctx *context.APIContext, form api.EditNotificationThreadsOption) {
+	// swagger:operation PATCH /notifications/threads notification notifyEditThreads
+	// ---
+	// summary: Mark notification threads as read, unread, pinned or unpinned, or unsubscribe from them
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditNotificationThreadsOption"
+	// responses:
+	//   "205":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	var err error
+	switch form.Action {
+	case "read", "unpin":
+		err = models.SetNotificationsStatus(ctx.User, form.IDs, models.NotificationStatusRead)
+	case "unread":
+		err = models.SetNotificationsStatus(ctx.User, form.IDs, models.NotificationStatusUnread)
+	case "pin":
+		err = models.SetNotificationsStatus(ctx.User, form.IDs, models.NotificationStatusPinned)
+	case "unsubscribe":
+		err = models.UnsubscribeNotifications(ctx.User, form.IDs)
+	}
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusResetContent)
+}
+
 func getThread(ctx *context.APIContext) *models.Notification {
 	n, err := models.GetNotificationByID(ctx.ParamsInt64(":id"))
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -182,5 +183,6 @@ func DeleteHook(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditWebhookDelete, audit.Target{Type: "webhook", ID: hookID}, audit.Diff{"org_id": org.ID})
 	ctx.Status(http.StatusNoContent)
 }
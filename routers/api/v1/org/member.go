@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
@@ -297,6 +298,8 @@ func DeleteMember(ctx *context.APIContext) {
 	}
 	if err := ctx.Org.Organization.RemoveMember(member.ID); err != nil {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
+		return
 	}
+	ctx.Audit(models.AuditOrgMemberRemove, ctx.Org.Organization, audit.Diff{"member_id": member.ID})
 	ctx.Status(http.StatusNoContent)
 }
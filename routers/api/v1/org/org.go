@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -237,8 +238,11 @@ func Edit(ctx *context.APIContext, form api.EditOrgOption) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/Organization"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
 
 	org := ctx.Org.Organization
+	before := *org
 	org.FullName = form.FullName
 	org.Description = form.Description
 	org.Website = form.Website
@@ -246,10 +250,26 @@ func Edit(ctx *context.APIContext, form api.EditOrgOption) {
 	if form.Visibility != "" {
 		org.Visibility = api.VisibilityModes[form.Visibility]
 	}
-	if err := models.UpdateUserCols(org, "full_name", "description", "website", "location", "visibility"); err != nil {
+	if form.RequireTwoFactor != nil {
+		if *form.RequireTwoFactor && !org.RequireTwoFactor {
+			hasTwoFactor, err := models.HasTwoFactorByUID(ctx.User.ID)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "HasTwoFactorByUID", err)
+				return
+			} else if !hasTwoFactor {
+				ctx.Error(http.StatusUnprocessableEntity, "", "you must enroll two-factor authentication before requiring it for the organization")
+				return
+			}
+		}
+		org.RequireTwoFactor = *form.RequireTwoFactor
+	}
+	if err := models.UpdateUserCols(org, "full_name", "description", "website", "location", "visibility", "require_two_factor"); err != nil {
 		ctx.Error(http.StatusInternalServerError, "EditOrganization", err)
 		return
 	}
+	if diff := audit.OrgSettingsDiff(&before, org); len(diff) > 0 {
+		ctx.Audit(models.AuditOrgSettings, org, diff)
+	}
 
 	ctx.JSON(http.StatusOK, convert.ToOrganization(org))
 }
@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
@@ -162,6 +163,7 @@ func CreateTeam(ctx *context.APIContext, form api.CreateTeamOption) {
 		CanCreateOrgRepo:        form.CanCreateOrgRepo,
 		Authorize:               models.ParseAccessMode(form.Permission),
 	}
+	team.ReviewAssignment, _ = models.TeamReviewAssignmentFromName(form.ReviewAssignment)
 
 	unitTypes := models.FindUnitTypes(form.Units...)
 
@@ -184,6 +186,7 @@ func CreateTeam(ctx *context.APIContext, form api.CreateTeamOption) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditTeamCreate, team, audit.TeamDiff(nil, team))
 
 	ctx.JSON(http.StatusCreated, convert.ToTeam(team))
 }
@@ -216,6 +219,7 @@ func EditTeam(ctx *context.APIContext, form api.EditTeamOption) {
 		ctx.InternalServerError(err)
 		return
 	}
+	before := *team
 
 	if form.CanCreateOrgRepo != nil {
 		team.CanCreateOrgRepo = *form.CanCreateOrgRepo
@@ -229,6 +233,10 @@ func EditTeam(ctx *context.APIContext, form api.EditTeamOption) {
 		team.Description = *form.Description
 	}
 
+	if len(form.ReviewAssignment) > 0 {
+		team.ReviewAssignment, _ = models.TeamReviewAssignmentFromName(form.ReviewAssignment)
+	}
+
 	isAuthChanged := false
 	isIncludeAllChanged := false
 	if !team.IsOwnerTeam() && len(form.Permission) != 0 {
@@ -264,6 +272,7 @@ func EditTeam(ctx *context.APIContext, form api.EditTeamOption) {
 		ctx.Error(http.StatusInternalServerError, "EditTeam", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamUpdate, team, audit.TeamDiff(&before, team))
 	ctx.JSON(http.StatusOK, convert.ToTeam(team))
 }
 
@@ -287,6 +296,7 @@ func DeleteTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteTeam", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamDelete, ctx.Org.Team, nil)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -412,6 +422,7 @@ func AddTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddMember", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamMemberAdd, ctx.Org.Team, audit.Diff{"member_id": u.ID})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -449,6 +460,7 @@ func RemoveTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamMemberRemove, ctx.Org.Team, audit.Diff{"member_id": u.ID})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -555,6 +567,7 @@ func AddTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddRepository", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamRepoAdd, ctx.Org.Team, audit.Diff{"repo": repo.FullName()})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -605,6 +618,7 @@ func RemoveTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveRepository", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamRepoRemove, ctx.Org.Team, audit.Diff{"repo": repo.FullName()})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -0,0 +1,390 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// OwnerAssignment loads the owner of the packages and checks that the client can see them
+func OwnerAssignment(ctx *context.APIContext) {
+	if !setting.Packages.Enabled {
+		ctx.NotFound()
+		return
+	}
+
+	owner, err := models.GetUserByName(ctx.Params(":username"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+		}
+		return
+	}
+	if !packages.CanRead(owner, ctx.User) {
+		ctx.NotFound()
+		return
+	}
+	ctx.Data["PackageOwner"] = owner
+}
+
+// ReqPackageWriter makes sure the signed in user may change the packages of the owner
+func ReqPackageWriter(ctx *context.APIContext) {
+	owner := packageOwner(ctx)
+	allowed, err := packages.CanWrite(owner, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CanWrite", err)
+	} else if !allowed {
+		ctx.Error(http.StatusForbidden, "", "no permission to change packages of "+owner.Name)
+	}
+}
+
+func packageOwner(ctx *context.APIContext) *models.User {
+	return ctx.Data["PackageOwner"].(*models.User)
+}
+
+// toAPIPackage converts the package version with its files, the linked repository is
+// only included if the client can read it
+func toAPIPackage(ctx *context.APIContext, pv *models.PackageVersion) (*api.Package, error) {
+	if err := pv.LoadAttributes(); err != nil {
+		return nil, fmt.Errorf("LoadAttributes: %v", err)
+	}
+	files, err := models.GetPackageFiles(pv.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetPackageFiles: %v", err)
+	}
+	return convertPackage(ctx, pv, files, nil)
+}
+
+// convertPackage converts the package version with its loaded attributes and files,
+// the access modes to the linked repositories are cached in modes if it is not nil
+func convertPackage(ctx *context.APIContext, pv *models.PackageVersion, files []*models.PackageFile, modes map[int64]models.AccessMode) (*api.Package, error) {
+
+	owner := pv.Package.Owner
+	authed := ctx.User != nil && (ctx.User.IsAdmin || ctx.User.ID == owner.ID)
+	p := &api.Package{
+		ID:            pv.ID,
+		Owner:         convert.ToUser(owner, ctx.IsSigned, authed),
+		Type:          pv.Package.Type.Name(),
+		Name:          pv.Package.Name,
+		Version:       pv.Version,
+		Creator:       convert.ToUser(pv.Creator, ctx.IsSigned, ctx.User != nil && (ctx.User.IsAdmin || ctx.User.ID == pv.CreatorID)),
+		DownloadCount: pv.DownloadCount,
+		Files:         make([]*api.PackageFile, 0, len(files)),
+		Created:       pv.CreatedUnix.AsTime(),
+	}
+	for _, pf := range files {
+		p.Files = append(p.Files, convert.ToPackageFile(pf))
+	}
+	if len(pv.Metadata) > 0 {
+		if err := json.Unmarshal([]byte(pv.Metadata), &p.Metadata); err != nil {
+			return nil, fmt.Errorf("Unmarshal: %v", err)
+		}
+	}
+
+	if pv.Repo != nil {
+		mode, has := modes[pv.RepoID]
+		if !has {
+			var err error
+			if mode, err = models.AccessLevel(ctx.User, pv.Repo); err != nil {
+				return nil, fmt.Errorf("AccessLevel: %v", err)
+			}
+			if modes != nil {
+				modes[pv.RepoID] = mode
+			}
+		}
+		if mode >= models.AccessModeRead || (ctx.User != nil && ctx.User.IsAdmin) {
+			p.Repository = pv.Repo.APIFormat(mode)
+		}
+	}
+	return p, nil
+}
+
+// ListPackages lists the package versions of an owner
+func ListPackages(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner} package listPackages
+	// ---
+	// summary: List the package versions of a user or an organization, the most recent first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the packages
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: query
+	//   description: only show packages of this type
+	//   type: string
+	//   enum: [generic, npm, maven, pypi]
+	// - name: q
+	//   in: query
+	//   description: only show packages whose name contains this keyword
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts := &models.FindPackageVersionsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		OwnerID:     packageOwner(ctx).ID,
+		Query:       ctx.Query("q"),
+	}
+	if t := ctx.Query("type"); len(t) > 0 {
+		if opts.Type = models.ParsePackageType(t); opts.Type == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "unknown package type "+t)
+			return
+		}
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	versions, count, err := models.FindPackageVersions(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindPackageVersions", err)
+		return
+	}
+
+	files, err := models.PackageVersionList(versions).LoadFiles()
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadFiles", err)
+		return
+	}
+	modes := make(map[int64]models.AccessMode)
+	apiPackages := make([]*api.Package, 0, len(versions))
+	for _, pv := range versions {
+		p, err := convertPackage(ctx, pv, files[pv.ID], modes)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "convertPackage", err)
+			return
+		}
+		apiPackages = append(apiPackages, p)
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, &apiPackages)
+}
+
+// loadPackageVersion loads the package version addressed by the path, the name of the
+// package may contain slashes, e.g. scoped npm packages
+func loadPackageVersion(ctx *context.APIContext) *models.PackageVersion {
+	t := models.ParsePackageType(ctx.Params(":type"))
+	path := ctx.Params("*")
+	pos := strings.LastIndex(path, "/")
+	if t == 0 || pos <= 0 {
+		ctx.NotFound()
+		return nil
+	}
+
+	pv, err := models.GetPackageVersionByName(packageOwner(ctx).ID, t, path[:pos], path[pos+1:])
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageVersionByName", err)
+		}
+		return nil
+	}
+	pv.Package.Owner = packageOwner(ctx)
+	return pv
+}
+
+// GetPackage returns a package version with its files
+func GetPackage(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version} package getPackage
+	// ---
+	// summary: Get a package version with its files
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   enum: [generic, npm, maven, pypi]
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Package"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := loadPackageVersion(ctx)
+	if pv == nil {
+		return
+	}
+	p, err := toAPIPackage(ctx, pv)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "toAPIPackage", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, p)
+}
+
+// EditPackage links a package version to a repository of the owner
+func EditPackage(ctx *context.APIContext, form api.EditPackageOption) {
+	// swagger:operation PATCH /packages/{owner}/{type}/{name}/{version} package editPackage
+	// ---
+	// summary: Edit a package version
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   enum: [generic, npm, maven, pypi]
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditPackageOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Package"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	pv := loadPackageVersion(ctx)
+	if pv == nil {
+		return
+	}
+
+	if form.Repository != nil {
+		var repoID int64
+		if len(*form.Repository) > 0 {
+			repo, err := models.GetRepositoryByName(pv.Package.OwnerID, *form.Repository)
+			if err != nil {
+				if models.IsErrRepoNotExist(err) {
+					ctx.Error(http.StatusUnprocessableEntity, "", "repository does not exist")
+				} else {
+					ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+				}
+				return
+			}
+			repoID = repo.ID
+		}
+		if err := models.SetPackageVersionRepo(pv, repoID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "SetPackageVersionRepo", err)
+			return
+		}
+	}
+
+	p, err := toAPIPackage(ctx, pv)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "toAPIPackage", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, p)
+}
+
+// DeletePackage deletes a package version with its files
+func DeletePackage(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version} package deletePackage
+	// ---
+	// summary: Delete a package version with its files
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   enum: [generic, npm, maven, pypi]
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := loadPackageVersion(ctx)
+	if pv == nil {
+		return
+	}
+	if err := packages.DeleteVersion(pv); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteVersion", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -343,6 +344,7 @@ func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtec
 		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoBranchProtection, ctx.Repo.Repository, audit.ProtectedBranchDiff(nil, protectBranch))
 
 	// Reload from db to get all whitelists
 	bp, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, form.BranchName)
@@ -407,6 +409,7 @@ func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtection
 		ctx.NotFound()
 		return
 	}
+	before := *protectBranch
 
 	if form.EnablePush != nil {
 		if !*form.EnablePush {
@@ -558,6 +561,7 @@ func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtection
 		ctx.Error(http.StatusInternalServerError, "UpdateProtectBranch", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoBranchProtection, ctx.Repo.Repository, audit.ProtectedBranchDiff(&before, protectBranch))
 
 	// Reload from db to ensure get all whitelists
 	bp, err := models.GetProtectedBranchBy(repo.ID, bpName)
@@ -618,6 +622,7 @@ func DeleteBranchProtection(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteProtectedBranch", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoBranchProtectionDrop, ctx.Repo.Repository, audit.Diff{"branch": bp.BranchName})
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -0,0 +1,191 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// SearchCode searches the code of the repositories
+func SearchCode(ctx *context.APIContext) {
+	// swagger:operation GET /repos/search/code repository repoSearchCode
+	// ---
+	// summary: Search the code of the repositories the user can read
+	// description: The query may contain the filters `lang:`, `path:`, `repo:` (as owner/name)
+	//              and `sym:` to find definitions, "exact phrases" and a /regular expression/.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: search query
+	//   type: string
+	//   required: true
+	// - name: language
+	//   in: query
+	//   description: language of the files, replaces the lang filters of the query
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CodeSearchResults"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	searchCode(ctx, 0)
+}
+
+// SearchOrgCode searches the code of the repositories of an organization
+func SearchOrgCode(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/search/code organization orgSearchCode
+	// ---
+	// summary: Search the code of the repositories of an organization the user can read
+	// description: The query may contain the filters `lang:`, `path:`, `repo:` (as owner/name)
+	//              and `sym:` to find definitions, "exact phrases" and a /regular expression/.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: search query
+	//   type: string
+	//   required: true
+	// - name: language
+	//   in: query
+	//   description: language of the files, replaces the lang filters of the query
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CodeSearchResults"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if !models.HasOrgVisible(ctx.Org.Organization, ctx.User) {
+		ctx.NotFound("HasOrgVisible", nil)
+		return
+	}
+	searchCode(ctx, ctx.Org.Organization.ID)
+}
+
+// searchCode searches the code of the repositories the user can read, only
+// the ones of ownerID if it isn't 0
+func searchCode(ctx *context.APIContext, ownerID int64) {
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.NotFound()
+		return
+	}
+
+	listOptions := utils.GetListOptions(ctx)
+	if listOptions.Page <= 0 {
+		listOptions.Page = 1
+	}
+
+	repoIDs, err := models.FindUserCodeAccessibleRepoIDs(ctx.User, ownerID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindUserCodeAccessibleRepoIDs", err)
+		return
+	}
+	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch(repoIDs, ctx.Query("language"),
+		strings.TrimSpace(ctx.Query("q")), listOptions.Page, listOptions.PageSize)
+	if err != nil {
+		if code_indexer.IsErrInvalidQuery(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "PerformSearch", err)
+		return
+	}
+
+	repos := make(map[int64]*api.Repository)
+	results := make([]*api.CodeSearchResult, 0, len(searchResults))
+	for _, result := range searchResults {
+		apiRepo, ok := repos[result.RepoID]
+		if !ok {
+			repo, err := models.GetRepositoryByID(result.RepoID)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "GetRepositoryByID", err)
+				return
+			}
+			accessMode, err := models.AccessLevel(ctx.User, repo)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+				return
+			}
+			apiRepo = repo.APIFormat(accessMode)
+			repos[result.RepoID] = apiRepo
+		}
+
+		lines := make([]*api.CodeSearchLine, len(result.Lines))
+		for i, line := range result.Lines {
+			lines[i] = &api.CodeSearchLine{
+				Number:  result.LineNumbers[i],
+				Content: line,
+			}
+		}
+		htmlURL := fmt.Sprintf("%s/src/branch/%s/%s", apiRepo.HTMLURL,
+			util.PathEscapeSegments(apiRepo.DefaultBranch), util.PathEscapeSegments(result.Filename))
+		if len(result.LineNumbers) > 0 {
+			htmlURL += fmt.Sprintf("#L%d", result.LineNumbers[0])
+		}
+		results = append(results, &api.CodeSearchResult{
+			Repository: apiRepo,
+			Filename:   result.Filename,
+			Language:   result.Language,
+			HTMLURL:    htmlURL,
+			Lines:      lines,
+		})
+	}
+
+	languages := make([]*api.CodeSearchLanguage, len(searchResultLanguages))
+	for i, language := range searchResultLanguages {
+		languages[i] = &api.CodeSearchLanguage{
+			Language: language.Language,
+			Color:    language.Color,
+			Count:    language.Count,
+		}
+	}
+
+	ctx.SetLinkHeader(total, listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(http.StatusOK, api.CodeSearchResults{
+		OK:        true,
+		Data:      results,
+		Languages: languages,
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -162,12 +163,15 @@ func AddCollaborator(ctx *context.APIContext, form api.AddCollaboratorOption) {
 		ctx.Error(http.StatusInternalServerError, "AddCollaborator", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoCollaboratorAdd, ctx.Repo.Repository, audit.Diff{"collaborator": collaborator.Name})
 
 	if form.Permission != nil {
-		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, models.ParseAccessMode(*form.Permission)); err != nil {
+		mode := models.ParseAccessMode(*form.Permission)
+		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, mode); err != nil {
 			ctx.Error(http.StatusInternalServerError, "ChangeCollaborationAccessMode", err)
 			return
 		}
+		ctx.Audit(models.AuditRepoCollaboratorMode, ctx.Repo.Repository, audit.Diff{"collaborator": collaborator.Name, "mode": mode.String()})
 	}
 
 	ctx.Status(http.StatusNoContent)
@@ -216,5 +220,6 @@ func DeleteCollaborator(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteCollaboration", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoCollaboratorRemove, ctx.Repo.Repository, audit.Diff{"collaborator": collaborator.Name})
 	ctx.Status(http.StatusNoContent)
 }
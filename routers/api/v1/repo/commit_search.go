@@ -0,0 +1,175 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// SearchCommits searches the commits of the repositories
+func SearchCommits(ctx *context.APIContext) {
+	// swagger:operation GET /repos/search/commits repository repoSearchCommits
+	// ---
+	// summary: Search the commits of the repositories the user can read
+	// description: The query may contain the filters `author:`, `committer:`, `path:`, `repo:` (as owner/name),
+	//              `after:` and `before:` (as YYYY-MM-DD), issue references like `#123` and "exact phrases".
+	//              The most recently committed commits come first.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: search query
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CommitSearchResults"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.NotFound()
+		return
+	}
+
+	repoIDs, err := models.FindUserCodeAccessibleRepoIDs(ctx.User, 0)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindUserCodeAccessibleRepoIDs", err)
+		return
+	}
+	searchCommits(ctx, repoIDs)
+}
+
+// SearchRepoCommits searches the commits of a repository
+func SearchRepoCommits(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/search/commits repository repoSearchRepoCommits
+	// ---
+	// summary: Search the commits of all the branches of a repository
+	// description: The query may contain the filters `author:`, `committer:`, `path:`,
+	//              `after:` and `before:` (as YYYY-MM-DD), issue references like `#123` and "exact phrases".
+	//              The most recently committed commits come first.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: q
+	//   in: query
+	//   description: search query
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CommitSearchResults"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.NotFound()
+		return
+	}
+	searchCommits(ctx, []int64{ctx.Repo.Repository.ID})
+}
+
+// searchCommits searches the commits of the given repositories, nil meaning
+// all of them
+func searchCommits(ctx *context.APIContext, repoIDs []int64) {
+	listOptions := utils.GetListOptions(ctx)
+	if listOptions.Page <= 0 {
+		listOptions.Page = 1
+	}
+
+	total, searchResults, err := commit_indexer.PerformSearch(repoIDs, strings.TrimSpace(ctx.Query("q")),
+		listOptions.Page, listOptions.PageSize)
+	if err != nil {
+		if commit_indexer.IsErrInvalidQuery(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "PerformSearch", err)
+		return
+	}
+
+	repos := make(map[int64]*api.Repository)
+	results := make([]*api.CommitSearchResult, 0, len(searchResults))
+	for _, result := range searchResults {
+		apiRepo, ok := repos[result.RepoID]
+		if !ok {
+			repo, err := models.GetRepositoryByID(result.RepoID)
+			if err != nil {
+				// the repository may have been deleted since the commit was indexed
+				if models.IsErrRepoNotExist(err) {
+					continue
+				}
+				ctx.Error(http.StatusInternalServerError, "GetRepositoryByID", err)
+				return
+			}
+			accessMode, err := models.AccessLevel(ctx.User, repo)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "AccessLevel", err)
+				return
+			}
+			apiRepo = repo.APIFormat(accessMode)
+			repos[result.RepoID] = apiRepo
+		}
+
+		results = append(results, &api.CommitSearchResult{
+			Repository:  apiRepo,
+			SHA:         result.SHA,
+			HTMLURL:     fmt.Sprintf("%s/commit/%s", apiRepo.HTMLURL, result.SHA),
+			Message:     result.Message,
+			AuthorName:  result.AuthorName,
+			AuthorEmail: result.AuthorEmail,
+			Authored:    time.Unix(result.AuthorUnix, 0),
+			Committed:   time.Unix(result.CommitterUnix, 0),
+		})
+	}
+
+	ctx.SetLinkHeader(int(total), listOptions.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(http.StatusOK, api.CommitSearchResults{
+		OK:   true,
+		Data: results,
+	})
+}
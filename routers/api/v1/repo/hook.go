@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/git"
@@ -254,7 +255,8 @@ func DeleteHook(ctx *context.APIContext) {
 	//     "$ref": "#/responses/empty"
 	//   "404":
 	//     "$ref": "#/responses/notFound"
-	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id")); err != nil {
+	hookID := ctx.ParamsInt64(":id")
+	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, hookID); err != nil {
 		if models.IsErrWebhookNotExist(err) {
 			ctx.NotFound()
 		} else {
@@ -262,5 +264,6 @@ func DeleteHook(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditWebhookDelete, audit.Target{Type: "webhook", ID: hookID}, audit.Diff{"repo_id": ctx.Repo.Repository.ID})
 	ctx.Status(http.StatusNoContent)
 }
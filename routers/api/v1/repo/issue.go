@@ -41,7 +41,9 @@ func SearchIssues(ctx *context.APIContext) {
 	//   type: string
 	// - name: q
 	//   in: query
-	//   description: search string
+	//   description: search string, which may contain qualifiers like `is:closed`, `is:pr`, `author:name`, `assignee:@me`,
+	//                `mentions:name`, `label:bug`, `-label:wontfix`, `milestone:"v2"`, `repo:owner/*`, `sort:updated-desc`
+	//                or `created:>2020-01-01`
 	//   type: string
 	// - name: priority_repo_id
 	//   in: query
@@ -59,6 +61,8 @@ func SearchIssues(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/IssueList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
 
 	var isClosed util.OptionalBool
 	switch ctx.Query("state") {
@@ -119,13 +123,8 @@ func SearchIssues(ctx *context.APIContext) {
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
-	var issueIDs []int64
 	var labelIDs []int64
 	var err error
-	if len(keyword) > 0 && len(repoIDs) > 0 {
-		issueIDs, err = issue_indexer.SearchIssuesByKeyword(repoIDs, keyword)
-	}
-
 	labels := ctx.Query("labels")
 	if splitted := strings.Split(labels, ","); labels != "" && len(splitted) > 0 {
 		labelIDs, err = models.GetLabelIDsInReposByNames(repoIDs, splitted)
@@ -145,22 +144,33 @@ func SearchIssues(ctx *context.APIContext) {
 		isPull = util.OptionalBoolNone
 	}
 
-	// Only fetch the issues if we either don't have a keyword or the search returned issues
-	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(labelIDs) > 0 {
-		issues, err = models.Issues(&models.IssuesOptions{
+	// Only fetch the issues if the user can access some repositories,
+	// no repository would otherwise mean all of them.
+	if len(repoIDs) > 0 {
+		issuesOpts := &models.IssuesOptions{
 			ListOptions: models.ListOptions{
 				Page:     ctx.QueryInt("page"),
 				PageSize: setting.UI.IssuePagingNum,
 			},
 			RepoIDs:        repoIDs,
 			IsClosed:       isClosed,
-			IssueIDs:       issueIDs,
 			LabelIDs:       labelIDs,
 			SortType:       "priorityrepo",
 			PriorityRepoID: ctx.QueryInt64("priority_repo_id"),
 			IsPull:         isPull,
-		})
+		}
+		if len(keyword) > 0 {
+			if !applyIssueQuery(ctx, issuesOpts, keyword) {
+				return
+			}
+			count, err := models.CountIssues(issuesOpts)
+			if err != nil {
+				ctx.Error(http.StatusInternalServerError, "CountIssues", err)
+				return
+			}
+			issueCount = int(count)
+		}
+		issues, err = models.Issues(issuesOpts)
 	}
 
 	if err != nil {
@@ -205,7 +215,9 @@ func ListIssues(ctx *context.APIContext) {
 	//   type: string
 	// - name: q
 	//   in: query
-	//   description: search string
+	//   description: search string, which may contain qualifiers like `is:closed`, `is:pr`, `author:name`, `assignee:@me`,
+	//                `mentions:name`, `label:bug`, `-label:wontfix`, `milestone:"v2"`, `repo:owner/*`, `sort:updated-desc`
+	//                or `created:>2020-01-01`
 	//   type: string
 	// - name: type
 	//   in: query
@@ -222,7 +234,37 @@ func ListIssues(ctx *context.APIContext) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/IssueList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	listOptions := utils.GetListOptions(ctx)
+	if ctx.QueryInt("limit") == 0 {
+		listOptions.PageSize = setting.UI.IssuePagingNum
+	}
+
+	issuesOpts, ok := repoIssuesOptions(ctx)
+	if !ok {
+		return
+	}
+	issuesOpts.ListOptions = listOptions
+	issues, err := models.Issues(issuesOpts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Issues", err)
+		return
+	}
 
+	apiIssues := make([]*api.Issue, len(issues))
+	for i := range issues {
+		apiIssues[i] = issues[i].APIFormat()
+	}
+
+	ctx.SetLinkHeader(ctx.Repo.Repository.NumIssues, listOptions.PageSize)
+	ctx.JSON(http.StatusOK, &apiIssues)
+}
+
+// repoIssuesOptions returns the options to find the issues of the repository
+// filtered by the state, labels, type and q parameters
+func repoIssuesOptions(ctx *context.APIContext) (*models.IssuesOptions, bool) {
 	var isClosed util.OptionalBool
 	switch ctx.Query("state") {
 	case "closed":
@@ -233,32 +275,20 @@ func ListIssues(ctx *context.APIContext) {
 		isClosed = util.OptionalBoolFalse
 	}
 
-	var issues []*models.Issue
-
 	keyword := strings.Trim(ctx.Query("q"), " ")
 	if strings.IndexByte(keyword, 0) >= 0 {
 		keyword = ""
 	}
-	var issueIDs []int64
 	var labelIDs []int64
 	var err error
-	if len(keyword) > 0 {
-		issueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{ctx.Repo.Repository.ID}, keyword)
-	}
-
 	if splitted := strings.Split(ctx.Query("labels"), ","); len(splitted) > 0 {
 		labelIDs, err = models.GetLabelIDsInRepoByNames(ctx.Repo.Repository.ID, splitted)
 		if err != nil {
 			ctx.Error(http.StatusInternalServerError, "GetLabelIDsInRepoByNames", err)
-			return
+			return nil, false
 		}
 	}
 
-	listOptions := utils.GetListOptions(ctx)
-	if ctx.QueryInt("limit") == 0 {
-		listOptions.PageSize = setting.UI.IssuePagingNum
-	}
-
 	var isPull util.OptionalBool
 	switch ctx.Query("type") {
 	case "pulls":
@@ -269,31 +299,38 @@ func ListIssues(ctx *context.APIContext) {
 		isPull = util.OptionalBoolNone
 	}
 
-	// Only fetch the issues if we either don't have a keyword or the search returned issues
-	// This would otherwise return all issues if no issues were found by the search.
-	if len(keyword) == 0 || len(issueIDs) > 0 || len(labelIDs) > 0 {
-		issues, err = models.Issues(&models.IssuesOptions{
-			ListOptions: listOptions,
-			RepoIDs:     []int64{ctx.Repo.Repository.ID},
-			IsClosed:    isClosed,
-			IssueIDs:    issueIDs,
-			LabelIDs:    labelIDs,
-			IsPull:      isPull,
-		})
+	opts := &models.IssuesOptions{
+		RepoIDs:  []int64{ctx.Repo.Repository.ID},
+		IsClosed: isClosed,
+		LabelIDs: labelIDs,
+		IsPull:   isPull,
 	}
-
-	if err != nil {
-		ctx.Error(http.StatusInternalServerError, "Issues", err)
-		return
+	if len(keyword) > 0 && !applyIssueQuery(ctx, opts, keyword) {
+		return nil, false
 	}
+	return opts, true
+}
 
-	apiIssues := make([]*api.Issue, len(issues))
-	for i := range issues {
-		apiIssues[i] = issues[i].APIFormat()
+// applyIssueQuery adds the conditions of an issue search query to the
+// options, returning false if the response has been written
+func applyIssueQuery(ctx *context.APIContext, opts *models.IssuesOptions, keyword string) bool {
+	query, err := issue_indexer.ParseQuery(keyword)
+	if err == nil {
+		// the state given in the query takes precedence over the state parameter
+		if !query.IsClosed.IsNone() {
+			opts.IsClosed = util.OptionalBoolNone
+		}
+		err = query.Apply(opts, ctx.User)
 	}
-
-	ctx.SetLinkHeader(ctx.Repo.Repository.NumIssues, listOptions.PageSize)
-	ctx.JSON(http.StatusOK, &apiIssues)
+	if err != nil {
+		if issue_indexer.IsErrInvalidQuery(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return false
+		}
+		ctx.Error(http.StatusInternalServerError, "ApplyQuery", err)
+		return false
+	}
+	return true
 }
 
 // GetIssue get an issue of a repository
@@ -667,3 +704,211 @@ func UpdateIssueDeadline(ctx *context.APIContext, form api.EditDeadlineOption) {
 
 	ctx.JSON(http.StatusCreated, api.IssueDeadline{Deadline: &deadline})
 }
+
+// TransferIssue moves an issue to another repository
+func TransferIssue(ctx *context.APIContext, form api.TransferIssueOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/{index}/transfer issue issueTransferIssue
+	// ---
+	// summary: Transfer an issue to another repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the issue to transfer
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/TransferIssueOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Issue"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return
+	}
+
+	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Error(http.StatusForbidden, "", "Not repo writer")
+		return
+	}
+
+	newRepo, err := models.GetRepositoryByOwnerAndName(form.NewOwner, form.NewRepo)
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound("GetRepositoryByOwnerAndName", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByOwnerAndName", err)
+		}
+		return
+	}
+
+	if err = issue_service.TransferIssue(ctx.User, issue, newRepo); err != nil {
+		switch {
+		case models.IsErrUserDoesNotHaveAccessToRepo(err):
+			// Do not reveal the repositories the user cannot write issues to
+			ctx.NotFound("TransferIssue", err)
+		case models.IsErrIssueCannotBeTransferred(err):
+			ctx.Error(http.StatusUnprocessableEntity, "TransferIssue", err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "TransferIssue", err)
+		}
+		return
+	}
+
+	// Refetch from database to assign some automatic values
+	issue, err = models.GetIssueByID(issue.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, issue.APIFormat())
+}
+
+// BulkEditIssues applies the same changes to many issues and pull requests at once
+func BulkEditIssues(ctx *context.APIContext, form api.BulkEditIssuesOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/bulk issue issueBulkEditIssues
+	// ---
+	// summary: Edit many issues and pull requests at once. Either all the changes are applied or none.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/BulkEditIssuesOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "412":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	issues := make([]*models.Issue, 0, len(form.Indexes))
+	for _, index := range form.Indexes {
+		issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, index)
+		if err != nil {
+			if models.IsErrIssueNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+			}
+			return
+		}
+		if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+			ctx.Error(http.StatusForbidden, "", "Not repo writer")
+			return
+		}
+		issues = append(issues, issue)
+	}
+
+	opts := &models.BulkEditIssuesOptions{
+		AddLabelIDs:    form.AddLabels,
+		RemoveLabelIDs: form.RemoveLabels,
+		MilestoneID:    form.Milestone,
+		IsLocked:       form.Locked,
+	}
+	var err error
+	if opts.AddAssigneeIDs, err = models.GetUserIDsByNames(form.AddAssignees, false); err != nil {
+		if models.IsErrUserNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "GetUserIDsByNames", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetUserIDsByNames", err)
+		}
+		return
+	}
+	if opts.RemoveAssigneeIDs, err = models.GetUserIDsByNames(form.RemoveAssignees, false); err != nil {
+		if models.IsErrUserNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "GetUserIDsByNames", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetUserIDsByNames", err)
+		}
+		return
+	}
+	if form.State != nil {
+		if *form.State != string(api.StateOpen) && *form.State != string(api.StateClosed) {
+			ctx.Error(http.StatusUnprocessableEntity, "", "state must be open or closed")
+			return
+		}
+		isClosed := *form.State == string(api.StateClosed)
+		opts.IsClosed = &isClosed
+	}
+	if form.RemoveDeadline != nil && *form.RemoveDeadline {
+		var deadlineUnix timeutil.TimeStamp
+		opts.DeadlineUnix = &deadlineUnix
+	} else if form.Deadline != nil && !form.Deadline.IsZero() {
+		deadline := time.Date(form.Deadline.Year(), form.Deadline.Month(), form.Deadline.Day(),
+			23, 59, 59, 0, form.Deadline.Location())
+		deadlineUnix := timeutil.TimeStamp(deadline.Unix())
+		opts.DeadlineUnix = &deadlineUnix
+	}
+
+	edited, err := issue_service.BulkEdit(ctx.User, ctx.Repo.Repository, issues, opts)
+	if err != nil {
+		switch {
+		case models.IsErrDependenciesLeft(err):
+			ctx.Error(http.StatusPreconditionFailed, "DependenciesLeft", "cannot close an issue because it still has open dependencies")
+		case models.IsErrLabelNotExist(err), models.IsErrMilestoneNotExist(err), models.IsErrUserDoesNotHaveAccessToRepo(err):
+			ctx.Error(http.StatusUnprocessableEntity, "BulkEdit", err)
+		default:
+			ctx.Error(http.StatusInternalServerError, "BulkEdit", err)
+		}
+		return
+	}
+
+	apiIssues := make([]*api.Issue, 0, len(edited))
+	for _, edit := range edited {
+		// Refetch from database to assign some automatic values
+		issue, err := models.GetIssueByID(edit.ID)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+			return
+		}
+		apiIssues = append(apiIssues, issue.APIFormat())
+	}
+	ctx.JSON(http.StatusOK, &apiIssues)
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// ExportIssues exports the issues of a repository
+func ExportIssues(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/issues/export issue issueExportIssues
+	// ---
+	// summary: Export the issues of a repository as a CSV or JSON file
+	// produces:
+	// - text/csv
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: format
+	//   in: query
+	//   description: format of the file, csv by default
+	//   type: string
+	//   enum: [csv, json]
+	// - name: state
+	//   in: query
+	//   description: whether issue is open or closed
+	//   type: string
+	// - name: labels
+	//   in: query
+	//   description: comma separated list of labels. Fetch only issues that have any of this labels. Non existent labels are discarded
+	//   type: string
+	// - name: q
+	//   in: query
+	//   description: search string, which may contain qualifiers like `is:closed`, `label:bug` or `milestone:"v2"`
+	//   type: string
+	// - name: type
+	//   in: query
+	//   description: filter by type (issues / pulls) if set
+	//   type: string
+	// responses:
+	//   "200":
+	//     description: the exported issues
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	format := ctx.QueryTrim("format")
+	if format == "" {
+		format = issue_service.FormatCSV
+	} else if format != issue_service.FormatCSV && format != issue_service.FormatJSON {
+		ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown format: %s", format))
+		return
+	}
+
+	opts, ok := repoIssuesOptions(ctx)
+	if !ok {
+		return
+	}
+	issues, err := models.Issues(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Issues", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", issue_service.ContentType(format))
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-issues.%s"`, ctx.Repo.Repository.Name, format))
+	if err = issue_service.ExportIssues(ctx.Resp, format, issues); err != nil {
+		// the headers are already written
+		log.Error("ExportIssues: %v", err)
+	}
+}
+
+// ImportIssues creates issues from a CSV or JSON file
+func ImportIssues(ctx *context.APIContext, form api.ImportIssuesOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/issues/import issue issueImportIssues
+	// ---
+	// summary: Create issues from a CSV or JSON file, nothing is created if a row is invalid
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/ImportIssuesOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ImportedIssueRowList"
+	//   "201":
+	//     "$ref": "#/responses/ImportedIssueRowList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	table, err := issue_service.ParseImportFile(strings.NewReader(form.Content), form.Format)
+	if err != nil {
+		if issue_service.IsErrInvalidImport(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "ParseImportFile", err)
+		return
+	}
+
+	fields := issue_service.DefaultImportMapping(table.Columns)
+	if form.Mapping != nil {
+		for column := range form.Mapping {
+			found := false
+			for _, name := range table.Columns {
+				if name == column {
+					found = true
+					break
+				}
+			}
+			if !found {
+				ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("unknown column: %s", column))
+				return
+			}
+		}
+		for i, column := range table.Columns {
+			fields[i] = form.Mapping[column]
+		}
+	}
+
+	imported, err := issue_service.PrepareImport(ctx.User, ctx.Repo.Repository, table, fields)
+	if err != nil {
+		if issue_service.IsErrInvalidImport(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		ctx.Error(http.StatusInternalServerError, "PrepareImport", err)
+		return
+	}
+
+	rows := make([]*api.ImportedIssueRow, len(imported))
+	for i, row := range imported {
+		rows[i] = &api.ImportedIssueRow{
+			Row:    row.Row,
+			Title:  row.Issue.Title,
+			Errors: row.Errors,
+		}
+		if rows[i].Errors == nil {
+			rows[i].Errors = []string{}
+		}
+	}
+	if form.DryRun {
+		ctx.JSON(http.StatusOK, rows)
+		return
+	}
+
+	issues, err := issue_service.ImportIssues(ctx.User, ctx.Repo.Repository, imported)
+	if err != nil {
+		if issue_service.IsErrInvalidImport(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+			return
+		}
+		// the message tells the rows whose issues are created
+		ctx.Error(http.StatusInternalServerError, "ImportIssues", err)
+		return
+	}
+	for i, issue := range issues {
+		// reload the issue to get its assignees and final state
+		issue, err = models.GetIssueByID(issue.ID)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+			return
+		}
+		rows[i].Issue = issue.APIFormat()
+	}
+	ctx.JSON(http.StatusCreated, rows)
+}
@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/routers/api/v1/utils"
 )
 
@@ -57,6 +58,7 @@ func StartIssueStopwatch(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "CreateOrStopIssueStopwatch", err)
 		return
 	}
+	notification.NotifyIssueChangeStopwatch(ctx.User, issue)
 
 	ctx.Status(http.StatusCreated)
 }
@@ -106,6 +108,7 @@ func StopIssueStopwatch(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "CreateOrStopIssueStopwatch", err)
 		return
 	}
+	notification.NotifyIssueChangeStopwatch(ctx.User, issue)
 
 	ctx.Status(http.StatusCreated)
 }
@@ -155,6 +158,7 @@ func DeleteIssueStopwatch(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "CancelStopwatch", err)
 		return
 	}
+	notification.NotifyIssueChangeStopwatch(ctx.User, issue)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -10,6 +10,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
@@ -223,6 +224,7 @@ func CreateDeployKey(ctx *context.APIContext, form api.CreateKeyOption) {
 		HandleAddKeyError(ctx, err)
 		return
 	}
+	ctx.Audit(models.AuditRepoDeployKeyAdd, ctx.Repo.Repository, audit.Diff{"key": key.Name, "fingerprint": key.Fingerprint, "writable": !key.IsReadOnly()})
 
 	key.Content = content
 	apiLink := composeDeployKeysAPILink(ctx.Repo.Owner.Name + "/" + ctx.Repo.Repository.Name)
@@ -257,7 +259,8 @@ func DeleteDeploykey(ctx *context.APIContext) {
 	//   "403":
 	//     "$ref": "#/responses/forbidden"
 
-	if err := models.DeleteDeployKey(ctx.User, ctx.ParamsInt64(":id")); err != nil {
+	id := ctx.ParamsInt64(":id")
+	if err := models.DeleteDeployKey(ctx.User, id); err != nil {
 		if models.IsErrKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
 		} else {
@@ -265,6 +268,7 @@ func DeleteDeploykey(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditRepoDeployKeyDelete, ctx.Repo.Repository, audit.Diff{"key_id": id})
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -0,0 +1,242 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// GetPullReviewRequests lists the pending review requests of a pull request
+func GetPullReviewRequests(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoGetPullReviewRequests
+	// ---
+	// summary: List the users and teams requested to review a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewRequests"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	issue := getPullIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+	writePullReviewRequests(ctx, http.StatusOK, issue)
+}
+
+// CreatePullReviewRequests requests users and teams to review a pull request
+func CreatePullReviewRequests(ctx *context.APIContext, form api.PullReviewRequestOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoCreatePullReviewRequests
+	// ---
+	// summary: Request users and teams to review a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PullReviewRequests"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	updatePullReviewRequests(ctx, form, true)
+}
+
+// DeletePullReviewRequests withdraws the requests for users and teams to review a pull request
+func DeletePullReviewRequests(ctx *context.APIContext, form api.PullReviewRequestOptions) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/requested_reviewers repository repoDeletePullReviewRequests
+	// ---
+	// summary: Withdraw the requests for users and teams to review a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/PullReviewRequestOptions"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	updatePullReviewRequests(ctx, form, false)
+}
+
+func getPullIssue(ctx *context.APIContext) *models.Issue {
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByIndex", err)
+		}
+		return nil
+	}
+	if !issue.IsPull {
+		ctx.NotFound()
+		return nil
+	}
+	issue.Repo = ctx.Repo.Repository
+	return issue
+}
+
+func updatePullReviewRequests(ctx *context.APIContext, form api.PullReviewRequestOptions, isAdd bool) {
+	issue := getPullIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reviewers := make([]*models.User, 0, len(form.Reviewers))
+	for _, name := range form.Reviewers {
+		reviewer, err := models.GetUserByName(name)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("user %s doesn't exist", name))
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+
+	teams := make([]*models.Team, 0, len(form.TeamReviewers))
+	for _, name := range form.TeamReviewers {
+		if !ctx.Repo.Owner.IsOrganization() {
+			ctx.Error(http.StatusUnprocessableEntity, "", "only the repositories of organizations can be reviewed by teams")
+			return
+		}
+		team, err := models.GetTeam(ctx.Repo.Owner.ID, name)
+		if err != nil {
+			if models.IsErrTeamNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", fmt.Sprintf("team %s doesn't exist", name))
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetTeam", err)
+			}
+			return
+		}
+		teams = append(teams, team)
+	}
+
+	for _, reviewer := range reviewers {
+		if _, err := issue_service.ReviewRequest(issue, ctx.User, reviewer, isAdd); err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "ReviewRequest", err)
+			}
+			return
+		}
+	}
+	for _, team := range teams {
+		if _, err := issue_service.TeamReviewRequest(issue, ctx.User, team, isAdd); err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+			} else {
+				ctx.Error(http.StatusInternalServerError, "TeamReviewRequest", err)
+			}
+			return
+		}
+	}
+
+	if !isAdd {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	writePullReviewRequests(ctx, http.StatusCreated, issue)
+}
+
+func writePullReviewRequests(ctx *context.APIContext, status int, issue *models.Issue) {
+	requests, err := models.GetReviewRequests(issue.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetReviewRequests", err)
+		return
+	}
+
+	result := &api.PullReviewRequests{
+		Users: make([]*api.User, 0, len(requests)),
+		Teams: make([]*api.Team, 0, len(requests)),
+	}
+	for _, request := range requests {
+		if request.ReviewerTeam != nil {
+			result.Teams = append(result.Teams, convert.ToTeam(request.ReviewerTeam))
+		} else {
+			result.Users = append(result.Users, convert.ToUser(request.Reviewer, ctx.IsSigned, ctx.User != nil && ctx.User.IsAdmin))
+		}
+	}
+	ctx.JSON(status, result)
+}
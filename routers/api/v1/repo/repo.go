@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
@@ -546,6 +547,9 @@ func updateBasicProperties(ctx *context.APIContext, opts api.EditRepoOption) err
 		ctx.Error(http.StatusInternalServerError, "UpdateRepository", err)
 		return err
 	}
+	if visibilityChanged {
+		ctx.Audit(models.AuditRepoVisibility, repo, audit.Diff{}.Changed("private", !repo.IsPrivate, repo.IsPrivate))
+	}
 
 	log.Trace("Repository basic settings updated: %s/%s", owner.Name, repo.Name)
 	return nil
@@ -779,6 +783,7 @@ func Delete(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteRepository", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoDelete, repo, nil)
 
 	log.Trace("Repository deleted: %s/%s", owner.Name, repo.Name)
 	ctx.Status(http.StatusNoContent)
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/log"
@@ -84,10 +85,12 @@ func Transfer(ctx *context.APIContext, opts api.TransferRepoOption) {
 		}
 	}
 
+	oldOwnerName := ctx.Repo.Owner.Name
 	if err = repo_service.TransferOwnership(ctx.User, newOwner, ctx.Repo.Repository, teams); err != nil {
 		ctx.InternalServerError(err)
 		return
 	}
+	ctx.Audit(models.AuditRepoTransfer, ctx.Repo.Repository, audit.Diff{}.Changed("owner", oldOwnerName, newOwner.Name))
 
 	newRepo, err := models.GetRepositoryByName(newOwner.ID, ctx.Repo.Repository.Name)
 	if err != nil {
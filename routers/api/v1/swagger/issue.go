@@ -112,3 +112,10 @@ type swaggerReactionList struct {
 	// in:body
 	Body []api.Reaction `json:"body"`
 }
+
+// ImportedIssueRowList
+// swagger:response ImportedIssueRowList
+type swaggerImportedIssueRowList struct {
+	// in:body
+	Body []api.ImportedIssueRow `json:"body"`
+}
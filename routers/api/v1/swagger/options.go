@@ -37,6 +37,12 @@ type swaggerParameterBodies struct {
 	EditIssueOption api.EditIssueOption
 	// in:body
 	EditDeadlineOption api.EditDeadlineOption
+	// in:body
+	TransferIssueOption api.TransferIssueOption
+	// in:body
+	BulkEditIssuesOption api.BulkEditIssuesOption
+	// in:body
+	ImportIssuesOption api.ImportIssuesOption
 
 	// in:body
 	CreateIssueCommentOption api.CreateIssueCommentOption
@@ -134,4 +140,13 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	EditBranchProtectionOption api.EditBranchProtectionOption
+
+	// in:body
+	EditPackageOption api.EditPackageOption
+
+	// in:body
+	EditNotificationThreadsOption api.EditNotificationThreadsOption
+
+	// in:body
+	PullReviewRequestOptions api.PullReviewRequestOptions
 }
@@ -134,6 +134,13 @@ type swaggerResponsePullRequest struct {
 	Body api.PullRequest `json:"body"`
 }
 
+// PullReviewRequests
+// swagger:response PullReviewRequests
+type swaggerResponsePullReviewRequests struct {
+	// in:body
+	Body api.PullReviewRequests `json:"body"`
+}
+
 // PullRequestList
 // swagger:response PullRequestList
 type swaggerResponsePullRequestList struct {
@@ -169,6 +176,20 @@ type swaggerResponseSearchResults struct {
 	Body api.SearchResults `json:"body"`
 }
 
+// CodeSearchResults
+// swagger:response CodeSearchResults
+type swaggerResponseCodeSearchResults struct {
+	// in:body
+	Body api.CodeSearchResults `json:"body"`
+}
+
+// CommitSearchResults
+// swagger:response CommitSearchResults
+type swaggerResponseCommitSearchResults struct {
+	// in:body
+	Body api.CommitSearchResults `json:"body"`
+}
+
 // AttachmentList
 // swagger:response AttachmentList
 type swaggerResponseAttachmentList struct {
@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/utils"
@@ -92,6 +93,7 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
+	ctx.Audit(models.AuditUserAccessTokenCreate, t, nil)
 	ctx.JSON(http.StatusCreated, &api.AccessToken{
 		Name:           t.Name,
 		Token:          t.Token,
@@ -132,6 +134,7 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditUserAccessTokenDelete, audit.Target{Type: "access_token", ID: tokenID}, nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -123,6 +124,7 @@ func CreateUserGPGKey(ctx *context.APIContext, form api.CreateGPGKeyOption, uid
 		HandleAddGPGKeyError(ctx, err)
 		return
 	}
+	ctx.Audit(models.AuditUserGPGKeyAdd, key, nil)
 	ctx.JSON(http.StatusCreated, convert.ToGPGKey(key))
 }
 
@@ -170,7 +172,8 @@ func DeleteGPGKey(ctx *context.APIContext) {
 	//   "403":
 	//     "$ref": "#/responses/forbidden"
 
-	if err := models.DeleteGPGKey(ctx.User, ctx.ParamsInt64(":id")); err != nil {
+	id := ctx.ParamsInt64(":id")
+	if err := models.DeleteGPGKey(ctx.User, id); err != nil {
 		if models.IsErrGPGKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
 		} else {
@@ -178,6 +181,7 @@ func DeleteGPGKey(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditUserGPGKeyDelete, audit.Target{Type: "gpg_key", ID: id}, nil)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
@@ -214,6 +215,7 @@ func CreateUserPublicKey(ctx *context.APIContext, form api.CreateKeyOption, uid
 		repo.HandleAddKeyError(ctx, err)
 		return
 	}
+	ctx.Audit(models.AuditUserKeyAdd, key, nil)
 	apiLink := composePublicKeysAPILink()
 	apiKey := convert.ToPublicKey(apiLink, key)
 	if ctx.User.IsAdmin || ctx.User.ID == key.OwnerID {
@@ -267,7 +269,8 @@ func DeletePublicKey(ctx *context.APIContext) {
 	//   "404":
 	//     "$ref": "#/responses/notFound"
 
-	if err := models.DeletePublicKey(ctx.User, ctx.ParamsInt64(":id")); err != nil {
+	id := ctx.ParamsInt64(":id")
+	if err := models.DeletePublicKey(ctx.User, id); err != nil {
 		if models.IsErrKeyNotExist(err) {
 			ctx.NotFound()
 		} else if models.IsErrKeyAccessDenied(err) {
@@ -277,6 +280,7 @@ func DeletePublicKey(ctx *context.APIContext) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditUserKeyDelete, audit.Target{Type: "public_key", ID: id}, nil)
 
 	ctx.Status(http.StatusNoContent)
 }
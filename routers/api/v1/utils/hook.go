@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
@@ -150,6 +151,7 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 		ctx.Error(http.StatusInternalServerError, "CreateWebhook", err)
 		return nil, false
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 	return w, true
 }
 
@@ -253,5 +255,6 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 		ctx.Error(http.StatusInternalServerError, "UpdateWebhook", err)
 		return false
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 	return true
 }
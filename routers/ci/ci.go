@@ -0,0 +1,192 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package ci implements the protocol runners use to pick up CI jobs and report their
+// progress, it is served under /api/ci.
+package ci
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gitea.com/macaron/binding"
+	"gitea.com/macaron/macaron"
+)
+
+// RegisterRoutes registers the runner routes, they are served under /api/ci.
+func RegisterRoutes(m *macaron.Macaron) {
+	bind := binding.Bind
+
+	m.Post("/runners/register", bind(ci.RegisterRunnerOptions{}), RegisterRunner)
+	m.Group("", func() {
+		m.Post("/runners/fetch", FetchTask)
+		m.Group("/jobs/:id", func() {
+			m.Post("", bind(ci.UpdateStatusOptions{}), FinishJob)
+			m.Post("/logs", bind(ci.AppendLogOptions{}), AppendLog)
+			m.Post("/steps/:index", bind(ci.UpdateStatusOptions{}), UpdateStep)
+		}, assignJob)
+	}, authenticateRunner)
+}
+
+// Enabled makes sure CI is enabled
+func Enabled(ctx *context.Context) {
+	if !setting.CI.Enabled {
+		ctx.NotFound("", nil)
+	}
+}
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	message := obj
+	if status == http.StatusInternalServerError {
+		log.Error("%v", obj)
+		message = "internal server error"
+	} else if err, ok := obj.(error); ok {
+		message = err.Error()
+	}
+	ctx.JSON(status, map[string]interface{}{"message": message})
+}
+
+// authenticateRunner loads the runner authenticating with the runner token header
+func authenticateRunner(ctx *context.Context) {
+	runner, err := models.GetCIRunnerByToken(ctx.Req.Header.Get(ci.RunnerTokenHeader))
+	if err != nil {
+		if models.IsErrCIRunnerNotExist(err) {
+			apiError(ctx, http.StatusUnauthorized, "invalid runner token")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	ctx.Data["CIRunner"] = runner
+}
+
+func ciRunner(ctx *context.Context) *models.CIRunner {
+	return ctx.Data["CIRunner"].(*models.CIRunner)
+}
+
+// assignJob loads the job of the request, it must be running on the runner
+func assignJob(ctx *context.Context) {
+	job, err := models.GetCIJobByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrCIJobNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	if job.RunnerID != ciRunner(ctx).ID {
+		apiError(ctx, http.StatusNotFound, models.ErrCIJobNotExist{ID: job.ID})
+		return
+	}
+	if job.Status != models.CIStatusRunning {
+		apiError(ctx, http.StatusConflict, "job is "+job.Status.String())
+		return
+	}
+	ctx.Data["CIJob"] = job
+}
+
+func ciJob(ctx *context.Context) *models.CIJob {
+	return ctx.Data["CIJob"].(*models.CIJob)
+}
+
+// RegisterRunner registers a runner with a registration token
+func RegisterRunner(ctx *context.Context, opts ci.RegisterRunnerOptions) {
+	token, err := models.GetActiveCIRunnerToken(opts.Token)
+	if err != nil {
+		if models.IsErrCIRunnerTokenNotExist(err) {
+			apiError(ctx, http.StatusUnauthorized, "invalid registration token")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	runner := &models.CIRunner{
+		Name:   opts.Name,
+		RepoID: token.RepoID,
+		Labels: opts.Labels,
+	}
+	if err = models.RegisterCIRunner(runner); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, &ci.RegisteredRunner{
+		ID:    runner.ID,
+		UUID:  runner.UUID,
+		Token: runner.Token,
+	})
+}
+
+// FetchTask waits for a job the runner can run and assigns it to the runner, it answers
+// with no content if there was none before the poll timeout
+func FetchTask(ctx *context.Context) {
+	job, err := ci.FetchJob(ctx.Req.Request.Context(), ciRunner(ctx))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	} else if job == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+	ctx.JSON(http.StatusOK, ci.ToTask(job))
+}
+
+// AppendLog appends lines to the log of a step of the job
+func AppendLog(ctx *context.Context, opts ci.AppendLogOptions) {
+	length, err := ci.AppendLog(ciJob(ctx), opts.Step, opts.Offset, opts.Lines)
+	switch err {
+	case nil:
+		ctx.JSON(http.StatusOK, &ci.AppendLogResult{Length: length})
+	case ci.ErrStepNotExist:
+		apiError(ctx, http.StatusNotFound, err)
+	case ci.ErrLogOffset:
+		apiError(ctx, http.StatusBadRequest, err)
+	case ci.ErrLogTooLarge:
+		apiError(ctx, http.StatusRequestEntityTooLarge, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// UpdateStep reports the status of a step of the job
+func UpdateStep(ctx *context.Context, opts ci.UpdateStatusOptions) {
+	status := models.ParseCIStatus(opts.Status)
+	switch status {
+	case models.CIStatusRunning, models.CIStatusSuccess, models.CIStatusFailure, models.CIStatusSkipped:
+	default:
+		apiError(ctx, http.StatusBadRequest, "invalid step status "+opts.Status)
+		return
+	}
+
+	if err := ci.UpdateStep(ciJob(ctx), ctx.ParamsInt(":index"), status); err != nil {
+		if err == ci.ErrStepNotExist {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// FinishJob reports the final status of the job
+func FinishJob(ctx *context.Context, opts ci.UpdateStatusOptions) {
+	status := models.ParseCIStatus(opts.Status)
+	if status != models.CIStatusSuccess && status != models.CIStatusFailure {
+		apiError(ctx, http.StatusBadRequest, "invalid job status "+opts.Status)
+		return
+	}
+
+	if err := ci.FinishJob(ciJob(ctx), status); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,317 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/packages"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+func getBlob(r *request, digest string) {
+	if !container.IsValidDigest(digest) {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "invalid digest")
+		return
+	}
+	if !r.loadImage(false, false) {
+		return
+	}
+
+	if _, err := models.GetContainerBlob(r.image.ID, digest); err != nil {
+		if models.IsErrContainerBlobNotExist(err) {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeBlobUnknown, "blob unknown to registry")
+		} else {
+			serverError(r.ctx, "GetContainerBlob", err)
+		}
+		return
+	}
+
+	serveContent(r, digest, "application/octet-stream")
+}
+
+// serveContent writes the content of a blob, range and HEAD requests are supported
+func serveContent(r *request, digest, contentType string) {
+	file, err := container.NewContentStore().Get(digest)
+	if err != nil {
+		serverError(r.ctx, "Get", err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		serverError(r.ctx, "Stat", err)
+		return
+	}
+
+	r.ctx.Resp.Header().Set("Content-Type", contentType)
+	r.ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	r.ctx.Resp.Header().Set("ETag", `"`+digest+`"`)
+	http.ServeContent(r.ctx.Resp, r.ctx.Req.Request, "", info.ModTime(), file)
+}
+
+func deleteBlob(r *request, digest string) {
+	if !container.IsValidDigest(digest) {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "invalid digest")
+		return
+	}
+	if !r.loadImage(true, false) {
+		return
+	}
+
+	if _, err := models.GetContainerBlob(r.image.ID, digest); err != nil {
+		if models.IsErrContainerBlobNotExist(err) {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeBlobUnknown, "blob unknown to registry")
+		} else {
+			serverError(r.ctx, "GetContainerBlob", err)
+		}
+		return
+	}
+
+	manifests, err := models.GetContainerManifestsReferencing(r.image.ID, digest)
+	if err != nil {
+		serverError(r.ctx, "GetContainerManifestsReferencing", err)
+		return
+	} else if len(manifests) > 0 {
+		writeError(r.ctx, http.StatusConflict, container.ErrorCodeDenied, "blob is referenced by manifest "+manifests[0].Digest)
+		return
+	}
+
+	if err = models.DeleteContainerBlob(r.image.ID, digest); err != nil {
+		serverError(r.ctx, "DeleteContainerBlob", err)
+		return
+	}
+	r.ctx.Resp.WriteHeader(http.StatusAccepted)
+}
+
+// blobCreated answers a request that completed the upload of a blob
+func blobCreated(r *request, digest string) {
+	r.ctx.Resp.Header().Set("Location", r.link("blobs", digest))
+	r.ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	r.ctx.Resp.Header().Set("Content-Length", "0")
+	r.ctx.Resp.WriteHeader(http.StatusCreated)
+}
+
+// uploadAccepted answers a request that started or continued an upload
+func uploadAccepted(r *request, upload *models.ContainerUpload, status int) {
+	r.ctx.Resp.Header().Set("Location", r.link("blobs/uploads", upload.UUID))
+	r.ctx.Resp.Header().Set("Docker-Upload-UUID", upload.UUID)
+	if upload.Size > 0 {
+		r.ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", upload.Size-1))
+	} else {
+		r.ctx.Resp.Header().Set("Range", "0-0")
+	}
+	r.ctx.Resp.Header().Set("Content-Length", "0")
+	r.ctx.Resp.WriteHeader(status)
+}
+
+// startUpload starts a chunked upload, uploads a blob in a single request if the digest is
+// given or mounts a blob of another image
+func startUpload(r *request, _ string) {
+	if !r.loadImage(true, true) {
+		return
+	}
+
+	if digest := r.ctx.Query("mount"); len(digest) > 0 && container.IsValidDigest(digest) {
+		if mountBlob(r, digest, r.ctx.Query("from")) || r.ctx.Written() {
+			return
+		}
+	}
+
+	if digest := r.ctx.Query("digest"); len(digest) > 0 {
+		if !container.IsValidDigest(digest) {
+			writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "invalid digest")
+			return
+		}
+		body := r.ctx.Req.Body().ReadCloser()
+		defer body.Close()
+		size, err := container.NewContentStore().Put(digest, r.ctx.Req.ContentLength, body)
+		if err != nil {
+			uploadError(r, "Put", err)
+			return
+		}
+		if err = models.LinkContainerBlob(r.image.ID, digest, size); err != nil {
+			serverError(r.ctx, "LinkContainerBlob", err)
+			return
+		}
+		blobCreated(r, digest)
+		return
+	}
+
+	upload := &models.ContainerUpload{
+		UUID:    gouuid.NewV4().String(),
+		ImageID: r.image.ID,
+	}
+	if err := models.CreateContainerUpload(upload); err != nil {
+		serverError(r.ctx, "CreateContainerUpload", err)
+		return
+	}
+	uploadAccepted(r, upload, http.StatusAccepted)
+}
+
+// mountBlob links a blob of another image the client can read and returns true on success
+func mountBlob(r *request, digest, from string) bool {
+	if !container.IsValidName(from) {
+		return false
+	}
+	ownerName, imageName := container.SplitName(from)
+	owner, err := models.GetUserByName(ownerName)
+	if err != nil {
+		if !models.IsErrUserNotExist(err) {
+			serverError(r.ctx, "GetUserByName", err)
+		}
+		return false
+	}
+	if !packages.CanRead(owner, r.ctx.User) {
+		return false
+	}
+
+	img, err := models.GetContainerImage(owner.ID, imageName)
+	if err != nil {
+		if !models.IsErrContainerImageNotExist(err) {
+			serverError(r.ctx, "GetContainerImage", err)
+		}
+		return false
+	}
+	blob, err := models.GetContainerBlob(img.ID, digest)
+	if err != nil {
+		if !models.IsErrContainerBlobNotExist(err) {
+			serverError(r.ctx, "GetContainerBlob", err)
+		}
+		return false
+	}
+	if !container.NewContentStore().Exists(digest) {
+		return false
+	}
+
+	if err = models.LinkContainerBlob(r.image.ID, digest, blob.Size); err != nil {
+		serverError(r.ctx, "LinkContainerBlob", err)
+		return false
+	}
+	blobCreated(r, digest)
+	return true
+}
+
+// loadUpload loads the upload in progress with the given UUID
+func (r *request) loadUpload(uuid string) *models.ContainerUpload {
+	if !r.loadImage(true, false) {
+		return nil
+	}
+	upload, err := models.GetContainerUpload(r.image.ID, uuid)
+	if err != nil {
+		if models.IsErrContainerUploadNotExist(err) {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeBlobUploadUnknown, "blob upload unknown to registry")
+		} else {
+			serverError(r.ctx, "GetContainerUpload", err)
+		}
+		return nil
+	}
+	return upload
+}
+
+func getUploadStatus(r *request, uuid string) {
+	upload := r.loadUpload(uuid)
+	if upload == nil {
+		return
+	}
+	uploadAccepted(r, upload, http.StatusNoContent)
+}
+
+// appendChunk appends the request body to the upload, the chunk must start where the
+// previous one ended
+func appendChunk(r *request, upload *models.ContainerUpload) bool {
+	if contentRange := r.ctx.Req.Header.Get("Content-Range"); len(contentRange) > 0 {
+		start, err := strconv.ParseInt(strings.SplitN(contentRange, "-", 2)[0], 10, 64)
+		if err != nil || start != upload.Size {
+			r.ctx.Resp.Header().Set("Location", r.link("blobs/uploads", upload.UUID))
+			r.ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", upload.Size-1))
+			writeError(r.ctx, http.StatusRequestedRangeNotSatisfiable, container.ErrorCodeBlobUploadInvalid, "chunk does not continue the upload")
+			return false
+		}
+	}
+
+	body := r.ctx.Req.Body().ReadCloser()
+	defer body.Close()
+	written, err := container.NewContentStore().AppendUpload(upload.UUID, body)
+	upload.Size += written
+	if updateErr := models.UpdateContainerUploadSize(upload); err == nil {
+		err = updateErr
+	}
+	if err != nil {
+		serverError(r.ctx, "AppendUpload", err)
+		return false
+	}
+	return true
+}
+
+func patchUpload(r *request, uuid string) {
+	upload := r.loadUpload(uuid)
+	if upload == nil || !appendChunk(r, upload) {
+		return
+	}
+	uploadAccepted(r, upload, http.StatusAccepted)
+}
+
+func finishUpload(r *request, uuid string) {
+	digest := r.ctx.Query("digest")
+	if !container.IsValidDigest(digest) {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "invalid digest")
+		return
+	}
+
+	upload := r.loadUpload(uuid)
+	if upload == nil || !appendChunk(r, upload) {
+		return
+	}
+
+	size, err := container.NewContentStore().FinishUpload(upload.UUID, digest)
+	if err != nil {
+		uploadError(r, "FinishUpload", err)
+		return
+	}
+	if err = models.LinkContainerBlob(r.image.ID, digest, size); err != nil {
+		serverError(r.ctx, "LinkContainerBlob", err)
+		return
+	}
+	if err = models.DeleteContainerUpload(upload); err != nil {
+		serverError(r.ctx, "DeleteContainerUpload", err)
+		return
+	}
+	blobCreated(r, digest)
+}
+
+func cancelUpload(r *request, uuid string) {
+	upload := r.loadUpload(uuid)
+	if upload == nil {
+		return
+	}
+	if err := container.NewContentStore().DeleteUpload(upload.UUID); err != nil {
+		serverError(r.ctx, "DeleteUpload", err)
+		return
+	}
+	if err := models.DeleteContainerUpload(upload); err != nil {
+		serverError(r.ctx, "DeleteContainerUpload", err)
+		return
+	}
+	r.ctx.Resp.WriteHeader(http.StatusNoContent)
+}
+
+func uploadError(r *request, title string, err error) {
+	switch err {
+	case container.ErrDigestMismatch:
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "content does not match digest")
+	case container.ErrSizeMismatch:
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeSizeInvalid, "content does not match size")
+	default:
+		serverError(r.ctx, title, err)
+	}
+}
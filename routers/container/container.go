@@ -0,0 +1,302 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package container implements the OCI distribution API of the container registry, served
+// under /v2. Images belong to users and organizations, the first component of an image name
+// is the name of its owner.
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gitea.com/macaron/macaron"
+)
+
+var (
+	tagsPattern      = regexp.MustCompile(`^(.+)/tags/list$`)
+	manifestsPattern = regexp.MustCompile(`^(.+)/manifests/([^/]+)$`)
+	uploadsPattern   = regexp.MustCompile(`^(.+)/blobs/uploads/?$`)
+	uploadPattern    = regexp.MustCompile(`^(.+)/blobs/uploads/([^/]+)$`)
+	blobsPattern     = regexp.MustCompile(`^(.+)/blobs/([^/]+)$`)
+)
+
+// RegisterRoutes registers the registry routes, they are served under /v2.
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Get("", CheckSignedIn)
+	m.Get("/", CheckSignedIn)
+	m.Get("/_catalog", Catalog)
+	m.Any("/*", dispatch)
+}
+
+// request holds the image a request applies to
+type request struct {
+	ctx   *context.Context
+	owner *models.User
+	// name is the full name of the image including its owner
+	name  string
+	image *models.ContainerImage
+}
+
+// dispatch routes a request by the path following the image name, image names may
+// contain slashes and can't be matched by the router.
+func dispatch(ctx *context.Context) {
+	path := ctx.Params("*")
+	method := ctx.Req.Method
+
+	var handler func(*request, string)
+	var name, reference string
+	if match := tagsPattern.FindStringSubmatch(path); match != nil && method == "GET" {
+		name, handler = match[1], listTags
+	} else if match = manifestsPattern.FindStringSubmatch(path); match != nil {
+		name, reference = match[1], match[2]
+		switch method {
+		case "GET", "HEAD":
+			handler = getManifest
+		case "PUT":
+			handler = putManifest
+		case "DELETE":
+			handler = deleteManifest
+		}
+	} else if match = uploadsPattern.FindStringSubmatch(path); match != nil && method == "POST" {
+		name, handler = match[1], startUpload
+	} else if match = uploadPattern.FindStringSubmatch(path); match != nil {
+		name, reference = match[1], match[2]
+		switch method {
+		case "GET":
+			handler = getUploadStatus
+		case "PATCH":
+			handler = patchUpload
+		case "PUT":
+			handler = finishUpload
+		case "DELETE":
+			handler = cancelUpload
+		}
+	} else if match = blobsPattern.FindStringSubmatch(path); match != nil {
+		name, reference = match[1], match[2]
+		switch method {
+		case "GET", "HEAD":
+			handler = getBlob
+		case "DELETE":
+			handler = deleteBlob
+		}
+	}
+
+	if handler == nil {
+		writeError(ctx, http.StatusNotFound, container.ErrorCodeUnsupported, "unsupported request")
+		return
+	}
+
+	if !container.IsValidName(name) {
+		writeError(ctx, http.StatusBadRequest, container.ErrorCodeNameInvalid, "invalid repository name")
+		return
+	}
+	ownerName, imageName := container.SplitName(name)
+	if len(imageName) == 0 {
+		writeError(ctx, http.StatusBadRequest, container.ErrorCodeNameInvalid, "repository name must start with the name of a user or an organization")
+		return
+	}
+
+	owner, err := models.GetUserByName(ownerName)
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			writeError(ctx, http.StatusNotFound, container.ErrorCodeNameUnknown, "repository name not known to registry")
+		} else {
+			serverError(ctx, "GetUserByName", err)
+		}
+		return
+	}
+
+	handler(&request{ctx: ctx, owner: owner, name: name}, reference)
+}
+
+// Authenticate makes sure the registry is enabled and rejects password authentication of
+// users who enrolled two-factor authentication, they must use an access token.
+func Authenticate(ctx *context.Context) {
+	if !setting.ContainerRegistry.Enabled {
+		ctx.NotFound("", nil)
+		return
+	}
+	ctx.Resp.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+
+	if ctx.IsSigned && ctx.IsBasicAuth && ctx.Data["IsApiToken"] != true {
+		_, err := models.GetTwoFactorByUID(ctx.User.ID)
+		if err == nil {
+			unauthorized(ctx, "two-factor authentication is enabled, sign in with an access token instead")
+			return
+		} else if !models.IsErrTwoFactorNotEnrolled(err) {
+			serverError(ctx, "GetTwoFactorByUID", err)
+			return
+		}
+	}
+
+	if !ctx.IsSigned && setting.Service.RequireSignInView {
+		unauthorized(ctx, "authentication required")
+	}
+}
+
+// CheckSignedIn answers the version check of clients, it fails if the client is not
+// authenticated so that clients know which authentication to use
+func CheckSignedIn(ctx *context.Context) {
+	if !ctx.IsSigned {
+		unauthorized(ctx, "authentication required")
+		return
+	}
+	writeJSON(ctx, http.StatusOK, struct{}{})
+}
+
+type catalog struct {
+	Repositories []string `json:"repositories"`
+}
+
+// Catalog lists the images visible to the client
+func Catalog(ctx *context.Context) {
+	images, _, err := models.FindContainerImages(&models.FindContainerImagesOptions{Actor: ctx.User})
+	if err != nil {
+		serverError(ctx, "FindContainerImages", err)
+		return
+	}
+
+	names := make([]string, 0, len(images))
+	for _, img := range images {
+		names = append(names, img.FullName())
+	}
+	sort.Strings(names)
+
+	names = paginate(ctx, names, setting.AppSubURL+"/v2/_catalog")
+	if ctx.Written() {
+		return
+	}
+	writeJSON(ctx, http.StatusOK, &catalog{Repositories: names})
+}
+
+// paginate returns the sorted names following the "last" query parameter, limited to "n"
+// names, and adds a link to the next page if there are more names
+func paginate(ctx *context.Context, names []string, link string) []string {
+	if last := ctx.Query("last"); len(last) > 0 {
+		names = names[sort.SearchStrings(names, last):]
+		if len(names) > 0 && names[0] == last {
+			names = names[1:]
+		}
+	}
+
+	if len(ctx.Query("n")) == 0 {
+		return names
+	}
+	n, err := strconv.Atoi(ctx.Query("n"))
+	if err != nil || n < 0 {
+		writeError(ctx, http.StatusBadRequest, container.ErrorCodeUnsupported, "invalid number of results")
+		return nil
+	}
+	if n < len(names) {
+		names = names[:n]
+		if n > 0 {
+			ctx.Resp.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`, link, n, names[n-1]))
+		}
+	}
+	return names
+}
+
+// checkAccess answers the request with an error if the client may not access the image,
+// write access is required to change it
+func (r *request) checkAccess(write bool) bool {
+	if !packages.CanRead(r.owner, r.ctx.User) {
+		if r.ctx.User == nil {
+			unauthorized(r.ctx, "authentication required")
+		} else {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeNameUnknown, "repository name not known to registry")
+		}
+		return false
+	}
+	if !write {
+		return true
+	}
+
+	allowed, err := packages.CanWrite(r.owner, r.ctx.User)
+	if err != nil {
+		serverError(r.ctx, "CanWrite", err)
+		return false
+	} else if !allowed {
+		if r.ctx.User == nil {
+			unauthorized(r.ctx, "authentication required")
+		} else {
+			writeError(r.ctx, http.StatusForbidden, container.ErrorCodeDenied, "requested access to the resource is denied")
+		}
+		return false
+	}
+	return true
+}
+
+// loadImage checks the access of the client and loads the image, it is created if create is
+// true and the image does not exist yet
+func (r *request) loadImage(write, create bool) bool {
+	if !r.checkAccess(write) {
+		return false
+	}
+
+	_, imageName := container.SplitName(r.name)
+	var err error
+	if create {
+		r.image, err = models.GetOrCreateContainerImage(r.owner.ID, imageName)
+	} else {
+		r.image, err = models.GetContainerImage(r.owner.ID, imageName)
+	}
+	if err != nil {
+		if models.IsErrContainerImageNotExist(err) {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeNameUnknown, "repository name not known to registry")
+		} else {
+			serverError(r.ctx, "GetContainerImage", err)
+		}
+		return false
+	}
+	r.image.Owner = r.owner
+	return true
+}
+
+// link returns the URL of a resource of the image
+func (r *request) link(kind, reference string) string {
+	return setting.AppSubURL + "/v2/" + r.name + "/" + kind + "/" + reference
+}
+
+func unauthorized(ctx *context.Context, message string) {
+	ctx.Resp.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, setting.AppName))
+	writeError(ctx, http.StatusUnauthorized, container.ErrorCodeUnauthorized, message)
+}
+
+func writeJSON(ctx *context.Context, status int, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		log.Error("json.Marshal: %v", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", "application/json")
+	ctx.Resp.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	ctx.Resp.WriteHeader(status)
+	if _, err = ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+func writeError(ctx *context.Context, status int, code, message string) {
+	writeJSON(ctx, status, &container.ErrorResponse{
+		Errors: []container.Error{{Code: code, Message: message}},
+	})
+}
+
+func serverError(ctx *context.Context, title string, err error) {
+	log.Error("%s: %v", title, err)
+	writeError(ctx, http.StatusInternalServerError, container.ErrorCodeUnsupported, "internal server error")
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+type tagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+func listTags(r *request, _ string) {
+	if !r.loadImage(false, false) {
+		return
+	}
+
+	tags, err := models.GetContainerTags(r.image.ID)
+	if err != nil {
+		serverError(r.ctx, "GetContainerTags", err)
+		return
+	}
+
+	tags = paginate(r.ctx, tags, setting.AppSubURL+"/v2/"+r.name+"/tags/list")
+	if r.ctx.Written() {
+		return
+	}
+	writeJSON(r.ctx, http.StatusOK, &tagList{Name: r.name, Tags: tags})
+}
+
+// loadManifest returns the manifest the reference, either a digest or a tag, points to
+func (r *request) loadManifest(reference string) *models.ContainerManifest {
+	var m *models.ContainerManifest
+	var err error
+	if container.IsValidDigest(reference) {
+		m, err = models.GetContainerManifest(r.image.ID, reference)
+	} else if container.IsValidTag(reference) {
+		m, err = models.GetContainerManifestByTag(r.image.ID, reference)
+	} else {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestInvalid, "invalid tag or digest")
+		return nil
+	}
+	if err != nil {
+		if models.IsErrContainerManifestNotExist(err) {
+			writeError(r.ctx, http.StatusNotFound, container.ErrorCodeManifestUnknown, "manifest unknown")
+		} else {
+			serverError(r.ctx, "GetContainerManifest", err)
+		}
+		return nil
+	}
+	return m
+}
+
+func getManifest(r *request, reference string) {
+	if !r.loadImage(false, false) {
+		return
+	}
+	m := r.loadManifest(reference)
+	if m == nil {
+		return
+	}
+	serveContent(r, m.Digest, m.MediaType)
+}
+
+func putManifest(r *request, reference string) {
+	isDigest := container.IsValidDigest(reference)
+	if !isDigest && !container.IsValidTag(reference) {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestInvalid, "invalid tag or digest")
+		return
+	}
+	if !r.loadImage(true, true) {
+		return
+	}
+
+	body := r.ctx.Req.Body().ReadCloser()
+	defer body.Close()
+	content, err := ioutil.ReadAll(io.LimitReader(body, setting.ContainerRegistry.MaxManifestSize+1))
+	if err != nil {
+		serverError(r.ctx, "ReadAll", err)
+		return
+	} else if int64(len(content)) > setting.ContainerRegistry.MaxManifestSize {
+		writeError(r.ctx, http.StatusRequestEntityTooLarge, container.ErrorCodeSizeInvalid, "manifest is too large")
+		return
+	}
+
+	hash := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(hash[:])
+	if isDigest && reference != digest {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeDigestInvalid, "manifest does not match digest")
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.ctx.Req.Header.Get("Content-Type"))
+	manifest, err := container.ParseManifest(contentType, content)
+	if err != nil {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestInvalid, err.Error())
+		return
+	}
+
+	// Everything the manifest references must have been pushed to the image before
+	references := manifest.Blobs()
+	for _, blob := range references {
+		if _, err = models.GetContainerBlob(r.image.ID, blob); err != nil {
+			if models.IsErrContainerBlobNotExist(err) {
+				writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestBlobUnknown, "blob unknown to registry: "+blob)
+			} else {
+				serverError(r.ctx, "GetContainerBlob", err)
+			}
+			return
+		}
+	}
+	for _, child := range manifest.Children() {
+		if _, err = models.GetContainerManifest(r.image.ID, child); err != nil {
+			if models.IsErrContainerManifestNotExist(err) {
+				writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestUnknown, "manifest unknown to registry: "+child)
+			} else {
+				serverError(r.ctx, "GetContainerManifest", err)
+			}
+			return
+		}
+		references = append(references, child)
+	}
+
+	if _, err = container.NewContentStore().Put(digest, int64(len(content)), bytes.NewReader(content)); err != nil {
+		serverError(r.ctx, "Put", err)
+		return
+	}
+
+	tag := ""
+	if !isDigest {
+		tag = reference
+	}
+	if err = models.PutContainerManifest(&models.ContainerManifest{
+		ImageID:   r.image.ID,
+		Digest:    digest,
+		MediaType: manifest.MediaType,
+		Size:      int64(len(content)),
+	}, references, tag); err != nil {
+		serverError(r.ctx, "PutContainerManifest", err)
+		return
+	}
+
+	r.ctx.Resp.Header().Set("Location", r.link("manifests", digest))
+	r.ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	r.ctx.Resp.Header().Set("Content-Length", "0")
+	r.ctx.Resp.WriteHeader(http.StatusCreated)
+}
+
+// deleteManifest deletes a manifest and its tags if the reference is a digest, otherwise
+// only the tag is deleted
+func deleteManifest(r *request, reference string) {
+	if !r.loadImage(true, false) {
+		return
+	}
+
+	if container.IsValidDigest(reference) {
+		m := r.loadManifest(reference)
+		if m == nil {
+			return
+		}
+		if err := models.DeleteContainerManifest(m); err != nil {
+			serverError(r.ctx, "DeleteContainerManifest", err)
+			return
+		}
+	} else if container.IsValidTag(reference) {
+		if err := models.DeleteContainerTag(r.image.ID, reference); err != nil {
+			if models.IsErrContainerManifestNotExist(err) {
+				writeError(r.ctx, http.StatusNotFound, container.ErrorCodeManifestUnknown, "manifest unknown")
+			} else {
+				serverError(r.ctx, "DeleteContainerTag", err)
+			}
+			return
+		}
+	} else {
+		writeError(r.ctx, http.StatusBadRequest, container.ErrorCodeManifestInvalid, "invalid tag or digest")
+		return
+	}
+	r.ctx.Resp.WriteHeader(http.StatusAccepted)
+}
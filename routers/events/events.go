@@ -0,0 +1,114 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package events
+
+import (
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/eventsource"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// reconnectDelay is how long the browsers wait before reconnecting a closed
+// stream, e.g. while the server is restarting
+const reconnectDelay = 5 * time.Second
+
+// Events streams the events of the signed in user: the count of the unread
+// notifications, the running stopwatches and the updates of the issue passed
+// in the "issue" query parameter, if any
+func Events(ctx *context.Context) {
+	var issueID int64
+	if id := ctx.QueryInt64("issue"); id > 0 {
+		issue, err := models.GetIssueByID(id)
+		if err != nil {
+			if models.IsErrIssueNotExist(err) {
+				ctx.NotFound("GetIssueByID", err)
+			} else {
+				ctx.ServerError("GetIssueByID", err)
+			}
+			return
+		}
+		if err := issue.LoadRepo(); err != nil {
+			ctx.ServerError("LoadRepo", err)
+			return
+		}
+		perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+		if err != nil {
+			ctx.ServerError("GetUserRepoPermission", err)
+			return
+		}
+		if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+			ctx.NotFound("CanReadIssuesOrPulls", nil)
+			return
+		}
+		issueID = issue.ID
+	}
+
+	count, err := models.GetNotificationCount(ctx.User, models.NotificationStatusUnread)
+	if err != nil {
+		ctx.ServerError("GetNotificationCount", err)
+		return
+	}
+	stopwatches, err := eventsource.NewStopwatchesEvent(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("NewStopwatchesEvent", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/event-stream")
+	ctx.Resp.Header().Set("Cache-Control", "no-cache")
+	ctx.Resp.Header().Set("Connection", "keep-alive")
+	// Prevents nginx from buffering the stream
+	ctx.Resp.Header().Set("X-Accel-Buffering", "no")
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	manager := eventsource.GetManager()
+	stream := manager.Register(ctx.User.ID, issueID)
+	defer manager.Unregister(stream)
+
+	send := func(event *eventsource.Event) bool {
+		if _, err := event.WriteTo(ctx.Resp); err != nil {
+			log.Trace("Unable to write the event stream of user %d: %v", ctx.User.ID, err)
+			return false
+		}
+		ctx.Resp.Flush()
+		return true
+	}
+
+	if !send(&eventsource.Event{Retry: reconnectDelay}) ||
+		!send(eventsource.NewNotificationCountEvent(count)) ||
+		!send(stopwatches) {
+		return
+	}
+
+	keepAlive := time.NewTicker(setting.UI.Notification.EventSourceKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-keepAlive.C:
+			// A comment line keeps the idle connection open through the proxies
+			if _, err := ctx.Resp.Write([]byte(": ping\n\n")); err != nil {
+				return
+			}
+			ctx.Resp.Flush()
+		case <-ctx.Req.Context().Done():
+			return
+		case <-graceful.GetManager().IsShutdown():
+			// Closing the stream lets the server shut down, the browser
+			// reconnects to the process taking over
+			return
+		case event, ok := <-stream.Events():
+			if !ok || !send(event) {
+				return
+			}
+		}
+	}
+}
@@ -12,11 +12,11 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
-	code_indexer "code.gitea.io/gitea/modules/indexer/code"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/repo"
 	"code.gitea.io/gitea/routers/user"
 )
 
@@ -31,6 +31,8 @@ const (
 	tplExploreOrganizations base.TplName = "explore/organizations"
 	// tplExploreCode explore code page template
 	tplExploreCode base.TplName = "explore/code"
+	// tplExploreCommits explore commits page template
+	tplExploreCommits base.TplName = "explore/commits"
 )
 
 // Home render home page
@@ -67,6 +69,7 @@ func Home(ctx *context.Context) {
 
 	ctx.Data["PageIsHome"] = true
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 	ctx.HTML(200, tplHome)
 }
 
@@ -159,6 +162,7 @@ func RenderRepoSearch(ctx *context.Context, opts *RepoSearchOptions) {
 	ctx.Data["Total"] = count
 	ctx.Data["Repos"] = repos
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	pager := context.NewPagination(int(count), opts.PageSize, page, 5)
 	pager.SetDefaultParams(ctx)
@@ -174,6 +178,7 @@ func ExploreRepos(ctx *context.Context) {
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreRepositories"] = true
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	var ownerID int64
 	if ctx.User != nil && !ctx.User.IsAdmin {
@@ -236,6 +241,7 @@ func RenderUserSearch(ctx *context.Context, opts *models.SearchUserOptions, tplN
 	ctx.Data["Users"] = users
 	ctx.Data["ShowUserEmail"] = setting.UI.ShowUserEmail
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	pager := context.NewPagination(int(count), opts.PageSize, opts.Page, 5)
 	pager.SetDefaultParams(ctx)
@@ -250,6 +256,7 @@ func ExploreUsers(ctx *context.Context) {
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreUsers"] = true
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	RenderUserSearch(ctx, &models.SearchUserOptions{
 		Type:        models.UserTypeIndividual,
@@ -265,6 +272,7 @@ func ExploreOrganizations(ctx *context.Context) {
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreOrganizations"] = true
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	visibleTypes := []structs.VisibleType{structs.VisibleTypePublic}
 	if ctx.User != nil {
@@ -286,106 +294,30 @@ func ExploreCode(ctx *context.Context) {
 	}
 
 	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 	ctx.Data["Title"] = ctx.Tr("explore")
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreCode"] = true
 
-	keyword := strings.TrimSpace(ctx.Query("q"))
-	page := ctx.QueryInt("page")
-	if page <= 0 {
-		page = 1
-	}
-
-	var (
-		repoIDs []int64
-		err     error
-		isAdmin bool
-		userID  int64
-	)
-	if ctx.User != nil {
-		userID = ctx.User.ID
-		isAdmin = ctx.User.IsAdmin
-	}
-
-	// guest user or non-admin user
-	if ctx.User == nil || !isAdmin {
-		repoIDs, err = models.FindUserAccessibleRepoIDs(ctx.User)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-	}
-
-	var (
-		total         int
-		searchResults []*code_indexer.Result
-	)
-
-	// if non-admin login user, we need check UnitTypeCode at first
-	if ctx.User != nil && len(repoIDs) > 0 {
-		repoMaps, err := models.GetRepositoriesMapByIDs(repoIDs)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var rightRepoMap = make(map[int64]*models.Repository, len(repoMaps))
-		repoIDs = make([]int64, 0, len(repoMaps))
-		for id, repo := range repoMaps {
-			if repo.CheckUnitUser(userID, isAdmin, models.UnitTypeCode) {
-				rightRepoMap[id] = repo
-				repoIDs = append(repoIDs, id)
-			}
-		}
-
-		ctx.Data["RepoMaps"] = rightRepoMap
-
-		total, searchResults, err = code_indexer.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-		// if non-login user or isAdmin, no need to check UnitTypeCode
-	} else if (ctx.User == nil && len(repoIDs) > 0) || isAdmin {
-		total, searchResults, err = code_indexer.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var loadRepoIDs = make([]int64, 0, len(searchResults))
-		for _, result := range searchResults {
-			var find bool
-			for _, id := range loadRepoIDs {
-				if id == result.RepoID {
-					find = true
-					break
-				}
-			}
-			if !find {
-				loadRepoIDs = append(loadRepoIDs, result.RepoID)
-			}
-		}
-
-		repoMaps, err := models.GetRepositoriesMapByIDs(loadRepoIDs)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
+	repo.RenderCodeSearch(ctx, &repo.CodeSearchOptions{
+		TplName: tplExploreCode,
+	})
+}
 
-		ctx.Data["RepoMaps"] = repoMaps
+// ExploreCommits render explore commits page
+func ExploreCommits(ctx *context.Context) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.Redirect(setting.AppSubURL+"/explore", 302)
+		return
 	}
 
-	ctx.Data["Keyword"] = keyword
-	ctx.Data["SearchResults"] = searchResults
-	ctx.Data["RequireHighlightJS"] = true
-	ctx.Data["PageIsViewCode"] = true
-
-	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
-	pager.SetDefaultParams(ctx)
-	ctx.Data["Page"] = pager
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
+	ctx.Data["Title"] = ctx.Tr("explore")
+	ctx.Data["PageIsExplore"] = true
+	ctx.Data["PageIsExploreCommits"] = true
 
-	ctx.HTML(200, tplExploreCode)
+	repo.RenderCommitSearch(ctx, tplExploreCommits)
 }
 
 // NotFound render 404 page
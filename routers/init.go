@@ -18,6 +18,7 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	stats_indexer "code.gitea.io/gitea/modules/indexer/stats"
 	"code.gitea.io/gitea/modules/log"
@@ -29,8 +30,10 @@ import (
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/services/mailer"
+	"code.gitea.io/gitea/services/mailer/incoming"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	pull_service "code.gitea.io/gitea/services/pull"
+	"code.gitea.io/gitea/services/webpush"
 
 	"gitea.com/macaron/macaron"
 )
@@ -51,6 +54,7 @@ func checkRunMode() {
 func NewServices() {
 	setting.NewServices()
 	mailer.NewContext()
+	webpush.Init()
 	_ = cache.NewContext()
 	notification.NewContext()
 }
@@ -112,6 +116,7 @@ func GlobalInit(ctx context.Context) {
 		cron.NewContext()
 		issue_indexer.InitIssueIndexer(false)
 		code_indexer.Init()
+		commit_indexer.Init()
 		if err := stats_indexer.Init(); err != nil {
 			log.Fatal("Failed to initialize repository stats indexer queue: %v", err)
 		}
@@ -139,6 +144,13 @@ func GlobalInit(ctx context.Context) {
 		} else {
 			ssh.Unused()
 		}
+
+		if setting.IncomingEmail.Enabled {
+			incoming.Listen()
+			log.Info("Incoming mail server started on %s (%s)", setting.IncomingEmail.ListenAddr, setting.IncomingEmail.Protocol)
+		} else {
+			incoming.Unused()
+		}
 	}
 
 	if setting.InstallLock {
@@ -0,0 +1,39 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/repo"
+)
+
+const (
+	// tplCode template for organization code search page
+	tplCode base.TplName = "org/code"
+)
+
+// Code render organization code search page
+func Code(ctx *context.Context) {
+	org := ctx.Org.Organization
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.Redirect(org.HomeLink(), 302)
+		return
+	}
+	if !models.HasOrgVisible(org, ctx.User) {
+		ctx.NotFound("HasOrgVisible", nil)
+		return
+	}
+
+	ctx.Data["Title"] = org.DisplayName()
+	ctx.Data["PageIsOrgCode"] = true
+
+	repo.RenderCodeSearch(ctx, &repo.CodeSearchOptions{
+		OwnerID: org.ID,
+		TplName: tplCode,
+	})
+}
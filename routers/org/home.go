@@ -119,6 +119,21 @@ func Home(ctx *context.Context) {
 		return
 	}
 
+	if setting.ContainerRegistry.Enabled {
+		images, imagesCount, err := models.FindContainerImages(&models.FindContainerImagesOptions{
+			ListOptions: models.ListOptions{Page: 1, PageSize: 10},
+			OwnerID:     org.ID,
+			Actor:       ctx.User,
+		})
+		if err != nil {
+			ctx.ServerError("FindContainerImages", err)
+			return
+		}
+		ctx.Data["ContainerImages"] = images
+		ctx.Data["ContainerImagesTotal"] = imagesCount
+		ctx.Data["ContainerRegistryHost"] = setting.ContainerRegistry.Host
+	}
+
 	ctx.Data["Repos"] = repos
 	ctx.Data["Total"] = count
 	ctx.Data["MembersTotal"] = membersCount
@@ -7,6 +7,7 @@ package org
 
 import (
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
@@ -120,6 +121,13 @@ func MembersAction(ctx *context.Context) {
 		return
 	}
 
+	switch ctx.Params(":action") {
+	case "remove":
+		ctx.Audit(models.AuditOrgMemberRemove, org, audit.Diff{"member_id": uid})
+	case "leave":
+		ctx.Audit(models.AuditOrgMemberRemove, org, audit.Diff{"member_id": ctx.User.ID})
+	}
+
 	if ctx.Params(":action") != "leave" {
 		ctx.Redirect(ctx.Org.OrgLink + "/members")
 	} else {
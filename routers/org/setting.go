@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -32,6 +33,7 @@ func Settings(ctx *context.Context) {
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["CurrentVisibility"] = ctx.Org.Organization.Visibility
 	ctx.Data["RepoAdminChangeTeamAccess"] = ctx.Org.Organization.RepoAdminChangeTeamAccess
+	ctx.Data["RequireTwoFactor"] = ctx.Org.Organization.RequireTwoFactor
 	ctx.HTML(200, tplSettingsOptions)
 }
 
@@ -47,6 +49,19 @@ func SettingsPost(ctx *context.Context, form auth.UpdateOrgSettingForm) {
 	}
 
 	org := ctx.Org.Organization
+	before := *org
+
+	// Owners can't lock themselves out of the organization's repositories
+	if form.RequireTwoFactor && !org.RequireTwoFactor {
+		hasTwoFactor, err := models.HasTwoFactorByUID(ctx.User.ID)
+		if err != nil {
+			ctx.ServerError("HasTwoFactorByUID", err)
+			return
+		} else if !hasTwoFactor {
+			ctx.RenderWithErr(ctx.Tr("org.settings.require_two_factor_not_enrolled"), tplSettingsOptions, &form)
+			return
+		}
+	}
 
 	// Check if organization name has been changed.
 	if org.LowerName != strings.ToLower(form.Name) {
@@ -85,10 +100,14 @@ func SettingsPost(ctx *context.Context, form auth.UpdateOrgSettingForm) {
 	org.Location = form.Location
 	org.Visibility = form.Visibility
 	org.RepoAdminChangeTeamAccess = form.RepoAdminChangeTeamAccess
+	org.RequireTwoFactor = form.RequireTwoFactor
 	if err := models.UpdateUser(org); err != nil {
 		ctx.ServerError("UpdateUser", err)
 		return
 	}
+	if diff := audit.OrgSettingsDiff(&before, org); len(diff) > 0 {
+		ctx.Audit(models.AuditOrgSettings, org, diff)
+	}
 	log.Trace("Organization setting updated: %s", org.Name)
 	ctx.Flash.Success(ctx.Tr("org.settings.update_setting_success"))
 	ctx.Redirect(ctx.Org.OrgLink + "/settings")
@@ -135,6 +154,9 @@ func SettingsDelete(ctx *context.Context) {
 			if models.IsErrUserOwnRepos(err) {
 				ctx.Flash.Error(ctx.Tr("form.org_still_own_repo"))
 				ctx.Redirect(ctx.Org.OrgLink + "/settings/delete")
+			} else if models.IsErrUserOwnPackages(err) {
+				ctx.Flash.Error(ctx.Tr("form.org_still_own_packages"))
+				ctx.Redirect(ctx.Org.OrgLink + "/settings/delete")
 			} else {
 				ctx.ServerError("DeleteOrganization", err)
 			}
@@ -167,9 +189,11 @@ func Webhooks(ctx *context.Context) {
 
 // DeleteWebhook response for delete webhook
 func DeleteWebhook(ctx *context.Context) {
-	if err := models.DeleteWebhookByOrgID(ctx.Org.Organization.ID, ctx.QueryInt64("id")); err != nil {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteWebhookByOrgID(ctx.Org.Organization.ID, id); err != nil {
 		ctx.Flash.Error("DeleteWebhookByOrgID: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditWebhookDelete, audit.Target{Type: "webhook", ID: id}, audit.Diff{"org_id": ctx.Org.Organization.ID})
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -58,6 +59,8 @@ func TeamsAction(ctx *context.Context) {
 
 	page := ctx.Query("page")
 	var err error
+	var auditAction models.AuditAction
+	var memberID int64
 	switch ctx.Params(":action") {
 	case "join":
 		if !ctx.Org.IsOwner {
@@ -65,14 +68,17 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddMember(ctx.User.ID)
+		auditAction, memberID = models.AuditTeamMemberAdd, ctx.User.ID
 	case "leave":
 		err = ctx.Org.Team.RemoveMember(ctx.User.ID)
+		auditAction, memberID = models.AuditTeamMemberRemove, ctx.User.ID
 	case "remove":
 		if !ctx.Org.IsOwner {
 			ctx.Error(404)
 			return
 		}
 		err = ctx.Org.Team.RemoveMember(uid)
+		auditAction, memberID = models.AuditTeamMemberRemove, uid
 		page = "team"
 	case "add":
 		if !ctx.Org.IsOwner {
@@ -102,6 +108,7 @@ func TeamsAction(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("org.teams.add_duplicate_users"))
 		} else {
 			err = ctx.Org.Team.AddMember(u.ID)
+			auditAction, memberID = models.AuditTeamMemberAdd, u.ID
 		}
 
 		page = "team"
@@ -118,6 +125,8 @@ func TeamsAction(ctx *context.Context) {
 			})
 			return
 		}
+	} else if len(auditAction) > 0 {
+		ctx.Audit(auditAction, ctx.Org.Team, audit.Diff{"member_id": memberID})
 	}
 
 	switch page {
@@ -138,6 +147,7 @@ func TeamsRepoAction(ctx *context.Context) {
 	}
 
 	var err error
+	var diff audit.Diff
 	action := ctx.Params(":action")
 	switch action {
 	case "add":
@@ -154,12 +164,17 @@ func TeamsRepoAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddRepository(repo)
+		diff = audit.Diff{"repo": repo.FullName()}
 	case "remove":
-		err = ctx.Org.Team.RemoveRepository(com.StrTo(ctx.Query("repoid")).MustInt64())
+		repoID := com.StrTo(ctx.Query("repoid")).MustInt64()
+		err = ctx.Org.Team.RemoveRepository(repoID)
+		diff = audit.Diff{"repo_id": repoID}
 	case "addall":
 		err = ctx.Org.Team.AddAllRepositories()
+		diff = audit.Diff{"all": true}
 	case "removeall":
 		err = ctx.Org.Team.RemoveAllRepositories()
+		diff = audit.Diff{"all": true}
 	}
 
 	if err != nil {
@@ -167,6 +182,11 @@ func TeamsRepoAction(ctx *context.Context) {
 		ctx.ServerError("TeamsRepoAction", err)
 		return
 	}
+	if action == "add" || action == "addall" {
+		ctx.Audit(models.AuditTeamRepoAdd, ctx.Org.Team, diff)
+	} else if action == "remove" || action == "removeall" {
+		ctx.Audit(models.AuditTeamRepoRemove, ctx.Org.Team, diff)
+	}
 
 	if action == "addall" || action == "removeall" {
 		ctx.JSON(200, map[string]interface{}{
@@ -203,6 +223,7 @@ func NewTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		IncludesAllRepositories: includesAllRepositories,
 		CanCreateOrgRepo:        form.CanCreateOrgRepo,
 	}
+	t.ReviewAssignment, _ = models.TeamReviewAssignmentFromName(form.ReviewAssignment)
 
 	if t.Authorize < models.AccessModeOwner {
 		var units = make([]*models.TeamUnit, 0, len(form.Units))
@@ -238,6 +259,7 @@ func NewTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		return
 	}
 	log.Trace("Team created: %s/%s", ctx.Org.Organization.Name, t.Name)
+	ctx.Audit(models.AuditTeamCreate, t, audit.TeamDiff(nil, t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + t.LowerName)
 }
 
@@ -278,6 +300,7 @@ func EditTeam(ctx *context.Context) {
 // EditTeamPost response for modify team information
 func EditTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 	t := ctx.Org.Team
+	before := *t
 	ctx.Data["Title"] = ctx.Org.Organization.FullName
 	ctx.Data["PageIsOrgTeams"] = true
 	ctx.Data["Team"] = t
@@ -318,6 +341,7 @@ func EditTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		}
 	}
 	t.CanCreateOrgRepo = form.CanCreateOrgRepo
+	t.ReviewAssignment, _ = models.TeamReviewAssignmentFromName(form.ReviewAssignment)
 
 	if ctx.HasError() {
 		ctx.HTML(200, tplTeamNew)
@@ -339,6 +363,7 @@ func EditTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		}
 		return
 	}
+	ctx.Audit(models.AuditTeamUpdate, t, audit.TeamDiff(&before, t))
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + t.LowerName)
 }
 
@@ -347,6 +372,7 @@ func DeleteTeam(ctx *context.Context) {
 	if err := models.DeleteTeam(ctx.Org.Team); err != nil {
 		ctx.Flash.Error("DeleteTeam: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditTeamDelete, ctx.Org.Team, nil)
 		ctx.Flash.Success(ctx.Tr("org.teams.delete_team_success"))
 	}
 
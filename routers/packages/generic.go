@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"net/http"
+	"regexp"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+)
+
+var genericPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+\-]*$`)
+
+// DownloadGenericFile serves a file of a generic package
+func DownloadGenericFile(ctx *context.Context) {
+	pv, pf := loadPackageFile(ctx, models.PackageGeneric, ctx.Params(":packagename"), ctx.Params(":packageversion"), ctx.Params(":filename"))
+	if pf == nil {
+		return
+	}
+	serveFile(ctx, pv, pf)
+}
+
+// UploadGenericFile adds the request body as file to a version of a generic package,
+// existing files can't be replaced
+func UploadGenericFile(ctx *context.Context) {
+	name, version, filename := ctx.Params(":packagename"), ctx.Params(":packageversion"), ctx.Params(":filename")
+	for _, s := range []string{name, version, filename} {
+		if !genericPattern.MatchString(s) {
+			apiError(ctx, http.StatusBadRequest, "invalid package name, version or filename")
+			return
+		}
+	}
+
+	body := ctx.Req.Body().ReadCloser()
+	defer body.Close()
+	content, err := packages.NewContentStore().Put(body)
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+
+	if _, err = publish(ctx, &models.PackageUpload{
+		Type:    models.PackageGeneric,
+		Name:    name,
+		Version: version,
+	}, filename, content); err != nil {
+		uploadError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusCreated)
+}
+
+// DeleteGenericFile deletes a file of a generic package, the version is deleted with its
+// last file
+func DeleteGenericFile(ctx *context.Context) {
+	pv, pf := loadPackageFile(ctx, models.PackageGeneric, ctx.Params(":packagename"), ctx.Params(":packageversion"), ctx.Params(":filename"))
+	if pf == nil {
+		return
+	}
+	if err := packages.DeleteFile(pv, pf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/packages/maven"
+)
+
+// dispatchMaven routes a request of the Maven repository, group ids are paths of
+// arbitrary depth and can't be matched by the router.
+func dispatchMaven(ctx *context.Context) {
+	path, err := maven.ParsePath(ctx.Params("*"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	switch ctx.Req.Method {
+	case "GET", "HEAD":
+		if path.IsMetadata() {
+			mavenMetadata(ctx, path)
+		} else {
+			downloadMavenFile(ctx, path)
+		}
+	case "PUT":
+		if checkPackageAccess(ctx, true) {
+			uploadMavenFile(ctx, path)
+		}
+	default:
+		apiError(ctx, http.StatusMethodNotAllowed, "unsupported request")
+	}
+}
+
+// mavenChecksum returns the checksum of the file with the algorithm of the path
+func mavenChecksum(pf *models.PackageFile, algorithm string) string {
+	switch algorithm {
+	case "md5":
+		return pf.HashMD5
+	case "sha1":
+		return pf.HashSHA1
+	case "sha256":
+		return pf.HashSHA256
+	case "sha512":
+		return pf.HashSHA512
+	}
+	return ""
+}
+
+// mavenMetadata serves the maven-metadata.xml listing the versions of the artifact, the
+// file is generated from the published versions
+func mavenMetadata(ctx *context.Context, path *maven.Path) {
+	p, err := models.GetPackage(packageOwner(ctx).ID, models.PackageMaven, path.PackageName())
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "package not found")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	names := make([]string, 0, len(versions))
+	for _, pv := range versions {
+		names = append(names, pv.Version)
+	}
+
+	data, err := maven.NewMetadataXML(path.GroupID, path.ArtifactID, names, p.UpdatedUnix.AsTime())
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(path.Checksum) > 0 {
+		var sum []byte
+		switch path.Checksum {
+		case "md5":
+			hash := md5.Sum(data)
+			sum = hash[:]
+		case "sha1":
+			hash := sha1.Sum(data)
+			sum = hash[:]
+		case "sha256":
+			hash := sha256.Sum256(data)
+			sum = hash[:]
+		case "sha512":
+			hash := sha512.Sum512(data)
+			sum = hash[:]
+		}
+		ctx.PlainText(http.StatusOK, []byte(hex.EncodeToString(sum)))
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	if _, err = ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+func downloadMavenFile(ctx *context.Context, path *maven.Path) {
+	pv, pf := loadPackageFile(ctx, models.PackageMaven, path.PackageName(), path.Version, path.Filename)
+	if pf == nil {
+		return
+	}
+	if len(path.Checksum) > 0 {
+		ctx.PlainText(http.StatusOK, []byte(mavenChecksum(pf, path.Checksum)))
+		return
+	}
+	serveFile(ctx, pv, pf)
+}
+
+// uploadMavenFile stores a file of a version of the artifact. Uploaded checksums are
+// verified instead of being stored and uploaded metadata is ignored, it is generated from
+// the published versions.
+func uploadMavenFile(ctx *context.Context, path *maven.Path) {
+	body := ctx.Req.Body().ReadCloser()
+	defer body.Close()
+
+	if path.IsMetadata() {
+		if _, err := io.Copy(ioutil.Discard, body); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	if len(path.Checksum) > 0 {
+		_, pf := loadPackageFile(ctx, models.PackageMaven, path.PackageName(), path.Version, path.Filename)
+		if pf == nil {
+			return
+		}
+		checksum, err := ioutil.ReadAll(io.LimitReader(body, 256))
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if !strings.EqualFold(strings.TrimSpace(string(checksum)), mavenChecksum(pf, path.Checksum)) {
+			apiError(ctx, http.StatusBadRequest, "checksum does not match")
+			return
+		}
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	content, err := packages.NewContentStore().Put(body)
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+
+	upload := &models.PackageUpload{
+		Type:    models.PackageMaven,
+		Name:    path.PackageName(),
+		Version: path.Version,
+	}
+	if strings.HasSuffix(path.Filename, ".pom") {
+		if upload.Metadata, err = readPOMMetadata(content); err != nil {
+			log.Warn("Invalid POM %s of %s: %v", path.Filename, upload.Name, err)
+		}
+	}
+
+	if _, err = publish(ctx, upload, path.Filename, content); err != nil {
+		uploadError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusCreated)
+}
+
+// readPOMMetadata returns the JSON encoded metadata read from the stored POM
+func readPOMMetadata(content *models.PackageFile) (string, error) {
+	file, err := packages.NewContentStore().Get(content.HashSHA256)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	metadata, err := maven.ParsePOM(file)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(metadata)
+	return string(data), err
+}
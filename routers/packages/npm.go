@@ -0,0 +1,158 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var npmTarballPattern = regexp.MustCompile(`^(.+)/-/([^/]+)/([^/]+)$`)
+
+// dispatchNpm routes a request of the npm registry, scoped package names contain a slash
+// and can't be matched by the router.
+func dispatchNpm(ctx *context.Context) {
+	path := ctx.Params("*")
+	if match := npmTarballPattern.FindStringSubmatch(path); match != nil {
+		if ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD" {
+			downloadNpmTarball(ctx, match[1], match[2], match[3])
+			return
+		}
+	} else if npm.IsValidName(path) {
+		switch ctx.Req.Method {
+		case "GET", "HEAD":
+			npmPackageDocument(ctx, path)
+			return
+		case "PUT":
+			if checkPackageAccess(ctx, true) {
+				publishNpmPackage(ctx, path)
+			}
+			return
+		}
+	}
+	apiError(ctx, http.StatusNotFound, "unsupported request")
+}
+
+// npmTarballURL returns the URL clients download the tarball of a version from
+func npmTarballURL(owner *models.User, name, version, filename string) string {
+	return setting.AppURL + "api/packages/" + url.PathEscape(owner.Name) + "/npm/" + name + "/-/" + url.PathEscape(version) + "/" + url.PathEscape(filename)
+}
+
+func npmPackageDocument(ctx *context.Context, name string) {
+	owner := packageOwner(ctx)
+	p, err := models.GetPackage(owner.ID, models.PackageNpm, name)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "package not found")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	doc := &npm.PackageDocument{
+		ID:       p.Name,
+		Name:     p.Name,
+		DistTags: make(map[string]string),
+		Versions: make(map[string]*npm.PackageVersion, len(versions)),
+		Time: map[string]time.Time{
+			"created":  p.CreatedUnix.AsTime(),
+			"modified": p.UpdatedUnix.AsTime(),
+		},
+	}
+	for _, pv := range versions {
+		files, err := models.GetPackageFiles(pv.ID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		} else if len(files) == 0 {
+			continue
+		}
+
+		v := &npm.PackageVersion{
+			ID:      p.Name + "@" + pv.Version,
+			Name:    p.Name,
+			Version: pv.Version,
+			Dist: &npm.Distribution{
+				Integrity: npm.Integrity(files[0].HashSHA512),
+				Shasum:    files[0].HashSHA1,
+				Tarball:   npmTarballURL(owner, p.Name, pv.Version, files[0].Name),
+			},
+		}
+		if len(pv.Metadata) > 0 {
+			if err = json.Unmarshal([]byte(pv.Metadata), &v.Metadata); err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		// The most recently published version is the latest one
+		doc.Versions[pv.Version] = v
+		doc.DistTags["latest"] = pv.Version
+		doc.Description = v.Description
+		doc.Time[pv.Version] = pv.CreatedUnix.AsTime()
+	}
+	ctx.JSON(http.StatusOK, doc)
+}
+
+func downloadNpmTarball(ctx *context.Context, name, version, filename string) {
+	pv, pf := loadPackageFile(ctx, models.PackageNpm, name, version, filename)
+	if pf == nil {
+		return
+	}
+	serveFile(ctx, pv, pf)
+}
+
+// publishNpmPackage publishes a new version of an npm package, published versions can't
+// be replaced
+func publishNpmPackage(ctx *context.Context, name string) {
+	body := ctx.Req.Body().ReadCloser()
+	defer body.Close()
+	p, err := npm.ParsePackage(body)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	} else if p.Name != name {
+		apiError(ctx, http.StatusBadRequest, "package name does not match the request")
+		return
+	}
+
+	metadata, err := json.Marshal(p.Metadata)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	content, err := packages.NewContentStore().Put(bytes.NewReader(p.Data))
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+
+	if _, err = publish(ctx, &models.PackageUpload{
+		Type:       models.PackageNpm,
+		Name:       p.Name,
+		Version:    p.Version,
+		Metadata:   string(metadata),
+		NewVersion: true,
+	}, p.Filename, content); err != nil {
+		uploadError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, map[string]interface{}{"ok": true})
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package packages implements the endpoints of the package registry served under
+// /api/packages/{owner}. Every package type is published and installed with the protocol
+// of its native clients.
+package packages
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gitea.com/macaron/macaron"
+)
+
+// RegisterRoutes registers the registry routes, they are served under /api/packages.
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Group("/:username", func() {
+		m.Group("/generic", func() {
+			m.Combo("/:packagename/:packageversion/:filename").
+				Get(reqPackageAccess(false), DownloadGenericFile).
+				Put(reqPackageAccess(true), UploadGenericFile).
+				Delete(reqPackageAccess(true), DeleteGenericFile)
+		})
+		m.Group("/npm", func() {
+			m.Any("/*", reqPackageAccess(false), dispatchNpm)
+		})
+		m.Group("/maven", func() {
+			m.Any("/*", reqPackageAccess(false), dispatchMaven)
+		})
+		m.Group("/pypi", func() {
+			m.Post("", reqPackageAccess(true), UploadPyPIFile)
+			m.Post("/", reqPackageAccess(true), UploadPyPIFile)
+			m.Get("/simple/:packagename", reqPackageAccess(false), PyPIPackageIndex)
+			m.Get("/simple/:packagename/", reqPackageAccess(false), PyPIPackageIndex)
+			m.Get("/files/:packagename/:packageversion/:filename", reqPackageAccess(false), DownloadPyPIFile)
+		})
+	}, assignOwner)
+}
+
+// Authenticate makes sure the registry is enabled and asks anonymous clients to
+// authenticate if the instance requires signing in
+func Authenticate(ctx *context.Context) {
+	if !setting.Packages.Enabled {
+		ctx.NotFound("", nil)
+		return
+	}
+	if !ctx.IsSigned && setting.Service.RequireSignInView {
+		apiError(ctx, http.StatusUnauthorized, "authentication required")
+	}
+}
+
+// assignOwner loads the user or organization owning the packages of the request
+func assignOwner(ctx *context.Context) {
+	owner, err := models.GetUserByName(ctx.Params(":username"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	ctx.Data["PackageOwner"] = owner
+}
+
+func packageOwner(ctx *context.Context) *models.User {
+	return ctx.Data["PackageOwner"].(*models.User)
+}
+
+// reqPackageAccess answers the request with an error if the client may not access the
+// packages of the owner
+func reqPackageAccess(write bool) macaron.Handler {
+	return func(ctx *context.Context) {
+		checkPackageAccess(ctx, write)
+	}
+}
+
+// checkPackageAccess answers the request with an error and returns false if the client may
+// not access the packages of the owner. Publishing and deleting packages requires an
+// access token.
+func checkPackageAccess(ctx *context.Context, write bool) bool {
+	owner := packageOwner(ctx)
+	if !packages.CanRead(owner, ctx.User) {
+		if ctx.User == nil {
+			apiError(ctx, http.StatusUnauthorized, "authentication required")
+		} else {
+			apiError(ctx, http.StatusNotFound, "package owner not found")
+		}
+		return false
+	}
+	if !write {
+		return true
+	}
+
+	if ctx.User == nil || ctx.Data["IsApiToken"] != true {
+		apiError(ctx, http.StatusUnauthorized, "authentication with an access token required")
+		return false
+	}
+	allowed, err := packages.CanWrite(owner, ctx.User)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return false
+	} else if !allowed {
+		apiError(ctx, http.StatusForbidden, "no permission to publish packages of "+owner.Name)
+		return false
+	}
+	return true
+}
+
+// apiError answers the request with the status and a plain text message, internal errors
+// are logged instead of being shown to the client
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	message := fmt.Sprint(obj)
+	switch status {
+	case http.StatusInternalServerError:
+		log.Error("%v", obj)
+		message = "internal server error"
+	case http.StatusUnauthorized:
+		ctx.Resp.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, setting.AppName))
+	}
+	ctx.PlainText(status, []byte(message))
+}
+
+// uploadError answers a failed upload of a package file
+func uploadError(ctx *context.Context, err error) {
+	switch {
+	case err == packages.ErrFileTooLarge:
+		apiError(ctx, http.StatusRequestEntityTooLarge, err)
+	case models.IsErrPackageFileAlreadyExist(err), models.IsErrPackageVersionAlreadyExist(err):
+		apiError(ctx, http.StatusConflict, "package file or version already exists")
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// loadPackageFile loads the file of the package version, it answers the request with an
+// error and returns nil if it does not exist
+func loadPackageFile(ctx *context.Context, t models.PackageType, name, version, filename string) (*models.PackageVersion, *models.PackageFile) {
+	pv, err := models.GetPackageVersionByName(packageOwner(ctx).ID, t, name, version)
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "package version not found")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+	pf, err := models.GetPackageFile(pv.ID, filename)
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "package file not found")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil, nil
+	}
+	return pv, pf
+}
+
+// serveFile writes the content of the package file and counts the download of its version
+func serveFile(ctx *context.Context, pv *models.PackageVersion, pf *models.PackageFile) {
+	file, err := packages.NewContentStore().Get(pf.HashSHA256)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer file.Close()
+
+	if ctx.Req.Method == "GET" {
+		if err = models.IncrementPackageDownloadCount(pv.ID); err != nil {
+			log.Error("IncrementPackageDownloadCount: %v", err)
+		}
+	}
+	ctx.ServeContent(pf.Name, file, pf.CreatedUnix.AsTime())
+}
+
+// publish stores the uploaded content and adds it as a file to the package version
+func publish(ctx *context.Context, u *models.PackageUpload, filename string, content *models.PackageFile) (*models.PackageVersion, error) {
+	content.Name = filename
+	u.OwnerID = packageOwner(ctx).ID
+	u.CreatorID = ctx.User.ID
+	u.File = content
+	return packages.Publish(u)
+}
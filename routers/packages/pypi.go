@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/packages/pypi"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const tplPyPISimple base.TplName = "api/packages/pypi/simple"
+
+// pypiFile represents a distribution file in the package index
+type pypiFile struct {
+	*models.PackageFile
+	Version        string
+	RequiresPython string
+}
+
+// PyPIPackageIndex renders the links to the distribution files of a project as defined
+// by the simple repository API
+func PyPIPackageIndex(ctx *context.Context) {
+	name := pypi.NormalizeName(ctx.Params(":packagename"))
+	p, err := models.GetPackage(packageOwner(ctx).ID, models.PackagePyPI, name)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, "package not found")
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	versions, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	var files []*pypiFile
+	for _, pv := range versions {
+		var metadata pypi.Metadata
+		if len(pv.Metadata) > 0 {
+			if err = json.Unmarshal([]byte(pv.Metadata), &metadata); err != nil {
+				apiError(ctx, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		pfs, err := models.GetPackageFiles(pv.ID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		for _, pf := range pfs {
+			files = append(files, &pypiFile{PackageFile: pf, Version: pv.Version, RequiresPython: metadata.RequiresPython})
+		}
+	}
+
+	ctx.Data["RegistryURL"] = setting.AppURL + "api/packages/" + url.PathEscape(packageOwner(ctx).Name) + "/pypi"
+	ctx.Data["PackageName"] = p.Name
+	ctx.Data["PackageFiles"] = files
+	ctx.HTML(http.StatusOK, tplPyPISimple)
+}
+
+// DownloadPyPIFile serves a distribution file of a project
+func DownloadPyPIFile(ctx *context.Context) {
+	name := pypi.NormalizeName(ctx.Params(":packagename"))
+	pv, pf := loadPackageFile(ctx, models.PackagePyPI, name, ctx.Params(":packageversion"), ctx.Params(":filename"))
+	if pf == nil {
+		return
+	}
+	serveFile(ctx, pv, pf)
+}
+
+// UploadPyPIFile stores a distribution file uploaded with the legacy upload API used by
+// twine, the metadata of its version is updated with the metadata sent with the file
+func UploadPyPIFile(ctx *context.Context) {
+	file, header, err := ctx.Req.FormFile("content")
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, "missing distribution file")
+		return
+	}
+	defer file.Close()
+
+	name, version := ctx.Req.FormValue("name"), ctx.Req.FormValue("version")
+	if !pypi.IsValidName(name) {
+		apiError(ctx, http.StatusBadRequest, "invalid project name")
+		return
+	} else if !pypi.IsValidVersion(version) {
+		apiError(ctx, http.StatusBadRequest, "invalid version")
+		return
+	} else if !pypi.IsValidFilename(header.Filename) {
+		apiError(ctx, http.StatusBadRequest, "invalid distribution filename")
+		return
+	}
+
+	content, err := packages.NewContentStore().Put(file)
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+	if digest := ctx.Req.FormValue("sha256_digest"); len(digest) > 0 && !strings.EqualFold(digest, content.HashSHA256) {
+		if err = packages.RemoveUnreferencedContent(content.HashSHA256); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		apiError(ctx, http.StatusBadRequest, "distribution file does not match the digest")
+		return
+	}
+
+	metadata, err := json.Marshal(&pypi.Metadata{
+		Summary:        ctx.Req.FormValue("summary"),
+		Description:    ctx.Req.FormValue("description"),
+		Author:         ctx.Req.FormValue("author"),
+		License:        ctx.Req.FormValue("license"),
+		HomepageURL:    ctx.Req.FormValue("home_page"),
+		RequiresPython: ctx.Req.FormValue("requires_python"),
+		RequiresDist:   ctx.Req.Form["requires_dist"],
+		Keywords:       ctx.Req.FormValue("keywords"),
+	})
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if _, err = publish(ctx, &models.PackageUpload{
+		Type:     models.PackagePyPI,
+		Name:     pypi.NormalizeName(name),
+		Version:  version,
+		Metadata: string(metadata),
+	}, header.Filename, content); err != nil {
+		uploadError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusCreated)
+}
@@ -92,6 +92,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/manager/shutdown", Shutdown)
 		m.Post("/manager/restart", Restart)
 		m.Post("/manager/flush-queues", bind(private.FlushOptions{}), FlushQueues)
+		m.Post("/manager/reindex-code", bind(private.ReindexOptions{}), ReindexCode)
+		m.Post("/manager/reindex-commits", bind(private.ReindexOptions{}), ReindexCommits)
 
 	}, CheckInternalToken)
 }
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"gitea.com/macaron/macaron"
+	"golang.org/x/crypto/ssh"
 )
 
 // UpdatePublicKeyInRepo update public key and deploy key updates
@@ -48,11 +49,33 @@ func UpdatePublicKeyInRepo(ctx *macaron.Context) {
 }
 
 // AuthorizedPublicKeyByContent searches content as prefix (leak e-mail part)
-// and returns public key found.
+// and returns public key found. For SSH certificates, as passed to the
+// AuthorizedPrincipalsCommand, it returns the authorized principal instead.
 func AuthorizedPublicKeyByContent(ctx *macaron.Context) {
 	content := ctx.Query("content")
 
-	publicKey, err := models.SearchPublicKeyByContent(content)
+	var publicKey *models.PublicKey
+	var err error
+	if pk, _, _, _, parseErr := ssh.ParseAuthorizedKey([]byte(content)); parseErr == nil {
+		if cert, ok := pk.(*ssh.Certificate); ok {
+			// OpenSSH has already enforced the source-address option
+			publicKey, err = models.CheckSSHCertificate(cert, nil)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if models.IsErrSSHCertificateInvalid(err) {
+					status = http.StatusForbidden
+				}
+				ctx.JSON(status, map[string]interface{}{
+					"err": err.Error(),
+				})
+				return
+			}
+			ctx.PlainText(http.StatusOK, []byte(publicKey.AuthorizedString()))
+			return
+		}
+	}
+
+	publicKey, err = models.SearchPublicKeyByContent(content)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
 			"err": err.Error(),
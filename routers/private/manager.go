@@ -6,11 +6,16 @@ package private
 
 import (
 	"net/http"
+	"strings"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/graceful"
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
 
 	"gitea.com/macaron/macaron"
 )
@@ -39,3 +44,59 @@ func FlushQueues(ctx *macaron.Context, opts private.FlushOptions) {
 	}
 	ctx.PlainText(http.StatusOK, []byte("success"))
 }
+
+// ReindexCode drops repositories from the code indexer and indexes them again,
+// all of them unless a repository is given
+func ReindexCode(ctx *macaron.Context, opts private.ReindexOptions) {
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
+			"err": "The code indexer is not enabled",
+		})
+		return
+	}
+	reindex(ctx, opts, code_indexer.ReindexAllRepos, code_indexer.ReindexRepo)
+}
+
+// ReindexCommits drops repositories from the commit indexer and indexes them
+// again, all of them unless a repository is given
+func ReindexCommits(ctx *macaron.Context, opts private.ReindexOptions) {
+	if !setting.Indexer.CommitIndexerEnabled {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
+			"err": "The commit indexer is not enabled",
+		})
+		return
+	}
+	reindex(ctx, opts, commit_indexer.ReindexAllRepos, commit_indexer.ReindexRepo)
+}
+
+func reindex(ctx *macaron.Context, opts private.ReindexOptions, reindexAll func(), reindexRepo func(*models.Repository, ...chan<- error)) {
+	if len(opts.Repo) == 0 {
+		reindexAll()
+		ctx.PlainText(http.StatusOK, []byte("success"))
+		return
+	}
+
+	parts := strings.SplitN(opts.Repo, "/", 2)
+	if len(parts) != 2 {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
+			"err": "Repository must be given as owner/name",
+		})
+		return
+	}
+	repo, err := models.GetRepositoryByOwnerAndName(parts[0], parts[1])
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.JSON(http.StatusNotFound, map[string]interface{}{
+				"err": err.Error(),
+			})
+			return
+		}
+		log.Error("GetRepositoryByOwnerAndName: %v", err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": err.Error(),
+		})
+		return
+	}
+	reindexRepo(repo)
+	ctx.PlainText(http.StatusOK, []byte("success"))
+}
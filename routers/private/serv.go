@@ -46,7 +46,7 @@ func ServNoCommand(ctx *macaron.Context) {
 	}
 	results.Key = key
 
-	if key.Type == models.KeyTypeUser {
+	if key.Type == models.KeyTypeUser || key.Type == models.KeyTypePrincipal {
 		user, err := models.GetUserByID(key.OwnerID)
 		if err != nil {
 			if models.IsErrUserNotExist(err) {
@@ -239,6 +239,24 @@ func ServCommand(ctx *macaron.Context) {
 			return
 		}
 		results.UserName = user.Name
+
+		mustEnroll, err := user.MustEnrollTwoFactor()
+		if err != nil {
+			log.Error("Unable to check two-factor authentication of %-v Error: %v", user, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"results": results,
+				"type":    "InternalServerError",
+				"err":     fmt.Sprintf("Unable to check two-factor authentication of user %d:%s Error: %v", user.ID, user.Name, err),
+			})
+			return
+		} else if mustEnroll {
+			ctx.JSON(http.StatusForbidden, map[string]interface{}{
+				"results": results,
+				"type":    "ErrTwoFactorRequired",
+				"err":     models.ErrTwoFactorRequired{}.Error(),
+			})
+			return
+		}
 	}
 
 	// Don't allow pushing if the repo is archived
@@ -277,6 +295,14 @@ func ServCommand(ctx *macaron.Context) {
 			userMode := perm.UnitAccessMode(unitType)
 
 			if userMode < mode {
+				if perm.TwoFactorRequired {
+					ctx.JSON(http.StatusForbidden, map[string]interface{}{
+						"results": results,
+						"type":    "ErrTwoFactorRequired",
+						"err":     models.ErrTwoFactorRequired{OrgName: ownerName}.Error(),
+					})
+					return
+				}
 				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"results": results,
 					"type":    "ErrUnauthorized",
@@ -0,0 +1,243 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/ci"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplCIRuns      base.TplName = "repo/ci/list"
+	tplCIRun       base.TplName = "repo/ci/view"
+	tplCIRunners   base.TplName = "repo/settings/ci_runners"
+	ciRunsPageSize              = 20
+)
+
+// MustEnableCI makes sure CI is enabled
+func MustEnableCI(ctx *context.Context) {
+	if !setting.CI.Enabled {
+		ctx.NotFound("MustEnableCI", nil)
+	}
+}
+
+// CIRuns shows the runs of the repository
+func CIRuns(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.ci")
+	ctx.Data["PageIsCI"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	status := models.ParseCIStatus(ctx.Query("status"))
+	runs, total, err := models.FindCIRuns(&models.FindCIRunsOptions{
+		ListOptions: models.ListOptions{Page: page, PageSize: ciRunsPageSize},
+		RepoID:      ctx.Repo.Repository.ID,
+		Status:      status,
+	})
+	if err != nil {
+		ctx.ServerError("FindCIRuns", err)
+		return
+	}
+	for _, run := range runs {
+		run.Repo = ctx.Repo.Repository
+		if err = run.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+	ctx.Data["Runs"] = runs
+	if status > 0 {
+		ctx.Data["Status"] = status.String()
+	}
+
+	pager := context.NewPagination(int(total), ciRunsPageSize, page, 5)
+	pager.AddParam(ctx, "status", "Status")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplCIRuns)
+}
+
+// retrieveCIRun loads the run of the request
+func retrieveCIRun(ctx *context.Context) *models.CIRun {
+	run, err := models.GetCIRunByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrCIRunNotExist(err) {
+			ctx.NotFound("GetCIRunByIndex", err)
+		} else {
+			ctx.ServerError("GetCIRunByIndex", err)
+		}
+		return nil
+	}
+	run.Repo = ctx.Repo.Repository
+	if err = run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	return run
+}
+
+// ViewCIRun shows the jobs of a run with the logs of their steps
+func ViewCIRun(ctx *context.Context) {
+	run := retrieveCIRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["Title"] = fmt.Sprintf("%s #%d", run.WorkflowName, run.Index)
+	ctx.Data["PageIsCI"] = true
+	ctx.Data["Run"] = run
+	ctx.Data["CanCancel"] = !run.Status.IsDone() && ctx.Repo.CanWrite(models.UnitTypeCode)
+	ctx.Data["CanApprove"] = run.NeedApproval && !run.Status.IsDone() && ctx.Repo.CanWrite(models.UnitTypeCode)
+
+	jobs, err := models.GetCIJobs(run.ID)
+	if err != nil {
+		ctx.ServerError("GetCIJobs", err)
+		return
+	}
+	logs := make(map[int64]string)
+	for _, job := range jobs {
+		job.Run = run
+		if err = job.LoadSteps(); err != nil {
+			ctx.ServerError("LoadSteps", err)
+			return
+		}
+		for _, step := range job.Steps {
+			lines, err := ci.ReadLog(job, step.Index)
+			if err != nil {
+				ctx.ServerError("ReadLog", err)
+				return
+			}
+			logs[step.ID] = strings.Join(lines, "\n")
+		}
+	}
+	ctx.Data["Jobs"] = jobs
+	ctx.Data["StepLogs"] = logs
+
+	ctx.HTML(200, tplCIRun)
+}
+
+// CIStepLog serves the log of a step as plain text
+func CIStepLog(ctx *context.Context) {
+	run := retrieveCIRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	job, err := models.GetCIJobByID(ctx.ParamsInt64(":jobid"))
+	if err != nil || job.RunID != run.ID {
+		ctx.NotFound("GetCIJobByID", err)
+		return
+	}
+	lines, err := ci.ReadLog(job, ctx.ParamsInt(":step"))
+	if err != nil {
+		ctx.ServerError("ReadLog", err)
+		return
+	}
+	ctx.PlainText(200, []byte(strings.Join(lines, "\n")))
+}
+
+// CancelCIRun cancels the jobs of a run which are not done yet
+func CancelCIRun(ctx *context.Context) {
+	run := retrieveCIRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if !run.Status.IsDone() {
+		if err := ci.CancelRun(run); err != nil {
+			ctx.ServerError("CancelRun", err)
+			return
+		}
+		log.Trace("CI run %d of %s cancelled by %s", run.Index, ctx.Repo.Repository.FullName(), ctx.User.Name)
+	}
+	ctx.Redirect(run.HTMLURL())
+}
+
+// ApproveCIRun lets the jobs of a run of a pull request from a fork run
+func ApproveCIRun(ctx *context.Context) {
+	run := retrieveCIRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if run.NeedApproval && !run.Status.IsDone() {
+		if err := ci.ApproveRun(run, ctx.User); err != nil {
+			ctx.ServerError("ApproveRun", err)
+			return
+		}
+		log.Trace("CI run %d of %s approved by %s", run.Index, ctx.Repo.Repository.FullName(), ctx.User.Name)
+	}
+	ctx.Redirect(run.HTMLURL())
+}
+
+func loadCIRunners(ctx *context.Context, repoID int64) {
+	token, err := models.GetCIRunnerToken(repoID)
+	if err != nil {
+		ctx.ServerError("GetCIRunnerToken", err)
+		return
+	}
+	ctx.Data["CIRunnerToken"] = token.Token
+	ctx.Data["CIRegisterURL"] = setting.AppURL + "api/ci/runners/register"
+
+	runners, err := models.GetCIRunners(repoID)
+	if err != nil {
+		ctx.ServerError("GetCIRunners", err)
+		return
+	}
+	ctx.Data["CIRunners"] = runners
+}
+
+// CIRunners shows the registration token and the runners of the repository
+func CIRunners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.ci_runners")
+	ctx.Data["PageIsSettingsCIRunners"] = true
+	ctx.Data["BaseLink"] = ctx.Repo.RepoLink + "/settings/ci/runners"
+
+	loadCIRunners(ctx, ctx.Repo.Repository.ID)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tplCIRunners)
+}
+
+// ResetCIRunnerToken replaces the registration token of the repository
+func ResetCIRunnerToken(ctx *context.Context) {
+	token, err := models.NewCIRunnerToken(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("NewCIRunnerToken", err)
+		return
+	}
+	ctx.Audit(models.AuditRepoCIRunnerTokenReset, ctx.Repo.Repository, nil)
+	ctx.Flash.Success(ctx.Tr("repo.settings.ci_runners.reset_token_success"))
+	ctx.Flash.Info(token.Token)
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/ci/runners")
+}
+
+// DeleteCIRunner deletes a runner of the repository
+func DeleteCIRunner(ctx *context.Context) {
+	runner, err := models.GetCIRunnerByID(ctx.QueryInt64("id"))
+	if err == nil && runner.RepoID != ctx.Repo.Repository.ID {
+		err = models.ErrCIRunnerNotExist{ID: runner.ID}
+	}
+	if err == nil {
+		err = ci.DeleteRunner(runner)
+	}
+	if err != nil {
+		ctx.Flash.Error("DeleteCIRunner: " + err.Error())
+	} else {
+		ctx.Audit(models.AuditRepoCIRunnerDelete, ctx.Repo.Repository, audit.Diff{"runner": runner.Name})
+		ctx.Flash.Success(ctx.Tr("repo.settings.ci_runners.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/settings/ci/runners",
+	})
+}
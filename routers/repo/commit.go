@@ -6,6 +6,7 @@
 package repo
 
 import (
+	"container/list"
 	"path"
 	"strings"
 
@@ -15,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/gitgraph"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/gitdiff"
@@ -46,6 +48,7 @@ func Commits(ctx *context.Context) {
 		return
 	}
 	ctx.Data["PageIsViewCode"] = true
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
 
 	commitsCount, err := ctx.Repo.GetCommitsCount()
 	if err != nil {
@@ -131,6 +134,15 @@ func SearchCommits(ctx *context.Context) {
 		return
 	}
 
+	ctx.Data["Username"] = ctx.Repo.Owner.Name
+	ctx.Data["Reponame"] = ctx.Repo.Repository.Name
+	ctx.Data["Keyword"] = query
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled
+	if setting.Indexer.CommitIndexerEnabled {
+		searchIndexedCommits(ctx, query)
+		return
+	}
+
 	all := ctx.QueryBool("all")
 	opts := git.NewSearchCommitsOptions(query, all)
 	commits, err := ctx.Repo.Commit.SearchCommits(opts)
@@ -143,17 +155,57 @@ func SearchCommits(ctx *context.Context) {
 	commits = models.ParseCommitsWithStatus(commits, ctx.Repo.Repository)
 	ctx.Data["Commits"] = commits
 
-	ctx.Data["Keyword"] = query
 	if all {
 		ctx.Data["All"] = "checked"
 	}
-	ctx.Data["Username"] = ctx.Repo.Owner.Name
-	ctx.Data["Reponame"] = ctx.Repo.Repository.Name
 	ctx.Data["CommitCount"] = commits.Len()
 	ctx.Data["Branch"] = ctx.Repo.BranchName
 	ctx.HTML(200, tplCommits)
 }
 
+// searchIndexedCommits renders the commits of all the branches matching the
+// query according to the commit indexer
+func searchIndexedCommits(ctx *context.Context, query string) {
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	total, results, err := commit_indexer.PerformSearch([]int64{ctx.Repo.Repository.ID}, query, page, git.CommitsRangeSize)
+	if err != nil {
+		if !commit_indexer.IsErrInvalidQuery(err) {
+			ctx.ServerError("PerformSearch", err)
+			return
+		}
+		ctx.Data["QueryError"] = err.Error()
+	}
+
+	commits := list.New()
+	for _, result := range results {
+		commit, err := ctx.Repo.GitRepo.GetCommit(result.SHA)
+		if err != nil {
+			// the commit may have been garbage collected since it was indexed
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			ctx.ServerError("GetCommit", err)
+			return
+		}
+		commits.PushBack(commit)
+	}
+	commits = models.ValidateCommitsWithEmails(commits)
+	commits = models.ParseCommitsWithSignature(commits)
+	commits = models.ParseCommitsWithStatus(commits, ctx.Repo.Repository)
+	ctx.Data["Commits"] = commits
+	ctx.Data["CommitCount"] = total
+
+	pager := context.NewPagination(int(total), git.CommitsRangeSize, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplCommits)
+}
+
 // FileHistory show a file's reversions
 func FileHistory(ctx *context.Context) {
 	ctx.Data["IsRepoToolbarCommits"] = true
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
+	"code.gitea.io/gitea/modules/auth/lockout"
 	"code.gitea.io/gitea/modules/auth/sso"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -135,6 +137,11 @@ func HTTP(ctx *context.Context) {
 		environ      []string
 	)
 
+	// Running CI jobs clone their repository with the token of the job
+	if askAuth && isPull && !isWiki && repoExist && setting.CI.Enabled && isCIJobClone(ctx, repo) {
+		askAuth = false
+	}
+
 	// check access
 	if askAuth {
 		authUsername = ctx.Req.Header.Get(setting.ReverseProxyAuthUser)
@@ -218,6 +225,16 @@ func HTTP(ctx *context.Context) {
 			}
 
 			if authUser == nil {
+				if err = lockout.Check(authUsername, ctx.RemoteAddr()); err != nil {
+					if locked, ok := err.(lockout.ErrLocked); ok {
+						ctx.Resp.Header().Set("Retry-After", strconv.FormatInt(locked.RetryAfter(), 10))
+						ctx.HandleText(http.StatusTooManyRequests, "Too many failed authentication attempts, try again later")
+					} else {
+						ctx.ServerError("Check", err)
+					}
+					return
+				}
+
 				// Check username and password
 				authUser, err = models.UserSignIn(authUsername, authPasswd)
 				if err != nil {
@@ -231,9 +248,12 @@ func HTTP(ctx *context.Context) {
 				}
 
 				if authUser == nil {
+					lockout.Fail(authUsername, ctx.RemoteAddr())
+					audit.RecordLoginFailed(authUsername, ctx.RemoteAddr(), "invalid_credentials")
 					ctx.HandleText(http.StatusUnauthorized, fmt.Sprintf("invalid credentials from %s", ctx.RemoteAddr()))
 					return
 				}
+				lockout.Succeed(authUsername)
 
 				_, err = models.GetTwoFactorByUID(authUser.ID)
 				if err == nil {
@@ -247,6 +267,17 @@ func HTTP(ctx *context.Context) {
 			}
 		}
 
+		if authUser != nil {
+			mustEnroll, err := authUser.MustEnrollTwoFactor()
+			if err != nil {
+				ctx.ServerError("MustEnrollTwoFactor", err)
+				return
+			} else if mustEnroll {
+				ctx.HandleText(http.StatusForbidden, models.ErrTwoFactorRequired{}.Error())
+				return
+			}
+		}
+
 		if repoExist {
 			perm, err := models.GetUserRepoPermission(repo, authUser)
 			if err != nil {
@@ -255,6 +286,10 @@ func HTTP(ctx *context.Context) {
 			}
 
 			if !perm.CanAccess(accessMode, unitType) {
+				if perm.TwoFactorRequired {
+					ctx.HandleText(http.StatusForbidden, models.ErrTwoFactorRequired{OrgName: repo.OwnerName}.Error())
+					return
+				}
 				ctx.HandleText(http.StatusForbidden, "User permission denied")
 				return
 			}
@@ -367,6 +402,27 @@ func HTTP(ctx *context.Context) {
 	ctx.NotFound("Smart Git HTTP", nil)
 }
 
+// isCIJobClone returns true if the password of the basic authentication is the token of
+// a running CI job of the repository
+func isCIJobClone(ctx *context.Context, repo *models.Repository) bool {
+	auths := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(auths) != 2 || auths[0] != "Basic" {
+		return false
+	}
+	_, passwd, err := base.BasicAuthDecode(auths[1])
+	if err != nil || len(passwd) == 0 {
+		return false
+	}
+	job, err := models.GetRunningCIJobByToken(passwd)
+	if err != nil {
+		if !models.IsErrCIJobNotExist(err) {
+			log.Error("GetRunningCIJobByToken: %v", err)
+		}
+		return false
+	}
+	return job.RepoID == repo.ID
+}
+
 var (
 	infoRefsCache []byte
 	infoRefsOnce  sync.Once
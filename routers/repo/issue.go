@@ -36,9 +36,10 @@ import (
 const (
 	tplAttachment base.TplName = "repo/issue/view_content/attachments"
 
-	tplIssues    base.TplName = "repo/issue/list"
-	tplIssueNew  base.TplName = "repo/issue/new"
-	tplIssueView base.TplName = "repo/issue/view"
+	tplIssues      base.TplName = "repo/issue/list"
+	tplIssueNew    base.TplName = "repo/issue/new"
+	tplIssueView   base.TplName = "repo/issue/view"
+	tplIssueImport base.TplName = "repo/issue/import"
 
 	tplReactions base.TplName = "repo/issue/view_content/reactions"
 
@@ -146,21 +147,46 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		keyword = ""
 	}
 
-	var issueIDs []int64
+	opts := &models.IssuesOptions{
+		ListOptions: models.ListOptions{
+			PageSize: setting.UI.IssuePagingNum,
+		},
+		RepoIDs:     []int64{repo.ID},
+		AssigneeID:  assigneeID,
+		PosterID:    posterID,
+		MentionedID: mentionedID,
+		MilestoneID: milestoneID,
+		IsPull:      isPullOption,
+		LabelIDs:    labelIDs,
+		SortType:    sortType,
+	}
+
+	var issueStats *models.IssueStats
 	if len(keyword) > 0 {
-		issueIDs, err = issue_indexer.SearchIssuesByKeyword([]int64{repo.ID}, keyword)
-		if err != nil {
-			ctx.ServerError("issueIndexer.Search", err)
-			return
+		query, err := issue_indexer.ParseQuery(keyword)
+		if err == nil {
+			err = query.Apply(opts, ctx.User)
 		}
-		if len(issueIDs) == 0 {
+		if err != nil {
+			if !issue_indexer.IsErrInvalidQuery(err) {
+				ctx.ServerError("ApplyQuery", err)
+				return
+			}
+			ctx.Data["QueryError"] = err.Error()
 			forceEmpty = true
+		} else if !query.IsClosed.IsNone() {
+			isShowClosed = query.IsClosed.IsTrue()
 		}
 	}
 
-	var issueStats *models.IssueStats
 	if forceEmpty {
 		issueStats = &models.IssueStats{}
+	} else if len(keyword) > 0 {
+		issueStats, err = models.CountIssueStats(opts)
+		if err != nil {
+			ctx.ServerError("CountIssueStats", err)
+			return
+		}
 	} else {
 		issueStats, err = models.GetIssueStats(&models.IssueStatsOptions{
 			RepoID:      repo.ID,
@@ -170,7 +196,6 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 			MentionedID: mentionedID,
 			PosterID:    posterID,
 			IsPull:      isPullOption,
-			IssueIDs:    issueIDs,
 		})
 		if err != nil {
 			ctx.ServerError("GetIssueStats", err)
@@ -194,22 +219,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 	if forceEmpty {
 		issues = []*models.Issue{}
 	} else {
-		issues, err = models.Issues(&models.IssuesOptions{
-			ListOptions: models.ListOptions{
-				Page:     pager.Paginater.Current(),
-				PageSize: setting.UI.IssuePagingNum,
-			},
-			RepoIDs:     []int64{repo.ID},
-			AssigneeID:  assigneeID,
-			PosterID:    posterID,
-			MentionedID: mentionedID,
-			MilestoneID: milestoneID,
-			IsClosed:    util.OptionalBoolOf(isShowClosed),
-			IsPull:      isPullOption,
-			LabelIDs:    labelIDs,
-			SortType:    sortType,
-			IssueIDs:    issueIDs,
-		})
+		opts.Page = pager.Paginater.Current()
+		opts.IsClosed = util.OptionalBoolOf(isShowClosed)
+		issues, err = models.Issues(opts)
 		if err != nil {
 			ctx.ServerError("Issues", err)
 			return
@@ -635,6 +647,10 @@ func ViewIssue(ctx *context.Context) {
 	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		if models.IsErrIssueNotExist(err) {
+			redirectTransferredIssue(ctx)
+			if ctx.Written() {
+				return
+			}
 			ctx.NotFound("GetIssueByIndex", err)
 		} else {
 			ctx.ServerError("GetIssueByIndex", err)
@@ -717,6 +733,10 @@ func ViewIssue(ctx *context.Context) {
 
 	// Get more information if it's a pull request.
 	if issue.IsPull {
+		retrieveReviewRequests(ctx, issue)
+		if ctx.Written() {
+			return
+		}
 		if issue.PullRequest.HasMerged {
 			ctx.Data["DisableStatusChange"] = issue.PullRequest.HasMerged
 			PrepareMergedViewPullInfo(ctx, issue)
@@ -874,6 +894,15 @@ func ViewIssue(ctx *context.Context) {
 				ctx.ServerError("LoadAssigneeUser", err)
 				return
 			}
+		} else if comment.Type == models.CommentTypeReviewRequest {
+			if err = comment.LoadAssigneeUser(); err != nil {
+				ctx.ServerError("LoadAssigneeUser", err)
+				return
+			}
+			if err = comment.LoadAssigneeTeam(); err != nil {
+				ctx.ServerError("LoadAssigneeTeam", err)
+				return
+			}
 		} else if comment.Type == models.CommentTypeRemoveDependency || comment.Type == models.CommentTypeAddDependency {
 			if err = comment.LoadDepIssueDetails(); err != nil {
 				ctx.ServerError("LoadDepIssueDetails", err)
@@ -1023,6 +1052,7 @@ func ViewIssue(ctx *context.Context) {
 	ctx.Data["IsIssuePoster"] = ctx.IsSigned && issue.IsPoster(ctx.User.ID)
 	ctx.Data["IsIssueWriter"] = ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull)
 	ctx.Data["IsRepoAdmin"] = ctx.IsSigned && (ctx.Repo.IsAdmin() || ctx.User.IsAdmin)
+	ctx.Data["IsCommitIndexerEnabled"] = setting.Indexer.CommitIndexerEnabled && ctx.Repo.CanRead(models.UnitTypeCode)
 	ctx.Data["LockReasons"] = setting.Repository.Issue.LockReasons
 	ctx.HTML(200, tplIssueView)
 }
@@ -0,0 +1,100 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"strconv"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/timeutil"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// BulkEditIssues applies the changes of the form to the selected issues and pull requests at once
+func BulkEditIssues(ctx *context.Context, form auth.BulkEditIssuesForm) {
+	redirectTo := ctx.Repo.RepoLink + "/issues"
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.RedirectToFirst(form.RedirectTo, redirectTo)
+		return
+	}
+
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+	for _, issue := range issues {
+		if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+			ctx.NotFound("CanWriteIssuesOrPulls", nil)
+			return
+		}
+	}
+
+	opts, err := toBulkEditIssuesOptions(form)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("repo.issues.bulk_edit.invalid"))
+		ctx.RedirectToFirst(form.RedirectTo, redirectTo)
+		return
+	}
+
+	edited, err := issue_service.BulkEdit(ctx.User, ctx.Repo.Repository, issues, opts)
+	if err != nil {
+		switch {
+		case models.IsErrDependenciesLeft(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.bulk_edit.dependencies_left"))
+		case models.IsErrIssueNotExist(err), models.IsErrLabelNotExist(err), models.IsErrMilestoneNotExist(err),
+			models.IsErrUserNotExist(err), models.IsErrUserDoesNotHaveAccessToRepo(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.bulk_edit.invalid"))
+		default:
+			ctx.ServerError("BulkEdit", err)
+			return
+		}
+		ctx.RedirectToFirst(form.RedirectTo, redirectTo)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.issues.bulk_edit.success", len(edited)))
+	ctx.RedirectToFirst(form.RedirectTo, redirectTo)
+}
+
+func toBulkEditIssuesOptions(form auth.BulkEditIssuesForm) (*models.BulkEditIssuesOptions, error) {
+	opts := &models.BulkEditIssuesOptions{
+		AddLabelIDs:       form.AddLabelIDs,
+		RemoveLabelIDs:    form.RemoveLabelIDs,
+		AddAssigneeIDs:    form.AddAssigneeIDs,
+		RemoveAssigneeIDs: form.RemoveAssigneeIDs,
+	}
+	if form.Milestone != "" {
+		milestoneID, err := strconv.ParseInt(form.Milestone, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		opts.MilestoneID = &milestoneID
+	}
+	if form.State != "" {
+		isClosed := form.State == "closed"
+		opts.IsClosed = &isClosed
+	}
+	if form.Lock != "" {
+		isLocked := form.Lock == "lock"
+		opts.IsLocked = &isLocked
+	}
+	if form.RemoveDeadline {
+		var deadlineUnix timeutil.TimeStamp
+		opts.DeadlineUnix = &deadlineUnix
+	} else if form.Deadline != "" {
+		deadline, err := time.ParseInLocation("2006-01-02", form.Deadline, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		deadlineUnix := timeutil.TimeStamp(time.Date(deadline.Year(), deadline.Month(), deadline.Day(),
+			23, 59, 59, 0, deadline.Location()).Unix())
+		opts.DeadlineUnix = &deadlineUnix
+	}
+	return opts, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// ExportIssues downloads the issues of the list filtered as in the issue list page
+func ExportIssues(ctx *context.Context) {
+	format := ctx.QueryTrim("format")
+	if format == "" {
+		format = issue_service.FormatCSV
+	} else if format != issue_service.FormatCSV && format != issue_service.FormatJSON {
+		ctx.NotFound("ExportIssues", nil)
+		return
+	}
+
+	opts := &models.IssuesOptions{
+		RepoIDs:     []int64{ctx.Repo.Repository.ID},
+		AssigneeID:  ctx.QueryInt64("assignee"),
+		MilestoneID: ctx.QueryInt64("milestone"),
+		IsPull:      util.OptionalBoolFalse,
+		SortType:    ctx.Query("sort"),
+	}
+	switch ctx.Query("type") {
+	case "created_by":
+		opts.PosterID = ctx.User.ID
+	case "mentioned":
+		opts.MentionedID = ctx.User.ID
+	}
+	if selectLabels := ctx.Query("labels"); len(selectLabels) > 0 && selectLabels != "0" {
+		labelIDs, err := base.StringsToInt64s(strings.Split(selectLabels, ","))
+		if err != nil {
+			ctx.ServerError("StringsToInt64s", err)
+			return
+		}
+		opts.LabelIDs = labelIDs
+	}
+
+	isShowClosed := ctx.Query("state") == "closed"
+	if keyword := strings.Trim(ctx.Query("q"), " "); len(keyword) > 0 && strings.IndexByte(keyword, 0) < 0 {
+		query, err := issue_indexer.ParseQuery(keyword)
+		if err == nil {
+			err = query.Apply(opts, ctx.User)
+		}
+		if err != nil {
+			if issue_indexer.IsErrInvalidQuery(err) {
+				ctx.Error(http.StatusBadRequest, err.Error())
+				return
+			}
+			ctx.ServerError("ApplyQuery", err)
+			return
+		}
+		if !query.IsClosed.IsNone() {
+			isShowClosed = query.IsClosed.IsTrue()
+		}
+	}
+	opts.IsClosed = util.OptionalBoolOf(isShowClosed)
+
+	issues, err := models.Issues(opts)
+	if err != nil {
+		ctx.ServerError("Issues", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", issue_service.ContentType(format))
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-issues.%s"`, ctx.Repo.Repository.Name, format))
+	if err = issue_service.ExportIssues(ctx.Resp, format, issues); err != nil {
+		// the headers are already written
+		log.Error("ExportIssues: %v", err)
+	}
+}
+
+// ImportIssues renders the page to upload the file of the imported issues
+func ImportIssues(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.issues.import.title")
+	ctx.Data["PageIsIssueList"] = true
+	ctx.HTML(200, tplIssueImport)
+}
+
+// ImportIssuesPost reads the uploaded file, previews its rows mapped to issues
+// and creates the issues once asked to and all the rows are valid
+func ImportIssuesPost(ctx *context.Context, form auth.ImportIssuesForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.issues.import.title")
+	ctx.Data["PageIsIssueList"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplIssueImport)
+		return
+	}
+
+	content, format := form.Content, form.Format
+	if form.File != nil {
+		file, err := form.File.Open()
+		if err != nil {
+			ctx.ServerError("Open", err)
+			return
+		}
+		defer file.Close()
+		data, err := issue_service.ReadImportFile(file)
+		if err != nil {
+			if issue_service.IsErrInvalidImport(err) {
+				ctx.RenderWithErr(ctx.Tr("repo.issues.import.invalid_file", err.(issue_service.ErrInvalidImport).Reason), tplIssueImport, nil)
+				return
+			}
+			ctx.ServerError("ReadImportFile", err)
+			return
+		}
+		content = string(data)
+		if format == "" && strings.ToLower(path.Ext(form.File.Filename)) == ".json" {
+			format = issue_service.FormatJSON
+		}
+	}
+	if format == "" {
+		format = issue_service.FormatCSV
+	}
+	if len(content) == 0 {
+		ctx.RenderWithErr(ctx.Tr("repo.issues.import.no_file"), tplIssueImport, nil)
+		return
+	}
+
+	table, err := issue_service.ParseImportFile(strings.NewReader(content), format)
+	if err != nil {
+		if issue_service.IsErrInvalidImport(err) {
+			ctx.RenderWithErr(ctx.Tr("repo.issues.import.invalid_file", err.(issue_service.ErrInvalidImport).Reason), tplIssueImport, nil)
+			return
+		}
+		ctx.ServerError("ParseImportFile", err)
+		return
+	}
+
+	fields := form.Fields
+	if len(fields) != len(table.Columns) {
+		fields = issue_service.DefaultImportMapping(table.Columns)
+	}
+	ctx.Data["Content"] = content
+	ctx.Data["Format"] = format
+	ctx.Data["Columns"] = table.Columns
+	ctx.Data["Fields"] = fields
+	ctx.Data["ImportFields"] = issue_service.ImportFields
+
+	imported, err := issue_service.PrepareImport(ctx.User, ctx.Repo.Repository, table, fields)
+	if err != nil {
+		if issue_service.IsErrInvalidImport(err) {
+			ctx.RenderWithErr(ctx.Tr("repo.issues.import.invalid_mapping", err.(issue_service.ErrInvalidImport).Reason), tplIssueImport, nil)
+			return
+		}
+		ctx.ServerError("PrepareImport", err)
+		return
+	}
+	numInvalidRows := 0
+	for _, row := range imported {
+		if len(row.Errors) > 0 {
+			numInvalidRows++
+		}
+	}
+	ctx.Data["Rows"] = imported
+	ctx.Data["NumInvalidRows"] = numInvalidRows
+
+	if form.Action != "import" || numInvalidRows > 0 {
+		ctx.HTML(200, tplIssueImport)
+		return
+	}
+
+	issues, err := issue_service.ImportIssues(ctx.User, ctx.Repo.Repository, imported)
+	if err != nil {
+		if issue_service.IsErrImportInterrupted(err) {
+			// the issues of the previous rows are created, don't let the form be submitted again
+			log.Error("ImportIssues: %v", err)
+			interrupted := err.(issue_service.ErrImportInterrupted)
+			ctx.Flash.Error(ctx.Tr("repo.issues.import.interrupted", interrupted.Row, interrupted.Created))
+			ctx.Redirect(ctx.Repo.RepoLink + "/issues")
+			return
+		}
+		ctx.ServerError("ImportIssues", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("repo.issues.import.success", len(issues)))
+	ctx.Redirect(ctx.Repo.RepoLink + "/issues")
+}
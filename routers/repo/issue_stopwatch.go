@@ -9,6 +9,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/notification"
 )
 
 // IssueStopwatch creates or stops a stopwatch for the given issue.
@@ -33,6 +34,7 @@ func IssueStopwatch(c *context.Context) {
 		c.ServerError("CreateOrStopIssueStopwatch", err)
 		return
 	}
+	notification.NotifyIssueChangeStopwatch(c.User, issue)
 
 	if showSuccessMessage {
 		c.Flash.Success(c.Tr("repo.issues.tracker_auto_close"))
@@ -57,6 +59,7 @@ func CancelStopwatch(c *context.Context) {
 		c.ServerError("CancelStopwatch", err)
 		return
 	}
+	notification.NotifyIssueChangeStopwatch(c.User, issue)
 
 	url := issue.HTMLURL()
 	c.Redirect(url, http.StatusSeeOther)
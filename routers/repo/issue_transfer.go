@@ -0,0 +1,93 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/context"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// TransferIssue moves an issue to another repository
+func TransferIssue(ctx *context.Context, form auth.TransferIssueForm) {
+	issue := GetActionIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(issue.HTMLURL())
+		return
+	}
+
+	fullName := strings.TrimSpace(form.NewRepo)
+	var newRepo *models.Repository
+	if parts := strings.SplitN(fullName, "/", 2); len(parts) == 2 {
+		var err error
+		newRepo, err = models.GetRepositoryByOwnerAndName(parts[0], parts[1])
+		if err != nil && !models.IsErrRepoNotExist(err) {
+			ctx.ServerError("GetRepositoryByOwnerAndName", err)
+			return
+		}
+	}
+	if newRepo == nil {
+		ctx.Flash.Error(ctx.Tr("repo.issues.transfer.repo_not_exist", fullName))
+		ctx.Redirect(issue.HTMLURL())
+		return
+	}
+
+	if err := issue_service.TransferIssue(ctx.User, issue, newRepo); err != nil {
+		switch {
+		case models.IsErrUserDoesNotHaveAccessToRepo(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.transfer.repo_not_exist", fullName))
+		case models.IsErrIssueCannotBeTransferred(err):
+			ctx.Flash.Error(ctx.Tr("repo.issues.transfer.not_allowed", newRepo.FullName()))
+		default:
+			ctx.ServerError("TransferIssue", err)
+			return
+		}
+		ctx.Redirect(ctx.Repo.RepoLink + "/issues/" + ctx.Params(":index"))
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.issues.transfer.success", newRepo.FullName()))
+	ctx.Redirect(issue.HTMLURL(), http.StatusSeeOther)
+}
+
+// redirectTransferredIssue redirects to the issue transferred from the requested
+// index to a repository the user can read, if any
+func redirectTransferredIssue(ctx *context.Context) {
+	issueID, err := models.LookupIssueRedirect(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if !models.IsErrIssueRedirectNotExist(err) {
+			ctx.ServerError("LookupIssueRedirect", err)
+		}
+		return
+	}
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		if !models.IsErrIssueNotExist(err) {
+			ctx.ServerError("GetIssueByID", err)
+		}
+		return
+	}
+	if err = issue.LoadRepo(); err != nil {
+		ctx.ServerError("LoadRepo", err)
+		return
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		ctx.ServerError("GetUserRepoPermission", err)
+		return
+	}
+	if !perm.CanRead(models.UnitTypeIssues) {
+		return
+	}
+	ctx.Redirect(issue.HTMLURL(), http.StatusMovedPermanently)
+}
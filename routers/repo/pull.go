@@ -827,6 +827,7 @@ func stopTimerIfAvailable(user *models.User, issue *models.Issue) error {
 		if err := models.CreateOrStopIssueStopwatch(user, issue); err != nil {
 			return err
 		}
+		notification.NotifyIssueChangeStopwatch(user, issue)
 	}
 
 	return nil
@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	issue_service "code.gitea.io/gitea/services/issue"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
 
@@ -110,3 +111,137 @@ func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 
 	ctx.Redirect(fmt.Sprintf("%s/pulls/%d#%s", ctx.Repo.RepoLink, issue.Index, comm.HashTag()))
 }
+
+// reviewerCandidate is a user or a team who can be requested to review a pull
+// request, identified by the negated ID of the team for a team
+type reviewerCandidate struct {
+	ID        int64
+	User      *models.User
+	Team      *models.Team
+	Requested bool
+}
+
+// retrieveReviewRequests finds the pending review requests of the pull
+// request, and who can be requested to review it if the user can do it
+func retrieveReviewRequests(ctx *context.Context, issue *models.Issue) {
+	requests, err := models.GetReviewRequests(issue.ID)
+	if err != nil {
+		ctx.ServerError("GetReviewRequests", err)
+		return
+	}
+	ctx.Data["ReviewRequests"] = requests
+
+	if !ctx.Repo.CanWrite(models.UnitTypePullRequests) {
+		return
+	}
+
+	requested := make(map[int64]bool, len(requests))
+	for _, request := range requests {
+		if request.ReviewerTeamID > 0 {
+			requested[-request.ReviewerTeamID] = true
+		} else {
+			requested[request.ReviewerID] = true
+		}
+	}
+
+	users, err := ctx.Repo.Repository.GetAssignees()
+	if err != nil {
+		ctx.ServerError("GetAssignees", err)
+		return
+	}
+	candidates := make([]*reviewerCandidate, 0, len(users))
+	listed := make(map[int64]bool, len(users))
+	for _, u := range users {
+		if u.ID != issue.PosterID {
+			candidates = append(candidates, &reviewerCandidate{ID: u.ID, User: u, Requested: requested[u.ID]})
+			listed[u.ID] = true
+		}
+	}
+	// the readers requested through the API can be withdrawn too
+	for _, request := range requests {
+		if request.ReviewerID > 0 && !listed[request.ReviewerID] {
+			candidates = append(candidates, &reviewerCandidate{ID: request.ReviewerID, User: request.Reviewer, Requested: true})
+		}
+	}
+
+	if ctx.Repo.Owner.IsOrganization() {
+		teams, err := ctx.Repo.Repository.GetRepoTeams()
+		if err != nil {
+			ctx.ServerError("GetRepoTeams", err)
+			return
+		}
+		for _, team := range teams {
+			if err := models.IsValidTeamReviewRequest(issue, team); err != nil {
+				if models.IsErrNotValidReviewRequest(err) {
+					continue
+				}
+				ctx.ServerError("IsValidTeamReviewRequest", err)
+				return
+			}
+			candidates = append(candidates, &reviewerCandidate{ID: -team.ID, Team: team, Requested: requested[-team.ID]})
+		}
+	}
+	ctx.Data["ReviewerCandidates"] = candidates
+}
+
+// UpdatePullReviewRequest requests a user or a team to review the pull
+// requests, or withdraws the request; a negative ID is the one of a team
+func UpdatePullReviewRequest(ctx *context.Context) {
+	issues := getActionIssues(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	reviewID := ctx.QueryInt64("id")
+	action := ctx.Query("action")
+	if action != "attach" && action != "detach" {
+		log.Warn("Unrecognized action: %s", action)
+		ctx.Error(500)
+		return
+	}
+	isAdd := action == "attach"
+
+	for _, issue := range issues {
+		if !issue.IsPull {
+			ctx.Error(400, "not a pull request")
+			return
+		}
+
+		var err error
+		if reviewID < 0 {
+			var team *models.Team
+			if team, err = models.GetTeamByID(-reviewID); err != nil {
+				if models.IsErrTeamNotExist(err) {
+					ctx.Error(404, "GetTeamByID")
+				} else {
+					ctx.ServerError("GetTeamByID", err)
+				}
+				return
+			}
+			_, err = issue_service.TeamReviewRequest(issue, ctx.User, team, isAdd)
+		} else {
+			var reviewer *models.User
+			if reviewer, err = models.GetUserByID(reviewID); err != nil {
+				if models.IsErrUserNotExist(err) {
+					ctx.Error(404, "GetUserByID")
+				} else {
+					ctx.ServerError("GetUserByID", err)
+				}
+				return
+			}
+			_, err = issue_service.ReviewRequest(issue, ctx.User, reviewer, isAdd)
+		}
+		if err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				ctx.Error(403, err.Error())
+			} else {
+				ctx.ServerError("ReviewRequest", err)
+			}
+			return
+		}
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
@@ -402,6 +402,15 @@ func Action(ctx *context.Context) {
 		ctx.Repo.Repository.Description = ctx.Query("desc")
 		ctx.Repo.Repository.Website = ctx.Query("site")
 		err = models.UpdateRepository(ctx.Repo.Repository, false)
+	default:
+		if name := strings.TrimPrefix(ctx.Params(":action"), "notify_"); name != ctx.Params(":action") {
+			level, ok := models.RepoNotificationLevelFromName(name)
+			if !ok {
+				ctx.Error(404)
+				return
+			}
+			err = models.SetRepoNotificationLevel(ctx.User.ID, ctx.Repo.Repository.ID, level)
+		}
 	}
 
 	if err != nil {
@@ -7,10 +7,13 @@ package repo
 import (
 	"path"
 	"strings"
+	"time"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	commit_indexer "code.gitea.io/gitea/modules/indexer/commits"
 	"code.gitea.io/gitea/modules/setting"
 )
 
@@ -23,26 +26,174 @@ func Search(ctx *context.Context) {
 		return
 	}
 	keyword := strings.TrimSpace(ctx.Query("q"))
+	language := ctx.Query("l")
 	page := ctx.QueryInt("page")
 	if page <= 0 {
 		page = 1
 	}
-	total, searchResults, err := code_indexer.PerformSearch([]int64{ctx.Repo.Repository.ID},
-		keyword, page, setting.UI.RepoSearchPagingNum)
+	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch([]int64{ctx.Repo.Repository.ID},
+		language, keyword, page, setting.UI.RepoSearchPagingNum)
 	if err != nil {
-		ctx.ServerError("SearchResults", err)
-		return
+		if !code_indexer.IsErrInvalidQuery(err) {
+			ctx.ServerError("SearchResults", err)
+			return
+		}
+		ctx.Data["QueryError"] = err.Error()
 	}
 	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = language
 	ctx.Data["SourcePath"] = setting.AppSubURL + "/" +
 		path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "branch", ctx.Repo.Repository.DefaultBranch)
 	ctx.Data["SearchResults"] = searchResults
+	ctx.Data["SearchResultLanguages"] = searchResultLanguages
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
 
 	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
 	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "l", "Language")
 	ctx.Data["Page"] = pager
 
 	ctx.HTML(200, tplSearch)
 }
+
+// CodeSearchGroup are the results of a code search in a repository
+type CodeSearchGroup struct {
+	Repo    *models.Repository
+	Results []*code_indexer.Result
+}
+
+// CodeSearchOptions are the options to render a code search across repositories
+type CodeSearchOptions struct {
+	// OwnerID restricts the search to the repositories of an owner if not 0
+	OwnerID int64
+	TplName base.TplName
+}
+
+// RenderCodeSearch renders a code search in the repositories whose code the
+// user can read, the results of each repository grouped together
+func RenderCodeSearch(ctx *context.Context, opts *CodeSearchOptions) {
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	language := ctx.Query("l")
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	// site admins can read all repositories, nil repoIDs means all of them
+	repoIDs, err := models.FindUserCodeAccessibleRepoIDs(ctx.User, opts.OwnerID)
+	if err != nil {
+		ctx.ServerError("FindUserCodeAccessibleRepoIDs", err)
+		return
+	}
+
+	total, searchResults, searchResultLanguages, err := code_indexer.PerformSearch(repoIDs, language, keyword, page, setting.UI.RepoSearchPagingNum)
+	if err != nil {
+		if !code_indexer.IsErrInvalidQuery(err) {
+			ctx.ServerError("SearchResults", err)
+			return
+		}
+		ctx.Data["QueryError"] = err.Error()
+	}
+
+	// the results keep the order of relevance of the first result of each repository
+	var groups []*CodeSearchGroup
+	groupIndexes := make(map[int64]int)
+	loadRepoIDs := make([]int64, 0, len(searchResults))
+	for _, result := range searchResults {
+		i, ok := groupIndexes[result.RepoID]
+		if !ok {
+			i = len(groups)
+			groupIndexes[result.RepoID] = i
+			groups = append(groups, &CodeSearchGroup{})
+			loadRepoIDs = append(loadRepoIDs, result.RepoID)
+		}
+		groups[i].Results = append(groups[i].Results, result)
+	}
+	repoMaps, err := models.GetRepositoriesMapByIDs(loadRepoIDs)
+	if err != nil {
+		ctx.ServerError("GetRepositoriesMapByIDs", err)
+		return
+	}
+	for _, group := range groups {
+		group.Repo = repoMaps[group.Results[0].RepoID]
+	}
+
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["Language"] = language
+	ctx.Data["SearchResults"] = searchResults
+	ctx.Data["SearchResultGroups"] = groups
+	ctx.Data["SearchResultLanguages"] = searchResultLanguages
+	ctx.Data["RequireHighlightJS"] = true
+	ctx.Data["PageIsViewCode"] = true
+
+	pager := context.NewPagination(total, setting.UI.RepoSearchPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParam(ctx, "l", "Language")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, opts.TplName)
+}
+
+// CommitSearchResult is a commit found by a commit search across repositories
+type CommitSearchResult struct {
+	Repo *models.Repository
+	*commit_indexer.SearchResult
+}
+
+// AuthoredTime returns the time the commit was authored
+func (r *CommitSearchResult) AuthoredTime() time.Time {
+	return time.Unix(r.AuthorUnix, 0)
+}
+
+// RenderCommitSearch renders a commit search in the repositories whose code
+// the user can read
+func RenderCommitSearch(ctx *context.Context, tplName base.TplName) {
+	keyword := strings.TrimSpace(ctx.Query("q"))
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+
+	// site admins can read all repositories, nil repoIDs means all of them
+	repoIDs, err := models.FindUserCodeAccessibleRepoIDs(ctx.User, 0)
+	if err != nil {
+		ctx.ServerError("FindUserCodeAccessibleRepoIDs", err)
+		return
+	}
+
+	total, searchResults, err := commit_indexer.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
+	if err != nil {
+		if !commit_indexer.IsErrInvalidQuery(err) {
+			ctx.ServerError("PerformSearch", err)
+			return
+		}
+		ctx.Data["QueryError"] = err.Error()
+	}
+
+	loadRepoIDs := make([]int64, 0, len(searchResults))
+	for _, result := range searchResults {
+		loadRepoIDs = append(loadRepoIDs, result.RepoID)
+	}
+	repoMaps, err := models.GetRepositoriesMapByIDs(loadRepoIDs)
+	if err != nil {
+		ctx.ServerError("GetRepositoriesMapByIDs", err)
+		return
+	}
+	results := make([]*CommitSearchResult, 0, len(searchResults))
+	for _, result := range searchResults {
+		// the repository may have been deleted since the commit was indexed
+		if repo, ok := repoMaps[result.RepoID]; ok {
+			results = append(results, &CommitSearchResult{Repo: repo, SearchResult: result})
+		}
+	}
+
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["SearchResults"] = results
+
+	pager := context.NewPagination(int(total), setting.UI.RepoSearchPagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplName)
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -116,6 +117,9 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.ServerError("UpdateRepository", err)
 			return
 		}
+		if visibilityChanged {
+			ctx.Audit(models.AuditRepoVisibility, repo, audit.Diff{}.Changed("private", !repo.IsPrivate, repo.IsPrivate))
+		}
 		log.Trace("Repository basic settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
@@ -384,6 +388,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.Repo.GitRepo.Close()
 			ctx.Repo.GitRepo = nil
 		}
+		oldOwnerName := ctx.Repo.Owner.Name
 		if err = repo_service.TransferOwnership(ctx.User, newOwner, repo, nil); err != nil {
 			if models.IsErrRepoAlreadyExist(err) {
 				ctx.RenderWithErr(ctx.Tr("repo.settings.new_owner_has_same_repo"), tplSettingsOptions, nil)
@@ -392,6 +397,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			}
 			return
 		}
+		ctx.Audit(models.AuditRepoTransfer, repo, audit.Diff{}.Changed("owner", oldOwnerName, newOwner.Name))
 
 		log.Trace("Repository transferred: %s/%s -> %s", ctx.Repo.Owner.Name, repo.Name, newOwner)
 		ctx.Flash.Success(ctx.Tr("repo.settings.transfer_succeed"))
@@ -411,6 +417,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.ServerError("DeleteRepository", err)
 			return
 		}
+		ctx.Audit(models.AuditRepoDelete, ctx.Repo.Repository, nil)
 		log.Trace("Repository deleted: %s/%s", ctx.Repo.Owner.Name, repo.Name)
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.deletion_success"))
@@ -550,6 +557,7 @@ func CollaborationPost(ctx *context.Context) {
 		ctx.ServerError("AddCollaborator", err)
 		return
 	}
+	ctx.Audit(models.AuditRepoCollaboratorAdd, ctx.Repo.Repository, audit.Diff{"collaborator": u.Name})
 
 	if setting.Service.EnableNotifyMail {
 		mailer.SendCollaboratorMail(u, ctx.User, ctx.Repo.Repository)
@@ -561,18 +569,22 @@ func CollaborationPost(ctx *context.Context) {
 
 // ChangeCollaborationAccessMode response for changing access of a collaboration
 func ChangeCollaborationAccessMode(ctx *context.Context) {
-	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(
-		ctx.QueryInt64("uid"),
-		models.AccessMode(ctx.QueryInt("mode"))); err != nil {
+	uid := ctx.QueryInt64("uid")
+	mode := models.AccessMode(ctx.QueryInt("mode"))
+	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(uid, mode); err != nil {
 		log.Error("ChangeCollaborationAccessMode: %v", err)
+		return
 	}
+	ctx.Audit(models.AuditRepoCollaboratorMode, ctx.Repo.Repository, audit.Diff{"collaborator_id": uid, "mode": mode.String()})
 }
 
 // DeleteCollaboration delete a collaboration for a repository
 func DeleteCollaboration(ctx *context.Context) {
-	if err := ctx.Repo.Repository.DeleteCollaboration(ctx.QueryInt64("id")); err != nil {
+	uid := ctx.QueryInt64("id")
+	if err := ctx.Repo.Repository.DeleteCollaboration(uid); err != nil {
 		ctx.Flash.Error("DeleteCollaboration: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditRepoCollaboratorRemove, ctx.Repo.Repository, audit.Diff{"collaborator_id": uid})
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_collaborator_success"))
 	}
 
@@ -622,6 +634,7 @@ func AddTeamPost(ctx *context.Context) {
 		ctx.ServerError("team.AddRepository", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamRepoAdd, team, audit.Diff{"repo": ctx.Repo.Repository.FullName()})
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_team_success"))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
@@ -645,6 +658,7 @@ func DeleteTeam(ctx *context.Context) {
 		ctx.ServerError("team.RemoveRepositorys", err)
 		return
 	}
+	ctx.Audit(models.AuditTeamRepoRemove, team, audit.Diff{"repo": ctx.Repo.Repository.FullName()})
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.remove_team_success"))
 	ctx.JSON(200, map[string]interface{}{
@@ -800,15 +814,18 @@ func DeployKeysPost(ctx *context.Context, form auth.AddKeyForm) {
 	}
 
 	log.Trace("Deploy key added: %d", ctx.Repo.Repository.ID)
+	ctx.Audit(models.AuditRepoDeployKeyAdd, ctx.Repo.Repository, audit.Diff{"key": key.Name, "fingerprint": key.Fingerprint, "writable": !key.IsReadOnly()})
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_key_success", key.Name))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
 }
 
 // DeleteDeployKey response for deleting a deploy key
 func DeleteDeployKey(ctx *context.Context) {
-	if err := models.DeleteDeployKey(ctx.User, ctx.QueryInt64("id")); err != nil {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteDeployKey(ctx.User, id); err != nil {
 		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditRepoDeployKeyDelete, ctx.Repo.Repository, audit.Diff{"key_id": id})
 		ctx.Flash.Success(ctx.Tr("repo.settings.deploy_key_deletion_success"))
 	}
 
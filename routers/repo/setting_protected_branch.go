@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -183,7 +184,11 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 	}
 
 	if f.Protected {
-		if protectBranch == nil {
+		var before *models.ProtectedBranch
+		if protectBranch != nil {
+			snapshot := *protectBranch
+			before = &snapshot
+		} else {
 			// No options found, create defaults.
 			protectBranch = &models.ProtectedBranch{
 				RepoID:     ctx.Repo.Repository.ID,
@@ -260,6 +265,7 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 			ctx.ServerError("UpdateProtectBranch", err)
 			return
 		}
+		ctx.Audit(models.AuditRepoBranchProtection, ctx.Repo.Repository, audit.ProtectedBranchDiff(before, protectBranch))
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_protect_branch_success", branch))
 		ctx.Redirect(fmt.Sprintf("%s/settings/branches/%s", ctx.Repo.RepoLink, branch))
 	} else {
@@ -268,6 +274,7 @@ func SettingsProtectedBranchPost(ctx *context.Context, f auth.ProtectBranchForm)
 				ctx.ServerError("DeleteProtectedBranch", err)
 				return
 			}
+			ctx.Audit(models.AuditRepoBranchProtectionDrop, ctx.Repo.Repository, audit.Diff{"branch": branch})
 		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_protected_branch_success", branch))
 		ctx.Redirect(fmt.Sprintf("%s/settings/branches", ctx.Repo.RepoLink))
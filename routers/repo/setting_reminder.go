@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+)
+
+const (
+	tplSettingsReminders base.TplName = "repo/settings/reminders"
+)
+
+func loadReminderRules(ctx *context.Context) {
+	rules, err := models.GetReminderRules(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetReminderRules", err)
+		return
+	}
+	ctx.Data["DueDateRule"] = rules[models.ReminderRuleDueDate]
+	ctx.Data["ReviewRequestRule"] = rules[models.ReminderRuleReviewRequest]
+	ctx.Data["InactivePullRule"] = rules[models.ReminderRuleInactivePull]
+}
+
+// Reminders shows the reminder rules of the repository
+func Reminders(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.reminders")
+	ctx.Data["PageIsSettingsReminders"] = true
+
+	loadReminderRules(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tplSettingsReminders)
+}
+
+// RemindersPost updates the reminder rules of the repository
+func RemindersPost(ctx *context.Context, form auth.RepoRemindersForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.reminders")
+	ctx.Data["PageIsSettingsReminders"] = true
+
+	if ctx.HasError() {
+		loadReminderRules(ctx)
+		if ctx.Written() {
+			return
+		}
+		ctx.HTML(200, tplSettingsReminders)
+		return
+	}
+
+	repoID := ctx.Repo.Repository.ID
+	for _, rule := range []*models.ReminderRule{
+		{RepoID: repoID, Type: models.ReminderRuleDueDate, Enabled: form.EnableDueDate, Days: form.DueDateDays},
+		{RepoID: repoID, Type: models.ReminderRuleReviewRequest, Enabled: form.EnableReviewRequest, Days: form.ReviewRequestDays},
+		{RepoID: repoID, Type: models.ReminderRuleInactivePull, Enabled: form.EnableInactivePull, Days: form.InactivePullDays},
+	} {
+		if err := models.SetReminderRule(rule); err != nil {
+			ctx.ServerError("SetReminderRule", err)
+			return
+		}
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/reminders")
+}
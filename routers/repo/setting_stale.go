@@ -0,0 +1,91 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/stale"
+	stale_service "code.gitea.io/gitea/services/stale"
+)
+
+const (
+	tplSettingsStale base.TplName = "repo/settings/stale"
+)
+
+// loadStaleConfigFile shows whether the configuration file of the repository
+// overrides its stale rule, and whether it is valid
+func loadStaleConfigFile(ctx *context.Context, rule *models.StaleRule) {
+	data, err := stale_service.ReadConfigFile(ctx.Repo.Repository)
+	if err != nil {
+		ctx.ServerError("ReadConfigFile", err)
+		return
+	} else if data == nil {
+		return
+	}
+	ctx.Data["StaleConfigFile"] = stale.ConfigFile
+	if _, _, err = stale.ParseConfig(data, rule.Config()); err != nil {
+		ctx.Data["StaleConfigError"] = err.Error()
+	}
+}
+
+// Stale shows the stale rule of the repository
+func Stale(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.stale")
+	ctx.Data["PageIsSettingsStale"] = true
+
+	rule, err := models.GetStaleRule(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetStaleRule", err)
+		return
+	}
+	ctx.Data["StaleRule"] = rule
+	loadStaleConfigFile(ctx, rule)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tplSettingsStale)
+}
+
+// StalePost updates the stale rule of the repository
+func StalePost(ctx *context.Context, form auth.RepoStaleForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.settings.stale")
+	ctx.Data["PageIsSettingsStale"] = true
+
+	rule := &models.StaleRule{
+		RepoID:           ctx.Repo.Repository.ID,
+		Enabled:          form.Enabled,
+		Only:             form.Only,
+		DaysUntilStale:   form.DaysUntilStale,
+		DaysUntilClose:   form.DaysUntilClose,
+		OnlyLabels:       form.OnlyLabels,
+		ExemptLabels:     form.ExemptLabels,
+		ExemptMilestones: form.ExemptMilestones,
+		ExemptAssignees:  form.ExemptAssignees,
+		StaleLabel:       form.StaleLabel,
+		MarkComment:      form.MarkComment,
+		CloseComment:     form.CloseComment,
+		LimitPerRun:      form.LimitPerRun,
+	}
+	if ctx.HasError() {
+		ctx.Data["StaleRule"] = rule
+		loadStaleConfigFile(ctx, rule)
+		if ctx.Written() {
+			return
+		}
+		ctx.HTML(200, tplSettingsStale)
+		return
+	}
+
+	if err := models.SetStaleRule(rule); err != nil {
+		ctx.ServerError("SetStaleRule", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/settings/stale")
+}
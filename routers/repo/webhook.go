@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -193,6 +194,7 @@ func WebHooksNewPost(ctx *context.Context, form auth.NewWebhookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -245,6 +247,7 @@ func newGogsWebhookPost(ctx *context.Context, form auth.NewGogshookForm, kind mo
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -294,6 +297,7 @@ func DiscordHooksNewPost(ctx *context.Context, form auth.NewDiscordHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -334,6 +338,7 @@ func DingtalkHooksNewPost(ctx *context.Context, form auth.NewDingtalkHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -383,6 +388,7 @@ func TelegramHooksNewPost(ctx *context.Context, form auth.NewTelegramHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -423,6 +429,7 @@ func MSTeamsHooksNewPost(ctx *context.Context, form auth.NewMSTeamsHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -480,6 +487,7 @@ func SlackHooksNewPost(ctx *context.Context, form auth.NewSlackHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -520,6 +528,7 @@ func FeishuHooksNewPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 		ctx.ServerError("CreateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookCreate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_hook_success"))
 	ctx.Redirect(orCtx.Link)
@@ -619,6 +628,7 @@ func WebHooksEditPost(ctx *context.Context, form auth.NewWebhookForm) {
 		ctx.ServerError("WebHooksEditPost", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -658,6 +668,7 @@ func GogsHooksEditPost(ctx *context.Context, form auth.NewGogshookForm) {
 		ctx.ServerError("GogsHooksEditPost", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -708,6 +719,7 @@ func SlackHooksEditPost(ctx *context.Context, form auth.NewSlackHookForm) {
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -750,6 +762,7 @@ func DiscordHooksEditPost(ctx *context.Context, form auth.NewDiscordHookForm) {
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -782,6 +795,7 @@ func DingtalkHooksEditPost(ctx *context.Context, form auth.NewDingtalkHookForm)
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -822,6 +836,7 @@ func TelegramHooksEditPost(ctx *context.Context, form auth.NewTelegramHookForm)
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -854,6 +869,7 @@ func MSTeamsHooksEditPost(ctx *context.Context, form auth.NewMSTeamsHookForm) {
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -886,6 +902,7 @@ func FeishuHooksEditPost(ctx *context.Context, form auth.NewFeishuHookForm) {
 		ctx.ServerError("UpdateWebhook", err)
 		return
 	}
+	ctx.Audit(models.AuditWebhookUpdate, w, audit.WebhookDiff(w))
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.update_hook_success"))
 	ctx.Redirect(fmt.Sprintf("%s/%d", orCtx.Link, w.ID))
@@ -948,9 +965,11 @@ func TestWebhook(ctx *context.Context) {
 
 // DeleteWebhook delete a webhook
 func DeleteWebhook(ctx *context.Context) {
-	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, id); err != nil {
 		ctx.Flash.Error("DeleteWebhookByRepoID: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditWebhookDelete, audit.Target{Type: "webhook", ID: id}, audit.Diff{"repo_id": ctx.Repo.Repository.ID})
 		ctx.Flash.Success(ctx.Tr("repo.settings.webhook_deletion_success"))
 	}
 
@@ -26,10 +26,15 @@ import (
 	"code.gitea.io/gitea/routers"
 	"code.gitea.io/gitea/routers/admin"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
+	"code.gitea.io/gitea/routers/ci"
+	"code.gitea.io/gitea/routers/container"
 	"code.gitea.io/gitea/routers/dev"
+	"code.gitea.io/gitea/routers/events"
 	"code.gitea.io/gitea/routers/org"
+	"code.gitea.io/gitea/routers/packages"
 	"code.gitea.io/gitea/routers/private"
 	"code.gitea.io/gitea/routers/repo"
+	"code.gitea.io/gitea/routers/scim"
 	"code.gitea.io/gitea/routers/user"
 	userSetting "code.gitea.io/gitea/routers/user/setting"
 	"code.gitea.io/gitea/services/mailer"
@@ -130,6 +135,13 @@ func NewMacaron() *macaron.Macaron {
 	}
 	m.Use(macaron.Recovery())
 	if setting.EnableGzip {
+		// The event stream must reach the browsers as soon as it's written,
+		// which the compression buffers prevent
+		m.Use(func(ctx *macaron.Context) {
+			if ctx.Req.URL.Path == "/user/events" {
+				ctx.Req.Header.Del("Accept-Encoding")
+			}
+		})
 		m.Use(gzip.Middleware())
 	}
 	if setting.Protocol == setting.FCGI || setting.Protocol == setting.FCGIUnix {
@@ -292,10 +304,15 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/users", routers.ExploreUsers)
 		m.Get("/organizations", routers.ExploreOrganizations)
 		m.Get("/code", routers.ExploreCode)
+		m.Get("/commits", routers.ExploreCommits)
 	}, ignSignIn)
 	m.Combo("/install", routers.InstallInit).Get(routers.Install).
 		Post(bindIgnErr(auth.InstallForm{}), routers.InstallPost)
 	m.Get("/^:type(issues|pulls)$", reqSignIn, user.Issues)
+	m.Group("/issues/saved_queries", func() {
+		m.Post("", bindIgnErr(auth.SavedIssueQueryForm{}), user.SavedIssueQueryPost)
+		m.Post("/:id/delete", user.DeleteSavedIssueQuery)
+	}, reqSignIn)
 	m.Get("/milestones", reqSignIn, reqMilestonesDashboardPageEnabled, user.Milestones)
 
 	// ***** START: User *****
@@ -414,6 +431,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/forgot_password", user.ForgotPasswd)
 		m.Post("/forgot_password", user.ForgotPasswdPost)
 		m.Get("/logout", user.SignOut)
+		if setting.UI.Notification.EventSourceEnabled {
+			m.Get("/events", reqSignIn, events.Events)
+		}
 	})
 	// ***** END: User *****
 
@@ -483,6 +503,26 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/:authid/delete", admin.DeleteAuthSource)
 		})
 
+		m.Group("/ssh_cas", func() {
+			m.Combo("").Get(admin.SSHCertificateAuthorities).
+				Post(bindIgnErr(auth.AdminAddSSHCertificateAuthorityForm{}), admin.NewSSHCertificateAuthorityPost)
+			m.Post("/delete", admin.DeleteSSHCertificateAuthority)
+		})
+
+		m.Group("/ci/runners", func() {
+			m.Get("", admin.CIRunners)
+			m.Post("/reset_token", admin.ResetCIRunnerToken)
+			m.Post("/delete", admin.DeleteCIRunner)
+		}, repo.MustEnableCI)
+
+		m.Group("/lockouts", func() {
+			m.Get("", admin.LoginLockouts)
+			m.Post("/delete", admin.ClearLoginLockout)
+		})
+
+		m.Get("/twofa", admin.TwoFactorReport)
+		m.Get("/audit", admin.AuditLog)
+
 		m.Group("/notices", func() {
 			m.Get("", admin.Notices)
 			m.Post("/delete", admin.DeleteNotices)
@@ -494,6 +534,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 	m.Group("", func() {
 		m.Get("/:username", user.Profile)
 		m.Get("/attachments/:uuid", repo.GetAttachment)
+		m.Get("/org/:org/code", context.OrgAssignment(), org.Code)
 	}, ignSignIn)
 
 	m.Group("/attachments", func() {
@@ -655,12 +696,23 @@ func RegisterRoutes(m *macaron.Macaron) {
 				}, context.GitHookService())
 			})
 
+			m.Combo("/reminders").Get(repo.Reminders).
+				Post(bindIgnErr(auth.RepoRemindersForm{}), repo.RemindersPost)
+			m.Combo("/stale").Get(repo.Stale).
+				Post(bindIgnErr(auth.RepoStaleForm{}), repo.StalePost)
+
 			m.Group("/keys", func() {
 				m.Combo("").Get(repo.DeployKeys).
 					Post(bindIgnErr(auth.AddKeyForm{}), repo.DeployKeysPost)
 				m.Post("/delete", repo.DeleteDeployKey)
 			})
 
+			m.Group("/ci/runners", func() {
+				m.Get("", repo.CIRunners)
+				m.Post("/reset_token", repo.ResetCIRunnerToken)
+				m.Post("/delete", repo.DeleteCIRunner)
+			}, repo.MustEnableCI)
+
 			m.Group("/lfs", func() {
 				m.Get("", repo.LFSFiles)
 				m.Get("/show/:oid", repo.LFSFileGet)
@@ -688,6 +740,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Combo("/new").Get(context.RepoRef(), repo.NewIssue).
 				Post(bindIgnErr(auth.CreateIssueForm{}), repo.NewIssuePost)
 		}, context.RepoMustNotBeArchived(), reqRepoIssueReader)
+		m.Group("/issues", func() {
+			m.Get("/export", repo.ExportIssues)
+			m.Combo("/import", context.RepoMustNotBeArchived()).Get(repo.ImportIssues).
+				Post(bindIgnErr(auth.ImportIssuesForm{}), repo.ImportIssuesPost)
+		}, reqRepoIssueReader, reqRepoAdmin)
 		// FIXME: should use different URLs but mostly same logic for comments of issue and pull reuqest.
 		// So they can apply their own enable/disable logic on routers.
 		m.Group("/issues", func() {
@@ -710,13 +767,16 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/reactions/:action", bindIgnErr(auth.ReactionForm{}), repo.ChangeIssueReaction)
 				m.Post("/lock", reqRepoIssueWriter, bindIgnErr(auth.IssueLockForm{}), repo.LockIssue)
 				m.Post("/unlock", reqRepoIssueWriter, repo.UnlockIssue)
+				m.Post("/transfer", reqRepoIssueWriter, bindIgnErr(auth.TransferIssueForm{}), repo.TransferIssue)
 				m.Get("/attachments", repo.GetIssueAttachments)
 			}, context.RepoMustNotBeArchived())
 
 			m.Post("/labels", reqRepoIssuesOrPullsWriter, repo.UpdateIssueLabel)
 			m.Post("/milestone", reqRepoIssuesOrPullsWriter, repo.UpdateIssueMilestone)
 			m.Post("/assignee", reqRepoIssuesOrPullsWriter, repo.UpdateIssueAssignee)
+			m.Post("/request_review", reqRepoPullsWriter, repo.UpdatePullReviewRequest)
 			m.Post("/status", reqRepoIssuesOrPullsWriter, repo.UpdateIssueStatus)
+			m.Post("/bulk", reqRepoIssuesOrPullsWriter, bindIgnErr(auth.BulkEditIssuesForm{}), repo.BulkEditIssues)
 		}, context.RepoMustNotBeArchived())
 		m.Group("/comments/:id", func() {
 			m.Post("", repo.UpdateCommentContent)
@@ -843,6 +903,16 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/:period", repo.Activity)
 		}, context.RepoRef(), repo.MustBeNotEmpty, context.RequireRepoReaderOr(models.UnitTypePullRequests, models.UnitTypeIssues, models.UnitTypeReleases))
 
+		m.Group("/ci", func() {
+			m.Get("", repo.CIRuns)
+			m.Group("/runs/:index", func() {
+				m.Get("", repo.ViewCIRun)
+				m.Get("/jobs/:jobid/steps/:step/log", repo.CIStepLog)
+				m.Post("/approve", reqSignIn, reqRepoCodeWriter, repo.ApproveCIRun)
+				m.Post("/cancel", reqSignIn, reqRepoCodeWriter, repo.CancelCIRun)
+			})
+		}, repo.MustEnableCI, reqRepoCodeReader)
+
 		m.Group("/activity_author_data", func() {
 			m.Get("", repo.ActivityAuthors)
 			m.Get("/:period", repo.ActivityAuthors)
@@ -966,7 +1036,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("", user.Notifications)
 		m.Post("/status", user.NotificationStatusPost)
 		m.Post("/purge", user.NotificationPurgePost)
+		m.Post("/bulk", bindIgnErr(auth.NotificationBulkForm{}), user.NotificationBulkPost)
+		m.Group("/webpush", func() {
+			m.Post("/subscribe", bindIgnErr(auth.WebPushSubscriptionForm{}), user.WebPushSubscribePost)
+			m.Post("/unsubscribe", bindIgnErr(auth.WebPushSubscriptionForm{}), user.WebPushUnsubscribePost)
+		}, func(ctx *context.Context) {
+			if !setting.WebPush.Enabled {
+				ctx.NotFound("", nil)
+			}
+		})
 	}, reqSignIn)
+	// The push service worker can only be served below its scope
+	m.Get("/notifications/serviceworker.js", templates.JSRenderer(), func(ctx *context.Context) {
+		if !setting.WebPush.Enabled {
+			ctx.NotFound("", nil)
+			return
+		}
+		ctx.HTML(200, "pwa/notification_serviceworker_js")
+	})
 
 	if setting.API.EnableSwagger {
 		m.Get("/swagger.v1.json", templates.JSONRenderer(), routers.SwaggerV1Json)
@@ -988,11 +1075,27 @@ func RegisterRoutes(m *macaron.Macaron) {
 		apiv1.RegisterRoutes(m)
 	}, handlers...)
 
+	m.Group("/api/packages", func() {
+		packages.RegisterRoutes(m)
+	}, ignSignInAndCsrf, packages.Authenticate)
+
+	m.Group("/api/ci", func() {
+		ci.RegisterRoutes(m)
+	}, ignSignInAndCsrf, ci.Enabled)
+
 	m.Group("/api/internal", func() {
 		// package name internal is ideal but Golang is not allowed, so we use private as package name.
 		private.RegisterRoutes(m)
 	})
 
+	m.Group("/scim/v2", func() {
+		scim.RegisterRoutes(m)
+	}, ignSignInAndCsrf, scim.CheckToken)
+
+	m.Group("/v2", func() {
+		container.RegisterRoutes(m)
+	}, ignSignInAndCsrf, container.Authenticate)
+
 	// robots.txt
 	m.Get("/robots.txt", func(ctx *context.Context) {
 		if setting.HasRobotsTxt {
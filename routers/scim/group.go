@@ -0,0 +1,492 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+)
+
+// Groups are organization teams, named "<organization>/<team>".
+
+// memberValuePathPattern matches paths like `members[value eq "2"]`
+var memberValuePathPattern = regexp.MustCompile(`(?i)^members\[value eq "(\d+)"\]$`)
+
+func toSCIMGroup(org *models.User, team *models.Team) *scim.Group {
+	return &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          strconv.FormatInt(team.ID, 10),
+		DisplayName: org.Name + "/" + team.Name,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     resourceLocation("Groups", team.ID),
+		},
+	}
+}
+
+// loadMembers fills the members attribute of the group of the given team
+func loadMembers(group *scim.Group, teamID int64) error {
+	members, err := models.GetTeamMembers(teamID)
+	if err != nil {
+		return err
+	}
+	group.Members = make([]*scim.MultiValue, len(members))
+	for i, member := range members {
+		group.Members[i] = &scim.MultiValue{
+			Value:   strconv.FormatInt(member.ID, 10),
+			Display: member.Name,
+			Ref:     resourceLocation("Users", member.ID),
+		}
+	}
+	return nil
+}
+
+// writeGroup responds with the group of the given team
+func writeGroup(ctx *context.Context, status int, org *models.User, team *models.Team) {
+	group := toSCIMGroup(org, team)
+	if membersRequested(ctx) {
+		if err := loadMembers(group, team.ID); err != nil {
+			serverError(ctx, "GetTeamMembers", err)
+			return
+		}
+	}
+	writeJSON(ctx, status, group)
+}
+
+// membersRequested checks whether the client asked to leave out the members attribute
+func membersRequested(ctx *context.Context) bool {
+	for _, attr := range strings.Split(ctx.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+// getGroupByParams returns the team of the :id parameter together with its organization
+func getGroupByParams(ctx *context.Context) (*models.User, *models.Team) {
+	team, err := models.GetTeamByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrTeamNotExist(err) {
+			writeError(ctx, http.StatusNotFound, "", "group not found")
+		} else {
+			serverError(ctx, "GetTeamByID", err)
+		}
+		return nil, nil
+	}
+	org, err := models.GetUserByID(team.OrgID)
+	if err != nil {
+		serverError(ctx, "GetUserByID", err)
+		return nil, nil
+	}
+	return org, team
+}
+
+// parseGroupName splits a group displayName into organization and team name
+func parseGroupName(ctx *context.Context, displayName string) (*models.User, string) {
+	parts := strings.SplitN(displayName, "/", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, `displayName must have the form "organization/team"`)
+		return nil, ""
+	}
+	org, err := models.GetOrgByName(parts[0])
+	if err != nil {
+		if models.IsErrOrgNotExist(err) {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "organization does not exist: "+parts[0])
+		} else {
+			serverError(ctx, "GetOrgByName", err)
+		}
+		return nil, ""
+	}
+	return org, parts[1]
+}
+
+// ListGroups queries the groups
+func ListGroups(ctx *context.Context) {
+	filter, ok := parseFilter(ctx)
+	if !ok {
+		return
+	}
+	startIndex, count := pagination(ctx)
+
+	teams, _, err := models.SearchTeam(&models.SearchTeamOptions{
+		ListOptions: models.ListOptions{PageSize: -1},
+	})
+	if err != nil {
+		serverError(ctx, "SearchTeam", err)
+		return
+	}
+
+	orgs := make(map[int64]*models.User)
+	matched := make([]*scim.Group, 0, len(teams))
+	for _, team := range teams {
+		org, has := orgs[team.OrgID]
+		if !has {
+			if org, err = models.GetUserByID(team.OrgID); err != nil {
+				serverError(ctx, "GetUserByID", err)
+				return
+			}
+			orgs[team.OrgID] = org
+		}
+
+		group := toSCIMGroup(org, team)
+		if filter != nil && !filter.Match(func(attr string) (interface{}, bool) {
+			switch attr {
+			case "id":
+				return group.ID, true
+			case "displayname":
+				return group.DisplayName, true
+			}
+			return nil, false
+		}) {
+			continue
+		}
+		matched = append(matched, group)
+	}
+
+	total := int64(len(matched))
+	start := startIndex - 1
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + count
+	if end > len(matched) {
+		end = len(matched)
+	}
+	matched = matched[start:end]
+
+	if membersRequested(ctx) {
+		for _, group := range matched {
+			teamID, _ := strconv.ParseInt(group.ID, 10, 64)
+			if err := loadMembers(group, teamID); err != nil {
+				serverError(ctx, "GetTeamMembers", err)
+				return
+			}
+		}
+	}
+
+	writeJSON(ctx, http.StatusOK, scim.NewListResponse(matched, total, startIndex, len(matched)))
+}
+
+// GetGroup returns a single group
+func GetGroup(ctx *context.Context) {
+	org, team := getGroupByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeGroup(ctx, http.StatusOK, org, team)
+}
+
+// memberIDs parses the user IDs of group members
+func memberIDs(ctx *context.Context, members []*scim.MultiValue) []int64 {
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member.Value, 10, 64)
+		if err != nil {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "invalid member "+member.Value)
+			return nil
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// patchMemberIDs parses the user IDs of the members value of a patch operation
+func patchMemberIDs(ctx *context.Context, value interface{}) []int64 {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+	members := make([]*scim.MultiValue, 0, len(values))
+	for _, v := range values {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "invalid members value")
+			return nil
+		}
+		str, _ := obj["value"].(string)
+		members = append(members, &scim.MultiValue{Value: str})
+	}
+	return memberIDs(ctx, members)
+}
+
+func addMembers(ctx *context.Context, team *models.Team, ids []int64) {
+	for _, id := range ids {
+		if team.IsMember(id) {
+			continue
+		}
+		u, err := models.GetUserByID(id)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+			} else {
+				serverError(ctx, "GetUserByID", err)
+			}
+			return
+		}
+		if u.IsOrganization() {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "members must be users")
+			return
+		}
+		if err := models.AddTeamMember(team, id); err != nil {
+			serverError(ctx, "AddTeamMember", err)
+			return
+		}
+	}
+}
+
+func removeMembers(ctx *context.Context, team *models.Team, ids []int64) {
+	for _, id := range ids {
+		if !team.IsMember(id) {
+			continue
+		}
+		if err := models.RemoveTeamMember(team, id); err != nil {
+			if models.IsErrLastOrgOwner(err) {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, err.Error())
+			} else {
+				serverError(ctx, "RemoveTeamMember", err)
+			}
+			return
+		}
+	}
+}
+
+// setMembers makes the given users the only members of the team
+func setMembers(ctx *context.Context, team *models.Team, ids []int64) {
+	current, err := models.GetTeamMembers(team.ID)
+	if err != nil {
+		serverError(ctx, "GetTeamMembers", err)
+		return
+	}
+	wanted := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var stale []int64
+	for _, member := range current {
+		if !wanted[member.ID] {
+			stale = append(stale, member.ID)
+		}
+	}
+
+	// Add before removing so the owner team is never left empty in between
+	addMembers(ctx, team, ids)
+	if ctx.Written() {
+		return
+	}
+	removeMembers(ctx, team, stale)
+}
+
+func renameTeam(ctx *context.Context, org *models.User, team *models.Team, displayName string) {
+	newOrg, name := parseGroupName(ctx, displayName)
+	if ctx.Written() {
+		return
+	}
+	if newOrg.ID != org.ID {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "groups cannot be moved to another organization")
+		return
+	}
+	if name == team.Name {
+		return
+	}
+	if team.IsOwnerTeam() {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the owner team cannot be renamed")
+		return
+	}
+	if err := models.IsUsableTeamName(name); err != nil {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		return
+	}
+	team.Name = name
+	if err := models.UpdateTeam(team, false, false); err != nil {
+		if models.IsErrTeamAlreadyExist(err) {
+			writeError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+		} else {
+			serverError(ctx, "UpdateTeam", err)
+		}
+	}
+}
+
+// CreateGroup creates a team in the organization named by displayName
+func CreateGroup(ctx *context.Context) {
+	var form scim.Group
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	org, name := parseGroupName(ctx, form.DisplayName)
+	if ctx.Written() {
+		return
+	}
+	ids := memberIDs(ctx, form.Members)
+	if ctx.Written() {
+		return
+	}
+
+	units := make([]*models.TeamUnit, 0, len(models.AllRepoUnitTypes))
+	for _, tp := range models.AllRepoUnitTypes {
+		units = append(units, &models.TeamUnit{
+			OrgID: org.ID,
+			Type:  tp,
+		})
+	}
+	team := &models.Team{
+		OrgID:     org.ID,
+		Name:      name,
+		Authorize: models.AccessModeRead,
+		Units:     units,
+	}
+	if err := models.NewTeam(team); err != nil {
+		switch {
+		case models.IsErrTeamAlreadyExist(err):
+			writeError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+		case models.IsErrNameReserved(err), models.IsErrNamePatternNotAllowed(err):
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		default:
+			serverError(ctx, "NewTeam", err)
+		}
+		return
+	}
+	log.Trace("Team provisioned by SCIM (%s): %s/%s", ctx.User.Name, org.Name, team.Name)
+
+	addMembers(ctx, team, ids)
+	if ctx.Written() {
+		return
+	}
+
+	writeGroup(ctx, http.StatusCreated, org, team)
+}
+
+// ReplaceGroup replaces the name and members of a group
+func ReplaceGroup(ctx *context.Context) {
+	org, team := getGroupByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	var form scim.Group
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	ids := memberIDs(ctx, form.Members)
+	if ctx.Written() {
+		return
+	}
+
+	renameTeam(ctx, org, team, form.DisplayName)
+	if ctx.Written() {
+		return
+	}
+	setMembers(ctx, team, ids)
+	if ctx.Written() {
+		return
+	}
+
+	writeGroup(ctx, http.StatusOK, org, team)
+}
+
+// PatchGroup applies PATCH operations to a group
+func PatchGroup(ctx *context.Context) {
+	org, team := getGroupByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	var form scim.PatchRequest
+	if !decodeBody(ctx, &form) {
+		return
+	}
+
+	for _, op := range form.Operations {
+		path := strings.ToLower(op.Path)
+		switch strings.ToLower(op.Op) {
+		case "add":
+			if path != "members" {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidPath, "unsupported path "+op.Path)
+				return
+			}
+			if ids := patchMemberIDs(ctx, op.Value); !ctx.Written() {
+				addMembers(ctx, team, ids)
+			}
+		case "remove":
+			if match := memberValuePathPattern.FindStringSubmatch(op.Path); match != nil {
+				id, _ := strconv.ParseInt(match[1], 10, 64)
+				removeMembers(ctx, team, []int64{id})
+			} else if path == "members" && op.Value != nil {
+				if ids := patchMemberIDs(ctx, op.Value); !ctx.Written() {
+					removeMembers(ctx, team, ids)
+				}
+			} else if path == "members" {
+				setMembers(ctx, team, nil)
+			} else {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidPath, "unsupported path "+op.Path)
+			}
+		case "replace":
+			switch path {
+			case "members":
+				if ids := patchMemberIDs(ctx, op.Value); !ctx.Written() {
+					setMembers(ctx, team, ids)
+				}
+			case "displayname":
+				if name, ok := patchValueString(op.Value); ok {
+					renameTeam(ctx, org, team, name)
+				} else {
+					writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "displayName must be a string")
+				}
+			case "":
+				attrs, ok := op.Value.(map[string]interface{})
+				if !ok {
+					writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "value must be an object")
+					return
+				}
+				for key, value := range attrs {
+					if strings.EqualFold(key, "displayName") {
+						if name, ok := patchValueString(value); ok {
+							renameTeam(ctx, org, team, name)
+						}
+					} else if strings.EqualFold(key, "members") {
+						if ids := patchMemberIDs(ctx, value); !ctx.Written() {
+							setMembers(ctx, team, ids)
+						}
+					}
+					if ctx.Written() {
+						return
+					}
+				}
+			default:
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidPath, "unsupported path "+op.Path)
+			}
+		default:
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "unsupported operation "+op.Op)
+		}
+		if ctx.Written() {
+			return
+		}
+	}
+
+	writeGroup(ctx, http.StatusOK, org, team)
+}
+
+// DeleteGroup deletes a team
+func DeleteGroup(ctx *context.Context) {
+	org, team := getGroupByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if team.IsOwnerTeam() {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "the owner team cannot be deleted")
+		return
+	}
+	if err := models.DeleteTeam(team); err != nil {
+		serverError(ctx, "DeleteTeam", err)
+		return
+	}
+	log.Trace("Team deleted by SCIM (%s): %s/%s", ctx.User.Name, org.Name, team.Name)
+	ctx.Status(http.StatusNoContent)
+}
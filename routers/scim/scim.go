@@ -0,0 +1,168 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package scim implements the SCIM 2.0 provisioning endpoints used by identity providers
+// to manage users and organization teams.
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"gitea.com/macaron/macaron"
+)
+
+// RegisterRoutes registers the SCIM routes, they are served under /scim/v2.
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Group("/Users", func() {
+		m.Combo("").Get(ListUsers).Post(CreateUser)
+		m.Combo("/:id").Get(GetUser).Put(ReplaceUser).Patch(PatchUser).Delete(DeleteUser)
+	})
+	m.Group("/Groups", func() {
+		m.Combo("").Get(ListGroups).Post(CreateGroup)
+		m.Combo("/:id").Get(GetGroup).Put(ReplaceGroup).Patch(PatchGroup).Delete(DeleteGroup)
+	})
+	m.Any("/*", func(ctx *context.Context) {
+		writeError(ctx, http.StatusNotFound, "", "resource type not found")
+	})
+}
+
+// CheckToken makes sure SCIM is enabled and the request carries an access token of
+// a site administrator as bearer token.
+func CheckToken(ctx *context.Context) {
+	if !setting.SCIM.Enabled {
+		writeError(ctx, http.StatusNotFound, "", "SCIM is disabled")
+		return
+	}
+
+	fields := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "Bearer") {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="gitea-scim"`)
+		writeError(ctx, http.StatusUnauthorized, "", "bearer token required")
+		return
+	}
+
+	token, err := models.GetAccessTokenBySHA(fields[1])
+	if err != nil {
+		if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
+			log.Error("GetAccessTokenBySHA: %v", err)
+		}
+		writeError(ctx, http.StatusUnauthorized, "", "invalid bearer token")
+		return
+	}
+
+	u, err := models.GetUserByID(token.UID)
+	if err != nil {
+		log.Error("GetUserByID: %v", err)
+		writeError(ctx, http.StatusUnauthorized, "", "invalid bearer token")
+		return
+	}
+	if !u.IsAdmin || !u.IsActive || u.ProhibitLogin {
+		log.Debug("Forbidden attempt to access SCIM endpoints by %s", u.Name)
+		writeError(ctx, http.StatusForbidden, "", "token does not belong to a site administrator")
+		return
+	}
+
+	token.UpdatedUnix = timeutil.TimeStampNow()
+	if err = models.UpdateAccessToken(token); err != nil {
+		log.Error("UpdateAccessToken: %v", err)
+	}
+	ctx.User = u
+}
+
+func writeJSON(ctx *context.Context, status int, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		log.Error("json.Marshal: %v", err)
+		ctx.Resp.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ctx.Resp.Header().Set("Content-Type", scim.ContentType)
+	ctx.Resp.WriteHeader(status)
+	if _, err = ctx.Resp.Write(data); err != nil {
+		log.Error("Write: %v", err)
+	}
+}
+
+func writeError(ctx *context.Context, status int, scimType, detail string) {
+	writeJSON(ctx, status, &scim.Error{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func serverError(ctx *context.Context, title string, err error) {
+	log.Error("%s: %v", title, err)
+	writeError(ctx, http.StatusInternalServerError, "", "internal server error")
+}
+
+func decodeBody(ctx *context.Context, obj interface{}) bool {
+	body := ctx.Req.Body().ReadCloser()
+	defer body.Close()
+	if err := json.NewDecoder(body).Decode(obj); err != nil {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, err.Error())
+		return false
+	}
+	return true
+}
+
+// pagination reads the 1-based startIndex and the count query parameters
+func pagination(ctx *context.Context) (startIndex, count int) {
+	count = ctx.QueryInt("count")
+	if count <= 0 || count > setting.API.MaxResponseItems {
+		count = setting.API.MaxResponseItems
+	}
+	startIndex = ctx.QueryInt("startIndex")
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	return startIndex, count
+}
+
+func parseFilter(ctx *context.Context) (scim.Filter, bool) {
+	filter := ctx.Query("filter")
+	if len(filter) == 0 {
+		return nil, true
+	}
+	f, err := scim.ParseFilter(filter)
+	if err != nil {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err.Error())
+		return nil, false
+	}
+	return f, true
+}
+
+func resourceLocation(resourceType string, id int64) string {
+	return setting.AppURL + "scim/v2/" + resourceType + "/" + strconv.FormatInt(id, 10)
+}
+
+// patchValueString returns the value of a patch operation as string
+func patchValueString(value interface{}) (string, bool) {
+	str, ok := value.(string)
+	return str, ok
+}
+
+// patchValueBool returns the value of a patch operation as boolean. Some identity
+// providers send booleans as strings.
+func patchValueBool(value interface{}) (bool, bool) {
+	switch v := value.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	return false, false
+}
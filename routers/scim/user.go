@@ -0,0 +1,441 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package scim
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/scim"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+// userColumns maps the filterable user attributes to their columns
+var userColumns = map[string]string{
+	"username":       "lower_name",
+	"displayname":    "full_name",
+	"name.formatted": "full_name",
+	"emails":         "email",
+	"emails.value":   "email",
+	"active":         "is_active",
+}
+
+func toSCIMUser(u *models.User) *scim.User {
+	active := u.IsActive && !u.ProhibitLogin
+	return &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          strconv.FormatInt(u.ID, 10),
+		UserName:    u.Name,
+		Name:        &scim.Name{Formatted: u.FullName},
+		DisplayName: u.FullName,
+		Emails: []*scim.MultiValue{{
+			Value:   u.Email,
+			Type:    "work",
+			Primary: true,
+		}},
+		Active: &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      u.CreatedUnix.AsTimePtr(),
+			LastModified: u.UpdatedUnix.AsTimePtr(),
+			Location:     resourceLocation("Users", u.ID),
+		},
+	}
+}
+
+// getUserByParams returns the user of the :id parameter, organizations are not SCIM users
+func getUserByParams(ctx *context.Context) *models.User {
+	u, err := models.GetUserByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			writeError(ctx, http.StatusNotFound, "", "user not found")
+		} else {
+			serverError(ctx, "GetUserByID", err)
+		}
+		return nil
+	}
+	if u.IsOrganization() {
+		writeError(ctx, http.StatusNotFound, "", "user not found")
+		return nil
+	}
+	return u
+}
+
+// ListUsers queries the users
+func ListUsers(ctx *context.Context) {
+	filter, ok := parseFilter(ctx)
+	if !ok {
+		return
+	}
+	startIndex, count := pagination(ctx)
+
+	// SCIM pages by arbitrary offsets while the users are listed by page number,
+	// so the page containing the start index is loaded with the next one if needed
+	page := (startIndex-1)/count + 1
+	skip := (startIndex - 1) % count
+	opts := &models.SearchUserOptions{
+		ListOptions: models.ListOptions{Page: page, PageSize: count},
+		Type:        models.UserTypeIndividual,
+		OrderBy:     models.SearchOrderByID,
+		Visible:     []structs.VisibleType{structs.VisibleTypePublic, structs.VisibleTypeLimited, structs.VisibleTypePrivate},
+	}
+	if filter != nil {
+		cond, err := filter.Cond(userColumns)
+		if err != nil {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidFilter, err.Error())
+			return
+		}
+		opts.ExtraCond = cond
+	}
+
+	users, total, err := models.SearchUsers(opts)
+	if err != nil {
+		serverError(ctx, "SearchUsers", err)
+		return
+	}
+	if skip > 0 && len(users) == count {
+		opts.Page++
+		next, _, err := models.SearchUsers(opts)
+		if err != nil {
+			serverError(ctx, "SearchUsers", err)
+			return
+		}
+		users = append(users, next...)
+	}
+	if skip > len(users) {
+		skip = len(users)
+	}
+	users = users[skip:]
+	if len(users) > count {
+		users = users[:count]
+	}
+
+	resources := make([]*scim.User, len(users))
+	for i := range users {
+		resources[i] = toSCIMUser(users[i])
+	}
+	writeJSON(ctx, http.StatusOK, scim.NewListResponse(resources, total, startIndex, len(resources)))
+}
+
+// GetUser returns a single user
+func GetUser(ctx *context.Context) {
+	u := getUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	writeJSON(ctx, http.StatusOK, toSCIMUser(u))
+}
+
+// CreateUser provisions a new user
+func CreateUser(ctx *context.Context) {
+	var form scim.User
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	email := form.PrimaryEmail()
+	if len(form.UserName) == 0 || len(email) == 0 {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName and emails are required")
+		return
+	}
+
+	passwd := form.Password
+	if len(passwd) == 0 {
+		// Provisioned users are expected to sign in through the identity provider,
+		// they can still reset this password with their email address.
+		var err error
+		if passwd, err = generate.GetRandomString(32); err != nil {
+			serverError(ctx, "GetRandomString", err)
+			return
+		}
+	}
+
+	u := &models.User{
+		Name:          form.UserName,
+		FullName:      form.FullName(),
+		Email:         email,
+		Passwd:        passwd,
+		IsActive:      form.Active == nil || *form.Active,
+		ProhibitLogin: form.Active != nil && !*form.Active,
+		LoginType:     models.LoginPlain,
+	}
+	if err := models.CreateUser(u); err != nil {
+		switch {
+		case models.IsErrUserAlreadyExist(err), models.IsErrEmailAlreadyUsed(err):
+			writeError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+		case models.IsErrNameReserved(err), models.IsErrNamePatternNotAllowed(err):
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+		default:
+			serverError(ctx, "CreateUser", err)
+		}
+		return
+	}
+	log.Trace("Account provisioned by SCIM (%s): %s", ctx.User.Name, u.Name)
+
+	writeJSON(ctx, http.StatusCreated, toSCIMUser(u))
+}
+
+// userChanges holds the attributes to change on a user, nil fields are left untouched
+type userChanges struct {
+	UserName *string
+	FullName *string
+	Email    *string
+	Active   *bool
+}
+
+func (c *userChanges) set(path string, value interface{}) (ok bool) {
+	path = strings.ToLower(path)
+	switch {
+	case path == "active":
+		var active bool
+		if active, ok = patchValueBool(value); ok {
+			c.Active = &active
+		}
+	case path == "username":
+		var name string
+		if name, ok = patchValueString(value); ok {
+			c.UserName = &name
+		}
+	case path == "displayname" || path == "name.formatted":
+		var name string
+		if name, ok = patchValueString(value); ok {
+			c.FullName = &name
+		}
+	case path == "emails" || strings.HasPrefix(path, "emails["):
+		var email string
+		if email, ok = patchValueString(value); !ok {
+			email, ok = primaryEmailValue(value)
+		}
+		if ok {
+			c.Email = &email
+		}
+	case path == "name":
+		// Compound names from an object value
+		var obj map[string]interface{}
+		if obj, ok = value.(map[string]interface{}); ok {
+			for key, v := range obj {
+				if strings.EqualFold(key, "formatted") {
+					return c.set("name.formatted", v)
+				}
+			}
+		}
+	default:
+		// Attributes we do not store are ignored
+		return true
+	}
+	return ok
+}
+
+// remove clears the attribute at the path, it returns false if the attribute is required
+func (c *userChanges) remove(path string) bool {
+	path = strings.ToLower(path)
+	switch {
+	case path == "username", path == "active", path == "emails" || strings.HasPrefix(path, "emails["):
+		return false
+	case path == "displayname" || path == "name" || path == "name.formatted":
+		empty := ""
+		c.FullName = &empty
+	}
+	// Attributes we do not store are ignored
+	return true
+}
+
+// checkSelfDeactivation writes an error response if the changes would deactivate
+// the account of the token in use, which would lock the provisioning client out
+func checkSelfDeactivation(ctx *context.Context, u *models.User, changes *userChanges) bool {
+	if u.ID == ctx.User.ID && changes.Active != nil && !*changes.Active {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, "cannot deprovision the account of the token in use")
+		return false
+	}
+	return true
+}
+
+// primaryEmailValue extracts the primary email from a multi-valued emails patch value
+func primaryEmailValue(value interface{}) (string, bool) {
+	values, ok := value.([]interface{})
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	var first string
+	for _, v := range values {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		email, _ := obj["value"].(string)
+		if primary, _ := patchValueBool(obj["primary"]); primary {
+			return email, len(email) > 0
+		}
+		if len(first) == 0 {
+			first = email
+		}
+	}
+	return first, len(first) > 0
+}
+
+// applyUserChanges updates the user with the given changes and writes an error response on failure
+func applyUserChanges(ctx *context.Context, u *models.User, changes *userChanges) {
+	if changes.UserName != nil && *changes.UserName != u.Name {
+		if !strings.EqualFold(*changes.UserName, u.Name) {
+			if err := models.ChangeUserName(u, *changes.UserName); err != nil {
+				switch {
+				case models.IsErrUserAlreadyExist(err):
+					writeError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+				case models.IsErrNameReserved(err), models.IsErrNamePatternNotAllowed(err):
+					writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, err.Error())
+				default:
+					serverError(ctx, "ChangeUserName", err)
+				}
+				return
+			}
+		}
+		u.Name = *changes.UserName
+		u.LowerName = strings.ToLower(u.Name)
+	}
+	if changes.FullName != nil {
+		u.FullName = *changes.FullName
+	}
+	if changes.Email != nil {
+		u.Email = *changes.Email
+	}
+	if changes.Active != nil {
+		// Deprovisioned users are deactivated and prohibited from signing in
+		// rather than deleted, so their repositories and history are kept.
+		u.IsActive = *changes.Active
+		u.ProhibitLogin = !*changes.Active
+	}
+
+	if err := models.UpdateUserSetting(u); err != nil {
+		if models.IsErrEmailAlreadyUsed(err) {
+			writeError(ctx, http.StatusConflict, scim.ErrorTypeUniqueness, err.Error())
+		} else {
+			serverError(ctx, "UpdateUserSetting", err)
+		}
+		return
+	}
+	log.Trace("Account updated by SCIM (%s): %s", ctx.User.Name, u.Name)
+}
+
+// ReplaceUser replaces the attributes of a user
+func ReplaceUser(ctx *context.Context) {
+	u := getUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var form scim.User
+	if !decodeBody(ctx, &form) {
+		return
+	}
+	if len(form.UserName) == 0 {
+		writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "userName is required")
+		return
+	}
+
+	fullName := form.FullName()
+	changes := &userChanges{
+		UserName: &form.UserName,
+		FullName: &fullName,
+		Active:   form.Active,
+	}
+	if email := form.PrimaryEmail(); len(email) > 0 {
+		changes.Email = &email
+	}
+
+	if !checkSelfDeactivation(ctx, u, changes) {
+		return
+	}
+	applyUserChanges(ctx, u, changes)
+	if ctx.Written() {
+		return
+	}
+	writeJSON(ctx, http.StatusOK, toSCIMUser(u))
+}
+
+// PatchUser applies PATCH operations to a user
+func PatchUser(ctx *context.Context) {
+	u := getUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var form scim.PatchRequest
+	if !decodeBody(ctx, &form) {
+		return
+	}
+
+	changes := &userChanges{}
+	for _, op := range form.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		case "remove":
+			if len(op.Path) == 0 {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeNoTarget, "path is required to remove an attribute")
+				return
+			}
+			if !changes.remove(op.Path) {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeMutability, op.Path+" is required and cannot be removed")
+				return
+			}
+			continue
+		default:
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidSyntax, "unsupported operation "+op.Op)
+			return
+		}
+
+		if len(op.Path) > 0 {
+			if !changes.set(op.Path, op.Value) {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "invalid value for "+op.Path)
+				return
+			}
+			continue
+		}
+
+		// Without a path the value holds the attributes to change
+		attrs, ok := op.Value.(map[string]interface{})
+		if !ok {
+			writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "value must be an object")
+			return
+		}
+		for path, value := range attrs {
+			if !changes.set(path, value) {
+				writeError(ctx, http.StatusBadRequest, scim.ErrorTypeInvalidValue, "invalid value for "+path)
+				return
+			}
+		}
+	}
+
+	if !checkSelfDeactivation(ctx, u, changes) {
+		return
+	}
+	applyUserChanges(ctx, u, changes)
+	if ctx.Written() {
+		return
+	}
+	writeJSON(ctx, http.StatusOK, toSCIMUser(u))
+}
+
+// DeleteUser deprovisions a user by deactivating the account
+func DeleteUser(ctx *context.Context) {
+	u := getUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	active := false
+	changes := &userChanges{Active: &active}
+	if !checkSelfDeactivation(ctx, u, changes) {
+		return
+	}
+	applyUserChanges(ctx, u, changes)
+	if ctx.Written() {
+		return
+	}
+	log.Trace("Account deprovisioned by SCIM (%s): %s", ctx.User.Name, u.Name)
+	ctx.Status(http.StatusNoContent)
+}
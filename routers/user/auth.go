@@ -12,7 +12,9 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/lockout"
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -164,9 +166,23 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		return
 	}
 
+	if err := lockout.Check(form.UserName, ctx.RemoteAddr()); err != nil {
+		if locked, ok := err.(lockout.ErrLocked); ok {
+			log.Info("Refused authentication attempt for %s from %s: %v", form.UserName, ctx.RemoteAddr(), err)
+			audit.RecordLoginFailed(form.UserName, ctx.RemoteAddr(), "locked")
+			retryAfter := timeutil.MinutesToFriendly(int((locked.RetryAfter()+59)/60), ctx.Locale.Language())
+			ctx.RenderWithErr(ctx.Tr("auth.login_locked", retryAfter), tplSignIn, &form)
+		} else {
+			ctx.ServerError("Check", err)
+		}
+		return
+	}
+
 	u, err := models.UserSignIn(form.UserName, form.Password)
 	if err != nil {
 		if models.IsErrUserNotExist(err) {
+			lockout.Fail(form.UserName, ctx.RemoteAddr())
+			audit.RecordLoginFailed(form.UserName, ctx.RemoteAddr(), "invalid_credentials")
 			ctx.RenderWithErr(ctx.Tr("form.username_password_incorrect"), tplSignIn, &form)
 			log.Info("Failed authentication attempt for %s from %s", form.UserName, ctx.RemoteAddr())
 		} else if models.IsErrEmailAlreadyUsed(err) {
@@ -174,6 +190,7 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 			log.Info("Failed authentication attempt for %s from %s", form.UserName, ctx.RemoteAddr())
 		} else if models.IsErrUserProhibitLogin(err) {
 			log.Info("Failed authentication attempt for %s from %s", form.UserName, ctx.RemoteAddr())
+			audit.RecordLoginFailed(form.UserName, ctx.RemoteAddr(), "prohibited")
 			ctx.Data["Title"] = ctx.Tr("auth.prohibit_login")
 			ctx.HTML(200, "user/auth/prohibit_login")
 		} else if models.IsErrUserInactive(err) {
@@ -190,6 +207,8 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		}
 		return
 	}
+	lockout.Succeed(form.UserName)
+
 	// If this user is enrolled in 2FA, we can't sign the user in just yet.
 	// Instead, redirect them to the 2FA authentication page.
 	_, err = models.GetTwoFactorByUID(u.ID)
@@ -298,6 +317,7 @@ func TwoFactorPost(ctx *context.Context, form auth.TwoFactorAuthForm) {
 		return
 	}
 
+	audit.Record(models.AuditUserLoginFailed, nil, ctx.RemoteAddr(), audit.Target{Type: "user", ID: id}, audit.Diff{"reason": "invalid_passcode"})
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_passcode_incorrect"), tplTwofa, auth.TwoFactorAuthForm{})
 }
 
@@ -363,6 +383,7 @@ func TwoFactorScratchPost(ctx *context.Context, form auth.TwoFactorScratchAuthFo
 		return
 	}
 
+	audit.Record(models.AuditUserLoginFailed, nil, ctx.RemoteAddr(), audit.Target{Type: "user", ID: id}, audit.Diff{"reason": "invalid_scratch_token"})
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_scratch_token_incorrect"), tplTwofaScratch, auth.TwoFactorScratchAuthForm{})
 }
 
@@ -470,6 +491,7 @@ func U2FSign(ctx *context.Context, signResp u2f.SignResponse) {
 			return
 		}
 	}
+	audit.Record(models.AuditUserLoginFailed, nil, ctx.RemoteAddr(), audit.Target{Type: "user", ID: id}, audit.Diff{"reason": "invalid_u2f"})
 	ctx.Error(401)
 }
 
@@ -524,6 +546,7 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 		ctx.ServerError("UpdateUserCols", err)
 		return setting.AppSubURL + "/"
 	}
+	audit.Record(models.AuditUserLogin, u, ctx.RemoteAddr(), u, nil)
 
 	if redirectTo := ctx.GetCookie("redirect_to"); len(redirectTo) > 0 && !util.IsExternalURL(redirectTo) {
 		ctx.SetCookie("redirect_to", "", -1, setting.AppSubURL, "", setting.SessionConfig.Secure, true)
@@ -15,8 +15,10 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup/markdown"
 	"code.gitea.io/gitea/modules/setting"
@@ -181,6 +183,20 @@ func Milestones(ctx *context.Context) {
 	reposQuery := ctx.Query("repos")
 	isShowClosed := ctx.Query("state") == "closed"
 
+	keyword := strings.Trim(ctx.Query("q"), " ")
+	if strings.IndexByte(keyword, 0) >= 0 {
+		keyword = ""
+	}
+	var query *issue_indexer.Query
+	if len(keyword) > 0 {
+		var err error
+		if query, err = issue_indexer.ParseQuery(keyword); err != nil {
+			ctx.Data["QueryError"] = err.Error()
+		} else if !query.IsClosed.IsNone() {
+			isShowClosed = query.IsClosed.IsTrue()
+		}
+	}
+
 	// Get repositories.
 	var err error
 	var userRepoIDs []int64
@@ -418,6 +434,20 @@ func Issues(ctx *context.Context) {
 
 	isShowClosed := ctx.Query("state") == "closed"
 
+	keyword := strings.Trim(ctx.Query("q"), " ")
+	if strings.IndexByte(keyword, 0) >= 0 {
+		keyword = ""
+	}
+	var query *issue_indexer.Query
+	if len(keyword) > 0 {
+		var err error
+		if query, err = issue_indexer.ParseQuery(keyword); err != nil {
+			ctx.Data["QueryError"] = err.Error()
+		} else if !query.IsClosed.IsNone() {
+			isShowClosed = query.IsClosed.IsTrue()
+		}
+	}
+
 	// Get repositories.
 	var err error
 	var userRepoIDs []int64
@@ -465,6 +495,24 @@ func Issues(ctx *context.Context) {
 		opts.MentionedID = ctxUser.ID
 	}
 
+	if len(keyword) > 0 {
+		// the query may only search the repositories the user can read
+		if len(opts.RepoIDs) == 0 {
+			opts.RepoIDs = userRepoIDs
+		}
+		if query == nil {
+			opts.IssueIDs = []int64{-1}
+		} else if err = query.Apply(opts, ctx.User); err != nil {
+			if !issue_indexer.IsErrInvalidQuery(err) {
+				ctx.ServerError("ApplyQuery", err)
+				return
+			}
+			ctx.Data["QueryError"] = err.Error()
+			opts.IssueIDs = []int64{-1}
+		}
+	}
+	queryRepoIDs := opts.RepoIDs
+
 	counts, err := models.CountIssuesByRepo(opts)
 	if err != nil {
 		ctx.ServerError("CountIssuesByRepo", err)
@@ -566,6 +614,33 @@ func Issues(ctx *context.Context) {
 		return
 	}
 
+	if len(keyword) > 0 {
+		// the numbers of issues have to match the query
+		statsOpts := *opts
+		statsOpts.IsClosed = util.OptionalBoolNone
+		if query != nil {
+			statsOpts.IsClosed = query.IsClosed
+		}
+		queryStats, err := models.CountIssueStats(&statsOpts)
+		if err != nil {
+			ctx.ServerError("CountIssueStats", err)
+			return
+		}
+		issueStats.OpenCount, issueStats.ClosedCount = queryStats.OpenCount, queryStats.ClosedCount
+
+		statsOpts.RepoIDs = queryRepoIDs
+		if allIssueStats, err = models.CountIssueStats(&statsOpts); err != nil {
+			ctx.ServerError("CountIssueStats All", err)
+			return
+		}
+	}
+
+	savedQueries, err := models.GetSavedIssueQueries(ctx.User, isPullList)
+	if err != nil {
+		ctx.ServerError("GetSavedIssueQueries", err)
+		return
+	}
+
 	var shownIssues int
 	var totalIssues int
 	if !isShowClosed {
@@ -586,6 +661,13 @@ func Issues(ctx *context.Context) {
 	ctx.Data["RepoIDs"] = repoIDs
 	ctx.Data["IsShowClosed"] = isShowClosed
 	ctx.Data["TotalIssueCount"] = totalIssues
+	ctx.Data["Keyword"] = keyword
+	ctx.Data["SavedQueries"] = savedQueries
+	for _, q := range savedQueries {
+		if q.Query == keyword && (ctx.Data["CurrentSavedQuery"] == nil || q.UserID == ctx.User.ID) {
+			ctx.Data["CurrentSavedQuery"] = q
+		}
+	}
 
 	if isShowClosed {
 		ctx.Data["State"] = "closed"
@@ -599,6 +681,7 @@ func Issues(ctx *context.Context) {
 	ctx.Data["ReposParam"] = string(reposParam)
 
 	pager := context.NewPagination(shownIssues, setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "q", "Keyword")
 	pager.AddParam(ctx, "type", "ViewType")
 	pager.AddParam(ctx, "repos", "ReposParam")
 	pager.AddParam(ctx, "sort", "SortType")
@@ -611,6 +694,74 @@ func Issues(ctx *context.Context) {
 	ctx.HTML(200, tplIssues)
 }
 
+// SavedIssueQueryPost saves the issue search query of the dashboard
+func SavedIssueQueryPost(ctx *context.Context, form auth.SavedIssueQueryForm) {
+	q := &models.SavedIssueQuery{
+		UserID: ctx.User.ID,
+		OrgID:  form.OrgID,
+		Name:   form.Name,
+		Query:  strings.TrimSpace(form.Query),
+		IsPull: form.IsPull,
+	}
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(q.Link())
+		return
+	}
+	if _, err := issue_indexer.ParseQuery(q.Query); err != nil {
+		ctx.Flash.Error(err.Error())
+		ctx.Redirect(q.Link())
+		return
+	}
+
+	if q.OrgID > 0 {
+		isMember, err := models.IsOrganizationMember(q.OrgID, ctx.User.ID)
+		if err != nil {
+			ctx.ServerError("IsOrganizationMember", err)
+			return
+		} else if !isMember {
+			ctx.Error(403)
+			return
+		}
+	}
+
+	if err := models.CreateSavedIssueQuery(q); err != nil {
+		ctx.ServerError("CreateSavedIssueQuery", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("home.issues.saved_query_success", q.Name))
+	ctx.Redirect(q.Link())
+}
+
+// DeleteSavedIssueQuery deletes an issue search query saved by the user
+func DeleteSavedIssueQuery(ctx *context.Context) {
+	q, err := models.GetSavedIssueQueryByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrSavedIssueQueryNotExist(err) {
+			ctx.NotFound("GetSavedIssueQueryByID", err)
+		} else {
+			ctx.ServerError("GetSavedIssueQueryByID", err)
+		}
+		return
+	}
+	// the members of an organization can't delete the queries shared with it
+	if q.UserID != ctx.User.ID {
+		ctx.NotFound("DeleteSavedIssueQuery", nil)
+		return
+	}
+
+	if err = models.DeleteSavedIssueQuery(ctx.User.ID, q.ID); err != nil {
+		ctx.ServerError("DeleteSavedIssueQuery", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("home.issues.saved_query_deleted", q.Name))
+	if q.IsPull {
+		ctx.Redirect(setting.AppSubURL + "/pulls")
+	} else {
+		ctx.Redirect(setting.AppSubURL + "/issues")
+	}
+}
+
 // ShowSSHKeys output all the ssh keys of user by uid
 func ShowSSHKeys(ctx *context.Context, uid int64) {
 	keys, err := models.ListPublicKeys(uid, models.ListOptions{})
@@ -7,13 +7,19 @@ package user
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/eventsource"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/webpush"
 )
 
 const (
@@ -42,10 +48,13 @@ func GetNotificationCount(c *context.Context) {
 // Notifications is the notifications page
 func Notifications(c *context.Context) {
 	var (
-		keyword = strings.Trim(c.Query("q"), " ")
-		status  models.NotificationStatus
-		page    = c.QueryInt("page")
-		perPage = c.QueryInt("perPage")
+		keyword     = strings.Trim(c.Query("q"), " ")
+		reasonName  = c.Query("reason")
+		subjectType = c.Query("type")
+		repoID      = c.QueryInt64("repo")
+		status      models.NotificationStatus
+		page        = c.QueryInt("page")
+		perPage     = c.QueryInt("perPage")
 	)
 	if page < 1 {
 		page = 1
@@ -61,23 +70,78 @@ func Notifications(c *context.Context) {
 		status = models.NotificationStatusUnread
 	}
 
-	total, err := models.GetNotificationCount(c.User, status)
+	opts := models.FindNotificationOptions{
+		UserID:   c.User.ID,
+		Statuses: []models.NotificationStatus{status, models.NotificationStatusPinned},
+	}
+	if reason, ok := models.NotificationReasonFromName(reasonName); ok {
+		opts.Reasons = []models.NotificationReason{reason}
+	} else {
+		reasonName = ""
+	}
+	switch subjectType {
+	case "issue":
+		opts.Sources = []models.NotificationSource{models.NotificationSourceIssue}
+	case "pull":
+		opts.Sources = []models.NotificationSource{models.NotificationSourcePullRequest}
+	default:
+		subjectType = ""
+	}
+
+	// The repositories to filter by are the ones of the notifications matching the other filters
+	repoIDs, err := models.GetNotificationRepoIDs(opts)
+	if err != nil {
+		c.ServerError("GetNotificationRepoIDs", err)
+		return
+	}
+	repoMap, err := models.GetRepositoriesMapByIDs(repoIDs)
+	if err != nil {
+		c.ServerError("GetRepositoriesMapByIDs", err)
+		return
+	}
+	filterRepos := make(models.RepositoryList, 0, len(repoMap))
+	for _, repo := range repoMap {
+		filterRepos = append(filterRepos, repo)
+	}
+	if err := filterRepos.LoadAttributes(); err != nil {
+		c.ServerError("LoadAttributes", err)
+		return
+	}
+	sort.Slice(filterRepos, func(i, j int) bool {
+		return filterRepos[i].FullName() < filterRepos[j].FullName()
+	})
+	if _, ok := repoMap[repoID]; ok {
+		opts.RepoID = repoID
+	} else {
+		repoID = 0
+	}
+
+	total, err := models.CountNotifications(opts)
 	if err != nil {
-		c.ServerError("ErrGetNotificationCount", err)
+		c.ServerError("CountNotifications", err)
 		return
 	}
 
+	c.Data["Keyword"] = keyword
+	c.Data["Reason"] = reasonName
+	c.Data["SubjectType"] = subjectType
+	c.Data["RepoID"] = repoID
+
 	// redirect to last page if request page is more than total pages
 	pager := context.NewPagination(int(total), perPage, page, 5)
+	pager.SetDefaultParams(c)
+	pager.AddParam(c, "reason", "Reason")
+	pager.AddParam(c, "type", "SubjectType")
+	pager.AddParam(c, "repo", "RepoID")
 	if pager.Paginater.Current() < page {
-		c.Redirect(fmt.Sprintf("/notifications?q=%s&page=%d", c.Query("q"), pager.Paginater.Current()))
+		c.Redirect(fmt.Sprintf("%s/notifications?%s&page=%d", setting.AppSubURL, pager.GetParams(), pager.Paginater.Current()))
 		return
 	}
 
-	statuses := []models.NotificationStatus{status, models.NotificationStatusPinned}
-	notifications, err := models.NotificationsForUser(c.User, statuses, page, perPage)
+	opts.ListOptions = models.ListOptions{Page: page, PageSize: perPage}
+	notifications, err := models.GetNotifications(opts)
 	if err != nil {
-		c.ServerError("ErrNotificationsForUser", err)
+		c.ServerError("GetNotifications", err)
 		return
 	}
 
@@ -101,15 +165,15 @@ func Notifications(c *context.Context) {
 	}
 
 	title := c.Tr("notifications")
-	if status == models.NotificationStatusUnread && total > 0 {
-		title = fmt.Sprintf("(%d) %s", total, title)
+	if count, ok := c.Data["NotificationUnreadCount"].(int64); ok && count > 0 {
+		title = fmt.Sprintf("(%d) %s", count, title)
 	}
 	c.Data["Title"] = title
-	c.Data["Keyword"] = keyword
 	c.Data["Status"] = status
 	c.Data["Notifications"] = notifications
-
-	pager.SetDefaultParams(c)
+	c.Data["NotificationReasons"] = models.NotificationReasons
+	c.Data["FilterRepos"] = filterRepos
+	c.Data["IsFiltered"] = reasonName != "" || subjectType != "" || repoID != 0
 	c.Data["Page"] = pager
 
 	c.HTML(200, tplNotification)
@@ -139,6 +203,7 @@ func NotificationStatusPost(c *context.Context) {
 		c.ServerError("SetNotificationStatus", err)
 		return
 	}
+	sendNotificationCount(c.User)
 
 	url := fmt.Sprintf("%s/notifications?page=%s", setting.AppSubURL, c.Query("page"))
 	c.Redirect(url, 303)
@@ -151,7 +216,83 @@ func NotificationPurgePost(c *context.Context) {
 		c.ServerError("ErrUpdateNotificationStatuses", err)
 		return
 	}
+	sendNotificationCount(c.User)
 
 	url := fmt.Sprintf("%s/notifications", setting.AppSubURL)
 	c.Redirect(url, 303)
 }
+
+// NotificationBulkPost is a route for changing the status of several
+// notifications at once, or unsubscribing from them
+func NotificationBulkPost(c *context.Context, form auth.NotificationBulkForm) {
+	if c.HasError() {
+		c.Error(http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	switch form.Action {
+	case "read", "unpin":
+		err = models.SetNotificationsStatus(c.User, form.IDs, models.NotificationStatusRead)
+	case "unread":
+		err = models.SetNotificationsStatus(c.User, form.IDs, models.NotificationStatusUnread)
+	case "pin":
+		err = models.SetNotificationsStatus(c.User, form.IDs, models.NotificationStatusPinned)
+	case "unsubscribe":
+		err = models.UnsubscribeNotifications(c.User, form.IDs)
+	}
+	if err != nil {
+		c.ServerError("NotificationBulkPost", err)
+		return
+	}
+	sendNotificationCount(c.User)
+
+	c.RedirectToFirst(form.RedirectTo, setting.AppSubURL+"/notifications")
+}
+
+// sendNotificationCount updates the unread notification count shown by the
+// other open pages of the user
+func sendNotificationCount(u *models.User) {
+	if !eventsource.GetManager().IsConnected(u.ID) {
+		return
+	}
+	count, err := models.GetNotificationCount(u, models.NotificationStatusUnread)
+	if err != nil {
+		log.Error("GetNotificationCount: %v", err)
+		return
+	}
+	eventsource.GetManager().SendMessage(u.ID, eventsource.NewNotificationCountEvent(count))
+}
+
+// WebPushSubscribePost subscribes the browser of the user to the push notifications
+func WebPushSubscribePost(c *context.Context, form auth.WebPushSubscriptionForm) {
+	if c.HasError() || !webpush.IsAllowedEndpoint(form.Endpoint) || form.P256DH == "" || form.Auth == "" {
+		c.Error(http.StatusBadRequest)
+		return
+	}
+
+	if err := models.CreateWebPushSubscription(&models.WebPushSubscription{
+		UserID:   c.User.ID,
+		Endpoint: form.Endpoint,
+		P256DH:   form.P256DH,
+		Auth:     form.Auth,
+	}); err != nil {
+		c.ServerError("CreateWebPushSubscription", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// WebPushUnsubscribePost unsubscribes the browser of the user from the push notifications
+func WebPushUnsubscribePost(c *context.Context, form auth.WebPushSubscriptionForm) {
+	if c.HasError() {
+		c.Error(http.StatusBadRequest)
+		return
+	}
+
+	if err := models.DeleteWebPushSubscription(c.User.ID, form.Endpoint); err != nil {
+		c.ServerError("DeleteWebPushSubscription", err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
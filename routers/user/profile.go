@@ -95,6 +95,7 @@ func Profile(ctx *context.Context) {
 	ctx.Data["OpenIDs"] = openIDs
 	ctx.Data["EnableHeatmap"] = setting.Service.EnableUserHeatmap
 	ctx.Data["HeatmapUser"] = ctxUser.Name
+	ctx.Data["EnableContainerRegistry"] = setting.ContainerRegistry.Enabled
 	showPrivate := ctx.IsSigned && (ctx.User.IsAdmin || ctx.User.ID == ctxUser.ID)
 
 	orgs, err := models.GetOrgsByUserID(ctxUser.ID, showPrivate)
@@ -208,6 +209,33 @@ func Profile(ctx *context.Context) {
 			return
 		}
 
+		total = int(count)
+	case "container_images":
+		if !setting.ContainerRegistry.Enabled {
+			ctx.NotFound("ContainerRegistry", nil)
+			return
+		}
+		images, count, err := models.FindContainerImages(&models.FindContainerImagesOptions{
+			ListOptions: models.ListOptions{
+				PageSize: setting.UI.User.RepoPagingNum,
+				Page:     page,
+			},
+			OwnerID: ctxUser.ID,
+			Actor:   ctx.User,
+		})
+		if err != nil {
+			ctx.ServerError("FindContainerImages", err)
+			return
+		}
+		for _, img := range images {
+			if err = img.LoadTags(); err != nil {
+				ctx.ServerError("LoadTags", err)
+				return
+			}
+		}
+		ctx.Data["ContainerImages"] = images
+		ctx.Data["ContainerRegistryHost"] = setting.ContainerRegistry.Host
+
 		total = int(count)
 	default:
 		repos, count, err = models.SearchRepository(&models.SearchRepoOptions{
@@ -103,6 +103,30 @@ func EmailPost(ctx *context.Context, form auth.AddEmailForm) {
 			ctx.ServerError("SetEmailNotifications", err)
 			return
 		}
+		if digest := ctx.Query("digest"); digest != "" {
+			if !(digest == models.EmailDigestNone ||
+				digest == models.EmailDigestHourly ||
+				digest == models.EmailDigestDaily) {
+				log.Error("Email notifications digest change returned unrecognized option %s: %s", digest, ctx.User.Name)
+				ctx.ServerError("SetEmailDigest", errors.New("option unrecognized"))
+				return
+			}
+			if err := ctx.User.SetEmailDigest(digest); err != nil {
+				ctx.ServerError("SetEmailDigest", err)
+				return
+			}
+		}
+		if reminders := ctx.Query("reminders"); reminders != "" {
+			if !(reminders == "enabled" || reminders == "disabled") {
+				log.Error("Reminders preference change returned unrecognized option %s: %s", reminders, ctx.User.Name)
+				ctx.ServerError("SetReminders", errors.New("option unrecognized"))
+				return
+			}
+			if err := ctx.User.SetReminders(reminders == "enabled"); err != nil {
+				ctx.ServerError("SetReminders", err)
+				return
+			}
+		}
 		log.Trace("Email notifications preference made %s: %s", preference, ctx.User.Name)
 		ctx.Redirect(setting.AppSubURL + "/user/settings/account")
 		return
@@ -182,6 +206,9 @@ func DeleteAccount(ctx *context.Context) {
 		case models.IsErrUserOwnRepos(err):
 			ctx.Flash.Error(ctx.Tr("form.still_own_repo"))
 			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
+		case models.IsErrUserOwnPackages(err):
+			ctx.Flash.Error(ctx.Tr("form.still_own_packages"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
 		case models.IsErrUserHasOrgs(err):
 			ctx.Flash.Error(ctx.Tr("form.still_has_org"))
 			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
@@ -230,4 +257,6 @@ func loadAccountData(ctx *context.Context) {
 	}
 	ctx.Data["Emails"] = emails
 	ctx.Data["EmailNotificationsPreference"] = ctx.User.EmailNotifications()
+	ctx.Data["EmailNotificationsDigest"] = ctx.User.EmailDigest()
+	ctx.Data["EnableReminders"] = !ctx.User.DisableReminders
 }
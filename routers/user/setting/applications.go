@@ -7,6 +7,7 @@ package setting
 
 import (
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -47,6 +48,7 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
+	ctx.Audit(models.AuditUserAccessTokenCreate, t, nil)
 
 	ctx.Flash.Success(ctx.Tr("settings.generate_token_success"))
 	ctx.Flash.Info(t.Token)
@@ -56,9 +58,11 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 
 // DeleteApplication response for delete user access token
 func DeleteApplication(ctx *context.Context) {
-	if err := models.DeleteAccessTokenByID(ctx.QueryInt64("id"), ctx.User.ID); err != nil {
+	id := ctx.QueryInt64("id")
+	if err := models.DeleteAccessTokenByID(id, ctx.User.ID); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
+		ctx.Audit(models.AuditUserAccessTokenDelete, audit.Target{Type: "access_token", ID: id}, nil)
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
 	}
 
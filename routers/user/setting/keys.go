@@ -7,6 +7,7 @@ package setting
 
 import (
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/audit"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
@@ -63,6 +64,7 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 			}
 			return
 		}
+		ctx.Audit(models.AuditUserGPGKeyAdd, key, nil)
 		ctx.Flash.Success(ctx.Tr("settings.add_gpg_key_success", key.KeyID))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 	case "ssh":
@@ -79,7 +81,8 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 			return
 		}
 
-		if _, err = models.AddPublicKey(ctx.User.ID, form.Title, content, 0); err != nil {
+		key, err := models.AddPublicKey(ctx.User.ID, form.Title, content, 0)
+		if err != nil {
 			ctx.Data["HasSSHError"] = true
 			switch {
 			case models.IsErrKeyAlreadyExist(err):
@@ -97,6 +100,7 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 			}
 			return
 		}
+		ctx.Audit(models.AuditUserKeyAdd, key, nil)
 		ctx.Flash.Success(ctx.Tr("settings.add_key_success", form.Title))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 
@@ -110,17 +114,20 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 // DeleteKey response for delete user's SSH/GPG key
 func DeleteKey(ctx *context.Context) {
 
+	id := ctx.QueryInt64("id")
 	switch ctx.Query("type") {
 	case "gpg":
-		if err := models.DeleteGPGKey(ctx.User, ctx.QueryInt64("id")); err != nil {
+		if err := models.DeleteGPGKey(ctx.User, id); err != nil {
 			ctx.Flash.Error("DeleteGPGKey: " + err.Error())
 		} else {
+			ctx.Audit(models.AuditUserGPGKeyDelete, audit.Target{Type: "gpg_key", ID: id}, nil)
 			ctx.Flash.Success(ctx.Tr("settings.gpg_key_deletion_success"))
 		}
 	case "ssh":
-		if err := models.DeletePublicKey(ctx.User, ctx.QueryInt64("id")); err != nil {
+		if err := models.DeletePublicKey(ctx.User, id); err != nil {
 			ctx.Flash.Error("DeletePublicKey: " + err.Error())
 		} else {
+			ctx.Audit(models.AuditUserKeyDelete, audit.Target{Type: "public_key", ID: id}, nil)
 			ctx.Flash.Success(ctx.Tr("settings.ssh_key_deletion_success"))
 		}
 	default:
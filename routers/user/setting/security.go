@@ -62,6 +62,14 @@ func loadSecurityData(ctx *context.Context) {
 		}
 	}
 	ctx.Data["TwofaEnrolled"] = enrolled
+	ctx.Data["TwofaRequired"] = ctx.User.IsTwoFactorRequired()
+	if !enrolled {
+		ctx.Data["TwofaRequiringOrgs"], err = models.GetOrgsRequiringTwoFactorByUserID(ctx.User.ID)
+		if err != nil {
+			ctx.ServerError("GetOrgsRequiringTwoFactorByUserID", err)
+			return
+		}
+	}
 	if enrolled {
 		ctx.Data["U2FRegistrations"], err = models.GetU2FRegistrationsByUID(ctx.User.ID)
 		if err != nil {
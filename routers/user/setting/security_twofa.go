@@ -43,6 +43,7 @@ func RegenerateScratchTwoFactor(ctx *context.Context) {
 		return
 	}
 
+	ctx.Audit(models.AuditUserTwoFactorScratch, ctx.User, nil)
 	ctx.Flash.Success(ctx.Tr("settings.twofa_scratch_token_regenerated", token))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
 }
@@ -52,6 +53,12 @@ func DisableTwoFactor(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("settings")
 	ctx.Data["PageIsSettingsSecurity"] = true
 
+	if ctx.User.IsTwoFactorRequired() {
+		ctx.Flash.Error(ctx.Tr("settings.twofa_disable_required"))
+		ctx.Redirect(setting.AppSubURL + "/user/settings/security")
+		return
+	}
+
 	t, err := models.GetTwoFactorByUID(ctx.User.ID)
 	if err != nil {
 		ctx.ServerError("SettingsTwoFactor", err)
@@ -63,6 +70,7 @@ func DisableTwoFactor(ctx *context.Context) {
 		return
 	}
 
+	ctx.Audit(models.AuditUserTwoFactorDisable, ctx.User, nil)
 	ctx.Flash.Success(ctx.Tr("settings.twofa_disabled"))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
 }
@@ -205,6 +213,7 @@ func EnrollTwoFactorPost(ctx *context.Context, form auth.TwoFactorAuthForm) {
 		ctx.ServerError("SettingsTwoFactor", err)
 		return
 	}
+	ctx.Audit(models.AuditUserTwoFactorEnroll, ctx.User, nil)
 	ctx.Flash.Success(ctx.Tr("settings.twofa_enrolled", token))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
 }
@@ -70,10 +70,12 @@ func U2FRegisterPost(ctx *context.Context, response u2f.RegisterResponse) {
 		ctx.ServerError("u2f.Register", err)
 		return
 	}
-	if _, err = models.CreateRegistration(ctx.User, name, reg); err != nil {
+	registration, err := models.CreateRegistration(ctx.User, name, reg)
+	if err != nil {
 		ctx.ServerError("u2f.Register", err)
 		return
 	}
+	ctx.Audit(models.AuditUserU2FRegister, registration, nil)
 	ctx.Status(200)
 }
 
@@ -96,6 +98,7 @@ func U2FDelete(ctx *context.Context, form auth.U2FDeleteForm) {
 		ctx.ServerError("DeleteRegistration", err)
 		return
 	}
+	ctx.Audit(models.AuditUserU2FDelete, reg, nil)
 	ctx.JSON(200, map[string]interface{}{
 		"redirect": setting.AppSubURL + "/user/settings/security",
 	})
@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// addToTaskQueue regenerates the patch of a pull request and tests its conflicts,
+// it is set by the pull request service, which depends on this one, once its
+// task queue runs
+var addToTaskQueue = func(pr *models.PullRequest) {}
+
+// SetPullRequestTaskQueue sets the function adding the reopened pull requests to
+// the task queue testing their patches
+func SetPullRequestTaskQueue(add func(pr *models.PullRequest)) {
+	addToTaskQueue = add
+}
+
+// BulkEdit applies the changes to the issues of the repository in a single transaction,
+// notifies the edited issues together, and tests the patches of the reopened pull requests
+func BulkEdit(doer *models.User, repo *models.Repository, issues []*models.Issue, opts *models.BulkEditIssuesOptions) ([]*models.BulkEditedIssue, error) {
+	edited, err := models.BulkEditIssues(doer, repo, issues, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.NotifyBulkEditIssues(doer, repo, edited)
+
+	// Regenerate the patches of the reopened pull requests and test their conflicts
+	for _, issue := range edited {
+		if issue.StatusComment == nil || !issue.IsPull || issue.IsClosed {
+			continue
+		}
+		if err = issue.LoadPullRequest(); err != nil {
+			log.Error("LoadPullRequest[%d]: %v", issue.ID, err)
+			continue
+		}
+		addToTaskQueue(issue.PullRequest)
+	}
+	return edited, nil
+}
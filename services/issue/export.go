@@ -0,0 +1,170 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+)
+
+// The formats of the exported and imported issues
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ExportedIssue represents an issue in the exported files, the lists are
+// comma separated and the times are formatted as RFC 3339 in the CSV files
+type ExportedIssue struct {
+	Index     int64    `json:"index"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	State     string   `json:"state"`
+	Poster    string   `json:"poster"`
+	Labels    []string `json:"labels"`
+	Assignees []string `json:"assignees"`
+	Milestone string   `json:"milestone"`
+	// TrackedTime is the total time spent on the issue in seconds
+	TrackedTime int64      `json:"tracked_time"`
+	Deadline    *time.Time `json:"deadline"`
+	Created     time.Time  `json:"created"`
+	Updated     time.Time  `json:"updated"`
+	Closed      *time.Time `json:"closed"`
+}
+
+// ExportFields are the columns of the exported CSV files
+var ExportFields = []string{"index", "title", "body", "state", "poster", "labels", "assignees", "milestone",
+	"tracked_time", "deadline", "created", "updated", "closed"}
+
+// ContentType returns the MIME type of the files in the format
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ExportIssues writes the issues to w in the format
+func ExportIssues(w io.Writer, format string, issues []*models.Issue) error {
+	if err := models.IssueList(issues).LoadAttributes(); err != nil {
+		return err
+	}
+
+	exported := make([]*ExportedIssue, 0, len(issues))
+	for _, issue := range issues {
+		exported = append(exported, toExportedIssue(issue))
+	}
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, exported)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exported)
+	}
+	return fmt.Errorf("unknown format: %s", format)
+}
+
+func toExportedIssue(issue *models.Issue) *ExportedIssue {
+	exported := &ExportedIssue{
+		Index:       issue.Index,
+		Title:       issue.Title,
+		Body:        issue.Content,
+		State:       string(issue.State()),
+		Labels:      make([]string, 0, len(issue.Labels)),
+		Assignees:   make([]string, 0, len(issue.Assignees)),
+		TrackedTime: issue.TotalTrackedTime,
+		Created:     issue.CreatedUnix.AsTime(),
+		Updated:     issue.UpdatedUnix.AsTime(),
+	}
+	if issue.Poster != nil {
+		exported.Poster = issue.Poster.Name
+	} else {
+		exported.Poster = issue.OriginalAuthor
+	}
+	for _, label := range issue.Labels {
+		exported.Labels = append(exported.Labels, label.Name)
+	}
+	for _, assignee := range issue.Assignees {
+		exported.Assignees = append(exported.Assignees, assignee.Name)
+	}
+	if issue.Milestone != nil {
+		exported.Milestone = issue.Milestone.Name
+	}
+	if issue.DeadlineUnix != 0 {
+		exported.Deadline = issue.DeadlineUnix.AsTimePtr()
+	}
+	if issue.IsClosed && issue.ClosedUnix != 0 {
+		exported.Closed = issue.ClosedUnix.AsTimePtr()
+	}
+	return exported
+}
+
+// csvFormulaPrefixes start the cells spreadsheets evaluate as formulas, and
+// the quote escaping them
+const csvFormulaPrefixes = "=+-@\t\r'"
+
+// escapeCSVCell prefixes the cells which would be evaluated as formulas by
+// spreadsheets with a quote, which unescapeCSVCell removes on import
+func escapeCSVCell(cell string) string {
+	if cell != "" && strings.IndexByte(csvFormulaPrefixes, cell[0]) >= 0 {
+		return "'" + cell
+	}
+	return cell
+}
+
+func unescapeCSVCell(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.IndexByte(csvFormulaPrefixes, cell[1]) >= 0 {
+		return cell[1:]
+	}
+	return cell
+}
+
+func writeCSV(w io.Writer, issues []*ExportedIssue) error {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ExportFields); err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		record := []string{
+			strconv.FormatInt(issue.Index, 10),
+			issue.Title,
+			issue.Body,
+			issue.State,
+			issue.Poster,
+			strings.Join(issue.Labels, ","),
+			strings.Join(issue.Assignees, ","),
+			issue.Milestone,
+			strconv.FormatInt(issue.TrackedTime, 10),
+			formatTime(issue.Deadline),
+			formatTime(&issue.Created),
+			formatTime(&issue.Updated),
+			formatTime(issue.Closed),
+		}
+		for i := range record {
+			record[i] = escapeCSVCell(record[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
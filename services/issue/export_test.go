@@ -0,0 +1,67 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportIssues(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+
+	var buf bytes.Buffer
+	assert.NoError(t, ExportIssues(&buf, FormatCSV, []*models.Issue{issue}))
+	table, err := ParseImportFile(&buf, FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, ExportFields, table.Columns)
+	if assert.Len(t, table.Rows, 1) {
+		row := table.Rows[0]
+		assert.Equal(t, []string{"1", "issue1", "content for the first issue", "open", "user1", "label1", "user1", ""}, row[:8])
+		assert.Equal(t, time.Unix(946684800, 0).Format(time.RFC3339), row[10])
+		assert.Empty(t, row[12])
+	}
+
+	buf.Reset()
+	assert.NoError(t, ExportIssues(&buf, FormatJSON, []*models.Issue{issue}))
+	table, err = ParseImportFile(&buf, FormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, ExportFields, table.Columns)
+	if assert.Len(t, table.Rows, 1) {
+		assert.Equal(t, "issue1", table.Rows[0][1])
+		assert.Equal(t, "label1", table.Rows[0][5])
+	}
+}
+
+func TestExportIssuesCSVFormulas(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	issue.Title = `=HYPERLINK("http://example.com","click")`
+	issue.Content = "'quoted"
+
+	// the cells are not evaluated by spreadsheets
+	var buf bytes.Buffer
+	assert.NoError(t, ExportIssues(&buf, FormatCSV, []*models.Issue{issue}))
+	assert.Contains(t, buf.String(), `"'=HYPERLINK(""http://example.com"",""click"")"`)
+	assert.Contains(t, buf.String(), ",''quoted,")
+
+	// and imported back unchanged
+	table, err := ParseImportFile(&buf, FormatCSV)
+	assert.NoError(t, err)
+	if assert.Len(t, table.Rows, 1) {
+		assert.Equal(t, issue.Title, table.Rows[0][1])
+		assert.Equal(t, issue.Content, table.Rows[0][2])
+	}
+
+	buf.Reset()
+	assert.NoError(t, ExportIssues(&buf, FormatJSON, []*models.Issue{issue}))
+	assert.Contains(t, buf.String(), `"title": "=HYPERLINK(\"http://example.com\",\"click\")"`)
+}
@@ -0,0 +1,463 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/unknwon/com"
+)
+
+// ImportFields are the issue fields the columns of the imported files can be mapped to
+var ImportFields = []string{"title", "body", "state", "labels", "assignees", "milestone",
+	"deadline", "created", "updated", "closed"}
+
+// ErrInvalidImport represents an imported file which cannot be read or mapped to issues
+type ErrInvalidImport struct {
+	Reason string
+}
+
+// IsErrInvalidImport checks if an error is a ErrInvalidImport.
+func IsErrInvalidImport(err error) bool {
+	_, ok := err.(ErrInvalidImport)
+	return ok
+}
+
+func (err ErrInvalidImport) Error() string {
+	return fmt.Sprintf("invalid import: %s", err.Reason)
+}
+
+// ErrImportInterrupted represents an import stopped by a row whose issue cannot be created,
+// once the issues of the previous rows were created
+type ErrImportInterrupted struct {
+	Row     int
+	Created int
+	Err     error
+}
+
+// IsErrImportInterrupted checks if an error is a ErrImportInterrupted.
+func IsErrImportInterrupted(err error) bool {
+	_, ok := err.(ErrImportInterrupted)
+	return ok
+}
+
+func (err ErrImportInterrupted) Error() string {
+	return fmt.Sprintf("import interrupted at row %d after creating %d issues: %v", err.Row, err.Created, err.Err)
+}
+
+// ImportTable holds the columns and the rows of an imported file
+type ImportTable struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// ImportedIssue is a row of an imported file mapped to an issue
+type ImportedIssue struct {
+	// Row is the position of the row in the file, starting at 1 after the header
+	Row         int
+	Issue       *models.Issue
+	LabelIDs    []int64
+	AssigneeIDs []int64
+	// Errors are the reasons why the row cannot be imported
+	Errors []string
+}
+
+// ReadImportFile reads an imported file, which cannot be larger than the configured max size
+func ReadImportFile(r io.Reader) ([]byte, error) {
+	maxSize := setting.Repository.Issue.ImportMaxSize * 1024 * 1024
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrInvalidImport{fmt.Sprintf("the file is larger than %d MB", setting.Repository.Issue.ImportMaxSize)}
+	}
+	return data, nil
+}
+
+// ParseImportFile reads the columns and the rows of a file in the format. A CSV file starts
+// with the names of its columns, a JSON file is a list of objects whose keys are the columns.
+// The files larger than the configured max size or with more rows than the max rows are invalid.
+func ParseImportFile(r io.Reader, format string) (*ImportTable, error) {
+	data, err := ReadImportFile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var table *ImportTable
+	switch format {
+	case FormatCSV:
+		table, err = parseCSV(bytes.NewReader(data))
+	case FormatJSON:
+		table, err = parseJSON(bytes.NewReader(data))
+	default:
+		return nil, ErrInvalidImport{fmt.Sprintf("unknown format %q", format)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(table.Rows) > setting.Repository.Issue.ImportMaxRows {
+		return nil, ErrInvalidImport{fmt.Sprintf("the file has more than %d rows", setting.Repository.Issue.ImportMaxRows)}
+	}
+	return table, nil
+}
+
+func parseCSV(r io.Reader) (*ImportTable, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, ErrInvalidImport{err.Error()}
+	}
+	if len(records) == 0 {
+		return nil, ErrInvalidImport{"the file is empty"}
+	}
+	// spreadsheets often prepend a byte order mark
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	for _, record := range records[1:] {
+		for i := range record {
+			record[i] = unescapeCSVCell(record[i])
+		}
+	}
+	return &ImportTable{Columns: records[0], Rows: records[1:]}, nil
+}
+
+func parseJSON(r io.Reader) (*ImportTable, error) {
+	var objects []map[string]interface{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&objects); err != nil {
+		return nil, ErrInvalidImport{err.Error()}
+	}
+
+	// the known fields come first, in the order of the exports
+	table := &ImportTable{}
+	seen := make(map[string]bool)
+	for _, field := range ExportFields {
+		for _, object := range objects {
+			if _, ok := object[field]; ok {
+				table.Columns = append(table.Columns, field)
+				seen[field] = true
+				break
+			}
+		}
+	}
+	var others []string
+	for _, object := range objects {
+		for key := range object {
+			if !seen[key] {
+				others = append(others, key)
+				seen[key] = true
+			}
+		}
+	}
+	sort.Strings(others)
+	table.Columns = append(table.Columns, others...)
+
+	table.Rows = make([][]string, 0, len(objects))
+	for _, object := range objects {
+		row := make([]string, len(table.Columns))
+		for i, column := range table.Columns {
+			row[i] = jsonValueToString(object[column])
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func jsonValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, jsonValueToString(item))
+		}
+		return strings.Join(values, ",")
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// DefaultImportMapping maps the columns named after an issue field to it,
+// the other columns are mapped to nothing
+func DefaultImportMapping(columns []string) []string {
+	fields := make([]string, len(columns))
+	used := make(map[string]bool)
+	for i, column := range columns {
+		field := strings.ToLower(strings.TrimSpace(column))
+		if com.IsSliceContainsStr(ImportFields, field) && !used[field] {
+			fields[i] = field
+			used[field] = true
+		}
+	}
+	return fields
+}
+
+// PrepareImport maps the rows of the table to new issues of the repository posted by the doer,
+// fields holding the issue field of each column or an empty string to ignore it. The issues are
+// not created, the rows which cannot be imported are returned with their errors.
+func PrepareImport(doer *models.User, repo *models.Repository, table *ImportTable, fields []string) ([]*ImportedIssue, error) {
+	if len(fields) != len(table.Columns) {
+		return nil, ErrInvalidImport{"the mapping does not match the columns"}
+	}
+	columns := make(map[string]int, len(fields))
+	for i, field := range fields {
+		if field == "" {
+			continue
+		}
+		if !com.IsSliceContainsStr(ImportFields, field) {
+			return nil, ErrInvalidImport{fmt.Sprintf("unknown field %q", field)}
+		}
+		if _, ok := columns[field]; ok {
+			return nil, ErrInvalidImport{fmt.Sprintf("several columns are mapped to %q", field)}
+		}
+		columns[field] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, ErrInvalidImport{"no column is mapped to the title"}
+	}
+
+	labels, err := models.GetLabelsByRepoID(repo.ID, "", models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	labelIDs := make(map[string]int64, len(labels))
+	for _, label := range labels {
+		labelIDs[label.Name] = label.ID
+	}
+	milestones, err := models.GetMilestonesByRepoID(repo.ID, api.StateAll, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	milestoneIDs := make(map[string]int64, len(milestones))
+	for _, milestone := range milestones {
+		milestoneIDs[milestone.Name] = milestone.ID
+	}
+	assignees := &assigneeChecker{repo: repo, ids: make(map[string]int64), errors: make(map[string]string)}
+
+	imported := make([]*ImportedIssue, 0, len(table.Rows))
+	for i, row := range table.Rows {
+		value := func(field string) string {
+			if column, ok := columns[field]; ok && column < len(row) {
+				return row[column]
+			}
+			return ""
+		}
+		result := &ImportedIssue{
+			Row: i + 1,
+			Issue: &models.Issue{
+				RepoID:   repo.ID,
+				Repo:     repo,
+				Title:    strings.TrimSpace(value("title")),
+				PosterID: doer.ID,
+				Poster:   doer,
+				Content:  value("body"),
+			},
+		}
+		issue := result.Issue
+
+		if issue.Title == "" {
+			result.Errors = append(result.Errors, "the title is empty")
+		} else if utf8.RuneCountInString(issue.Title) > 255 {
+			result.Errors = append(result.Errors, "the title is longer than 255 characters")
+		}
+
+		switch state := strings.ToLower(strings.TrimSpace(value("state"))); state {
+		case "", string(api.StateOpen):
+		case string(api.StateClosed):
+			issue.IsClosed = true
+		default:
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown state %q", state))
+		}
+
+		for _, name := range splitList(value("labels")) {
+			if id, ok := labelIDs[name]; ok {
+				result.LabelIDs = append(result.LabelIDs, id)
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("unknown label %q", name))
+			}
+		}
+
+		for _, name := range splitList(value("assignees")) {
+			id, err := assignees.check(name)
+			if err != nil {
+				return nil, err
+			}
+			if id > 0 {
+				result.AssigneeIDs = append(result.AssigneeIDs, id)
+			} else {
+				result.Errors = append(result.Errors, assignees.errors[name])
+			}
+		}
+
+		if name := strings.TrimSpace(value("milestone")); name != "" {
+			if id, ok := milestoneIDs[name]; ok {
+				issue.MilestoneID = id
+			} else {
+				result.Errors = append(result.Errors, fmt.Sprintf("unknown milestone %q", name))
+			}
+		}
+
+		for _, field := range []string{"deadline", "created", "updated", "closed"} {
+			t, err := parseImportTime(value(field))
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("invalid %s time %q", field, value(field)))
+				continue
+			}
+			if t.IsZero() {
+				continue
+			}
+			switch field {
+			case "deadline":
+				issue.DeadlineUnix = timeutil.TimeStamp(time.Date(t.Year(), t.Month(), t.Day(),
+					23, 59, 59, 0, t.Location()).Unix())
+			case "created":
+				issue.CreatedUnix = timeutil.TimeStamp(t.Unix())
+			case "updated":
+				issue.UpdatedUnix = timeutil.TimeStamp(t.Unix())
+			case "closed":
+				issue.ClosedUnix = timeutil.TimeStamp(t.Unix())
+			}
+		}
+
+		imported = append(imported, result)
+	}
+	return imported, nil
+}
+
+// splitList returns the trimmed and unique values of a comma separated list
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" && !com.IsSliceContainsStr(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseImportTime parses a time formatted as RFC 3339 or a date, the zero time is returned for an empty value
+func parseImportTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// assigneeChecker looks up the users who can be assigned in the repository by name
+type assigneeChecker struct {
+	repo   *models.Repository
+	ids    map[string]int64
+	errors map[string]string
+}
+
+// check returns the ID of the user, or 0 if the user cannot be assigned and the reason is in errors
+func (c *assigneeChecker) check(name string) (int64, error) {
+	if id, ok := c.ids[name]; ok {
+		return id, nil
+	}
+	if _, ok := c.errors[name]; ok {
+		return 0, nil
+	}
+
+	u, err := models.GetUserByName(name)
+	if err != nil {
+		if !models.IsErrUserNotExist(err) {
+			return 0, err
+		}
+		c.errors[name] = fmt.Sprintf("unknown user %q", name)
+		return 0, nil
+	}
+	canBeAssigned := false
+	if !u.IsOrganization() {
+		if canBeAssigned, err = models.CanBeAssigned(u, c.repo, false); err != nil {
+			return 0, err
+		}
+	}
+	if !canBeAssigned {
+		c.errors[name] = fmt.Sprintf("user %q cannot be assigned", name)
+		return 0, nil
+	}
+	c.ids[name] = u.ID
+	return u.ID, nil
+}
+
+// ImportIssues creates the issues of the imported rows, which must have no errors,
+// with the state and the times of the rows. The issues are created one by one: if
+// a row fails, the issues already created, including the one of the row if it got
+// that far, are kept and returned with an ErrImportInterrupted.
+func ImportIssues(doer *models.User, repo *models.Repository, imported []*ImportedIssue) ([]*models.Issue, error) {
+	for _, row := range imported {
+		if len(row.Errors) > 0 {
+			return nil, ErrInvalidImport{fmt.Sprintf("row %d: %s", row.Row, row.Errors[0])}
+		}
+	}
+
+	issues := make([]*models.Issue, 0, len(imported))
+	for _, row := range imported {
+		issue := row.Issue
+		isClosed, createdUnix, updatedUnix, closedUnix := issue.IsClosed, issue.CreatedUnix, issue.UpdatedUnix, issue.ClosedUnix
+		issue.IsClosed = false
+		if err := models.NewIssue(repo, issue, row.LabelIDs, nil); err != nil {
+			return issues, ErrImportInterrupted{Row: row.Row, Created: len(issues), Err: err}
+		}
+		issues = append(issues, issue)
+		for _, assigneeID := range row.AssigneeIDs {
+			if err := AddAssigneeIfNotAssigned(issue, issue.Poster, assigneeID); err != nil {
+				return issues, ErrImportInterrupted{Row: row.Row, Created: len(issues), Err: err}
+			}
+		}
+		notification.NotifyNewIssue(issue)
+
+		if isClosed {
+			if err := ChangeStatus(issue, doer, true); err != nil {
+				return issues, ErrImportInterrupted{Row: row.Row, Created: len(issues), Err: err}
+			}
+		}
+
+		if createdUnix != 0 || updatedUnix != 0 || closedUnix != 0 {
+			if createdUnix != 0 {
+				issue.CreatedUnix = createdUnix
+			}
+			if updatedUnix != 0 {
+				issue.UpdatedUnix = updatedUnix
+			}
+			if closedUnix != 0 && isClosed {
+				issue.ClosedUnix = closedUnix
+			}
+			if err := models.UpdateIssueTimestamps(issue); err != nil {
+				return issues, ErrImportInterrupted{Row: row.Row, Created: len(issues), Err: err}
+			}
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImportFile(t *testing.T) {
+	table, err := ParseImportFile(strings.NewReader("\ufeffTitle,Labels,Notes\nfirst,\"label1, label2\",x\n"), FormatCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Title", "Labels", "Notes"}, table.Columns)
+	assert.Equal(t, [][]string{{"first", "label1, label2", "x"}}, table.Rows)
+	assert.Equal(t, []string{"title", "labels", ""}, DefaultImportMapping(table.Columns))
+
+	table, err = ParseImportFile(strings.NewReader(`[{"title": "first", "labels": ["label1", "label2"], "extra": 1}, {"title": "second", "state": null}]`), FormatJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"title", "state", "labels", "extra"}, table.Columns)
+	assert.Equal(t, [][]string{{"first", "", "label1,label2", "1"}, {"second", "", "", ""}}, table.Rows)
+
+	_, err = ParseImportFile(strings.NewReader("title\nfirst,second\n"), FormatCSV)
+	assert.True(t, IsErrInvalidImport(err))
+	_, err = ParseImportFile(strings.NewReader(`{"title": "first"}`), FormatJSON)
+	assert.True(t, IsErrInvalidImport(err))
+}
+
+func TestParseImportFileLimits(t *testing.T) {
+	defer func(maxSize int64, maxRows int) {
+		setting.Repository.Issue.ImportMaxSize = maxSize
+		setting.Repository.Issue.ImportMaxRows = maxRows
+	}(setting.Repository.Issue.ImportMaxSize, setting.Repository.Issue.ImportMaxRows)
+	setting.Repository.Issue.ImportMaxSize = 1
+	setting.Repository.Issue.ImportMaxRows = 2
+
+	table, err := ParseImportFile(strings.NewReader("title\nfirst\nsecond\n"), FormatCSV)
+	assert.NoError(t, err)
+	assert.Len(t, table.Rows, 2)
+
+	_, err = ParseImportFile(strings.NewReader("title\nfirst\nsecond\nthird\n"), FormatCSV)
+	assert.True(t, IsErrInvalidImport(err))
+	_, err = ParseImportFile(strings.NewReader(`[{"title": "first"}, {"title": "second"}, {"title": "third"}]`), FormatJSON)
+	assert.True(t, IsErrInvalidImport(err))
+
+	// a single row larger than the max size
+	_, err = ParseImportFile(strings.NewReader("title\n"+strings.Repeat("a", 1024*1024)+"\n"), FormatCSV)
+	assert.True(t, IsErrInvalidImport(err))
+	_, err = ReadImportFile(strings.NewReader(strings.Repeat("a", 1024*1024)))
+	assert.NoError(t, err)
+}
+
+func TestImportIssues(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	table := &ImportTable{
+		Columns: []string{"title", "state", "labels", "assignees", "milestone", "created", "closed", "ignored"},
+		Rows: [][]string{
+			{"imported", "closed", "label1,label2", "user2", "milestone1", "2019-01-01T00:00:00Z", "2019-02-01", "x"},
+			{"", "merged", "missing", "user4", "missing", "yesterday", "", ""},
+		},
+	}
+	fields := DefaultImportMapping(table.Columns)
+
+	// the fields are mapped to at most one column and the title to one
+	_, err := PrepareImport(doer, repo, table, []string{"title", "title", "", "", "", "", "", ""})
+	assert.True(t, IsErrInvalidImport(err))
+	_, err = PrepareImport(doer, repo, table, make([]string, len(table.Columns)))
+	assert.True(t, IsErrInvalidImport(err))
+
+	imported, err := PrepareImport(doer, repo, table, fields)
+	assert.NoError(t, err)
+	if assert.Len(t, imported, 2) {
+		assert.Empty(t, imported[0].Errors)
+		assert.Equal(t, []int64{1, 2}, imported[0].LabelIDs)
+		assert.Equal(t, []int64{2}, imported[0].AssigneeIDs)
+		assert.EqualValues(t, 1, imported[0].Issue.MilestoneID)
+		assert.Equal(t, []string{
+			"the title is empty",
+			`unknown state "merged"`,
+			`unknown label "missing"`,
+			`user "user4" cannot be assigned`,
+			`unknown milestone "missing"`,
+			`invalid created time "yesterday"`,
+		}, imported[1].Errors)
+	}
+
+	// nothing is created if a row is invalid
+	_, err = ImportIssues(doer, repo, imported)
+	assert.True(t, IsErrInvalidImport(err))
+	models.AssertNotExistsBean(t, &models.Issue{RepoID: repo.ID, Title: "imported"})
+
+	issues, err := ImportIssues(doer, repo, imported[:1])
+	assert.NoError(t, err)
+	if assert.Len(t, issues, 1) {
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: issues[0].ID}).(*models.Issue)
+		assert.Equal(t, "imported", issue.Title)
+		assert.True(t, issue.IsClosed)
+		assert.EqualValues(t, 1546300800, issue.CreatedUnix)
+		closed, _ := time.ParseInLocation("2006-01-02", "2019-02-01", time.Local)
+		assert.EqualValues(t, closed.Unix(), issue.ClosedUnix)
+		models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: 2})
+		models.AssertExistsAndLoadBean(t, &models.IssueAssignees{IssueID: issue.ID, AssigneeID: 2})
+	}
+	models.CheckConsistencyFor(t, &models.Repository{ID: repo.ID}, &models.Milestone{ID: 1})
+}
+
+func TestImportIssuesInterrupted(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	newRow := func(row int, title string, assigneeIDs ...int64) *ImportedIssue {
+		return &ImportedIssue{
+			Row:         row,
+			Issue:       &models.Issue{RepoID: repo.ID, Repo: repo, Title: title, PosterID: doer.ID, Poster: doer},
+			AssigneeIDs: assigneeIDs,
+		}
+	}
+
+	// the assignee of the second row was deleted since the rows were prepared
+	issues, err := ImportIssues(doer, repo, []*ImportedIssue{
+		newRow(1, "first imported"),
+		newRow(2, "second imported", 404),
+		newRow(3, "third imported"),
+	})
+	if assert.True(t, IsErrImportInterrupted(err)) {
+		interrupted := err.(ErrImportInterrupted)
+		assert.Equal(t, 2, interrupted.Row)
+		assert.Equal(t, 2, interrupted.Created)
+	}
+	assert.Len(t, issues, 2)
+	models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Title: "first imported"})
+	models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Title: "second imported"})
+	models.AssertNotExistsBean(t, &models.Issue{RepoID: repo.ID, Title: "third imported"})
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// ReviewRequest requests a user to review a pull request, or withdraws the
+// request, and makes a pull request comment for it if it changed
+func ReviewRequest(issue *models.Issue, doer, reviewer *models.User, isAdd bool) (comment *models.Comment, err error) {
+	if isAdd {
+		if err = models.IsValidReviewRequest(issue, reviewer); err != nil {
+			return nil, err
+		}
+		comment, err = models.AddReviewRequest(issue, reviewer, doer)
+	} else {
+		comment, err = models.RemoveReviewRequest(issue, reviewer, doer)
+	}
+	if err != nil || comment == nil {
+		return comment, err
+	}
+
+	notification.NotifyPullReviewRequest(doer, issue, reviewer, isAdd, comment)
+	return comment, nil
+}
+
+// TeamReviewRequest requests a team to review a pull request, or withdraws
+// the request, and makes a pull request comment for it if it changed. The
+// members of the team are notified of the request, unless the team picks
+// the member to review it.
+func TeamReviewRequest(issue *models.Issue, doer *models.User, team *models.Team, isAdd bool) (comment *models.Comment, err error) {
+	if !isAdd {
+		return models.RemoveTeamReviewRequest(issue, team, doer)
+	}
+
+	if err = models.IsValidTeamReviewRequest(issue, team); err != nil {
+		return nil, err
+	}
+	if comment, err = models.AddTeamReviewRequest(issue, team, doer); err != nil || comment == nil {
+		return comment, err
+	}
+
+	reviewer, err := models.PickTeamReviewer(issue, team, doer)
+	if err != nil {
+		return nil, err
+	} else if reviewer != nil {
+		if _, err = ReviewRequest(issue, doer, reviewer, true); err != nil {
+			return nil, err
+		}
+		return comment, nil
+	}
+
+	members, err := models.GetTeamMembers(team.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if member.ID == doer.ID {
+			continue
+		}
+		if err = models.IsValidReviewRequest(issue, member); err != nil {
+			if models.IsErrNotValidReviewRequest(err) {
+				continue
+			}
+			return nil, err
+		}
+		notification.NotifyPullReviewRequest(doer, issue, member, true, comment)
+	}
+	return comment, nil
+}
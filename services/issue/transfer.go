@@ -0,0 +1,33 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issue
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/notification"
+)
+
+// TransferIssue moves the issue to another repository where the doer can write issues
+func TransferIssue(doer *models.User, issue *models.Issue, newRepo *models.Repository) error {
+	perm, err := models.GetUserRepoPermission(newRepo, doer)
+	if err != nil {
+		return err
+	}
+	if !perm.CanWrite(models.UnitTypeIssues) {
+		return models.ErrUserDoesNotHaveAccessToRepo{UserID: doer.ID, RepoName: newRepo.FullName()}
+	}
+
+	if err = issue.LoadRepo(); err != nil {
+		return err
+	}
+	oldRepo := issue.Repo
+	comment, err := models.TransferIssue(doer, issue, newRepo)
+	if err != nil {
+		return err
+	}
+
+	notification.NotifyTransferIssue(doer, issue, oldRepo, comment)
+	return nil
+}
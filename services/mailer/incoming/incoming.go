@@ -0,0 +1,198 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/upload"
+	comment_service "code.gitea.io/gitea/services/comments"
+	"code.gitea.io/gitea/services/mailer/token"
+)
+
+// ErrRejected represents a mail which is refused, e.g. because its user is
+// not allowed to comment on the issue anymore
+type ErrRejected struct {
+	Reason string
+}
+
+// IsErrRejected checks if an error is a ErrRejected.
+func IsErrRejected(err error) bool {
+	_, ok := err.(ErrRejected)
+	return ok
+}
+
+func (err ErrRejected) Error() string {
+	return err.Reason
+}
+
+// Listen starts the server receiving the replies to the notification mails
+func Listen() {
+	network := "tcp"
+	if strings.HasPrefix(setting.IncomingEmail.ListenAddr, "/") {
+		network = "unix"
+	}
+	srv := newServer()
+	go func() {
+		gracefulServer := graceful.NewServer(network, setting.IncomingEmail.ListenAddr)
+		if err := gracefulServer.ListenAndServe(srv.serve); err != nil {
+			log.Critical("Failed to start incoming mail server: %v", err)
+		}
+		log.Info("Incoming mail Listener: %s Closed", setting.IncomingEmail.ListenAddr)
+	}()
+}
+
+// Unused informs our cleanup routine that we will not be using an incoming mail listener
+func Unused() {
+	graceful.GetManager().InformCleanup()
+}
+
+func newServer() *server {
+	return &server{
+		lmtp:     setting.IncomingEmail.Protocol == "lmtp",
+		hostname: setting.Domain,
+		maxSize:  setting.IncomingEmail.MaximumMessageSize,
+		verify:   verifyRecipient,
+		deliver:  handleMail,
+	}
+}
+
+// verifyRecipient checks that the address is a reply address with a valid token
+func verifyRecipient(address string) error {
+	tok, ok := token.FromAddress(address)
+	if !ok {
+		return ErrRejected{"No such user here"}
+	}
+	if _, _, _, err := token.ExtractToken(tok); err != nil {
+		if err == token.ErrInvalidToken {
+			return ErrRejected{"No such user here"}
+		}
+		return err
+	}
+	return nil
+}
+
+// handleMail performs the action of the token of the recipient on the mail
+func handleMail(recipient string, data []byte) error {
+	tok, ok := token.FromAddress(recipient)
+	if !ok {
+		return ErrRejected{"No such user here"}
+	}
+	ht, user, issueID, err := token.ExtractToken(tok)
+	if err != nil {
+		if err == token.ErrInvalidToken {
+			return ErrRejected{"No such user here"}
+		}
+		return err
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return ErrRejected{"Malformed message"}
+	}
+	// Never act on the replies of vacation responders and the like
+	if autoSubmitted := msg.Header.Get("Auto-Submitted"); autoSubmitted != "" && !strings.EqualFold(autoSubmitted, "no") {
+		log.Trace("Ignoring automatic mail to %s", recipient)
+		return nil
+	}
+
+	if !user.IsActive || user.ProhibitLogin {
+		return ErrRejected{"The user is not allowed to sign in"}
+	}
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			return ErrRejected{"The issue does not exist anymore"}
+		}
+		return err
+	}
+	if err = issue.LoadRepo(); err != nil {
+		return err
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, user)
+	if err != nil {
+		return err
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return ErrRejected{"The user can not access the issue anymore"}
+	}
+
+	if ht == token.UnsubscribeHandlerType {
+		return unsubscribe(user, issue)
+	}
+
+	content, err := parseMail(data)
+	if err != nil {
+		return ErrRejected{fmt.Sprintf("Malformed message: %v", err)}
+	}
+	reply := stripReply(content.Text)
+	if isUnsubscribeRequest(reply) {
+		return unsubscribe(user, issue)
+	}
+	return createComment(user, issue, perm, reply, content.Attachments)
+}
+
+func unsubscribe(user *models.User, issue *models.Issue) error {
+	if err := models.CreateOrUpdateIssueWatch(user.ID, issue.ID, false); err != nil {
+		return fmt.Errorf("CreateOrUpdateIssueWatch: %v", err)
+	}
+	log.Trace("User %d unsubscribed from issue %d by mail", user.ID, issue.ID)
+	return nil
+}
+
+func createComment(user *models.User, issue *models.Issue, perm models.Permission, reply string, attachments []*mailAttachment) error {
+	if issue.Repo.IsArchived {
+		return ErrRejected{"The repository is archived"}
+	}
+	if issue.IsLocked && !perm.CanWriteIssuesOrPulls(issue.IsPull) && !user.IsAdmin {
+		return ErrRejected{"The conversation is locked"}
+	}
+
+	uuids := make([]string, 0, len(attachments))
+	if setting.AttachmentEnabled {
+		allowedTypes := strings.Split(setting.AttachmentAllowedTypes, ",")
+		for _, attachment := range attachments {
+			if int64(len(attachment.Content)) > setting.AttachmentMaxSize<<20 {
+				log.Info("Attachment %s of the reply of user %d is too large", attachment.Name, user.ID)
+				continue
+			}
+			buf := attachment.Content
+			if len(buf) > 512 {
+				buf = buf[:512]
+			}
+			if upload.VerifyAllowedContentType(buf, allowedTypes) != nil {
+				log.Info("Attachment %s of the reply of user %d is of type %s which is not allowed", attachment.Name, user.ID, http.DetectContentType(buf))
+				continue
+			}
+			attach, err := models.NewAttachment(&models.Attachment{
+				UploaderID: user.ID,
+				Name:       attachment.Name,
+			}, attachment.Content, bytes.NewReader(nil))
+			if err != nil {
+				return fmt.Errorf("NewAttachment: %v", err)
+			}
+			uuids = append(uuids, attach.UUID)
+		}
+	}
+
+	if reply == "" && len(uuids) == 0 {
+		return ErrRejected{"The reply is empty"}
+	}
+
+	comment, err := comment_service.CreateIssueComment(user, issue.Repo, issue, reply, uuids)
+	if err != nil {
+		return fmt.Errorf("CreateIssueComment: %v", err)
+	}
+	log.Trace("Comment %d created by mail by user %d on issue %d", comment.ID, user.ID, issue.ID)
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startTestServer(t *testing.T, protocol string) (string, func()) {
+	setting.Domain = "localhost"
+	setting.SecretKey = "secret"
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@localhost"
+	setting.IncomingEmail.Protocol = protocol
+	setting.IncomingEmail.MaximumMessageSize = 1024 * 1024
+	setting.AttachmentEnabled = true
+	setting.AttachmentPath = filepath.Join(setting.AppDataPath, "attachments")
+	setting.AttachmentAllowedTypes = "image/png"
+	setting.AttachmentMaxSize = 4
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		_ = newServer().serve(l)
+	}()
+	return l.Addr().String(), func() {
+		l.Close()
+	}
+}
+
+func replyAddress(t *testing.T, ht token.HandlerType, userID, issueID int64) string {
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: userID}).(*models.User)
+	return token.Address(token.CreateToken(ht, user, issueID))
+}
+
+func composeMail(headers, body string) []byte {
+	return []byte(strings.Replace("From: user@example.com\nSubject: Re: issue\n"+headers+"\n"+body, "\n", "\r\n", -1))
+}
+
+func TestIncomingReply(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	addr, stop := startTestServer(t, "smtp")
+	defer stop()
+
+	to := replyAddress(t, token.ReplyHandlerType, 2, 1)
+	err := smtp.SendMail(addr, nil, "user2@example.com", []string{to}, composeMail(
+		"Content-Type: multipart/mixed; boundary=\"b\"\n", `--b
+Content-Type: text/plain
+
+Sounds like a plan.
+
+On Mon, Jan 2, 2006 at 3:04 PM User1 <user1@example.com> wrote:
+> Shall we fix it?
+--b
+Content-Type: image/png
+Content-Disposition: attachment; filename="screenshot.png"
+Content-Transfer-Encoding: base64
+
+iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==
+--b
+Content-Type: application/x-msdownload
+Content-Disposition: attachment; filename="virus.exe"
+
+MZ
+--b--
+`))
+	assert.NoError(t, err)
+
+	comment := models.AssertExistsAndLoadBean(t, &models.Comment{
+		Type:     models.CommentTypeComment,
+		PosterID: 2,
+		IssueID:  1,
+		Content:  "Sounds like a plan.",
+	}).(*models.Comment)
+	// only the allowed attachments are kept
+	attachment := models.AssertExistsAndLoadBean(t, &models.Attachment{CommentID: comment.ID}).(*models.Attachment)
+	assert.Equal(t, "screenshot.png", attachment.Name)
+	assert.EqualValues(t, 1, models.GetCount(t, &models.Attachment{CommentID: comment.ID}))
+
+	// the token of another address is rejected before receiving the mail
+	err = smtp.SendMail(addr, nil, "user2@example.com", []string{"user2@localhost"}, composeMail("", "Hi"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "550")
+	}
+
+	// a user can't reply to an issue it can't access anymore
+	err = smtp.SendMail(addr, nil, "user5@example.com", []string{replyAddress(t, token.ReplyHandlerType, 5, 4)}, composeMail("", "Hi"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "550")
+	}
+	models.AssertNotExistsBean(t, &models.Comment{PosterID: 5, IssueID: 4})
+
+	// the replies of vacation responders are dropped
+	err = smtp.SendMail(addr, nil, "user2@example.com", []string{to}, composeMail("Auto-Submitted: auto-replied\n", "I'm away"))
+	assert.NoError(t, err)
+	models.AssertNotExistsBean(t, &models.Comment{PosterID: 2, IssueID: 1, Content: "I'm away"})
+}
+
+func TestIncomingUnsubscribe(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	addr, stop := startTestServer(t, "smtp")
+	defer stop()
+
+	// replying unsubscribe mutes the issue
+	err := smtp.SendMail(addr, nil, "user2@example.com", []string{replyAddress(t, token.ReplyHandlerType, 2, 1)}, composeMail("", "Unsubscribe\n\n> Shall we fix it?\n"))
+	assert.NoError(t, err)
+	models.AssertExistsAndLoadBean(t, &models.IssueWatch{UserID: 2, IssueID: 1, IsWatching: false})
+	models.AssertNotExistsBean(t, &models.Comment{PosterID: 2, IssueID: 1, Content: "Unsubscribe"})
+
+	// as does any mail sent to the List-Unsubscribe address
+	err = smtp.SendMail(addr, nil, "user4@example.com", []string{replyAddress(t, token.UnsubscribeHandlerType, 4, 1)}, composeMail("", ""))
+	assert.NoError(t, err)
+	models.AssertExistsAndLoadBean(t, &models.IssueWatch{UserID: 4, IssueID: 1, IsWatching: false})
+}
+
+func TestIncomingLMTP(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	addr, stop := startTestServer(t, "lmtp")
+	defer stop()
+
+	conn, err := textproto.Dial("tcp", addr)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	cmd := func(expectCode int, format string, args ...interface{}) {
+		id, err := conn.Cmd(format, args...)
+		assert.NoError(t, err)
+		conn.StartResponse(id)
+		defer conn.EndResponse(id)
+		_, _, err = conn.ReadResponse(expectCode)
+		assert.NoError(t, err, format)
+	}
+
+	_, _, err = conn.ReadResponse(220)
+	assert.NoError(t, err)
+	cmd(500, "EHLO localhost")
+	cmd(250, "LHLO localhost")
+	cmd(250, "MAIL FROM:<user@example.com>")
+	cmd(250, "RCPT TO:<%s>", replyAddress(t, token.ReplyHandlerType, 2, 1))
+	cmd(250, "RCPT TO:<%s>", replyAddress(t, token.ReplyHandlerType, 5, 4))
+	cmd(354, "DATA")
+
+	// one reply per recipient
+	w := conn.DotWriter()
+	_, err = fmt.Fprint(w, string(composeMail("", "Delivered by LMTP")))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	_, _, err = conn.ReadResponse(250)
+	assert.NoError(t, err)
+	_, _, err = conn.ReadResponse(550)
+	assert.NoError(t, err)
+	cmd(221, "QUIT")
+
+	models.AssertExistsAndLoadBean(t, &models.Comment{PosterID: 2, IssueID: 1, Content: "Delivered by LMTP"})
+	models.AssertNotExistsBean(t, &models.Comment{PosterID: 5, IssueID: 4})
+}
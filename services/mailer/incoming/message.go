@@ -0,0 +1,136 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+	"golang.org/x/net/html/charset"
+)
+
+// maxMIMEDepth is the maximum nesting of the multipart bodies of a mail
+const maxMIMEDepth = 10
+
+// mailContent is the content of a received mail relevant to its handlers
+type mailContent struct {
+	Text        string
+	HTML        string
+	Attachments []*mailAttachment
+}
+
+// mailAttachment is a file attached to a received mail
+type mailAttachment struct {
+	Name    string
+	Content []byte
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// parseMail extracts the text and the attachments of a mail
+func parseMail(data []byte) (*mailContent, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("ReadMessage: %v", err)
+	}
+
+	content := &mailContent{}
+	if err = content.parsePart(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	if content.Text == "" && content.HTML != "" {
+		if content.Text, err = html2text.FromString(content.HTML); err != nil {
+			return nil, fmt.Errorf("html2text: %v", err)
+		}
+	}
+	return content, nil
+}
+
+func (c *mailContent) parsePart(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	body = decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body)
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMIMEDepth {
+			return fmt.Errorf("too many nested multipart bodies")
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("NextPart: %v", err)
+			}
+			if err = c.parsePart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := dispositionParams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if decoded, err := wordDecoder.DecodeHeader(name); err == nil {
+		name = decoded
+	}
+
+	if disposition == "attachment" || name != "" {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("ReadAll: %v", err)
+		}
+		if name == "" {
+			name = "attachment"
+		}
+		c.Attachments = append(c.Attachments, &mailAttachment{Name: name, Content: data})
+		return nil
+	}
+
+	switch mediaType {
+	case "text/plain", "text/html":
+		if cs, ok := params["charset"]; ok {
+			if body, err = charset.NewReaderLabel(cs, body); err != nil {
+				return fmt.Errorf("unsupported charset %s: %v", cs, err)
+			}
+		}
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("ReadAll: %v", err)
+		}
+		// The first alternative is the one kept
+		if mediaType == "text/plain" && c.Text == "" {
+			c.Text = string(data)
+		} else if mediaType == "text/html" && c.HTML == "" {
+			c.HTML = string(data)
+		}
+	}
+	return nil
+}
+
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
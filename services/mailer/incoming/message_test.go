@@ -0,0 +1,74 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMail(t *testing.T) {
+	// plain text in another charset
+	content, err := parseMail([]byte("From: user2@example.com\r\n" +
+		"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Caf=E9 =\r\nbreak\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Café break\r\n", content.Text)
+	assert.Empty(t, content.Attachments)
+
+	// the text alternative is preferred and attachments are extracted
+	content, err = parseMail([]byte(strings.Replace(`From: user2@example.com
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+
+Text body
+--inner
+Content-Type: text/html; charset=utf-8
+
+<p>HTML body</p>
+--inner--
+--outer
+Content-Type: image/png; name="=?utf-8?q?sch=C3=A9ma.png?="
+Content-Disposition: attachment
+Content-Transfer-Encoding: base64
+
+iVBORw0KGgo=
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment; filename="notes.txt"
+
+some notes
+--outer--
+`, "\n", "\r\n", -1)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Text body", content.Text)
+	assert.Equal(t, "<p>HTML body</p>", content.HTML)
+	if assert.Len(t, content.Attachments, 2) {
+		assert.Equal(t, "schéma.png", content.Attachments[0].Name)
+		assert.Equal(t, []byte("\x89PNG\r\n\x1a\n"), content.Attachments[0].Content)
+		assert.Equal(t, "notes.txt", content.Attachments[1].Name)
+		assert.Equal(t, "some notes", string(content.Attachments[1].Content))
+	}
+
+	// HTML only mails are converted to text
+	content, err = parseMail([]byte("From: user2@example.com\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<div>Looks <b>good</b></div>\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Looks *good*", content.Text)
+
+	_, err = parseMail([]byte("not a mail"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// replyHeaderPattern matches the line introducing the quoted mail, e.g.
+	// "On Mon, Jan 2, 2006 at 3:04 PM User <user@example.com> wrote:"
+	replyHeaderPattern = regexp.MustCompile(`^On\s.*\swrote:$`)
+	// forwardHeaderPattern matches the first line of the header of the quoted
+	// mail added by some clients instead of quoting it
+	forwardHeaderPattern = regexp.MustCompile(`^-+\s*Original Message\s*-+$|^_{20,}$`)
+)
+
+// stripReply removes the quoted mail and the signature from the text of a
+// reply. Quotes interleaved with the reply are kept since they are valid
+// markdown.
+func stripReply(text string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if line == "-- " || trimmed == "--" || forwardHeaderPattern.MatchString(trimmed) ||
+			(strings.HasPrefix(trimmed, "From: ") && i+1 < len(lines) && isOutlookHeaderLine(lines[i+1])) {
+			lines = lines[:i]
+			break
+		}
+	}
+
+	lines = trimQuotedLines(lines)
+	if n := len(lines); n > 0 && replyHeaderPattern.MatchString(strings.TrimSpace(lines[n-1])) {
+		lines = lines[:n-1]
+	} else if n > 1 && replyHeaderPattern.MatchString(strings.TrimSpace(lines[n-2])+" "+strings.TrimSpace(lines[n-1])) {
+		// the header is often wrapped
+		lines = lines[:n-2]
+	}
+	lines = trimQuotedLines(lines)
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// trimQuotedLines removes the trailing quoted and blank lines
+func trimQuotedLines(lines []string) []string {
+	for len(lines) > 0 {
+		trimmed := strings.TrimSpace(lines[len(lines)-1])
+		if trimmed != "" && !strings.HasPrefix(trimmed, ">") {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isOutlookHeaderLine(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "Sent: ") || strings.HasPrefix(line, "Date: ")
+}
+
+// isUnsubscribeRequest returns whether the stripped reply asks to stop the
+// notifications of the issue
+func isUnsubscribeRequest(reply string) bool {
+	return strings.EqualFold(strings.TrimSpace(reply), "unsubscribe")
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStripReply(t *testing.T) {
+	for text, expected := range map[string]string{
+		"Looks good to me.": "Looks good to me.",
+		"Looks good to me.\r\n\r\nOn Mon, Jan 2, 2006 at 3:04 PM User2 <user2@example.com> wrote:\r\n> Please review\r\n> the change\r\n": "Looks good to me.",
+		"Looks good to me.\n\nOn Mon, Jan 2, 2006 at 3:04 PM User2\n<user2@example.com> wrote:\n\n> Please review\n":                      "Looks good to me.",
+		"> Please review\n\nDone.\n\n> and the tests?\n\nAdded.\n\n> Thanks\n":                                                            "> Please review\n\nDone.\n\n> and the tests?\n\nAdded.",
+		"Thanks!\n-- \nUser2\nGitea developer\n":                                                                      "Thanks!",
+		"Thanks!\n\n-----Original Message-----\nFrom: Gitea\nPlease review\n":                                         "Thanks!",
+		"Thanks!\n\nFrom: Gitea <gitea@example.com>\nSent: Monday, January 2, 2006 3:04 PM\nSubject: Please review\n": "Thanks!",
+		"Thanks!\n\n________________________________\nFrom: Gitea\n":                                                  "Thanks!",
+		"On second thought, this is fine.\n":                                                                          "On second thought, this is fine.",
+		"> quoted only\n":                                                                                             "",
+	} {
+		assert.Equal(t, expected, stripReply(text), text)
+	}
+}
+
+func TestIsUnsubscribeRequest(t *testing.T) {
+	assert.True(t, isUnsubscribeRequest("unsubscribe"))
+	assert.True(t, isUnsubscribeRequest(" Unsubscribe\n"))
+	assert.False(t, isUnsubscribeRequest("please unsubscribe me"))
+}
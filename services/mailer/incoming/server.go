@@ -0,0 +1,284 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package incoming
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	maxRecipients = 100
+	idleTimeout   = 5 * time.Minute
+)
+
+// server is a minimal SMTP (RFC 5321) or LMTP (RFC 2033) server, meant to sit
+// behind the mail server of the instance which relays the replies to it.
+type server struct {
+	lmtp     bool
+	hostname string
+	maxSize  int64
+
+	// verify checks a recipient before the mail is received
+	verify func(recipient string) error
+	// deliver handles the mail received for a verified recipient
+	deliver func(recipient string, data []byte) error
+}
+
+// session is the state of a mail transaction
+type session struct {
+	greeted    bool
+	from       string
+	hasFrom    bool
+	recipients []string
+}
+
+func (s *session) reset() {
+	s.from = ""
+	s.hasFrom = false
+	s.recipients = nil
+}
+
+// serve accepts the connections of the listener until it is closed
+func (srv *server) serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		go srv.handleConn(conn)
+	}
+}
+
+func (srv *server) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	name := "ESMTP"
+	if srv.lmtp {
+		name = "LMTP"
+	}
+	if tp.PrintfLine("220 %s %s Service ready", srv.hostname, name) != nil {
+		return
+	}
+
+	s := &session{}
+	for {
+		_ = conn.SetDeadline(time.Now().Add(idleTimeout))
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg := line, ""
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			verb, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+
+		var code int
+		var msg string
+		switch verb = strings.ToUpper(verb); verb {
+		case "HELO", "EHLO", "LHLO":
+			if srv.lmtp != (verb == "LHLO") {
+				code, msg = 500, "Unrecognized command"
+				break
+			}
+			s.reset()
+			s.greeted = true
+			if verb == "HELO" {
+				code, msg = 250, srv.hostname
+				break
+			}
+			if err = tp.PrintfLine("250-%s", srv.hostname); err == nil {
+				err = tp.PrintfLine("250-PIPELINING")
+			}
+			if err == nil {
+				err = tp.PrintfLine("250-8BITMIME")
+			}
+			if err == nil {
+				err = tp.PrintfLine("250 SIZE %d", srv.maxSize)
+			}
+			if err != nil {
+				return
+			}
+			continue
+		case "MAIL":
+			code, msg = srv.handleMail(s, arg)
+		case "RCPT":
+			code, msg = srv.handleRcpt(s, arg)
+		case "DATA":
+			if len(s.recipients) == 0 {
+				code, msg = 503, "Bad sequence of commands"
+				break
+			}
+			if srv.handleData(tp, s) != nil {
+				return
+			}
+			s.reset()
+			continue
+		case "RSET":
+			s.reset()
+			code, msg = 250, "OK"
+		case "NOOP":
+			code, msg = 250, "OK"
+		case "VRFY":
+			code, msg = 252, "Cannot VRFY user"
+		case "QUIT":
+			_ = tp.PrintfLine("221 %s Service closing transmission channel", srv.hostname)
+			return
+		default:
+			code, msg = 502, "Command not implemented"
+		}
+		if tp.PrintfLine("%d %s", code, msg) != nil {
+			return
+		}
+	}
+}
+
+func (srv *server) handleMail(s *session, arg string) (int, string) {
+	if !s.greeted || s.hasFrom {
+		return 503, "Bad sequence of commands"
+	}
+	if len(arg) < 5 || !strings.EqualFold(arg[:5], "FROM:") {
+		return 501, "Syntax error in parameters"
+	}
+	from, params, err := parsePath(arg[5:])
+	if err != nil {
+		return 501, "Syntax error in parameters"
+	}
+	for _, param := range params {
+		if len(param) > 5 && strings.EqualFold(param[:5], "SIZE=") {
+			if size, err := strconv.ParseInt(param[5:], 10, 64); err == nil && size > srv.maxSize {
+				return 552, "Message size exceeds fixed maximum message size"
+			}
+		}
+	}
+	s.from = from
+	s.hasFrom = true
+	return 250, "OK"
+}
+
+func (srv *server) handleRcpt(s *session, arg string) (int, string) {
+	if !s.hasFrom {
+		return 503, "Bad sequence of commands"
+	}
+	if len(arg) < 3 || !strings.EqualFold(arg[:3], "TO:") {
+		return 501, "Syntax error in parameters"
+	}
+	recipient, _, err := parsePath(arg[3:])
+	if err != nil || recipient == "" {
+		return 501, "Syntax error in parameters"
+	}
+	if len(s.recipients) >= maxRecipients {
+		return 452, "Too many recipients"
+	}
+	if err = srv.verify(recipient); err != nil {
+		return 550, err.Error()
+	}
+	s.recipients = append(s.recipients, recipient)
+	return 250, "OK"
+}
+
+func (srv *server) handleData(tp *textproto.Conn, s *session) error {
+	if err := tp.PrintfLine("354 Start mail input; end with <CRLF>.<CRLF>"); err != nil {
+		return err
+	}
+
+	r := tp.DotReader()
+	data, err := ioutil.ReadAll(io.LimitReader(r, srv.maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) > srv.maxSize {
+		if _, err = io.Copy(ioutil.Discard, r); err != nil {
+			return err
+		}
+		return srv.replyAll(tp, s, 552, "Message size exceeds fixed maximum message size")
+	}
+
+	// An LMTP server replies for each recipient, an SMTP server once for all
+	// of them, so the mail is only bounced if it couldn't be delivered at all.
+	code, msg := 250, "OK"
+	delivered := false
+	for _, recipient := range s.recipients {
+		rcode, rmsg := 250, "OK"
+		if err := srv.deliver(recipient, data); err != nil {
+			if IsErrRejected(err) {
+				rcode, rmsg = 550, err.Error()
+			} else {
+				log.Error("Unable to handle the incoming mail from %s to %s: %v", s.from, recipient, err)
+				rcode, rmsg = 451, "Requested action aborted: local error in processing"
+			}
+		}
+		if srv.lmtp {
+			if err := tp.PrintfLine("%d %s", rcode, rmsg); err != nil {
+				return err
+			}
+		} else if rcode == 250 {
+			delivered = true
+		} else if !delivered {
+			code, msg = rcode, rmsg
+		}
+	}
+	if srv.lmtp {
+		return nil
+	}
+	if delivered {
+		code, msg = 250, "OK"
+	}
+	return tp.PrintfLine("%d %s", code, msg)
+}
+
+func (srv *server) replyAll(tp *textproto.Conn, s *session, code int, msg string) error {
+	count := 1
+	if srv.lmtp {
+		count = len(s.recipients)
+	}
+	for i := 0; i < count; i++ {
+		if err := tp.PrintfLine("%d %s", code, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parsePath parses the reverse-path or forward-path argument of the MAIL and
+// RCPT commands, returning the address and the ESMTP parameters
+func parsePath(arg string) (string, []string, error) {
+	arg = strings.TrimSpace(arg)
+	if !strings.HasPrefix(arg, "<") {
+		return "", nil, fmt.Errorf("missing <")
+	}
+	end := strings.IndexByte(arg, '>')
+	if end < 0 {
+		return "", nil, fmt.Errorf("missing >")
+	}
+	address := arg[1:end]
+	params := strings.Fields(arg[end+1:])
+	if address == "" {
+		// null reverse-path of bounces
+		return "", params, nil
+	}
+	// Drop the obsolete source route
+	if i := strings.IndexByte(address, ':'); i >= 0 && strings.HasPrefix(address, "@") {
+		address = address[i+1:]
+	}
+	if _, err := mail.ParseAddress(address); err != nil {
+		return "", nil, err
+	}
+	return address, params, nil
+}
@@ -21,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/markup/markdown"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/mailer/token"
 
 	"gopkg.in/gomail.v2"
 )
@@ -163,34 +164,22 @@ func SendCollaboratorMail(u, doer *models.User, repo *models.Repository) {
 	SendAsync(msg)
 }
 
-func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMention bool, info string) []*Message {
+func composeIssueCommentMessages(ctx *mailCommentContext, recipients []*models.User, fromMention bool, info string) []*Message {
 
 	var (
 		subject string
-		link    string
 		prefix  string
 		// Fall back subject for bad templates, make sure subject is never empty
 		fallback       string
 		reviewComments []*models.Comment
 	)
 
-	commentType := models.CommentTypeComment
-	if ctx.Comment != nil {
-		commentType = ctx.Comment.Type
-		link = ctx.Issue.HTMLURL() + "#" + ctx.Comment.HashTag()
-	} else {
-		link = ctx.Issue.HTMLURL()
-	}
-
-	reviewType := models.ReviewTypeComment
-	if ctx.Comment != nil && ctx.Comment.Review != nil {
-		reviewType = ctx.Comment.Review.Type
-	}
+	link := ctx.link()
 
 	// This is the body of the new issue or comment, not the mail body
 	body := string(markup.RenderByType(markdown.MarkupName, []byte(ctx.Content), ctx.Issue.Repo.HTMLURL(), ctx.Issue.Repo.ComposeMetas()))
 
-	actType, actName, tplName := actionToTemplate(ctx.Issue, ctx.ActionType, commentType, reviewType)
+	actType, actName, tplName := ctx.action()
 
 	if actName != "new" {
 		prefix = "Re: "
@@ -221,6 +210,7 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 		"ActionType":      actType,
 		"ActionName":      actName,
 		"ReviewComments":  reviewComments,
+		"CanReply":        setting.IncomingEmail.Enabled,
 	}
 
 	var mailSubject bytes.Buffer
@@ -242,9 +232,9 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 	}
 
 	// Make sure to compose independent messages to avoid leaking user emails
-	msgs := make([]*Message, 0, len(tos))
-	for _, to := range tos {
-		msg := NewMessageFrom([]string{to}, ctx.Doer.DisplayName(), setting.MailService.FromEmail, subject, mailBody.String())
+	msgs := make([]*Message, 0, len(recipients))
+	for _, recipient := range recipients {
+		msg := NewMessageFrom([]string{recipient.Email}, ctx.Doer.DisplayName(), setting.MailService.FromEmail, subject, mailBody.String())
 		msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
 
 		// Set Message-ID on first message so replies know what to reference
@@ -254,6 +244,12 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 			msg.SetHeader("In-Reply-To", "<"+ctx.Issue.ReplyReference()+">")
 			msg.SetHeader("References", "<"+ctx.Issue.ReplyReference()+">")
 		}
+
+		// Replies are posted as comments of the recipient
+		if setting.IncomingEmail.Enabled {
+			msg.SetHeader("Reply-To", token.Address(token.CreateToken(token.ReplyHandlerType, recipient, ctx.Issue.ID)))
+			msg.SetHeader("List-Unsubscribe", "<mailto:"+token.Address(token.CreateToken(token.UnsubscribeHandlerType, recipient, ctx.Issue.ID))+">")
+		}
 		msgs = append(msgs, msg)
 	}
 
@@ -270,25 +266,76 @@ func sanitizeSubject(subject string) string {
 }
 
 // SendIssueAssignedMail composes and sends issue assigned email
-func SendIssueAssignedMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, tos []string) {
-	SendAsyncs(composeIssueCommentMessages(&mailCommentContext{
+func SendIssueAssignedMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, recipients []*models.User) error {
+	notified, err := filterIssueMailRecipients(issue, recipients)
+	if err != nil {
+		return err
+	}
+
+	return sendIssueCommentMails(&mailCommentContext{
+		Issue:      issue,
+		Doer:       doer,
+		ActionType: models.ActionType(0),
+		Content:    content,
+		Comment:    comment,
+	}, notified, false, "issue assigned")
+}
+
+// SendReviewRequestMail composes and sends the mail requesting the review of a pull request
+func SendReviewRequestMail(issue *models.Issue, doer *models.User, content string, comment *models.Comment, recipients []*models.User) error {
+	notified, err := filterIssueMailRecipients(issue, recipients)
+	if err != nil {
+		return err
+	}
+
+	return sendIssueCommentMails(&mailCommentContext{
 		Issue:      issue,
 		Doer:       doer,
 		ActionType: models.ActionType(0),
 		Content:    content,
 		Comment:    comment,
-	}, tos, false, "issue assigned"))
+	}, notified, false, "review requested")
+}
+
+// SendIssueReminderMail sends a scheduled reminder of the issue to the recipients
+func SendIssueReminderMail(issue *models.Issue, doer *models.User, content string, recipients []*models.User) error {
+	if err := issue.LoadRepo(); err != nil {
+		return fmt.Errorf("LoadRepo(): %v", err)
+	}
+	notified, err := filterIssueMailRecipients(issue, recipients)
+	if err != nil {
+		return err
+	}
+
+	return sendIssueCommentMails(&mailCommentContext{
+		Issue:      issue,
+		Doer:       doer,
+		ActionType: models.ActionType(0),
+		Content:    content,
+		ActionName: "reminder",
+	}, notified, false, "reminder")
+}
+
+// filterIssueMailRecipients removes the recipients who muted the issues of the repository
+func filterIssueMailRecipients(issue *models.Issue, recipients []*models.User) ([]*models.User, error) {
+	levels, err := models.GetRepoNotificationLevels(issue.RepoID)
+	if err != nil {
+		return nil, fmt.Errorf("GetRepoNotificationLevels(%d): %v", issue.RepoID, err)
+	}
+	notified := make([]*models.User, 0, len(recipients))
+	for _, recipient := range recipients {
+		if level := levels[recipient.ID]; level != models.RepoNotificationLevelReleases && level != models.RepoNotificationLevelMuted {
+			notified = append(notified, recipient)
+		}
+	}
+	return notified, nil
 }
 
 // actionToTemplate returns the type and name of the action facing the user
 // (slightly different from models.ActionType) and the name of the template to use (based on availability)
 func actionToTemplate(issue *models.Issue, actionType models.ActionType,
 	commentType models.CommentType, reviewType models.ReviewType) (typeName, name, template string) {
-	if issue.IsPull {
-		typeName = "pull"
-	} else {
-		typeName = "issue"
-	}
+	typeName = issueTypeName(issue)
 	switch actionType {
 	case models.ActionCreateIssue, models.ActionCreatePullRequest:
 		name = "new"
@@ -315,12 +362,26 @@ func actionToTemplate(issue *models.Issue, actionType models.ActionType,
 			name = "code"
 		case models.CommentTypeAssignees:
 			name = "assigned"
+		case models.CommentTypeReviewRequest:
+			name = "review_request"
 		default:
 			name = "default"
 		}
 	}
+	return typeName, name, actionTemplate(typeName, name)
+}
+
+func issueTypeName(issue *models.Issue) string {
+	if issue.IsPull {
+		return "pull"
+	}
+	return "issue"
+}
 
-	template = typeName + "/" + name
+// actionTemplate returns the name of the template of the action, falling back
+// to the one of the issues and to the default one when it does not exist
+func actionTemplate(typeName, name string) string {
+	template := typeName + "/" + name
 	ok := bodyTemplates.Lookup(template) != nil
 	if !ok && typeName != "issue" {
 		template = "issue/" + name
@@ -333,5 +394,5 @@ func actionToTemplate(issue *models.Issue, actionType models.ActionType,
 	if !ok {
 		template = "issue/default"
 	}
-	return
+	return template
 }
@@ -0,0 +1,160 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	mailNotifyBulkEdit base.TplName = "notify/bulk_edit"
+)
+
+// bulkEditEvent is what happened to an issue edited at once with other ones,
+// as the name of the action of its mail when changed alone
+type bulkEditEvent struct {
+	ActionName string
+	Verb       string
+	Comment    *models.Comment
+}
+
+type bulkEditThread struct {
+	Issue  *models.Issue
+	Events []*bulkEditEvent
+}
+
+// bulkEditMails gathers the threads to mail about to each recipient, in the
+// order of the edited issues
+type bulkEditMails struct {
+	recipients []int64
+	threads    map[int64][]*bulkEditThread
+}
+
+func (m *bulkEditMails) add(userID int64, issue *models.Issue, event *bulkEditEvent) {
+	threads, ok := m.threads[userID]
+	if !ok {
+		m.recipients = append(m.recipients, userID)
+	}
+	if len(threads) == 0 || threads[len(threads)-1].Issue.ID != issue.ID {
+		threads = append(threads, &bulkEditThread{Issue: issue})
+	}
+	thread := threads[len(threads)-1]
+	thread.Events = append(thread.Events, event)
+	m.threads[userID] = threads
+}
+
+// MailIssuesBulkEdited sends a single mail to each user who would have been
+// mailed about the issues of the repository if they were changed one by one:
+// the subscribers of the closed and reopened issues, and the new assignees.
+func MailIssuesBulkEdited(doer *models.User, repo *models.Repository, issues []*models.BulkEditedIssue) error {
+	levels, err := models.GetRepoNotificationLevels(repo.ID)
+	if err != nil {
+		return fmt.Errorf("GetRepoNotificationLevels(%d): %v", repo.ID, err)
+	}
+
+	mails := &bulkEditMails{threads: make(map[int64][]*bulkEditThread)}
+	for _, issue := range issues {
+		issue.Repo = repo
+		if issue.StatusComment != nil {
+			event := &bulkEditEvent{ActionName: "close", Comment: issue.StatusComment}
+			if !issue.IsClosed {
+				event.ActionName = "reopen"
+			}
+			event.Verb = digestVerbs[event.ActionName]
+			subscribed, visited, err := getIssueSubscribers(issue.Issue, doer, 0)
+			if err != nil {
+				return err
+			}
+			for _, id := range subscribed {
+				if !visited[id] {
+					visited[id] = true
+					mails.add(id, issue.Issue, event)
+				}
+			}
+		}
+
+		for _, assignee := range issue.AddedAssignees {
+			if level := levels[assignee.ID]; assignee.ID == doer.ID ||
+				level == models.RepoNotificationLevelReleases || level == models.RepoNotificationLevelMuted {
+				continue
+			}
+			mails.add(assignee.ID, issue.Issue, &bulkEditEvent{ActionName: "assigned", Verb: digestVerbs["assigned"]})
+		}
+	}
+	if len(mails.recipients) == 0 {
+		return nil
+	}
+
+	recipients, err := models.GetMaileableUsersByIDs(mails.recipients, false)
+	if err != nil {
+		return fmt.Errorf("GetMaileableUsersByIDs: %v", err)
+	}
+	msgs := make([]*Message, 0, len(recipients))
+	items := make([]*models.MailDigestItem, 0, len(recipients))
+	for _, recipient := range recipients {
+		threads := mails.threads[recipient.ID]
+		if recipient.EmailDigest() == models.EmailDigestNone {
+			if msg := composeBulkEditMessage(doer, repo, recipient, threads); msg != nil {
+				msgs = append(msgs, msg)
+			}
+			continue
+		}
+		for _, thread := range threads {
+			for _, event := range thread.Events {
+				item := &models.MailDigestItem{
+					UserID:     recipient.ID,
+					RepoID:     repo.ID,
+					IssueID:    thread.Issue.ID,
+					DoerID:     doer.ID,
+					ActionName: event.ActionName,
+					Link:       thread.Issue.HTMLURL(),
+				}
+				if event.Comment != nil {
+					item.CommentID = event.Comment.ID
+					item.Link += "#" + event.Comment.HashTag()
+				}
+				items = append(items, item)
+			}
+		}
+	}
+
+	if len(msgs) > 0 {
+		SendAsyncs(msgs)
+	}
+	if err := models.CreateMailDigestItems(items); err != nil {
+		return fmt.Errorf("CreateMailDigestItems: %v", err)
+	}
+	return nil
+}
+
+func composeBulkEditMessage(doer *models.User, repo *models.Repository, recipient *models.User, threads []*bulkEditThread) *Message {
+	subject := fmt.Sprintf("[%s] %s changed %d issues", repo.FullName(), doer.DisplayName(), len(threads))
+	if len(threads) == 1 {
+		subject = "Re: " + fallbackMailSubject(threads[0].Issue)
+	}
+
+	data := map[string]interface{}{
+		"Subject": subject,
+		"Doer":    doer,
+		"Repo":    repo,
+		"Threads": threads,
+	}
+
+	var content bytes.Buffer
+	if err := bodyTemplates.ExecuteTemplate(&content, string(mailNotifyBulkEdit), data); err != nil {
+		log.Error("Template: %v", err)
+		return nil
+	}
+
+	msg := NewMessageFrom([]string{recipient.Email}, doer.DisplayName(), setting.MailService.FromEmail, sanitizeSubject(subject), content.String())
+	msg.Info = fmt.Sprintf("UID: %d, issues bulk edited", recipient.ID)
+	return msg
+}
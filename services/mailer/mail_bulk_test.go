@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const bulkEditTpl = `{{range .Threads}}#{{.Issue.Index}}{{range .Events}} {{.Verb}}{{end}};{{end}}`
+
+func TestMailIssuesBulkEdited(t *testing.T) {
+	prepareDigestTest(t)
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	assignee := models.AssertExistsAndLoadBean(t, &models.User{ID: 10}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	issue.IsClosed = true
+	comment := &models.Comment{ID: 100, Type: models.CommentTypeClose}
+
+	assert.NoError(t, MailIssuesBulkEdited(doer, repo, []*models.BulkEditedIssue{
+		{Issue: issue, StatusComment: comment, AddedAssignees: []*models.User{assignee, doer}},
+	}))
+	ids, err := models.GetMailDigestUserIDs(models.EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 5, 10, 11}, ids)
+	models.AssertExistsAndLoadBean(t, &models.MailDigestItem{UserID: 1, IssueID: 1, CommentID: 100, DoerID: 2, ActionName: "close"})
+	models.AssertExistsAndLoadBean(t, &models.MailDigestItem{UserID: 10, IssueID: 1, DoerID: 2, ActionName: "assigned"})
+	models.AssertNotExistsBean(t, &models.MailDigestItem{UserID: 2})
+
+	// the changes of all the issues are sent at once
+	issue2 := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 2}).(*models.Issue)
+	issue.Repo, issue2.Repo = repo, repo
+	msg := composeBulkEditMessage(doer, repo, assignee, []*bulkEditThread{
+		{Issue: issue, Events: []*bulkEditEvent{{ActionName: "close", Verb: "closed"}, {ActionName: "assigned", Verb: "assigned you to"}}},
+		{Issue: issue2, Events: []*bulkEditEvent{{ActionName: "close", Verb: "closed"}}},
+	})
+	if assert.NotNil(t, msg) {
+		assert.Equal(t, []string{assignee.Email}, msg.To)
+		assert.Equal(t, "[user2/repo1] "+doer.DisplayName()+" changed 2 issues", msg.Subject)
+		assert.Equal(t, "#1 closed assigned you to;#2 closed;", msg.Body)
+	}
+}
@@ -0,0 +1,293 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+const (
+	mailNotifyDigest base.TplName = "notify/digest"
+)
+
+// digestVerbs are the verbs describing the actions of the digest items
+var digestVerbs = map[string]string{
+	"new":            "opened",
+	"comment":        "commented on",
+	"close":          "closed",
+	"reopen":         "reopened",
+	"merge":          "merged",
+	"approve":        "approved",
+	"reject":         "requested changes on",
+	"review":         "reviewed",
+	"code":           "commented on the code of",
+	"assigned":       "assigned you to",
+	"release":        "published",
+	"review_request": "requested your review on",
+	"reminder":       "reminded you of",
+	"transfer":       "transferred",
+}
+
+type digestEvent struct {
+	Doer    *models.User
+	Action  string
+	Verb    string
+	Body    template.HTML
+	Link    string
+	Created timeutil.TimeStamp
+}
+
+type digestThread struct {
+	Title   string
+	Link    string
+	Issue   *models.Issue
+	Release *models.Release
+	Events  []*digestEvent
+}
+
+type digestRepo struct {
+	Repo    *models.Repository
+	Threads []*digestThread
+}
+
+// digestBuilder groups the digest items of a user by repository and thread,
+// caching what they refer to across the users
+type digestBuilder struct {
+	repos    map[int64]*models.Repository
+	issues   map[int64]*models.Issue
+	releases map[int64]*models.Release
+	users    map[int64]*models.User
+}
+
+func newDigestBuilder() *digestBuilder {
+	return &digestBuilder{
+		repos:    make(map[int64]*models.Repository),
+		issues:   make(map[int64]*models.Issue),
+		releases: make(map[int64]*models.Release),
+		users:    make(map[int64]*models.User),
+	}
+}
+
+func (b *digestBuilder) repo(id int64) *models.Repository {
+	repo, ok := b.repos[id]
+	if !ok {
+		var err error
+		if repo, err = models.GetRepositoryByID(id); err != nil && !models.IsErrRepoNotExist(err) {
+			log.Error("GetRepositoryByID(%d): %v", id, err)
+		}
+		b.repos[id] = repo
+	}
+	return repo
+}
+
+func (b *digestBuilder) issue(id int64) *models.Issue {
+	issue, ok := b.issues[id]
+	if !ok {
+		var err error
+		if issue, err = models.GetIssueByID(id); err != nil && !models.IsErrIssueNotExist(err) {
+			log.Error("GetIssueByID(%d): %v", id, err)
+		}
+		b.issues[id] = issue
+	}
+	return issue
+}
+
+func (b *digestBuilder) release(id int64) *models.Release {
+	rel, ok := b.releases[id]
+	if !ok {
+		var err error
+		if rel, err = models.GetReleaseByID(id); err != nil && !models.IsErrReleaseNotExist(err) {
+			log.Error("GetReleaseByID(%d): %v", id, err)
+		}
+		b.releases[id] = rel
+	}
+	return rel
+}
+
+func (b *digestBuilder) user(id int64) *models.User {
+	u, ok := b.users[id]
+	if !ok {
+		var err error
+		if u, err = models.GetUserByID(id); err != nil {
+			if !models.IsErrUserNotExist(err) {
+				log.Error("GetUserByID(%d): %v", id, err)
+			}
+			u = models.NewGhostUser()
+		}
+		b.users[id] = u
+	}
+	return u
+}
+
+// build groups the items in the order of their first occurrence, skipping
+// the ones about repositories, issues or releases deleted since, or that the
+// user can't read anymore
+func (b *digestBuilder) build(u *models.User, items []*models.MailDigestItem) ([]*digestRepo, int) {
+	repos := make([]*digestRepo, 0, 5)
+	repoIndexes := make(map[int64]*digestRepo)
+	threadIndexes := make(map[string]*digestThread)
+	perms := make(map[int64]*models.Permission)
+	count := 0
+	for _, item := range items {
+		repo := b.repo(item.RepoID)
+		if repo == nil {
+			continue
+		}
+		perm, ok := perms[repo.ID]
+		if !ok {
+			p, err := models.GetUserRepoPermission(repo, u)
+			if err != nil {
+				log.Error("GetUserRepoPermission(%d): %v", repo.ID, err)
+				continue
+			}
+			perm = &p
+			perms[repo.ID] = perm
+		}
+
+		var key string
+		thread := &digestThread{}
+		if item.ReleaseID != 0 {
+			if thread.Release = b.release(item.ReleaseID); thread.Release == nil || !perm.CanRead(models.UnitTypeReleases) {
+				continue
+			}
+			thread.Release.Repo = repo
+			key = fmt.Sprintf("release-%d", item.ReleaseID)
+			thread.Title = thread.Release.Title
+			if thread.Title == "" {
+				thread.Title = thread.Release.TagName
+			}
+			thread.Link = thread.Release.HTMLURL()
+		} else {
+			if thread.Issue = b.issue(item.IssueID); thread.Issue == nil || !perm.CanReadIssuesOrPulls(thread.Issue.IsPull) {
+				continue
+			}
+			thread.Issue.Repo = repo
+			key = fmt.Sprintf("issue-%d", item.IssueID)
+			thread.Title = fmt.Sprintf("%s (#%d)", thread.Issue.Title, thread.Issue.Index)
+			thread.Link = thread.Issue.HTMLURL()
+		}
+
+		dr, ok := repoIndexes[repo.ID]
+		if !ok {
+			dr = &digestRepo{Repo: repo}
+			repoIndexes[repo.ID] = dr
+			repos = append(repos, dr)
+		}
+		if existing, ok := threadIndexes[key]; ok {
+			thread = existing
+		} else {
+			threadIndexes[key] = thread
+			dr.Threads = append(dr.Threads, thread)
+		}
+
+		verb, ok := digestVerbs[item.ActionName]
+		if !ok {
+			verb = "updated"
+		}
+		thread.Events = append(thread.Events, &digestEvent{
+			Doer:    b.user(item.DoerID),
+			Action:  item.ActionName,
+			Verb:    verb,
+			Body:    template.HTML(markup.RenderByType(markdown.MarkupName, []byte(item.Content), repo.HTMLURL(), repo.ComposeMetas())),
+			Link:    item.Link,
+			Created: item.CreatedUnix,
+		})
+		count++
+	}
+	return repos, count
+}
+
+// SendEmailDigests sends their digests to the users with pending digest items
+// getting their digests at one of the frequencies
+func SendEmailDigests(ctx context.Context, frequencies ...string) {
+	ids, err := models.GetMailDigestUserIDs(frequencies...)
+	if err != nil {
+		log.Error("GetMailDigestUserIDs: %v", err)
+		return
+	}
+
+	b := newDigestBuilder()
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			log.Warn("SendEmailDigests: Cancelled before sending the digests of all the users")
+			return
+		default:
+		}
+		if err := sendEmailDigest(b, id); err != nil {
+			log.Error("sendEmailDigest(%d): %v", id, err)
+		}
+	}
+}
+
+func sendEmailDigest(b *digestBuilder, userID int64) error {
+	items, err := models.GetMailDigestItems(userID)
+	if err != nil {
+		return fmt.Errorf("GetMailDigestItems: %v", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	u := b.user(userID)
+	if setting.MailService != nil && u.ID == userID && u.IsMailable() && !u.ProhibitLogin &&
+		u.EmailNotifications() != models.EmailNotificationsDisabled {
+		if msg := composeDigestMessage(b, u, items); msg != nil {
+			SendAsync(msg)
+		}
+	}
+	return models.DeleteMailDigestItems(userID, items[len(items)-1].ID)
+}
+
+func composeDigestMessage(b *digestBuilder, u *models.User, items []*models.MailDigestItem) *Message {
+	repos, count := b.build(u, items)
+	if count == 0 {
+		return nil
+	}
+
+	var subject string
+	switch u.EmailDigest() {
+	case models.EmailDigestDaily:
+		subject = "Your daily notification digest"
+	case models.EmailDigestHourly:
+		subject = "Your hourly notification digest"
+	default:
+		subject = "Your notification digest"
+	}
+	if count == 1 {
+		subject += " (1 new notification)"
+	} else {
+		subject += fmt.Sprintf(" (%d new notifications)", count)
+	}
+
+	data := map[string]interface{}{
+		"Subject": subject,
+		"User":    u,
+		"Repos":   repos,
+		"Count":   count,
+		"Link":    setting.AppURL + "user/settings/account",
+	}
+
+	var content bytes.Buffer
+	if err := bodyTemplates.ExecuteTemplate(&content, string(mailNotifyDigest), data); err != nil {
+		log.Error("Template: %v", err)
+		return nil
+	}
+
+	msg := NewMessage([]string{u.Email}, subject, content.String())
+	msg.Info = fmt.Sprintf("UID: %d, notification digest", u.ID)
+	return msg
+}
@@ -0,0 +1,115 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"html/template"
+	"testing"
+	texttmpl "text/template"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const digestTpl = `{{range .Repos}}{{.Repo.FullName}}:{{range .Threads}} {{.Title}}{{range .Events}} [{{.Doer.Name}} {{.Verb}}]{{end}}{{end}}{{end}}`
+
+// prepareDigestTest makes every user get daily digests, so that no mail is
+// sent right away
+func prepareDigestTest(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	setting.MailService = &setting.Mailer{From: "test@gitea.com"}
+	setting.Domain = "localhost"
+
+	btpl := template.Must(template.New("issue/default").Parse(bodyTpl))
+	template.Must(btpl.New(string(mailNotifyDigest)).Parse(digestTpl))
+	template.Must(btpl.New(string(mailNotifyBulkEdit)).Parse(bulkEditTpl))
+	InitMailRender(texttmpl.Must(texttmpl.New("issue/default").Parse(subjectTpl)), btpl)
+
+	for _, id := range []int64{1, 4, 5, 10, 11} {
+		u := models.AssertExistsAndLoadBean(t, &models.User{ID: id}).(*models.User)
+		assert.NoError(t, u.SetEmailDigest(models.EmailDigestDaily))
+	}
+}
+
+func TestMailIssueCommentToParticipantsLevels(t *testing.T) {
+	prepareDigestTest(t)
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1}).(*models.Issue)
+	ctx := &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue, Content: "hi"}
+
+	assert.NoError(t, mailIssueCommentToParticipants(ctx, nil))
+	ids, err := models.GetMailDigestUserIDs(models.EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 5, 11}, ids)
+	models.AssertExistsAndLoadBean(t, &models.MailDigestItem{UserID: 1, RepoID: 1, IssueID: 1, DoerID: 2, ActionName: "comment", Content: "hi"})
+
+	for _, id := range ids {
+		assert.NoError(t, models.DeleteMailDigestItems(id, 1<<62))
+	}
+
+	// user 11 only watches the repository, user 10 doesn't but wants all the activity
+	assert.NoError(t, models.SetRepoNotificationLevel(11, 1, models.RepoNotificationLevelParticipating))
+	assert.NoError(t, models.SetRepoNotificationLevel(10, 1, models.RepoNotificationLevelAll))
+	assert.NoError(t, models.SetRepoNotificationLevel(5, 1, models.RepoNotificationLevelMuted))
+	// user 4 is only notified on mention
+	assert.NoError(t, mailIssueCommentToParticipants(ctx, []int64{4, 5}))
+	ids, err = models.GetMailDigestUserIDs(models.EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 4, 10}, ids)
+
+	// users unsubscribed from the issue only get their mentions
+	assert.NoError(t, models.CreateOrUpdateIssueWatch(10, 1, false))
+	assert.NoError(t, mailIssueCommentToParticipants(ctx, nil))
+	assert.EqualValues(t, 1, models.GetCount(t, &models.MailDigestItem{UserID: 10}))
+}
+
+func TestMailIssueCommentToParticipantsLevelAllAccess(t *testing.T) {
+	prepareDigestTest(t)
+
+	// repo2 is private: only the admin user 1 can read its issues
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 4}).(*models.Issue)
+	for _, id := range []int64{1, 10, 11} {
+		assert.NoError(t, models.SetRepoNotificationLevel(id, 2, models.RepoNotificationLevelAll))
+	}
+	ctx := &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue, Content: "hi"}
+
+	assert.NoError(t, mailIssueCommentToParticipants(ctx, nil))
+	ids, err := models.GetMailDigestUserIDs(models.EmailDigestDaily)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1}, ids)
+}
+
+func TestSendEmailDigests(t *testing.T) {
+	prepareDigestTest(t)
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	assert.NoError(t, models.CreateMailDigestItems([]*models.MailDigestItem{
+		{UserID: 1, RepoID: 1, IssueID: 1, DoerID: 2, ActionName: "comment", Content: "first"},
+		{UserID: 1, RepoID: 1, IssueID: 2, DoerID: 2, ActionName: "close"},
+		{UserID: 1, RepoID: 1, IssueID: 1, DoerID: 404, ActionName: "comment", Content: "second"},
+		{UserID: 1, RepoID: 1, ReleaseID: 1, DoerID: 2, ActionName: "release"},
+		// deleted since
+		{UserID: 1, RepoID: 1, IssueID: 404, DoerID: 2, ActionName: "comment"},
+	}))
+	items, err := models.GetMailDigestItems(1)
+	assert.NoError(t, err)
+
+	msg := composeDigestMessage(newDigestBuilder(), user, items)
+	if assert.NotNil(t, msg) {
+		gomailMsg := msg.ToMessage()
+		assert.Equal(t, []string{user.Email}, gomailMsg.GetHeader("To"))
+		assert.Equal(t, []string{"Your daily notification digest (4 new notifications)"}, gomailMsg.GetHeader("Subject"))
+		assert.Equal(t, "user2/repo1: issue1 (#1) [user2 commented on] [Ghost commented on] issue2 (#2) [user2 closed] testing-release [user2 published]", msg.Body)
+	}
+
+	// the items are flushed even without a mail service
+	setting.MailService = nil
+	assert.NoError(t, sendEmailDigest(newDigestBuilder(), user.ID))
+	models.AssertNotExistsBean(t, &models.MailDigestItem{UserID: 1})
+}
@@ -22,6 +22,37 @@ type mailCommentContext struct {
 	ActionType models.ActionType
 	Content    string
 	Comment    *models.Comment
+	// ActionName overrides the name of the action derived from its type
+	ActionName string
+}
+
+// link returns the link to the comment, or to the issue if there is none
+func (ctx *mailCommentContext) link() string {
+	if ctx.Comment != nil {
+		return ctx.Issue.HTMLURL() + "#" + ctx.Comment.HashTag()
+	}
+	return ctx.Issue.HTMLURL()
+}
+
+// action returns the type and the name of the action facing the user and the
+// name of the template of its mail
+func (ctx *mailCommentContext) action() (typeName, name, template string) {
+	if ctx.ActionName != "" {
+		typeName = issueTypeName(ctx.Issue)
+		return typeName, ctx.ActionName, actionTemplate(typeName, ctx.ActionName)
+	}
+
+	commentType := models.CommentTypeComment
+	if ctx.Comment != nil {
+		commentType = ctx.Comment.Type
+	}
+
+	reviewType := models.ReviewTypeComment
+	if ctx.Comment != nil && ctx.Comment.Review != nil {
+		reviewType = ctx.Comment.Review.Type
+	}
+
+	return actionToTemplate(ctx.Issue, ctx.ActionType, commentType, reviewType)
 }
 
 // mailIssueCommentToParticipants can be used for both new issue creation and comment.
@@ -41,60 +72,146 @@ func mailIssueCommentToParticipants(ctx *mailCommentContext, mentions []int64) e
 		return fmt.Errorf("LoadPullRequest(): %v", err)
 	}
 
+	subscribed, visited, err := getIssueSubscribers(ctx.Issue, ctx.Doer, len(mentions))
+	if err != nil {
+		return err
+	}
+
+	if err = mailIssueCommentBatch(ctx, subscribed, visited, false); err != nil {
+		return fmt.Errorf("mailIssueCommentBatch(): %v", err)
+	}
+
+	// =========== Mentions ===========
+	if err = mailIssueCommentBatch(ctx, mentions, visited, true); err != nil {
+		return fmt.Errorf("mailIssueCommentBatch() mentions: %v", err)
+	}
+
+	return nil
+}
+
+// getIssueSubscribers returns the users subscribed to the issue, and the ones
+// not to mail about it: the doer and the users who muted the issues of the
+// repository. extra is the number of other users to be added to the latter.
+func getIssueSubscribers(issue *models.Issue, doer *models.User, extra int) (subscribed []int64, visited map[int64]bool, err error) {
 	// Enough room to avoid reallocations
 	unfiltered := make([]int64, 1, 64)
 
 	// =========== Original poster ===========
-	unfiltered[0] = ctx.Issue.PosterID
+	unfiltered[0] = issue.PosterID
 
 	// =========== Assignees ===========
-	ids, err := models.GetAssigneeIDsByIssue(ctx.Issue.ID)
+	ids, err := models.GetAssigneeIDsByIssue(issue.ID)
 	if err != nil {
-		return fmt.Errorf("GetAssigneeIDsByIssue(%d): %v", ctx.Issue.ID, err)
+		return nil, nil, fmt.Errorf("GetAssigneeIDsByIssue(%d): %v", issue.ID, err)
 	}
 	unfiltered = append(unfiltered, ids...)
 
 	// =========== Participants (i.e. commenters, reviewers) ===========
-	ids, err = models.GetParticipantsIDsByIssueID(ctx.Issue.ID)
+	ids, err = models.GetParticipantsIDsByIssueID(issue.ID)
 	if err != nil {
-		return fmt.Errorf("GetParticipantsIDsByIssueID(%d): %v", ctx.Issue.ID, err)
+		return nil, nil, fmt.Errorf("GetParticipantsIDsByIssueID(%d): %v", issue.ID, err)
 	}
 	unfiltered = append(unfiltered, ids...)
 
 	// =========== Issue watchers ===========
-	ids, err = models.GetIssueWatchersIDs(ctx.Issue.ID)
+	ids, err = models.GetIssueWatchersIDs(issue.ID, true)
 	if err != nil {
-		return fmt.Errorf("GetIssueWatchersIDs(%d): %v", ctx.Issue.ID, err)
+		return nil, nil, fmt.Errorf("GetIssueWatchersIDs(%d): %v", issue.ID, err)
 	}
 	unfiltered = append(unfiltered, ids...)
 
+	levels, err := models.GetRepoNotificationLevels(issue.RepoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetRepoNotificationLevels(%d): %v", issue.RepoID, err)
+	}
+
 	// =========== Repo watchers ===========
 	// Make repo watchers last, since it's likely the list with the most users
-	ids, err = models.GetRepoWatchersIDs(ctx.Issue.RepoID)
+	ids, err = models.GetRepoWatchersIDs(issue.RepoID)
 	if err != nil {
-		return fmt.Errorf("GetRepoWatchersIDs(%d): %v", ctx.Issue.RepoID, err)
+		return nil, nil, fmt.Errorf("GetRepoWatchersIDs(%d): %v", issue.RepoID, err)
+	}
+	// Users getting all the activity of the repository get it even when not
+	// watching it, users only getting their threads don't get the other ones
+	watchers := make([]int64, 0, len(ids)+len(levels))
+	for _, id := range ids {
+		if levels[id] != models.RepoNotificationLevelParticipating {
+			watchers = append(watchers, id)
+		}
+	}
+	all := make([]int64, 0, len(levels))
+	for id, level := range levels {
+		if level == models.RepoNotificationLevelAll {
+			all = append(all, id)
+		}
+	}
+	if all, err = filterIssueReaders(issue, all); err != nil {
+		return nil, nil, err
 	}
-	unfiltered = append(ids, unfiltered...)
+	watchers = append(watchers, all...)
+	unfiltered = append(watchers, unfiltered...)
 
-	visited := make(map[int64]bool, len(unfiltered)+len(mentions)+1)
+	// =========== Issue unwatchers ===========
+	// Users who unsubscribed from the issue only get mails when mentioned
+	ids, err = models.GetIssueWatchersIDs(issue.ID, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetIssueWatchersIDs(%d): %v", issue.ID, err)
+	}
+	unwatched := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		unwatched[id] = true
+	}
+	subscribed = unfiltered[:0]
+	for _, id := range unfiltered {
+		if !unwatched[id] {
+			subscribed = append(subscribed, id)
+		}
+	}
+
+	visited = make(map[int64]bool, len(subscribed)+extra+1)
 
 	// Avoid mailing the doer
-	visited[ctx.Doer.ID] = true
+	visited[doer.ID] = true
 
-	if err = mailIssueCommentBatch(ctx, unfiltered, visited, false); err != nil {
-		return fmt.Errorf("mailIssueCommentBatch(): %v", err)
+	// and the users who muted the issues of the repository
+	for id, level := range levels {
+		if level == models.RepoNotificationLevelReleases || level == models.RepoNotificationLevelMuted {
+			visited[id] = true
+		}
 	}
 
-	// =========== Mentions ===========
-	if err = mailIssueCommentBatch(ctx, mentions, visited, true); err != nil {
-		return fmt.Errorf("mailIssueCommentBatch() mentions: %v", err)
-	}
+	return subscribed, visited, nil
+}
 
-	return nil
+// filterIssueReaders returns the users who can read the issue, since the
+// users getting all the activity of a repository may have lost their access
+func filterIssueReaders(issue *models.Issue, ids []int64) ([]int64, error) {
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return nil, fmt.Errorf("LoadRepo: %v", err)
+	}
+	users, err := models.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("GetUsersByIDs: %v", err)
+	}
+	readers := make([]int64, 0, len(users))
+	for _, user := range users {
+		perm, err := models.GetUserRepoPermission(issue.Repo, user)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserRepoPermission: %v", err)
+		}
+		if perm.CanReadIssuesOrPulls(issue.IsPull) {
+			readers = append(readers, user.ID)
+		}
+	}
+	return readers, nil
 }
 
 func mailIssueCommentBatch(ctx *mailCommentContext, ids []int64, visited map[int64]bool, fromMention bool) error {
 	const batchSize = 100
+	checked := make(map[int64]bool, len(ids))
 	for i := 0; i < len(ids); i += batchSize {
 		var last int
 		if i+batchSize < len(ids) {
@@ -105,22 +222,60 @@ func mailIssueCommentBatch(ctx *mailCommentContext, ids []int64, visited map[int
 		unique := make([]int64, 0, last-i)
 		for j := i; j < last; j++ {
 			id := ids[j]
-			if _, ok := visited[id]; !ok {
+			if !visited[id] && !checked[id] {
 				unique = append(unique, id)
-				visited[id] = true
+				checked[id] = true
 			}
 		}
-		recipients, err := models.GetMaileableUsersByIDs(unique)
+		recipients, err := models.GetMaileableUsersByIDs(unique, fromMention)
 		if err != nil {
 			return err
 		}
+		// Users only mailed on mention may still be mailed by the mentions batch
+		for _, recipient := range recipients {
+			visited[recipient.ID] = true
+		}
 		// TODO: Check issue visibility for each user
 		// TODO: Separate recipients by language for i18n mail templates
-		tos := make([]string, len(recipients))
-		for i := range recipients {
-			tos[i] = recipients[i].Email
+		if err = sendIssueCommentMails(ctx, recipients, fromMention, "issue comments"); err != nil {
+			return err
 		}
-		SendAsyncs(composeIssueCommentMessages(ctx, tos, fromMention, "issue comments"))
+	}
+	return nil
+}
+
+// sendIssueCommentMails sends the mails of the event to the recipients getting
+// their notifications as they happen, and withholds the other ones until the
+// next digests of their recipients
+func sendIssueCommentMails(ctx *mailCommentContext, recipients []*models.User, fromMention bool, info string) error {
+	immediate := make([]*models.User, 0, len(recipients))
+	items := make([]*models.MailDigestItem, 0, len(recipients))
+	_, actName, _ := ctx.action()
+	for _, recipient := range recipients {
+		if recipient.EmailDigest() == models.EmailDigestNone {
+			immediate = append(immediate, recipient)
+			continue
+		}
+		item := &models.MailDigestItem{
+			UserID:     recipient.ID,
+			RepoID:     ctx.Issue.RepoID,
+			IssueID:    ctx.Issue.ID,
+			DoerID:     ctx.Doer.ID,
+			ActionName: actName,
+			Content:    ctx.Content,
+			Link:       ctx.link(),
+		}
+		if ctx.Comment != nil {
+			item.CommentID = ctx.Comment.ID
+		}
+		items = append(items, item)
+	}
+
+	if len(immediate) > 0 {
+		SendAsyncs(composeIssueCommentMessages(ctx, immediate, fromMention, info))
+	}
+	if err := models.CreateMailDigestItems(items); err != nil {
+		return fmt.Errorf("CreateMailDigestItems: %v", err)
 	}
 	return nil
 }
@@ -156,3 +311,16 @@ func mailParticipants(ctx models.DBContext, issue *models.Issue, doer *models.Us
 	}
 	return nil
 }
+
+// MailIssueTransferred sends the mails of the transfer of the issue from another
+// repository to its participants and the watchers of its new repository
+func MailIssueTransferred(issue *models.Issue, doer *models.User, comment *models.Comment) error {
+	return mailIssueCommentToParticipants(&mailCommentContext{
+		Issue:      issue,
+		Doer:       doer,
+		ActionType: models.ActionType(0),
+		Content:    fmt.Sprintf("Transferred from %s.", comment.OldRef),
+		Comment:    comment,
+		ActionName: "transfer",
+	}, nil)
+}
@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/unknwon/i18n"
+)
+
+const (
+	mailAuthLoginLockout base.TplName = "auth/login_lockout"
+)
+
+// SendLoginLockoutMail tells the user their account has been locked after too many failed sign-in attempts
+func SendLoginLockoutMail(u *models.User, remoteAddr string, attempts int64, until time.Time) {
+	if setting.MailService == nil {
+		return
+	}
+
+	lang := u.Language
+	if len(lang) == 0 && len(setting.Langs) > 0 {
+		lang = setting.Langs[0]
+	}
+
+	data := map[string]interface{}{
+		"DisplayName": u.DisplayName(),
+		"Username":    u.Name,
+		"RemoteAddr":  remoteAddr,
+		"Attempts":    attempts,
+		"LockedUntil": until.Format(time.RFC1123),
+	}
+
+	var content bytes.Buffer
+
+	if err := bodyTemplates.ExecuteTemplate(&content, string(mailAuthLoginLockout), data); err != nil {
+		log.Error("Template: %v", err)
+		return
+	}
+
+	msg := NewMessage([]string{u.Email}, i18n.Tr(lang, "mail.login_lockout"), content.String())
+	msg.Info = fmt.Sprintf("UID: %d, login lockout", u.ID)
+
+	SendAsync(msg)
+}
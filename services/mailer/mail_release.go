@@ -0,0 +1,107 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	mailNotifyRelease base.TplName = "notify/release"
+)
+
+// MailNewRelease sends new release emails to the users who chose to get the
+// releases of the repository
+func MailNewRelease(rel *models.Release) error {
+	if err := rel.LoadAttributes(); err != nil {
+		return fmt.Errorf("LoadAttributes: %v", err)
+	}
+
+	levels, err := models.GetRepoNotificationLevels(rel.RepoID)
+	if err != nil {
+		return fmt.Errorf("GetRepoNotificationLevels(%d): %v", rel.RepoID, err)
+	}
+	ids := make([]int64, 0, len(levels))
+	for id, level := range levels {
+		if id != rel.PublisherID && (level == models.RepoNotificationLevelAll || level == models.RepoNotificationLevelReleases) {
+			ids = append(ids, id)
+		}
+	}
+	users, err := models.GetMaileableUsersByIDs(ids, false)
+	if err != nil {
+		return fmt.Errorf("GetMaileableUsersByIDs: %v", err)
+	}
+
+	immediate := make([]*models.User, 0, len(users))
+	items := make([]*models.MailDigestItem, 0, len(users))
+	for _, user := range users {
+		perm, err := models.GetUserRepoPermission(rel.Repo, user)
+		if err != nil {
+			return fmt.Errorf("GetUserRepoPermission: %v", err)
+		}
+		if !perm.CanRead(models.UnitTypeReleases) {
+			continue
+		}
+
+		if user.EmailDigest() == models.EmailDigestNone {
+			immediate = append(immediate, user)
+			continue
+		}
+		items = append(items, &models.MailDigestItem{
+			UserID:     user.ID,
+			RepoID:     rel.RepoID,
+			ReleaseID:  rel.ID,
+			DoerID:     rel.PublisherID,
+			ActionName: "release",
+			Content:    rel.Note,
+			Link:       rel.HTMLURL(),
+		})
+	}
+
+	if len(immediate) > 0 {
+		SendAsyncs(composeReleaseMessages(rel, immediate))
+	}
+	if err := models.CreateMailDigestItems(items); err != nil {
+		return fmt.Errorf("CreateMailDigestItems: %v", err)
+	}
+	return nil
+}
+
+func composeReleaseMessages(rel *models.Release, recipients []*models.User) []*Message {
+	subject := fmt.Sprintf("[%s] %s released %s", rel.Repo.FullName(), rel.Publisher.DisplayName(), rel.TagName)
+	if rel.Title != "" && rel.Title != rel.TagName {
+		subject += " - " + rel.Title
+	}
+	data := map[string]interface{}{
+		"Subject": subject,
+		"Release": rel,
+		"Repo":    rel.Repo.FullName(),
+		"Body":    string(markup.RenderByType(markdown.MarkupName, []byte(rel.Note), rel.Repo.HTMLURL(), rel.Repo.ComposeMetas())),
+		"Link":    rel.HTMLURL(),
+	}
+
+	var content bytes.Buffer
+	if err := bodyTemplates.ExecuteTemplate(&content, string(mailNotifyRelease), data); err != nil {
+		log.Error("Template: %v", err)
+		return nil
+	}
+
+	// Make sure to compose independent messages to avoid leaking user emails
+	msgs := make([]*Message, 0, len(recipients))
+	for _, recipient := range recipients {
+		msg := NewMessageFrom([]string{recipient.Email}, rel.Publisher.DisplayName(), setting.MailService.FromEmail, sanitizeSubject(subject), content.String())
+		msg.Info = fmt.Sprintf("Subject: %s, new release", subject)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
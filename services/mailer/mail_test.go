@@ -7,11 +7,13 @@ package mailer
 import (
 	"bytes"
 	"html/template"
+	"strings"
 	"testing"
 	texttmpl "text/template"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer/token"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -57,9 +59,9 @@ func TestComposeIssueCommentMessage(t *testing.T) {
 	btpl := template.Must(template.New("issue/comment").Parse(bodyTpl))
 	InitMailRender(stpl, btpl)
 
-	tos := []string{"test@gitea.com", "test2@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}, {Name: "Test2", Email: "test2@gitea.com"}}
 	msgs := composeIssueCommentMessages(&mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue,
-		Content: "test body", Comment: comment}, tos, false, "issue comment")
+		Content: "test body", Comment: comment}, recipients, false, "issue comment")
 	assert.Len(t, msgs, 2)
 	gomailMsg := msgs[0].ToMessage()
 	mailto := gomailMsg.GetHeader("To")
@@ -91,9 +93,9 @@ func TestComposeIssueMessage(t *testing.T) {
 	btpl := template.Must(template.New("issue/new").Parse(bodyTpl))
 	InitMailRender(stpl, btpl)
 
-	tos := []string{"test@gitea.com", "test2@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}, {Name: "Test2", Email: "test2@gitea.com"}}
 	msgs := composeIssueCommentMessages(&mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
-		Content: "test body"}, tos, false, "issue create")
+		Content: "test body"}, recipients, false, "issue create")
 	assert.Len(t, msgs, 2)
 
 	gomailMsg := msgs[0].ToMessage()
@@ -108,6 +110,58 @@ func TestComposeIssueMessage(t *testing.T) {
 	assert.Equal(t, messageID[0], "<user2/repo1/issues/1@localhost>", "Message-ID header doesn't match")
 }
 
+func TestComposeIssueMessageReplyTo(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	var mailService = setting.Mailer{
+		From: "test@gitea.com",
+	}
+
+	setting.MailService = &mailService
+	setting.Domain = "localhost"
+	setting.IncomingEmail.Enabled = true
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@localhost"
+	defer func() {
+		setting.IncomingEmail.Enabled = false
+	}()
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1, Owner: doer}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, Repo: repo, Poster: doer}).(*models.Issue)
+
+	stpl := texttmpl.Must(texttmpl.New("issue/new").Parse(subjectTpl))
+	btpl := template.Must(template.New("issue/new").Parse(bodyTpl))
+	InitMailRender(stpl, btpl)
+
+	recipients := []*models.User{
+		models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User),
+		models.AssertExistsAndLoadBean(t, &models.User{ID: 5}).(*models.User),
+	}
+	msgs := composeIssueCommentMessages(&mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
+		Content: "test body"}, recipients, false, "issue create")
+	assert.Len(t, msgs, 2)
+
+	// each recipient replies with its own token
+	for i, msg := range msgs {
+		replyTo := msg.ToMessage().GetHeader("Reply-To")
+		assert.Len(t, replyTo, 1)
+		tok, ok := token.FromAddress(replyTo[0])
+		assert.True(t, ok)
+		ht, user, issueID, err := token.ExtractToken(tok)
+		assert.NoError(t, err)
+		assert.Equal(t, token.ReplyHandlerType, ht)
+		assert.EqualValues(t, recipients[i].ID, user.ID)
+		assert.EqualValues(t, issue.ID, issueID)
+
+		unsubscribe := msg.ToMessage().GetHeader("List-Unsubscribe")
+		assert.Len(t, unsubscribe, 1)
+		tok, ok = token.FromAddress(strings.TrimSuffix(strings.TrimPrefix(unsubscribe[0], "<mailto:"), ">"))
+		assert.True(t, ok)
+		ht, _, _, err = token.ExtractToken(tok)
+		assert.NoError(t, err)
+		assert.Equal(t, token.UnsubscribeHandlerType, ht)
+	}
+}
+
 func TestTemplateSelection(t *testing.T) {
 	assert.NoError(t, models.PrepareTestDatabase())
 	var mailService = setting.Mailer{
@@ -120,7 +174,7 @@ func TestTemplateSelection(t *testing.T) {
 	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
 	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1, Owner: doer}).(*models.Repository)
 	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 1, Repo: repo, Poster: doer}).(*models.Issue)
-	tos := []string{"test@gitea.com"}
+	recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}}
 
 	stpl := texttmpl.Must(texttmpl.New("issue/default").Parse("issue/default/subject"))
 	texttmpl.Must(stpl.New("issue/new").Parse("issue/new/subject"))
@@ -131,6 +185,7 @@ func TestTemplateSelection(t *testing.T) {
 	template.Must(btpl.New("issue/new").Parse("issue/new/body"))
 	template.Must(btpl.New("pull/comment").Parse("pull/comment/body"))
 	template.Must(btpl.New("issue/close").Parse("issue/close/body"))
+	template.Must(btpl.New("issue/reminder").Parse("issue/reminder/body"))
 
 	InitMailRender(stpl, btpl)
 
@@ -144,23 +199,27 @@ func TestTemplateSelection(t *testing.T) {
 	}
 
 	msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCreateIssue,
-		Content: "test body"}, tos, false, "TestTemplateSelection")
+		Content: "test body"}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "issue/new/subject", "issue/new/body")
 
 	comment := models.AssertExistsAndLoadBean(t, &models.Comment{ID: 2, Issue: issue}).(*models.Comment)
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCommentIssue,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "issue/default/subject", "issue/default/body")
 
 	pull := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 2, Repo: repo, Poster: doer}).(*models.Issue)
 	comment = models.AssertExistsAndLoadBean(t, &models.Comment{ID: 4, Issue: pull}).(*models.Comment)
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: pull, Doer: doer, ActionType: models.ActionCommentPull,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "pull/comment/subject", "pull/comment/body")
 
 	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: models.ActionCloseIssue,
-		Content: "test body", Comment: comment}, tos, false, "TestTemplateSelection")
+		Content: "test body", Comment: comment}, recipients, false, "TestTemplateSelection")
 	expect(t, msg, "Re: [user2/repo1] issue1 (#1)", "issue/close/body")
+
+	msg = testComposeIssueCommentMessage(t, &mailCommentContext{Issue: pull, Doer: doer, ActionName: "reminder",
+		Content: "test body"}, recipients, false, "TestTemplateSelection")
+	expect(t, msg, "Re: [user2/repo1] issue2 (#2)", "issue/reminder/body")
 }
 
 func TestTemplateServices(t *testing.T) {
@@ -185,9 +244,9 @@ func TestTemplateServices(t *testing.T) {
 		btpl := template.Must(template.New("issue/default").Parse(tplBody))
 		InitMailRender(stpl, btpl)
 
-		tos := []string{"test@gitea.com"}
+		recipients := []*models.User{{Name: "Test", Email: "test@gitea.com"}}
 		msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: actionType,
-			Content: "test body", Comment: comment}, tos, fromMention, "TestTemplateServices")
+			Content: "test body", Comment: comment}, recipients, fromMention, "TestTemplateServices")
 
 		subject := msg.ToMessage().GetHeader("Subject")
 		msgbuf := new(bytes.Buffer)
@@ -217,8 +276,8 @@ func TestTemplateServices(t *testing.T) {
 		"//Re: //")
 }
 
-func testComposeIssueCommentMessage(t *testing.T, ctx *mailCommentContext, tos []string, fromMention bool, info string) *Message {
-	msgs := composeIssueCommentMessages(ctx, tos, fromMention, info)
+func testComposeIssueCommentMessage(t *testing.T, ctx *mailCommentContext, recipients []*models.User, fromMention bool, info string) *Message {
+	msgs := composeIssueCommentMessages(ctx, recipients, fromMention, info)
 	assert.Len(t, msgs, 1)
 	return msgs[0]
 }
@@ -0,0 +1,122 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// A token is the base32 encoding (lowercased so it survives in the local part
+// of an address) of:
+//
+//   version | handler type | uvarint(user id) | uvarint(issue id) | mac
+//
+// The mac is a truncated HMAC-SHA256 of the payload keyed by the secret key of
+// the instance and the random string of the user, so tokens can't be forged
+// for another user even by someone knowing the ID of the user.
+
+// HandlerType is the action performed by a mail sent to a token address
+type HandlerType byte
+
+const (
+	// ReplyHandlerType creates a comment from the mail
+	ReplyHandlerType HandlerType = 1
+	// UnsubscribeHandlerType stops the notifications of the issue
+	UnsubscribeHandlerType HandlerType = 2
+)
+
+const (
+	tokenVersion = 1
+	macLength    = 10
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrInvalidToken represents a malformed, forged or revoked token
+var ErrInvalidToken = errors.New("invalid incoming mail token")
+
+// CreateToken creates a token allowing the user to perform the action on the issue
+func CreateToken(ht HandlerType, user *models.User, issueID int64) string {
+	payload := make([]byte, 2, 2+2*binary.MaxVarintLen64)
+	payload[0] = tokenVersion
+	payload[1] = byte(ht)
+	payload = appendUvarint(payload, uint64(user.ID))
+	payload = appendUvarint(payload, uint64(issueID))
+
+	return strings.ToLower(encoding.EncodeToString(append(payload, computeMAC(payload, user)...)))
+}
+
+// ExtractToken verifies the token and returns its action, its user and the ID of its issue
+func ExtractToken(token string) (HandlerType, *models.User, int64, error) {
+	data, err := encoding.DecodeString(strings.ToUpper(token))
+	if err != nil || len(data) <= 2+macLength || data[0] != tokenVersion {
+		return 0, nil, 0, ErrInvalidToken
+	}
+	payload, mac := data[:len(data)-macLength], data[len(data)-macLength:]
+
+	ht := HandlerType(payload[1])
+	if ht != ReplyHandlerType && ht != UnsubscribeHandlerType {
+		return 0, nil, 0, ErrInvalidToken
+	}
+	userID, n := binary.Uvarint(payload[2:])
+	if n <= 0 {
+		return 0, nil, 0, ErrInvalidToken
+	}
+	issueID, m := binary.Uvarint(payload[2+n:])
+	if m <= 0 || 2+n+m != len(payload) {
+		return 0, nil, 0, ErrInvalidToken
+	}
+
+	user, err := models.GetUserByID(int64(userID))
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			return 0, nil, 0, ErrInvalidToken
+		}
+		return 0, nil, 0, err
+	}
+	if !hmac.Equal(mac, computeMAC(payload, user)) {
+		return 0, nil, 0, ErrInvalidToken
+	}
+	return ht, user, int64(issueID), nil
+}
+
+// Address returns the reply address of the token
+func Address(token string) string {
+	return strings.Replace(setting.IncomingEmail.ReplyToAddress, setting.IncomingEmailTokenPlaceholder, token, 1)
+}
+
+// FromAddress returns the token of a reply address
+func FromAddress(address string) (string, bool) {
+	parts := strings.SplitN(strings.ToLower(setting.IncomingEmail.ReplyToAddress), setting.IncomingEmailTokenPlaceholder, 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	address = strings.ToLower(address)
+	if len(address) <= len(parts[0])+len(parts[1]) ||
+		!strings.HasPrefix(address, parts[0]) || !strings.HasSuffix(address, parts[1]) {
+		return "", false
+	}
+	return address[len(parts[0]) : len(address)-len(parts[1])], true
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+func computeMAC(payload []byte, user *models.User) []byte {
+	mac := hmac.New(sha256.New, []byte(setting.SecretKey+user.Rands))
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)[:macLength]
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package token
+
+import (
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	setting.SecretKey = "secret"
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	tok := CreateToken(ReplyHandlerType, user, 1)
+	assert.Equal(t, strings.ToLower(tok), tok)
+	ht, u, issueID, err := ExtractToken(tok)
+	assert.NoError(t, err)
+	assert.Equal(t, ReplyHandlerType, ht)
+	assert.EqualValues(t, user.ID, u.ID)
+	assert.EqualValues(t, 1, issueID)
+
+	// the token is case insensitive
+	_, _, _, err = ExtractToken(strings.ToUpper(tok))
+	assert.NoError(t, err)
+
+	tok = CreateToken(UnsubscribeHandlerType, user, 1<<40)
+	ht, _, issueID, err = ExtractToken(tok)
+	assert.NoError(t, err)
+	assert.Equal(t, UnsubscribeHandlerType, ht)
+	assert.EqualValues(t, 1<<40, issueID)
+
+	// any change of the token or of the key invalidates it
+	forged := []byte(tok)
+	forged[5]++
+	_, _, _, err = ExtractToken(string(forged))
+	assert.Equal(t, ErrInvalidToken, err)
+	for _, invalid := range []string{"", "!!", "aaaa", tok[:len(tok)-2]} {
+		_, _, _, err = ExtractToken(invalid)
+		assert.Equal(t, ErrInvalidToken, err, invalid)
+	}
+	setting.SecretKey = "other"
+	_, _, _, err = ExtractToken(tok)
+	assert.Equal(t, ErrInvalidToken, err)
+}
+
+func TestAddress(t *testing.T) {
+	setting.IncomingEmail.ReplyToAddress = "incoming+%{token}@example.com"
+
+	assert.Equal(t, "incoming+abcd@example.com", Address("abcd"))
+	for address, expected := range map[string]string{
+		"incoming+abcd@example.com": "abcd",
+		"Incoming+ABCD@Example.com": "abcd",
+		"incoming+@example.com":     "",
+		"incoming@example.com":      "",
+		"other+abcd@example.com":    "",
+		"incoming+abcd@example.org": "",
+	} {
+		tok, ok := FromAddress(address)
+		assert.Equal(t, expected != "", ok, address)
+		assert.Equal(t, expected, tok, address)
+	}
+}
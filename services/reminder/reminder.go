@@ -0,0 +1,246 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// SendReminders sends the reminders of the due dates, of the pending review
+// requests and of the inactive pull requests following the rules of the repositories
+func SendReminders(ctx context.Context) {
+	now := time.Now()
+	if err := sendDueDateReminders(ctx, now); err != nil {
+		log.Error("sendDueDateReminders: %v", err)
+	}
+	if err := sendReviewRequestReminders(ctx, now); err != nil {
+		log.Error("sendReviewRequestReminders: %v", err)
+	}
+	if err := sendInactivePullReminders(ctx, now); err != nil {
+		log.Error("sendInactivePullReminders: %v", err)
+	}
+}
+
+func isCancelled(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		log.Warn("SendReminders: Cancelled before sending all the reminders")
+		return true
+	default:
+		return false
+	}
+}
+
+// sendDueDateReminders reminds the assignees of the open issues some days before
+// and on their due date
+func sendDueDateReminders(ctx context.Context, now time.Time) error {
+	rules, err := models.GetEnabledReminderRules(models.ReminderRuleDueDate)
+	if err != nil {
+		return fmt.Errorf("GetEnabledReminderRules: %v", err)
+	}
+	disabled, err := models.GetDisabledReminderRepoIDs(models.ReminderRuleDueDate)
+	if err != nil {
+		return fmt.Errorf("GetDisabledReminderRepoIDs: %v", err)
+	}
+
+	maxDays := setting.Cron.SendReminders.DueDateDays
+	repoDays := make(map[int64]int, len(rules))
+	for _, rule := range rules {
+		repoDays[rule.RepoID] = rule.Days
+		if rule.Days > maxDays {
+			maxDays = rule.Days
+		}
+	}
+
+	issues, err := models.GetOpenIssuesDueBetween(timeutil.TimeStamp(now.Unix()), timeutil.TimeStamp(now.AddDate(0, 0, maxDays+1).Unix()))
+	if err != nil {
+		return fmt.Errorf("GetOpenIssuesDueBetween: %v", err)
+	}
+	for _, issue := range issues {
+		if isCancelled(ctx) {
+			return nil
+		}
+		if disabled[issue.RepoID] {
+			continue
+		}
+		days, ok := repoDays[issue.RepoID]
+		if !ok {
+			days = setting.Cron.SendReminders.DueDateDays
+		}
+
+		var name, content string
+		deadline := issue.DeadlineUnix.AsTime()
+		switch left := daysUntil(now, deadline); {
+		case left == 0:
+			name = fmt.Sprintf("due-%d", issue.DeadlineUnix)
+			content = fmt.Sprintf("#%d is due today.", issue.Index)
+		case left <= days:
+			name = fmt.Sprintf("due-before-%d", issue.DeadlineUnix)
+			if left == 1 {
+				content = fmt.Sprintf("#%d is due tomorrow.", issue.Index)
+			} else {
+				content = fmt.Sprintf("#%d is due in %d days, on %s.", issue.Index, left, deadline.Format("2006-01-02"))
+			}
+		default:
+			continue
+		}
+
+		assignees, err := models.GetAssigneesByIssue(issue)
+		if err != nil {
+			return fmt.Errorf("GetAssigneesByIssue(%d): %v", issue.ID, err)
+		}
+		for _, assignee := range assignees {
+			if err = remind(issue, assignee, name, content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sendReviewRequestReminders reminds the reviewers of the review requests pending
+// for more business days than the rules of the repositories allow
+func sendReviewRequestReminders(ctx context.Context, now time.Time) error {
+	rules, err := models.GetEnabledReminderRules(models.ReminderRuleReviewRequest)
+	if err != nil {
+		return fmt.Errorf("GetEnabledReminderRules: %v", err)
+	}
+	for _, rule := range rules {
+		before := businessDaysBefore(now, rule.Days)
+		requests, err := models.GetPendingReviewRequests(rule.RepoID, timeutil.TimeStamp(before.Unix()))
+		if err != nil {
+			return fmt.Errorf("GetPendingReviewRequests(%d): %v", rule.RepoID, err)
+		}
+		for _, request := range requests {
+			if isCancelled(ctx) {
+				return nil
+			}
+			issue, err := models.GetIssueByID(request.IssueID)
+			if err != nil {
+				return fmt.Errorf("GetIssueByID(%d): %v", request.IssueID, err)
+			}
+
+			name := fmt.Sprintf("review-%d", request.ID)
+			if request.ReviewerID > 0 {
+				content := fmt.Sprintf("Your review of #%d has been pending for more than %d business days.", issue.Index, rule.Days)
+				if err = remind(issue, request.Reviewer, name, content); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// The teams assigning the reviews have a pending request for the picked member
+			team := request.ReviewerTeam
+			if team == nil || team.ReviewAssignment != models.TeamReviewAssignmentNone {
+				continue
+			}
+			members, err := models.GetTeamMembers(team.ID)
+			if err != nil {
+				return fmt.Errorf("GetTeamMembers(%d): %v", team.ID, err)
+			}
+			content := fmt.Sprintf("The review of #%d by the team %s has been pending for more than %d business days.", issue.Index, team.Name, rule.Days)
+			for _, member := range members {
+				if member.ID == issue.PosterID {
+					continue
+				}
+				if err = remind(issue, member, name, content); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sendInactivePullReminders reminds the authors of the pull requests without
+// activity for more days than the rules of the repositories allow
+func sendInactivePullReminders(ctx context.Context, now time.Time) error {
+	rules, err := models.GetEnabledReminderRules(models.ReminderRuleInactivePull)
+	if err != nil {
+		return fmt.Errorf("GetEnabledReminderRules: %v", err)
+	}
+	for _, rule := range rules {
+		issues, err := models.GetInactivePulls(rule.RepoID, timeutil.TimeStamp(now.AddDate(0, 0, -rule.Days).Unix()))
+		if err != nil {
+			return fmt.Errorf("GetInactivePulls(%d): %v", rule.RepoID, err)
+		}
+		for _, issue := range issues {
+			if isCancelled(ctx) {
+				return nil
+			}
+			if err = issue.LoadPoster(); err != nil {
+				return fmt.Errorf("LoadPoster(%d): %v", issue.ID, err)
+			}
+
+			// Any activity updates the pull request, and starts a new period of inactivity
+			name := fmt.Sprintf("inactive-%d", issue.UpdatedUnix)
+			content := fmt.Sprintf("#%d has had no activity for %d days.", issue.Index, rule.Days)
+			if err = remind(issue, issue.Poster, name, content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// remind sends the reminder to the user, unless they opted out of the reminders,
+// cannot read the issue anymore or already got it
+func remind(issue *models.Issue, u *models.User, name, content string) error {
+	if u == nil || u.ID <= 0 || u.DisableReminders || !u.IsActive || u.ProhibitLogin {
+		return nil
+	}
+	if err := issue.LoadRepo(); err != nil {
+		return fmt.Errorf("LoadRepo(%d): %v", issue.ID, err)
+	}
+	if issue.Repo.IsArchived {
+		return nil
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, u)
+	if err != nil {
+		return fmt.Errorf("GetUserRepoPermission(%d, %d): %v", issue.RepoID, u.ID, err)
+	}
+	if !perm.CanReadIssuesOrPulls(issue.IsPull) {
+		return nil
+	}
+
+	sent, err := models.MarkReminderSent(issue.ID, u.ID, name)
+	if err != nil {
+		return fmt.Errorf("MarkReminderSent(%d, %d): %v", issue.ID, u.ID, err)
+	} else if !sent {
+		return nil
+	}
+	notification.NotifyIssueReminder(models.NewGhostUser(), issue, u, content)
+	return nil
+}
+
+// businessDaysBefore returns the time the number of business days before t,
+// not counting the weekends
+func businessDaysBefore(t time.Time, days int) time.Time {
+	for days > 0 {
+		t = t.AddDate(0, 0, -1)
+		if wd := t.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			days--
+		}
+	}
+	return t
+}
+
+// daysUntil returns the number of calendar days from now to t
+func daysUntil(now, t time.Time) int {
+	y, m, d := now.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	y, m, d = t.In(now.Location()).Date()
+	to := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	return int(math.Round(to.Sub(from).Hours() / 24))
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBusinessDaysBefore(t *testing.T) {
+	// Tuesday
+	now := time.Date(2020, 6, 16, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, businessDaysBefore(now, 0))
+	assert.Equal(t, time.Date(2020, 6, 15, 10, 0, 0, 0, time.UTC), businessDaysBefore(now, 1))
+	assert.Equal(t, time.Date(2020, 6, 12, 10, 0, 0, 0, time.UTC), businessDaysBefore(now, 2))
+	assert.Equal(t, time.Date(2020, 6, 9, 10, 0, 0, 0, time.UTC), businessDaysBefore(now, 5))
+}
+
+func TestDaysUntil(t *testing.T) {
+	now := time.Date(2020, 6, 16, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, 0, daysUntil(now, time.Date(2020, 6, 16, 23, 59, 59, 0, time.UTC)))
+	assert.Equal(t, 1, daysUntil(now, time.Date(2020, 6, 17, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 3, daysUntil(now, time.Date(2020, 6, 19, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestSendDueDateReminders(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	// issue 6 is assigned to user1 and user2, due in two days
+	y, m, d := time.Now().Date()
+	now := time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 6}).(*models.Issue)
+	user1 := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	assert.NoError(t, models.UpdateIssueDeadline(issue, timeutil.TimeStamp(now.Add(49*time.Hour).Unix()), user1))
+	issue = models.AssertExistsAndLoadBean(t, &models.Issue{ID: 6}).(*models.Issue)
+
+	assert.NoError(t, sendDueDateReminders(context.Background(), now))
+	models.AssertNotExistsBean(t, &models.SentReminder{IssueID: 6})
+
+	assert.NoError(t, models.SetReminderRule(&models.ReminderRule{RepoID: 3, Type: models.ReminderRuleDueDate, Enabled: true, Days: 3}))
+	assert.NoError(t, user1.SetReminders(false))
+
+	assert.NoError(t, sendDueDateReminders(context.Background(), now))
+	assert.NoError(t, sendDueDateReminders(context.Background(), now))
+	name := fmt.Sprintf("due-before-%d", issue.DeadlineUnix)
+	models.AssertExistsAndLoadBean(t, &models.SentReminder{IssueID: 6, UserID: 2, Name: name})
+	models.AssertNotExistsBean(t, &models.SentReminder{IssueID: 6, UserID: 1})
+	models.AssertCount(t, &models.SentReminder{IssueID: 6}, 1)
+
+	// on the due date
+	assert.NoError(t, sendDueDateReminders(context.Background(), now.AddDate(0, 0, 2)))
+	models.AssertExistsAndLoadBean(t, &models.SentReminder{IssueID: 6, UserID: 2, Name: fmt.Sprintf("due-%d", issue.DeadlineUnix)})
+	models.AssertCount(t, &models.SentReminder{IssueID: 6}, 2)
+}
+
+func TestSendReviewRequestReminders(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 2}).(*models.Issue)
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	reviewer := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	_, err := models.AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	request := models.AssertExistsAndLoadBean(t, &models.ReviewRequest{IssueID: 2, ReviewerID: 2}).(*models.ReviewRequest)
+
+	assert.NoError(t, models.SetReminderRule(&models.ReminderRule{RepoID: 1, Type: models.ReminderRuleReviewRequest, Enabled: true, Days: 2}))
+
+	now := time.Now()
+	assert.NoError(t, sendReviewRequestReminders(context.Background(), now))
+	models.AssertNotExistsBean(t, &models.SentReminder{IssueID: 2})
+
+	assert.NoError(t, sendReviewRequestReminders(context.Background(), now.AddDate(0, 0, 5)))
+	models.AssertExistsAndLoadBean(t, &models.SentReminder{IssueID: 2, UserID: 2, Name: fmt.Sprintf("review-%d", request.ID)})
+}
+
+func TestSendInactivePullReminders(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	now := time.Now()
+	assert.NoError(t, sendInactivePullReminders(context.Background(), now))
+	models.AssertNotExistsBean(t, &models.SentReminder{UserID: 1})
+
+	assert.NoError(t, models.SetReminderRule(&models.ReminderRule{RepoID: 1, Type: models.ReminderRuleInactivePull, Enabled: true, Days: 14}))
+	assert.NoError(t, sendInactivePullReminders(context.Background(), now))
+	assert.NoError(t, sendInactivePullReminders(context.Background(), now))
+	for _, id := range []int64{2, 3, 11} {
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: id}).(*models.Issue)
+		models.AssertExistsAndLoadBean(t, &models.SentReminder{IssueID: id, UserID: 1, Name: fmt.Sprintf("inactive-%d", issue.UpdatedUnix)})
+	}
+	models.AssertCount(t, &models.SentReminder{UserID: 1}, 3)
+}
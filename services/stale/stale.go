@@ -0,0 +1,315 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package stale
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/stale"
+	"code.gitea.io/gitea/modules/timeutil"
+	comment_service "code.gitea.io/gitea/services/comments"
+	issue_service "code.gitea.io/gitea/services/issue"
+)
+
+// staleLabelColor is the color of the stale labels created by the automation
+const staleLabelColor = "#ededed"
+
+// Run follows the stale rules of the repositories: it removes the stale mark of the
+// items with activity, closes the stale items without activity for the grace period
+// and marks the inactive items as stale
+func Run(ctx context.Context) {
+	rules, err := models.GetEnabledStaleRules()
+	if err != nil {
+		log.Error("GetEnabledStaleRules: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		if isCancelled(ctx) {
+			return
+		}
+		if err = processRepo(ctx, rule, now); err != nil {
+			log.Error("Stale issues of repository %d: %v", rule.RepoID, err)
+		}
+	}
+}
+
+func isCancelled(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		log.Warn("Stale issues: Cancelled before processing all the repositories")
+		return true
+	default:
+		return false
+	}
+}
+
+// ReadConfigFile returns the content of the configuration file of the default
+// branch of the repository, nil if it has none
+func ReadConfigFile(repo *models.Repository) ([]byte, error) {
+	if repo.IsEmpty {
+		return nil, nil
+	}
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(repo.DefaultBranch)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entry, err := commit.GetTreeEntryByPath(stale.ConfigFile)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !entry.IsRegular() {
+		return nil, nil
+	}
+	reader, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	// Read one more byte than allowed so that parsing rejects the larger files
+	return ioutil.ReadAll(io.LimitReader(reader, stale.MaxConfigSize+1))
+}
+
+// LoadConfigs returns the configurations of the issues and of the pull requests of
+// the repository, nil for the ones the automation does not apply to. The configuration
+// file of the repository overrides its rule.
+func LoadConfigs(repo *models.Repository, rule *models.StaleRule) (issues, pulls *stale.Config, err error) {
+	base := rule.Config()
+	data, err := ReadConfigFile(repo)
+	if err != nil {
+		return nil, nil, err
+	} else if data != nil {
+		return stale.ParseConfig(data, base)
+	}
+	if err = base.Validate(); err != nil {
+		return nil, nil, err
+	}
+	issues, pulls = base.Split()
+	return issues, pulls, nil
+}
+
+func processRepo(ctx context.Context, rule *models.StaleRule, now time.Time) error {
+	repo, err := models.GetRepositoryByID(rule.RepoID)
+	if err != nil {
+		return fmt.Errorf("GetRepositoryByID: %v", err)
+	}
+	if repo.IsArchived {
+		return nil
+	}
+	issues, pulls, err := LoadConfigs(repo, rule)
+	if err != nil {
+		log.Warn("Invalid stale configuration of repository %s: %v", repo.FullName(), err)
+		return nil
+	}
+	if !repo.UnitEnabled(models.UnitTypeIssues) {
+		issues = nil
+	}
+	if !repo.UnitEnabled(models.UnitTypePullRequests) {
+		pulls = nil
+	}
+
+	if err = processStaleIssues(ctx, repo, issues, pulls, now); err != nil {
+		return err
+	}
+	if issues != nil {
+		if err = markStaleIssues(ctx, repo, issues, false, now); err != nil {
+			return err
+		}
+	}
+	if pulls != nil {
+		if err = markStaleIssues(ctx, repo, pulls, true, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// processStaleIssues removes the stale mark of the items with activity since they
+// were marked, and closes the ones without activity for the grace period
+func processStaleIssues(ctx context.Context, repo *models.Repository, issues, pulls *stale.Config, now time.Time) error {
+	stales, err := models.GetStaleIssues(repo.ID)
+	if err != nil {
+		return fmt.Errorf("GetStaleIssues: %v", err)
+	}
+	for _, s := range stales {
+		if isCancelled(ctx) {
+			return nil
+		}
+		issue, err := models.GetIssueByID(s.IssueID)
+		if err != nil {
+			if models.IsErrIssueNotExist(err) {
+				if err = models.UnmarkIssueStale(s.IssueID); err != nil {
+					return fmt.Errorf("UnmarkIssueStale(%d): %v", s.IssueID, err)
+				}
+				continue
+			}
+			return fmt.Errorf("GetIssueByID(%d): %v", s.IssueID, err)
+		}
+		issue.Repo = repo
+
+		c := issues
+		if issue.IsPull {
+			c = pulls
+		}
+		switch {
+		case issue.IsClosed || c == nil:
+			err = models.UnmarkIssueStale(issue.ID)
+		case issue.UpdatedUnix > s.MarkedUnix:
+			err = unmarkIssue(issue, c)
+		case c.DaysUntilClose > 0 && !now.Before(s.MarkedUnix.AsTime().AddDate(0, 0, c.DaysUntilClose)):
+			err = closeIssue(issue, c)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarkIssue removes the stale label and the stale mark of the issue
+func unmarkIssue(issue *models.Issue, c *stale.Config) error {
+	label, err := models.GetLabelInRepoByName(issue.RepoID, c.StaleLabel)
+	if err != nil && !models.IsErrLabelNotExist(err) {
+		return fmt.Errorf("GetLabelInRepoByName: %v", err)
+	}
+	if label != nil && models.HasIssueLabel(issue.ID, label.ID) {
+		// Not issue_service.RemoveLabel: the system user has no permission on the repository
+		doer := models.NewGhostUser()
+		if err = models.DeleteIssueLabel(issue, label, doer); err != nil {
+			return fmt.Errorf("DeleteIssueLabel(%d): %v", issue.ID, err)
+		}
+		notification.NotifyIssueChangeLabels(doer, issue, nil, []*models.Label{label})
+	}
+	if err = models.UnmarkIssueStale(issue.ID); err != nil {
+		return fmt.Errorf("UnmarkIssueStale(%d): %v", issue.ID, err)
+	}
+	return nil
+}
+
+// closeIssue closes the stale issue and posts the close comment
+func closeIssue(issue *models.Issue, c *stale.Config) error {
+	doer := models.NewGhostUser()
+	if err := issue_service.ChangeStatus(issue, doer, true); err != nil {
+		if models.IsErrDependenciesLeft(err) {
+			// Stays stale until its dependencies are closed
+			log.Trace("Stale issue %d has open dependencies", issue.ID)
+			return nil
+		}
+		return fmt.Errorf("ChangeStatus(%d): %v", issue.ID, err)
+	}
+	if c.CloseComment != "" {
+		if _, err := comment_service.CreateIssueComment(doer, issue.Repo, issue, c.CloseComment, nil); err != nil {
+			return fmt.Errorf("CreateIssueComment(%d): %v", issue.ID, err)
+		}
+	}
+	if err := models.UnmarkIssueStale(issue.ID); err != nil {
+		return fmt.Errorf("UnmarkIssueStale(%d): %v", issue.ID, err)
+	}
+	return nil
+}
+
+// markStaleIssues marks the inactive issues or pull requests of the repository as stale
+func markStaleIssues(ctx context.Context, repo *models.Repository, c *stale.Config, isPull bool, now time.Time) error {
+	opts := &models.FindStaleCandidatesOptions{
+		RepoID:           repo.ID,
+		IsPull:           isPull,
+		UpdatedBefore:    timeutil.TimeStamp(now.AddDate(0, 0, -c.DaysUntilStale).Unix()),
+		ExemptMilestones: c.ExemptMilestones,
+		ExemptAssignees:  c.ExemptAssignees,
+		Limit:            c.LimitPerRun,
+	}
+	var err error
+	if len(c.OnlyLabels) > 0 {
+		if opts.OnlyLabelIDs, err = models.GetLabelIDsInRepoByNames(repo.ID, c.OnlyLabels); err != nil {
+			return fmt.Errorf("GetLabelIDsInRepoByNames: %v", err)
+		} else if len(opts.OnlyLabelIDs) == 0 {
+			return nil
+		}
+	}
+	if len(c.ExemptLabels) > 0 {
+		if opts.ExemptLabelIDs, err = models.GetLabelIDsInRepoByNames(repo.ID, c.ExemptLabels); err != nil {
+			return fmt.Errorf("GetLabelIDsInRepoByNames: %v", err)
+		}
+	}
+
+	candidates, err := models.FindStaleCandidates(opts)
+	if err != nil {
+		return fmt.Errorf("FindStaleCandidates: %v", err)
+	} else if len(candidates) == 0 {
+		return nil
+	}
+	label, err := getOrCreateStaleLabel(repo, c.StaleLabel)
+	if err != nil {
+		return err
+	}
+
+	doer := models.NewGhostUser()
+	for _, issue := range candidates {
+		if isCancelled(ctx) {
+			return nil
+		}
+		issue.Repo = repo
+		if c.MarkComment != "" {
+			if _, err = comment_service.CreateIssueComment(doer, repo, issue, c.MarkComment, nil); err != nil {
+				return fmt.Errorf("CreateIssueComment(%d): %v", issue.ID, err)
+			}
+		}
+		if err = issue_service.AddLabel(issue, doer, label); err != nil {
+			return fmt.Errorf("AddLabel(%d): %v", issue.ID, err)
+		}
+
+		// The mark is the update of the issue by the comment and the label, the
+		// activity is any later update
+		marked, err := models.GetIssueByID(issue.ID)
+		if err != nil {
+			return fmt.Errorf("GetIssueByID(%d): %v", issue.ID, err)
+		}
+		if err = models.MarkIssueStale(marked); err != nil {
+			return fmt.Errorf("MarkIssueStale(%d): %v", issue.ID, err)
+		}
+	}
+	return nil
+}
+
+// getOrCreateStaleLabel returns the stale label of the repository, creating it if needed
+func getOrCreateStaleLabel(repo *models.Repository, name string) (*models.Label, error) {
+	label, err := models.GetLabelInRepoByName(repo.ID, name)
+	if err == nil {
+		return label, nil
+	} else if !models.IsErrLabelNotExist(err) {
+		return nil, fmt.Errorf("GetLabelInRepoByName: %v", err)
+	}
+
+	label = &models.Label{
+		RepoID:      repo.ID,
+		Name:        name,
+		Color:       staleLabelColor,
+		Description: "No recent activity",
+	}
+	if err = models.NewLabel(label); err != nil {
+		return nil, fmt.Errorf("NewLabel: %v", err)
+	}
+	return label, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package stale
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/stale"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessRepo(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	rule, err := models.GetStaleRule(1)
+	assert.NoError(t, err)
+	rule.Enabled = true
+	rule.CloseComment = "Closed for inactivity."
+	rule.LimitPerRun = 2
+	assert.NoError(t, models.SetStaleRule(rule))
+
+	// issue 1 and the least recently updated pull requests 3 and 2 are marked
+	now := time.Now()
+	assert.NoError(t, processRepo(context.Background(), rule, now))
+	label := models.AssertExistsAndLoadBean(t, &models.Label{RepoID: 1, Name: "stale"}).(*models.Label)
+	for _, id := range []int64{1, 2, 3} {
+		models.AssertExistsAndLoadBean(t, &models.StaleIssue{RepoID: 1, IssueID: id})
+		models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: id, LabelID: label.ID})
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: id, PosterID: -1, Type: models.CommentTypeComment, Content: rule.MarkComment})
+	}
+	models.AssertNotExistsBean(t, &models.StaleIssue{IssueID: 11})
+
+	// pull request 3 had activity after it was marked
+	pull := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	assert.NoError(t, models.UnmarkIssueStale(3))
+	pull.UpdatedUnix--
+	assert.NoError(t, models.MarkIssueStale(pull))
+
+	// the grace period is over for the others
+	assert.NoError(t, processRepo(context.Background(), rule, now.AddDate(0, 0, 8)))
+	models.AssertNotExistsBean(t, &models.StaleIssue{IssueID: 3})
+	models.AssertNotExistsBean(t, &models.IssueLabel{IssueID: 3, LabelID: label.ID})
+	assert.False(t, models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue).IsClosed)
+	for _, id := range []int64{1, 2} {
+		models.AssertNotExistsBean(t, &models.StaleIssue{IssueID: id})
+		assert.True(t, models.AssertExistsAndLoadBean(t, &models.Issue{ID: id}).(*models.Issue).IsClosed)
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: id, PosterID: -1, Type: models.CommentTypeClose})
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: id, PosterID: -1, Type: models.CommentTypeComment, Content: rule.CloseComment})
+	}
+	models.AssertExistsAndLoadBean(t, &models.StaleIssue{IssueID: 11})
+}
+
+func TestProcessRepoExemptions(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	rule, err := models.GetStaleRule(1)
+	assert.NoError(t, err)
+	rule.Enabled = true
+	rule.Only = "pulls"
+	rule.ExemptLabels = "label1"
+	rule.MarkComment = ""
+	assert.NoError(t, models.SetStaleRule(rule))
+
+	assert.NoError(t, processRepo(context.Background(), rule, time.Now()))
+	models.AssertNotExistsBean(t, &models.StaleIssue{IssueID: 1})
+	models.AssertNotExistsBean(t, &models.StaleIssue{IssueID: 2})
+	models.AssertExistsAndLoadBean(t, &models.StaleIssue{IssueID: 3})
+	models.AssertNotExistsBean(t, &models.Comment{IssueID: 3, PosterID: -1, Content: stale.DefaultConfig().MarkComment})
+}
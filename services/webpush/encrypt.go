@@ -0,0 +1,113 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// recordSize is the size of the single record of the encrypted payload
+	recordSize = 4096
+	// MaxPayloadSize is the maximum size of a payload fitting in a record,
+	// with its padding delimiter and the authentication tag
+	MaxPayloadSize = recordSize - 1 - 16
+)
+
+// decodeKey decodes a key of a subscription, encoded in URL-safe base64 by the
+// browsers but possibly padded
+func decodeKey(key string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+}
+
+// hkdfExpand derives a key of the size from the secret, as defined by RFC 5869
+func hkdfExpand(secret, salt, info []byte, size int) ([]byte, error) {
+	key := make([]byte, size)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), key)
+	return key, err
+}
+
+// encrypt encrypts the payload for the browser of the subscription with the
+// aes128gcm content encoding, as defined by RFC 8291
+func encrypt(sub *models.WebPushSubscription, payload []byte) ([]byte, error) {
+	if len(payload) > MaxPayloadSize {
+		return nil, fmt.Errorf("payload of %d bytes is too large", len(payload))
+	}
+
+	curve := elliptic.P256()
+	uaPublic, err := decodeKey(sub.P256DH)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %v", err)
+	}
+	uaX, uaY := elliptic.Unmarshal(curve, uaPublic)
+	if uaX == nil {
+		return nil, errors.New("invalid p256dh key: not a P-256 point")
+	}
+	authSecret, err := decodeKey(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %v", err)
+	}
+
+	// Each message uses its own key pair for the key agreement
+	asPrivate, asX, asY, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := elliptic.Marshal(curve, asX, asY)
+	sharedX, _ := curve.ScalarMult(uaX, uaY, asPrivate)
+	ecdhSecret := make([]byte, 32)
+	sharedBytes := sharedX.Bytes()
+	copy(ecdhSecret[32-len(sharedBytes):], sharedBytes)
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdfExpand(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	cek, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// The header is followed by a single record ending with its padding delimiter
+	body := make([]byte, 16+4+1, 16+4+1+len(asPublic)+len(payload)+1+gcm.Overhead())
+	copy(body, salt)
+	binary.BigEndian.PutUint32(body[16:], recordSize)
+	body[20] = byte(len(asPublic))
+	body = append(body, asPublic...)
+	return gcm.Seal(body, nonce, append(payload, 2), nil), nil
+}
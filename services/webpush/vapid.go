@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webpush
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// vapidAuthorization returns the Authorization header identifying the
+// instance to the push service of the endpoint, as defined by RFC 8292
+func vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	d, err := decodeKey(setting.WebPush.VAPIDPrivateKey)
+	if err != nil {
+		return "", err
+	}
+	key := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(d)}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(d)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": setting.WebPush.Subject,
+	}).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+	return "vapid t=" + token + ", k=" + setting.WebPush.VAPIDPublicKey, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webpush
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Message is the payload of a push notification, as shown by the service worker
+type Message struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	// Tag groups the notifications, a new one replacing the previous one with the same tag
+	Tag string `json:"tag,omitempty"`
+}
+
+type pushTask struct {
+	UserID  int64
+	Message *Message
+}
+
+// errSubscriptionGone is returned when the push service doesn't know the
+// subscription anymore, e.g. because the user revoked the permission
+var errSubscriptionGone = errors.New("push subscription is gone")
+
+var (
+	pushQueue queue.Queue
+	client    = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+	}
+)
+
+// Init starts the queue of the push notifications if they are enabled
+func Init() {
+	if !setting.WebPush.Enabled || pushQueue != nil {
+		return
+	}
+
+	pushQueue = queue.CreateQueue("webpush", handle, &pushTask{})
+	if pushQueue == nil {
+		log.Fatal("Unable to create webpush Queue")
+	}
+	go graceful.GetManager().RunWithShutdownFns(pushQueue.Run)
+}
+
+// Send queues the message to the subscribed browsers of the user
+func Send(userID int64, msg *Message) {
+	if pushQueue == nil {
+		return
+	}
+	if err := pushQueue.Push(&pushTask{UserID: userID, Message: msg}); err != nil {
+		log.Error("Unable to push message for user %d to the webpush queue: %v", userID, err)
+	}
+}
+
+// IsAllowedEndpoint returns true if the endpoint of a subscription is an
+// https:// URL of an allowed push service
+func IsAllowedEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, pattern := range setting.WebPush.AllowedHosts {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		task := datum.(*pushTask)
+		if err := sendToUser(task.UserID, task.Message); err != nil {
+			log.Error("sendToUser(%d): %v", task.UserID, err)
+		}
+	}
+}
+
+func sendToUser(userID int64, msg *Message) error {
+	subs, err := models.GetWebPushSubscriptions(userID)
+	if err != nil {
+		return fmt.Errorf("GetWebPushSubscriptions: %v", err)
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		err := sendToSubscription(sub, payload)
+		if err == errSubscriptionGone {
+			log.Trace("Deleting the gone push subscription %d of user %d", sub.ID, userID)
+			err = models.DeleteWebPushSubscriptionByID(sub.ID)
+		}
+		if err != nil {
+			log.Error("Unable to send a push notification to subscription %d: %v", sub.ID, err)
+		}
+	}
+	return nil
+}
+
+func sendToSubscription(sub *models.WebPushSubscription, payload []byte) error {
+	// the allowed push services may have changed since the browser subscribed
+	if !IsAllowedEndpoint(sub.Endpoint) {
+		return errSubscriptionGone
+	}
+
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return fmt.Errorf("encrypt: %v", err)
+	}
+	authorization, err := vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("vapidAuthorization: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("TTL", strconv.Itoa(int(setting.WebPush.TTL/time.Second)))
+	req.Header.Set("Urgency", "normal")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	case resp.StatusCode >= 300:
+		msg, _ := ioutil.ReadAll(&io.LimitedReader{R: resp.Body, N: 1024})
+		return fmt.Errorf("push service responded %s: %s", resp.Status, msg)
+	}
+	return nil
+}
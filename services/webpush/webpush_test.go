@@ -0,0 +1,178 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// browser is the user agent side of a subscription
+type browser struct {
+	private []byte
+	public  []byte
+	auth    []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	private, x, y, err := elliptic.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	b := &browser{private: private, public: elliptic.Marshal(elliptic.P256(), x, y), auth: make([]byte, 16)}
+	_, err = rand.Read(b.auth)
+	assert.NoError(t, err)
+	return b
+}
+
+func (b *browser) subscription(endpoint string) *models.WebPushSubscription {
+	return &models.WebPushSubscription{
+		UserID:   2,
+		Endpoint: endpoint,
+		P256DH:   base64.RawURLEncoding.EncodeToString(b.public),
+		// some browsers pad the keys
+		Auth: base64.URLEncoding.EncodeToString(b.auth),
+	}
+}
+
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	if !assert.True(t, len(body) > 21) {
+		return nil
+	}
+	salt := body[:16]
+	assert.EqualValues(t, recordSize, binary.BigEndian.Uint32(body[16:20]))
+	keyLen := int(body[20])
+	asPublic := body[21 : 21+keyLen]
+
+	curve := elliptic.P256()
+	x, y := elliptic.Unmarshal(curve, asPublic)
+	sharedX, _ := curve.ScalarMult(x, y, b.private)
+	ecdhSecret := make([]byte, 32)
+	copy(ecdhSecret[32-len(sharedX.Bytes()):], sharedX.Bytes())
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), b.public...), asPublic...)
+	ikm, err := hkdfExpand(ecdhSecret, b.auth, keyInfo, 32)
+	assert.NoError(t, err)
+	cek, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	assert.NoError(t, err)
+	nonce, err := hkdfExpand(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	assert.NoError(t, err)
+
+	block, err := aes.NewCipher(cek)
+	assert.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	assert.NoError(t, err)
+	plain, err := gcm.Open(nil, nonce, body[21+keyLen:], nil)
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, plain) {
+		return nil
+	}
+	assert.EqualValues(t, 2, plain[len(plain)-1], "missing padding delimiter")
+	return plain[:len(plain)-1]
+}
+
+func TestEncrypt(t *testing.T) {
+	b := newBrowser(t)
+	payload := []byte(`{"title":"[user2/repo1] issue1 (#1)"}`)
+
+	body, err := encrypt(b.subscription("https://push.example.com/1"), payload)
+	assert.NoError(t, err)
+	assert.Equal(t, payload, b.decrypt(t, body))
+
+	_, err = encrypt(b.subscription("https://push.example.com/1"), make([]byte, MaxPayloadSize+1))
+	assert.Error(t, err)
+
+	sub := b.subscription("https://push.example.com/1")
+	sub.P256DH = "invalid"
+	_, err = encrypt(sub, payload)
+	assert.Error(t, err)
+}
+
+func TestIsAllowedEndpoint(t *testing.T) {
+	assert.True(t, IsAllowedEndpoint("https://fcm.googleapis.com/fcm/send/abc"))
+	assert.True(t, IsAllowedEndpoint("https://updates.push.services.mozilla.com/wpush/v2/abc"))
+	assert.True(t, IsAllowedEndpoint("https://WNS2-BY3P.notify.windows.com/w/?token=abc"))
+	assert.False(t, IsAllowedEndpoint("http://fcm.googleapis.com/fcm/send/abc"))
+	assert.False(t, IsAllowedEndpoint("https://user@fcm.googleapis.com/fcm/send/abc"))
+	assert.False(t, IsAllowedEndpoint("https://fcm.googleapis.com.example.com/abc"))
+	assert.False(t, IsAllowedEndpoint("https://127.0.0.1/admin"))
+	assert.False(t, IsAllowedEndpoint("https://localhost:3000/api"))
+}
+
+func setVAPIDKeys(t *testing.T) {
+	var err error
+	setting.WebPush.VAPIDPublicKey, setting.WebPush.VAPIDPrivateKey, err = generate.NewVAPIDKeys()
+	assert.NoError(t, err)
+	setting.WebPush.Subject = "mailto:gitea@example.com"
+}
+
+func TestSendToUser(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	setVAPIDKeys(t)
+
+	b := newBrowser(t)
+	var received []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+
+		assert.Equal(t, "aes128gcm", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "86400", r.Header.Get("TTL"))
+
+		authorization := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(authorization, "vapid t="))
+		fields := strings.SplitN(strings.TrimPrefix(authorization, "vapid t="), ", k=", 2)
+		if assert.Len(t, fields, 2) {
+			assert.Equal(t, setting.WebPush.VAPIDPublicKey, fields[1])
+			claims := jwt.MapClaims{}
+			_, _, err := new(jwt.Parser).ParseUnverified(fields[0], claims)
+			assert.NoError(t, err)
+			assert.Equal(t, "https://"+r.Host, claims["aud"])
+			assert.Equal(t, setting.WebPush.Subject, claims["sub"])
+		}
+
+		var err error
+		received, err = ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+	defer func(c *http.Client, hosts []string) {
+		client = c
+		setting.WebPush.AllowedHosts = hosts
+	}(client, setting.WebPush.AllowedHosts)
+	client = server.Client()
+	setting.WebPush.AllowedHosts = []string{"127.0.0.1"}
+
+	assert.NoError(t, models.CreateWebPushSubscription(b.subscription(server.URL+"/valid")))
+	assert.NoError(t, models.CreateWebPushSubscription(b.subscription(server.URL+"/gone")))
+	// subscriptions to push services not allowed anymore are deleted too
+	assert.NoError(t, models.CreateWebPushSubscription(b.subscription("https://push.example.com/1")))
+
+	assert.NoError(t, sendToUser(2, &Message{Title: "title", Body: "body", URL: "http://localhost:3000/"}))
+	assert.JSONEq(t, `{"title":"title","body":"body","url":"http://localhost:3000/"}`, string(b.decrypt(t, received)))
+
+	// the gone subscription is deleted
+	subs, err := models.GetWebPushSubscriptions(2)
+	assert.NoError(t, err)
+	if assert.Len(t, subs, 1) {
+		assert.Equal(t, server.URL+"/valid", subs[0].Endpoint)
+	}
+}
@@ -0,0 +1,124 @@
+const { AppSubUrl, NotificationEvents, WebPushPublicKey } = window.config;
+
+function updateNotificationCount(count) {
+  const $label = $('.notification_count');
+  $label.text(count);
+  if (count > 0) {
+    $label.show();
+  } else {
+    $label.hide();
+  }
+}
+
+function initNotificationEvents() {
+  if (!NotificationEvents || typeof EventSource === 'undefined') return;
+
+  const $banner = $('#issue-update-banner');
+  const issueID = $banner.data('issue-id');
+  const url = issueID ? `${AppSubUrl}/user/events?issue=${issueID}` : `${AppSubUrl}/user/events`;
+
+  // The browser reconnects by itself, e.g. while the server restarts
+  const source = new EventSource(url);
+  source.addEventListener('notification-count', (e) => {
+    updateNotificationCount(JSON.parse(e.data).count);
+  });
+  if (!issueID) return;
+
+  source.addEventListener('issue-update', () => {
+    $banner.show();
+  });
+  source.addEventListener('stopwatches', (e) => {
+    const running = JSON.parse(e.data).some((sw) => sw.issue_id === issueID);
+    if (running !== $banner.data('stopwatch-running')) {
+      $banner.show();
+    }
+  });
+}
+
+function initNotificationSelection() {
+  const $checkboxes = $('.notification-select');
+  if (!$checkboxes.length) return;
+
+  // The rows open their notification when clicked
+  $checkboxes.closest('td').on('click', (e) => e.stopPropagation());
+
+  const $all = $('#notification-select-all');
+  const $actions = $('#notification-bulk-form .button');
+  const update = () => {
+    const checked = $checkboxes.filter(':checked').length;
+    $all.prop('checked', checked === $checkboxes.length);
+    $actions.toggleClass('disabled', checked === 0);
+  };
+  $all.on('change', () => {
+    $checkboxes.prop('checked', $all.prop('checked'));
+    update();
+  });
+  $checkboxes.on('change', update);
+  update();
+}
+
+function urlBase64ToUint8Array(base64) {
+  const raw = window.atob((base64 + '='.repeat((4 - base64.length % 4) % 4)).replace(/-/g, '+').replace(/_/g, '/'));
+  return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+}
+
+function postSubscription(action, subscription) {
+  const { endpoint, keys } = subscription.toJSON();
+  return $.post(`${AppSubUrl}/notifications/webpush/${action}`, {
+    _csrf: $('meta[name=_csrf]').attr('content'),
+    endpoint,
+    p256dh: keys.p256dh,
+    auth: keys.auth,
+  });
+}
+
+async function initWebPush() {
+  const $subscribe = $('#webpush-subscribe');
+  const $unsubscribe = $('#webpush-unsubscribe');
+  if (!WebPushPublicKey || !$subscribe.length || !('serviceWorker' in navigator) || !('PushManager' in window)) return;
+
+  const registration = await navigator.serviceWorker.register(`${AppSubUrl}/notifications/serviceworker.js`, {
+    scope: `${AppSubUrl}/notifications/`
+  });
+  const subscription = await registration.pushManager.getSubscription();
+  if (subscription) {
+    // Keeps the server in sync in case it lost the subscription
+    await postSubscription('subscribe', subscription);
+    $unsubscribe.show();
+  } else if (Notification.permission !== 'denied') {
+    $subscribe.show();
+  }
+
+  $subscribe.on('click', async () => {
+    try {
+      const sub = await registration.pushManager.subscribe({
+        userVisibleOnly: true,
+        applicationServerKey: urlBase64ToUint8Array(WebPushPublicKey),
+      });
+      await postSubscription('subscribe', sub);
+      $subscribe.hide();
+      $unsubscribe.show();
+    } catch (err) {
+      console.error('Unable to subscribe to the push notifications', err);
+    }
+  });
+  $unsubscribe.on('click', async () => {
+    const sub = await registration.pushManager.getSubscription();
+    if (sub) {
+      await postSubscription('unsubscribe', sub);
+      await sub.unsubscribe();
+    }
+    $unsubscribe.hide();
+    $subscribe.show();
+  });
+}
+
+export default async function initNotification() {
+  initNotificationEvents();
+  initNotificationSelection();
+  try {
+    await initWebPush();
+  } catch (err) {
+    console.error('Unable to initialize the push notifications', err);
+  }
+}
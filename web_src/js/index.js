@@ -12,6 +12,7 @@ import initContextPopups from './features/contextPopup.js';
 import initHighlight from './features/highlight.js';
 import initGitGraph from './features/gitGraph.js';
 import initClipboard from './features/clipboard.js';
+import initNotification from './features/notification.js';
 
 import ActivityTopAuthors from './components/ActivityTopAuthors.vue';
 
@@ -469,6 +470,7 @@ function initCommentForm() {
   initListSubmits('select-label', 'labels');
   initListSubmits('select-assignees', 'assignees');
   initListSubmits('select-assignees-modify', 'assignees');
+  initListSubmits('select-reviewers', 'reviewers');
 
   function selectItem(select_id, input_id) {
     const $menu = $(`${select_id} .menu`);
@@ -2534,6 +2536,14 @@ $(document).ready(async () => {
     });
   });
 
+  $('#bulk-edit-issues form').on('submit', function () {
+    const issueIDs = $('.issue-checkbox').children('input:checked').map(function () {
+      return this.dataset.issueId;
+    }).get().join();
+    $(this).find('input[name=issue_ids]').val(issueIDs);
+    $(this).find('input[name=redirect_to]').val(window.location.pathname + window.location.search);
+  });
+
   // NOTICE: This event trigger targets Firefox caching behaviour, as the checkboxes stay checked after reload
   // trigger ckecked event, if checkboxes are checked on load
   $('.issue-checkbox input[type="checkbox"]:checked').first().each((_, e) => {
@@ -2612,6 +2622,7 @@ $(document).ready(async () => {
     initHighlight(),
     initGitGraph(),
     initClipboard(),
+    initNotification(),
   ]);
 });
 